@@ -32,6 +32,9 @@ const (
 	// DefaultInitTimeout is the default value for MainConfig.InitTimeout if not specified.
 	DefaultInitTimeout = time.Second * 10
 
+	// DefaultReadHeaderTimeout is the default value for MainConfig.ReadHeaderTimeout if not specified.
+	DefaultReadHeaderTimeout = time.Second * 10
+
 	// DefaultEventCapacity is the default value for EventsConfig.Capacity if not specified.
 	DefaultEventCapacity = 1000
 
@@ -53,6 +56,67 @@ const (
 	// DefaultBigSegmentsStaleThreshold is the default value for MainConfig.BigSegmentsStaleThreshold if not specified.
 	DefaultBigSegmentsStaleThreshold = time.Minute * 5
 
+	// DefaultStoreWriteAttempts is the default value for MainConfig.StoreWriteAttempts if not specified.
+	DefaultStoreWriteAttempts = 3
+
+	// DefaultStoreWriteRetryDelay is the default value for MainConfig.StoreWriteRetryDelay if not specified.
+	DefaultStoreWriteRetryDelay = time.Millisecond * 100
+
+	// DefaultStoreBreakerOpenTime is the default value for MainConfig.StoreBreakerOpenTime if not specified.
+	DefaultStoreBreakerOpenTime = time.Second * 10
+
+	// DefaultMobileLongPollTimeout is the default value for MainConfig.MobileLongPollTimeout if not specified.
+	DefaultMobileLongPollTimeout = time.Second * 30
+
+	// DefaultMaxReportBodySize is the default value for MainConfig.MaxReportBodySize if not specified.
+	DefaultMaxReportBodySize = 1024 * 1024
+
+	// DefaultMaxImageDataSize is the default value for EventsConfig.MaxImageDataSize if not specified.
+	DefaultMaxImageDataSize = 64 * 1024
+
+	// DefaultMaxDecompressedEventsSize is the default value for EventsConfig.MaxDecompressedSize if not specified.
+	DefaultMaxDecompressedEventsSize = 10 * 1024 * 1024
+
+	// DefaultPurgeAge is the default value for RedisConfig.PurgeAge or DynamoDBConfig.PurgeAge if not specified.
+	DefaultPurgeAge = time.Hour * 24
+
+	// DataStoreCompressionNone is a value for RedisConfig.Compression or DynamoDBConfig.Compression meaning
+	// that data is stored without compression. This is the default.
+	DataStoreCompressionNone = "none"
+
+	// DataStoreCompressionGzip is a value for RedisConfig.Compression or DynamoDBConfig.Compression meaning
+	// that data is compressed with gzip before it is stored.
+	DataStoreCompressionGzip = "gzip"
+
+	// EventsCompressionNone is a value for EventsConfig.Compression meaning that events are sent to
+	// LaunchDarkly without compression. This is the default.
+	EventsCompressionNone = "none"
+
+	// EventsCompressionGzip is a value for EventsConfig.Compression meaning that the body of each event
+	// payload sent to LaunchDarkly is compressed with gzip.
+	EventsCompressionGzip = "gzip"
+
+	// EventsUnknownFlagsForward is a value for EventsConfig.UnknownFlags meaning that analytics events are
+	// forwarded without checking whether the flags they refer to exist. This is the default.
+	EventsUnknownFlagsForward = "forward"
+
+	// EventsUnknownFlagsCount is a value for EventsConfig.UnknownFlags meaning that analytics events that
+	// refer to flags that do not exist in the environment are counted in the unknown_flag_events metric,
+	// but are still forwarded.
+	EventsUnknownFlagsCount = "count"
+
+	// EventsUnknownFlagsDrop is a value for EventsConfig.UnknownFlags meaning that analytics events that
+	// refer to flags that do not exist in the environment are counted, and are not forwarded.
+	EventsUnknownFlagsDrop = "drop"
+
+	// StatusKeyDisplayMasked is a value for MainConfig.StatusKeyDisplay meaning that SDK keys and mobile keys
+	// are shown in the status resource with most of their characters replaced by asterisks. This is the default.
+	StatusKeyDisplayMasked = "masked"
+
+	// StatusKeyDisplayHidden is a value for MainConfig.StatusKeyDisplay meaning that SDK keys and mobile keys
+	// are omitted from the status resource.
+	StatusKeyDisplayHidden = "hidden"
+
 	// AutoConfigEnvironmentIDPlaceholder is a string that can appear within
 	// AutoConfigConfig.EnvDataStorePrefix or AutoConfigConfig.EnvDataStoreTableName to indicate that
 	// the environment ID should be substituted at that point.
@@ -129,6 +193,7 @@ type Config struct {
 type MainConfig struct {
 	ExitOnError                bool                     `conf:"EXIT_ON_ERROR"`
 	ExitAlways                 bool                     `conf:"EXIT_ALWAYS"`
+	RequireAllEnvironments     bool                     `conf:"REQUIRE_ALL_ENVIRONMENTS"`
 	IgnoreConnectionErrors     bool                     `conf:"IGNORE_CONNECTION_ERRORS"`
 	StreamURI                  ct.OptURLAbsolute        `conf:"STREAM_URI"`
 	BaseURI                    ct.OptURLAbsolute        `conf:"BASE_URI"`
@@ -137,7 +202,23 @@ type MainConfig struct {
 	InitTimeout                ct.OptDuration           `conf:"INIT_TIMEOUT"`
 	HeartbeatInterval          ct.OptDuration           `conf:"HEARTBEAT_INTERVAL"`
 	MaxClientConnectionTime    ct.OptDuration           `conf:"MAX_CLIENT_CONNECTION_TIME"`
+	MaxClientConnectionJitter  ct.OptDuration           `conf:"MAX_CLIENT_CONNECTION_JITTER"`
+	StreamFlagValues           bool                     `conf:"STREAM_FLAG_VALUES"`
+	MobilePollFallback         bool                     `conf:"MOBILE_POLL_FALLBACK"`
+	MobileLongPollTimeout      ct.OptDuration           `conf:"MOBILE_LONG_POLL_TIMEOUT"`
+	MaxSSEMessageSize          ct.OptIntGreaterThanZero `conf:"MAX_SSE_MESSAGE_SIZE"`
+	MaxTotalConnections        ct.OptIntGreaterThanZero `conf:"MAX_TOTAL_CONNECTIONS"`
+	MaxConnectionWait          ct.OptDuration           `conf:"MAX_CONNECTION_WAIT"`
+	LogConnections             bool                     `conf:"LOG_CONNECTIONS"`
 	DisconnectedStatusTime     ct.OptDuration           `conf:"DISCONNECTED_STATUS_TIME"`
+	ConfigSourceStaleTime      ct.OptDuration           `conf:"CONFIG_SOURCE_STALE_TIME"`
+	ReportCachedStatus         bool                     `conf:"REPORT_CACHED_STATUS"`
+	StatusKeyDisplay           string                   `conf:"STATUS_KEY_DISPLAY"`
+	ReadTimeout                ct.OptDuration           `conf:"READ_TIMEOUT"`
+	ReadHeaderTimeout          ct.OptDuration           `conf:"READ_HEADER_TIMEOUT"`
+	WriteTimeout               ct.OptDuration           `conf:"WRITE_TIMEOUT"`
+	IdleTimeout                ct.OptDuration           `conf:"IDLE_TIMEOUT"`
+	MaxHeaderBytes             ct.OptIntGreaterThanZero `conf:"MAX_HEADER_BYTES"`
 	TLSEnabled                 bool                     `conf:"TLS_ENABLED"`
 	TLSCert                    string                   `conf:"TLS_CERT"`
 	TLSKey                     string                   `conf:"TLS_KEY"`
@@ -145,6 +226,41 @@ type MainConfig struct {
 	LogLevel                   OptLogLevel              `conf:"LOG_LEVEL"`
 	BigSegmentsStaleAsDegraded bool                     `conf:"BIG_SEGMENTS_STALE_AS_DEGRADED"`
 	BigSegmentsStaleThreshold  ct.OptDuration           `conf:"BIG_SEGMENTS_STALE_THRESHOLD"`
+	BigSegmentsMaxPatchRate    ct.OptIntGreaterThanZero `conf:"BIG_SEGMENTS_MAX_PATCH_RATE"`
+	VerifyStoreOnInit          bool                     `conf:"VERIFY_STORE_ON_INIT"`
+	VerifyStoreMinFlags        ct.OptIntGreaterThanZero `conf:"VERIFY_STORE_MIN_FLAGS"`
+	MaxFlagCount               ct.OptIntGreaterThanZero `conf:"MAX_FLAG_COUNT"`
+	EnforceMaxFlagCount        bool                     `conf:"ENFORCE_MAX_FLAG_COUNT"`
+	SendDataAgeHeader          bool                     `conf:"SEND_DATA_AGE_HEADER"`
+	RetryWhileInitializing     bool                     `conf:"RETRY_WHILE_INITIALIZING"`
+	MaintenanceMode            bool                     `conf:"MAINTENANCE_MODE"`
+	EnableMaintenanceEndpoint  bool                     `conf:"ENABLE_MAINTENANCE_ENDPOINT"`
+	AdminKey                   string                   `conf:"ADMIN_KEY"`
+	RequireAdminKeyForStatus   bool                     `conf:"REQUIRE_ADMIN_KEY_FOR_STATUS"`
+	FlagHistoryDepth           ct.OptIntGreaterThanZero `conf:"FLAG_HISTORY_DEPTH"`
+	StoreWriteAttempts         ct.OptIntGreaterThanZero `conf:"STORE_WRITE_ATTEMPTS"`
+	StoreWriteRetryDelay       ct.OptDuration           `conf:"STORE_WRITE_RETRY_DELAY"`
+	StoreBreakerFailures       ct.OptIntGreaterThanZero `conf:"STORE_BREAKER_FAILURES"`
+	StoreBreakerLatency        ct.OptDuration           `conf:"STORE_BREAKER_LATENCY"`
+	StoreBreakerOpenTime       ct.OptDuration           `conf:"STORE_BREAKER_OPEN_TIME"`
+	MaxReportBodySize          ct.OptInt                `conf:"MAX_REPORT_BODY_SIZE"`
+	StartupJitter              ct.OptDuration           `conf:"STARTUP_JITTER"`
+	MaxConcurrentInits         ct.OptIntGreaterThanZero `conf:"MAX_CONCURRENT_INITS"`
+	InitialReconnectDelay      ct.OptDuration           `conf:"INITIAL_RECONNECT_DELAY"`
+	AllowedMethods             ct.OptStringList         `conf:"ALLOWED_METHODS"`
+	IgnoreTrailingSlash        bool                     `conf:"IGNORE_TRAILING_SLASH"`
+	IgnorePathCase             bool                     `conf:"IGNORE_PATH_CASE"`
+	CORSAllowedHeaders         ct.OptStringList         `conf:"CORS_ALLOWED_HEADERS"`
+	CORSAllowedMethods         ct.OptStringList         `conf:"CORS_ALLOWED_METHODS"`
+	AdminPort                  ct.OptIntGreaterThanZero `conf:"ADMIN_PORT"`
+	GRPCPort                   ct.OptIntGreaterThanZero `conf:"GRPC_PORT"`
+	ResponseHeaders            ct.OptStringList         `conf:"RESPONSE_HEADERS"`
+	NoDefaultResponseHeaders   bool                     `conf:"NO_DEFAULT_RESPONSE_HEADERS"`
+	MinSDKVersions             ct.OptStringList         `conf:"MIN_SDK_VERSIONS"`
+	RejectUnknownSDKVersions   bool                     `conf:"REJECT_UNKNOWN_SDK_VERSIONS"`
+	MobilePingCoalesceTime     ct.OptDuration           `conf:"MOBILE_PING_COALESCE_TIME"`
+	JSClientPingCoalesceTime   ct.OptDuration           `conf:"JS_CLIENT_PING_COALESCE_TIME"`
+	TrustedProxies             ct.OptStringList         `conf:"TRUSTED_PROXIES"`
 }
 
 // AutoConfigConfig contains configuration parameters for the auto-configuration feature.
@@ -154,6 +270,7 @@ type AutoConfigConfig struct {
 	EnvDatastoreTableName string           `conf:"ENV_DATASTORE_TABLE_NAME"`
 	EnvAllowedOrigin      ct.OptStringList `conf:"ENV_ALLOWED_ORIGIN"`
 	EnvAllowedHeader      ct.OptStringList `conf:"ENV_ALLOWED_HEADER"`
+	KeyExpiryGracePeriod  ct.OptDuration   `conf:"KEY_EXPIRY_GRACE_PERIOD"`
 }
 
 // OfflineModeConfig contains configuration parameters for the offline/file data source feature.
@@ -176,6 +293,15 @@ type EventsConfig struct {
 	FlushInterval ct.OptDuration           `conf:"EVENTS_FLUSH_INTERVAL"`
 	Capacity      ct.OptIntGreaterThanZero `conf:"EVENTS_CAPACITY"`
 	InlineUsers   bool                     `conf:"EVENTS_INLINE_USERS"`
+	RecentBatches ct.OptIntGreaterThanZero `conf:"EVENTS_RECENT_BATCHES"`
+	MaxBatchSize  ct.OptIntGreaterThanZero `conf:"EVENTS_MAX_BATCH_SIZE"`
+	Compression   string                   `conf:"EVENTS_COMPRESSION"`
+	UnknownFlags  string                   `conf:"EVENTS_UNKNOWN_FLAGS"`
+
+	DisableImageEndpoint    bool                     `conf:"EVENTS_DISABLE_IMAGE_ENDPOINT"`
+	MaxImageDataSize        ct.OptIntGreaterThanZero `conf:"EVENTS_MAX_IMAGE_DATA_SIZE"`
+	MaxDecompressedSize     ct.OptIntGreaterThanZero `conf:"EVENTS_MAX_DECOMPRESSED_SIZE"`
+	AugmentDiagnosticEvents bool                     `conf:"EVENTS_AUGMENT_DIAGNOSTIC_EVENTS"`
 }
 
 // RedisConfig configures the optional Redis integration.
@@ -190,13 +316,16 @@ type EventsConfig struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type RedisConfig struct {
-	Host     string `conf:"REDIS_HOST"`
-	Port     ct.OptIntGreaterThanZero
-	URL      ct.OptURLAbsolute `conf:"REDIS_URL"`
-	LocalTTL ct.OptDuration    `conf:"CACHE_TTL"`
-	TLS      bool              `conf:"REDIS_TLS"`
-	Username string            `conf:"REDIS_USERNAME"`
-	Password string            `conf:"REDIS_PASSWORD"`
+	Host          string `conf:"REDIS_HOST"`
+	Port          ct.OptIntGreaterThanZero
+	URL           ct.OptURLAbsolute `conf:"REDIS_URL"`
+	LocalTTL      ct.OptDuration    `conf:"CACHE_TTL"`
+	TLS           bool              `conf:"REDIS_TLS"`
+	Username      string            `conf:"REDIS_USERNAME"`
+	Password      string            `conf:"REDIS_PASSWORD"`
+	Compression   string            `conf:"REDIS_COMPRESSION"`
+	PurgeInterval ct.OptDuration    `conf:"REDIS_PURGE_INTERVAL"`
+	PurgeAge      ct.OptDuration    `conf:"REDIS_PURGE_AGE"`
 }
 
 // ConsulConfig configures the optional Consul integration.
@@ -223,10 +352,13 @@ type ConsulConfig struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type DynamoDBConfig struct {
-	Enabled   bool              `conf:"USE_DYNAMODB"`
-	TableName string            `conf:"DYNAMODB_TABLE"`
-	URL       ct.OptURLAbsolute `conf:"DYNAMODB_URL"`
-	LocalTTL  ct.OptDuration    `conf:"CACHE_TTL"`
+	Enabled       bool              `conf:"USE_DYNAMODB"`
+	TableName     string            `conf:"DYNAMODB_TABLE"`
+	URL           ct.OptURLAbsolute `conf:"DYNAMODB_URL"`
+	LocalTTL      ct.OptDuration    `conf:"CACHE_TTL"`
+	Compression   string            `conf:"DYNAMODB_COMPRESSION"`
+	PurgeInterval ct.OptDuration    `conf:"DYNAMODB_PURGE_INTERVAL"`
+	PurgeAge      ct.OptDuration    `conf:"DYNAMODB_PURGE_AGE"`
 }
 
 // EnvConfig describes an environment to be relayed. There may be any number of these.
@@ -238,18 +370,60 @@ type DynamoDBConfig struct {
 // variables, individual fields are not documented here; instead, see the `README.md` section on
 // configuration.
 type EnvConfig struct {
-	SDKKey        SDKKey           // set from env var LD_ENV_envname
-	MobileKey     MobileKey        `conf:"LD_MOBILE_KEY_"`
-	EnvID         EnvironmentID    `conf:"LD_CLIENT_SIDE_ID_"`
-	Prefix        string           `conf:"LD_PREFIX_"`     // used only if Redis, Consul, or DynamoDB is enabled
-	TableName     string           `conf:"LD_TABLE_NAME_"` // used only if DynamoDB is enabled
-	AllowedOrigin ct.OptStringList `conf:"LD_ALLOWED_ORIGIN_"`
-	AllowedHeader ct.OptStringList `conf:"LD_ALLOWED_HEADER_"`
-	SecureMode    bool             `conf:"LD_SECURE_MODE_"`
-	LogLevel      OptLogLevel      `conf:"LD_LOG_LEVEL_"`
-	TTL           ct.OptDuration   `conf:"LD_TTL_"`
-	ProjKey       string           `conf:"LD_PROJ_KEY_"`
-	FilterKey     FilterKey        // injected based on [filters] section
+	SDKKey               SDKKey            // set from env var LD_ENV_envname
+	MobileKey            MobileKey         `conf:"LD_MOBILE_KEY_"`
+	EnvID                EnvironmentID     `conf:"LD_CLIENT_SIDE_ID_"`
+	Prefix               string            `conf:"LD_PREFIX_"`     // used only if Redis, Consul, or DynamoDB is enabled
+	TableName            string            `conf:"LD_TABLE_NAME_"` // used only if DynamoDB is enabled
+	AllowedOrigin        ct.OptStringList  `conf:"LD_ALLOWED_ORIGIN_"`
+	AllowedHeader        ct.OptStringList  `conf:"LD_ALLOWED_HEADER_"`
+	EventForwardingURI   ct.OptStringList  `conf:"LD_EVENT_FORWARDING_URI_"`
+	SecureMode           bool              `conf:"LD_SECURE_MODE_"`
+	LogLevel             OptLogLevel       `conf:"LD_LOG_LEVEL_"`
+	TTL                  ct.OptDuration    `conf:"LD_TTL_"`
+	ProjKey              string            `conf:"LD_PROJ_KEY_"`
+	Group                string            `conf:"LD_GROUP_"`
+	StartupFlags         ct.OptStringList  `conf:"LD_STARTUP_FLAGS_"`
+	ContextKindPrefixes  ct.OptStringList  `conf:"LD_CONTEXT_KIND_PREFIXES_"`
+	RedactedFlags        ct.OptStringList  `conf:"LD_REDACTED_FLAGS_"`
+	FallbackFlagValues   OptFlagValues     `conf:"LD_FALLBACK_FLAG_VALUES_"`
+	TLSServerName        string            `conf:"LD_TLS_SERVER_NAME_"`
+	ProxyURL             ct.OptURLAbsolute `conf:"LD_PROXY_URL_"`
+	ProxyNTLMAuth        bool              `conf:"LD_PROXY_AUTH_NTLM_"`
+	ProxyUser            string            `conf:"LD_PROXY_AUTH_USER_"`
+	ProxyPassword        string            `conf:"LD_PROXY_AUTH_PASSWORD_"`
+	ProxyDomain          string            `conf:"LD_PROXY_AUTH_DOMAIN_"`
+	ProxyCACertFiles     ct.OptStringList  `conf:"LD_PROXY_CA_CERTS_"`
+	ProxyDisabled        bool              `conf:"LD_PROXY_DISABLED_"`
+	StrictEvents         bool              `conf:"LD_STRICT_EVENTS_"`
+	EventsFlushInterval  ct.OptDuration    `conf:"LD_EVENTS_FLUSH_INTERVAL_"`
+	BigSegmentsRedisURL  ct.OptURLAbsolute `conf:"LD_BIG_SEGMENTS_REDIS_URL_"`
+	BigSegmentsTableName string            `conf:"LD_BIG_SEGMENTS_TABLE_NAME_"`
+	ChangeWebhookURL     ct.OptURLAbsolute `conf:"LD_CHANGE_WEBHOOK_URL_"`
+	ChangeWebhookSecret  string            `conf:"LD_CHANGE_WEBHOOK_SECRET_"`
+	FilterKey            FilterKey         // injected based on [filters] section
+}
+
+// GetProxyConfig returns the proxy settings that should be used for this environment. If the environment
+// has its own proxy URL, its own proxy settings are used instead of the global ones, except for the DNS
+// cache TTL which is always global. If the environment has ProxyDisabled set, it connects directly, without
+// any proxy. Otherwise the global settings are used.
+func (c EnvConfig) GetProxyConfig(global ProxyConfig) ProxyConfig {
+	if c.ProxyDisabled {
+		return ProxyConfig{Disabled: true, CACertFiles: c.ProxyCACertFiles, DNSCacheTTL: global.DNSCacheTTL}
+	}
+	if !c.ProxyURL.IsDefined() {
+		return global
+	}
+	return ProxyConfig{
+		URL:         c.ProxyURL,
+		NTLMAuth:    c.ProxyNTLMAuth,
+		User:        c.ProxyUser,
+		Password:    c.ProxyPassword,
+		Domain:      c.ProxyDomain,
+		CACertFiles: c.ProxyCACertFiles,
+		DNSCacheTTL: global.DNSCacheTTL,
+	}
 }
 
 type FiltersConfig struct {
@@ -268,15 +442,18 @@ type ProxyConfig struct {
 	Password    string            `conf:"PROXY_AUTH_PASSWORD"`
 	Domain      string            `conf:"PROXY_AUTH_DOMAIN"`
 	CACertFiles ct.OptStringList  `conf:"PROXY_CA_CERTS"`
+	DNSCacheTTL ct.OptDuration    `conf:"DNS_CACHE_TTL"`
+	Disabled    bool              // injected by EnvConfig.GetProxyConfig if the environment has ProxyDisabled set
 }
 
 // MetricsConfig contains configurations for optional metrics integrations.
 //
 // This corresponds to the [Datadog], [Stackdriver], and [Prometheus] sections in the configuration file.
 type MetricsConfig struct {
-	Datadog     DatadogConfig
-	Stackdriver StackdriverConfig
-	Prometheus  PrometheusConfig
+	Datadog       DatadogConfig
+	Stackdriver   StackdriverConfig
+	Prometheus    PrometheusConfig
+	OpenTelemetry OpenTelemetryConfig
 }
 
 // DatadogConfig configures the optional Datadog integration, which is used only if Enabled is true.
@@ -319,3 +496,19 @@ type PrometheusConfig struct {
 	Prefix  string                   `conf:"PROMETHEUS_PREFIX"`
 	Port    ct.OptIntGreaterThanZero `conf:"PROMETHEUS_PORT"`
 }
+
+// OpenTelemetryConfig configures the optional export of traces to an OpenTelemetry collector using
+// OTLP over HTTP, which is used only if Enabled is true.
+//
+// This corresponds to the [OpenTelemetry] section in the configuration file.
+//
+// Since configuration options can be set either programmatically, or from a file, or from environment
+// variables, individual fields are not documented here; instead, see the `README.md` section on
+// configuration.
+type OpenTelemetryConfig struct {
+	Enabled     bool              `conf:"USE_OPENTELEMETRY"`
+	Endpoint    ct.OptURLAbsolute `conf:"OPENTELEMETRY_ENDPOINT"`
+	Headers     ct.OptStringList  `conf:"OPENTELEMETRY_HEADERS"`
+	ServiceName string            `conf:"OPENTELEMETRY_SERVICE_NAME"`
+	SampleRate  ct.OptFloat64     `conf:"OPENTELEMETRY_SAMPLE_RATE"`
+}
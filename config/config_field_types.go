@@ -2,12 +2,14 @@ package config
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 )
 
 func errBadLogLevel(s string) error {
@@ -18,6 +20,10 @@ func errBadTLSVersion(s string) error {
 	return fmt.Errorf("%q is not a valid TLS version", s)
 }
 
+func errBadFlagValues(s string) error {
+	return fmt.Errorf("%q is not a JSON object of flag keys and values", s)
+}
+
 // SDKKey is a type tag to indicate when a string is used as a server-side SDK key for a LaunchDarkly
 // environment.
 type SDKKey string
@@ -207,6 +213,15 @@ func (o *OptLogLevel) UnmarshalText(data []byte) error {
 	return err
 }
 
+// MarshalJSON converts the value to a JSON string containing the level name, or a JSON null if there
+// is no value.
+func (o OptLogLevel) MarshalJSON() ([]byte, error) {
+	if !o.IsDefined() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(strings.ToLower(o.level.Name()))
+}
+
 // OptTLSVersion represents an optional TLS level parameter. When represented as a string, it must be
 // "1.0", "1.1", "1.2", or "1.3". This is converted into a uint16 value as defined by crypto/tls.
 type OptTLSVersion struct {
@@ -258,6 +273,15 @@ func (o *OptTLSVersion) UnmarshalText(data []byte) error {
 	return err
 }
 
+// MarshalJSON converts the value to a JSON string in the same format as String(), or a JSON null if
+// there is no value.
+func (o OptTLSVersion) MarshalJSON() ([]byte, error) {
+	if !o.IsDefined() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.String())
+}
+
 // String returns a string description of the value.
 func (o OptTLSVersion) String() string {
 	switch o.value {
@@ -275,3 +299,66 @@ func (o OptTLSVersion) String() string {
 		return fmt.Sprintf("unknown (%d)", o.value)
 	}
 }
+
+// OptFlagValues represents an optional set of flag values, keyed by flag key. When represented as a string,
+// it must be a JSON object, such as {"flag1": true, "flag2": "value"}.
+//
+// The zero value OptFlagValues{} is valid and undefined (IsDefined() is false).
+type OptFlagValues struct {
+	values map[string]ldvalue.Value
+}
+
+// NewOptFlagValues creates an OptFlagValues that wraps the given map. An empty or nil map is equivalent
+// to undefined.
+func NewOptFlagValues(values map[string]ldvalue.Value) OptFlagValues {
+	if len(values) == 0 {
+		return OptFlagValues{}
+	}
+	return OptFlagValues{values: values}
+}
+
+// NewOptFlagValuesFromString creates an OptFlagValues from a string that must either be a JSON object or
+// an empty string.
+func NewOptFlagValuesFromString(s string) (OptFlagValues, error) {
+	if strings.TrimSpace(s) == "" {
+		return OptFlagValues{}, nil
+	}
+	value := ldvalue.Parse([]byte(s))
+	if value.Type() != ldvalue.ObjectType {
+		return OptFlagValues{}, errBadFlagValues(s)
+	}
+	values := make(map[string]ldvalue.Value, value.Count())
+	for _, key := range value.Keys(nil) {
+		values[key] = value.GetByKey(key)
+	}
+	return NewOptFlagValues(values), nil
+}
+
+// IsDefined returns true if the instance contains at least one flag value.
+func (o OptFlagValues) IsDefined() bool {
+	return len(o.values) != 0
+}
+
+// Values returns the wrapped map, or nil if there is no value.
+func (o OptFlagValues) Values() map[string]ldvalue.Value {
+	return o.values
+}
+
+// UnmarshalText attempts to parse the value from a byte string, using the same logic as
+// NewOptFlagValuesFromString.
+func (o *OptFlagValues) UnmarshalText(data []byte) error {
+	opt, err := NewOptFlagValuesFromString(string(data))
+	if err == nil {
+		*o = opt
+	}
+	return err
+}
+
+// MarshalJSON converts the value to a JSON object of flag keys and values, or a JSON null if there is
+// no value.
+func (o OptFlagValues) MarshalJSON() ([]byte, error) {
+	if !o.IsDefined() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.values)
+}
@@ -2,11 +2,13 @@ package config
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 )
 
 func TestSDKCredential(t *testing.T) {
@@ -45,6 +47,15 @@ func TestOptLogLevel(t *testing.T) {
 		assert.Equal(t, errBadLogLevel(badString), err)
 		assert.Equal(t, OptLogLevel{}, o)
 	})
+
+	t.Run("marshal to JSON", func(t *testing.T) {
+		data, err := json.Marshal(OptLogLevel{})
+		assert.NoError(t, err)
+		assert.Equal(t, "null", string(data))
+		data, err = json.Marshal(NewOptLogLevel(validLevel))
+		assert.NoError(t, err)
+		assert.Equal(t, `"warn"`, string(data))
+	})
 }
 
 func TestOptTLSVersion(t *testing.T) {
@@ -90,4 +101,55 @@ func TestOptTLSVersion(t *testing.T) {
 		assert.Equal(t, "1.3", NewOptTLSVersion(tls.VersionTLS13).String())
 		assert.Equal(t, "unknown (9999)", NewOptTLSVersion(9999).String())
 	})
+
+	t.Run("marshal to JSON", func(t *testing.T) {
+		data, err := json.Marshal(OptTLSVersion{})
+		assert.NoError(t, err)
+		assert.Equal(t, "null", string(data))
+		data, err = json.Marshal(NewOptTLSVersion(tls.VersionTLS12))
+		assert.NoError(t, err)
+		assert.Equal(t, `"1.2"`, string(data))
+	})
+}
+
+func TestOptFlagValues(t *testing.T) {
+	t.Run("zero value", func(t *testing.T) {
+		o := OptFlagValues{}
+		assert.False(t, o.IsDefined())
+		assert.Nil(t, o.Values())
+	})
+
+	t.Run("new from valid string", func(t *testing.T) {
+		o, err := NewOptFlagValuesFromString(`{"a": true, "b": {"c": 1}}`)
+		assert.NoError(t, err)
+		assert.True(t, o.IsDefined())
+		assert.Equal(t, map[string]ldvalue.Value{
+			"a": ldvalue.Bool(true),
+			"b": ldvalue.ObjectBuild().Set("c", ldvalue.Int(1)).Build(),
+		}, o.Values())
+	})
+
+	t.Run("new from empty string or object", func(t *testing.T) {
+		for _, s := range []string{"", " ", "{}"} {
+			o, err := NewOptFlagValuesFromString(s)
+			assert.NoError(t, err)
+			assert.Equal(t, OptFlagValues{}, o)
+		}
+	})
+
+	t.Run("new from invalid string", func(t *testing.T) {
+		for _, s := range []string{"x", "true", `["a"]`, `{"a":`} {
+			o, err := NewOptFlagValuesFromString(s)
+			assert.Equal(t, errBadFlagValues(s), err)
+			assert.Equal(t, OptFlagValues{}, o)
+		}
+	})
+	t.Run("marshal to JSON", func(t *testing.T) {
+		data, err := json.Marshal(OptFlagValues{})
+		assert.NoError(t, err)
+		assert.Equal(t, "null", string(data))
+		data, err = json.Marshal(NewOptFlagValues(map[string]ldvalue.Value{"a": ldvalue.Bool(true)}))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"a": true}`, string(data))
+	})
 }
@@ -3,10 +3,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"sort"
 	"strings"
 
 	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	semver "github.com/launchdarkly/go-semver"
 )
 
 var (
@@ -23,16 +27,116 @@ var (
 	errConsulTokenAndTokenFile = errors.New("Consul token must be specified as either an inline value or a file, but not both") //nolint:stylecheck
 	errAutoConfWithFilters     = errors.New("cannot configure filters if auto-configuration is enabled")
 	errMissingProjKey          = errors.New("when filters are configured, all environments must specify a 'projKey'")
+	errEnvironmentWithNoName   = errors.New("environment name cannot be empty")
+	errNegativeMaxReportBody   = errors.New("maximum REPORT body size cannot be negative")
+	errNegativeStoreRetryDelay = errors.New("data store write retry delay cannot be negative")
+	errHeartbeatNotPositive    = errors.New("heartbeat interval must be greater than zero")
+	errLongPollNotPositive     = errors.New("mobile long poll timeout must be greater than zero")
+	errAdminPortSameAsPort     = errors.New("admin port cannot be the same as the main port")
+	errGRPCPortSameAsPort      = errors.New("gRPC port cannot be the same as the main port or the admin port")
+	errStatusAuthWithoutKey    = errors.New("admin key must be specified if it is required for the status endpoint")
+	errMaintenanceWithoutKey   = errors.New("admin key must be specified if the maintenance endpoint is enabled")
+	errMaxConnJitterTooLarge   = errors.New("maximum client connection jitter must be less than the maximum client connection time")
+	errEnforceMaxFlagCountOnly = errors.New("maximum flag count must be specified if it is enforced")
+	errStoreBreakerNotPositive = errors.New("data store circuit breaker latency and open time must be greater than zero")
+	errStoreBreakerOnly        = errors.New("data store circuit breaker failure count must be specified if its latency or open time is")
+	errOpenTelemetryNoEndpoint = errors.New("OpenTelemetry endpoint must be specified if OpenTelemetry is enabled")
+	errOpenTelemetrySampleRate = errors.New("OpenTelemetry sample rate must be between 0 and 1")
 )
 
 func errEnvironmentWithNoSDKKey(envName string) error {
 	return fmt.Errorf("SDK key is required for environment %q", envName)
 }
 
+func errEnvironmentDuplicateName(envName, otherName string) error {
+	return fmt.Errorf("environment names %q and %q are the same once surrounding whitespace is removed", otherName, envName)
+}
+
+func errEnvironmentDuplicateCredential(credentialKind, envName, otherName string) error {
+	return fmt.Errorf("environments %q and %q have the same %s", otherName, envName, credentialKind)
+}
+
+func errEnvInvalidEventForwardingURI(envName, uri string) error {
+	return fmt.Errorf("event forwarding URI %q for environment %q must be an absolute URL", uri, envName)
+}
+
+func errEnvMultipleBigSegmentsStores(envName string) error {
+	return fmt.Errorf("environment %q cannot specify both a Redis URL and a DynamoDB table name for big segments", envName)
+}
+
+func errEnvBadContextKindPrefix(envName, entry string) error {
+	return fmt.Errorf("invalid context kind flag prefix %q for environment %q; must be in the form \"kind:prefix\"",
+		entry, envName)
+}
+
+func errEnvProxyAuthWithoutProxyURL(envName string) error {
+	return fmt.Errorf("environment %q cannot specify proxy authentication without a proxy URL", envName)
+}
+
+func errEnvProxyDisabledWithProxyURL(envName string) error {
+	return fmt.Errorf("environment %q cannot specify a proxy URL if its proxy is disabled", envName)
+}
+
+func errEnvChangeWebhookSecretWithoutURL(envName string) error {
+	return fmt.Errorf("environment %q cannot specify a change webhook secret without a change webhook URL", envName)
+}
+
+func errEnvNTLMProxyAuthWithoutCredentials(envName string) error {
+	return fmt.Errorf("NTLM proxy authentication for environment %q requires username and password", envName)
+}
+
 func errMultipleDatabases(databases []string) error {
 	return fmt.Errorf("multiple databases are enabled (%s); only one is allowed", strings.Join(databases, ", "))
 }
 
+func errBadDataStoreCompression(database, value string) error {
+	return fmt.Errorf("invalid %s compression %q; must be %q or %q", database, value,
+		DataStoreCompressionNone, DataStoreCompressionGzip)
+}
+
+func errBadDataStorePurgeInterval(database string) error {
+	return fmt.Errorf("%s purge interval must be greater than zero", database)
+}
+
+func errBadEventsCompression(value string) error {
+	return fmt.Errorf("invalid events compression %q; must be %q or %q", value,
+		EventsCompressionNone, EventsCompressionGzip)
+}
+
+func errBadEventsUnknownFlags(value string) error {
+	return fmt.Errorf("invalid events unknown flags mode %q; must be %q, %q, or %q", value,
+		EventsUnknownFlagsForward, EventsUnknownFlagsCount, EventsUnknownFlagsDrop)
+}
+
+func errBadStatusKeyDisplay(value string) error {
+	return fmt.Errorf("invalid status key display %q; must be %q or %q", value,
+		StatusKeyDisplayMasked, StatusKeyDisplayHidden)
+}
+
+func errBadResponseHeader(entry string) error {
+	return fmt.Errorf("invalid response header %q; must be in the form \"Name: value\"", entry)
+}
+
+func errBadOpenTelemetryHeader(entry string) error {
+	return fmt.Errorf("invalid OpenTelemetry header %q; must be in the form \"Name: value\"", entry)
+}
+
+func errBadCORSHeader(name string) error {
+	return fmt.Errorf("invalid CORS allowed header %q; must be a header name", name)
+}
+
+func errBadCORSMethod(method string) error {
+	return fmt.Errorf("invalid CORS allowed method %q; must be an HTTP method name", method)
+}
+
+func errBadTrustedProxy(entry string) error {
+	return fmt.Errorf("invalid trusted proxy %q; must be an IP address or a CIDR range", entry)
+}
+
+func errBadMinSDKVersion(entry string) error {
+	return fmt.Errorf("invalid minimum SDK version %q; must be in the form \"SDKName/version\"", entry)
+}
+
 func errEnvWithoutDBDisambiguation(envName string, canUseTableName bool) error {
 	if canUseTableName {
 		return fmt.Errorf("environment %q does not have a prefix or table name specified for database storage", envName)
@@ -73,9 +177,19 @@ func ValidateConfig(c *Config, loggers ldlog.Loggers) error {
 
 	validateConfigDefaultURLs(c)
 	validateConfigTLS(&result, c)
+	validateConfigLimits(&result, c)
+	validateConfigPorts(&result, c)
+	validateConfigStatusKeyDisplay(&result, c)
+	validateConfigAdminKey(&result, c)
+	validateConfigResponseHeaders(&result, c)
+	validateConfigCORS(&result, c)
+	validateConfigMinSDKVersions(&result, c)
+	validateConfigTrustedProxies(&result, c)
 	validateConfigEnvironments(&result, c)
+	validateConfigEvents(&result, c)
 	validateConfigDatabases(&result, c, loggers)
 	validateConfigFilters(&result, c)
+	validateConfigOpenTelemetry(&result, c)
 
 	return result.GetError()
 }
@@ -111,6 +225,134 @@ func validateConfigTLS(result *ct.ValidationResult, c *Config) {
 	}
 }
 
+func validateConfigLimits(result *ct.ValidationResult, c *Config) {
+	if c.Main.MaxReportBodySize.GetOrElse(0) < 0 {
+		result.AddError(nil, errNegativeMaxReportBody)
+	}
+	if c.Main.StoreWriteRetryDelay.GetOrElse(0) < 0 {
+		result.AddError(nil, errNegativeStoreRetryDelay)
+	}
+	if c.Main.HeartbeatInterval.IsDefined() && c.Main.HeartbeatInterval.GetOrElse(0) <= 0 {
+		result.AddError(nil, errHeartbeatNotPositive)
+	}
+	if c.Main.MobileLongPollTimeout.IsDefined() && c.Main.MobileLongPollTimeout.GetOrElse(0) <= 0 {
+		result.AddError(nil, errLongPollNotPositive)
+	}
+	if jitter := c.Main.MaxClientConnectionJitter.GetOrElse(0); jitter > 0 &&
+		jitter >= c.Main.MaxClientConnectionTime.GetOrElse(0) {
+		result.AddError(nil, errMaxConnJitterTooLarge)
+	}
+	if c.Main.EnforceMaxFlagCount && !c.Main.MaxFlagCount.IsDefined() {
+		result.AddError(nil, errEnforceMaxFlagCountOnly)
+	}
+	if (c.Main.StoreBreakerLatency.IsDefined() && c.Main.StoreBreakerLatency.GetOrElse(0) <= 0) ||
+		(c.Main.StoreBreakerOpenTime.IsDefined() && c.Main.StoreBreakerOpenTime.GetOrElse(0) <= 0) {
+		result.AddError(nil, errStoreBreakerNotPositive)
+	}
+	if (c.Main.StoreBreakerLatency.IsDefined() || c.Main.StoreBreakerOpenTime.IsDefined()) &&
+		!c.Main.StoreBreakerFailures.IsDefined() {
+		result.AddError(nil, errStoreBreakerOnly)
+	}
+}
+
+func validateConfigStatusKeyDisplay(result *ct.ValidationResult, c *Config) {
+	switch c.Main.StatusKeyDisplay {
+	case "", StatusKeyDisplayMasked, StatusKeyDisplayHidden:
+	default:
+		result.AddError(nil, errBadStatusKeyDisplay(c.Main.StatusKeyDisplay))
+	}
+}
+
+func validateConfigAdminKey(result *ct.ValidationResult, c *Config) {
+	if c.Main.RequireAdminKeyForStatus && c.Main.AdminKey == "" {
+		result.AddError(nil, errStatusAuthWithoutKey)
+	}
+	if c.Main.EnableMaintenanceEndpoint && c.Main.AdminKey == "" {
+		result.AddError(nil, errMaintenanceWithoutKey)
+	}
+}
+
+func validateConfigResponseHeaders(result *ct.ValidationResult, c *Config) {
+	for _, entry := range c.Main.ResponseHeaders.Values() {
+		if !isValidHeaderEntry(entry) {
+			result.AddError(nil, errBadResponseHeader(entry))
+		}
+	}
+}
+
+func validateConfigCORS(result *ct.ValidationResult, c *Config) {
+	for _, name := range c.Main.CORSAllowedHeaders.Values() {
+		if !isValidToken(name) {
+			result.AddError(nil, errBadCORSHeader(name))
+		}
+	}
+	for _, method := range c.Main.CORSAllowedMethods.Values() {
+		if !isValidToken(method) {
+			result.AddError(nil, errBadCORSMethod(method))
+		}
+	}
+}
+
+func validateConfigOpenTelemetry(result *ct.ValidationResult, c *Config) {
+	if !c.OpenTelemetry.Enabled {
+		return
+	}
+	if !c.OpenTelemetry.Endpoint.IsDefined() {
+		result.AddError(nil, errOpenTelemetryNoEndpoint)
+	}
+	for _, entry := range c.OpenTelemetry.Headers.Values() {
+		if !isValidHeaderEntry(entry) {
+			result.AddError(nil, errBadOpenTelemetryHeader(entry))
+		}
+	}
+	if rate := c.OpenTelemetry.SampleRate; rate.IsDefined() && (rate.GetOrElse(0) < 0 || rate.GetOrElse(0) > 1) {
+		result.AddError(nil, errOpenTelemetrySampleRate)
+	}
+}
+
+// isValidHeaderEntry returns true if entry is in the form "Name: value".
+func isValidHeaderEntry(entry string) bool {
+	name, _, found := strings.Cut(entry, ":")
+	name = strings.TrimSpace(name)
+	return found && name != "" && !strings.ContainsAny(name, " \t")
+}
+
+// isValidToken returns true if s can be used as an HTTP header name or method name.
+func isValidToken(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t:,;\"()<>@[]{}/?=\\")
+}
+
+func validateConfigMinSDKVersions(result *ct.ValidationResult, c *Config) {
+	for _, entry := range c.Main.MinSDKVersions.Values() {
+		name, version, found := strings.Cut(entry, "/")
+		_, err := semver.ParseAs(version, semver.ParseModeAllowMissingMinorAndPatch)
+		if !found || name == "" || strings.ContainsAny(name, " \t") || err != nil {
+			result.AddError(nil, errBadMinSDKVersion(entry))
+		}
+	}
+}
+
+func validateConfigTrustedProxies(result *ct.ValidationResult, c *Config) {
+	for _, entry := range c.Main.TrustedProxies.Values() {
+		entry = strings.TrimSpace(entry)
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			result.AddError(nil, errBadTrustedProxy(entry))
+		}
+	}
+}
+
+func validateConfigPorts(result *ct.ValidationResult, c *Config) {
+	if c.Main.AdminPort.IsDefined() && c.Main.AdminPort.GetOrElse(0) == c.Main.Port.GetOrElse(DefaultPort) {
+		result.AddError(nil, errAdminPortSameAsPort)
+	}
+	if c.Main.GRPCPort.IsDefined() {
+		grpcPort := c.Main.GRPCPort.GetOrElse(0)
+		if grpcPort == c.Main.Port.GetOrElse(DefaultPort) || grpcPort == c.Main.AdminPort.GetOrElse(0) {
+			result.AddError(nil, errGRPCPortSameAsPort)
+		}
+	}
+}
+
 func validateConfigEnvironments(result *ct.ValidationResult, c *Config) {
 	if c.AutoConfig.Key == "" {
 		if c.AutoConfig.EnvDatastorePrefix != "" || c.AutoConfig.EnvDatastoreTableName != "" ||
@@ -134,11 +376,93 @@ func validateConfigEnvironments(result *ct.ValidationResult, c *Config) {
 		}
 	}
 
+	envNames := make([]string, 0, len(c.Environment))
+	for envName := range c.Environment {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames) // so that any errors are reported in a consistent order
+	trimmedNames := make(map[string]string, len(envNames))
+	for _, envName := range envNames {
+		trimmed := strings.TrimSpace(envName)
+		if trimmed == "" {
+			result.AddError(nil, errEnvironmentWithNoName)
+		} else if otherName, ok := trimmedNames[trimmed]; ok {
+			result.AddError(nil, errEnvironmentDuplicateName(envName, otherName))
+		} else {
+			trimmedNames[trimmed] = envName
+		}
+	}
+
+	// Each credential must identify a single environment, or requests using it would be ambiguous.
+	credentialEnvNames := make(map[string]string)
+	for _, envName := range envNames {
+		envConfig := c.Environment[envName]
+		for _, cred := range []struct {
+			kind  string
+			value string
+		}{
+			{"SDK key", string(envConfig.SDKKey)},
+			{"mobile key", string(envConfig.MobileKey)},
+			{"client-side ID", string(envConfig.EnvID)},
+		} {
+			if cred.value == "" {
+				continue
+			}
+			key := cred.kind + ":" + cred.value
+			if otherName, ok := credentialEnvNames[key]; ok {
+				result.AddError(nil, errEnvironmentDuplicateCredential(cred.kind, envName, otherName))
+			} else {
+				credentialEnvNames[key] = envName
+			}
+		}
+	}
+
 	for envName, envConfig := range c.Environment {
 		if envConfig.SDKKey == "" {
 			result.AddError(nil, errEnvironmentWithNoSDKKey(envName))
 		}
+		for _, uri := range envConfig.EventForwardingURI.Values() {
+			if u, err := url.Parse(uri); err != nil || !u.IsAbs() || u.Host == "" {
+				result.AddError(nil, errEnvInvalidEventForwardingURI(envName, uri))
+			}
+		}
+		if envConfig.BigSegmentsRedisURL.IsDefined() && envConfig.BigSegmentsTableName != "" {
+			result.AddError(nil, errEnvMultipleBigSegmentsStores(envName))
+		}
+		if envConfig.ChangeWebhookSecret != "" && !envConfig.ChangeWebhookURL.IsDefined() {
+			result.AddError(nil, errEnvChangeWebhookSecretWithoutURL(envName))
+		}
+		for _, entry := range envConfig.ContextKindPrefixes.Values() {
+			if kind, _, found := strings.Cut(entry, ":"); !found || !isValidContextKind(kind) {
+				result.AddError(nil, errEnvBadContextKindPrefix(envName, entry))
+			}
+		}
+		// These are the same checks that are done for the global proxy settings when the HTTP
+		// configuration is created, but we can catch them earlier here.
+		hasProxyAuth := envConfig.ProxyNTLMAuth || envConfig.ProxyUser != "" || envConfig.ProxyPassword != ""
+		switch {
+		case envConfig.ProxyDisabled && envConfig.ProxyURL.IsDefined():
+			result.AddError(nil, errEnvProxyDisabledWithProxyURL(envName))
+		case hasProxyAuth && !envConfig.ProxyURL.IsDefined():
+			result.AddError(nil, errEnvProxyAuthWithoutProxyURL(envName))
+		case envConfig.ProxyNTLMAuth && (envConfig.ProxyUser == "" || envConfig.ProxyPassword == ""):
+			result.AddError(nil, errEnvNTLMProxyAuthWithoutCredentials(envName))
+		}
+	}
+}
+
+// isValidContextKind returns true if the string can be used as a context kind in a single-kind context.
+func isValidContextKind(kind string) bool {
+	if kind == "" || kind == "kind" || kind == "multi" {
+		return false
+	}
+	for _, ch := range kind {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') ||
+			ch == '.' || ch == '_' || ch == '-') {
+			return false
+		}
 	}
+	return true
 }
 
 func validateConfigFilters(result *ct.ValidationResult, c *Config) {
@@ -184,6 +508,19 @@ func validateConfigFilters(result *ct.ValidationResult, c *Config) {
 		}
 	}
 }
+func validateConfigEvents(result *ct.ValidationResult, c *Config) {
+	switch c.Events.Compression {
+	case "", EventsCompressionNone, EventsCompressionGzip:
+	default:
+		result.AddError(nil, errBadEventsCompression(c.Events.Compression))
+	}
+	switch c.Events.UnknownFlags {
+	case "", EventsUnknownFlagsForward, EventsUnknownFlagsCount, EventsUnknownFlagsDrop:
+	default:
+		result.AddError(nil, errBadEventsUnknownFlags(c.Events.UnknownFlags))
+	}
+}
+
 func validateConfigDatabases(result *ct.ValidationResult, c *Config, loggers ldlog.Loggers) {
 	normalizeRedisConfig(result, c)
 
@@ -206,6 +543,11 @@ func validateConfigDatabases(result *ct.ValidationResult, c *Config, loggers ldl
 		return // no point doing further database config validation if it's in this state
 	}
 
+	validateDataStoreCompression(result, "Redis", c.Redis.Compression)
+	validateDataStoreCompression(result, "DynamoDB", c.DynamoDB.Compression)
+	validateDataStorePurgeInterval(result, "Redis", c.Redis.PurgeInterval)
+	validateDataStorePurgeInterval(result, "DynamoDB", c.DynamoDB.PurgeInterval)
+
 	if c.Consul.Host != "" {
 		if c.Consul.Token != "" && c.Consul.TokenFile != "" {
 			result.AddError(nil, errConsulTokenAndTokenFile)
@@ -239,6 +581,20 @@ func validateConfigDatabases(result *ct.ValidationResult, c *Config, loggers ldl
 	}
 }
 
+func validateDataStoreCompression(result *ct.ValidationResult, database, value string) {
+	switch value {
+	case "", DataStoreCompressionNone, DataStoreCompressionGzip:
+	default:
+		result.AddError(nil, errBadDataStoreCompression(database, value))
+	}
+}
+
+func validateDataStorePurgeInterval(result *ct.ValidationResult, database string, value ct.OptDuration) {
+	if value.IsDefined() && value.GetOrElse(0) <= 0 {
+		result.AddError(nil, errBadDataStorePurgeInterval(database))
+	}
+}
+
 func normalizeRedisConfig(result *ct.ValidationResult, c *Config) {
 	if c.Redis.URL.IsDefined() {
 		if c.Redis.Host != "" || c.Redis.Port.IsDefined() {
@@ -11,10 +11,47 @@ type testDataInvalidConfig struct {
 func makeInvalidConfigs() []testDataInvalidConfig {
 	return []testDataInvalidConfig{
 		makeInvalidConfigMissingSDKKey(),
+		makeInvalidConfigEventForwardingURINotAbsolute(),
+		makeInvalidConfigBigSegmentsRedisURLAndTableName(),
+		makeInvalidConfigBadContextKindPrefix(),
+		makeInvalidConfigEnvProxyAuthWithoutProxyURL(),
+		makeInvalidConfigEnvProxyCredentialsWithoutProxyURL(),
+		makeInvalidConfigEnvProxyDisabledWithProxyURL(),
+		makeInvalidConfigEnvChangeWebhookSecretWithoutURL(),
+		makeInvalidConfigEnvNTLMProxyAuthWithoutCredentials(),
+		makeInvalidConfigEmptyEnvironmentName(),
+		makeInvalidConfigDuplicateEnvironmentName(),
+		makeInvalidConfigDuplicateSDKKey(),
+		makeInvalidConfigDuplicateEnvironmentID(),
 		makeInvalidConfigTLSWithNoCertOrKey(),
 		makeInvalidConfigTLSWithNoCert(),
 		makeInvalidConfigTLSWithNoKey(),
 		makeInvalidConfigTLSVersion(),
+		makeInvalidConfigBadFallbackFlagValues(),
+		makeInvalidConfigNegativeMaxReportBodySize(),
+		makeInvalidConfigNegativeStoreWriteRetryDelay(),
+		makeInvalidConfigZeroHeartbeatInterval(),
+		makeInvalidConfigZeroMobileLongPollTimeout(),
+		makeInvalidConfigMaxClientConnectionJitterTooLarge(),
+		makeInvalidConfigEnforceMaxFlagCountWithoutMax(),
+		makeInvalidConfigZeroStoreBreakerLatency(),
+		makeInvalidConfigZeroStoreBreakerOpenTime(),
+		makeInvalidConfigStoreBreakerLatencyWithoutFailures(),
+		makeInvalidConfigStoreBreakerOpenTimeWithoutFailures(),
+		makeInvalidConfigAdminPortSameAsPort(),
+		makeInvalidConfigGRPCPortSameAsPort(),
+		makeInvalidConfigGRPCPortSameAsAdminPort(),
+		makeInvalidConfigBadResponseHeader(),
+		makeInvalidConfigBadCORSAllowedHeader(),
+		makeInvalidConfigBadCORSAllowedMethod(),
+		makeInvalidConfigBadStatusKeyDisplay(),
+		makeInvalidConfigStatusAuthWithoutAdminKey(),
+		makeInvalidConfigMaintenanceEndpointWithoutAdminKey(),
+		makeInvalidConfigOpenTelemetryWithoutEndpoint(),
+		makeInvalidConfigBadOpenTelemetryHeader(),
+		makeInvalidConfigOpenTelemetrySampleRateTooHigh(),
+		makeInvalidConfigBadMinSDKVersion(),
+		makeInvalidConfigBadTrustedProxy(),
 		makeInvalidConfigAutoConfKeyWithEnvironments(),
 		makeInvalidConfigAutoConfAllowedOriginWithNoKey(),
 		makeInvalidConfigAutoConfAllowedHeaderWithNoKey(),
@@ -29,6 +66,11 @@ func makeInvalidConfigs() []testDataInvalidConfig {
 		makeInvalidConfigRedisInvalidHostname(),
 		makeInvalidConfigRedisInvalidDockerPort(),
 		makeInvalidConfigRedisConflictingParams(),
+		makeInvalidConfigRedisBadCompression(),
+		makeInvalidConfigRedisZeroPurgeInterval(),
+		makeInvalidConfigDynamoDBZeroPurgeInterval(),
+		makeInvalidConfigEventsBadCompression(),
+		makeInvalidConfigEventsBadUnknownFlags(),
 		makeInvalidConfigRedisNoPrefix(),
 		makeInvalidConfigRedisAutoConfNoPrefix(),
 		makeInvalidConfigConsulNoPrefix(),
@@ -50,6 +92,214 @@ MobileKey = mob-xxx
 	return c
 }
 
+func makeInvalidConfigEmptyEnvironmentName() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment with empty name"}
+	c.envVarsError = "environment name cannot be empty"
+	c.envVars = map[string]string{
+		"LD_ENV_": "sdk-xxx",
+	}
+	c.fileContent = `
+[Environment " "]
+SdkKey = sdk-xxx
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigDuplicateEnvironmentName() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment names that differ only by whitespace"}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+
+[Environment " envname "]
+SdkKey = sdk-yyy
+`
+	c.fileError = `environment names " envname " and "envname" are the same once surrounding whitespace is removed`
+	return c
+}
+
+func makeInvalidConfigDuplicateSDKKey() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environments with the same SDK key"}
+	c.envVarsError = `environments "env1" and "env2" have the same SDK key`
+	c.envVars = map[string]string{
+		"LD_ENV_env1": "sdk-xxx",
+		"LD_ENV_env2": "sdk-xxx",
+	}
+	c.fileContent = `
+[Environment "env1"]
+SdkKey = sdk-xxx
+
+[Environment "env2"]
+SdkKey = sdk-xxx
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigDuplicateEnvironmentID() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environments with the same client-side ID"}
+	c.envVarsError = `environments "env1" and "env2" have the same client-side ID`
+	c.envVars = map[string]string{
+		"LD_ENV_env1":            "sdk-xxx",
+		"LD_CLIENT_SIDE_ID_env1": "env-id",
+		"LD_ENV_env2":            "sdk-yyy",
+		"LD_CLIENT_SIDE_ID_env2": "env-id",
+	}
+	c.fileContent = `
+[Environment "env1"]
+SdkKey = sdk-xxx
+EnvId = env-id
+
+[Environment "env2"]
+SdkKey = sdk-yyy
+EnvId = env-id
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEventForwardingURINotAbsolute() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "event forwarding URI is not absolute"}
+	c.envVarsError = `event forwarding URI "/sink" for environment "envname" must be an absolute URL`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                  "sdk-xxx",
+		"LD_EVENT_FORWARDING_URI_envname": "/sink",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+EventForwardingUri = /sink
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigBigSegmentsRedisURLAndTableName() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "big segments Redis URL and table name both specified"}
+	c.envVarsError = `environment "envname" cannot specify both a Redis URL and a DynamoDB table name for big segments`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                     "sdk-xxx",
+		"LD_BIG_SEGMENTS_REDIS_URL_envname":  "redis://localhost:6379",
+		"LD_BIG_SEGMENTS_TABLE_NAME_envname": "my-table",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+BigSegmentsRedisUrl = redis://localhost:6379
+BigSegmentsTableName = my-table
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigBadContextKindPrefix() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "context kind flag prefix without a valid kind"}
+	c.envVarsError = `invalid context kind flag prefix "org unit:org-" for environment "envname"`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                   "sdk-xxx",
+		"LD_CONTEXT_KIND_PREFIXES_envname": "user:user-,org unit:org-",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ContextKindPrefixes = "user:user-"
+ContextKindPrefixes = "org unit:org-"
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEnvProxyAuthWithoutProxyURL() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment proxy authentication without proxy URL"}
+	c.envVarsError = `environment "envname" cannot specify proxy authentication without a proxy URL`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                 "sdk-xxx",
+		"LD_PROXY_AUTH_NTLM_envname":     "1",
+		"LD_PROXY_AUTH_USER_envname":     "user",
+		"LD_PROXY_AUTH_PASSWORD_envname": "pass",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ProxyNTLMAuth = 1
+ProxyUser = user
+ProxyPassword = pass
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEnvProxyCredentialsWithoutProxyURL() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment proxy credentials without proxy URL"}
+	c.envVarsError = `environment "envname" cannot specify proxy authentication without a proxy URL`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                 "sdk-xxx",
+		"LD_PROXY_AUTH_USER_envname":     "user",
+		"LD_PROXY_AUTH_PASSWORD_envname": "pass",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ProxyUser = user
+ProxyPassword = pass
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEnvProxyDisabledWithProxyURL() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment proxy disabled with proxy URL"}
+	c.envVarsError = `environment "envname" cannot specify a proxy URL if its proxy is disabled`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":            "sdk-xxx",
+		"LD_PROXY_URL_envname":      "http://proxy",
+		"LD_PROXY_DISABLED_envname": "1",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ProxyURL = http://proxy
+ProxyDisabled = 1
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEnvChangeWebhookSecretWithoutURL() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment change webhook secret without URL"}
+	c.envVarsError = `environment "envname" cannot specify a change webhook secret without a change webhook URL`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":                   "sdk-xxx",
+		"LD_CHANGE_WEBHOOK_SECRET_envname": "secret",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ChangeWebhookSecret = secret
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
+func makeInvalidConfigEnvNTLMProxyAuthWithoutCredentials() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "environment NTLM proxy authentication without credentials"}
+	c.envVarsError = `NTLM proxy authentication for environment "envname" requires username and password`
+	c.envVars = map[string]string{
+		"LD_ENV_envname":             "sdk-xxx",
+		"LD_PROXY_URL_envname":       "http://proxy",
+		"LD_PROXY_AUTH_NTLM_envname": "1",
+	}
+	c.fileContent = `
+[Environment "envname"]
+SdkKey = sdk-xxx
+ProxyURL = http://proxy
+ProxyNTLMAuth = 1
+`
+	c.fileError = c.envVarsError
+	return c
+}
+
 func makeInvalidConfigTLSWithNoCertOrKey() testDataInvalidConfig {
 	c := testDataInvalidConfig{name: "TLS without cert/key"}
 	c.envVarsError = "TLS cert and key are required if TLS is enabled"
@@ -97,6 +347,298 @@ TLSMinVersion = x
 	return c
 }
 
+func makeInvalidConfigBadFallbackFlagValues() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "fallback flag values not a JSON object"}
+	c.envVarsError = "not a JSON object of flag keys and values"
+	c.envVars = map[string]string{"LD_ENV_envname": "sdk-xxx", "LD_FALLBACK_FLAG_VALUES_envname": "[true]"}
+	c.fileContent = `
+[Environment "envname"]
+SDKKey = sdk-xxx
+FallbackFlagValues = [true]
+`
+	return c
+}
+
+func makeInvalidConfigNegativeMaxReportBodySize() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "negative max REPORT body size"}
+	c.envVarsError = "maximum REPORT body size cannot be negative"
+	c.envVars = map[string]string{"MAX_REPORT_BODY_SIZE": "-1"}
+	c.fileContent = `
+[Main]
+MaxReportBodySize = -1
+`
+	return c
+}
+
+func makeInvalidConfigNegativeStoreWriteRetryDelay() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "negative store write retry delay"}
+	c.envVarsError = "data store write retry delay cannot be negative"
+	c.envVars = map[string]string{"STORE_WRITE_RETRY_DELAY": "-1s"}
+	c.fileContent = `
+[Main]
+StoreWriteRetryDelay = -1s
+`
+	return c
+}
+
+func makeInvalidConfigZeroHeartbeatInterval() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "zero heartbeat interval"}
+	c.envVarsError = "heartbeat interval must be greater than zero"
+	c.envVars = map[string]string{"HEARTBEAT_INTERVAL": "0s"}
+	c.fileContent = `
+[Main]
+HeartbeatInterval = 0s
+`
+	return c
+}
+
+func makeInvalidConfigZeroMobileLongPollTimeout() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "zero mobile long poll timeout"}
+	c.envVarsError = "mobile long poll timeout must be greater than zero"
+	c.envVars = map[string]string{"MOBILE_LONG_POLL_TIMEOUT": "0s"}
+	c.fileContent = `
+[Main]
+MobileLongPollTimeout = 0s
+`
+	return c
+}
+
+func makeInvalidConfigMaxClientConnectionJitterTooLarge() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "max client connection jitter not less than max client connection time"}
+	c.envVarsError = "maximum client connection jitter must be less than the maximum client connection time"
+	c.envVars = map[string]string{"MAX_CLIENT_CONNECTION_TIME": "1m", "MAX_CLIENT_CONNECTION_JITTER": "1m"}
+	c.fileContent = `
+[Main]
+MaxClientConnectionTime = 1m
+MaxClientConnectionJitter = 1m
+`
+	return c
+}
+
+func makeInvalidConfigEnforceMaxFlagCountWithoutMax() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "max flag count enforced without a maximum"}
+	c.envVarsError = "maximum flag count must be specified if it is enforced"
+	c.envVars = map[string]string{"ENFORCE_MAX_FLAG_COUNT": "true"}
+	c.fileContent = `
+[Main]
+EnforceMaxFlagCount = true
+`
+	return c
+}
+
+func makeInvalidConfigZeroStoreBreakerLatency() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "zero store breaker latency"}
+	c.envVarsError = "data store circuit breaker latency and open time must be greater than zero"
+	c.envVars = map[string]string{"STORE_BREAKER_FAILURES": "3", "STORE_BREAKER_LATENCY": "0s"}
+	c.fileContent = `
+[Main]
+StoreBreakerFailures = 3
+StoreBreakerLatency = 0s
+`
+	return c
+}
+
+func makeInvalidConfigZeroStoreBreakerOpenTime() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "zero store breaker open time"}
+	c.envVarsError = "data store circuit breaker latency and open time must be greater than zero"
+	c.envVars = map[string]string{"STORE_BREAKER_FAILURES": "3", "STORE_BREAKER_OPEN_TIME": "0s"}
+	c.fileContent = `
+[Main]
+StoreBreakerFailures = 3
+StoreBreakerOpenTime = 0s
+`
+	return c
+}
+
+func makeInvalidConfigStoreBreakerLatencyWithoutFailures() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "store breaker latency without failure count"}
+	c.envVarsError = "data store circuit breaker failure count must be specified if its latency or open time is"
+	c.envVars = map[string]string{"STORE_BREAKER_LATENCY": "1s"}
+	c.fileContent = `
+[Main]
+StoreBreakerLatency = 1s
+`
+	return c
+}
+
+func makeInvalidConfigStoreBreakerOpenTimeWithoutFailures() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "store breaker open time without failure count"}
+	c.envVarsError = "data store circuit breaker failure count must be specified if its latency or open time is"
+	c.envVars = map[string]string{"STORE_BREAKER_OPEN_TIME": "1s"}
+	c.fileContent = `
+[Main]
+StoreBreakerOpenTime = 1s
+`
+	return c
+}
+
+func makeInvalidConfigAdminPortSameAsPort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "admin port same as main port"}
+	c.envVarsError = "admin port cannot be the same as the main port"
+	c.envVars = map[string]string{"ADMIN_PORT": "8030"}
+	c.fileContent = `
+[Main]
+AdminPort = 8030
+`
+	return c
+}
+
+func makeInvalidConfigGRPCPortSameAsPort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "gRPC port same as main port"}
+	c.envVarsError = "gRPC port cannot be the same as the main port or the admin port"
+	c.envVars = map[string]string{"GRPC_PORT": "8030"}
+	c.fileContent = `
+[Main]
+GRPCPort = 8030
+`
+	return c
+}
+
+func makeInvalidConfigGRPCPortSameAsAdminPort() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "gRPC port same as admin port"}
+	c.envVarsError = "gRPC port cannot be the same as the main port or the admin port"
+	c.envVars = map[string]string{"ADMIN_PORT": "8031", "GRPC_PORT": "8031"}
+	c.fileContent = `
+[Main]
+AdminPort = 8031
+GRPCPort = 8031
+`
+	return c
+}
+
+func makeInvalidConfigBadResponseHeader() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "response header without colon"}
+	c.envVarsError = `invalid response header "X-Frame-Options DENY"`
+	c.envVars = map[string]string{"RESPONSE_HEADERS": "X-Frame-Options DENY"}
+	c.fileContent = `
+[Main]
+ResponseHeaders = X-Frame-Options DENY
+`
+	return c
+}
+
+func makeInvalidConfigBadCORSAllowedHeader() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "CORS allowed header with colon"}
+	c.envVarsError = `invalid CORS allowed header "X-My-Wrapper: 1"`
+	c.envVars = map[string]string{"CORS_ALLOWED_HEADERS": "X-My-Wrapper: 1"}
+	c.fileContent = `
+[Main]
+CORSAllowedHeaders = X-My-Wrapper: 1
+`
+	return c
+}
+
+func makeInvalidConfigBadCORSAllowedMethod() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "CORS allowed method with space"}
+	c.envVarsError = `invalid CORS allowed method "GET "`
+	c.envVars = map[string]string{"CORS_ALLOWED_METHODS": "GET ,REPORT"}
+	c.fileContent = `
+[Main]
+CORSAllowedMethods = GET ,REPORT
+`
+	return c
+}
+
+func makeInvalidConfigBadStatusKeyDisplay() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "unknown status key display"}
+	c.envVarsError = errBadStatusKeyDisplay("full").Error()
+	c.envVars = map[string]string{"STATUS_KEY_DISPLAY": "full"}
+	c.fileContent = `
+[Main]
+StatusKeyDisplay = "full"
+`
+	return c
+}
+
+func makeInvalidConfigStatusAuthWithoutAdminKey() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "status authentication without admin key"}
+	c.envVarsError = "admin key must be specified if it is required for the status endpoint"
+	c.envVars = map[string]string{"REQUIRE_ADMIN_KEY_FOR_STATUS": "1"}
+	c.fileContent = `
+[Main]
+RequireAdminKeyForStatus = 1
+`
+	return c
+}
+
+func makeInvalidConfigMaintenanceEndpointWithoutAdminKey() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "maintenance endpoint without admin key"}
+	c.envVarsError = "admin key must be specified if the maintenance endpoint is enabled"
+	c.envVars = map[string]string{"ENABLE_MAINTENANCE_ENDPOINT": "1"}
+	c.fileContent = `
+[Main]
+EnableMaintenanceEndpoint = 1
+`
+	return c
+}
+
+func makeInvalidConfigOpenTelemetryWithoutEndpoint() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "OpenTelemetry enabled without endpoint"}
+	c.envVarsError = errOpenTelemetryNoEndpoint.Error()
+	c.envVars = map[string]string{"USE_OPENTELEMETRY": "1"}
+	c.fileContent = `
+[OpenTelemetry]
+Enabled = true
+`
+	return c
+}
+
+func makeInvalidConfigBadOpenTelemetryHeader() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "OpenTelemetry header without colon"}
+	c.envVarsError = `invalid OpenTelemetry header "Authorization Bearer xyz"`
+	c.envVars = map[string]string{
+		"USE_OPENTELEMETRY":      "1",
+		"OPENTELEMETRY_ENDPOINT": "http://localhost:4318",
+		"OPENTELEMETRY_HEADERS":  "Authorization Bearer xyz",
+	}
+	c.fileContent = `
+[OpenTelemetry]
+Enabled = true
+Endpoint = "http://localhost:4318"
+Headers = "Authorization Bearer xyz"
+`
+	return c
+}
+
+func makeInvalidConfigOpenTelemetrySampleRateTooHigh() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "OpenTelemetry sample rate greater than 1"}
+	c.envVarsError = errOpenTelemetrySampleRate.Error()
+	c.envVars = map[string]string{
+		"USE_OPENTELEMETRY":         "1",
+		"OPENTELEMETRY_ENDPOINT":    "http://localhost:4318",
+		"OPENTELEMETRY_SAMPLE_RATE": "1.5",
+	}
+	c.fileContent = `
+[OpenTelemetry]
+Enabled = true
+Endpoint = "http://localhost:4318"
+SampleRate = 1.5
+`
+	return c
+}
+
+func makeInvalidConfigBadMinSDKVersion() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "minimum SDK version that is not a semantic version"}
+	c.envVarsError = `invalid minimum SDK version "GoClient/latest"`
+	c.envVars = map[string]string{"MIN_SDK_VERSIONS": "GoClient/latest"}
+	c.fileContent = `
+[Main]
+MinSDKVersions = GoClient/latest
+`
+	return c
+}
+
+func makeInvalidConfigBadTrustedProxy() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "trusted proxy that is not an IP address or CIDR range"}
+	c.envVarsError = `invalid trusted proxy "10.0.0.0/99"`
+	c.envVars = map[string]string{"TRUSTED_PROXIES": "10.0.0.0/99"}
+	c.fileContent = `
+[Main]
+TrustedProxies = 10.0.0.0/99
+`
+	return c
+}
+
 func makeInvalidConfigAutoConfKeyWithEnvironments() testDataInvalidConfig {
 	c := testDataInvalidConfig{name: "auto-conf key with environments"}
 	c.envVarsError = errAutoConfWithEnvironments.Error()
@@ -280,6 +822,77 @@ Url = "http://redishost:6400"
 	return c
 }
 
+func makeInvalidConfigRedisBadCompression() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "Redis - unknown compression type"}
+	c.envVarsError = errBadDataStoreCompression("Redis", "lzma").Error()
+	c.envVars = map[string]string{
+		"USE_REDIS":         "1",
+		"REDIS_COMPRESSION": "lzma",
+	}
+	c.fileContent = `
+[Redis]
+Host = "redishost"
+Compression = "lzma"
+`
+	return c
+}
+
+func makeInvalidConfigRedisZeroPurgeInterval() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "Redis - zero purge interval"}
+	c.envVarsError = "Redis purge interval must be greater than zero"
+	c.envVars = map[string]string{
+		"USE_REDIS":            "1",
+		"REDIS_PURGE_INTERVAL": "0s",
+	}
+	c.fileContent = `
+[Redis]
+Host = "redishost"
+PurgeInterval = 0s
+`
+	return c
+}
+
+func makeInvalidConfigDynamoDBZeroPurgeInterval() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "DynamoDB - zero purge interval"}
+	c.envVarsError = "DynamoDB purge interval must be greater than zero"
+	c.envVars = map[string]string{
+		"USE_DYNAMODB":            "1",
+		"DYNAMODB_PURGE_INTERVAL": "0s",
+	}
+	c.fileContent = `
+[DynamoDB]
+Enabled = true
+PurgeInterval = 0s
+`
+	return c
+}
+
+func makeInvalidConfigEventsBadCompression() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "events - unknown compression type"}
+	c.envVarsError = errBadEventsCompression("lzma").Error()
+	c.envVars = map[string]string{
+		"EVENTS_COMPRESSION": "lzma",
+	}
+	c.fileContent = `
+[Events]
+Compression = "lzma"
+`
+	return c
+}
+
+func makeInvalidConfigEventsBadUnknownFlags() testDataInvalidConfig {
+	c := testDataInvalidConfig{name: "events - unknown mode for unknown flags"}
+	c.envVarsError = errBadEventsUnknownFlags("ignore").Error()
+	c.envVars = map[string]string{
+		"EVENTS_UNKNOWN_FLAGS": "ignore",
+	}
+	c.fileContent = `
+[Events]
+UnknownFlags = "ignore"
+`
+	return c
+}
+
 func makeInvalidConfigRedisNoPrefix() testDataInvalidConfig {
 	c := testDataInvalidConfig{name: "Redis - multiple environments, prefix not defined"}
 	c.envVarsError = errEnvWithoutDBDisambiguation("env2", false).Error()
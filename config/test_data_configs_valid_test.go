@@ -11,6 +11,7 @@ import (
 	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 )
 
 type testDataValidConfig struct {
@@ -87,6 +88,8 @@ func makeValidConfigs() []testDataValidConfig {
 		makeValidConfigStackdriverAll(),
 		makeValidConfigPrometheusMinimal(),
 		makeValidConfigPrometheusAll(),
+		makeValidConfigOpenTelemetryMinimal(),
+		makeValidConfigOpenTelemetryAll(),
 		makeValidConfigProxy(),
 	}
 }
@@ -101,10 +104,27 @@ func makeValidConfigAllBaseProperties() testDataValidConfig {
 			StreamURI:                  newOptURLAbsoluteMustBeValid("http://stream"),
 			ExitOnError:                true,
 			ExitAlways:                 true,
+			RequireAllEnvironments:     true,
 			IgnoreConnectionErrors:     true,
 			HeartbeatInterval:          ct.NewOptDuration(90 * time.Second),
 			MaxClientConnectionTime:    ct.NewOptDuration(30 * time.Minute),
+			MaxClientConnectionJitter:  ct.NewOptDuration(5 * time.Minute),
+			StreamFlagValues:           true,
+			MobilePollFallback:         true,
+			MobileLongPollTimeout:      ct.NewOptDuration(45 * time.Second),
+			MaxSSEMessageSize:          mustOptIntGreaterThanZero(1000000),
+			MaxTotalConnections:        mustOptIntGreaterThanZero(5000),
+			MaxConnectionWait:          ct.NewOptDuration(2 * time.Second),
+			LogConnections:             true,
 			DisconnectedStatusTime:     ct.NewOptDuration(3 * time.Minute),
+			ConfigSourceStaleTime:      ct.NewOptDuration(10 * time.Minute),
+			ReportCachedStatus:         true,
+			StatusKeyDisplay:           StatusKeyDisplayHidden,
+			ReadTimeout:                ct.NewOptDuration(20 * time.Second),
+			ReadHeaderTimeout:          ct.NewOptDuration(5 * time.Second),
+			WriteTimeout:               ct.NewOptDuration(30 * time.Second),
+			IdleTimeout:                ct.NewOptDuration(2 * time.Minute),
+			MaxHeaderBytes:             mustOptIntGreaterThanZero(8192),
 			TLSEnabled:                 true,
 			TLSCert:                    "cert",
 			TLSKey:                     "key",
@@ -112,6 +132,41 @@ func makeValidConfigAllBaseProperties() testDataValidConfig {
 			LogLevel:                   NewOptLogLevel(ldlog.Warn),
 			BigSegmentsStaleAsDegraded: true,
 			BigSegmentsStaleThreshold:  ct.NewOptDuration(10 * time.Minute),
+			BigSegmentsMaxPatchRate:    mustOptIntGreaterThanZero(100),
+			VerifyStoreOnInit:          true,
+			VerifyStoreMinFlags:        mustOptIntGreaterThanZero(10),
+			MaxFlagCount:               mustOptIntGreaterThanZero(20000),
+			EnforceMaxFlagCount:        true,
+			SendDataAgeHeader:          true,
+			RetryWhileInitializing:     true,
+			MaintenanceMode:            true,
+			EnableMaintenanceEndpoint:  true,
+			AdminKey:                   "admin-key",
+			RequireAdminKeyForStatus:   true,
+			FlagHistoryDepth:           mustOptIntGreaterThanZero(5),
+			StoreWriteAttempts:         mustOptIntGreaterThanZero(5),
+			StoreWriteRetryDelay:       ct.NewOptDuration(250 * time.Millisecond),
+			StoreBreakerFailures:       mustOptIntGreaterThanZero(5),
+			StoreBreakerLatency:        ct.NewOptDuration(2 * time.Second),
+			StoreBreakerOpenTime:       ct.NewOptDuration(30 * time.Second),
+			MaxReportBodySize:          ct.NewOptInt(65536),
+			StartupJitter:              ct.NewOptDuration(10 * time.Second),
+			MaxConcurrentInits:         mustOptIntGreaterThanZero(5),
+			InitialReconnectDelay:      ct.NewOptDuration(3 * time.Second),
+			AllowedMethods:             ct.NewOptStringList([]string{"GET", "REPORT"}),
+			IgnoreTrailingSlash:        true,
+			IgnorePathCase:             true,
+			CORSAllowedHeaders:         ct.NewOptStringList([]string{"X-My-Wrapper", "X-Other"}),
+			CORSAllowedMethods:         ct.NewOptStringList([]string{"GET", "REPORT", "OPTIONS"}),
+			AdminPort:                  mustOptIntGreaterThanZero(8334),
+			GRPCPort:                   mustOptIntGreaterThanZero(8335),
+			ResponseHeaders:            ct.NewOptStringList([]string{"X-Frame-Options: SAMEORIGIN", "Referrer-Policy: no-referrer"}),
+			NoDefaultResponseHeaders:   true,
+			MinSDKVersions:             ct.NewOptStringList([]string{"GoClient/6.0.0", "JSClient/3"}),
+			RejectUnknownSDKVersions:   true,
+			MobilePingCoalesceTime:     ct.NewOptDuration(500 * time.Millisecond),
+			JSClientPingCoalesceTime:   ct.NewOptDuration(2 * time.Second),
+			TrustedProxies:             ct.NewOptStringList([]string{"10.0.0.0/8", "192.168.1.1"}),
 		}
 		c.Events = EventsConfig{
 			SendEvents:    true,
@@ -119,67 +174,174 @@ func makeValidConfigAllBaseProperties() testDataValidConfig {
 			FlushInterval: ct.NewOptDuration(120 * time.Second),
 			Capacity:      mustOptIntGreaterThanZero(500),
 			InlineUsers:   true,
+			RecentBatches: mustOptIntGreaterThanZero(20),
+			MaxBatchSize:  mustOptIntGreaterThanZero(1000),
+			Compression:   EventsCompressionGzip,
+			UnknownFlags:  EventsUnknownFlagsDrop,
+
+			DisableImageEndpoint:    true,
+			MaxImageDataSize:        mustOptIntGreaterThanZero(4096),
+			MaxDecompressedSize:     mustOptIntGreaterThanZero(1000000),
+			AugmentDiagnosticEvents: true,
 		}
 		c.Environment = map[string]*EnvConfig{
 			"earth": {
-				SDKKey:    "earth-sdk",
-				MobileKey: "earth-mob",
-				EnvID:     "earth-env",
-				Prefix:    "earth-",
-				TableName: "earth-table",
-				LogLevel:  NewOptLogLevel(ldlog.Debug),
+				SDKKey:        "earth-sdk",
+				MobileKey:     "earth-mob",
+				EnvID:         "earth-env",
+				Prefix:        "earth-",
+				TableName:     "earth-table",
+				LogLevel:      NewOptLogLevel(ldlog.Debug),
+				ProxyDisabled: true,
 			},
 			"krypton": {
-				SDKKey:        "krypton-sdk",
-				MobileKey:     "krypton-mob",
-				EnvID:         "krypton-env",
-				SecureMode:    true,
-				Prefix:        "krypton-",
-				TableName:     "krypton-table",
-				AllowedOrigin: ct.NewOptStringList([]string{"https://oa", "https://rann"}),
-				AllowedHeader: ct.NewOptStringList([]string{"Timestamp-Valid", "Random-Id-Valid"}),
-				TTL:           ct.NewOptDuration(5 * time.Minute),
+				SDKKey:               "krypton-sdk",
+				MobileKey:            "krypton-mob",
+				EnvID:                "krypton-env",
+				SecureMode:           true,
+				Prefix:               "krypton-",
+				TableName:            "krypton-table",
+				AllowedOrigin:        ct.NewOptStringList([]string{"https://oa", "https://rann"}),
+				AllowedHeader:        ct.NewOptStringList([]string{"Timestamp-Valid", "Random-Id-Valid"}),
+				EventForwardingURI:   ct.NewOptStringList([]string{"https://sink1", "https://sink2"}),
+				TTL:                  ct.NewOptDuration(5 * time.Minute),
+				Group:                "superheroes",
+				StartupFlags:         ct.NewOptStringList([]string{"flight", "x-ray-vision"}),
+				ContextKindPrefixes:  ct.NewOptStringList([]string{"user:hero-", "device:"}),
+				RedactedFlags:        ct.NewOptStringList([]string{"secret-identity", "fortress-location"}),
+				FallbackFlagValues:   NewOptFlagValues(map[string]ldvalue.Value{"flight": ldvalue.Bool(true), "x-ray-vision": ldvalue.String("off")}),
+				TLSServerName:        "krypton.example.com",
+				ProxyURL:             newOptURLAbsoluteMustBeValid("http://krypton-proxy:8080"),
+				ProxyNTLMAuth:        true,
+				ProxyUser:            "kal",
+				ProxyPassword:        "el",
+				ProxyDomain:          "krypton",
+				ProxyCACertFiles:     ct.NewOptStringList([]string{"krypton-ca.pem"}),
+				StrictEvents:         true,
+				EventsFlushInterval:  ct.NewOptDuration(30 * time.Second),
+				BigSegmentsTableName: "krypton-big-segments",
+				ChangeWebhookURL:     newOptURLAbsoluteMustBeValid("https://hooks.krypton.example.com/flags"),
+				ChangeWebhookSecret:  "jor-el",
 			},
 		}
 	}
 	c.envVars = map[string]string{
-		"PORT":                           "8333",
-		"BASE_URI":                       "http://base",
-		"CLIENT_SIDE_BASE_URI":           "http://clientbase",
-		"STREAM_URI":                     "http://stream",
-		"EXIT_ON_ERROR":                  "1",
-		"EXIT_ALWAYS":                    "1",
-		"IGNORE_CONNECTION_ERRORS":       "1",
-		"HEARTBEAT_INTERVAL":             "90s",
-		"MAX_CLIENT_CONNECTION_TIME":     "30m",
-		"DISCONNECTED_STATUS_TIME":       "3m",
-		"TLS_ENABLED":                    "1",
-		"TLS_CERT":                       "cert",
-		"TLS_KEY":                        "key",
-		"TLS_MIN_VERSION":                "1.2",
-		"LOG_LEVEL":                      "warn",
-		"BIG_SEGMENTS_STALE_AS_DEGRADED": "true",
-		"BIG_SEGMENTS_STALE_THRESHOLD":   "10m",
-		"USE_EVENTS":                     "1",
-		"EVENTS_HOST":                    "http://events",
-		"EVENTS_FLUSH_INTERVAL":          "120s",
-		"EVENTS_CAPACITY":                "500",
-		"EVENTS_INLINE_USERS":            "1",
-		"LD_ENV_earth":                   "earth-sdk",
-		"LD_MOBILE_KEY_earth":            "earth-mob",
-		"LD_CLIENT_SIDE_ID_earth":        "earth-env",
-		"LD_PREFIX_earth":                "earth-",
-		"LD_TABLE_NAME_earth":            "earth-table",
-		"LD_LOG_LEVEL_earth":             "debug",
-		"LD_ENV_krypton":                 "krypton-sdk",
-		"LD_MOBILE_KEY_krypton":          "krypton-mob",
-		"LD_CLIENT_SIDE_ID_krypton":      "krypton-env",
-		"LD_SECURE_MODE_krypton":         "1",
-		"LD_PREFIX_krypton":              "krypton-",
-		"LD_TABLE_NAME_krypton":          "krypton-table",
-		"LD_ALLOWED_ORIGIN_krypton":      "https://oa,https://rann",
-		"LD_ALLOWED_HEADER_krypton":      "Timestamp-Valid,Random-Id-Valid",
-		"LD_TTL_krypton":                 "5m",
+		"PORT":                               "8333",
+		"BASE_URI":                           "http://base",
+		"CLIENT_SIDE_BASE_URI":               "http://clientbase",
+		"STREAM_URI":                         "http://stream",
+		"EXIT_ON_ERROR":                      "1",
+		"EXIT_ALWAYS":                        "1",
+		"REQUIRE_ALL_ENVIRONMENTS":           "1",
+		"IGNORE_CONNECTION_ERRORS":           "1",
+		"HEARTBEAT_INTERVAL":                 "90s",
+		"MAX_CLIENT_CONNECTION_TIME":         "30m",
+		"MAX_CLIENT_CONNECTION_JITTER":       "5m",
+		"STREAM_FLAG_VALUES":                 "1",
+		"MOBILE_POLL_FALLBACK":               "1",
+		"MOBILE_LONG_POLL_TIMEOUT":           "45s",
+		"MAX_SSE_MESSAGE_SIZE":               "1000000",
+		"MAX_TOTAL_CONNECTIONS":              "5000",
+		"MAX_CONNECTION_WAIT":                "2s",
+		"LOG_CONNECTIONS":                    "1",
+		"DISCONNECTED_STATUS_TIME":           "3m",
+		"CONFIG_SOURCE_STALE_TIME":           "10m",
+		"REPORT_CACHED_STATUS":               "1",
+		"STATUS_KEY_DISPLAY":                 "hidden",
+		"READ_TIMEOUT":                       "20s",
+		"READ_HEADER_TIMEOUT":                "5s",
+		"WRITE_TIMEOUT":                      "30s",
+		"IDLE_TIMEOUT":                       "2m",
+		"MAX_HEADER_BYTES":                   "8192",
+		"TLS_ENABLED":                        "1",
+		"TLS_CERT":                           "cert",
+		"TLS_KEY":                            "key",
+		"TLS_MIN_VERSION":                    "1.2",
+		"LOG_LEVEL":                          "warn",
+		"BIG_SEGMENTS_STALE_AS_DEGRADED":     "true",
+		"BIG_SEGMENTS_STALE_THRESHOLD":       "10m",
+		"BIG_SEGMENTS_MAX_PATCH_RATE":        "100",
+		"VERIFY_STORE_ON_INIT":               "1",
+		"VERIFY_STORE_MIN_FLAGS":             "10",
+		"MAX_FLAG_COUNT":                     "20000",
+		"ENFORCE_MAX_FLAG_COUNT":             "1",
+		"SEND_DATA_AGE_HEADER":               "1",
+		"RETRY_WHILE_INITIALIZING":           "1",
+		"MAINTENANCE_MODE":                   "1",
+		"ENABLE_MAINTENANCE_ENDPOINT":        "1",
+		"ADMIN_KEY":                          "admin-key",
+		"REQUIRE_ADMIN_KEY_FOR_STATUS":       "1",
+		"FLAG_HISTORY_DEPTH":                 "5",
+		"STORE_WRITE_ATTEMPTS":               "5",
+		"STORE_WRITE_RETRY_DELAY":            "250ms",
+		"STORE_BREAKER_FAILURES":             "5",
+		"STORE_BREAKER_LATENCY":              "2s",
+		"STORE_BREAKER_OPEN_TIME":            "30s",
+		"MAX_REPORT_BODY_SIZE":               "65536",
+		"STARTUP_JITTER":                     "10s",
+		"MAX_CONCURRENT_INITS":               "5",
+		"INITIAL_RECONNECT_DELAY":            "3s",
+		"ALLOWED_METHODS":                    "GET,REPORT",
+		"IGNORE_TRAILING_SLASH":              "1",
+		"IGNORE_PATH_CASE":                   "1",
+		"CORS_ALLOWED_HEADERS":               "X-My-Wrapper,X-Other",
+		"CORS_ALLOWED_METHODS":               "GET,REPORT,OPTIONS",
+		"ADMIN_PORT":                         "8334",
+		"GRPC_PORT":                          "8335",
+		"RESPONSE_HEADERS":                   "X-Frame-Options: SAMEORIGIN,Referrer-Policy: no-referrer",
+		"NO_DEFAULT_RESPONSE_HEADERS":        "1",
+		"MIN_SDK_VERSIONS":                   "GoClient/6.0.0,JSClient/3",
+		"REJECT_UNKNOWN_SDK_VERSIONS":        "1",
+		"MOBILE_PING_COALESCE_TIME":          "500ms",
+		"JS_CLIENT_PING_COALESCE_TIME":       "2s",
+		"TRUSTED_PROXIES":                    "10.0.0.0/8,192.168.1.1",
+		"USE_EVENTS":                         "1",
+		"EVENTS_HOST":                        "http://events",
+		"EVENTS_FLUSH_INTERVAL":              "120s",
+		"EVENTS_CAPACITY":                    "500",
+		"EVENTS_INLINE_USERS":                "1",
+		"EVENTS_RECENT_BATCHES":              "20",
+		"EVENTS_MAX_BATCH_SIZE":              "1000",
+		"EVENTS_COMPRESSION":                 "gzip",
+		"EVENTS_UNKNOWN_FLAGS":               "drop",
+		"EVENTS_DISABLE_IMAGE_ENDPOINT":      "1",
+		"EVENTS_MAX_IMAGE_DATA_SIZE":         "4096",
+		"EVENTS_MAX_DECOMPRESSED_SIZE":       "1000000",
+		"EVENTS_AUGMENT_DIAGNOSTIC_EVENTS":   "1",
+		"LD_ENV_earth":                       "earth-sdk",
+		"LD_MOBILE_KEY_earth":                "earth-mob",
+		"LD_CLIENT_SIDE_ID_earth":            "earth-env",
+		"LD_PREFIX_earth":                    "earth-",
+		"LD_TABLE_NAME_earth":                "earth-table",
+		"LD_LOG_LEVEL_earth":                 "debug",
+		"LD_PROXY_DISABLED_earth":            "1",
+		"LD_ENV_krypton":                     "krypton-sdk",
+		"LD_MOBILE_KEY_krypton":              "krypton-mob",
+		"LD_CLIENT_SIDE_ID_krypton":          "krypton-env",
+		"LD_SECURE_MODE_krypton":             "1",
+		"LD_PREFIX_krypton":                  "krypton-",
+		"LD_TABLE_NAME_krypton":              "krypton-table",
+		"LD_ALLOWED_ORIGIN_krypton":          "https://oa,https://rann",
+		"LD_ALLOWED_HEADER_krypton":          "Timestamp-Valid,Random-Id-Valid",
+		"LD_EVENT_FORWARDING_URI_krypton":    "https://sink1,https://sink2",
+		"LD_TTL_krypton":                     "5m",
+		"LD_GROUP_krypton":                   "superheroes",
+		"LD_STARTUP_FLAGS_krypton":           "flight,x-ray-vision",
+		"LD_CONTEXT_KIND_PREFIXES_krypton":   "user:hero-,device:",
+		"LD_REDACTED_FLAGS_krypton":          "secret-identity,fortress-location",
+		"LD_FALLBACK_FLAG_VALUES_krypton":    `{"flight": true, "x-ray-vision": "off"}`,
+		"LD_TLS_SERVER_NAME_krypton":         "krypton.example.com",
+		"LD_PROXY_URL_krypton":               "http://krypton-proxy:8080",
+		"LD_PROXY_AUTH_NTLM_krypton":         "1",
+		"LD_PROXY_AUTH_USER_krypton":         "kal",
+		"LD_PROXY_AUTH_PASSWORD_krypton":     "el",
+		"LD_PROXY_AUTH_DOMAIN_krypton":       "krypton",
+		"LD_PROXY_CA_CERTS_krypton":          "krypton-ca.pem",
+		"LD_STRICT_EVENTS_krypton":           "1",
+		"LD_EVENTS_FLUSH_INTERVAL_krypton":   "30s",
+		"LD_BIG_SEGMENTS_TABLE_NAME_krypton": "krypton-big-segments",
+		"LD_CHANGE_WEBHOOK_URL_krypton":      "https://hooks.krypton.example.com/flags",
+		"LD_CHANGE_WEBHOOK_SECRET_krypton":   "jor-el",
 	}
 	c.fileContent = `
 [Main]
@@ -189,10 +351,27 @@ ClientSideBaseUri = "http://clientbase"
 StreamUri = "http://stream"
 ExitOnError = 1
 ExitAlways = 1
+RequireAllEnvironments = 1
 IgnoreConnectionErrors = 1
 HeartbeatInterval = 90s
 MaxClientConnectionTime = 30m
+MaxClientConnectionJitter = 5m
+StreamFlagValues = 1
+MobilePollFallback = 1
+MobileLongPollTimeout = 45s
+MaxSSEMessageSize = 1000000
+MaxTotalConnections = 5000
+MaxConnectionWait = 2s
+LogConnections = 1
 DisconnectedStatusTime = 3m
+ConfigSourceStaleTime = 10m
+ReportCachedStatus = 1
+StatusKeyDisplay = "hidden"
+ReadTimeout = 20s
+ReadHeaderTimeout = 5s
+WriteTimeout = 30s
+IdleTimeout = 2m
+MaxHeaderBytes = 8192
 TLSEnabled = 1
 TLSCert = "cert"
 TLSKey = "key"
@@ -200,6 +379,46 @@ TLSMinVersion = "1.2"
 LogLevel = "warn"
 BigSegmentsStaleAsDegraded = 1
 BigSegmentsStaleThreshold = 10m
+BigSegmentsMaxPatchRate = 100
+VerifyStoreOnInit = 1
+VerifyStoreMinFlags = 10
+MaxFlagCount = 20000
+EnforceMaxFlagCount = 1
+SendDataAgeHeader = 1
+RetryWhileInitializing = 1
+MaintenanceMode = 1
+EnableMaintenanceEndpoint = 1
+AdminKey = "admin-key"
+RequireAdminKeyForStatus = 1
+FlagHistoryDepth = 5
+StoreWriteAttempts = 5
+StoreWriteRetryDelay = 250ms
+StoreBreakerFailures = 5
+StoreBreakerLatency = 2s
+StoreBreakerOpenTime = 30s
+MaxReportBodySize = 65536
+StartupJitter = 10s
+MaxConcurrentInits = 5
+InitialReconnectDelay = 3s
+AllowedMethods = GET
+AllowedMethods = REPORT
+IgnoreTrailingSlash = 1
+IgnorePathCase = 1
+CORSAllowedHeaders = X-My-Wrapper
+CORSAllowedHeaders = X-Other
+CORSAllowedMethods = GET,REPORT,OPTIONS
+AdminPort = 8334
+GRPCPort = 8335
+ResponseHeaders = X-Frame-Options: SAMEORIGIN
+ResponseHeaders = Referrer-Policy: no-referrer
+NoDefaultResponseHeaders = true
+MinSDKVersions = GoClient/6.0.0
+MinSDKVersions = JSClient/3
+RejectUnknownSDKVersions = true
+MobilePingCoalesceTime = 500ms
+JSClientPingCoalesceTime = 2s
+TrustedProxies = 10.0.0.0/8
+TrustedProxies = 192.168.1.1
 
 [Events]
 SendEvents = 1
@@ -207,6 +426,14 @@ EventsUri = "http://events"
 FlushInterval = 120s
 Capacity = 500
 InlineUsers = 1
+RecentBatches = 20
+MaxBatchSize = 1000
+Compression = "gzip"
+UnknownFlags = "drop"
+DisableImageEndpoint = 1
+MaxImageDataSize = 4096
+MaxDecompressedSize = 1000000
+AugmentDiagnosticEvents = 1
 
 [Environment "earth"]
 SdkKey = "earth-sdk"
@@ -215,6 +442,7 @@ EnvId = "earth-env"
 Prefix = "earth-"
 TableName = "earth-table"
 LogLevel = "debug"
+ProxyDisabled = 1
 
 [Environment "krypton"]
 SdkKey = "krypton-sdk"
@@ -227,7 +455,29 @@ AllowedOrigin = "https://oa"
 AllowedOrigin = "https://rann"
 AllowedHeader = "Timestamp-Valid"
 AllowedHeader = "Random-Id-Valid"
+EventForwardingUri = "https://sink1"
+EventForwardingUri = "https://sink2"
 TTL = 5m
+Group = "superheroes"
+StartupFlags = "flight"
+StartupFlags = "x-ray-vision"
+ContextKindPrefixes = "user:hero-"
+ContextKindPrefixes = "device:"
+RedactedFlags = "secret-identity"
+RedactedFlags = "fortress-location"
+FallbackFlagValues = "{\"flight\": true, \"x-ray-vision\": \"off\"}"
+TLSServerName = "krypton.example.com"
+ProxyURL = "http://krypton-proxy:8080"
+ProxyNTLMAuth = 1
+ProxyUser = "kal"
+ProxyPassword = "el"
+ProxyDomain = "krypton"
+ProxyCACertFiles = "krypton-ca.pem"
+StrictEvents = true
+EventsFlushInterval = 30s
+BigSegmentsTableName = "krypton-big-segments"
+ChangeWebhookURL = "https://hooks.krypton.example.com/flags"
+ChangeWebhookSecret = "jor-el"
 `
 	return c
 }
@@ -236,15 +486,17 @@ func makeValidConfigAutoConfig() testDataValidConfig {
 	c := testDataValidConfig{name: "auto-config properties"}
 	c.makeConfig = func(c *Config) {
 		c.AutoConfig = AutoConfigConfig{
-			Key:              AutoConfigKey("autokey"),
-			EnvAllowedOrigin: ct.NewOptStringList([]string{"http://first", "http://second"}),
-			EnvAllowedHeader: ct.NewOptStringList([]string{"First", "Second"}),
+			Key:                  AutoConfigKey("autokey"),
+			EnvAllowedOrigin:     ct.NewOptStringList([]string{"http://first", "http://second"}),
+			EnvAllowedHeader:     ct.NewOptStringList([]string{"First", "Second"}),
+			KeyExpiryGracePeriod: ct.NewOptDuration(time.Hour),
 		}
 	}
 	c.envVars = map[string]string{
-		"AUTO_CONFIG_KEY":    "autokey",
-		"ENV_ALLOWED_ORIGIN": "http://first,http://second",
-		"ENV_ALLOWED_HEADER": "First,Second",
+		"AUTO_CONFIG_KEY":         "autokey",
+		"ENV_ALLOWED_ORIGIN":      "http://first,http://second",
+		"ENV_ALLOWED_HEADER":      "First,Second",
+		"KEY_EXPIRY_GRACE_PERIOD": "1h",
 	}
 	c.fileContent = `
 [AutoConfig]
@@ -253,6 +505,7 @@ EnvAllowedOrigin = http://first
 EnvAllowedOrigin = http://second
 EnvAllowedHeader = First
 EnvAllowedHeader = Second
+KeyExpiryGracePeriod = 1h
 `
 	return c
 }
@@ -372,21 +625,27 @@ func makeValidConfigRedisAll() testDataValidConfig {
 	c := testDataValidConfig{name: "Redis - all parameters"}
 	c.makeConfig = func(c *Config) {
 		c.Redis = RedisConfig{
-			URL:      newOptURLAbsoluteMustBeValid("redis://redishost:6400"),
-			LocalTTL: ct.NewOptDuration(3 * time.Second),
-			TLS:      true,
-			Password: "pass",
-			Username: "user",
+			URL:           newOptURLAbsoluteMustBeValid("redis://redishost:6400"),
+			LocalTTL:      ct.NewOptDuration(3 * time.Second),
+			TLS:           true,
+			Password:      "pass",
+			Username:      "user",
+			Compression:   DataStoreCompressionGzip,
+			PurgeInterval: ct.NewOptDuration(time.Hour),
+			PurgeAge:      ct.NewOptDuration(48 * time.Hour),
 		}
 	}
 	c.envVars = map[string]string{
-		"USE_REDIS":      "1",
-		"REDIS_HOST":     "redishost",
-		"REDIS_PORT":     "6400",
-		"REDIS_TLS":      "1",
-		"REDIS_PASSWORD": "pass",
-		"REDIS_USERNAME": "user",
-		"CACHE_TTL":      "3s",
+		"USE_REDIS":            "1",
+		"REDIS_HOST":           "redishost",
+		"REDIS_PORT":           "6400",
+		"REDIS_TLS":            "1",
+		"REDIS_PASSWORD":       "pass",
+		"REDIS_USERNAME":       "user",
+		"REDIS_COMPRESSION":    "gzip",
+		"REDIS_PURGE_INTERVAL": "1h",
+		"REDIS_PURGE_AGE":      "48h",
+		"CACHE_TTL":            "3s",
 	}
 	c.fileContent = `
 [Redis]
@@ -395,6 +654,9 @@ Port = 6400
 TLS = 1
 Password = "pass"
 Username = "user"
+Compression = "gzip"
+PurgeInterval = 1h
+PurgeAge = 48h
 LocalTTL = 3s
 `
 	return c
@@ -602,23 +864,32 @@ func makeValidConfigDynamoDBAll() testDataValidConfig {
 	c := testDataValidConfig{name: "DynamoDB - all parameters"}
 	c.makeConfig = func(c *Config) {
 		c.DynamoDB = DynamoDBConfig{
-			Enabled:   true,
-			TableName: "table",
-			URL:       newOptURLAbsoluteMustBeValid("http://localhost:8000"),
-			LocalTTL:  ct.NewOptDuration(3 * time.Second),
+			Enabled:       true,
+			TableName:     "table",
+			URL:           newOptURLAbsoluteMustBeValid("http://localhost:8000"),
+			LocalTTL:      ct.NewOptDuration(3 * time.Second),
+			Compression:   DataStoreCompressionGzip,
+			PurgeInterval: ct.NewOptDuration(time.Hour),
+			PurgeAge:      ct.NewOptDuration(48 * time.Hour),
 		}
 	}
 	c.envVars = map[string]string{
-		"USE_DYNAMODB":   "1",
-		"DYNAMODB_TABLE": "table",
-		"DYNAMODB_URL":   "http://localhost:8000",
-		"CACHE_TTL":      "3s",
+		"USE_DYNAMODB":            "1",
+		"DYNAMODB_TABLE":          "table",
+		"DYNAMODB_URL":            "http://localhost:8000",
+		"DYNAMODB_COMPRESSION":    "gzip",
+		"DYNAMODB_PURGE_INTERVAL": "1h",
+		"DYNAMODB_PURGE_AGE":      "48h",
+		"CACHE_TTL":               "3s",
 	}
 	c.fileContent = `
 [DynamoDB]
 Enabled = true
 TableName = "table"
 URL = "http://localhost:8000"
+Compression = "gzip"
+PurgeInterval = 1h
+PurgeAge = 48h
 LocalTTL = 3s
 `
 	return c
@@ -810,6 +1081,56 @@ Port = 8333
 	return c
 }
 
+func makeValidConfigOpenTelemetryMinimal() testDataValidConfig {
+	c := testDataValidConfig{name: "OpenTelemetry - minimal parameters"}
+	c.makeConfig = func(c *Config) {
+		c.OpenTelemetry = OpenTelemetryConfig{
+			Enabled:  true,
+			Endpoint: newOptURLAbsoluteMustBeValid("http://localhost:4318"),
+		}
+	}
+	c.envVars = map[string]string{
+		"USE_OPENTELEMETRY":      "1",
+		"OPENTELEMETRY_ENDPOINT": "http://localhost:4318",
+	}
+	c.fileContent = `
+[OpenTelemetry]
+Enabled = true
+Endpoint = "http://localhost:4318"
+`
+	return c
+}
+
+func makeValidConfigOpenTelemetryAll() testDataValidConfig {
+	c := testDataValidConfig{name: "OpenTelemetry - all parameters"}
+	c.makeConfig = func(c *Config) {
+		c.OpenTelemetry = OpenTelemetryConfig{
+			Enabled:     true,
+			Endpoint:    newOptURLAbsoluteMustBeValid("http://localhost:4318"),
+			Headers:     ct.NewOptStringList([]string{"Authorization: Bearer xyz", "X-Team: relay"}),
+			ServiceName: "my-relay",
+			SampleRate:  ct.NewOptFloat64(0.25),
+		}
+	}
+	c.envVars = map[string]string{
+		"USE_OPENTELEMETRY":          "1",
+		"OPENTELEMETRY_ENDPOINT":     "http://localhost:4318",
+		"OPENTELEMETRY_HEADERS":      "Authorization: Bearer xyz,X-Team: relay",
+		"OPENTELEMETRY_SERVICE_NAME": "my-relay",
+		"OPENTELEMETRY_SAMPLE_RATE":  "0.25",
+	}
+	c.fileContent = `
+[OpenTelemetry]
+Enabled = true
+Endpoint = "http://localhost:4318"
+Headers = "Authorization: Bearer xyz"
+Headers = "X-Team: relay"
+ServiceName = "my-relay"
+SampleRate = 0.25
+`
+	return c
+}
+
 func makeValidConfigProxy() testDataValidConfig {
 	c := testDataValidConfig{name: "proxy"}
 	c.makeConfig = func(c *Config) {
@@ -820,6 +1141,7 @@ func makeValidConfigProxy() testDataValidConfig {
 			Domain:      "domain",
 			NTLMAuth:    true,
 			CACertFiles: ct.NewOptStringList([]string{"cert"}),
+			DNSCacheTTL: ct.NewOptDuration(5 * time.Minute),
 		}
 	}
 	c.envVars = map[string]string{
@@ -829,6 +1151,7 @@ func makeValidConfigProxy() testDataValidConfig {
 		"PROXY_AUTH_DOMAIN":   "domain",
 		"PROXY_AUTH_NTLM":     "1",
 		"PROXY_CA_CERTS":      "cert",
+		"DNS_CACHE_TTL":       "5m",
 	}
 	c.fileContent = `
 [Proxy]
@@ -838,6 +1161,7 @@ Password = "pass"
 Domain = "domain"
 NTLMAuth = true
 CaCertFiles = "cert"
+DnsCacheTTL = 5m
 `
 	return c
 }
@@ -214,7 +214,9 @@ func doBigSegmentsTestWithPreExistingSegment(
 			// It might or might not be reporting the status as valid yet, depending on whether a poll
 			// has happened.
 			for _, e := range status.Environments {
-				assert.NotNil(t, e.BigSegmentStatus)
+				if assert.NotNil(t, e.BigSegmentStatus) {
+					assert.True(t, e.BigSegmentStatus.Enabled)
+				}
 			}
 			verifyEvaluationWithBigSegment(t, manager, projectInfo, environments, flagKey, segmentKey, segmentTestData)
 		})
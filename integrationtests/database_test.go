@@ -51,10 +51,12 @@ func doDatabaseTest(
 
 		dbParams.withStartedRelay(t, manager, dbContainer, environments, nil, func(status api.StatusRep) {
 			// withStartedRelay has already verified that it started up correctly and is reporting
-			// a valid data store status; we'll just additionally verify that it is *not* reporting
-			// any Big Segment status, since there isn't any Big Segments data
+			// a valid data store status; we'll just additionally verify that, if the database supports
+			// Big Segments, it is reporting them as not available, since there isn't any Big Segments data
 			for _, e := range status.Environments {
-				assert.Nil(t, e.BigSegmentStatus)
+				if assert.NotNil(t, e.BigSegmentStatus) && e.BigSegmentStatus.Enabled {
+					assert.False(t, e.BigSegmentStatus.Available)
+				}
 			}
 		})
 	})
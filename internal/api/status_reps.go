@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 )
@@ -13,13 +15,55 @@ type StatusRep struct {
 	Status        string                          `json:"status"`
 	Version       string                          `json:"version"`
 	ClientVersion string                          `json:"clientVersion"`
+	Connections   ConnectionsStatusRep            `json:"connections"`
+	ConfigSource  *ConfigSourceStatusRep          `json:"configSource,omitempty"`
+}
+
+// GroupedStatusRep is the JSON representation returned by the status endpoint if the "group" query
+// parameter is true. Instead of the status of each environment, it has a summary for each group of
+// environments.
+//
+// This is exported for use in integration test code.
+type GroupedStatusRep struct {
+	Groups        map[string]GroupStatusRep `json:"groups"`
+	Status        string                    `json:"status"`
+	Version       string                    `json:"version"`
+	ClientVersion string                    `json:"clientVersion"`
+	Connections   ConnectionsStatusRep      `json:"connections"`
+}
+
+// GroupStatusRep is the per-group JSON representation in GroupedStatusRep. Environments that do not
+// have a group are counted under an empty group name.
+//
+// This is exported for use in integration test code.
+type GroupStatusRep struct {
+	Connected int `json:"connected"`
+	Total     int `json:"total"`
+}
+
+// ConnectionsStatusRep describes the stream connections that are currently open to Relay, across all
+// environments.
+//
+// This is exported for use in integration test code.
+type ConnectionsStatusRep struct {
+	Current int `json:"current"`
+	Limit   int `json:"limit,omitempty"`
+}
+
+// ConfigSourceStatusRep describes the auto-configuration stream or file data source, if Relay is using
+// one. It is only present in the status resource if the source is currently failing to provide updates.
+//
+// This is exported for use in integration test code.
+type ConfigSourceStatusRep struct {
+	InterruptedSince ldtime.UnixMillisecondTime `json:"interruptedSince"`
+	Stale            bool                       `json:"stale"`
 }
 
 // EnvironmentStatusRep is the per-environment JSON representation returned by the status endpoint.
 //
 // This is exported for use in integration test code.
 type EnvironmentStatusRep struct {
-	SDKKey           string               `json:"sdkKey"`
+	SDKKey           string               `json:"sdkKey,omitempty"`
 	EnvID            string               `json:"envId,omitempty"`
 	EnvKey           string               `json:"envKey,omitempty"`
 	EnvName          string               `json:"envName,omitempty"`
@@ -27,21 +71,47 @@ type EnvironmentStatusRep struct {
 	ProjName         string               `json:"projName,omitempty"`
 	MobileKey        string               `json:"mobileKey,omitempty"`
 	ExpiringSDKKey   string               `json:"expiringSdkKey,omitempty"`
+	Group            string               `json:"group,omitempty"`
 	Status           string               `json:"status"`
 	ConnectionStatus ConnectionStatusRep  `json:"connectionStatus"`
 	DataStoreStatus  DataStoreStatusRep   `json:"dataStoreStatus"`
 	BigSegmentStatus *BigSegmentStatusRep `json:"bigSegmentStatus,omitempty"`
+	DataUpdates      DataUpdatesRep       `json:"dataUpdates"`
+	InitDuration     int64                `json:"initDuration,omitempty"`
+	InitError        string               `json:"initError,omitempty"`
+	FlagCount        *FlagCountRep        `json:"flagCount,omitempty"`
+}
+
+// FlagCountRep describes the number of flags in an environment relative to the configured limit. It is
+// only present in the status resource if a limit is configured.
+//
+// This is exported for use in integration test code.
+type FlagCountRep struct {
+	Count    int  `json:"count"`
+	Limit    int  `json:"limit"`
+	Exceeded bool `json:"exceeded"`
 }
 
 // BigSegmentStatusRep is the big segment status representation returned by the status endpoint.
 //
 // This is exported for use in integration test code.
 type BigSegmentStatusRep struct {
+	Enabled            bool                       `json:"enabled"`
 	Available          bool                       `json:"available"`
 	PotentiallyStale   bool                       `json:"potentiallyStale"`
 	LastSynchronizedOn ldtime.UnixMillisecondTime `json:"lastSynchronizedOn"`
 }
 
+// MarshalJSON omits all properties other than enabled if big segments are not enabled, since they would
+// not be meaningful.
+func (r BigSegmentStatusRep) MarshalJSON() ([]byte, error) {
+	if !r.Enabled {
+		return []byte(`{"enabled":false}`), nil
+	}
+	type bigSegmentStatusRepWithoutMarshaler BigSegmentStatusRep
+	return json.Marshal(bigSegmentStatusRepWithoutMarshaler(r))
+}
+
 // ConnectionStatusRep is the data source status representation returned by the status endpoint.
 //
 // This is exported for use in integration test code.
@@ -59,14 +129,24 @@ type ConnectionErrorRep struct {
 	Time ldtime.UnixMillisecondTime     `json:"time"`
 }
 
+// DataUpdatesRep is the number of individual flag and segment updates that an environment has received
+// from LaunchDarkly since it was created, as returned by the status endpoint.
+//
+// This is exported for use in integration test code.
+type DataUpdatesRep struct {
+	Flags    int64 `json:"flags"`
+	Segments int64 `json:"segments"`
+}
+
 // DataStoreStatusRep is the data store status representation returned by the status endpoint.
 //
 // This is exported for use in integration test code.
 type DataStoreStatusRep struct {
-	State      string                     `json:"state"`
-	StateSince ldtime.UnixMillisecondTime `json:"stateSince"`
-	Database   string                     `json:"database,omitempty"`
-	DBServer   string                     `json:"dbServer,omitempty"`
-	DBPrefix   string                     `json:"dbPrefix,omitempty"`
-	DBTable    string                     `json:"dbTable,omitempty"`
+	State          string                     `json:"state"`
+	StateSince     ldtime.UnixMillisecondTime `json:"stateSince"`
+	Database       string                     `json:"database,omitempty"`
+	DBServer       string                     `json:"dbServer,omitempty"`
+	DBPrefix       string                     `json:"dbPrefix,omitempty"`
+	DBTable        string                     `json:"dbTable,omitempty"`
+	CircuitBreaker string                     `json:"circuitBreaker,omitempty"`
 }
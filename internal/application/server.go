@@ -7,15 +7,29 @@ import (
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 )
 
+// ServerTimeouts contains the timeout properties for the HTTP server. A zero value means there is no
+// timeout, as in http.Server.
+type ServerTimeouts struct {
+	Read       time.Duration
+	ReadHeader time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
 // StartHTTPServer starts the server, with or without TLS. It returns immediately, starting the server
 // on a separate goroutine; if the server fails to start up, it sends an error to the error channel.
+//
+// If maxHeaderBytes is zero, the server uses the default limit from http.Server.
 func StartHTTPServer(
 	port int,
 	handler http.Handler,
+	timeouts ServerTimeouts,
+	maxHeaderBytes int,
 	tlsEnabled bool,
 	tlsCertFile, tlsKeyFile string,
 	tlsMinVersion uint16,
@@ -24,7 +38,12 @@ func StartHTTPServer(
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", port),
 		Handler:           handler,
-		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		MaxHeaderBytes:    maxHeaderBytes,
+		ConnContext:       middleware.WithConnectionCache,
 	}
 
 	if tlsEnabled && tlsMinVersion != 0 {
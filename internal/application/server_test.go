@@ -43,7 +43,7 @@ func withSelfSignedCert(t *testing.T, action func(certFilePath, keyFilePath stri
 func TestStartHTTPServerInsecure(t *testing.T) {
 	port := st.GetAvailablePort(t)
 	mockLog := ldlogtest.NewMockLog()
-	server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), false, "", "", 0, mockLog.Loggers)
+	server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), ServerTimeouts{}, 0, false, "", "", 0, mockLog.Loggers)
 	require.NotNil(t, server)
 	require.NotNil(t, errCh)
 	require.Eventually(t, func() bool {
@@ -60,7 +60,7 @@ func TestStartHTTPServerSecure(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 
 	withSelfSignedCert(t, func(certFilePath, keyFilePath string, certPool *x509.CertPool) {
-		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK),
+		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), ServerTimeouts{}, 0,
 			true, certFilePath, keyFilePath, 0, mockLog.Loggers)
 		require.NotNil(t, server)
 		require.NotNil(t, errCh)
@@ -86,7 +86,7 @@ func TestStartHTTPServerSecureWithMinTLSVersion(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 
 	withSelfSignedCert(t, func(certFilePath, keyFilePath string, certPool *x509.CertPool) {
-		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK),
+		server, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), ServerTimeouts{}, 0,
 			true, certFilePath, keyFilePath, tls.VersionTLS12, mockLog.Loggers)
 		require.NotNil(t, server)
 		require.NotNil(t, errCh)
@@ -110,9 +110,51 @@ func TestStartHTTPServerSecureWithMinTLSVersion(t *testing.T) {
 	})
 }
 
+func TestStartHTTPServerTimeouts(t *testing.T) {
+	port := st.GetAvailablePort(t)
+	timeouts := ServerTimeouts{
+		Read:       time.Second,
+		ReadHeader: 2 * time.Second,
+		Write:      3 * time.Second,
+		Idle:       4 * time.Second,
+	}
+	server, _ := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), timeouts, 0,
+		false, "", "", 0, ldlog.NewDisabledLoggers())
+	require.NotNil(t, server)
+	defer server.Close()
+
+	assert.Equal(t, timeouts.Read, server.ReadTimeout)
+	assert.Equal(t, timeouts.ReadHeader, server.ReadHeaderTimeout)
+	assert.Equal(t, timeouts.Write, server.WriteTimeout)
+	assert.Equal(t, timeouts.Idle, server.IdleTimeout)
+}
+
+func TestStartHTTPServerMaxHeaderBytes(t *testing.T) {
+	port := st.GetAvailablePort(t)
+	server, _ := StartHTTPServer(port, httphelpers.HandlerWithStatus(http.StatusOK), ServerTimeouts{}, 1000,
+		false, "", "", 0, ldlog.NewDisabledLoggers())
+	require.NotNil(t, server)
+	defer server.Close()
+	assert.Equal(t, 1000, server.MaxHeaderBytes)
+
+	url := fmt.Sprintf("http://localhost:%d", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		return err == nil && resp.StatusCode == http.StatusOK
+	}, time.Second, time.Millisecond*10)
+
+	// http.Server allows some slack beyond MaxHeaderBytes, so the header has to be well over the limit
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("X-Large", strings.Repeat("x", 10000))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
 func TestStartHTTPServerPortAlreadyUsed(t *testing.T) {
 	st.WithListenerForAnyPort(t, func(l net.Listener, port int) {
-		_, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(200), false, "", "", 0, ldlog.NewDisabledLoggers())
+		_, errCh := StartHTTPServer(port, httphelpers.HandlerWithStatus(200), ServerTimeouts{}, 0, false, "", "", 0, ldlog.NewDisabledLoggers())
 		require.NotNil(t, errCh)
 		err := helpers.RequireValue(t, errCh, time.Second, "timed out waiting for error")
 		assert.NotNil(t, err)
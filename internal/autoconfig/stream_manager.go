@@ -54,9 +54,12 @@ type StreamManager struct {
 	expiryTimers      map[config.SDKKey]*time.Timer
 	httpConfig        httpconfig.HTTPConfig
 	initialRetryDelay time.Duration
+	keyExpiryGrace    time.Duration
 	loggers           ldlog.Loggers
 	halt              chan struct{}
 	closeOnce         sync.Once
+	interruptedSince  time.Time
+	interruptedLock   sync.Mutex
 
 	envReceiver    *MessageReceiver[envfactory.EnvironmentRep]
 	filterReceiver *MessageReceiver[envfactory.FilterRep]
@@ -75,6 +78,7 @@ func NewStreamManager(
 	handler MessageHandler,
 	httpConfig httpconfig.HTTPConfig,
 	initialRetryDelay time.Duration,
+	keyExpiryGracePeriod time.Duration,
 	protocolVersion int,
 	loggers ldlog.Loggers,
 ) *StreamManager {
@@ -93,6 +97,7 @@ func NewStreamManager(
 		expiryTimers:      make(map[config.SDKKey]*time.Timer),
 		httpConfig:        httpConfig,
 		initialRetryDelay: initialRetryDelay,
+		keyExpiryGrace:    keyExpiryGracePeriod,
 		loggers:           loggers,
 		halt:              make(chan struct{}),
 	}
@@ -121,6 +126,24 @@ func (s *StreamManager) Start() <-chan error {
 	return readyCh
 }
 
+// GetInterruptedSince returns the time when the stream last failed, if it has not received a full set of
+// environments since then; otherwise it returns a zero time.
+func (s *StreamManager) GetInterruptedSince() time.Time {
+	s.interruptedLock.Lock()
+	defer s.interruptedLock.Unlock()
+	return s.interruptedSince
+}
+
+func (s *StreamManager) setInterrupted(interrupted bool) {
+	s.interruptedLock.Lock()
+	defer s.interruptedLock.Unlock()
+	if !interrupted {
+		s.interruptedSince = time.Time{}
+	} else if s.interruptedSince.IsZero() {
+		s.interruptedSince = time.Now()
+	}
+}
+
 // Close permanently shuts down the stream.
 func (s *StreamManager) Close() {
 	s.closeOnce.Do(func() {
@@ -133,6 +156,7 @@ func (s *StreamManager) subscribe(readyCh chan<- error) {
 	signalReady := func(err error) { readyOnce.Do(func() { readyCh <- err }) }
 
 	errorHandler := func(err error) es.StreamErrorHandlerResult {
+		s.setInterrupted(true)
 		if se, ok := err.(es.SubscriptionError); ok {
 			if se.Code == 401 || se.Code == 403 {
 				s.loggers.Error(logMsgBadKey)
@@ -239,6 +263,7 @@ func (s *StreamManager) consumeStream(stream *es.Stream) {
 					s.loggers.Infof(logMsgWrongPath, PutEvent, putMessage.Path)
 					break
 				}
+				s.setInterrupted(false)
 				s.handlePut(putMessage.Data)
 
 			case PatchEvent:
@@ -409,6 +434,9 @@ func (s *StreamManager) IgnoreExpiringSDKKey(env envfactory.EnvironmentRep) bool
 		return false
 	}
 
+	// The configured grace period lets the old key keep working for a while after LaunchDarkly says it
+	// has expired.
+	expiryTime += ldtime.UnixMillisecondTime(s.keyExpiryGrace.Milliseconds())
 	timeFromNow := time.Duration(expiryTime-ldtime.UnixMillisNow()) * time.Millisecond
 	if timeFromNow <= 0 {
 		// LD might sometimes tell us about an "expiring" key that has really already expired. If so,
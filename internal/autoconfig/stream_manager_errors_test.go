@@ -90,6 +90,7 @@ func errorShouldCauseReconnect(t *testing.T, errorProducingHandler http.Handler,
 		streamHandler,         // request after reconnect will get this
 	)
 	streamManagerTestWithStreamHandler(t, handler, stream, func(p streamManagerTestParams) {
+		assert.True(t, p.streamManager.GetInterruptedSince().IsZero())
 		p.startStream()
 		<-p.requestsCh // first request
 		_ = helpers.RequireValue(t, p.requestsCh, time.Second, "timed out waiting for stream restart")
@@ -97,6 +98,7 @@ func errorShouldCauseReconnect(t *testing.T, errorProducingHandler http.Handler,
 		msg := p.requireMessage()
 		assert.NotNil(t, msg.add)
 		p.requireReceivedAllMessage()
+		assert.True(t, p.streamManager.GetInterruptedSince().IsZero())
 	})
 }
 
@@ -133,6 +135,7 @@ func TestNoReconnectAfterUnrecoverableHTTPError(t *testing.T) {
 					require.Fail(t, "got unexpected event")
 				case <-time.After(time.Millisecond * 200):
 					p.mockLog.AssertMessageMatch(t, true, ldlog.Error, "Invalid auto-configuration key")
+					assert.False(t, p.streamManager.GetInterruptedSince().IsZero())
 				}
 			})
 		})
@@ -2,6 +2,7 @@ package autoconfig
 
 import (
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/envfactory"
@@ -124,6 +125,23 @@ func TestExpiringKeyHasAlreadyExpiredInPutMessage(t *testing.T) {
 	})
 }
 
+func TestExpiringKeyHasAlreadyExpiredButIsWithinGracePeriod(t *testing.T) {
+	envWithExpiringKey := makeEnvWithAlreadyExpiredKey(testEnv1, oldKey)
+	event := makeEnvPutEvent(envWithExpiringKey)
+	streamManagerTest(t, &event, func(p streamManagerTestParams) {
+		p.streamManager.keyExpiryGrace = time.Millisecond * briefExpiryMillis
+		p.startStream()
+
+		msg := p.requireMessage()
+		require.NotNil(t, msg.add)
+		p.requireReceivedAllMessage()
+
+		assert.Equal(t, oldKey, msg.add.ExpiringSDKKey)
+
+		expectOldKeyWillExpire(p, envWithExpiringKey.EnvID)
+	})
+}
+
 func TestExpiringKeyHasAlreadyExpiredInPatchAdd(t *testing.T) {
 	envWithExpiringKey := makeEnvWithAlreadyExpiredKey(testEnv1, oldKey)
 	event := makePatchEnvEvent(envWithExpiringKey)
@@ -228,7 +228,7 @@ func streamManagerTestWithStreamHandler(
 	mockLog.Loggers.SetMinLevel(ldlog.Debug)
 
 	handler, requestsCh := httphelpers.RecordingHandler(autoConfigEndpointHandler(streamHandler))
-	httpConfig, err := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", mockLog.Loggers)
+	httpConfig, err := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", "", mockLog.Loggers)
 	if err != nil {
 		panic(err)
 	}
@@ -247,6 +247,7 @@ func streamManagerTestWithStreamHandler(
 			testMessageHandler,
 			httpConfig,
 			time.Millisecond,
+			0,
 			rpacProtocolVersion,
 			mockLog.Loggers,
 		)
@@ -0,0 +1,16 @@
+package basictypes
+
+const (
+	// EvalSchemaVersionHeader is an HTTP header that SDKs may send to the client-side evaluation endpoints
+	// to select the format of the response.
+	EvalSchemaVersionHeader = "X-LaunchDarkly-Schema-Version"
+
+	// EvalSchemaValueOnly is the EvalSchemaVersionHeader value for the older response format, in which
+	// each flag key maps directly to the flag value with no variation, version, or reason properties.
+	EvalSchemaValueOnly = "1"
+
+	// EvalSchemaDetailed is the EvalSchemaVersionHeader value for the current response format, in which
+	// each flag key maps to an object with the value, variation, version, and other metadata. This is
+	// the default if the header is absent or has an unrecognized value.
+	EvalSchemaDetailed = "2"
+)
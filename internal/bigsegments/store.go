@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
@@ -47,8 +48,8 @@ func DefaultBigSegmentStoreFactory(
 	allConfig config.Config,
 	loggers ldlog.Loggers,
 ) (BigSegmentStore, error) {
-	// Currently, the only supported store type is Redis, and if Redis is enabled then big segments
-	// are enabled.
+	// Big segments are enabled if Redis or DynamoDB is enabled, either globally or for this environment.
+	allConfig, envConfig = sdks.GetBigSegmentsStoreConfig(allConfig, envConfig)
 	if allConfig.Redis.URL.IsDefined() {
 		bigSegmentRedis, err := newRedisBigSegmentStore(allConfig.Redis, envConfig, false, loggers)
 		if err != nil {
@@ -2,7 +2,6 @@ package bigsegments
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"strconv"
 
@@ -49,35 +48,10 @@ func newRedisBigSegmentStore(
 	checkOnStartup bool,
 	loggers ldlog.Loggers,
 ) (*redisBigSegmentStore, error) {
-	redisURL, prefix := sdks.GetRedisBasicProperties(redisConfig, envConfig)
-
-	opts := redis.UniversalOptions{}
-
-	// Relay's Redis configuration allows setting the server address either as a URL or as a
-	// host & port, but our config validation logic simplifies this so that it is always a URL.
-	// However, it is still possible to set the Password and TLS options separately from the
-	// URL, so we still need to check for those.
-	parsed, err := redis.ParseURL(redisURL)
+	opts, prefix, err := sdks.GetRedisUniversalOptions(redisConfig, envConfig)
 	if err != nil {
 		return nil, err
 	}
-	opts.DB = parsed.DB
-	opts.Addrs = []string{parsed.Addr}
-	opts.Username = parsed.Username
-	opts.Password = parsed.Password
-	opts.TLSConfig = parsed.TLSConfig
-	if redisConfig.Password != "" {
-		opts.Password = redisConfig.Password
-	}
-	if redisConfig.Username != "" {
-		opts.Username = redisConfig.Username
-	}
-	if redisConfig.TLS && opts.TLSConfig == nil {
-		opts.TLSConfig = &tls.Config{
-			ServerName: redisConfig.URL.Get().Hostname(),
-			MinVersion: tls.VersionTLS12,
-		}
-	}
 
 	store := redisBigSegmentStore{
 		client:  redis.NewUniversalClient(&opts),
@@ -68,6 +68,9 @@ type UpdatesSummary struct {
 // BigSegmentSynchronizerFactory creates an implementation of BigSegmentSynchronizer. We
 // only use a single implementation in real life, but this allows us to use a mock one
 // in tests. Calling the factory does not automatically start the synchronizer.
+//
+// If maxPatchesPerSecond is greater than zero, the synchronizer will not write patches to the store
+// faster than that rate, so that a large backfill does not use up all of the database's capacity.
 type BigSegmentSynchronizerFactory func(
 	httpConfig httpconfig.HTTPConfig,
 	store BigSegmentStore,
@@ -75,6 +78,7 @@ type BigSegmentSynchronizerFactory func(
 	streamURI string,
 	envID config.EnvironmentID,
 	sdkKey config.SDKKey,
+	maxPatchesPerSecond int,
 	loggers ldlog.Loggers,
 	logPrefix string,
 ) BigSegmentSynchronizer
@@ -88,6 +92,10 @@ type defaultBigSegmentSynchronizer struct {
 	envID               config.EnvironmentID
 	sdkKey              config.SDKKey
 	streamRetryInterval time.Duration
+	minPatchInterval    time.Duration
+	lastPatchTime       time.Time
+	lastCursor          string
+	polledVersions      map[string]struct{}
 	segmentUpdatesChan  chan UpdatesSummary
 	hasSynced           bool
 	syncedLock          sync.RWMutex
@@ -102,7 +110,16 @@ type segmentChangesSummary map[string]struct{}
 type applyPatchesResult struct {
 	totalPatchesCount   int
 	patchesAppliedCount int
-	segmentsUpdated     segmentChangesSummary
+	// patchesSkippedCount is the number of patches that were not applied because another writer
+	// sharing the same store had already applied them.
+	patchesSkippedCount int
+	// patchesDuplicateCount is the number of patches from the stream that were not applied because
+	// this synchronizer had already applied them from a poll response.
+	patchesDuplicateCount int
+	// conflict is true if another writer advanced the store's cursor past this batch of patches, so
+	// the remaining data must be requested again starting from the store's cursor.
+	conflict        bool
+	segmentsUpdated segmentChangesSummary
 }
 
 // DefaultBigSegmentSynchronizerFactory creates the default implementation of BigSegmentSynchronizer.
@@ -113,10 +130,12 @@ func DefaultBigSegmentSynchronizerFactory(
 	streamURI string,
 	envID config.EnvironmentID,
 	sdkKey config.SDKKey,
+	maxPatchesPerSecond int,
 	loggers ldlog.Loggers,
 	logPrefix string,
 ) BigSegmentSynchronizer {
-	return newDefaultBigSegmentSynchronizer(httpConfig, store, pollURI, streamURI, envID, sdkKey, loggers, logPrefix)
+	return newDefaultBigSegmentSynchronizer(httpConfig, store, pollURI, streamURI, envID, sdkKey, maxPatchesPerSecond,
+		loggers, logPrefix)
 }
 
 func newDefaultBigSegmentSynchronizer(
@@ -126,6 +145,7 @@ func newDefaultBigSegmentSynchronizer(
 	streamURI string,
 	envID config.EnvironmentID,
 	sdkKey config.SDKKey,
+	maxPatchesPerSecond int,
 	loggers ldlog.Loggers,
 	logPrefix string,
 ) *defaultBigSegmentSynchronizer {
@@ -141,6 +161,9 @@ func newDefaultBigSegmentSynchronizer(
 		closeChan:           make(chan struct{}),
 		loggers:             loggers,
 	}
+	if maxPatchesPerSecond > 0 {
+		s.minPatchInterval = time.Second / time.Duration(maxPatchesPerSecond)
+	}
 
 	if logPrefix != "" {
 		logPrefix += " "
@@ -216,54 +239,64 @@ func (s *defaultBigSegmentSynchronizer) syncSupervisor() {
 
 func (s *defaultBigSegmentSynchronizer) sync(isRetry bool) error {
 	s.loggers.Debug("Polling for big segment updates")
+	s.polledVersions = make(map[string]struct{})
 	segmentsUpdated := make(segmentChangesSummary)
-	for {
-	SyncLoop:
-		for {
-			select {
-			case <-s.closeChan:
-				return nil
-			default:
-				done, updates, err := s.poll()
-				if err != nil {
-					return err
-				}
-				if isRetry {
-					s.loggers.Warn("Re-established connection")
-					isRetry = false
-				}
-				segmentsUpdated.addAll(updates)
-				if done {
-					break SyncLoop
-				}
-			}
+	closed, err := s.pollUntilDone(segmentsUpdated, func() {
+		if isRetry {
+			s.loggers.Warn("Re-established connection")
+			isRetry = false
 		}
+	})
+	if closed || err != nil {
+		return err
+	}
 
-		stream, err := s.connectStream()
-		if err != nil {
-			return err
-		}
-		defer stream.Close()
+	stream, err := s.connectStream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
 
-		done, updates, err := s.poll()
-		if err != nil {
-			return err
-		}
-		segmentsUpdated.addAll(updates)
-		if !done {
-			continue
-		}
+	// Any patches that were published while the stream was connecting will be returned by these polls,
+	// and may also be sent by the stream; applyPatches ignores the stream's copies of them.
+	closed, err = s.pollUntilDone(segmentsUpdated, nil)
+	if closed || err != nil {
+		return err
+	}
 
-		s.loggers.Debug("Marking store as synchronized")
-		err = s.setSynced()
-		if err != nil {
-			s.loggers.Error("Updating store timestamp failed:", err)
-			return err
-		}
+	s.loggers.Debug("Marking store as synchronized")
+	err = s.setSynced()
+	if err != nil {
+		s.loggers.Error("Updating store timestamp failed:", err)
+		return err
+	}
+
+	s.notifySegmentsUpdated(segmentsUpdated)
 
-		s.notifySegmentsUpdated(segmentsUpdated)
+	return s.consumeStream(stream)
+}
 
-		return s.consumeStream(stream)
+// pollUntilDone polls until there are no more patches, adding the updated segments to segmentsUpdated.
+// If onSuccess is not nil, it is called after each successful poll. It returns true if the synchronizer
+// was closed.
+func (s *defaultBigSegmentSynchronizer) pollUntilDone(segmentsUpdated segmentChangesSummary, onSuccess func()) (bool, error) {
+	for {
+		select {
+		case <-s.closeChan:
+			return true, nil
+		default:
+			done, updates, err := s.poll()
+			if err != nil {
+				return false, err
+			}
+			if onSuccess != nil {
+				onSuccess()
+			}
+			segmentsUpdated.addAll(updates)
+			if done {
+				return false, nil
+			}
+		}
 	}
 }
 
@@ -311,6 +344,7 @@ func (s *defaultBigSegmentSynchronizer) poll() (bool, segmentChangesSummary, err
 	if err != nil {
 		return false, segmentChangesSummary{}, err
 	}
+	s.lastCursor = cursor
 
 	if cursor != "" {
 		query := request.URL.Query()
@@ -334,7 +368,7 @@ func (s *defaultBigSegmentSynchronizer) poll() (bool, segmentChangesSummary, err
 		return false, segmentChangesSummary{}, err
 	}
 
-	applyPatchResult, err := s.applyPatches(responseBody)
+	applyPatchResult, err := s.applyPatches(responseBody, true)
 
 	return applyPatchResult.totalPatchesCount == 0, applyPatchResult.segmentsUpdated, err
 }
@@ -382,12 +416,19 @@ func (s *defaultBigSegmentSynchronizer) consumeStream(stream *es.Stream) error {
 			}
 
 			s.loggers.Debug("Received update(s) from stream")
-			applyPatchResult, err := s.applyPatches([]byte(event.Data()))
+			applyPatchResult, err := s.applyPatches([]byte(event.Data()), false)
 			if err != nil {
 				return err
 			}
 			s.notifySegmentsUpdated(applyPatchResult.segmentsUpdated)
-			if applyPatchResult.patchesAppliedCount < applyPatchResult.totalPatchesCount {
+			if applyPatchResult.conflict {
+				// Another Relay instance has gotten ahead of us, so catch up from wherever it left off;
+				// there's no need to restart the stream, since the stream itself was not out of order.
+				if err := s.catchUp(); err != nil {
+					return err
+				}
+			} else if applyPatchResult.patchesAppliedCount+applyPatchResult.patchesSkippedCount+
+				applyPatchResult.patchesDuplicateCount < applyPatchResult.totalPatchesCount {
 				return nil // forces a restart if we got an out-of-order patch
 			}
 
@@ -406,8 +447,32 @@ func (s *defaultBigSegmentSynchronizer) consumeStream(stream *es.Stream) error {
 	}
 }
 
+// catchUp polls until there are no more patches after the store's current cursor.
+func (s *defaultBigSegmentSynchronizer) catchUp() error {
+	for {
+		done, updates, err := s.poll()
+		if err != nil {
+			return err
+		}
+		s.notifySegmentsUpdated(updates)
+		if done {
+			return nil
+		}
+	}
+}
+
 // Returns total number of patches, number of patches applied, raw segment IDs, error
-func (s *defaultBigSegmentSynchronizer) applyPatches(jsonData []byte) (applyPatchesResult, error) {
+//
+// If a patch can't be applied because the store's cursor is not the patch's previous version, there are
+// two possible reasons. If the cursor is the same as the last one we saw, the patch really is out of
+// order, so we skip it and the rest of the batch. Otherwise, another writer-- usually another Relay
+// instance that is synchronizing the same store-- has advanced the cursor, in which case we skip any
+// patches in this batch that it has already applied, or report a conflict if it has gone past them.
+//
+// Patches from a poll response are remembered until a patch from the stream is applied, so that if the
+// stream sends the same patches, they are ignored rather than being treated as out of order or counted
+// as updates a second time.
+func (s *defaultBigSegmentSynchronizer) applyPatches(jsonData []byte, fromPoll bool) (applyPatchesResult, error) {
 	var patches []bigSegmentPatch
 	err := json.Unmarshal(jsonData, &patches)
 	if err != nil {
@@ -418,22 +483,65 @@ func (s *defaultBigSegmentSynchronizer) applyPatches(jsonData []byte) (applyPatc
 		totalPatchesCount: len(patches),
 		segmentsUpdated:   make(segmentChangesSummary),
 	}
-	for _, patch := range patches {
+	for i := 0; i < len(patches); i++ {
+		patch := patches[i]
 		if enableTraceLogging {
 			s.loggers.Debugf("Received patch: %+v", patch)
 		} else {
 			s.loggers.Debugf("Received patch for version %q (from previous version %q)", patch.Version, patch.PreviousVersion)
 		}
+		if _, polled := s.polledVersions[patch.Version]; polled && !fromPoll {
+			s.loggers.Debugf("Ignoring patch for version %q from stream, since it was already received by polling", patch.Version)
+			ret.patchesDuplicateCount++
+			continue
+		}
+		if !s.waitForPatchRateLimit() {
+			break // synchronizer was closed
+		}
 		success, err := s.store.applyPatch(patch)
 		if err != nil {
 			return ret, err
 		}
-		if !success {
+		if success {
+			s.lastCursor = patch.Version
+			if fromPoll {
+				s.polledVersions[patch.Version] = struct{}{}
+			} else if len(s.polledVersions) != 0 {
+				// The stream has moved past anything that was polled, so it can't send those patches again
+				s.polledVersions = make(map[string]struct{})
+			}
+			ret.patchesAppliedCount++
+			ret.segmentsUpdated.addSegmentID(patch.SegmentID)
+			continue
+		}
+		cursor, err := s.store.getCursor()
+		if err != nil {
+			return ret, err
+		}
+		if cursor == s.lastCursor {
 			s.loggers.Warnf("Received a patch to previous version %q which was not the latest known version; skipping", patch.PreviousVersion)
 			break
 		}
-		ret.patchesAppliedCount++
-		ret.segmentsUpdated.addSegmentID(patch.SegmentID)
+		s.lastCursor = cursor
+		appliedThrough := -1
+		for j := i; j < len(patches); j++ {
+			if patches[j].Version == cursor {
+				appliedThrough = j
+				break
+			}
+		}
+		if appliedThrough < 0 {
+			s.loggers.Infof("Store was updated to version %q by another writer; resuming from there", cursor)
+			ret.conflict = true
+			break
+		}
+		s.loggers.Infof("Store was updated to version %q by another writer; skipping %d patch(es) that it already applied",
+			cursor, appliedThrough-i+1)
+		for _, skipped := range patches[i : appliedThrough+1] {
+			ret.patchesSkippedCount++
+			ret.segmentsUpdated.addSegmentID(skipped.SegmentID)
+		}
+		i = appliedThrough
 	}
 	if ret.patchesAppliedCount > 0 {
 		updatesDesc := "updates"
@@ -445,6 +553,26 @@ func (s *defaultBigSegmentSynchronizer) applyPatches(jsonData []byte) (applyPatc
 	return ret, nil
 }
 
+// waitForPatchRateLimit blocks, if necessary, until enough time has passed since the last patch was
+// applied to stay within the configured maximum rate. It returns false if the synchronizer was closed
+// while waiting. This is only called from the synchronizer goroutine, so it does not need a lock.
+func (s *defaultBigSegmentSynchronizer) waitForPatchRateLimit() bool {
+	if s.minPatchInterval == 0 {
+		return true
+	}
+	if delay := time.Until(s.lastPatchTime.Add(s.minPatchInterval)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-s.closeChan:
+			return false
+		case <-timer.C:
+		}
+	}
+	s.lastPatchTime = time.Now()
+	return true
+}
+
 func (s *defaultBigSegmentSynchronizer) notifySegmentsUpdated(segmentsUpdated segmentChangesSummary) {
 	keys := segmentsUpdated.getUpdatedSegmentKeys()
 	if len(keys) != 0 {
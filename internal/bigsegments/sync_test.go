@@ -25,12 +25,20 @@ type bigSegmentStoreMock struct {
 	lock       sync.Mutex
 	patchCh    chan bigSegmentPatch
 	syncTimeCh chan ldtime.UnixMillisecondTime
+	// competingWriter, if set, is called before each patch is applied and returns a new cursor value
+	// if it wants to simulate another Relay instance updating the store first.
+	competingWriter func(patch bigSegmentPatch) (string, bool)
 }
 
 func (s *bigSegmentStoreMock) applyPatch(patch bigSegmentPatch) (bool, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.competingWriter != nil {
+		if cursor, ok := s.competingWriter(patch); ok {
+			s.cursor = cursor
+		}
+	}
 	if s.cursor != patch.PreviousVersion {
 		return false, nil
 	}
@@ -134,7 +142,7 @@ func TestBasicSync(t *testing.T) {
 			defer storeMock.Close()
 
 			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
-				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, mockLog.Loggers, "")
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
 			defer segmentSync.Close()
 			segmentSync.Start()
 
@@ -182,6 +190,78 @@ func TestBasicSync(t *testing.T) {
 	})
 }
 
+func TestSyncIgnoresPatchesFromStreamThatWereAlreadyPolled(t *testing.T) {
+	// Scenario:
+	// - The first polls return patch1, then nothing
+	// - While the stream is connecting, patch2 is published, so the poll after the stream connects returns it
+	// - The stream then sends patch2 and patch3 in one event; patch2 is ignored, without restarting the stream
+	mockLog := ldlogtest.NewMockLog()
+	mockLog.Loggers.SetMinLevel(ldlog.Debug)
+	defer mockLog.DumpIfTestFailed(t)
+
+	patch1 := newPatchBuilder("segment1.g1", "1", "").addIncludes("included1").build()
+	patch2 := newPatchBuilder("segment2.g1", "2", "1").addIncludes("included2").build()
+	patch3 := newPatchBuilder("segment3.g1", "3", "2").addIncludes("included3").build()
+
+	pollHandler, requestsCh := httphelpers.RecordingHandler(
+		httphelpers.SequentialHandler(
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch1}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch2}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+		),
+	)
+
+	sseHandler, _ := httphelpers.SSEHandler(makePatchEvent(patch2, patch3))
+	streamHandler, streamRequestsCh := httphelpers.RecordingHandler(sseHandler)
+
+	httphelpers.WithServer(pollHandler, func(pollServer *httptest.Server) {
+		httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
+			storeMock := newBigSegmentStoreMock()
+			defer storeMock.Close()
+
+			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
+			defer segmentSync.Close()
+			segmentSync.Start()
+
+			updatesCh := segmentSync.SegmentUpdatesCh()
+
+			requirePatch(t, storeMock, patch1)
+			requirePatch(t, storeMock, patch2)
+			requireUpdates(t, updatesCh, []string{"segment1", "segment2"})
+
+			requirePatch(t, storeMock, patch3)
+			requireUpdates(t, updatesCh, []string{"segment3"})
+			requireNoMorePatches(t, storeMock)
+
+			assertPollRequest(t, helpers.RequireValue(t, requestsCh, time.Second), "")
+			assertPollRequest(t, helpers.RequireValue(t, requestsCh, time.Second), patch1.Version)
+			assertPollRequest(t, helpers.RequireValue(t, requestsCh, time.Second), patch1.Version)
+			assertPollRequest(t, helpers.RequireValue(t, requestsCh, time.Second), patch2.Version)
+			if !helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50) {
+				t.FailNow()
+			}
+
+			assertStreamRequest(t, helpers.RequireValue(t, streamRequestsCh, time.Second))
+			if !helpers.AssertNoMoreValues(t, streamRequestsCh, time.Millisecond*50) {
+				t.FailNow()
+			}
+			if !helpers.AssertNoMoreValues(t, updatesCh, time.Millisecond*50) {
+				t.FailNow()
+			}
+
+			assert.Equal(t, []string{
+				"BigSegmentSynchronizer: Applied 1 update",
+				"BigSegmentSynchronizer: Applied 1 update",
+				"BigSegmentSynchronizer: Applied 1 update",
+			}, mockLog.GetOutput(ldlog.Info))
+			assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+			mockLog.AssertMessageMatch(t, true, ldlog.Debug, `Ignoring patch for version "2" from stream`)
+		})
+	})
+}
+
 func TestSyncSendsUpdates(t *testing.T) {
 	// Scenario:
 	// - Polling returns 3 patches (in 2 poll responses); these are aggregated into one UpdatesSummary
@@ -218,7 +298,7 @@ func TestSyncSendsUpdates(t *testing.T) {
 			defer storeMock.Close()
 
 			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
-				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, mockLog.Loggers, "")
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
 			defer segmentSync.Close()
 			segmentSync.Start()
 
@@ -299,7 +379,7 @@ func TestSyncSkipsOutOfOrderUpdateFromPoll(t *testing.T) {
 			defer storeMock.Close()
 
 			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
-				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, mockLog.Loggers, "")
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
 			defer segmentSync.Close()
 			segmentSync.Start()
 
@@ -374,7 +454,7 @@ func TestSyncSkipsOutOfOrderUpdateFromStreamAndRestartsStream(t *testing.T) {
 			defer storeMock.Close()
 
 			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
-				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, mockLog.Loggers, "")
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
 			segmentSync.streamRetryInterval = time.Millisecond
 			defer segmentSync.Close()
 			segmentSync.Start()
@@ -426,6 +506,141 @@ func TestSyncSkipsOutOfOrderUpdateFromStreamAndRestartsStream(t *testing.T) {
 	})
 }
 
+func TestSyncSkipsPatchesAppliedByCompetingWriter(t *testing.T) {
+	// Scenario:
+	// - Poll returns 3 patches
+	// - We apply the first patch, but another writer applies the second one before we do
+	// - We skip the second patch without a warning, and apply the third
+	mockLog := ldlogtest.NewMockLog()
+	mockLog.Loggers.SetMinLevel(ldlog.Debug)
+	defer mockLog.DumpIfTestFailed(t)
+
+	patch1 := newPatchBuilder("segment1.g1", "1", "").addIncludes("included1").build()
+	patch2 := newPatchBuilder("segment2.g1", "2", "1").addIncludes("included2").build()
+	patch3 := newPatchBuilder("segment1.g1", "3", "2").addIncludes("included3").build()
+
+	pollHandler, requestsCh := httphelpers.RecordingHandler(
+		httphelpers.SequentialHandler(
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch1, patch2, patch3}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+		),
+	)
+
+	sseHandler, _ := httphelpers.SSEHandler(nil)
+	streamHandler, streamRequestsCh := httphelpers.RecordingHandler(sseHandler)
+
+	httphelpers.WithServer(pollHandler, func(pollServer *httptest.Server) {
+		httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
+			storeMock := newBigSegmentStoreMock()
+			storeMock.competingWriter = func(patch bigSegmentPatch) (string, bool) {
+				return patch2.Version, patch.Version == patch2.Version
+			}
+			defer storeMock.Close()
+
+			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
+			defer segmentSync.Close()
+			segmentSync.Start()
+
+			pollReq1 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq1, "")
+			requirePatch(t, storeMock, patch1)
+			requirePatch(t, storeMock, patch3)
+
+			requireUpdates(t, segmentSync.SegmentUpdatesCh(), []string{"segment1", "segment2"})
+
+			pollReq2 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq2, patch3.Version)
+
+			pollReq3 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq3, patch3.Version)
+
+			streamReq1 := helpers.RequireValue(t, streamRequestsCh, time.Second)
+			assertStreamRequest(t, streamReq1)
+
+			requireNoMorePatches(t, storeMock)
+			if !helpers.AssertNoMoreValues(t, streamRequestsCh, time.Millisecond*50) {
+				t.FailNow()
+			}
+
+			mockLog.AssertMessageMatch(t, true, ldlog.Info, `updated to version "2" by another writer; skipping 1 patch`)
+			assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+		})
+	})
+}
+
+func TestSyncResumesFromCursorOfCompetingWriterWithoutRestartingStream(t *testing.T) {
+	// Scenario:
+	// - Poll returns 1 patch, which we apply
+	// - Stream returns a patch, but another writer has already applied it and a later one too
+	// - We poll again from the store's cursor, apply the next patch, and keep using the same stream
+	mockLog := ldlogtest.NewMockLog()
+	mockLog.Loggers.SetMinLevel(ldlog.Debug)
+	defer mockLog.DumpIfTestFailed(t)
+
+	patch1 := newPatchBuilder("segment.g1", "1", "").addIncludes("included1").build()
+	patch2 := newPatchBuilder("segment.g1", "2", "1").addIncludes("included2").build()
+	patch4 := newPatchBuilder("segment.g1", "4", "3").addIncludes("included4").build()
+
+	pollHandler, requestsCh := httphelpers.RecordingHandler(
+		httphelpers.SequentialHandler(
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch1}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch4}, nil),
+			httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+		),
+	)
+
+	sseHandler, _ := httphelpers.SSEHandler(makePatchEvent(patch2))
+	streamHandler, streamRequestsCh := httphelpers.RecordingHandler(sseHandler)
+
+	httphelpers.WithServer(pollHandler, func(pollServer *httptest.Server) {
+		httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
+			storeMock := newBigSegmentStoreMock()
+			storeMock.competingWriter = func(patch bigSegmentPatch) (string, bool) {
+				return "3", patch.Version == patch2.Version
+			}
+			defer storeMock.Close()
+
+			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
+			segmentSync.streamRetryInterval = time.Millisecond
+			defer segmentSync.Close()
+			segmentSync.Start()
+
+			pollReq1 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq1, "")
+			requirePatch(t, storeMock, patch1)
+
+			pollReq2 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq2, patch1.Version)
+
+			pollReq3 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq3, patch1.Version)
+
+			streamReq1 := helpers.RequireValue(t, streamRequestsCh, time.Second)
+			assertStreamRequest(t, streamReq1)
+
+			pollReq4 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq4, "3")
+			requirePatch(t, storeMock, patch4)
+
+			pollReq5 := helpers.RequireValue(t, requestsCh, time.Second)
+			assertPollRequest(t, pollReq5, patch4.Version)
+
+			requireNoMorePatches(t, storeMock)
+			if !helpers.AssertNoMoreValues(t, streamRequestsCh, time.Millisecond*50) {
+				t.FailNow()
+			}
+
+			mockLog.AssertMessageMatch(t, true, ldlog.Info, `updated to version "3" by another writer; resuming from there`)
+			assert.Len(t, mockLog.GetOutput(ldlog.Warn), 0)
+		})
+	})
+}
+
 func TestSyncRetryIfStreamFails(t *testing.T) {
 	// In this test, we set up a successful poll and stream. Then we force the stream to close.
 	// The synchronizer should start over with a new poll and stream.
@@ -463,7 +678,7 @@ func TestSyncRetryIfStreamFails(t *testing.T) {
 			defer storeMock.Close()
 
 			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
-				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, mockLog.Loggers, "")
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, 0, mockLog.Loggers, "")
 			segmentSync.streamRetryInterval = time.Millisecond
 			defer segmentSync.Close()
 			segmentSync.Start()
@@ -534,3 +749,52 @@ func TestSyncRetryIfStreamFails(t *testing.T) {
 		})
 	})
 }
+
+func TestSyncPatchRateLimit(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	patch1 := newPatchBuilder("segment.g1", "1", "").addIncludes("included1").build()
+	patch2 := newPatchBuilder("segment.g1", "2", "1").addIncludes("included2").build()
+	patch3 := newPatchBuilder("segment.g1", "3", "2").addIncludes("included3").build()
+	patch4 := newPatchBuilder("segment.g1", "4", "3").addIncludes("included4").build()
+
+	pollHandler := httphelpers.SequentialHandler(
+		httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{patch1, patch2, patch3}, nil),
+		httphelpers.HandlerWithJSONResponse([]bigSegmentPatch{}, nil),
+	)
+	streamHandler, _ := httphelpers.SSEHandler(makePatchEvent(patch4))
+
+	httphelpers.WithServer(pollHandler, func(pollServer *httptest.Server) {
+		httphelpers.WithServer(streamHandler, func(streamServer *httptest.Server) {
+			storeMock := newBigSegmentStoreMock()
+			defer storeMock.Close()
+
+			maxPatchesPerSecond := 20
+			minInterval := time.Second / time.Duration(maxPatchesPerSecond)
+
+			segmentSync := newDefaultBigSegmentSynchronizer(sharedtest.MakeBasicHTTPConfig(), storeMock,
+				pollServer.URL, streamServer.URL, config.EnvironmentID("env-xyz"), testSDKKey, maxPatchesPerSecond,
+				mockLog.Loggers, "")
+			defer segmentSync.Close()
+			segmentSync.Start()
+
+			updatesCh := segmentSync.SegmentUpdatesCh()
+			go func() {
+				for range updatesCh {
+				}
+			}() // just ensures that the synchronizer won't be blocked by the channel
+
+			requirePatch(t, storeMock, patch1)
+			startTime := time.Now()
+			requirePatch(t, storeMock, patch2) // these two come from polling
+			requirePatch(t, storeMock, patch3)
+			requirePatch(t, storeMock, patch4) // this one comes from the stream
+			elapsed := time.Since(startTime)
+
+			// Allow a little leeway because we're measuring when the mock store received each patch,
+			// not when the synchronizer decided to apply it
+			assert.GreaterOrEqual(t, elapsed, 3*minInterval-10*time.Millisecond)
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/events"
 )
 
@@ -26,9 +27,11 @@ var DefaultAllowedHeaders = strings.Join([]string{ //nolint:gochecknoglobals
 	"Content-Type",
 	"Content-Length",
 	"Accept-Encoding",
+	"Content-Encoding",
 	"X-LaunchDarkly-User-Agent",
 	"X-LaunchDarkly-Payload-ID",
 	"X-LaunchDarkly-Wrapper",
+	basictypes.EvalSchemaVersionHeader,
 	events.EventSchemaHeader,
 	events.TagsHeader,
 }, ",")
@@ -38,6 +41,7 @@ var DefaultAllowedHeaders = strings.Join([]string{ //nolint:gochecknoglobals
 type CORSContext interface {
 	AllowedOrigins() []string
 	AllowedHeaders() []string
+	AllowedMethods() []string
 }
 
 // GetCORSContext returns the CORSContext that has been attached to this Context with WithCORSContext(),
@@ -70,3 +74,11 @@ func SetCORSHeaders(w http.ResponseWriter, origin string, extraAllowedHeaders []
 	w.Header().Set("Access-Control-Allow-Headers", allAllowedHeaders)
 	w.Header().Set("Access-Control-Expose-Headers", "Date")
 }
+
+// SetCORSAllowedMethods overrides the Access-Control-Allow-Methods header, which is otherwise computed from
+// the methods that are routed for the request path. It has no effect if methods is empty.
+func SetCORSAllowedMethods(w http.ResponseWriter, methods []string) {
+	if len(methods) > 0 {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ","))
+	}
+}
@@ -19,6 +19,10 @@ func (m mockCORSContext) AllowedHeaders() []string {
 	return nil
 }
 
+func (m mockCORSContext) AllowedMethods() []string {
+	return nil
+}
+
 func TestCORSContext(t *testing.T) {
 	t.Run("GetCORSContext when there is no RequestContext returns nil", func(t *testing.T) {
 		assert.Nil(t, GetCORSContext(context.Background()))
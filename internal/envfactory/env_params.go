@@ -1,7 +1,11 @@
 package envfactory
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 
@@ -40,6 +44,48 @@ type EnvironmentParams struct {
 	SecureMode bool
 }
 
+var (
+	errEnvironmentIDMissing = errors.New("environment ID is missing")
+	errSDKKeyMissing        = errors.New("SDK key is missing")
+)
+
+func errInvalidCredential(name string) error {
+	return fmt.Errorf("%s contains whitespace or control characters", name)
+}
+
+func errNegativeTTL(ttl time.Duration) error {
+	return fmt.Errorf("TTL must not be negative (was %s)", ttl)
+}
+
+// Validate checks whether the parameters can be applied to an environment. It is called before
+// making any changes to an existing environment, so that an update with bad parameters leaves the
+// environment as it was instead of partly reconfigured.
+func (e EnvironmentParams) Validate() error {
+	if e.EnvID == "" {
+		return errEnvironmentIDMissing
+	}
+	if e.SDKKey == "" {
+		return errSDKKeyMissing
+	}
+	// Credentials are sent in HTTP headers, so they can't contain whitespace or control characters.
+	for _, c := range []struct {
+		name  string
+		value string
+	}{
+		{"SDK key", string(e.SDKKey)},
+		{"expiring SDK key", string(e.ExpiringSDKKey)},
+		{"mobile key", string(e.MobileKey)},
+	} {
+		if strings.IndexFunc(c.value, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+			return errInvalidCredential(c.name)
+		}
+	}
+	if e.TTL < 0 {
+		return errNegativeTTL(e.TTL)
+	}
+	return nil
+}
+
 func (e EnvironmentParams) Credentials() credential.AutoConfig {
 	return credential.AutoConfig{
 		SDKKey:         e.SDKKey,
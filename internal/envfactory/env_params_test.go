@@ -0,0 +1,52 @@
+package envfactory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentParamsValidate(t *testing.T) {
+	valid := EnvironmentParams{
+		EnvID:          config.EnvironmentID("envid1"),
+		SDKKey:         config.SDKKey("sdkkey1"),
+		MobileKey:      config.MobileKey("mobkey1"),
+		ExpiringSDKKey: config.SDKKey("sdkkey0"),
+		TTL:            time.Minute,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, valid.Validate())
+	})
+
+	t.Run("optional properties omitted", func(t *testing.T) {
+		p := EnvironmentParams{EnvID: valid.EnvID, SDKKey: valid.SDKKey}
+		assert.NoError(t, p.Validate())
+	})
+
+	for _, params := range []struct {
+		name     string
+		modify   func(*EnvironmentParams)
+		expected string
+	}{
+		{"missing environment ID", func(p *EnvironmentParams) { p.EnvID = "" }, "environment ID is missing"},
+		{"missing SDK key", func(p *EnvironmentParams) { p.SDKKey = "" }, "SDK key is missing"},
+		{"bad SDK key", func(p *EnvironmentParams) { p.SDKKey = "sdk key" }, "SDK key contains whitespace or control characters"},
+		{"bad expiring SDK key", func(p *EnvironmentParams) { p.ExpiringSDKKey = "sdkkey\n" },
+			"expiring SDK key contains whitespace or control characters"},
+		{"bad mobile key", func(p *EnvironmentParams) { p.MobileKey = "mob\x00key" }, "mobile key contains whitespace or control characters"},
+		{"negative TTL", func(p *EnvironmentParams) { p.TTL = -time.Second }, "TTL must not be negative (was -1s)"},
+	} {
+		t.Run(params.name, func(t *testing.T) {
+			p := valid
+			params.modify(&p)
+			err := p.Validate()
+			if assert.Error(t, err) {
+				assert.Equal(t, params.expected, err.Error())
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package events
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"reflect"
 	"sync"
@@ -33,6 +35,7 @@ const defaultEventQueueCleanupInterval = time.Hour
 type EventDispatcher struct {
 	analyticsEndpoints  map[basictypes.SDKKind]*analyticsEventEndpointDispatcher
 	diagnosticEndpoints map[basictypes.SDKKind]*diagnosticEventEndpointDispatcher
+	recentEvents        *recentEventsBuffer
 }
 
 type analyticsEventEndpointDispatcher struct {
@@ -41,9 +44,14 @@ type analyticsEventEndpointDispatcher struct {
 	httpConfig                httpconfig.HTTPConfig
 	authKey                   credential.SDKCredential
 	remotePath                string
+	forwardingURIs            []string
+	strictValidation          bool
+	forwarders                []EventPublisher
 	verbatimRelay             *eventVerbatimRelay
 	summarizingRelay          *eventSummarizingRelay
 	storeAdapter              *store.SSERelayDataStoreAdapter
+	recentEvents              *recentEventsBuffer
+	onUnknownFlagEvents       func(count int)
 	eventQueueCleanupInterval time.Duration
 	loggers                   ldlog.Loggers
 	mu                        sync.Mutex
@@ -54,9 +62,21 @@ type diagnosticEventEndpointDispatcher struct {
 	httpConfig httpconfig.HTTPConfig
 	baseURI    string
 	uriPath    string
+	relayInfo  json.RawMessage
 	loggers    ldlog.Loggers
 }
 
+// diagnosticRelayInfo is the metadata that is added to diagnostic events, if that is enabled with
+// EventsConfig.AugmentDiagnosticEvents, to show that they were forwarded by Relay.
+type diagnosticRelayInfo struct {
+	Version         string `json:"version"`
+	EnvironmentName string `json:"environmentName,omitempty"`
+}
+
+// diagnosticRelayInfoProperty is the top-level property of a diagnostic event that contains the
+// diagnosticRelayInfo.
+const diagnosticRelayInfoProperty = "relay"
+
 // GetHandler returns the HTTP handler for an endpoint, or nil if none is defined
 func (r *EventDispatcher) GetHandler(sdkKind basictypes.SDKKind, eventsKind ldevents.EventDataKind) func(w http.ResponseWriter, req *http.Request) {
 	if eventsKind == ldevents.DiagnosticEventDataKind {
@@ -72,7 +92,11 @@ func (r *EventDispatcher) GetHandler(sdkKind basictypes.SDKKind, eventsKind ldev
 }
 
 func (r *analyticsEventEndpointDispatcher) dispatch(w http.ResponseWriter, req *http.Request) {
-	consumeEvents(w, req, r.loggers, func(body []byte) {
+	var validate func([]byte) error
+	if r.strictValidation {
+		validate = validateClientSideEvents
+	}
+	consumeEvents(w, req, r.loggers, validate, func(body []byte) {
 		evts := make([]json.RawMessage, 0)
 		err := json.Unmarshal(body, &evts)
 		if err != nil {
@@ -83,10 +107,32 @@ func (r *analyticsEventEndpointDispatcher) dispatch(w http.ResponseWriter, req *
 		metadata := GetEventPayloadMetadata(req)
 
 		r.loggers.Debugf("Received %d events (v%d) to be proxied to %s", len(evts), metadata.SchemaVersion, r.remotePath)
+		if r.config.UnknownFlags == c.EventsUnknownFlagsCount || r.config.UnknownFlags == c.EventsUnknownFlagsDrop {
+			drop := r.config.UnknownFlags == c.EventsUnknownFlagsDrop
+			var unknownCount int
+			evts, unknownCount = checkUnknownFlags(evts, r.storeAdapter.GetStore(), drop)
+			if unknownCount > 0 {
+				if drop {
+					r.loggers.Debugf("Removed %d references to unknown flags from events", unknownCount)
+				}
+				if r.onUnknownFlagEvents != nil {
+					r.onUnknownFlagEvents(unknownCount)
+				}
+			}
+			if len(evts) == 0 {
+				return
+			}
+		}
+		if r.recentEvents != nil {
+			r.recentEvents.add(r.remotePath, metadata, evts)
+		}
 		if metadata.SchemaVersion >= SummaryEventsSchemaVersion {
 			// New-style events that have already gone through summarization - deliver them as-is
 			r.getVerbatimRelay().enqueue(metadata, evts)
+			forwardEvents(r.getForwarders(), metadata, evts)
 		} else {
+			// The summarizing relay takes care of forwarding, since it's the summarized output that
+			// should be forwarded
 			r.getSummarizingRelay().enqueue(metadata, evts)
 		}
 	})
@@ -115,13 +161,19 @@ func (r *analyticsEventEndpointDispatcher) close() {
 	if r.verbatimRelay != nil {
 		r.verbatimRelay.close()
 	}
+	for _, f := range r.forwarders {
+		f.Close()
+	}
 }
 
 func (d *diagnosticEventEndpointDispatcher) dispatch(w http.ResponseWriter, req *http.Request) {
-	consumeEvents(w, req, d.loggers, func(body []byte) {
+	consumeEvents(w, req, d.loggers, nil, func(body []byte) {
 		// We are just operating as a reverse proxy and passing the request on verbatim to LD; we do not
 		// need to parse the JSON.
 		d.loggers.Debugf("Received diagnostic event to be proxied to %s/%s", d.baseURI, d.uriPath)
+		if d.relayInfo != nil {
+			body = addDiagnosticRelayInfo(body, d.relayInfo)
+		}
 
 		sendConfig := ldevents.EventSenderConfiguration{
 			Client:      d.httpClient,
@@ -133,23 +185,67 @@ func (d *diagnosticEventEndpointDispatcher) dispatch(w http.ResponseWriter, req
 	})
 }
 
-func consumeEvents(w http.ResponseWriter, req *http.Request, loggers ldlog.Loggers, thenExecute func([]byte)) {
+// addDiagnosticRelayInfo adds Relay's metadata to a diagnostic event. None of the SDK's own properties
+// are changed; if the event is not a JSON object, or if it already has this metadata because it passed
+// through another Relay instance, it is returned unchanged.
+func addDiagnosticRelayInfo(body []byte, relayInfo json.RawMessage) []byte {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(body, &props); err != nil || props == nil {
+		return body
+	}
+	if _, ok := props[diagnosticRelayInfoProperty]; ok {
+		return body
+	}
+	props[diagnosticRelayInfoProperty] = relayInfo
+	data, err := json.Marshal(props)
+	if err != nil { // COVERAGE: can't happen, since all of the values were already valid JSON
+		return body
+	}
+	return data
+}
+
+func consumeEvents(
+	w http.ResponseWriter,
+	req *http.Request,
+	loggers ldlog.Loggers,
+	validate func([]byte) error,
+	thenExecute func([]byte),
+) {
 	body, bodyErr := io.ReadAll(req.Body)
 
-	if bodyErr != nil { // COVERAGE: can't make this happen in unit tests
+	if bodyErr != nil {
+		var tooLargeErr *http.MaxBytesError
+		if errors.As(bodyErr, &tooLargeErr) {
+			util.WriteErrorJSON(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
 		loggers.Errorf("Error reading event post body: %+v", bodyErr)
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg("unable to read request body"))
+		util.WriteErrorJSON(w, http.StatusBadRequest, "unable to read request body")
 		return
 	}
 
 	if len(body) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg("body may not be empty"))
+		util.WriteErrorJSON(w, http.StatusBadRequest, "body may not be empty")
+		return
+	}
+
+	// Browsers that deliver events with navigator.sendBeacon, typically while a page is being unloaded,
+	// can't use application/json without a CORS preflight request, so they send text/plain instead. We
+	// accept that, but since such a body could be anything, we make sure it is JSON before accepting it.
+	if isPlainTextBody(req) && !json.Valid(body) {
+		util.WriteErrorJSON(w, http.StatusBadRequest, "text/plain body must contain JSON event data")
 		return
 	}
 
-	// Always accept the data
+	if validate != nil {
+		if err := validate(body); err != nil {
+			loggers.Warnf("Rejected invalid event data: %s", err)
+			util.WriteErrorJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Otherwise, always accept the data
 	w.WriteHeader(http.StatusAccepted)
 
 	defer func() {
@@ -160,6 +256,11 @@ func consumeEvents(w http.ResponseWriter, req *http.Request, loggers ldlog.Logge
 	thenExecute(body)
 }
 
+func isPlainTextBody(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/plain"
+}
+
 func (r *analyticsEventEndpointDispatcher) getVerbatimRelay() *eventVerbatimRelay {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -170,15 +271,25 @@ func (r *analyticsEventEndpointDispatcher) getVerbatimRelay() *eventVerbatimRela
 }
 
 func (r *analyticsEventEndpointDispatcher) getSummarizingRelay() *eventSummarizingRelay {
+	forwarders := r.getForwarders()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.summarizingRelay == nil {
 		r.summarizingRelay = newEventSummarizingRelay(r.config, r.httpConfig, r.authKey, r.storeAdapter,
-			r.loggers, r.remotePath, r.eventQueueCleanupInterval)
+			forwarders, r.loggers, r.remotePath, r.eventQueueCleanupInterval)
 	}
 	return r.summarizingRelay
 }
 
+func (r *analyticsEventEndpointDispatcher) getForwarders() []EventPublisher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.forwarders == nil && len(r.forwardingURIs) != 0 {
+		r.forwarders = newEventForwarders(r.forwardingURIs, r.config, r.httpConfig, r.loggers, r.remotePath)
+	}
+	return r.forwarders
+}
+
 func (r *analyticsEventEndpointDispatcher) flush() { //nolint:unused // used only in tests
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -188,37 +299,73 @@ func (r *analyticsEventEndpointDispatcher) flush() { //nolint:unused // used onl
 	if r.summarizingRelay != nil {
 		r.summarizingRelay.flush()
 	}
+	for _, f := range r.forwarders {
+		f.Flush()
+	}
 }
 
-// NewEventDispatcher creates a handler for relaying events to LaunchDarkly for an environment
+// NewEventDispatcher creates a handler for relaying events to LaunchDarkly for an environment.
+//
+// If forwardingURIs is non-empty, a copy of every batch of analytics events is also delivered to each
+// of those base URIs.
+//
+// If strictClientSideValidation is true, batches of analytics events from mobile and JS client-side SDKs
+// are rejected with a 400 status if they are not well-formed, rather than being forwarded to LaunchDarkly;
+// see validateClientSideEvents.
+//
+// If config.AugmentDiagnosticEvents is true, diagnostic events are forwarded with an additional property
+// giving relayVersion and envName.
+//
+// If config.RecentBatches is set, that many of the most recent batches of analytics events are retained
+// in memory for debugging; see GetRecentEvents.
+//
+// If config.UnknownFlags is "count" or "drop", analytics events are checked for references to flags that
+// do not exist in the data store, and onUnknownFlagEvents (if not nil) is called with the number of such
+// references in each batch; in "drop" mode, they are also removed from the batch. See checkUnknownFlags.
 func NewEventDispatcher(
 	sdkKey c.SDKKey,
 	mobileKey c.MobileKey,
 	envID c.EnvironmentID,
+	envName string,
+	relayVersion string,
 	loggers ldlog.Loggers,
 	config c.EventsConfig,
+	forwardingURIs []string,
+	strictClientSideValidation bool,
 	httpConfig httpconfig.HTTPConfig,
 	storeAdapter *store.SSERelayDataStoreAdapter,
+	onUnknownFlagEvents func(count int),
 	eventQueueCleanupInterval time.Duration, // normally zero to use the default; overridden in tests
 ) *EventDispatcher {
+	var recentEvents *recentEventsBuffer
+	if config.RecentBatches.IsDefined() {
+		recentEvents = newRecentEventsBuffer(config.RecentBatches.GetOrElse(0), maxRecentEventsBytes)
+	}
+	var relayInfo json.RawMessage
+	if config.AugmentDiagnosticEvents {
+		relayInfo, _ = json.Marshal(diagnosticRelayInfo{Version: relayVersion, EnvironmentName: envName})
+	}
 	ep := &EventDispatcher{
 		analyticsEndpoints: map[basictypes.SDKKind]*analyticsEventEndpointDispatcher{
 			basictypes.ServerSDK: newAnalyticsEventEndpointDispatcher(sdkKey,
-				config, httpConfig, storeAdapter, loggers, "/bulk", eventQueueCleanupInterval),
+				config, forwardingURIs, httpConfig, storeAdapter, recentEvents, onUnknownFlagEvents, loggers, "/bulk", eventQueueCleanupInterval),
 		},
 		diagnosticEndpoints: map[basictypes.SDKKind]*diagnosticEventEndpointDispatcher{
-			basictypes.ServerSDK: newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers, "/diagnostic"),
+			basictypes.ServerSDK: newDiagnosticEventEndpointDispatcher(config, httpConfig, relayInfo, loggers, "/diagnostic"),
 		},
+		recentEvents: recentEvents,
 	}
 	if mobileKey.Defined() {
 		ep.analyticsEndpoints[basictypes.MobileSDK] = newAnalyticsEventEndpointDispatcher(mobileKey,
-			config, httpConfig, storeAdapter, loggers, "/mobile", eventQueueCleanupInterval)
-		ep.diagnosticEndpoints[basictypes.MobileSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers, "/mobile/events/diagnostic")
+			config, forwardingURIs, httpConfig, storeAdapter, recentEvents, onUnknownFlagEvents, loggers, "/mobile", eventQueueCleanupInterval)
+		ep.analyticsEndpoints[basictypes.MobileSDK].strictValidation = strictClientSideValidation
+		ep.diagnosticEndpoints[basictypes.MobileSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, relayInfo, loggers, "/mobile/events/diagnostic")
 	}
 	if envID.Defined() {
-		ep.analyticsEndpoints[basictypes.JSClientSDK] = newAnalyticsEventEndpointDispatcher(envID, config, httpConfig, storeAdapter, loggers,
+		ep.analyticsEndpoints[basictypes.JSClientSDK] = newAnalyticsEventEndpointDispatcher(envID, config, forwardingURIs, httpConfig, storeAdapter, recentEvents, onUnknownFlagEvents, loggers,
 			"/events/bulk/"+string(envID), eventQueueCleanupInterval)
-		ep.diagnosticEndpoints[basictypes.JSClientSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, loggers,
+		ep.analyticsEndpoints[basictypes.JSClientSDK].strictValidation = strictClientSideValidation
+		ep.diagnosticEndpoints[basictypes.JSClientSDK] = newDiagnosticEventEndpointDispatcher(config, httpConfig, relayInfo, loggers,
 			"/events/diagnostic/"+string(envID))
 	}
 	return ep
@@ -239,6 +386,15 @@ func (r *EventDispatcher) flush() { //nolint:unused // used only in tests
 	}
 }
 
+// GetRecentEvents returns the most recent batches of analytics events that were received for this
+// environment, oldest first. It returns nil if recent events are not being retained.
+func (r *EventDispatcher) GetRecentEvents() []RecentEventBatch {
+	if r.recentEvents == nil {
+		return nil
+	}
+	return r.recentEvents.getAll()
+}
+
 // ReplaceCredential changes the authorization credentail that is used when forwarding events to any
 // endpoints that use that type of credential. For instance, if newCredential is a MobileKey, this
 // affects only endpoints that use a mobile key.
@@ -253,6 +409,7 @@ func (r *EventDispatcher) ReplaceCredential(newCredential credential.SDKCredenti
 func newDiagnosticEventEndpointDispatcher(
 	config c.EventsConfig,
 	httpConfig httpconfig.HTTPConfig,
+	relayInfo json.RawMessage,
 	loggers ldlog.Loggers,
 	remotePath string,
 ) *diagnosticEventEndpointDispatcher {
@@ -262,6 +419,7 @@ func newDiagnosticEventEndpointDispatcher(
 		httpConfig: httpConfig,
 		baseURI:    eventsURI,
 		uriPath:    remotePath,
+		relayInfo:  relayInfo,
 		loggers:    loggers,
 	}
 }
@@ -269,8 +427,11 @@ func newDiagnosticEventEndpointDispatcher(
 func newAnalyticsEventEndpointDispatcher(
 	authKey credential.SDKCredential,
 	config c.EventsConfig,
+	forwardingURIs []string,
 	httpConfig httpconfig.HTTPConfig,
 	storeAdapter *store.SSERelayDataStoreAdapter,
+	recentEvents *recentEventsBuffer,
+	onUnknownFlagEvents func(count int),
 	loggers ldlog.Loggers,
 	remotePath string,
 	eventQueueCleanupInterval time.Duration,
@@ -281,8 +442,11 @@ func newAnalyticsEventEndpointDispatcher(
 		httpClient:                httpConfig.Client(),
 		httpConfig:                httpConfig,
 		storeAdapter:              storeAdapter,
+		recentEvents:              recentEvents,
+		onUnknownFlagEvents:       onUnknownFlagEvents,
 		loggers:                   loggers,
 		remotePath:                remotePath,
+		forwardingURIs:            forwardingURIs,
 		eventQueueCleanupInterval: eventQueueCleanupInterval,
 	}
 }
@@ -299,6 +463,8 @@ func newEventVerbatimRelay(
 		OptionCapacity(config.Capacity.GetOrElse(c.DefaultEventCapacity)),
 		OptionBaseURI(eventsURI),
 		OptionURIPath(remotePath),
+		OptionMaxBatchSize(config.MaxBatchSize.GetOrElse(0)),
+		OptionGzip(config.Compression == c.EventsCompressionGzip),
 	}
 
 	opts = append(opts, OptionFlushInterval(config.FlushInterval.GetOrElse(c.DefaultEventsFlushInterval)))
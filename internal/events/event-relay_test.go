@@ -1,6 +1,9 @@
 package events
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sort"
@@ -20,6 +23,7 @@ import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
@@ -67,6 +71,9 @@ var allTestEndpoints = []testEndpointInfo{testServerEndpointInfo, testMobileEndp
 
 type eventRelayTestOptions struct {
 	eventQueueCleanupInterval time.Duration
+	forwardingURIs            []string
+	strictValidation          bool
+	onUnknownFlagEvents       func(count int)
 }
 
 type eventRelayTestParams struct {
@@ -96,7 +103,7 @@ func eventRelayTestWithOptions(
 	mockLog.Loggers.SetMinLevel(ldlog.Debug)
 	defer mockLog.DumpIfTestFailed(t)
 
-	httpConfig, _ := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", mockLog.Loggers)
+	httpConfig, _ := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", "", mockLog.Loggers)
 
 	store := st.NewInMemoryStore()
 
@@ -113,10 +120,15 @@ func eventRelayTestWithOptions(
 			testEnv.Config.SDKKey,
 			testEnv.Config.MobileKey,
 			testEnv.Config.EnvID,
+			testEnv.Name,
+			"1.2.3",
 			mockLog.Loggers,
 			eventsConfig,
+			opts.forwardingURIs,
+			opts.strictValidation,
 			httpConfig,
 			makeStoreAdapterWithExistingStore(store),
+			opts.onUnknownFlagEvents,
 			opts.eventQueueCleanupInterval,
 		)
 		defer dispatcher.Close()
@@ -276,6 +288,44 @@ func TestDiagnosticEventForwarding(t *testing.T) {
 	}
 }
 
+func TestDiagnosticEventForwardingWithRelayInfo(t *testing.T) {
+	eventsConfig := config.EventsConfig{AugmentDiagnosticEvents: true}
+	expectedRelayInfo := `{"version": "1.2.3", "environmentName": "` + st.EnvWithAllCredentials.Name + `"}`
+
+	postDiagnosticEvent := func(p eventRelayTestParams, body string) string {
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(0))
+		handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.DiagnosticEventDataKind)
+		require.NotNil(t, handler)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+		r := helpers.RequireValue(t, p.requestsCh, time.Second)
+		return string(r.Body)
+	}
+
+	t.Run("adds relay info", func(t *testing.T) {
+		eventRelayTest(t, st.EnvWithAllCredentials, eventsConfig, func(p eventRelayTestParams) {
+			body := postDiagnosticEvent(p, `{"kind": "diagnostic", "id": {"diagnosticId": "x"}, "platform": {"name": "Go"}}`)
+			m.In(t).Assert(body, m.JSONStrEqual(`{"kind": "diagnostic", "id": {"diagnosticId": "x"}, "platform": {"name": "Go"}, "relay": `+
+				expectedRelayInfo+`}`))
+		})
+	})
+
+	t.Run("does not replace existing relay info", func(t *testing.T) {
+		eventRelayTest(t, st.EnvWithAllCredentials, eventsConfig, func(p eventRelayTestParams) {
+			event := `{"kind": "diagnostic", "relay": {"version": "0.0.1"}}`
+			assert.Equal(t, event, postDiagnosticEvent(p, event))
+		})
+	})
+
+	t.Run("forwards event verbatim if it is not an object", func(t *testing.T) {
+		eventRelayTest(t, st.EnvWithAllCredentials, eventsConfig, func(p eventRelayTestParams) {
+			assert.Equal(t, eventPayloadForVerbatimOnly, postDiagnosticEvent(p, eventPayloadForVerbatimOnly))
+		})
+	})
+}
+
 func TestEventDispatcherReplaceCredential(t *testing.T) {
 	summarizeEventsParams := makeBasicSummarizeEventsParams()
 
@@ -359,6 +409,148 @@ func TestEventHandlersRejectEmptyBody(t *testing.T) {
 	})
 }
 
+func TestEventHandlersAcceptPlainTextBody(t *testing.T) {
+	// This is what browsers send if the JS SDK delivers events with navigator.sendBeacon
+	plainTextHeaders := func() http.Header {
+		headers := headersWithEventSchema(CurrentEventsSchemaVersion)
+		headers.Set("Content-Type", "text/plain;charset=UTF-8")
+		return headers
+	}
+
+	for _, e := range allTestEndpoints {
+		t.Run(string(e.sdkKind), func(t *testing.T) {
+			eventRelayTest(t, st.EnvWithAllCredentials, config.EventsConfig{}, func(p eventRelayTestParams) {
+				handler := p.dispatcher.GetHandler(e.sdkKind, ldevents.AnalyticsEventDataKind)
+				require.NotNil(t, handler)
+
+				w := httptest.NewRecorder()
+				handler(w, st.BuildRequest("POST", "/", []byte(`[{"no`), plainTextHeaders()))
+				assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+
+				w = httptest.NewRecorder()
+				handler(w, st.BuildRequest("POST", "/", []byte(eventPayloadForVerbatimOnly), plainTextHeaders()))
+				assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+				p.dispatcher.flush()
+
+				r := helpers.RequireValue(t, p.requestsCh, time.Second)
+				assert.Equal(t, e.analyticsPath, r.Request.URL.Path)
+				assert.Equal(t, "application/json", r.Request.Header.Get("Content-Type"))
+				assert.Equal(t, eventPayloadForVerbatimOnly, string(r.Body))
+			})
+		})
+	}
+}
+
+func TestEventHandlersWithStrictValidation(t *testing.T) {
+	validPayload := `[{"kind":"custom","key":"event1","contextKeys":{"user":"a"},"creationDate":1000}]`
+	invalidPayload := `[{"kind":"custom","key":"event1"}]`
+	opts := eventRelayTestOptions{strictValidation: true}
+
+	for _, e := range []testEndpointInfo{testMobileEndpointInfo, testJSClientEndpointInfo} {
+		t.Run(string(e.sdkKind), func(t *testing.T) {
+			eventRelayTestWithOptions(t, st.EnvWithAllCredentials, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+				handler := p.dispatcher.GetHandler(e.sdkKind, ldevents.AnalyticsEventDataKind)
+				require.NotNil(t, handler)
+
+				w := httptest.NewRecorder()
+				handler(w, st.BuildRequest("POST", "/", []byte(invalidPayload), headersWithEventSchema(CurrentEventsSchemaVersion)))
+				assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+				assert.Contains(t, w.Body.String(), "must have a context or user")
+
+				w = httptest.NewRecorder()
+				handler(w, st.BuildRequest("POST", "/", []byte(validPayload), headersWithEventSchema(CurrentEventsSchemaVersion)))
+				assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+				p.dispatcher.flush()
+				r := helpers.RequireValue(t, p.requestsCh, time.Second)
+				assert.Equal(t, validPayload, string(r.Body))
+				helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*20)
+			})
+		})
+	}
+
+	t.Run("server-side events are not validated", func(t *testing.T) {
+		eventRelayTestWithOptions(t, st.EnvWithAllCredentials, config.EventsConfig{}, opts, func(p eventRelayTestParams) {
+			handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+			w := httptest.NewRecorder()
+			handler(w, st.BuildRequest("POST", "/", []byte(eventPayloadForVerbatimOnly), headersWithEventSchema(CurrentEventsSchemaVersion)))
+			assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+		})
+	})
+}
+
+func TestEventHandlersWithUnknownFlags(t *testing.T) {
+	payload := `[{"kind":"feature","key":"known-flag"},{"kind":"feature","key":"unknown-flag"},` +
+		`{"kind":"summary","features":{"known-flag":{},"unknown-flag":{}}},` +
+		`{"kind":"summary","features":{"unknown-flag":{}}},{"kind":"custom","key":"event1"}]`
+
+	for _, mode := range []string{"", config.EventsUnknownFlagsForward, config.EventsUnknownFlagsCount, config.EventsUnknownFlagsDrop} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			var counted []int
+			opts := eventRelayTestOptions{onUnknownFlagEvents: func(count int) { counted = append(counted, count) }}
+			eventsConfig := config.EventsConfig{UnknownFlags: mode}
+			eventRelayTestWithOptions(t, st.EnvWithAllCredentials, eventsConfig, opts, func(p eventRelayTestParams) {
+				require.NoError(t, p.dataStore.Init(nil))
+				_, _ = st.UpsertFlag(p.dataStore, ldbuilders.NewFlagBuilder("known-flag").Build())
+
+				handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+				w := httptest.NewRecorder()
+				handler(w, st.BuildRequest("POST", "/", []byte(payload), headersWithEventSchema(CurrentEventsSchemaVersion)))
+				assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+				p.dispatcher.flush()
+				r := helpers.RequireValue(t, p.requestsCh, time.Second)
+				switch mode {
+				case config.EventsUnknownFlagsDrop:
+					assert.JSONEq(t, `[{"kind":"feature","key":"known-flag"},`+
+						`{"kind":"summary","features":{"known-flag":{}}},{"kind":"custom","key":"event1"}]`, string(r.Body))
+					assert.Equal(t, []int{3}, counted)
+				case config.EventsUnknownFlagsCount:
+					assert.JSONEq(t, payload, string(r.Body))
+					assert.Equal(t, []int{3}, counted)
+				default:
+					assert.JSONEq(t, payload, string(r.Body))
+					assert.Len(t, counted, 0)
+				}
+			})
+		})
+	}
+
+	t.Run("nothing is counted or dropped if the store is not initialized", func(t *testing.T) {
+		var counted []int
+		opts := eventRelayTestOptions{onUnknownFlagEvents: func(count int) { counted = append(counted, count) }}
+		eventsConfig := config.EventsConfig{UnknownFlags: config.EventsUnknownFlagsDrop}
+		eventRelayTestWithOptions(t, st.EnvWithAllCredentials, eventsConfig, opts, func(p eventRelayTestParams) {
+			handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+			w := httptest.NewRecorder()
+			handler(w, st.BuildRequest("POST", "/", []byte(payload), headersWithEventSchema(CurrentEventsSchemaVersion)))
+			assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+			p.dispatcher.flush()
+			r := helpers.RequireValue(t, p.requestsCh, time.Second)
+			assert.JSONEq(t, payload, string(r.Body))
+			assert.Len(t, counted, 0)
+		})
+	})
+
+	t.Run("batch with only unknown flags is not delivered in drop mode", func(t *testing.T) {
+		eventsConfig := config.EventsConfig{UnknownFlags: config.EventsUnknownFlagsDrop}
+		eventRelayTestWithOptions(t, st.EnvWithAllCredentials, eventsConfig, eventRelayTestOptions{}, func(p eventRelayTestParams) {
+			require.NoError(t, p.dataStore.Init(nil))
+
+			handler := p.dispatcher.GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+			w := httptest.NewRecorder()
+			handler(w, st.BuildRequest("POST", "/", []byte(`[{"kind":"feature","key":"unknown-flag"}]`),
+				headersWithEventSchema(CurrentEventsSchemaVersion)))
+			assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+
+			p.dispatcher.flush()
+			helpers.AssertNoMoreValues(t, p.requestsCh, time.Millisecond*50)
+		})
+	})
+}
+
 func headersWithEventSchema(schemaVersion int) http.Header {
 	headers := make(http.Header)
 	headers.Set("Content-Type", "application/json")
@@ -369,7 +561,121 @@ func headersWithEventSchema(schemaVersion int) http.Header {
 }
 
 func makeStoreAdapterWithExistingStore(s subsystems.DataStore) *store.SSERelayDataStoreAdapter {
-	a := store.NewSSERelayDataStoreAdapter(st.ExistingInstance(s), nil)
+	a := store.NewSSERelayDataStoreAdapter(st.ExistingInstance(s), nil, store.WriteRetryConfig{}, store.CircuitBreakerConfig{})
 	_, _ = a.Build(subsystems.BasicClientContext{}) // ensure the wrapped store has been created
 	return a
 }
+
+func TestEventForwardingToAdditionalDestinations(t *testing.T) {
+	summarizeEventsParams := makeBasicSummarizeEventsParams()
+
+	withForwardingDestinationAndConfig := func(t *testing.T, handler http.Handler, eventsConfig config.EventsConfig,
+		fn func(eventRelayTestParams)) {
+		httphelpers.WithServer(handler, func(server *httptest.Server) {
+			opts := eventRelayTestOptions{forwardingURIs: []string{server.URL}}
+			eventRelayTestWithOptions(t, st.EnvWithAllCredentials, eventsConfig, opts, fn)
+		})
+	}
+	withForwardingDestination := func(t *testing.T, handler http.Handler, fn func(eventRelayTestParams)) {
+		withForwardingDestinationAndConfig(t, handler, config.EventsConfig{}, fn)
+	}
+
+	postEvents := func(t *testing.T, p eventRelayTestParams, e testEndpointInfo, body string, schemaVersion int) {
+		req := st.BuildRequest("POST", "/", []byte(body), headersWithEventSchema(schemaVersion))
+		handler := p.dispatcher.GetHandler(e.sdkKind, ldevents.AnalyticsEventDataKind)
+		require.NotNil(t, handler)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Result().StatusCode)
+	}
+
+	t.Run("verbatim events", func(t *testing.T) {
+		for _, e := range allTestEndpoints {
+			t.Run(string(e.sdkKind), func(t *testing.T) {
+				handler, forwardedCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+				withForwardingDestination(t, handler, func(p eventRelayTestParams) {
+					postEvents(t, p, e, eventPayloadForVerbatimOnly, CurrentEventsSchemaVersion)
+					p.dispatcher.flush()
+
+					r := helpers.RequireValue(t, p.requestsCh, time.Second)
+					assert.Equal(t, e.analyticsPath, r.Request.URL.Path)
+					assert.Equal(t, eventPayloadForVerbatimOnly, string(r.Body))
+
+					f := helpers.RequireValue(t, forwardedCh, time.Second)
+					assert.Equal(t, "POST", f.Request.Method)
+					assert.Equal(t, e.analyticsPath, f.Request.URL.Path)
+					assert.Equal(t, "", f.Request.Header.Get("Authorization"))
+					assert.Equal(t, strconv.Itoa(CurrentEventsSchemaVersion), f.Request.Header.Get(EventSchemaHeader))
+					assert.Equal(t, eventPayloadForVerbatimOnly, string(f.Body))
+				})
+			})
+		}
+	})
+
+	t.Run("summarized events", func(t *testing.T) {
+		handler, forwardedCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+		withForwardingDestination(t, handler, func(p eventRelayTestParams) {
+			postEvents(t, p, testServerEndpointInfo, summarizeEventsParams.inputEventsJSON,
+				summarizeEventsParams.schemaVersion)
+			p.dispatcher.flush()
+
+			r := helpers.RequireValue(t, p.requestsCh, time.Second)
+			m.In(t).Assert(r.Body, m.JSONStrEqual(summarizeEventsParams.expectedEventsJSON))
+
+			// The summarized output is handed to the forwarder when it is delivered to LaunchDarkly, so
+			// it's in the forwarder's queue only after the first flush
+			p.dispatcher.flush()
+
+			f := helpers.RequireValue(t, forwardedCh, time.Second)
+			assert.Equal(t, testServerEndpointInfo.analyticsPath, f.Request.URL.Path)
+			assert.Equal(t, "", f.Request.Header.Get("Authorization"))
+			assert.Equal(t, strconv.Itoa(CurrentEventsSchemaVersion), f.Request.Header.Get(EventSchemaHeader))
+			m.In(t).Assert(f.Body, m.JSONStrEqual(summarizeEventsParams.expectedEventsJSON))
+		})
+	})
+
+	t.Run("gzip compression", func(t *testing.T) {
+		handler, forwardedCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+		eventsConfig := config.EventsConfig{Compression: config.EventsCompressionGzip}
+		withForwardingDestinationAndConfig(t, handler, eventsConfig, func(p eventRelayTestParams) {
+			postEvents(t, p, testServerEndpointInfo, eventPayloadForVerbatimOnly, CurrentEventsSchemaVersion)
+			p.dispatcher.flush()
+			_ = helpers.RequireValue(t, p.requestsCh, time.Second)
+
+			f := helpers.RequireValue(t, forwardedCh, time.Second)
+			assert.Equal(t, "gzip", f.Request.Header.Get("Content-Encoding"))
+			zr, err := gzip.NewReader(bytes.NewReader(f.Body))
+			require.NoError(t, err)
+			body, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, eventPayloadForVerbatimOnly, string(body))
+		})
+	})
+
+	t.Run("unresponsive destination does not block delivery to LaunchDarkly", func(t *testing.T) {
+		release := make(chan struct{})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		withForwardingDestination(t, handler, func(p eventRelayTestParams) {
+			defer close(release)
+			for i := 0; i < 3; i++ {
+				postEvents(t, p, testServerEndpointInfo, eventPayloadForVerbatimOnly, CurrentEventsSchemaVersion)
+				p.dispatcher.flush()
+
+				r := helpers.RequireValue(t, p.requestsCh, time.Second)
+				assert.Equal(t, eventPayloadForVerbatimOnly, string(r.Body))
+			}
+		})
+	})
+
+	t.Run("no destinations", func(t *testing.T) {
+		eventRelayTest(t, st.EnvWithAllCredentials, config.EventsConfig{}, func(p eventRelayTestParams) {
+			postEvents(t, p, testServerEndpointInfo, eventPayloadForVerbatimOnly, CurrentEventsSchemaVersion)
+			p.dispatcher.flush()
+			_ = helpers.RequireValue(t, p.requestsCh, time.Second)
+			assert.Nil(t, p.dispatcher.analyticsEndpoints[basictypes.ServerSDK].getForwarders())
+		})
+	})
+}
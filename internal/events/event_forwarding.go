@@ -0,0 +1,49 @@
+package events
+
+import (
+	"encoding/json"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// newEventForwarders creates an EventPublisher for each additional destination that should receive a
+// copy of the analytics events that Relay delivers to LaunchDarkly. The events are posted to the same
+// path under each destination URI that they would be posted to under the LaunchDarkly events URI.
+//
+// Each forwarder has its own queue, flush schedule, and retry behavior, so a destination that is slow
+// or failing does not hold up delivery to LaunchDarkly or to any other destination. The SDK credential
+// is not sent to these destinations.
+func newEventForwarders(
+	uris []string,
+	config c.EventsConfig,
+	httpConfig httpconfig.HTTPConfig,
+	loggers ldlog.Loggers,
+	remotePath string,
+) []EventPublisher {
+	forwarders := make([]EventPublisher, 0, len(uris))
+	for _, uri := range uris {
+		publisher, err := NewHTTPEventPublisher(nil, httpConfig, loggers,
+			OptionCapacity(config.Capacity.GetOrElse(c.DefaultEventCapacity)),
+			OptionFlushInterval(config.FlushInterval.GetOrElse(c.DefaultEventsFlushInterval)),
+			OptionBaseURI(uri),
+			OptionURIPath(remotePath),
+			OptionMaxBatchSize(config.MaxBatchSize.GetOrElse(0)),
+			OptionGzip(config.Compression == c.EventsCompressionGzip),
+		)
+		if err != nil { // COVERAGE: can't happen in unit tests
+			loggers.Errorf("Unable to forward events to %s: %s", uri, err)
+			continue
+		}
+		forwarders = append(forwarders, publisher)
+	}
+	return forwarders
+}
+
+func forwardEvents(forwarders []EventPublisher, metadata EventPayloadMetadata, evts []json.RawMessage) {
+	for _, f := range forwarders {
+		f.Publish(metadata, evts...)
+	}
+}
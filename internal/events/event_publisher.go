@@ -1,7 +1,11 @@
 package events
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -13,6 +17,7 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
@@ -23,10 +28,12 @@ const (
 	defaultCapacity      = 1000
 	inputQueueSize       = 100
 	defaultEventsURIPath = "/bulk"
+	eventForwardSpanName = "event-forward"
 )
 
 var (
 	defaultEventsBaseURI, _ = url.Parse("https://events.launchdarkly.com") //nolint:gochecknoglobals
+	errEventDeliveryFailed  = errors.New("event delivery failed")
 )
 
 // EventPublisher is the interface for the component that buffers events and delivers them to LaunchDarkly.
@@ -103,10 +110,12 @@ type HTTPEventPublisher struct {
 	disableQueue chan interface{}
 	disabled     bool
 
-	queues     map[EventPayloadMetadata]*publisherQueue
-	capacity   int
-	overflowed bool
-	lock       sync.RWMutex
+	queues       map[EventPayloadMetadata]*publisherQueue
+	capacity     int
+	maxBatchSize int
+	gzip         bool
+	overflowed   bool
+	lock         sync.RWMutex
 }
 
 type eventBatch struct {
@@ -159,6 +168,24 @@ func (o OptionCapacity) apply(p *HTTPEventPublisher) error {
 	return nil
 }
 
+// OptionMaxBatchSize specifies the maximum number of events to deliver in a single HTTP post. If more
+// events than this are queued when the publisher flushes, they are delivered in several posts. Zero means
+// there is no limit.
+type OptionMaxBatchSize int
+
+func (o OptionMaxBatchSize) apply(p *HTTPEventPublisher) error {
+	p.maxBatchSize = int(o)
+	return nil
+}
+
+// OptionGzip specifies whether to compress the body of each HTTP post with gzip.
+type OptionGzip bool
+
+func (o OptionGzip) apply(p *HTTPEventPublisher) error {
+	p.gzip = bool(o)
+	return nil
+}
+
 // NewHTTPEventPublisher creates a new HTTPEventPublisher.
 func NewHTTPEventPublisher(authKey credential.SDKCredential, httpConfig httpconfig.HTTPConfig, loggers ldlog.Loggers, options ...OptionType) (*HTTPEventPublisher, error) {
 	closer := make(chan struct{})
@@ -306,56 +333,114 @@ func (p *HTTPEventPublisher) flush() {
 	p.lock.RUnlock()
 
 	for metadata, queue := range queues {
-		count := len(queue.events)
-		if count == 0 {
+		if len(queue.events) == 0 {
 			continue
 		}
-		payload, err := json.Marshal(queue.events)
+		batches := splitEventBatches(queue.events, p.maxBatchSize)
 		queue.events = queue.events[0:0]
 		if discardingUnusedBuffers {
 			p.queues[metadata] = queue
 		}
+		for _, batch := range batches {
+			p.send(metadata, authKey, batch)
+		}
+	}
+}
+
+// splitEventBatches returns the events as a list of payloads of at most maxBatchSize events each, or a
+// single payload if maxBatchSize is zero. The payloads do not share memory with the events slice, since
+// that will be reused for the next batch.
+func splitEventBatches(events []json.RawMessage, maxBatchSize int) [][]json.RawMessage {
+	if maxBatchSize <= 0 {
+		maxBatchSize = len(events)
+	}
+	batches := make([][]json.RawMessage, 0, (len(events)+maxBatchSize-1)/maxBatchSize)
+	for start := 0; start < len(events); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batches = append(batches, append([]json.RawMessage(nil), events[start:end]...))
+	}
+	return batches
+}
+
+func (p *HTTPEventPublisher) send(metadata EventPayloadMetadata, authKey credential.SDKCredential, events []json.RawMessage) {
+	count := len(events)
+	payload, err := json.Marshal(events)
+	if err != nil { // COVERAGE: can't happen in unit tests
+		p.loggers.Errorf("Unexpected error marshalling event json: %+v", err)
+		return
+	}
+	if p.gzip {
+		payload, err = gzipPayload(payload)
 		if err != nil { // COVERAGE: can't happen in unit tests
-			p.loggers.Errorf("Unexpected error marshalling event json: %+v", err)
-			continue
+			p.loggers.Errorf("Unexpected error compressing event json: %+v", err)
+			return
 		}
-		p.wg.Add(1)
+	}
+	p.wg.Add(1)
 
-		schemaVersion := metadata.SchemaVersion
-		tags := metadata.Tags
+	schemaVersion := metadata.SchemaVersion
+	tags := metadata.Tags
 
-		getBaseHeaders := func() http.Header {
-			ret := make(http.Header)
-			for k, v := range p.baseHeaders {
-				ret[k] = v
-			}
-			if authKey != nil && authKey.GetAuthorizationHeaderValue() != "" {
-				ret.Set("Authorization", authKey.GetAuthorizationHeaderValue())
-			}
-			if tags != "" {
-				ret.Set(TagsHeader, tags)
-			}
-			return ret
+	getBaseHeaders := func() http.Header {
+		ret := make(http.Header)
+		for k, v := range p.baseHeaders {
+			ret[k] = v
+		}
+		if authKey != nil && authKey.GetAuthorizationHeaderValue() != "" {
+			ret.Set("Authorization", authKey.GetAuthorizationHeaderValue())
+		}
+		if tags != "" {
+			ret.Set(TagsHeader, tags)
 		}
+		if p.gzip {
+			ret.Set("Content-Encoding", "gzip")
+		}
+		return ret
+	}
 
-		go func() {
-			// The EventSender created by ldevents.NewDefaultEventSender implements the standard retry behavior,
-			// and error logging, in its SendEventData method. Retries could cause this call to block for a while,
-			// so it's run on a separate goroutine.
+	go func() {
+		// The EventSender created by ldevents.NewDefaultEventSender implements the standard retry behavior,
+		// and error logging, in its SendEventData method. Retries could cause this call to block for a while,
+		// so it's run on a separate goroutine.
+		var result ldevents.EventSenderResult
+		_ = tracing.WithSpan(context.Background(), eventForwardSpanName, func(ctx context.Context) error {
 			sendConfig := ldevents.EventSenderConfiguration{
-				Client:        p.client,
-				BaseURI:       p.baseURI,
-				BaseHeaders:   getBaseHeaders,
+				Client:  p.client,
+				BaseURI: p.baseURI,
+				BaseHeaders: func() http.Header {
+					h := getBaseHeaders()
+					tracing.AddTraceHeaders(ctx, h)
+					return h
+				},
 				SchemaVersion: schemaVersion,
 				Loggers:       p.loggers,
 			}
-			result := ldevents.SendEventDataWithRetry(sendConfig, ldevents.AnalyticsEventDataKind, p.uriPath, payload, count)
-			p.wg.Done()
-			if result.MustShutDown {
-				p.disableQueue <- struct{}{}
+			result = ldevents.SendEventDataWithRetry(sendConfig, ldevents.AnalyticsEventDataKind, p.uriPath, payload, count)
+			if !result.Success {
+				return errEventDeliveryFailed
 			}
-		}()
+			return nil
+		})
+		p.wg.Done()
+		if result.MustShutDown {
+			p.disableQueue <- struct{}{}
+		}
+	}()
+}
+
+func gzipPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
 }
 
 func (p *HTTPEventPublisher) Close() { //nolint:golint // method is already documented in interface
@@ -1,7 +1,10 @@
 package events
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sort"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
@@ -19,12 +23,14 @@ import (
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
 )
 
 const testSDKKey = config.SDKKey("my-key")
 
 func defaultHTTPConfig() httpconfig.HTTPConfig {
-	hc, err := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", ldlog.NewDisabledLoggers())
+	hc, err := httpconfig.NewHTTPConfig(config.ProxyConfig{}, nil, "", "", ldlog.NewDisabledLoggers())
 	if err != nil {
 		panic(err)
 	}
@@ -49,6 +55,38 @@ func TestHTTPEventPublisherSimple(t *testing.T) {
 	})
 }
 
+type noOpTraceExporter struct{}
+
+func (noOpTraceExporter) ExportSpan(*trace.SpanData) {}
+
+func TestHTTPEventPublisherSendsTraceContext(t *testing.T) {
+	publishAndGetTraceParent := func(t *testing.T) string {
+		handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+		var traceParent string
+		httphelpers.WithServer(handler, func(server *httptest.Server) {
+			publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), ldlog.NewDisabledLoggers(),
+				OptionBaseURI(server.URL))
+			defer publisher.Close()
+			publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"hello"`))
+			publisher.Flush()
+			r := helpers.RequireValue(t, requestsCh, time.Second)
+			traceParent = r.Request.Header.Get("traceparent")
+		})
+		return traceParent
+	}
+
+	t.Run("sent if a trace exporter is registered", func(t *testing.T) {
+		exporter := noOpTraceExporter{}
+		tracing.RegisterExporter(exporter)
+		defer tracing.UnregisterExporter(exporter)
+		assert.Regexp(t, "^00-[0-9a-f]{32}-[0-9a-f]{16}-0[01]$", publishAndGetTraceParent(t))
+	})
+
+	t.Run("not sent if no trace exporter is registered", func(t *testing.T) {
+		assert.Equal(t, "", publishAndGetTraceParent(t))
+	})
+}
+
 func TestHTTPEventPublisherMultiQueuesWithMetadata(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
@@ -111,6 +149,54 @@ func TestHTTPEventPublisherOptionURIPath(t *testing.T) {
 	})
 }
 
+func TestHTTPEventPublisherOptionMaxBatchSize(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers,
+			OptionBaseURI(server.URL), OptionMaxBatchSize(2))
+		defer publisher.Close()
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"a"`), json.RawMessage(`"b"`),
+			json.RawMessage(`"c"`), json.RawMessage(`"d"`), json.RawMessage(`"e"`))
+		publisher.Flush()
+
+		var bodies []string
+		for i := 0; i < 3; i++ {
+			r := helpers.RequireValue(t, requestsCh, time.Second)
+			assert.Equal(t, "/bulk", r.Request.URL.Path)
+			assert.Equal(t, string(testSDKKey), r.Request.Header.Get("Authorization"))
+			bodies = append(bodies, string(r.Body))
+		}
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50)
+		sort.Strings(bodies)
+		m.In(t).Assert(bodies[0], m.JSONStrEqual(`["a", "b"]`))
+		m.In(t).Assert(bodies[1], m.JSONStrEqual(`["c", "d"]`))
+		m.In(t).Assert(bodies[2], m.JSONStrEqual(`["e"]`))
+	})
+}
+
+func TestHTTPEventPublisherOptionGzip(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		publisher, _ := NewHTTPEventPublisher(testSDKKey, defaultHTTPConfig(), mockLog.Loggers,
+			OptionBaseURI(server.URL), OptionGzip(true))
+		defer publisher.Close()
+		publisher.Publish(EventPayloadMetadata{}, json.RawMessage(`"hello"`))
+		publisher.Flush()
+		r := helpers.RequireValue(t, requestsCh, time.Second)
+		assert.Equal(t, "gzip", r.Request.Header.Get("Content-Encoding"))
+		assert.Equal(t, strconv.Itoa(CurrentEventsSchemaVersion), r.Request.Header.Get(EventSchemaHeader))
+		zr, err := gzip.NewReader(bytes.NewReader(r.Body))
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		m.In(t).Assert(body, m.JSONStrEqual(`["hello"]`))
+	})
+}
+
 func TestHTTPEventPublisherClosesImmediatelyAndOnlyOnce(t *testing.T) {
 	publisher, _ := NewHTTPEventPublisher(config.SDKKey("my-key"), defaultHTTPConfig(), ldlog.NewDisabledLoggers())
 	timeout := time.After(time.Second)
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+type clientSideEventKindRules struct {
+	needsKey     bool
+	needsContext bool
+}
+
+// clientSideEventKinds lists the event kinds that mobile and JS client-side SDKs may send, and what each
+// one must contain. Summary and alias events are not associated with a single evaluation context.
+var clientSideEventKinds = map[string]clientSideEventKindRules{ //nolint:gochecknoglobals
+	"feature":  {needsKey: true, needsContext: true},
+	"debug":    {needsKey: true, needsContext: true},
+	"custom":   {needsKey: true, needsContext: true},
+	"click":    {needsKey: true, needsContext: true},
+	"pageview": {needsKey: true, needsContext: true},
+	"identify": {needsContext: true},
+	"index":    {needsContext: true},
+	"summary":  {},
+	"alias":    {},
+}
+
+// clientSideEventFields is the subset of event properties that we check in strict validation mode. Both the
+// current schema (context, contextKeys) and the older user-based schema (user, userKey) are accepted.
+type clientSideEventFields struct {
+	Kind        string            `json:"kind"`
+	Key         *string           `json:"key"`
+	Context     json.RawMessage   `json:"context"`
+	ContextKeys map[string]string `json:"contextKeys"`
+	User        json.RawMessage   `json:"user"`
+	UserKey     *string           `json:"userKey"`
+}
+
+// validateClientSideEvents checks that a batch of analytics events from a mobile or JS client-side SDK is
+// well-formed: it must be a JSON array of objects, each with a known event kind, and each event of a kind
+// that refers to an evaluation context must have a valid one. The returned error describes the first
+// problem found, and is meant to be returned to the caller.
+func validateClientSideEvents(body []byte) error {
+	var evts []json.RawMessage
+	if err := json.Unmarshal(body, &evts); err != nil {
+		return fmt.Errorf("body must be a JSON array of events: %w", err)
+	}
+	for i, raw := range evts {
+		if err := validateClientSideEvent(raw); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateClientSideEvent(raw json.RawMessage) error {
+	var fields clientSideEventFields
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return fmt.Errorf("not a valid event object: %w", err)
+	}
+	rules, ok := clientSideEventKinds[fields.Kind]
+	if !ok {
+		return fmt.Errorf("unknown event kind %q", fields.Kind)
+	}
+	if rules.needsKey && (fields.Key == nil || *fields.Key == "") {
+		return fmt.Errorf("%q event must have a key", fields.Kind)
+	}
+	if !rules.needsContext {
+		return nil
+	}
+	switch {
+	case len(fields.Context) != 0:
+		return validateEventContext(fields.Context)
+	case len(fields.User) != 0:
+		return validateEventContext(fields.User)
+	case len(fields.ContextKeys) != 0:
+		for kind, key := range fields.ContextKeys {
+			if key == "" {
+				return fmt.Errorf("context key for kind %q must not be empty", kind)
+			}
+		}
+		return nil
+	case fields.UserKey != nil && *fields.UserKey != "":
+		return nil
+	default:
+		return fmt.Errorf("%q event must have a context or user", fields.Kind)
+	}
+}
+
+func validateEventContext(data json.RawMessage) error {
+	var context ldcontext.Context
+	if err := json.Unmarshal(data, &context); err != nil {
+		return fmt.Errorf("invalid context: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateClientSideEvents(t *testing.T) {
+	valid := []string{
+		`[]`,
+		`[{"kind":"feature","key":"flag1","contextKeys":{"user":"a"}}]`,
+		`[{"kind":"feature","key":"flag1","context":{"kind":"user","key":"a"}}]`,
+		`[{"kind":"custom","key":"event1","user":{"key":"a"}}]`,
+		`[{"kind":"custom","key":"event1","userKey":"a"}]`,
+		`[{"kind":"identify","context":{"kind":"multi","user":{"key":"a"},"org":{"key":"b"}}}]`,
+		`[{"kind":"pageview","key":"page1","contextKeys":{"user":"a"}}]`,
+		`[{"kind":"summary","features":{}}]`,
+	}
+	for _, body := range valid {
+		t.Run(body, func(t *testing.T) {
+			assert.NoError(t, validateClientSideEvents([]byte(body)))
+		})
+	}
+
+	invalid := map[string]string{
+		`{"kind":"custom"}`: "JSON array",
+		`["fake-event"]`:    "event 0: not a valid event object",
+		`[{"kind":"summary"},{"kind":"unknown","key":"x","userKey":"a"}]`: `event 1: unknown event kind "unknown"`,
+		`[{"kind":"custom","userKey":"a"}]`:                               `"custom" event must have a key`,
+		`[{"kind":"feature","key":"flag1"}]`:                              `"feature" event must have a context or user`,
+		`[{"kind":"identify","userKey":""}]`:                              `"identify" event must have a context or user`,
+		`[{"kind":"identify","context":{"kind":"user","key":""}}]`:        "invalid context",
+		`[{"kind":"identify","contextKeys":{"user":""}}]`:                 `context key for kind "user" must not be empty`,
+	}
+	for body, message := range invalid {
+		t.Run(body, func(t *testing.T) {
+			err := validateClientSideEvents([]byte(body))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), message)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// maxRecentEventsBytes is the maximum total size of the event data that is retained for debugging in
+// each environment, regardless of how many batches are configured to be retained.
+const maxRecentEventsBytes = 1024 * 1024
+
+// RecentEventBatch is a batch of analytics events that Relay received and forwarded, as retained for
+// debugging by EventDispatcher.
+type RecentEventBatch struct {
+	// Time is when Relay received the batch.
+	Time ldtime.UnixMillisecondTime `json:"time"`
+	// Path is the path of the LaunchDarkly endpoint that the events are delivered to.
+	Path string `json:"path"`
+	// SchemaVersion is the event schema version that the SDK reported.
+	SchemaVersion int `json:"schemaVersion"`
+	// EventCount is the number of events in the batch.
+	EventCount int `json:"eventCount"`
+	// Events is the event data, with any private context attributes removed. It is omitted if the
+	// batch by itself would exceed the memory limit of the buffer.
+	Events []json.RawMessage `json:"events,omitempty"`
+	// Truncated is true if Events was omitted.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// recentEventsBuffer is a ring buffer of the most recent event batches. It is bounded both by the number
+// of batches and by the total size of the event data.
+type recentEventsBuffer struct {
+	batches    []RecentEventBatch
+	sizes      []int
+	next       int
+	count      int
+	totalBytes int
+	maxBytes   int
+	mu         sync.Mutex
+}
+
+func newRecentEventsBuffer(maxBatches int, maxBytes int) *recentEventsBuffer {
+	return &recentEventsBuffer{
+		batches:  make([]RecentEventBatch, maxBatches),
+		sizes:    make([]int, maxBatches),
+		maxBytes: maxBytes,
+	}
+}
+
+func (b *recentEventsBuffer) add(path string, metadata EventPayloadMetadata, evts []json.RawMessage) {
+	batch := RecentEventBatch{
+		Time:          ldtime.UnixMillisNow(),
+		Path:          path,
+		SchemaVersion: metadata.SchemaVersion,
+		EventCount:    len(evts),
+		Events:        make([]json.RawMessage, 0, len(evts)),
+	}
+	size := 0
+	for _, e := range evts {
+		redacted := redactPrivateAttributes(e)
+		batch.Events = append(batch.Events, redacted)
+		size += len(redacted)
+	}
+	if size > b.maxBytes {
+		batch.Events = nil
+		batch.Truncated = true
+		size = 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.count > 0 && (b.count == len(b.batches) || b.totalBytes+size > b.maxBytes) {
+		oldest := (b.next - b.count + len(b.batches)) % len(b.batches)
+		b.totalBytes -= b.sizes[oldest]
+		b.batches[oldest] = RecentEventBatch{}
+		b.count--
+	}
+	b.batches[b.next] = batch
+	b.sizes[b.next] = size
+	b.totalBytes += size
+	b.next = (b.next + 1) % len(b.batches)
+	b.count++
+}
+
+// getAll returns the retained batches, oldest first.
+func (b *recentEventsBuffer) getAll() []RecentEventBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ret := make([]RecentEventBatch, 0, b.count)
+	for i := 0; i < b.count; i++ {
+		ret = append(ret, b.batches[(b.next-b.count+i+len(b.batches))%len(b.batches)])
+	}
+	return ret
+}
+
+// redactPrivateAttributes removes any context attributes that are marked as private from an event. SDKs
+// normally do this themselves before sending events, so this is only a safeguard to make sure that
+// private data is never exposed by the debugging endpoint. Both the context schema ("_meta" with
+// "privateAttributes") and the older user schema ("privateAttributeNames") are recognized.
+func redactPrivateAttributes(data json.RawMessage) json.RawMessage {
+	event := ldvalue.Parse(data)
+	if event.Type() != ldvalue.ObjectType {
+		return data
+	}
+	redacted := event.Transform(func(_ int, key string, value ldvalue.Value) (ldvalue.Value, bool) {
+		if key == "context" || key == "user" {
+			return redactContext(value), true
+		}
+		return value, true
+	})
+	return json.RawMessage(redacted.JSONString())
+}
+
+func redactContext(context ldvalue.Value) ldvalue.Value {
+	if context.GetByKey("kind").StringValue() != "multi" {
+		return redactSingleKindContext(context)
+	}
+	return context.Transform(func(_ int, key string, value ldvalue.Value) (ldvalue.Value, bool) {
+		if key == "kind" {
+			return value, true
+		}
+		return redactSingleKindContext(value), true
+	})
+}
+
+func redactSingleKindContext(context ldvalue.Value) ldvalue.Value {
+	if context.Type() != ldvalue.ObjectType {
+		return context
+	}
+	for _, s := range context.GetByKey("_meta").GetByKey("privateAttributes").AsValueArray().AsSlice() {
+		ref := ldattr.NewRef(s.StringValue())
+		if ref.Err() != nil || ref.Depth() == 0 {
+			continue
+		}
+		path := make([]string, 0, ref.Depth())
+		for i := 0; i < ref.Depth(); i++ {
+			path = append(path, ref.Component(i))
+		}
+		if path[0] != "kind" && path[0] != "key" && path[0] != "_meta" {
+			context = removeAttributePath(context, path)
+		}
+	}
+	for _, s := range context.GetByKey("privateAttributeNames").AsValueArray().AsSlice() {
+		name := s.StringValue()
+		if name != "key" {
+			context = removeAttributePath(context, []string{name})
+			context = removeAttributePath(context, []string{"custom", name})
+		}
+	}
+	return context
+}
+
+func removeAttributePath(value ldvalue.Value, path []string) ldvalue.Value {
+	if value.Type() != ldvalue.ObjectType {
+		return value
+	}
+	return value.Transform(func(_ int, key string, v ldvalue.Value) (ldvalue.Value, bool) {
+		if key != path[0] {
+			return v, true
+		}
+		if len(path) == 1 {
+			return ldvalue.Null(), false
+		}
+		return removeAttributePath(v, path[1:]), true
+	})
+}
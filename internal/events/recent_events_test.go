@@ -0,0 +1,119 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentEventsBuffer(t *testing.T) {
+	metadata := EventPayloadMetadata{SchemaVersion: SummaryEventsSchemaVersion}
+	makeEvents := func(keys ...string) []json.RawMessage {
+		ret := make([]json.RawMessage, 0, len(keys))
+		for _, key := range keys {
+			ret = append(ret, json.RawMessage(`{"kind":"custom","key":"`+key+`"}`))
+		}
+		return ret
+	}
+	eventKeys := func(batches []RecentEventBatch) [][]string {
+		ret := make([][]string, 0, len(batches))
+		for _, b := range batches {
+			var keys []string
+			for _, e := range b.Events {
+				var event struct{ Key string }
+				require.NoError(t, json.Unmarshal(e, &event))
+				keys = append(keys, event.Key)
+			}
+			ret = append(ret, keys)
+		}
+		return ret
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		b := newRecentEventsBuffer(3, maxRecentEventsBytes)
+		assert.Len(t, b.getAll(), 0)
+	})
+
+	t.Run("retains batches in order", func(t *testing.T) {
+		b := newRecentEventsBuffer(3, maxRecentEventsBytes)
+		b.add("/bulk", metadata, makeEvents("a", "b"))
+		b.add("/mobile", metadata, makeEvents("c"))
+
+		batches := b.getAll()
+		assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, eventKeys(batches))
+		assert.Equal(t, "/bulk", batches[0].Path)
+		assert.Equal(t, 2, batches[0].EventCount)
+		assert.Equal(t, SummaryEventsSchemaVersion, batches[0].SchemaVersion)
+		assert.NotZero(t, batches[0].Time)
+		assert.Equal(t, "/mobile", batches[1].Path)
+	})
+
+	t.Run("discards oldest batches beyond maximum count", func(t *testing.T) {
+		b := newRecentEventsBuffer(2, maxRecentEventsBytes)
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			b.add("/bulk", metadata, makeEvents(key))
+		}
+		assert.Equal(t, [][]string{{"d"}, {"e"}}, eventKeys(b.getAll()))
+	})
+
+	t.Run("discards oldest batches beyond maximum size", func(t *testing.T) {
+		eventSize := len(makeEvents("a")[0])
+		b := newRecentEventsBuffer(10, eventSize*3)
+		b.add("/bulk", metadata, makeEvents("a", "b"))
+		b.add("/bulk", metadata, makeEvents("c"))
+		b.add("/bulk", metadata, makeEvents("d"))
+		assert.Equal(t, [][]string{{"c"}, {"d"}}, eventKeys(b.getAll()))
+	})
+
+	t.Run("omits events from a batch that is too large by itself", func(t *testing.T) {
+		eventSize := len(makeEvents("a")[0])
+		b := newRecentEventsBuffer(10, eventSize*2)
+		b.add("/bulk", metadata, makeEvents("a"))
+		b.add("/bulk", metadata, makeEvents("b", "c", "d"))
+
+		batches := b.getAll()
+		require.Len(t, batches, 2)
+		assert.Equal(t, 3, batches[1].EventCount)
+		assert.Nil(t, batches[1].Events)
+		assert.True(t, batches[1].Truncated)
+	})
+}
+
+func TestRedactPrivateAttributes(t *testing.T) {
+	redact := func(s string) string {
+		return string(redactPrivateAttributes(json.RawMessage(s)))
+	}
+
+	t.Run("event without context is unchanged", func(t *testing.T) {
+		assert.JSONEq(t, `{"kind":"summary","features":{}}`, redact(`{"kind":"summary","features":{}}`))
+	})
+
+	t.Run("non-object is unchanged", func(t *testing.T) {
+		assert.Equal(t, `"x"`, redact(`"x"`))
+	})
+
+	t.Run("single-kind context", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"kind":"index","context":{"kind":"user","key":"a","name":"b","address":{"city":"d"},
+				"_meta":{"privateAttributes":["email","/address/street","key"]}}}`,
+			redact(`{"kind":"index","context":{"kind":"user","key":"a","name":"b","email":"c",
+				"address":{"street":"x","city":"d"},"_meta":{"privateAttributes":["email","/address/street","key"]}}}`))
+	})
+
+	t.Run("multi-kind context", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"kind":"custom","context":{"kind":"multi","user":{"key":"a","_meta":{"privateAttributes":["name"]}},
+				"org":{"key":"b","name":"c"}}}`,
+			redact(`{"kind":"custom","context":{"kind":"multi","user":{"key":"a","name":"x","_meta":{"privateAttributes":["name"]}},
+				"org":{"key":"b","name":"c"}}}`))
+	})
+
+	t.Run("old user schema", func(t *testing.T) {
+		assert.JSONEq(t,
+			`{"kind":"identify","user":{"key":"a","custom":{"c":2},"privateAttributeNames":["email","secret"]}}`,
+			redact(`{"kind":"identify","user":{"key":"a","email":"x","custom":{"secret":1,"c":2},
+				"privateAttributeNames":["email","secret"]}}`))
+	})
+}
@@ -36,6 +36,7 @@ type eventSummarizingRelay struct {
 	httpClient   *http.Client
 	baseHeaders  http.Header
 	storeAdapter *store.SSERelayDataStoreAdapter
+	forwarders   []EventPublisher
 	eventsConfig ldevents.EventsConfiguration
 	baseURI      string
 	remotePath   string
@@ -53,8 +54,10 @@ type eventSummarizingRelayQueue struct {
 }
 
 type delegatingEventSender struct {
-	wrapped ldevents.EventSender
-	lock    sync.Mutex
+	wrapped    ldevents.EventSender
+	forwarders []EventPublisher
+	metadata   EventPayloadMetadata
+	lock       sync.Mutex
 }
 
 func newEventSummarizingRelay(
@@ -62,6 +65,7 @@ func newEventSummarizingRelay(
 	httpConfig httpconfig.HTTPConfig,
 	credential credential.SDKCredential,
 	storeAdapter *store.SSERelayDataStoreAdapter,
+	forwarders []EventPublisher,
 	loggers ldlog.Loggers,
 	remotePath string,
 	eventQueueCleanupInterval time.Duration,
@@ -84,6 +88,7 @@ func newEventSummarizingRelay(
 		httpClient:   httpConfig.SDKHTTPConfig.CreateHTTPClient(),
 		baseHeaders:  baseHeaders,
 		storeAdapter: storeAdapter,
+		forwarders:   forwarders,
 		eventsConfig: eventsConfig,
 		baseURI:      getEventsURI(config),
 		remotePath:   remotePath,
@@ -104,7 +109,10 @@ func (er *eventSummarizingRelay) enqueue(metadata EventPayloadMetadata, rawEvent
 	queue := er.queues[metadata]
 	if queue == nil {
 		sender := &delegatingEventSender{
-			wrapped: makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, er.authKey, metadata, er.loggers),
+			wrapped:    makeEventSender(er.httpClient, er.baseURI, er.remotePath, er.baseHeaders, er.authKey, metadata, er.loggers),
+			forwarders: er.forwarders,
+			// The EventProcessor's output is always in the current schema, regardless of what we received
+			metadata: EventPayloadMetadata{SchemaVersion: CurrentEventsSchemaVersion, Tags: metadata.Tags},
 		}
 		eventsConfig := er.eventsConfig
 		eventsConfig.EventSender = sender
@@ -257,6 +265,13 @@ func (d *delegatingEventSender) SendEventData(kind ldevents.EventDataKind, data
 	d.lock.Lock()
 	sender := d.wrapped
 	d.lock.Unlock()
+	if len(d.forwarders) != 0 && kind == ldevents.AnalyticsEventDataKind {
+		// The forwarders have their own queues, so this does not wait for them to deliver anything
+		var evts []json.RawMessage
+		if err := json.Unmarshal(data, &evts); err == nil {
+			forwardEvents(d.forwarders, d.metadata, evts)
+		}
+	}
 	return sender.SendEventData(kind, data, count)
 }
 
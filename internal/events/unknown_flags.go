@@ -0,0 +1,93 @@
+package events
+
+import (
+	"encoding/json"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+)
+
+// unknownFlagEventFields is the subset of event properties that refer to flags.
+type unknownFlagEventFields struct {
+	Kind     string                     `json:"kind"`
+	Key      string                     `json:"key"`
+	Features map[string]json.RawMessage `json:"features"`
+}
+
+// checkUnknownFlags finds the references to flags that do not exist in the data store in a batch of
+// analytics events: the key of each "feature" or "debug" event, and each flag in a "summary" event. It
+// returns the number of such references and, if drop is true, a copy of the batch without them; a summary
+// event is only removed entirely if none of its flags exist. If drop is false, the batch is returned as-is.
+//
+// If the store has not been initialized, there is no way to know which flags exist, so nothing is counted.
+func checkUnknownFlags(evts []json.RawMessage, store subsystems.DataStore, drop bool) ([]json.RawMessage, int) {
+	if store == nil || !store.IsInitialized() {
+		return evts, 0
+	}
+	known := make(map[string]bool)
+	flagExists := func(key string) bool {
+		exists, ok := known[key]
+		if !ok {
+			item, err := store.Get(ldstoreimpl.Features(), key)
+			exists = err != nil || item.Item != nil // if we can't tell, assume that it exists
+			known[key] = exists
+		}
+		return exists
+	}
+
+	unknownCount := 0
+	ret := evts
+	if drop {
+		ret = make([]json.RawMessage, 0, len(evts))
+	}
+	for _, raw := range evts {
+		var fields unknownFlagEventFields
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			if drop {
+				ret = append(ret, raw)
+			}
+			continue
+		}
+		switch fields.Kind {
+		case "feature", "debug":
+			if !flagExists(fields.Key) {
+				unknownCount++
+				continue
+			}
+		case "summary":
+			removed := 0
+			for key := range fields.Features {
+				if !flagExists(key) {
+					removed++
+					delete(fields.Features, key)
+				}
+			}
+			unknownCount += removed
+			if drop && removed > 0 {
+				if len(fields.Features) == 0 {
+					continue
+				}
+				raw = withSummaryFeatures(raw, fields.Features)
+			}
+		}
+		if drop {
+			ret = append(ret, raw)
+		}
+	}
+	return ret, unknownCount
+}
+
+// withSummaryFeatures replaces the "features" property of a summary event, leaving its other properties
+// unchanged.
+func withSummaryFeatures(raw json.RawMessage, features map[string]json.RawMessage) json.RawMessage {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &props); err != nil { // COVERAGE: the event was already parsed successfully
+		return raw
+	}
+	props["features"], _ = json.Marshal(features)
+	data, err := json.Marshal(props)
+	if err != nil { // COVERAGE: can't happen, since all of the values were already valid JSON
+		return raw
+	}
+	return data
+}
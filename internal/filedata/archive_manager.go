@@ -33,12 +33,15 @@ type ArchiveManager struct {
 	loggers       ldlog.Loggers
 	closeCh       chan struct{}
 	closeOnce     sync.Once
+	failingSince  time.Time
+	failingLock   sync.Mutex
 }
 
 // ArchiveManagerInterface is an interface containing the public methods of ArchiveManager. This is used
 // for test stubbing.
 type ArchiveManagerInterface interface {
 	io.Closer
+	GetInterruptedSince() time.Time
 }
 
 // NewArchiveManager creates the ArchiveManager instance and attempts to read the initial file data.
@@ -99,6 +102,24 @@ func (am *ArchiveManager) Close() error {
 	return nil
 }
 
+// GetInterruptedSince returns the time when the ArchiveManager first failed to reload the data file, if it
+// has not successfully reloaded it since then; otherwise it returns a zero time.
+func (am *ArchiveManager) GetInterruptedSince() time.Time {
+	am.failingLock.Lock()
+	defer am.failingLock.Unlock()
+	return am.failingSince
+}
+
+func (am *ArchiveManager) setFailing(failing bool) {
+	am.failingLock.Lock()
+	defer am.failingLock.Unlock()
+	if !failing {
+		am.failingSince = time.Time{}
+	} else if am.failingSince.IsZero() {
+		am.failingSince = time.Now()
+	}
+}
+
 func (am *ArchiveManager) monitorForChanges(originalFileInfo os.FileInfo) {
 	lastFileInfo := originalFileInfo
 	retryCh := make(chan struct{})
@@ -138,12 +159,14 @@ func (am *ArchiveManager) monitorForChanges(originalFileInfo os.FileInfo) {
 					// A failure here might be a real failure, or it might be that the file is being copied
 					// over non-atomically so that we're seeing an invalid partial state. So we'll always
 					// retry at least once in this case.
+					am.setFailing(true)
 					am.loggers.Warnf(logMsgReloadError, err.Error())
 					lastError = err
 					scheduleRetry()
 					return
 				}
 				am.loggers.Warnf(logMsgReloadedData, am.filePath)
+				am.setFailing(false)
 				am.updatedArchive(ar)
 				ar.Close()
 				return
@@ -156,6 +179,7 @@ func (am *ArchiveManager) monitorForChanges(originalFileInfo os.FileInfo) {
 			}
 			am.loggers.Warn(logMsgReloadUnchangedRetry)
 		} else if lastError == nil {
+			am.setFailing(true)
 			am.loggers.Warn(logMsgReloadFileNotFound)
 			lastError = err
 		}
@@ -204,15 +204,19 @@ func TestFileDeletedAndThenRecreatedWithValidData(t *testing.T) {
 
 		p.expectEnvironmentsAdded(testEnv1, testEnv2)
 
+		assert.True(t, p.archiveManager.GetInterruptedSince().IsZero())
+
 		require.NoError(t, os.Remove(p.filePath))
 
 		requireLogMessage(t, p.mockLog, ldlog.Warn, "file not found")
+		assert.False(t, p.archiveManager.GetInterruptedSince().IsZero())
 
 		testEnv1a := testEnv1.withMetadataChange().withSDKDataChange()
 		writeArchive(t, p.filePath, false, nil, testEnv1a, testEnv2)
 
 		p.expectEnvironmentsUpdated(testEnv1a)
 		p.expectReloaded()
+		assert.True(t, p.archiveManager.GetInterruptedSince().IsZero())
 	})
 }
 
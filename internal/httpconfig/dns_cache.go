@@ -0,0 +1,86 @@
+package httpconfig
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// dnsCache remembers the results of DNS lookups for outbound connections, so that a new connection to
+// the same host does not require a new lookup until the TTL has elapsed. If a lookup fails, the last
+// addresses that were successfully resolved for that host are used instead, even if they have expired;
+// that way a brief DNS outage does not prevent Relay from reconnecting to LaunchDarkly.
+type dnsCache struct {
+	ttl        time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	now        func() time.Time
+	entries    map[string]dnsCacheEntry
+	loggers    ldlog.Loggers
+	lock       sync.Mutex
+}
+
+type dnsCacheEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(ttl time.Duration, loggers ldlog.Loggers) *dnsCache {
+	return &dnsCache{
+		ttl:        ttl,
+		lookupHost: net.DefaultResolver.LookupHost,
+		now:        time.Now,
+		entries:    make(map[string]dnsCacheEntry),
+		loggers:    loggers,
+	}
+}
+
+func (d *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+	d.lock.Lock()
+	entry, found := d.entries[host]
+	d.lock.Unlock()
+	if found && d.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.lookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if found {
+			d.loggers.Warnf("DNS lookup for %s failed (%s); using previously resolved addresses", host, err)
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+	d.lock.Lock()
+	d.entries[host] = dnsCacheEntry{addrs: addrs, expires: d.now().Add(d.ttl)}
+	d.lock.Unlock()
+	return addrs, nil
+}
+
+// dialContext returns a function that can be used as the DialContext of an http.Transport. It resolves
+// the host with the cache and then tries each of its addresses in turn with the given dialer.
+func (d *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var conn net.Conn
+		for _, addr := range addrs {
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
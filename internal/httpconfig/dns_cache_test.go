@@ -0,0 +1,108 @@
+package httpconfig
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubResolver struct {
+	addrs   []string
+	err     error
+	lookups int
+}
+
+func (s *stubResolver) lookupHost(ctx context.Context, host string) ([]string, error) {
+	s.lookups++
+	return s.addrs, s.err
+}
+
+func makeTestDNSCache(ttl time.Duration, resolver *stubResolver, loggers ldlog.Loggers) (*dnsCache, *time.Time) {
+	now := time.Now()
+	d := newDNSCache(ttl, loggers)
+	d.lookupHost = resolver.lookupHost
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestDNSCacheReusesAddressesUntilTTLExpires(t *testing.T) {
+	resolver := &stubResolver{addrs: []string{"10.0.0.1"}}
+	d, now := makeTestDNSCache(time.Minute, resolver, ldlog.NewDisabledLoggers())
+
+	addrs, err := d.lookup(context.Background(), "example")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	resolver.addrs = []string{"10.0.0.2"}
+	*now = now.Add(time.Second * 59)
+	addrs, err = d.lookup(context.Background(), "example")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	assert.Equal(t, 1, resolver.lookups)
+
+	*now = now.Add(time.Second)
+	addrs, err = d.lookup(context.Background(), "example")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.2"}, addrs)
+	assert.Equal(t, 2, resolver.lookups)
+}
+
+func TestDNSCacheUsesExpiredAddressesIfLookupFails(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	resolver := &stubResolver{addrs: []string{"10.0.0.1"}}
+	d, now := makeTestDNSCache(time.Minute, resolver, mockLog.Loggers)
+
+	_, err := d.lookup(context.Background(), "example")
+	require.NoError(t, err)
+
+	resolver.addrs, resolver.err = nil, errors.New("sorry")
+	*now = now.Add(time.Hour)
+	addrs, err := d.lookup(context.Background(), "example")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "DNS lookup for example failed")
+}
+
+func TestDNSCacheReturnsErrorIfFirstLookupFails(t *testing.T) {
+	fakeError := errors.New("sorry")
+	resolver := &stubResolver{err: fakeError}
+	d, _ := makeTestDNSCache(time.Minute, resolver, ldlog.NewDisabledLoggers())
+
+	_, err := d.lookup(context.Background(), "example")
+	assert.Equal(t, fakeError, err)
+}
+
+func TestDNSCacheDoesNotLookUpIPAddresses(t *testing.T) {
+	resolver := &stubResolver{err: errors.New("sorry")}
+	d, _ := makeTestDNSCache(time.Minute, resolver, ldlog.NewDisabledLoggers())
+
+	addrs, err := d.lookup(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	assert.Equal(t, 0, resolver.lookups)
+}
+
+func TestDNSCacheDialTriesEachAddress(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	// 192.0.2.0/24 is reserved for documentation, so nothing should be listening there
+	resolver := &stubResolver{addrs: []string{"192.0.2.1", "127.0.0.1"}}
+	d, _ := makeTestDNSCache(time.Minute, resolver, ldlog.NewDisabledLoggers())
+	dial := d.dialContext(&net.Dialer{Timeout: time.Millisecond * 100})
+
+	conn, err := dial(context.Background(), "tcp", net.JoinHostPort("example", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, listener.Addr().String(), conn.RemoteAddr().String())
+}
@@ -2,6 +2,7 @@
 package httpconfig
 
 import (
+	"crypto/tls"
 	"errors"
 	"net/http"
 
@@ -29,7 +30,20 @@ type HTTPConfig struct {
 }
 
 // NewHTTPConfig validates all of the HTTP-related options and returns an HTTPConfig if successful.
-func NewHTTPConfig(proxyConfig config.ProxyConfig, authKey credential.SDKCredential, userAgent string, loggers ldlog.Loggers) (HTTPConfig, error) {
+//
+// If tlsServerName is not empty, it is used instead of the URL host name for TLS server name indication
+// and certificate validation in all secure connections.
+//
+// If no proxy URL is configured, the standard HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables
+// (or their lowercase equivalents) are used to select a proxy for each request; see http.ProxyFromEnvironment.
+// A configured proxy URL always takes precedence over these variables.
+func NewHTTPConfig(
+	proxyConfig config.ProxyConfig,
+	authKey credential.SDKCredential,
+	userAgent string,
+	tlsServerName string,
+	loggers ldlog.Loggers,
+) (HTTPConfig, error) {
 	configBuilder := ldcomponents.HTTPConfiguration()
 	configBuilder.UserAgent(userAgent)
 
@@ -40,10 +54,12 @@ func NewHTTPConfig(proxyConfig config.ProxyConfig, authKey credential.SDKCredent
 		authKeyStr = authKey.GetAuthorizationHeaderValue()
 	}
 
-	if !proxyConfig.URL.IsDefined() && proxyConfig.NTLMAuth {
+	if !proxyConfig.URL.IsDefined() && (proxyConfig.NTLMAuth || proxyConfig.User != "" || proxyConfig.Password != "") {
 		return ret, errProxyAuthWithoutProxyURL
 	}
-	if proxyConfig.URL.IsDefined() {
+	if proxyConfig.Disabled {
+		loggers.Info("Not using a proxy server")
+	} else if proxyConfig.URL.IsDefined() {
 		loggers.Infof("Using proxy server at %s", proxyConfig.URL)
 	}
 
@@ -68,6 +84,48 @@ func NewHTTPConfig(proxyConfig config.ProxyConfig, authKey credential.SDKCredent
 		}
 		configBuilder.HTTPClientFactory(factory)
 		loggers.Info("NTLM proxy authentication enabled")
+		if proxyConfig.DNSCacheTTL.IsDefined() {
+			loggers.Warn("DNS caching is not supported with NTLM proxy authentication; ignoring DNS cache TTL")
+		}
+		if tlsServerName != "" {
+			loggers.Warn("TLS server name override is not supported with NTLM proxy authentication; ignoring it")
+		}
+	} else if proxyConfig.DNSCacheTTL.IsDefined() || tlsServerName != "" || proxyConfig.Disabled {
+		// The SDK's HTTP configuration builder doesn't let us change how connections are dialed, how TLS
+		// is configured, or turn off the proxy environment variables, so we build the transport ourselves
+		// with the same options it would have used.
+		transportOpts := []ldhttp.TransportOption{
+			ldhttp.ConnectTimeoutOption(ldcomponents.DefaultConnectTimeout),
+		}
+		if proxyConfig.URL.IsDefined() {
+			transportOpts = append(transportOpts, ldhttp.ProxyOption(*proxyConfig.URL.Get()))
+		}
+		for _, filePath := range caCertFiles {
+			if filePath != "" {
+				transportOpts = append(transportOpts, ldhttp.CACertFileOption(filePath))
+			}
+		}
+		transport, dialer, err := ldhttp.NewHTTPTransport(transportOpts...)
+		if err != nil {
+			return ret, err
+		}
+		if proxyConfig.Disabled {
+			transport.Proxy = nil
+		}
+		if proxyConfig.DNSCacheTTL.IsDefined() {
+			transport.DialContext = newDNSCache(proxyConfig.DNSCacheTTL.GetOrElse(0), loggers).dialContext(dialer)
+			loggers.Infof("DNS caching enabled with TTL of %s", proxyConfig.DNSCacheTTL.GetOrElse(0))
+		}
+		if tlsServerName != "" {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{} //nolint:gosec // not setting TLS.MinVersion, same as the SDK
+			}
+			transport.TLSClientConfig.ServerName = tlsServerName
+			loggers.Infof("Using TLS server name %q", tlsServerName)
+		}
+		configBuilder.HTTPClientFactory(func() *http.Client {
+			return &http.Client{Transport: transport}
+		})
 	} else {
 		if proxyConfig.URL.IsDefined() {
 			configBuilder.ProxyURL(proxyConfig.URL.String())
@@ -82,6 +140,13 @@ func NewHTTPConfig(proxyConfig config.ProxyConfig, authKey credential.SDKCredent
 	var err error
 	ret.SDKHTTPConfigFactory = configBuilder
 	ret.SDKHTTPConfig, err = configBuilder.Build(subsystems.BasicClientContext{SDKKey: authKeyStr})
+	if recorder := activeRecorder.Load(); recorder != nil && err == nil {
+		baseConfig := ret.SDKHTTPConfig
+		configBuilder.HTTPClientFactory(func() *http.Client {
+			return recorder.wrapClient(baseConfig.CreateHTTPClient())
+		})
+		ret.SDKHTTPConfig, err = configBuilder.Build(subsystems.BasicClientContext{SDKKey: authKeyStr})
+	}
 	return ret, err
 }
 
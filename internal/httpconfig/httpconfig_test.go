@@ -2,10 +2,13 @@ package httpconfig
 
 import (
 	"crypto/x509"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
 
@@ -20,7 +23,7 @@ import (
 )
 
 func TestUserAgentHeader(t *testing.T) {
-	hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "abc", ldlog.NewDefaultLoggers())
+	hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "abc", "", ldlog.NewDefaultLoggers())
 	require.NoError(t, err)
 	require.NotNil(t, hc)
 	headers := hc.SDKHTTPConfig.DefaultHeaders
@@ -28,7 +31,7 @@ func TestUserAgentHeader(t *testing.T) {
 }
 
 func TestNoAuthorizationHeader(t *testing.T) {
-	hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "", ldlog.NewDefaultLoggers())
+	hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "", "", ldlog.NewDefaultLoggers())
 	require.NoError(t, err)
 	require.NotNil(t, hc)
 	headers := hc.SDKHTTPConfig.DefaultHeaders
@@ -36,7 +39,7 @@ func TestNoAuthorizationHeader(t *testing.T) {
 }
 
 func TestAuthorizationHeader(t *testing.T) {
-	hc, err := NewHTTPConfig(config.ProxyConfig{}, config.SDKKey("key"), "", ldlog.NewDefaultLoggers())
+	hc, err := NewHTTPConfig(config.ProxyConfig{}, config.SDKKey("key"), "", "", ldlog.NewDefaultLoggers())
 	require.NoError(t, err)
 	require.NotNil(t, hc)
 	headers := hc.SDKHTTPConfig.DefaultHeaders
@@ -51,7 +54,7 @@ func TestSimpleProxy(t *testing.T) {
 	httphelpers.WithServer(handler, func(server *httptest.Server) {
 		proxyConfig := config.ProxyConfig{}
 		proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
-		hc, err := NewHTTPConfig(proxyConfig, nil, "", mockLog.Loggers)
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", "", mockLog.Loggers)
 
 		mockLog.AssertMessageMatch(t, true, ldlog.Info, "Using proxy server at "+server.URL)
 
@@ -65,6 +68,85 @@ func TestSimpleProxy(t *testing.T) {
 	})
 }
 
+func TestProxyFromEnvironment(t *testing.T) {
+	// http.ProxyFromEnvironment only reads the environment variables once per process, so rather than
+	// setting them we just verify that the transport uses it.
+	isProxyFromEnvironment := func(t *testing.T, hc HTTPConfig) bool {
+		transport, ok := hc.Client().Transport.(*http.Transport)
+		require.True(t, ok)
+		return reflect.ValueOf(transport.Proxy).Pointer() == reflect.ValueOf(http.ProxyFromEnvironment).Pointer()
+	}
+
+	t.Run("environment is used if no proxy URL is configured", func(t *testing.T) {
+		hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "", "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assert.True(t, isProxyFromEnvironment(t, hc))
+	})
+
+	t.Run("environment is used with a custom transport", func(t *testing.T) {
+		proxyConfig := config.ProxyConfig{DNSCacheTTL: configtypes.NewOptDuration(time.Minute)}
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assert.True(t, isProxyFromEnvironment(t, hc))
+	})
+
+	t.Run("environment is not used if proxy is disabled", func(t *testing.T) {
+		hc, err := NewHTTPConfig(config.ProxyConfig{Disabled: true}, nil, "", "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		transport, ok := hc.Client().Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.Nil(t, transport.Proxy)
+	})
+
+	t.Run("configured proxy URL takes precedence", func(t *testing.T) {
+		proxyConfig := config.ProxyConfig{}
+		proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://other-proxy")
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assert.False(t, isProxyFromEnvironment(t, hc))
+	})
+}
+
+func TestDNSCache(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+	mockLog := ldlogtest.NewMockLog()
+
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		proxyConfig := config.ProxyConfig{DNSCacheTTL: configtypes.NewOptDuration(time.Minute)}
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", "", mockLog.Loggers)
+		require.NoError(t, err)
+
+		mockLog.AssertMessageMatch(t, true, ldlog.Info, "DNS caching enabled with TTL of 1m0s")
+
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+		resp, err := hc.Client().Get("http://localhost:" + port + "/path")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		req := <-requestsCh
+		assert.Equal(t, "/path", req.Request.URL.Path)
+	})
+}
+
+func TestDNSCacheWithProxy(t *testing.T) {
+	fakeURL := "http://fake-url/"
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
+
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		proxyConfig := config.ProxyConfig{DNSCacheTTL: configtypes.NewOptDuration(time.Minute)}
+		proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
+		hc, err := NewHTTPConfig(proxyConfig, nil, "", "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+
+		resp, err := hc.Client().Get(fakeURL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		req := <-requestsCh
+		assert.Equal(t, fakeURL, req.Request.URL.String())
+	})
+}
+
 func TestSimpleProxyWithCACert(t *testing.T) {
 	fakeURL := "http://fake-url/"
 	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(http.StatusOK))
@@ -76,7 +158,7 @@ func TestSimpleProxyWithCACert(t *testing.T) {
 			proxyConfig := config.ProxyConfig{}
 			proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
 			proxyConfig.CACertFiles = configtypes.NewOptStringList([]string{certFilePath})
-			hc, err := NewHTTPConfig(proxyConfig, nil, "", mockLog.Loggers)
+			hc, err := NewHTTPConfig(proxyConfig, nil, "", "", mockLog.Loggers)
 
 			mockLog.AssertMessageMatch(t, true, ldlog.Info, "Using proxy server at "+server.URL)
 
@@ -91,6 +173,41 @@ func TestSimpleProxyWithCACert(t *testing.T) {
 	})
 }
 
+func TestTLSServerName(t *testing.T) {
+	handler := httphelpers.HandlerWithStatus(http.StatusOK)
+
+	httphelpers.WithSelfSignedServer(handler, func(server *httptest.Server, certData []byte, certPool *x509.CertPool) {
+		helpers.WithTempFile(func(certFilePath string) {
+			require.NoError(t, os.WriteFile(certFilePath, certData, 0))
+			proxyConfig := config.ProxyConfig{}
+			proxyConfig.CACertFiles = configtypes.NewOptStringList([]string{certFilePath})
+
+			// The test certificate is only valid for 127.0.0.1, so connecting via "localhost" fails
+			// certificate validation unless we override the server name.
+			_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+			url := "https://localhost:" + port + "/"
+
+			t.Run("default", func(t *testing.T) {
+				hc, err := NewHTTPConfig(proxyConfig, nil, "", "", ldlog.NewDisabledLoggers())
+				require.NoError(t, err)
+				_, err = hc.Client().Get(url)
+				assert.Error(t, err)
+			})
+
+			t.Run("override", func(t *testing.T) {
+				mockLog := ldlogtest.NewMockLog()
+				hc, err := NewHTTPConfig(proxyConfig, nil, "", "127.0.0.1", mockLog.Loggers)
+				require.NoError(t, err)
+				mockLog.AssertMessageMatch(t, true, ldlog.Info, `Using TLS server name "127.0.0.1"`)
+
+				resp, err := hc.Client().Get(url)
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			})
+		})
+	})
+}
+
 func TestSimpleProxyCACertError(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 
@@ -98,40 +215,45 @@ func TestSimpleProxyCACertError(t *testing.T) {
 		proxyConfig := config.ProxyConfig{}
 		proxyConfig.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://fake-proxy")
 		proxyConfig.CACertFiles = configtypes.NewOptStringList([]string{certFilePath})
-		_, err := NewHTTPConfig(proxyConfig, nil, "", mockLog.Loggers)
+		_, err := NewHTTPConfig(proxyConfig, nil, "", "", mockLog.Loggers)
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "invalid CA certificate data")
 		}
 	})
 }
 
+func TestProxyCredentialsWithoutProxyURL(t *testing.T) {
+	_, err := NewHTTPConfig(config.ProxyConfig{User: "user", Password: "pass"}, nil, "", "", ldlog.NewDisabledLoggers())
+	assert.Equal(t, errProxyAuthWithoutProxyURL, err)
+}
+
 func TestNTLMProxyInvalidConfigs(t *testing.T) {
 	// The actual functioning of the NTLM proxy transport is tested in the SDK package where it is defined,
 	// so here we're only testing that we validate the parameters correctly.
 
 	proxyConfig1 := config.ProxyConfig{NTLMAuth: true}
-	_, err := NewHTTPConfig(proxyConfig1, nil, "", ldlog.NewDisabledLoggers())
+	_, err := NewHTTPConfig(proxyConfig1, nil, "", "", ldlog.NewDisabledLoggers())
 	assert.Equal(t, errProxyAuthWithoutProxyURL, err)
 
 	proxyConfig2 := proxyConfig1
 	proxyConfig2.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://fake-proxy")
-	_, err = NewHTTPConfig(proxyConfig2, nil, "", ldlog.NewDisabledLoggers())
+	_, err = NewHTTPConfig(proxyConfig2, nil, "", "", ldlog.NewDisabledLoggers())
 	assert.Equal(t, errNTLMProxyAuthWithoutCredentials, err)
 
 	proxyConfig3 := proxyConfig2
 	proxyConfig3.User = "user"
-	_, err = NewHTTPConfig(proxyConfig3, nil, "", ldlog.NewDisabledLoggers())
+	_, err = NewHTTPConfig(proxyConfig3, nil, "", "", ldlog.NewDisabledLoggers())
 	assert.Equal(t, errNTLMProxyAuthWithoutCredentials, err)
 
 	proxyConfig4 := proxyConfig3
 	proxyConfig4.Password = "pass"
-	_, err = NewHTTPConfig(proxyConfig4, nil, "", ldlog.NewDisabledLoggers())
+	_, err = NewHTTPConfig(proxyConfig4, nil, "", "", ldlog.NewDisabledLoggers())
 	assert.NoError(t, err)
 
 	proxyConfig5 := proxyConfig4
 	helpers.WithTempFile(func(certFileName string) {
 		proxyConfig5.CACertFiles = configtypes.NewOptStringList([]string{certFileName})
-		_, err = NewHTTPConfig(proxyConfig5, nil, "", ldlog.NewDisabledLoggers())
+		_, err = NewHTTPConfig(proxyConfig5, nil, "", "", ldlog.NewDisabledLoggers())
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "invalid CA certificate data")
 		}
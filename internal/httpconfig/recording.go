@@ -0,0 +1,85 @@
+package httpconfig
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// activeRecorder is the RequestRecorder that NewHTTPConfig attaches to new HTTP clients, if any. It can
+// only be set by StartRecording, which exists only in builds with the relaytestsupport build tag, so in
+// normal builds outbound requests are never recorded.
+var activeRecorder atomic.Pointer[RequestRecorder] //nolint:gochecknoglobals
+
+// RecordedRequest describes an outbound HTTP request that was captured by a RequestRecorder.
+type RecordedRequest struct {
+	// Time is when the request was made.
+	Time time.Time
+	// Method is the HTTP method.
+	Method string
+	// URL is the full request URL.
+	URL string
+	// Header contains the request headers, including any that were added by Relay's HTTP configuration.
+	Header http.Header
+	// StatusCode is the status of the response, or zero if the request failed without a response.
+	StatusCode int
+}
+
+// RequestRecorder captures metadata about outbound HTTP requests, for test assertions. Request bodies
+// are not captured.
+type RequestRecorder struct {
+	requests []RecordedRequest
+	lock     sync.Mutex
+}
+
+// Requests returns all of the requests that have been recorded so far, oldest first.
+func (r *RequestRecorder) Requests() []RecordedRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]RecordedRequest(nil), r.requests...)
+}
+
+// Clear discards all of the requests that have been recorded so far.
+func (r *RequestRecorder) Clear() {
+	r.lock.Lock()
+	r.requests = nil
+	r.lock.Unlock()
+}
+
+func (r *RequestRecorder) add(req RecordedRequest) {
+	r.lock.Lock()
+	r.requests = append(r.requests, req)
+	r.lock.Unlock()
+}
+
+// wrapClient returns a copy of the client whose transport records every request.
+func (r *RequestRecorder) wrapClient(client *http.Client) *http.Client {
+	ret := *client
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	ret.Transport = recordingTransport{recorder: r, next: next}
+	return &ret
+}
+
+type recordingTransport struct {
+	recorder *RequestRecorder
+	next     http.RoundTripper
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := RecordedRequest{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+	}
+	resp, err := t.next.RoundTrip(req)
+	if resp != nil {
+		recorded.StatusCode = resp.StatusCode
+	}
+	t.recorder.add(recorded)
+	return resp, err
+}
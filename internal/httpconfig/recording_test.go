@@ -0,0 +1,59 @@
+package httpconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestsAreNotRecordedByDefault(t *testing.T) {
+	hc, err := NewHTTPConfig(config.ProxyConfig{}, nil, "", "", ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	_, isRecording := hc.Client().Transport.(recordingTransport)
+	assert.False(t, isRecording)
+}
+
+func TestRequestRecorder(t *testing.T) {
+	recorder := &RequestRecorder{}
+	activeRecorder.Store(recorder)
+	hc, err := NewHTTPConfig(config.ProxyConfig{}, config.SDKKey("key"), "abc", "", ldlog.NewDisabledLoggers())
+	activeRecorder.Store(nil)
+	require.NoError(t, err)
+
+	// The SDK builds its own HTTP configuration from the same factory
+	sdkHTTPConfig, err := hc.SDKHTTPConfigFactory.Build(subsystems.BasicClientContext{})
+	require.NoError(t, err)
+
+	handler := httphelpers.HandlerWithStatus(http.StatusTeapot)
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		for _, client := range []*http.Client{hc.Client(), sdkHTTPConfig.CreateHTTPClient()} {
+			req, _ := http.NewRequest("GET", server.URL+"/path", nil)
+			req.Header.Set("Authorization", "key")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+	})
+
+	requests := recorder.Requests()
+	require.Len(t, requests, 2)
+	for _, r := range requests {
+		assert.Equal(t, "GET", r.Method)
+		assert.Regexp(t, "/path$", r.URL)
+		assert.Equal(t, "key", r.Header.Get("Authorization"))
+		assert.Equal(t, http.StatusTeapot, r.StatusCode)
+		assert.False(t, r.Time.IsZero())
+	}
+
+	recorder.Clear()
+	assert.Len(t, recorder.Requests(), 0)
+}
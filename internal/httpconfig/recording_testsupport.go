@@ -0,0 +1,22 @@
+//go:build relaytestsupport
+
+package httpconfig
+
+// StartRecording causes every HTTP client that is subsequently configured by NewHTTPConfig to record
+// its requests in the returned RequestRecorder, replacing any recorder that was already active. Clients
+// that were configured earlier are not affected.
+//
+// This exists only in builds with the relaytestsupport build tag.
+func StartRecording() *RequestRecorder {
+	recorder := &RequestRecorder{}
+	activeRecorder.Store(recorder)
+	return recorder
+}
+
+// StopRecording stops attaching the active RequestRecorder to new HTTP clients. Clients that were
+// already configured to use it continue to record their requests.
+//
+// This exists only in builds with the relaytestsupport build tag.
+func StopRecording() {
+	activeRecorder.Store(nil)
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// LevelGate controls the minimum log level of any Loggers that it has been applied to. Unlike
+// ldlog.Loggers.SetMinLevel, which only affects the instance it is called on, changing the level of a
+// LevelGate affects every copy of those Loggers, including copies that have already been passed to
+// other components such as the SDK client.
+type LevelGate struct {
+	minLevel atomic.Int32
+}
+
+// NewLevelGate creates a LevelGate with the specified initial level.
+func NewLevelGate(minLevel ldlog.LogLevel) *LevelGate {
+	g := &LevelGate{}
+	g.SetMinLevel(minLevel)
+	return g
+}
+
+// Apply returns a copy of loggers whose minimum level is controlled by the LevelGate.
+//
+// The returned Loggers are configured to accept messages at all levels, so that the level can be
+// lowered later; messages below the gate's current level are discarded before they reach the
+// underlying loggers. Calling SetPrefix on the returned Loggers works as usual.
+func (g *LevelGate) Apply(loggers ldlog.Loggers) ldlog.Loggers {
+	inner := loggers
+	inner.SetMinLevel(ldlog.Debug)
+
+	var gated ldlog.Loggers
+	for _, level := range []ldlog.LogLevel{ldlog.Debug, ldlog.Info, ldlog.Warn, ldlog.Error} {
+		gated.SetBaseLoggerForLevel(level, gatedLogger{
+			gate:   g,
+			level:  level,
+			prefix: strings.ToUpper(level.Name()) + ":",
+			target: inner.ForLevel(level),
+		})
+	}
+	gated.SetMinLevel(ldlog.Debug)
+	return gated
+}
+
+// SetMinLevel changes the minimum level for output.
+func (g *LevelGate) SetMinLevel(level ldlog.LogLevel) {
+	g.minLevel.Store(int32(level))
+}
+
+// GetMinLevel returns the current minimum level for output.
+func (g *LevelGate) GetMinLevel() ldlog.LogLevel {
+	return ldlog.LogLevel(g.minLevel.Load())
+}
+
+// gatedLogger forwards messages at a single level to the corresponding level of the underlying Loggers,
+// if the level is enabled. Both the gated Loggers and the underlying Loggers add a "LEVEL:" prefix to each
+// message, so we remove the first one; any other prefix that was set with SetPrefix is kept.
+type gatedLogger struct {
+	gate   *LevelGate
+	level  ldlog.LogLevel
+	prefix string
+	target ldlog.BaseLogger
+}
+
+func (l gatedLogger) Println(values ...interface{}) {
+	if l.level < l.gate.GetMinLevel() {
+		return
+	}
+	if len(values) > 0 {
+		if s, ok := values[0].(string); ok && strings.HasPrefix(s, l.prefix) {
+			if rest := strings.TrimPrefix(strings.TrimPrefix(s, l.prefix), " "); rest != "" {
+				values = append([]interface{}{rest}, values[1:]...)
+			} else {
+				values = values[1:]
+			}
+		}
+	}
+	l.target.Println(values...)
+}
+
+func (l gatedLogger) Printf(format string, values ...interface{}) {
+	if l.level < l.gate.GetMinLevel() {
+		return
+	}
+	l.target.Printf(strings.TrimPrefix(format, l.prefix+" "), values...)
+}
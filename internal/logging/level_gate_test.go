@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelGate(t *testing.T) {
+	var buf bytes.Buffer
+	loggers := ldlog.NewDefaultLoggers()
+	loggers.SetBaseLogger(log.New(&buf, "", 0))
+
+	gate := NewLevelGate(ldlog.Info)
+	gated := gate.Apply(loggers)
+	assert.Equal(t, ldlog.Info, gate.GetMinLevel())
+
+	gated.Debug("a")
+	gated.Info("b")
+	gated.Warnf("c %d", 1)
+	assert.Equal(t, "INFO: b\nWARN: c 1\n", buf.String())
+
+	buf.Reset()
+	copied := gated
+	copied.SetPrefix("[env]")
+	gate.SetMinLevel(ldlog.Debug)
+	copied.Debug("a")
+	copied.Debugf("b %d", 2)
+	copied.Info("c", "d")
+	assert.Equal(t, "DEBUG: [env] a\nDEBUG: [env] b 2\nINFO: [env] c d\n", buf.String())
+
+	buf.Reset()
+	gate.SetMinLevel(ldlog.Error)
+	copied.Warn("a")
+	copied.Error("b")
+	assert.Equal(t, "ERROR: [env] b\n", buf.String())
+}
@@ -23,6 +23,23 @@ const (
 
 	requestMeasureName = "requests"
 
+	evalLatencyMeasureName = "eval_latency"
+
+	storeFormatMismatchMeasureName = "store_format_mismatches"
+
+	dataUpdateMeasureName = "data_updates"
+
+	initDurationMeasureName = "init_duration"
+
+	unknownFlagEventsMeasureName = "unknown_flag_events"
+
+	storeCircuitBreakerOpenMeasureName = "store_circuit_breaker_open"
+
+	oversizedStreamEventsMeasureName = "oversized_stream_events"
+
+	flagsTagValue    = "flags"
+	segmentsTagValue = "segments"
+
 	defaultFlushInterval = time.Minute
 )
 
@@ -33,6 +50,7 @@ var (
 	routeTagKey, _            = tag.NewKey("route")            //nolint:gochecknoglobals
 	methodTagKey, _           = tag.NewKey("method")           //nolint:gochecknoglobals
 	envNameTagKey, _          = tag.NewKey("env")              //nolint:gochecknoglobals
+	dataKindTagKey, _         = tag.NewKey("kind")             //nolint:gochecknoglobals
 
 	publicTags  = []tag.Key{platformCategoryTagKey, userAgentTagKey, envNameTagKey}                //nolint:gochecknoglobals
 	privateTags = []tag.Key{platformCategoryTagKey, userAgentTagKey, relayIDTagKey, envNameTagKey} //nolint:gochecknoglobals
@@ -3,10 +3,10 @@ package metrics
 import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	datadog "github.com/DataDog/opencensus-go-exporter-datadog"
 	"go.opencensus.io/stats/view"
-	"go.opencensus.io/trace"
 )
 
 var datadogExporterType exporterType = datadogExporterTypeImpl{} //nolint:gochecknoglobals
@@ -23,6 +23,7 @@ func (d datadogExporterTypeImpl) getName() string {
 
 func (d datadogExporterTypeImpl) createExporterIfEnabled(
 	mc config.MetricsConfig,
+	_ string,
 	loggers ldlog.Loggers,
 ) (exporter, error) {
 	if !mc.Datadog.Enabled {
@@ -45,13 +46,13 @@ func (d datadogExporterTypeImpl) createExporterIfEnabled(
 
 func (d *datadogExporterImpl) register() error {
 	view.RegisterExporter(d.exporter)
-	trace.RegisterExporter(d.exporter)
+	tracing.RegisterExporter(d.exporter)
 	return nil
 }
 
 func (d *datadogExporterImpl) close() error {
 	d.exporter.Stop()
 	view.UnregisterExporter(d.exporter)
-	trace.UnregisterExporter(d.exporter)
+	tracing.UnregisterExporter(d.exporter)
 	return nil
 }
@@ -24,7 +24,7 @@ func TestDatadogExporterType(t *testing.T) {
 
 	t.Run("does not create exporter if Datadog is disabled", func(t *testing.T) {
 		var mc config.MetricsConfig
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.Nil(t, e)
 	})
@@ -32,7 +32,7 @@ func TestDatadogExporterType(t *testing.T) {
 	t.Run("creates exporter if Datadog is enabled", func(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Datadog.Enabled = true
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.NotNil(t, e)
 		e.close()
@@ -42,7 +42,7 @@ func TestDatadogExporterType(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Datadog.Enabled = true
 		mc.Datadog.StatsAddr = "::"
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.Error(t, err)
 		assert.Nil(t, e)
 	})
@@ -50,7 +50,7 @@ func TestDatadogExporterType(t *testing.T) {
 	t.Run("registers exporter without errors", func(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Datadog.Enabled = true
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.NotNil(t, e)
 		defer e.close()
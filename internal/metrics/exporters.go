@@ -13,8 +13,9 @@ type exporterType interface {
 
 	// Checks the MetricsConfig and *if* this type of exporter is enabled in it, constructs an
 	// implementation of the exporter interface containing the relevant configuration (but does not
-	// register it yet). If this type of exporter is not enabled, returns (nil, nil).
-	createExporterIfEnabled(config.MetricsConfig, ldlog.Loggers) (exporter, error)
+	// register it yet). If this type of exporter is not enabled, returns (nil, nil). The string parameter is
+	// the Relay version, for exporters that report it.
+	createExporterIfEnabled(config.MetricsConfig, string, ldlog.Loggers) (exporter, error)
 }
 
 type exporter interface {
@@ -28,7 +29,7 @@ type exporter interface {
 type exportersSet map[exporterType]exporter
 
 func allExporterTypes() []exporterType {
-	return []exporterType{datadogExporterType, prometheusExporterType, stackdriverExporterType}
+	return []exporterType{datadogExporterType, prometheusExporterType, stackdriverExporterType, openTelemetryExporterType}
 }
 
 // Attempts to create and register all of the types of exporters in exporterTypes that are actually
@@ -37,11 +38,12 @@ func allExporterTypes() []exporterType {
 func registerExporters(
 	exporterTypes []exporterType,
 	c config.MetricsConfig,
+	relayVersion string,
 	loggers ldlog.Loggers,
 ) (exportersSet, error) {
 	registered := make(exportersSet)
 	for _, t := range exporterTypes {
-		exporter, err := t.createExporterIfEnabled(c, loggers)
+		exporter, err := t.createExporterIfEnabled(c, relayVersion, loggers)
 		if err != nil {
 			loggers.Errorf("Error creating %s metrics exporter: %s", t.getName(), err)
 			closeExporters(registered, loggers)
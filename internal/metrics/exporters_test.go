@@ -33,7 +33,7 @@ func TestRegisterExporters(t *testing.T) {
 		mockLog := ldlogtest.NewMockLog()
 
 		exporters, err := registerExporters([]exporterType{fakeDatadogType, fakePrometheusType},
-			mc, mockLog.Loggers)
+			mc, "", mockLog.Loggers)
 		require.Nil(t, err)
 		assert.Len(t, exporters, 1)
 		require.NotNil(t, exporters[fakePrometheusType])
@@ -54,7 +54,7 @@ func TestRegisterExporters(t *testing.T) {
 
 		mockLog := ldlogtest.NewMockLog()
 		exporters, err := registerExporters([]exporterType{fakeTypeThatSucceeds, fakeTypeThatFails},
-			config.MetricsConfig{}, mockLog.Loggers)
+			config.MetricsConfig{}, "", mockLog.Loggers)
 		require.NotNil(t, err)
 		assert.Len(t, exporters, 0)
 
@@ -77,7 +77,7 @@ func TestRegisterExporters(t *testing.T) {
 
 		mockLog := ldlogtest.NewMockLog()
 		exporters, err := registerExporters([]exporterType{fakeTypeThatSucceeds, fakeTypeThatFails},
-			config.MetricsConfig{}, mockLog.Loggers)
+			config.MetricsConfig{}, "", mockLog.Loggers)
 		require.NotNil(t, err)
 		assert.Len(t, exporters, 0)
 
@@ -98,7 +98,7 @@ func TestCloseExporters(t *testing.T) {
 
 		mockLog := ldlogtest.NewMockLog()
 		exporters, err := registerExporters([]exporterType{fakeType1, fakeType2},
-			config.MetricsConfig{}, mockLog.Loggers)
+			config.MetricsConfig{}, "", mockLog.Loggers)
 		require.Nil(t, err)
 		assert.Len(t, exporters, 2)
 		assert.Len(t, fakeType1.created, 1)
@@ -118,7 +118,7 @@ func TestCloseExporters(t *testing.T) {
 
 		mockLog := ldlogtest.NewMockLog()
 		exporters, err := registerExporters([]exporterType{fakeType1, fakeType2},
-			config.MetricsConfig{}, mockLog.Loggers)
+			config.MetricsConfig{}, "", mockLog.Loggers)
 		require.Nil(t, err)
 		assert.Len(t, exporters, 2)
 		assert.Len(t, fakeType1.created, 1)
@@ -2,12 +2,13 @@ package metrics
 
 import (
 	"context"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/logging"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
-	"go.opencensus.io/trace"
 )
 
 var (
@@ -23,6 +24,26 @@ var (
 	newConnMeasure = stats.Int64(newConnMeasureName, "total number of connections", stats.UnitDimensionless)
 	requestMeasure = stats.Int64(requestMeasureName, "Number of hits to a route", stats.UnitDimensionless)
 
+	evalLatencyMeasure = stats.Float64(evalLatencyMeasureName, "duration of flag evaluation requests", stats.UnitMilliseconds)
+
+	storeFormatMismatchMeasure = stats.Int64(storeFormatMismatchMeasureName,
+		"number of times a data store was found to contain data in an incompatible format", stats.UnitDimensionless)
+
+	dataUpdateMeasure = stats.Int64(dataUpdateMeasureName,
+		"number of flag or segment updates received from LaunchDarkly", stats.UnitDimensionless)
+
+	initDurationMeasure = stats.Float64(initDurationMeasureName,
+		"time taken for an environment to initialize", stats.UnitMilliseconds)
+
+	unknownFlagEventsMeasure = stats.Int64(unknownFlagEventsMeasureName,
+		"number of analytics events received that referred to flags that do not exist", stats.UnitDimensionless)
+
+	storeCircuitBreakerOpenMeasure = stats.Int64(storeCircuitBreakerOpenMeasureName,
+		"whether the data store circuit breaker for an environment is open (1) or closed (0)", stats.UnitDimensionless)
+
+	oversizedStreamEventsMeasure = stats.Int64(oversizedStreamEventsMeasureName,
+		"number of stream events not sent because they exceeded the maximum SSE message size", stats.UnitDimensionless)
+
 	// For internal event exporter
 	privateConnMeasure            = stats.Int64(privateConnMeasureName, "current number of connections", stats.UnitDimensionless)
 	privateNewConnMeasure         = stats.Int64(privateNewConnMeasureName, "total number of connections", stats.UnitDimensionless)
@@ -57,6 +78,42 @@ var (
 
 	// PollingRequests is a Measure representing the total number of polling style requests received from server-side SDKs.
 	PollingRequests = Measure{measures: []*stats.Int64Measure{privatePollingRequestsMeasure}, tags: makeServerTags()}
+
+	// StoreFormatMismatches is a Measure representing the number of times a persistent data store was found to
+	// contain data written in a newer format than this version of Relay understands.
+	StoreFormatMismatches = Measure{measures: []*stats.Int64Measure{storeFormatMismatchMeasure}}
+
+	// FlagUpdates is a Measure representing the number of individual flag updates received from LaunchDarkly.
+	FlagUpdates = Measure{measures: []*stats.Int64Measure{dataUpdateMeasure},
+		tags: []tag.Mutator{tag.Insert(dataKindTagKey, flagsTagValue)}}
+
+	// SegmentUpdates is a Measure representing the number of individual segment updates received from LaunchDarkly.
+	SegmentUpdates = Measure{measures: []*stats.Int64Measure{dataUpdateMeasure},
+		tags: []tag.Mutator{tag.Insert(dataKindTagKey, segmentsTagValue)}}
+
+	// UnknownFlagEvents is a Measure representing the number of references to nonexistent flags in analytics
+	// events received from SDKs.
+	UnknownFlagEvents = Measure{measures: []*stats.Int64Measure{unknownFlagEventsMeasure}}
+
+	// StoreCircuitBreakerOpen is a Measure representing whether the data store circuit breaker is open. It
+	// should be incremented when the circuit opens and decremented when it closes.
+	StoreCircuitBreakerOpen = Measure{measures: []*stats.Int64Measure{storeCircuitBreakerOpenMeasure}}
+
+	// OversizedStreamEvents is a Measure representing the number of server-side stream events that were not
+	// sent because they exceeded the maximum SSE message size.
+	OversizedStreamEvents = Measure{measures: []*stats.Int64Measure{oversizedStreamEventsMeasure}}
+
+	// BrowserEvalLatency is a LatencyMeasure representing the duration of flag evaluation requests from browsers.
+	BrowserEvalLatency = LatencyMeasure{measure: evalLatencyMeasure, tags: makeBrowserTags()}
+
+	// MobileEvalLatency is a LatencyMeasure representing the duration of flag evaluation requests from mobile SDKs.
+	MobileEvalLatency = LatencyMeasure{measure: evalLatencyMeasure, tags: makeMobileTags()}
+
+	// ServerEvalLatency is a LatencyMeasure representing the duration of flag evaluation requests from server-side SDKs.
+	ServerEvalLatency = LatencyMeasure{measure: evalLatencyMeasure, tags: makeServerTags()}
+
+	// EnvInitDuration is a LatencyMeasure representing how long an environment took to initialize.
+	EnvInitDuration = LatencyMeasure{measure: initDurationMeasure}
 )
 
 // Measure represents one of the types of metrics that can be passed to WithCount, WithGauge, or WithRouteCount.
@@ -65,6 +122,12 @@ type Measure struct {
 	tags     []tag.Mutator
 }
 
+// LatencyMeasure represents one of the types of duration metrics that can be passed to WithLatency.
+type LatencyMeasure struct {
+	measure *stats.Float64Measure
+	tags    []tag.Mutator
+}
+
 func makeBrowserTags() []tag.Mutator {
 	return []tag.Mutator{tag.Insert(platformCategoryTagKey, browserTagValue)}
 }
@@ -107,7 +170,22 @@ func WithCount(ctx context.Context, userAgent string, f func(), measure Measure)
 	f()
 }
 
-// WithRouteCount records a route hit and starts a trace. For stream connections, the duration of the stream connection is recorded
+// Increment records a single-unit increment for the specified metric, for metrics that are not associated
+// with a request.
+func Increment(ctx context.Context, measure Measure) {
+	IncrementBy(ctx, measure, 1)
+}
+
+// IncrementBy adds the specified amount to a counter metric.
+func IncrementBy(ctx context.Context, measure Measure, amount int64) {
+	for _, m := range measure.measures {
+		ctx, _ := tag.New(ctx, measure.tags...)
+		stats.Record(ctx, m.M(amount))
+	}
+}
+
+// WithRouteCount records a route hit and starts a trace. For stream connections, the duration of the stream connection is recorded.
+// If ctx was returned by tracing.WithTraceParent, the span is a child of the span from the incoming request.
 func WithRouteCount(ctx context.Context, userAgent, route, method string, f func(), measure Measure) {
 	tagCtx, err := tag.New(ctx, tag.Insert(routeTagKey, sanitizeTagValue(route)), tag.Insert(methodTagKey, sanitizeTagValue(method)))
 	if err != nil { // COVERAGE: can't make this happen in unit tests
@@ -115,8 +193,25 @@ func WithRouteCount(ctx context.Context, userAgent, route, method string, f func
 	} else {
 		ctx = tagCtx
 	}
-	ctx, span := trace.StartSpan(ctx, route)
+	ctx, span := tracing.StartServerSpan(ctx, route)
 	defer span.End()
 
 	WithCount(ctx, userAgent, f, measure)
 }
+
+// WithLatency runs a function and records how long it took, in milliseconds, for the specified metric.
+func WithLatency(ctx context.Context, f func(), measure LatencyMeasure) {
+	startTime := time.Now()
+	f()
+	RecordLatency(ctx, time.Since(startTime), measure)
+}
+
+// RecordLatency records a duration that was measured elsewhere, in milliseconds, for the specified metric.
+func RecordLatency(ctx context.Context, elapsed time.Duration, measure LatencyMeasure) {
+	tagCtx, err := tag.New(ctx, measure.tags...)
+	if err != nil { // COVERAGE: can't make this happen in unit tests
+		logging.GetGlobalContextLoggers(ctx).Errorf(`Failed to create tags: %s`, err)
+		return
+	}
+	stats.Record(tagCtx, measure.measure.M(float64(elapsed)/float64(time.Millisecond)))
+}
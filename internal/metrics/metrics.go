@@ -47,15 +47,16 @@ type EnvironmentManager struct {
 	closeOnce      sync.Once
 }
 
-// NewManager creates a Manager instance.
+// NewManager creates a Manager instance. The relayVersion is reported by exporters that support it.
 func NewManager(
 	metricsConfig config.MetricsConfig,
+	relayVersion string,
 	flushInterval time.Duration,
 	loggers ldlog.Loggers,
 ) (*Manager, error) {
 	metricsRelayID := uuid.New()
 
-	exporters, err := registerExporters(allExporterTypes(), metricsConfig, loggers)
+	exporters, err := registerExporters(allExporterTypes(), metricsConfig, relayVersion, loggers)
 	if err != nil { // COVERAGE: can't make this happen in unit tests
 		return nil, err
 	}
@@ -34,7 +34,7 @@ func (m measureAndPlatform) getExpectedTagsMap(relayID string, envName string, u
 }
 
 func TestAddEnvironmentWithoutEventPublisher(t *testing.T) {
-	manager, err := NewManager(config.MetricsConfig{}, 0, ldlog.NewDisabledLoggers())
+	manager, err := NewManager(config.MetricsConfig{}, "", 0, ldlog.NewDisabledLoggers())
 	require.NoError(t, err)
 	defer manager.Close()
 
@@ -50,7 +50,7 @@ func TestAddEnvironmentWithEventPublisher(t *testing.T) {
 	view.SetReportingPeriod(testReportingPeriod)
 	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
 
-	manager, err := NewManager(config.MetricsConfig{}, 0, ldlog.NewDisabledLoggers())
+	manager, err := NewManager(config.MetricsConfig{}, "", 0, ldlog.NewDisabledLoggers())
 	require.NoError(t, err)
 	defer manager.Close()
 
@@ -74,7 +74,7 @@ func TestAddEnvironmentWithEventPublisher(t *testing.T) {
 }
 
 func TestAddEnvironmentAfterManagerClosed(t *testing.T) {
-	manager, err := NewManager(config.MetricsConfig{}, 0, ldlog.NewDisabledLoggers())
+	manager, err := NewManager(config.MetricsConfig{}, "", 0, ldlog.NewDisabledLoggers())
 	require.NoError(t, err)
 	manager.Close()
 	env, err := manager.AddEnvironment("name", nil)
@@ -83,7 +83,7 @@ func TestAddEnvironmentAfterManagerClosed(t *testing.T) {
 }
 
 func TestRemoveEnvironment(t *testing.T) {
-	manager, err := NewManager(config.MetricsConfig{}, 0, ldlog.NewDisabledLoggers())
+	manager, err := NewManager(config.MetricsConfig{}, "", 0, ldlog.NewDisabledLoggers())
 	require.NoError(t, err)
 	defer manager.Close()
 
@@ -187,6 +187,102 @@ func TestWithRouteCount(t *testing.T) {
 	})
 }
 
+func TestWithLatency(t *testing.T) {
+	specs := []struct {
+		platform string
+		measure  LatencyMeasure
+	}{
+		{platform: browserTagValue, measure: BrowserEvalLatency},
+		{platform: mobileTagValue, measure: MobileEvalLatency},
+		{platform: serverTagValue, measure: ServerEvalLatency},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.platform, func(*testing.T) {
+			testWithExporter(t, func(p testWithExporterParams) {
+				called := false
+				WithLatency(p.env.GetOpenCensusContext(), func() { called = true }, tt.measure)
+				assert.True(t, called)
+
+				p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+					return d.HasRow(evalLatencyView.Name, st.TestMetricsRow{
+						Tags: map[string]string{
+							envNameTagKey.Name():          p.envName,
+							platformCategoryTagKey.Name(): tt.platform,
+						},
+						Count: 1,
+					})
+				})
+			})
+		})
+	}
+}
+
+func TestRecordLatency(t *testing.T) {
+	testWithExporter(t, func(p testWithExporterParams) {
+		RecordLatency(p.env.GetOpenCensusContext(), 250*time.Millisecond, EnvInitDuration)
+
+		p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+			return d.HasRow(initDurationView.Name, st.TestMetricsRow{
+				Tags: map[string]string{envNameTagKey.Name(): p.envName},
+				Sum:  250,
+			})
+		})
+	})
+}
+
+func TestIncrement(t *testing.T) {
+	testWithExporter(t, func(p testWithExporterParams) {
+		Increment(p.env.GetOpenCensusContext(), StoreFormatMismatches)
+
+		p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+			return d.HasRow(storeFormatMismatchView.Name, st.TestMetricsRow{
+				Tags:  map[string]string{envNameTagKey.Name(): p.envName},
+				Count: 1,
+			})
+		})
+	})
+}
+
+func TestIncrementBy(t *testing.T) {
+	testWithExporter(t, func(p testWithExporterParams) {
+		IncrementBy(p.env.GetOpenCensusContext(), UnknownFlagEvents, 3)
+		IncrementBy(p.env.GetOpenCensusContext(), UnknownFlagEvents, 2)
+
+		p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+			return d.HasRow(unknownFlagEventsView.Name, st.TestMetricsRow{
+				Tags: map[string]string{envNameTagKey.Name(): p.envName},
+				Sum:  5,
+			})
+		})
+	})
+}
+
+func TestIncrementDataUpdates(t *testing.T) {
+	specs := []struct {
+		kind    string
+		measure Measure
+	}{
+		{kind: flagsTagValue, measure: FlagUpdates},
+		{kind: segmentsTagValue, measure: SegmentUpdates},
+	}
+
+	for _, tt := range specs {
+		t.Run(tt.kind, func(*testing.T) {
+			testWithExporter(t, func(p testWithExporterParams) {
+				Increment(p.env.GetOpenCensusContext(), tt.measure)
+
+				p.exporter.AwaitData(t, time.Second, p.mockLog.Loggers, func(d st.TestMetricsData) bool {
+					return d.HasRow(dataUpdateView.Name, st.TestMetricsRow{
+						Tags:  map[string]string{envNameTagKey.Name(): p.envName, dataKindTagKey.Name(): tt.kind},
+						Count: 1,
+					})
+				})
+			})
+		})
+	}
+}
+
 func TestSanitizeTagValue(t *testing.T) {
 	assert.Equal(t, "abc", sanitizeTagValue("abc"))
 	assert.Equal(t, "_", sanitizeTagValue(""))
@@ -0,0 +1,316 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
+	"go.opencensus.io/trace"
+)
+
+const (
+	otlpTracesPath         = "/v1/traces"
+	otlpScopeName          = "github.com/launchdarkly/ld-relay"
+	otlpFlushInterval      = 5 * time.Second
+	otlpMaxBatchSize       = 512
+	otlpQueueCapacity      = 4096
+	otlpRequestTimeout     = 10 * time.Second
+	otlpServiceNameAttrKey = "service.name"
+	otlpDefaultSampleRate  = 1.0
+
+	// OTLP span kinds and status codes, from opentelemetry/proto/trace/v1/trace.proto
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+	otlpStatusCodeError  = 2
+)
+
+var openTelemetryExporterType exporterType = openTelemetryExporterTypeImpl{} //nolint:gochecknoglobals
+
+type openTelemetryExporterTypeImpl struct{}
+
+// openTelemetryExporterImpl is an OpenCensus trace exporter that delivers spans to an OpenTelemetry
+// collector, using the JSON encoding of OTLP over HTTP. Spans are queued and delivered in batches by a
+// separate goroutine, so that ExportSpan never blocks a request; if the queue is full, spans are dropped.
+// Only traces are exported this way; metrics are still available through the other exporters.
+type openTelemetryExporterImpl struct {
+	tracesURI     string
+	headers       http.Header
+	serviceName   string
+	relayVersion  string
+	sampleRate    float64
+	client        *http.Client
+	loggers       ldlog.Loggers
+	flushInterval time.Duration
+	spansCh       chan *trace.SpanData
+	closeCh       chan struct{}
+	doneCh        chan struct{}
+	closeOnce     sync.Once
+	overflowOnce  sync.Once
+}
+
+func (o openTelemetryExporterTypeImpl) getName() string {
+	return "OpenTelemetry"
+}
+
+func (o openTelemetryExporterTypeImpl) createExporterIfEnabled(
+	mc config.MetricsConfig,
+	relayVersion string,
+	loggers ldlog.Loggers,
+) (exporter, error) {
+	if !mc.OpenTelemetry.Enabled {
+		return nil, nil
+	}
+	return newOpenTelemetryExporter(mc.OpenTelemetry, relayVersion, loggers, otlpFlushInterval), nil
+}
+
+func newOpenTelemetryExporter(
+	oc config.OpenTelemetryConfig,
+	relayVersion string,
+	loggers ldlog.Loggers,
+	flushInterval time.Duration,
+) *openTelemetryExporterImpl {
+	headers := make(http.Header)
+	for _, entry := range oc.Headers.Values() { // entries have already been validated as "Name: value"
+		name, value, _ := strings.Cut(entry, ":")
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	serviceName := oc.ServiceName
+	if serviceName == "" {
+		serviceName = defaultMetricsPrefix
+	}
+	e := &openTelemetryExporterImpl{
+		tracesURI:     strings.TrimSuffix(oc.Endpoint.String(), "/") + otlpTracesPath,
+		headers:       headers,
+		serviceName:   serviceName,
+		relayVersion:  relayVersion,
+		sampleRate:    oc.SampleRate.GetOrElse(otlpDefaultSampleRate),
+		client:        &http.Client{Timeout: otlpRequestTimeout},
+		loggers:       loggers,
+		flushInterval: flushInterval,
+		spansCh:       make(chan *trace.SpanData, otlpQueueCapacity),
+		closeCh:       make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *openTelemetryExporterImpl) register() error {
+	// OpenCensus's default sampler only samples one in 10,000 new traces, which would make the exporter
+	// nearly useless. ProbabilitySampler always samples a span whose parent was sampled.
+	tracing.SetSampler(trace.ProbabilitySampler(e.sampleRate))
+	tracing.RegisterExporter(e)
+	return nil
+}
+
+func (e *openTelemetryExporterImpl) close() error {
+	tracing.UnregisterExporter(e)
+	tracing.SetSampler(nil)
+	e.closeOnce.Do(func() {
+		close(e.closeCh)
+	})
+	<-e.doneCh
+	return nil
+}
+
+// ExportSpan is called by OpenCensus for each sampled span when it ends.
+func (e *openTelemetryExporterImpl) ExportSpan(s *trace.SpanData) {
+	select {
+	case e.spansCh <- s:
+	default:
+		e.overflowOnce.Do(func() {
+			e.loggers.Warn("OpenTelemetry span queue is full; some spans will not be exported")
+		})
+	}
+}
+
+func (e *openTelemetryExporterImpl) run() {
+	defer close(e.doneCh)
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	var batch []*trace.SpanData
+	for {
+		select {
+		case s := <-e.spansCh:
+			batch = append(batch, s)
+			if len(batch) >= otlpMaxBatchSize {
+				e.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				e.send(batch)
+				batch = nil
+			}
+		case <-e.closeCh:
+		drain:
+			for {
+				select {
+				case s := <-e.spansCh:
+					batch = append(batch, s)
+				default:
+					break drain
+				}
+			}
+			if len(batch) > 0 {
+				e.send(batch)
+			}
+			return
+		}
+	}
+}
+
+func (e *openTelemetryExporterImpl) send(spans []*trace.SpanData) {
+	payload, err := json.Marshal(e.makeRequest(spans))
+	if err != nil { // COVERAGE: can't happen in unit tests
+		e.loggers.Errorf("Unexpected error marshalling OpenTelemetry spans: %s", err)
+		return
+	}
+	req, err := http.NewRequest("POST", e.tracesURI, bytes.NewReader(payload))
+	if err != nil { // COVERAGE: can't happen in unit tests
+		e.loggers.Errorf("Unexpected error creating OpenTelemetry request: %s", err)
+		return
+	}
+	for name, values := range e.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.loggers.Warnf("Failed to send %d spans to OpenTelemetry collector: %s", len(spans), err)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		e.loggers.Warnf("Failed to send %d spans to OpenTelemetry collector: HTTP status %d", len(spans), resp.StatusCode)
+	}
+}
+
+// The following types are the OTLP/JSON representation of an ExportTraceServiceRequest. In this encoding,
+// trace and span IDs are hex strings, and 64-bit integers are decimal strings.
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *openTelemetryExporterImpl) makeRequest(spans []*trace.SpanData) otlpTracesRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		otlpSpans = append(otlpSpans, makeOTLPSpan(s))
+	}
+	return otlpTracesRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{makeOTLPKeyValue(otlpServiceNameAttrKey, e.serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName, Version: e.relayVersion},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func makeOTLPSpan(s *trace.SpanData) otlpSpan {
+	ret := otlpSpan{
+		TraceID:           hex.EncodeToString(s.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.SpanID[:]),
+		Name:              s.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+	}
+	if s.ParentSpanID != (trace.SpanID{}) {
+		ret.ParentSpanID = hex.EncodeToString(s.ParentSpanID[:])
+	}
+	switch s.SpanKind {
+	case trace.SpanKindServer:
+		ret.Kind = otlpSpanKindServer
+	case trace.SpanKindClient:
+		ret.Kind = otlpSpanKindClient
+	}
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ret.Attributes = append(ret.Attributes, makeOTLPKeyValue(k, s.Attributes[k]))
+	}
+	if s.Code != trace.StatusCodeOK {
+		ret.Status = otlpStatus{Code: otlpStatusCodeError, Message: s.Message}
+	}
+	return ret
+}
+
+func makeOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	var v map[string]interface{}
+	switch value := value.(type) {
+	case bool:
+		v = map[string]interface{}{"boolValue": value}
+	case int64:
+		v = map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}
+	case float64:
+		v = map[string]interface{}{"doubleValue": value}
+	case string:
+		v = map[string]interface{}{"stringValue": value}
+	default:
+		v = map[string]interface{}{"stringValue": fmt.Sprint(value)}
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
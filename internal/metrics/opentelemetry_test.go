@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+type receivedOTLPRequest struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+func startFakeOTLPCollector(t *testing.T) (*httptest.Server, <-chan receivedOTLPRequest) {
+	requestsCh := make(chan receivedOTLPRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		requestsCh <- receivedOTLPRequest{path: req.URL.Path, headers: req.Header, body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, requestsCh
+}
+
+func makeOpenTelemetryConfig(t *testing.T, endpoint string) config.OpenTelemetryConfig {
+	uri, err := ct.NewOptURLAbsoluteFromString(endpoint)
+	require.NoError(t, err)
+	return config.OpenTelemetryConfig{
+		Enabled:  true,
+		Endpoint: uri,
+		Headers:  ct.NewOptStringList([]string{"Authorization: Bearer xyz"}),
+	}
+}
+
+func TestOpenTelemetryExporterType(t *testing.T) {
+	exporterType := openTelemetryExporterType
+
+	t.Run("name", func(t *testing.T) {
+		assert.Equal(t, "OpenTelemetry", exporterType.getName())
+	})
+
+	t.Run("included in allExporterTypes", func(t *testing.T) {
+		assert.Contains(t, allExporterTypes(), exporterType)
+	})
+
+	t.Run("does not create exporter if OpenTelemetry is disabled", func(t *testing.T) {
+		var mc config.MetricsConfig
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assert.Nil(t, e)
+	})
+
+	t.Run("creates and registers exporter if OpenTelemetry is enabled", func(t *testing.T) {
+		var mc config.MetricsConfig
+		mc.OpenTelemetry = makeOpenTelemetryConfig(t, "http://localhost:4318")
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		require.NotNil(t, e)
+		assert.NoError(t, e.register())
+		assert.NoError(t, e.close())
+	})
+
+	t.Run("register applies the configured sample rate to Relay's spans", func(t *testing.T) {
+		oc := makeOpenTelemetryConfig(t, "http://localhost:4318")
+		oc.SampleRate = ct.NewOptFloat64(0)
+		e := newOpenTelemetryExporter(oc, "", ldlog.NewDisabledLoggers(), time.Hour)
+		require.NoError(t, e.register())
+		defer e.close()
+
+		_, span := tracing.StartServerSpan(context.Background(), "unsampled")
+		span.End()
+		assert.False(t, span.SpanContext().IsSampled())
+
+		req, _ := http.NewRequest("GET", "http://localhost", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		_, span = tracing.StartServerSpan(tracing.WithTraceParent(context.Background(), req), "sampled-parent")
+		span.End()
+		assert.True(t, span.SpanContext().IsSampled())
+
+		// Spans that are not started by Relay still use the global default sampler, which the tests set
+		// to always sample
+		_, span = trace.StartSpan(context.Background(), "other")
+		span.End()
+		assert.True(t, span.SpanContext().IsSampled())
+	})
+
+	t.Run("close removes the sample rate", func(t *testing.T) {
+		oc := makeOpenTelemetryConfig(t, "http://localhost:4318")
+		oc.SampleRate = ct.NewOptFloat64(0)
+		e := newOpenTelemetryExporter(oc, "", ldlog.NewDisabledLoggers(), time.Hour)
+		require.NoError(t, e.register())
+		require.NoError(t, e.close())
+
+		_, span := tracing.StartServerSpan(context.Background(), "sampled")
+		span.End()
+		assert.True(t, span.SpanContext().IsSampled())
+	})
+}
+
+func TestOpenTelemetryExporterSendsSpans(t *testing.T) {
+	server, requestsCh := startFakeOTLPCollector(t)
+	oc := makeOpenTelemetryConfig(t, server.URL)
+	oc.ServiceName = "my-relay"
+	e := newOpenTelemetryExporter(oc, "1.2.3", ldlog.NewDisabledLoggers(), time.Hour)
+
+	startTime := time.Unix(1000, 0)
+	e.ExportSpan(&trace.SpanData{
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		},
+		ParentSpanID: trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		SpanKind:     trace.SpanKindServer,
+		Name:         "/sdk/evalx/contexts/{context}",
+		StartTime:    startTime,
+		EndTime:      startTime.Add(time.Second),
+		Attributes:   map[string]interface{}{"count": int64(3)},
+		Status:       trace.Status{Code: trace.StatusCodeUnknown, Message: "sorry"},
+	})
+	require.NoError(t, e.close()) // delivers any queued spans
+
+	var r receivedOTLPRequest
+	select {
+	case r = <-requestsCh:
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for spans")
+	}
+	assert.Equal(t, "/v1/traces", r.path)
+	assert.Equal(t, "application/json", r.headers.Get("Content-Type"))
+	assert.Equal(t, "Bearer xyz", r.headers.Get("Authorization"))
+
+	var body otlpTracesRequest
+	require.NoError(t, json.Unmarshal(r.body, &body))
+	require.Len(t, body.ResourceSpans, 1)
+	assert.Equal(t, "my-relay", body.ResourceSpans[0].Resource.Attributes[0].Value["stringValue"])
+	require.Len(t, body.ResourceSpans[0].ScopeSpans, 1)
+	assert.Equal(t, otlpScope{Name: otlpScopeName, Version: "1.2.3"}, body.ResourceSpans[0].ScopeSpans[0].Scope)
+	require.Len(t, body.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+	assert.Equal(t, otlpSpan{
+		TraceID:           "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:            "00f067aa0ba902b7",
+		ParentSpanID:      "0102030405060708",
+		Name:              "/sdk/evalx/contexts/{context}",
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: "1000000000000",
+		EndTimeUnixNano:   "1001000000000",
+		Attributes:        []otlpKeyValue{{Key: "count", Value: map[string]interface{}{"intValue": "3"}}},
+		Status:            otlpStatus{Code: otlpStatusCodeError, Message: "sorry"},
+	}, body.ResourceSpans[0].ScopeSpans[0].Spans[0])
+}
+
+func TestOpenTelemetryExporterLogsDeliveryFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	mockLog := ldlogtest.NewMockLog()
+	e := newOpenTelemetryExporter(makeOpenTelemetryConfig(t, server.URL), "", mockLog.Loggers, time.Hour)
+
+	e.ExportSpan(&trace.SpanData{Name: "x"})
+	require.NoError(t, e.close())
+
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Failed to send 1 spans to OpenTelemetry collector: HTTP status 503")
+}
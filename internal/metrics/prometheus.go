@@ -35,6 +35,7 @@ func (p prometheusExporterTypeImpl) getName() string {
 
 func (p prometheusExporterTypeImpl) createExporterIfEnabled(
 	mc config.MetricsConfig,
+	_ string,
 	loggers ldlog.Loggers,
 ) (exporter, error) {
 	if !mc.Prometheus.Enabled {
@@ -30,7 +30,7 @@ func TestPrometheusExporterType(t *testing.T) {
 
 	t.Run("does not create exporter if Prometheus is disabled", func(t *testing.T) {
 		var mc config.MetricsConfig
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.Nil(t, e)
 	})
@@ -38,7 +38,7 @@ func TestPrometheusExporterType(t *testing.T) {
 	t.Run("creates exporter if Prometheus is enabled", func(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Prometheus.Enabled = true
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.NotNil(t, e)
 		e.close()
@@ -49,7 +49,7 @@ func TestPrometheusExporterType(t *testing.T) {
 	t.Run("registers exporter without errors", func(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Prometheus.Enabled = true
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.NotNil(t, e)
 		defer e.close()
@@ -76,7 +76,7 @@ func TestPrometheusExporterType(t *testing.T) {
 	t.Run("listens on default port", func(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Prometheus.Enabled = true
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		require.NotNil(t, e)
 
@@ -91,7 +91,7 @@ func TestPrometheusExporterType(t *testing.T) {
 		var mc config.MetricsConfig
 		mc.Prometheus.Enabled = true
 		mc.Prometheus.Port, _ = ct.NewOptIntGreaterThanZero(availablePort)
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		require.NotNil(t, e)
 
@@ -106,7 +106,7 @@ func TestPrometheusExporterType(t *testing.T) {
 			var mc config.MetricsConfig
 			mc.Prometheus.Enabled = true
 			mc.Prometheus.Port, _ = ct.NewOptIntGreaterThanZero(usedPort)
-			e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+			e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 			require.NoError(t, err)
 			require.NotNil(t, e)
 
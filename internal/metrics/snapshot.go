@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"go.opencensus.io/stats/view"
+)
+
+// Snapshot is a point-in-time copy of all of the metric values that Relay has recorded, as returned by
+// Manager.GetSnapshot.
+type Snapshot struct {
+	// Environments maps each environment name (as used in the "env" tag) to its metrics. Values that
+	// were not recorded for any particular environment are grouped under "_".
+	Environments map[string]EnvironmentSnapshot `json:"environments"`
+}
+
+// EnvironmentSnapshot maps a metric name, such as "connections" or "requests", to its current values.
+// There is one value for each distinct combination of tags, not including the environment name.
+type EnvironmentSnapshot map[string][]SnapshotValue
+
+// SnapshotValue is the current value of a metric for one combination of tags. For counters and gauges,
+// Value is set; for latency histograms, Count, Mean, Min, and Max are set instead.
+type SnapshotValue struct {
+	Tags  map[string]string `json:"tags,omitempty"`
+	Value *float64          `json:"value,omitempty"`
+	Count int64             `json:"count,omitempty"`
+	Mean  float64           `json:"mean,omitempty"`
+	Min   float64           `json:"min,omitempty"`
+	Max   float64           `json:"max,omitempty"`
+}
+
+// GetSnapshot returns the current values of all of Relay's OpenCensus views, grouped by environment.
+// This is the same data that is sent to the configured exporters, so it is available even if no
+// exporters are enabled.
+func (m *Manager) GetSnapshot() (Snapshot, error) {
+	snapshot := Snapshot{Environments: make(map[string]EnvironmentSnapshot)}
+	for _, v := range append(getPublicViews(), getPrivateViews()...) {
+		rows, err := view.RetrieveData(v.Name)
+		if err != nil { // COVERAGE: can't happen in unit tests, since the views are always registered
+			return Snapshot{}, err
+		}
+		for _, row := range rows {
+			envName := sanitizeTagValue("")
+			value := SnapshotValue{}
+			for _, t := range row.Tags {
+				if t.Key == envNameTagKey {
+					envName = t.Value
+					continue
+				}
+				if value.Tags == nil {
+					value.Tags = make(map[string]string)
+				}
+				value.Tags[t.Key.Name()] = t.Value
+			}
+			switch data := row.Data.(type) {
+			case *view.CountData:
+				n := float64(data.Value)
+				value.Value = &n
+			case *view.SumData:
+				n := data.Value
+				value.Value = &n
+			case *view.LastValueData:
+				n := data.Value
+				value.Value = &n
+			case *view.DistributionData:
+				value.Count, value.Mean, value.Min, value.Max = data.Count, data.Mean, data.Min, data.Max
+			}
+			envSnapshot := snapshot.Environments[envName]
+			if envSnapshot == nil {
+				envSnapshot = make(EnvironmentSnapshot)
+				snapshot.Environments[envName] = envSnapshot
+			}
+			envSnapshot[v.Name] = append(envSnapshot[v.Name], value)
+		}
+	}
+	return snapshot, nil
+}
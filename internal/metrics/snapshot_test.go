@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSnapshot(t *testing.T) {
+	manager, err := NewManager(config.MetricsConfig{}, "", 0, ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	defer manager.Close()
+
+	envName := "env-" + uuid.New()
+	env, err := manager.AddEnvironment(envName, nil)
+	require.NoError(t, err)
+
+	WithRouteCount(env.GetOpenCensusContext(), userAgentValue, "someRoute", "GET", func() {}, ServerRequests)
+	WithLatency(env.GetOpenCensusContext(), func() {}, MobileEvalLatency)
+
+	var envSnapshot EnvironmentSnapshot
+	require.Eventually(t, func() bool {
+		snapshot, err := manager.GetSnapshot()
+		require.NoError(t, err)
+		envSnapshot = snapshot.Environments[envName]
+		return len(envSnapshot[requestView.Name]) != 0 && len(envSnapshot[evalLatencyView.Name]) != 0
+	}, time.Second, time.Millisecond*10)
+
+	requests := envSnapshot[requestView.Name]
+	require.Len(t, requests, 1)
+	assert.Equal(t, map[string]string{
+		methodTagKey.Name():           "GET",
+		platformCategoryTagKey.Name(): serverTagValue,
+		routeTagKey.Name():            "someRoute",
+		userAgentTagKey.Name():        userAgentValue,
+	}, requests[0].Tags)
+	require.NotNil(t, requests[0].Value)
+	assert.Equal(t, float64(1), *requests[0].Value)
+
+	latency := envSnapshot[evalLatencyView.Name]
+	require.Len(t, latency, 1)
+	assert.Equal(t, map[string]string{platformCategoryTagKey.Name(): mobileTagValue}, latency[0].Tags)
+	assert.Nil(t, latency[0].Value)
+	assert.Equal(t, int64(1), latency[0].Count)
+}
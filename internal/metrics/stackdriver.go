@@ -2,12 +2,12 @@ package metrics
 
 import (
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 
 	stackdriver "github.com/launchdarkly/opencensus-go-exporter-stackdriver"
 	"go.opencensus.io/stats/view"
-	"go.opencensus.io/trace"
 )
 
 var stackdriverExporterType exporterType = stackdriverExporterTypeImpl{} //nolint:gochecknoglobals
@@ -24,6 +24,7 @@ func (s stackdriverExporterTypeImpl) getName() string {
 
 func (s stackdriverExporterTypeImpl) createExporterIfEnabled(
 	mc config.MetricsConfig,
+	_ string,
 	loggers ldlog.Loggers,
 ) (exporter, error) {
 	if !mc.Stackdriver.Enabled {
@@ -44,12 +45,12 @@ func (s stackdriverExporterTypeImpl) createExporterIfEnabled(
 
 func (s *stackdriverExporterImpl) register() error {
 	view.RegisterExporter(s.exporter)
-	trace.RegisterExporter(s.exporter)
+	tracing.RegisterExporter(s.exporter)
 	return nil
 }
 
 func (s *stackdriverExporterImpl) close() error {
 	view.UnregisterExporter(s.exporter)
-	trace.UnregisterExporter(s.exporter)
+	tracing.UnregisterExporter(s.exporter)
 	return nil
 }
@@ -38,7 +38,7 @@ func TestStackdriverExporterType(t *testing.T) {
 
 	t.Run("does not create exporter if Stackdriver is disabled", func(t *testing.T) {
 		var mc config.MetricsConfig
-		e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+		e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 		require.NoError(t, err)
 		assert.Nil(t, e)
 	})
@@ -48,7 +48,7 @@ func TestStackdriverExporterType(t *testing.T) {
 		mc.Stackdriver.Enabled = true
 		mc.Stackdriver.ProjectID = fakeProjectID
 		withDefaultGoogleApplicationCredentials([]byte(fakeGoogleCredentials), func() {
-			e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+			e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 			require.NoError(t, err)
 			assert.NotNil(t, e)
 			e.close()
@@ -60,7 +60,7 @@ func TestStackdriverExporterType(t *testing.T) {
 		mc.Stackdriver.Enabled = true
 		mc.Stackdriver.ProjectID = fakeProjectID
 		withDefaultGoogleApplicationCredentials([]byte(fakeInvalidGoogleCredentials), func() {
-			e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+			e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 			require.Error(t, err)
 			assert.Nil(t, e)
 		})
@@ -71,7 +71,7 @@ func TestStackdriverExporterType(t *testing.T) {
 		mc.Stackdriver.Enabled = true
 		mc.Stackdriver.ProjectID = fakeProjectID
 		withDefaultGoogleApplicationCredentials([]byte(fakeGoogleCredentials), func() {
-			e, err := exporterType.createExporterIfEnabled(mc, ldlog.NewDisabledLoggers())
+			e, err := exporterType.createExporterIfEnabled(mc, "", ldlog.NewDisabledLoggers())
 			require.NoError(t, err)
 			assert.NotNil(t, e)
 			defer e.close()
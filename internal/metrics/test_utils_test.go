@@ -36,7 +36,7 @@ func testWithExporter(t *testing.T, action func(testWithExporterParams)) {
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
 
-	manager, err := NewManager(config.MetricsConfig{}, time.Millisecond*10, mockLog.Loggers)
+	manager, err := NewManager(config.MetricsConfig{}, "", time.Millisecond*10, mockLog.Loggers)
 	require.NoError(t, err)
 	defer manager.Close()
 
@@ -83,6 +83,7 @@ func (t *testExporterTypeImpl) getName() string {
 
 func (t *testExporterTypeImpl) createExporterIfEnabled(
 	mc config.MetricsConfig,
+	_ string,
 	loggers ldlog.Loggers,
 ) (exporter, error) {
 	if t.errorOnCreate != nil {
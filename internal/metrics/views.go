@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 )
 
 var (
@@ -22,6 +23,44 @@ var (
 		Aggregation: view.Count(),
 		TagKeys:     append(publicTags, routeTagKey, methodTagKey),
 	}
+	evalLatencyView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure: evalLatencyMeasure,
+		// Bucket boundaries are in milliseconds
+		Aggregation: view.Distribution(0.5, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000),
+		// We deliberately omit the user agent tag here, to keep the number of histograms manageable
+		TagKeys: []tag.Key{platformCategoryTagKey, envNameTagKey},
+	}
+	storeFormatMismatchView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     storeFormatMismatchMeasure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{envNameTagKey},
+	}
+	dataUpdateView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     dataUpdateMeasure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{envNameTagKey, dataKindTagKey},
+	}
+	initDurationView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure: initDurationMeasure,
+		// Each environment only initializes once, so the last value is all there is to report
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{envNameTagKey},
+	}
+	unknownFlagEventsView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     unknownFlagEventsMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{envNameTagKey},
+	}
+	storeCircuitBreakerOpenView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     storeCircuitBreakerOpenMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{envNameTagKey},
+	}
+	oversizedStreamEventsView *view.View = &view.View{ //nolint:gochecknoglobals
+		Measure:     oversizedStreamEventsMeasure,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{envNameTagKey},
+	}
 	privateConnView *view.View = &view.View{ //nolint:gochecknoglobals
 		Measure:     privateConnMeasure,
 		Aggregation: view.Sum(),
@@ -43,7 +82,8 @@ var (
 )
 
 func getPublicViews() []*view.View {
-	return []*view.View{publicConnView, publicNewConnView, requestView}
+	return []*view.View{publicConnView, publicNewConnView, requestView, evalLatencyView, storeFormatMismatchView, dataUpdateView,
+		initDurationView, unknownFlagEventsView, storeCircuitBreakerOpenView, oversizedStreamEventsView}
 }
 
 func getPrivateViews() []*view.View {
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminAuth creates a middleware function for administrative endpoints, which rejects the request with a
+// 401 error unless its Authorization header is exactly the configured admin key.
+func AdminAuth(adminKey string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			authHeader := req.Header.Get("Authorization")
+			if adminKey == "" || subtle.ConstantTimeCompare([]byte(authHeader), []byte(adminKey)) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	handler := AdminAuth("admin-key")(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, p := range []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"correct key", "admin-key", http.StatusNoContent},
+		{"wrong key", "other-key", http.StatusUnauthorized},
+		{"no key", "", http.StatusUnauthorized},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/", nil)
+			if p.header != "" {
+				req.Header.Set("Authorization", p.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, p.status, w.Result().StatusCode)
+		})
+	}
+
+	t.Run("empty admin key rejects everything", func(t *testing.T) {
+		h := AdminAuth("")(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		req, _ := http.NewRequest("POST", "/", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	})
+}
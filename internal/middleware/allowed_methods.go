@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// AllowMethods creates a middleware function that rejects any request whose HTTP method is not in the
+// given list with a 405 error. This is applied to the whole router, before routing, so that an
+// unexpected method never reaches any handler even if a route would otherwise accept it. Methods are
+// compared case-insensitively. If the list is empty, all methods are allowed.
+func AllowMethods(methods []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(methods))
+	names := make([]string, 0, len(methods))
+	for _, m := range methods {
+		name := strings.ToUpper(strings.TrimSpace(m))
+		if name != "" && !allowed[name] {
+			allowed[name] = true
+			names = append(names, name)
+		}
+	}
+	allowHeader := strings.Join(names, ", ")
+	return func(next http.Handler) http.Handler {
+		if len(allowed) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !allowed[strings.ToUpper(req.Method)] {
+				w.Header().Set("Allow", allowHeader)
+				util.WriteErrorJSONf(w, http.StatusMethodNotAllowed, "Method %s is not allowed", req.Method)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowMethods(t *testing.T) {
+	t.Run("allowed method is passed to handler", func(t *testing.T) {
+		for _, method := range []string{"GET", "REPORT", "report"} {
+			called := false
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+			req, _ := http.NewRequest(method, "", nil)
+			resp := httptest.NewRecorder()
+			AllowMethods([]string{"GET", "report"})(handler).ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusOK, resp.Code, method)
+			assert.True(t, called, method)
+		}
+	})
+
+	t.Run("other method is rejected without calling handler", func(t *testing.T) {
+		for _, method := range []string{"PUT", "DELETE", "PATCH"} {
+			called := false
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+			req, _ := http.NewRequest(method, "", nil)
+			resp := httptest.NewRecorder()
+			AllowMethods([]string{"GET", "POST"})(handler).ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusMethodNotAllowed, resp.Code, method)
+			assert.Equal(t, "GET, POST", resp.Header().Get("Allow"))
+			assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+			assert.JSONEq(t, string(util.ErrorJSONMsg(http.StatusMethodNotAllowed, "Method "+method+" is not allowed")),
+				resp.Body.String())
+			assert.False(t, called, method)
+		}
+	})
+
+	t.Run("empty list allows all methods", func(t *testing.T) {
+		called := false
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+		req, _ := http.NewRequest("DELETE", "", nil)
+		resp := httptest.NewRecorder()
+		AllowMethods(nil)(handler).ServeHTTP(resp, req)
+		assert.True(t, called)
+	})
+}
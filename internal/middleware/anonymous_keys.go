@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/sha1" //nolint:gosec // we're not using SHA1 for encryption, just for generating a stable key
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+// anonymousKeyPrefix is prepended to keys that we generate for anonymous contexts that have no key.
+const anonymousKeyPrefix = "anonymous-"
+
+// ContextFromJSON decodes a go-server-sdk evaluation context from JSON.
+//
+// Mobile and JS SDKs may send an anonymous user or context without a key, expecting the key to be
+// filled in as the SDK itself would do. In that case we generate a key from a hash of the context's
+// other properties, so that the same anonymous context gets the same evaluation results each time.
+// The hash is computed over a normalized form of the JSON, so property order and whitespace do not affect
+// it; a context sent in a REPORT body gets the same key as the equivalent context in a GET URL. The generated
+// key exists only in the returned Context; nothing is stored between requests.
+func ContextFromJSON(data []byte) (ldcontext.Context, error) {
+	var ldContext ldcontext.Context
+	err := json.Unmarshal(withAnonymousContextKeys(data), &ldContext)
+	return ldContext, err
+}
+
+func withAnonymousContextKeys(data []byte) []byte {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return data // let the real unmarshaler report the error
+	}
+	var kind string
+	_ = json.Unmarshal(obj["kind"], &kind)
+	if kind != "multi" {
+		if !fillAnonymousContextKey(obj) {
+			return data
+		}
+		out, _ := json.Marshal(obj)
+		return out
+	}
+	changed := false
+	for name, value := range obj {
+		if name == "kind" {
+			continue
+		}
+		var single map[string]json.RawMessage
+		if err := json.Unmarshal(value, &single); err != nil {
+			continue
+		}
+		if fillAnonymousContextKey(single) {
+			obj[name], _ = json.Marshal(single)
+			changed = true
+		}
+	}
+	if !changed {
+		return data
+	}
+	out, _ := json.Marshal(obj)
+	return out
+}
+
+// fillAnonymousContextKey adds a generated key to a single-kind context or old-style user object if it
+// is anonymous and has no key. It returns true if it made a change.
+func fillAnonymousContextKey(obj map[string]json.RawMessage) bool {
+	var anonymous bool
+	if err := json.Unmarshal(obj["anonymous"], &anonymous); err != nil || !anonymous {
+		return false
+	}
+	if rawKey, ok := obj["key"]; ok {
+		var key string
+		if err := json.Unmarshal(rawKey, &key); err != nil || key != "" {
+			return false
+		}
+	}
+	// Marshaling the map sorts its properties and compacts the raw values.
+	normalized, _ := json.Marshal(obj)
+	hash := sha1.Sum(normalized) //nolint:gosec // see above
+	obj["key"], _ = json.Marshal(anonymousKeyPrefix + hex.EncodeToString(hash[:]))
+	return true
+}
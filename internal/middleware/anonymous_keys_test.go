@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextFromJSON(t *testing.T) {
+	t.Run("context with key is unchanged", func(t *testing.T) {
+		c, err := ContextFromJSON([]byte(`{"kind":"user","key":"a","anonymous":true}`))
+		require.NoError(t, err)
+		assert.Equal(t, ldcontext.NewBuilder("a").Anonymous(true).Build(), c)
+	})
+
+	t.Run("anonymous old-style user without key", func(t *testing.T) {
+		c, err := ContextFromJSON([]byte(`{"anonymous":true,"name":"b"}`))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(c.Key(), anonymousKeyPrefix))
+		assert.True(t, c.Anonymous())
+		assert.Equal(t, "b", c.Name().StringValue())
+	})
+
+	t.Run("anonymous old-style user with empty key", func(t *testing.T) {
+		c, err := ContextFromJSON([]byte(`{"key":"","anonymous":true}`))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(c.Key(), anonymousKeyPrefix))
+	})
+
+	t.Run("anonymous single-kind context without key", func(t *testing.T) {
+		c, err := ContextFromJSON([]byte(`{"kind":"org","anonymous":true}`))
+		require.NoError(t, err)
+		assert.Equal(t, ldcontext.Kind("org"), c.Kind())
+		assert.True(t, strings.HasPrefix(c.Key(), anonymousKeyPrefix))
+	})
+
+	t.Run("anonymous context without key within multi-kind context", func(t *testing.T) {
+		c, err := ContextFromJSON([]byte(`{"kind":"multi","org":{"key":"x"},"user":{"anonymous":true}}`))
+		require.NoError(t, err)
+		org := c.IndividualContextByKind("org")
+		user := c.IndividualContextByKind("user")
+		assert.Equal(t, "x", org.Key())
+		assert.True(t, strings.HasPrefix(user.Key(), anonymousKeyPrefix))
+	})
+
+	t.Run("generated key is the same for the same context", func(t *testing.T) {
+		c1, _ := ContextFromJSON([]byte(`{"kind":"user","anonymous":true,"name":"b"}`))
+		c2, _ := ContextFromJSON([]byte(`{"kind":"user","anonymous":true,"name":"b"}`))
+		c3, _ := ContextFromJSON([]byte(`{"kind":"user","anonymous":true,"name":"c"}`))
+		assert.Equal(t, c1.Key(), c2.Key())
+		assert.NotEqual(t, c1.Key(), c3.Key())
+	})
+
+	t.Run("generated key does not depend on property order or whitespace", func(t *testing.T) {
+		c1, _ := ContextFromJSON([]byte(`{"kind":"user","anonymous":true,"name":"b"}`))
+		c2, _ := ContextFromJSON([]byte("{ \"name\": \"b\",\n  \"anonymous\": true, \"kind\": \"user\" }\n"))
+		assert.Equal(t, c1.Key(), c2.Key())
+	})
+
+	t.Run("non-anonymous context without key is still an error", func(t *testing.T) {
+		_, err := ContextFromJSON([]byte(`{"kind":"user","name":"b"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		_, err := ContextFromJSON([]byte(`{"sorry`))
+		assert.Error(t, err)
+	})
+}
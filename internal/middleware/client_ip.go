@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const clientIPContextKey contextKeyType = "clientIP"
+
+// parseTrustedProxies parses a list of trusted proxy addresses, each of which is either a CIDR range such
+// as "10.0.0.0/8" or a single IP address. It returns false if any entry is invalid.
+func parseTrustedProxies(entries []string) ([]*net.IPNet, bool) {
+	ret := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			ret = append(ret, ipNet)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, false
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		ret = append(ret, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return ret, true
+}
+
+// ClientIP creates a middleware function that determines the IP address of the client that made each
+// request, which can then be obtained with GetClientIP.
+//
+// The X-Forwarded-For header can be set to anything by the client, so it is only used if the request came
+// directly from one of the trusted proxies. In that case, the addresses in the header are examined from
+// right to left, since each proxy appends the address that it received the request from; the client is
+// the first address that is not a trusted proxy. If there are no trusted proxies, or if any of them are
+// invalid, the header is ignored and the client is the remote address of the connection.
+func ClientIP(trustedProxies []string) func(http.Handler) http.Handler {
+	trusted, ok := parseTrustedProxies(trustedProxies)
+	if !ok {
+		trusted = nil
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			clientIP := resolveClientIP(req, trusted)
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), clientIPContextKey, clientIP)))
+		})
+	}
+}
+
+// GetClientIP returns the IP address of the client that made the request, as determined by the ClientIP
+// middleware. If that middleware was not used, it returns the remote address of the connection.
+func GetClientIP(req *http.Request) string {
+	if clientIP, ok := req.Context().Value(clientIPContextKey).(string); ok {
+		return clientIP
+	}
+	return remoteHost(req.RemoteAddr)
+}
+
+func resolveClientIP(req *http.Request, trusted []*net.IPNet) string {
+	clientIP := remoteHost(req.RemoteAddr)
+	if !isTrustedProxy(net.ParseIP(clientIP), trusted) {
+		return clientIP
+	}
+	var forwarded []string
+	for _, value := range req.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			// We can't tell where a malformed address came from, so the last proxy that we trust is the
+			// closest we can get to the client.
+			break
+		}
+		clientIP = ip.String()
+		if !isTrustedProxy(ip, trusted) {
+			break
+		}
+	}
+	return clientIP
+}
+
+func isTrustedProxy(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
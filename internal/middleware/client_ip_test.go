@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	getClientIP := func(trustedProxies []string, remoteAddr string, forwardedFor ...string) string {
+		var clientIP string
+		handler := ClientIP(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			clientIP = GetClientIP(req)
+		}))
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, value := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return clientIP
+	}
+	trusted := []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"}
+
+	t.Run("remote address is used if there are no trusted proxies", func(t *testing.T) {
+		assert.Equal(t, "10.1.1.1", getClientIP(nil, "10.1.1.1:1234", "203.0.113.5"))
+	})
+
+	t.Run("header is ignored if the remote address is not a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.7", getClientIP(trusted, "198.51.100.7:1234", "203.0.113.5"))
+	})
+
+	t.Run("header is used if the remote address is a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.5", getClientIP(trusted, "10.1.1.1:1234", "203.0.113.5"))
+		assert.Equal(t, "203.0.113.5", getClientIP(trusted, "192.168.1.1:1234", "203.0.113.5"))
+		assert.Equal(t, "2001:db9::1", getClientIP(trusted, "[2001:db8::2]:1234", "2001:db9::1"))
+	})
+
+	t.Run("trusted proxies in the chain are skipped", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.5", getClientIP(trusted, "10.1.1.1:1234", "203.0.113.5, 192.168.1.1, 10.2.2.2"))
+		assert.Equal(t, "203.0.113.5", getClientIP(trusted, "10.1.1.1:1234", "203.0.113.5", "192.168.1.1,10.2.2.2"))
+	})
+
+	t.Run("spoofed addresses to the left of an untrusted address are ignored", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.5", getClientIP(trusted, "10.1.1.1:1234", "1.2.3.4, 203.0.113.5, 10.2.2.2"))
+	})
+
+	t.Run("leftmost address is used if the whole chain is trusted", func(t *testing.T) {
+		assert.Equal(t, "10.3.3.3", getClientIP(trusted, "10.1.1.1:1234", "10.3.3.3, 10.2.2.2"))
+	})
+
+	t.Run("last trusted address is used if the chain contains a malformed address", func(t *testing.T) {
+		assert.Equal(t, "10.2.2.2", getClientIP(trusted, "10.1.1.1:1234", "203.0.113.5, not-an-ip, 10.2.2.2"))
+	})
+
+	t.Run("remote address is used if there is no header", func(t *testing.T) {
+		assert.Equal(t, "10.1.1.1", getClientIP(trusted, "10.1.1.1:1234"))
+	})
+
+	t.Run("header is ignored if the trusted proxy list is invalid", func(t *testing.T) {
+		assert.Equal(t, "10.1.1.1", getClientIP([]string{"10.0.0.0/8", "bad"}, "10.1.1.1:1234", "203.0.113.5"))
+	})
+
+	t.Run("remote address is used if the middleware was not applied", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.1.1.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.5")
+		assert.Equal(t, "10.1.1.1", GetClientIP(req))
+	})
+}
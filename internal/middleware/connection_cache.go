@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+)
+
+const connectionCacheContextKey contextKeyType = "connectionCache"
+
+// connectionCache remembers which environment was selected for the most recent request on a single
+// connection. An SDK normally sends every request on a keep-alive connection with the same credential, so
+// this lets SelectEnvironmentByAuthorizationKey skip the environment lookup for all but the first one.
+//
+// An entry is only valid while the generation of the RelayEnvironments is the same as when the entry was
+// stored, so that adding or removing an environment, or rotating a key, is seen immediately. HTTP/2 can
+// send several requests on one connection at once, so access is synchronized.
+type connectionCache struct {
+	credential sdkauth.ScopedCredential
+	generation uint64
+	env        relayenv.EnvContext
+	lock       sync.Mutex
+}
+
+// WithConnectionCache returns a new Context with an empty environment cache added. It has the signature of
+// http.Server.ConnContext, so that each connection gets its own cache; if a request's context has no cache,
+// SelectEnvironmentByAuthorizationKey looks up the environment every time.
+func WithConnectionCache(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connectionCacheContextKey, &connectionCache{})
+}
+
+func getConnectionCache(ctx context.Context) *connectionCache {
+	cache, _ := ctx.Value(connectionCacheContextKey).(*connectionCache)
+	return cache
+}
+
+func (c *connectionCache) get(credential sdkauth.ScopedCredential, generation uint64) relayenv.EnvContext {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.env != nil && c.generation == generation && c.credential == credential {
+		return c.env
+	}
+	return nil
+}
+
+func (c *connectionCache) set(credential sdkauth.ScopedCredential, generation uint64, env relayenv.EnvContext) {
+	c.lock.Lock()
+	c.credential, c.generation, c.env = credential, generation, env
+	c.lock.Unlock()
+}
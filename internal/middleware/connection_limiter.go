@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// ConnectionLimitRetryAfter is the value of the Retry-After header that we send when a stream request
+// is rejected because the global connection limit has been reached.
+const ConnectionLimitRetryAfter = 10 * time.Second
+
+// ConnectionLimiter keeps track of the total number of active stream connections across all
+// environments, and rejects new ones once a configured limit is reached. Optionally, a new connection
+// can wait for a limited time for an active one to end, instead of being rejected immediately.
+type ConnectionLimiter struct {
+	maxConnections atomic.Int64
+	maxWait        atomic.Int64
+	count          atomic.Int64
+	released       chan struct{}
+	lock           sync.Mutex
+}
+
+// NewConnectionLimiter creates a ConnectionLimiter. If maxConnections is zero, there is no limit,
+// but connections are still counted. If maxWait is zero, connections over the limit are rejected
+// immediately.
+func NewConnectionLimiter(maxConnections int, maxWait time.Duration) *ConnectionLimiter {
+	l := &ConnectionLimiter{released: make(chan struct{})}
+	l.SetMaxConnections(maxConnections)
+	l.SetMaxWait(maxWait)
+	return l
+}
+
+// Limit is a middleware function that counts the request as an active connection until the handler
+// ends. If the limit has already been reached, it waits up to the configured maximum wait time for a
+// connection to end; if none does, it returns a 503 error with a Retry-After header. CORS preflight
+// requests are not counted or limited, since they end immediately without opening a stream.
+func (l *ConnectionLimiter) Limit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "OPTIONS" {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if !l.acquire(req.Context()) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(ConnectionLimitRetryAfter.Seconds())))
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Too many stream connections")
+			return
+		}
+		defer l.release()
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func (l *ConnectionLimiter) tryAcquire() bool {
+	for {
+		n := l.count.Load()
+		if limit := l.maxConnections.Load(); limit > 0 && n >= limit {
+			return false
+		}
+		if l.count.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+func (l *ConnectionLimiter) acquire(ctx context.Context) bool {
+	if l.tryAcquire() {
+		return true
+	}
+	maxWait := time.Duration(l.maxWait.Load())
+	if maxWait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	for {
+		l.lock.Lock()
+		releasedCh := l.released
+		l.lock.Unlock()
+		// Check again after getting the channel, in case a connection ended just before that.
+		if l.tryAcquire() {
+			return true
+		}
+		select {
+		case <-releasedCh:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (l *ConnectionLimiter) release() {
+	l.count.Add(-1)
+	// Wake up any requests that are waiting for a connection to end. Only one of them will get the
+	// available slot; the others go back to waiting.
+	l.lock.Lock()
+	close(l.released)
+	l.released = make(chan struct{})
+	l.lock.Unlock()
+}
+
+// Count returns the number of connections that are currently active.
+func (l *ConnectionLimiter) Count() int {
+	return int(l.count.Load())
+}
+
+// MaxConnections returns the configured limit, or zero if there is no limit.
+func (l *ConnectionLimiter) MaxConnections() int {
+	return int(l.maxConnections.Load())
+}
+
+// SetMaxConnections changes the limit. Connections that are already active are not affected, even if
+// there are now more of them than the new limit allows.
+func (l *ConnectionLimiter) SetMaxConnections(maxConnections int) {
+	l.maxConnections.Store(int64(maxConnections))
+}
+
+// MaxWait returns how long a connection over the limit can wait for another one to end, or zero if it
+// is rejected immediately.
+func (l *ConnectionLimiter) MaxWait() time.Duration {
+	return time.Duration(l.maxWait.Load())
+}
+
+// SetMaxWait changes how long a connection over the limit can wait for another one to end. Requests
+// that are already waiting are not affected.
+func (l *ConnectionLimiter) SetMaxWait(maxWait time.Duration) {
+	l.maxWait.Store(int64(maxWait))
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionLimiter(t *testing.T) {
+	withBlockedRequest := func(l *ConnectionLimiter, action func()) {
+		startedCh, releaseCh, doneCh := make(chan struct{}), make(chan struct{}), make(chan struct{})
+		handler := l.Limit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			close(startedCh)
+			<-releaseCh
+		}))
+		go func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			close(doneCh)
+		}()
+		<-startedCh
+		action()
+		close(releaseCh)
+		<-doneCh
+	}
+
+	t.Run("counts active connections", func(t *testing.T) {
+		l := NewConnectionLimiter(0, 0)
+		assert.Equal(t, 0, l.Count())
+		withBlockedRequest(l, func() {
+			assert.Equal(t, 1, l.Count())
+		})
+		assert.Equal(t, 0, l.Count())
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		l := NewConnectionLimiter(0, 0)
+		assert.Equal(t, 0, l.MaxConnections())
+		withBlockedRequest(l, func() {
+			rr := httptest.NewRecorder()
+			l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	})
+
+	t.Run("rejects connections over limit", func(t *testing.T) {
+		l := NewConnectionLimiter(1, 0)
+		assert.Equal(t, 1, l.MaxConnections())
+		withBlockedRequest(l, func() {
+			rr := httptest.NewRecorder()
+			l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+			assert.Equal(t, "10", rr.Header().Get("Retry-After"))
+			assert.Equal(t, 1, l.Count())
+		})
+		assert.Equal(t, 0, l.Count())
+
+		rr := httptest.NewRecorder()
+		l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("does not count or limit CORS preflight requests", func(t *testing.T) {
+		l := NewConnectionLimiter(1, 0)
+		withBlockedRequest(l, func() {
+			rr := httptest.NewRecorder()
+			l.Limit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, 1, l.Count())
+			})).ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/", nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+		assert.Equal(t, 0, l.Count())
+	})
+
+	t.Run("limit can be changed", func(t *testing.T) {
+		l := NewConnectionLimiter(1, 0)
+		l.SetMaxConnections(2)
+		assert.Equal(t, 2, l.MaxConnections())
+		withBlockedRequest(l, func() {
+			rr := httptest.NewRecorder()
+			l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	})
+
+	t.Run("waits for a connection to end if max wait is set", func(t *testing.T) {
+		l := NewConnectionLimiter(1, time.Minute)
+		assert.Equal(t, time.Minute, l.MaxWait())
+		rr := httptest.NewRecorder()
+		doneCh := make(chan struct{})
+		withBlockedRequest(l, func() {
+			go func() {
+				l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+				close(doneCh)
+			}()
+			select {
+			case <-doneCh:
+				require.Fail(t, "request should have waited")
+			case <-time.After(time.Millisecond * 50):
+			}
+			assert.Equal(t, 1, l.Count())
+		})
+		// The first request has now ended, so the second one can proceed
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			require.Fail(t, "timed out waiting for request")
+		}
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 0, l.Count())
+	})
+
+	t.Run("rejects connection if max wait elapses", func(t *testing.T) {
+		l := NewConnectionLimiter(1, time.Millisecond*20)
+		withBlockedRequest(l, func() {
+			rr := httptest.NewRecorder()
+			l.Limit(nullHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+			assert.Equal(t, "10", rr.Header().Get("Retry-After"))
+		})
+	})
+
+	t.Run("max wait can be changed", func(t *testing.T) {
+		l := NewConnectionLimiter(1, 0)
+		l.SetMaxWait(time.Second)
+		assert.Equal(t, time.Second, l.MaxWait())
+	})
+}
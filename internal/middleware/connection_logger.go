@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+)
+
+const (
+	// connectionLogInterval and connectionLogMaxPerInterval determine how many connection messages
+	// ConnectionLogger can log at once. Any more than this are counted, and the count is logged with the
+	// next message that is allowed.
+	connectionLogInterval       = time.Second
+	connectionLogMaxPerInterval = 20
+)
+
+// ConnectionLogger optionally logs a message at Info level whenever a stream connection is accepted or
+// closed, to help with diagnosing connection churn without turning on debug logging. Messages are logged
+// with the environment's loggers, so they include the environment name. The number of messages is
+// limited across all environments, so that a large number of reconnecting SDKs cannot flood the log.
+type ConnectionLogger struct {
+	enabled     atomic.Bool
+	windowStart time.Time
+	windowCount int
+	suppressed  int
+	lock        sync.Mutex
+}
+
+// NewConnectionLogger creates a ConnectionLogger.
+func NewConnectionLogger(enabled bool) *ConnectionLogger {
+	l := &ConnectionLogger{}
+	l.SetEnabled(enabled)
+	return l
+}
+
+// SetEnabled turns connection logging on or off.
+func (l *ConnectionLogger) SetEnabled(enabled bool) {
+	l.enabled.Store(enabled)
+}
+
+// Log is a middleware function that logs the start and end of a stream connection from the specified
+// kind of SDK, if logging is enabled.
+func (l *ConnectionLogger) Log(sdkKind basictypes.SDKKind, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !l.enabled.Load() {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		loggers := GetEnvContextInfo(req.Context()).Env.GetLoggers()
+		userAgent := getUserAgent(req)
+		clientIP := GetClientIP(req)
+		if suppressed, ok := l.allow(); ok {
+			loggers.Infof("Stream connection accepted (SDK: %s, user agent: %q, client IP: %s)%s",
+				sdkKind, userAgent, clientIP, suppressedDesc(suppressed))
+		}
+		startTime := time.Now()
+		defer func() {
+			if suppressed, ok := l.allow(); ok {
+				loggers.Infof("Stream connection closed after %s (SDK: %s, user agent: %q, client IP: %s)%s",
+					time.Since(startTime).Round(time.Millisecond), sdkKind, userAgent, clientIP, suppressedDesc(suppressed))
+			}
+		}()
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// allow returns true if another message can be logged now, along with the number of messages that were
+// not logged since the last one that was.
+func (l *ConnectionLogger) allow() (int, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if now := time.Now(); now.Sub(l.windowStart) >= connectionLogInterval {
+		l.windowStart = now
+		l.windowCount = 0
+	}
+	if l.windowCount >= connectionLogMaxPerInterval {
+		l.suppressed++
+		return 0, false
+	}
+	l.windowCount++
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return suppressed, true
+}
+
+func suppressedDesc(suppressed int) string {
+	if suppressed == 0 {
+		return ""
+	}
+	if suppressed == 1 {
+		return " (1 earlier message was suppressed)"
+	}
+	return " (" + strconv.Itoa(suppressed) + " earlier messages were suppressed)"
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connectionLoggerTest(t *testing.T, enabled bool, action func(*ConnectionLogger, func(), *ldlogtest.MockLog)) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := relayenv.NewEnvContext(relayenv.EnvContextImplParams{
+		Identifiers:   relayenv.EnvIdentifiers{ConfiguredName: "testenv"},
+		EnvConfig:     config.EnvConfig{},
+		AllConfig:     config.Config{},
+		ClientFactory: testclient.FakeLDClientFactory(true),
+		LogNameMode:   relayenv.LogNameIsEnvID,
+		Loggers:       mockLog.Loggers,
+	}, nil)
+	require.NoError(t, err)
+	defer env.Close()
+
+	l := NewConnectionLogger(enabled)
+	handler := l.Log(basictypes.MobileSDK, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	doRequest := func() {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", "fake-user-agent")
+		req = req.WithContext(WithEnvContextInfo(req.Context(), EnvContextInfo{Env: env}))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	action(l, doRequest, mockLog)
+}
+
+func TestConnectionLogger(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		connectionLoggerTest(t, false, func(l *ConnectionLogger, doRequest func(), mockLog *ldlogtest.MockLog) {
+			doRequest()
+			mockLog.AssertMessageMatch(t, false, ldlog.Info, "Stream connection")
+		})
+	})
+
+	t.Run("logs accepted and closed connections", func(t *testing.T) {
+		connectionLoggerTest(t, true, func(l *ConnectionLogger, doRequest func(), mockLog *ldlogtest.MockLog) {
+			doRequest()
+			mockLog.AssertMessageMatch(t, true, ldlog.Info,
+				`Stream connection accepted \(SDK: mobile, user agent: "fake-user-agent", client IP: 192\.0\.2\.1\)$`)
+			mockLog.AssertMessageMatch(t, true, ldlog.Info,
+				`Stream connection closed after .* \(SDK: mobile, user agent: "fake-user-agent", client IP: 192\.0\.2\.1\)$`)
+		})
+	})
+
+	t.Run("can be enabled and disabled", func(t *testing.T) {
+		connectionLoggerTest(t, false, func(l *ConnectionLogger, doRequest func(), mockLog *ldlogtest.MockLog) {
+			l.SetEnabled(true)
+			doRequest()
+			assert.Len(t, mockLog.GetOutput(ldlog.Info), 2)
+
+			l.SetEnabled(false)
+			doRequest()
+			assert.Len(t, mockLog.GetOutput(ldlog.Info), 2)
+		})
+	})
+
+	t.Run("limits the number of messages", func(t *testing.T) {
+		connectionLoggerTest(t, true, func(l *ConnectionLogger, doRequest func(), mockLog *ldlogtest.MockLog) {
+			for i := 0; i < connectionLogMaxPerInterval; i++ {
+				doRequest()
+			}
+			assert.Len(t, mockLog.GetOutput(ldlog.Info), connectionLogMaxPerInterval)
+
+			l.lock.Lock()
+			l.windowStart = time.Now().Add(-connectionLogInterval)
+			l.lock.Unlock()
+			doRequest()
+			messages := mockLog.GetOutput(ldlog.Info)
+			require.Len(t, messages, connectionLogMaxPerInterval+2)
+			assert.Contains(t, messages[connectionLogMaxPerInterval],
+				fmt.Sprintf("(%d earlier messages were suppressed)", connectionLogMaxPerInterval))
+		})
+	})
+}
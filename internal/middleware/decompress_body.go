@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// DecompressBody creates a middleware function that decompresses request bodies whose Content-Encoding is
+// "gzip" or "deflate", so that the handler always sees the uncompressed body. The Content-Encoding header is
+// removed, so it will not be passed along if the handler forwards the request headers.
+//
+// A small compressed body can expand to a very large one, so the decompressed body is wrapped so that
+// reading more than maxBytes fails, which the handler should treat as a 413 error. A body that is not
+// valid for its encoding is rejected with a 400 error, and any other encoding with a 415 error. Requests
+// without a Content-Encoding are not changed.
+func DecompressBody(maxBytes int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var decompressor io.ReadCloser
+			var err error
+			switch strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding"))) {
+			case "", "identity":
+				next.ServeHTTP(w, req)
+				return
+			case "gzip", "x-gzip":
+				decompressor, err = gzip.NewReader(req.Body)
+			case "deflate":
+				decompressor, err = zlib.NewReader(req.Body)
+			default:
+				util.WriteErrorJSON(w, http.StatusUnsupportedMediaType, "unsupported Content-Encoding")
+				return
+			}
+			if err != nil {
+				util.WriteErrorJSON(w, http.StatusBadRequest, "request body is not valid for its Content-Encoding")
+				return
+			}
+			req.Body = http.MaxBytesReader(w, decompressedBody{decompressor, req.Body}, maxBytes)
+			req.ContentLength = -1
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// decompressedBody reads from a decompressor, and closes both the decompressor and the original body.
+type decompressedBody struct {
+	io.ReadCloser
+	original io.Closer
+}
+
+func (b decompressedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.original.Close()
+}
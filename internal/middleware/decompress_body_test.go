@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompressBody(t *testing.T) {
+	body := `[{"kind":"identify"}]`
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+	deflated := func(s string) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		_, _ = zw.Write([]byte(s))
+		_ = zw.Close()
+		return buf.Bytes()
+	}
+
+	type handlerResult struct {
+		called          bool
+		body            string
+		readErr         error
+		contentEncoding string
+	}
+	serve := func(maxBytes int64, encoding string, data []byte) (*httptest.ResponseRecorder, handlerResult) {
+		var result handlerResult
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			result.called = true
+			var data []byte
+			data, result.readErr = io.ReadAll(req.Body)
+			result.body = string(data)
+			result.contentEncoding = req.Header.Get("Content-Encoding")
+		})
+		req, _ := http.NewRequest("POST", "", bytes.NewReader(data))
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		resp := httptest.NewRecorder()
+		DecompressBody(maxBytes)(handler).ServeHTTP(resp, req)
+		return resp, result
+	}
+
+	t.Run("uncompressed body is unchanged", func(t *testing.T) {
+		for _, encoding := range []string{"", "identity"} {
+			_, result := serve(1000, encoding, []byte(body))
+			require.True(t, result.called)
+			assert.NoError(t, result.readErr)
+			assert.Equal(t, body, result.body)
+		}
+	})
+
+	t.Run("gzip body is decompressed", func(t *testing.T) {
+		for _, encoding := range []string{"gzip", "GZIP", "x-gzip"} {
+			_, result := serve(1000, encoding, gzipped(body))
+			require.True(t, result.called)
+			assert.NoError(t, result.readErr)
+			assert.Equal(t, body, result.body)
+			assert.Equal(t, "", result.contentEncoding)
+		}
+	})
+
+	t.Run("deflate body is decompressed", func(t *testing.T) {
+		_, result := serve(1000, "deflate", deflated(body))
+		require.True(t, result.called)
+		assert.NoError(t, result.readErr)
+		assert.Equal(t, body, result.body)
+		assert.Equal(t, "", result.contentEncoding)
+	})
+
+	t.Run("decompressed body over limit causes read error", func(t *testing.T) {
+		_, result := serve(100, "gzip", gzipped(strings.Repeat("x", 101)))
+		require.True(t, result.called)
+		var tooLargeErr *http.MaxBytesError
+		assert.ErrorAs(t, result.readErr, &tooLargeErr)
+	})
+
+	t.Run("invalid compressed body is rejected", func(t *testing.T) {
+		for _, encoding := range []string{"gzip", "deflate"} {
+			resp, result := serve(1000, encoding, []byte(body))
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.False(t, result.called)
+		}
+	})
+
+	t.Run("unsupported encoding is rejected", func(t *testing.T) {
+		resp, result := serve(1000, "br", []byte(body))
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+		assert.False(t, result.called)
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// Drainer keeps track of whether Relay is in draining mode, in which it stops accepting new stream and
+// evaluation requests so that a load balancer will stop routing to it, while existing streams continue.
+type Drainer struct {
+	draining atomic.Bool
+}
+
+// NewDrainer creates a Drainer that is initially not draining.
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// SetDraining turns draining mode on or off.
+func (d *Drainer) SetDraining(draining bool) {
+	d.draining.Store(draining)
+}
+
+// IsDraining returns true if draining mode is on.
+func (d *Drainer) IsDraining() bool {
+	return d.draining.Load()
+}
+
+// Reject is a middleware function that returns a 503 error for new requests while draining mode is on.
+// Requests that were already being handled when draining started are not affected.
+func (d *Drainer) Reject(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if d.IsDraining() {
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Relay is draining connections")
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainer(t *testing.T) {
+	d := NewDrainer()
+	handler := d.Reject(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	doRequest := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Result().StatusCode
+	}
+
+	assert.False(t, d.IsDraining())
+	assert.Equal(t, http.StatusOK, doRequest())
+
+	d.SetDraining(true)
+	assert.True(t, d.IsDraining())
+	assert.Equal(t, http.StatusServiceUnavailable, doRequest())
+
+	d.SetDraining(false)
+	assert.False(t, d.IsDraining())
+	assert.Equal(t, http.StatusOK, doRequest())
+}
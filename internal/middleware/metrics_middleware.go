@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/metrics"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 
 	"github.com/gorilla/mux"
 )
@@ -59,7 +60,8 @@ func RequestCount(measure metrics.Measure) mux.MiddlewareFunc {
 			userAgent := getUserAgent(req)
 			// Ignoring internal routing error that would have been ignored anyway
 			route, _ := mux.CurrentRoute(req).GetPathTemplate()
-			metrics.WithRouteCount(ctx.Env.GetMetricsContext(), userAgent, route, req.Method, func() {
+			metricsCtx := tracing.WithTraceParent(ctx.Env.GetMetricsContext(), req)
+			metrics.WithRouteCount(metricsCtx, userAgent, route, req.Method, func() {
 				next.ServeHTTP(w, req)
 			}, measure)
 		})
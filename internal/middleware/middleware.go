@@ -2,17 +2,22 @@ package middleware
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/browser"
+	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	ld "github.com/launchdarkly/go-server-sdk/v7"
@@ -24,11 +29,20 @@ const (
 	userAgentHeader   = "user-agent"
 	ldUserAgentHeader = "X-LaunchDarkly-User-Agent"
 
+	// DataAgeHeader is the response header added by AddDataAgeHeader.
+	DataAgeHeader = "X-LaunchDarkly-Data-Age"
+
+	// InitializingRetryAfter is the value of the Retry-After header that RejectWhileInitializing sends.
+	InitializingRetryAfter = 5 * time.Second
+
 	httpStatusMessageInvalidEnvCredential  = "Relay Proxy does not recognize the client credential (missing or invalid Authorization header)"
 	httpStatusMessageNotFullyConfigured    = "Relay Proxy is not yet fully initialized, does not have list of environments yet"
 	httpStatusMessagePayloadFilterNotFound = "Relay Proxy recognizes the provided credential, but the payload filter was not found"
 	httpStatusMessageMissingEnvURLParam    = "URL did not contain an environment ID"
 	httpStatusMessageSDKClientNotInited    = "client was not initialized"
+	httpStatusMessageEnvInitializing       = "environment is still initializing and has no flag data"
+
+	logMsgDeprecatedCredentialUsed = "A request was authorized with the old SDK key %s, which has been replaced and will stop working when it expires"
 )
 
 var (
@@ -49,6 +63,9 @@ type RelayEnvironments interface {
 	// IsPayloadFilterNotFound should return true if the error returned by GetEnvironment represents the fact that
 	// the credential was correct, but the payload filter was not found.
 	IsPayloadFilterNotFound(error) bool
+	// GetGeneration returns a number that changes whenever the result of GetEnvironment might have changed,
+	// such as when an environment is added or removed or a key is rotated.
+	GetGeneration() uint64
 }
 
 // getUserAgent returns the X-LaunchDarkly-User-Agent if available, falling back to the normal "User-Agent" header
@@ -74,28 +91,42 @@ func Chain(middlewares ...mux.MiddlewareFunc) mux.MiddlewareFunc {
 // using the appropriate kind of credential for the basictypes.SDKKind. If successful, it updates the request context
 // so GetEnvContextInfo will return environment information. If not successful, it returns an error response.
 func SelectEnvironmentByAuthorizationKey(sdkKind basictypes.SDKKind, envs RelayEnvironments) mux.MiddlewareFunc {
+	var warnedDeprecated sync.Map
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			credential, err := sdks.GetCredential(sdkKind, req)
 			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
+				util.WriteErrorJSON(w, http.StatusUnauthorized, httpStatusMessageInvalidEnvCredential)
 				return
 			}
 
 			queryValues := req.URL.Query()
 			filterKey := config.FilterKey(queryValues.Get("filter"))
 
-			clientCtx, err := envs.GetEnvironment(sdkauth.NewScoped(filterKey, credential))
+			scopedCred := sdkauth.NewScoped(filterKey, credential)
+			cache := getConnectionCache(req.Context())
+			var generation uint64
+			var clientCtx relayenv.EnvContext
+			if cache != nil {
+				// The generation must be read before the lookup, so that a change made during the lookup
+				// causes the cached entry to be discarded.
+				generation = envs.GetGeneration()
+				clientCtx = cache.get(scopedCred, generation)
+			}
+			if clientCtx == nil {
+				clientCtx, err = envs.GetEnvironment(scopedCred)
+				if cache != nil && err == nil {
+					cache.set(scopedCred, generation, clientCtx)
+				}
+			}
 
 			if envs.IsNotReady(err) {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				_, _ = w.Write([]byte(httpStatusMessageNotFullyConfigured))
+				util.WriteErrorJSON(w, http.StatusServiceUnavailable, httpStatusMessageNotFullyConfigured)
 				return
 			}
 
 			if envs.IsPayloadFilterNotFound(err) {
-				w.WriteHeader(http.StatusNotFound)
-				_, _ = w.Write([]byte(httpStatusMessagePayloadFilterNotFound))
+				util.WriteErrorJSON(w, http.StatusNotFound, httpStatusMessagePayloadFilterNotFound)
 				return
 			}
 
@@ -103,21 +134,20 @@ func SelectEnvironmentByAuthorizationKey(sdkKind basictypes.SDKKind, envs RelayE
 				// ErrInitializationFailed is what the SDK returns if it got a 401 error from LD.
 				// Our error behavior here is slightly different for JS/browser clients
 				if sdkKind == basictypes.JSClientSDK {
-					w.WriteHeader(http.StatusNotFound)
-					_, _ = w.Write([]byte(httpStatusMessageMissingEnvURLParam))
+					util.WriteErrorJSON(w, http.StatusNotFound, httpStatusMessageMissingEnvURLParam)
 				} else {
-					w.WriteHeader(http.StatusUnauthorized)
-					_, _ = w.Write([]byte(httpStatusMessageInvalidEnvCredential))
+					util.WriteErrorJSON(w, http.StatusUnauthorized, httpStatusMessageInvalidEnvCredential)
 				}
 				return
 			}
 
 			if clientCtx.GetClient() == nil {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				_, _ = w.Write([]byte(httpStatusMessageSDKClientNotInited))
+				util.WriteErrorJSON(w, http.StatusServiceUnavailable, httpStatusMessageSDKClientNotInited)
 				return
 			}
 
+			warnIfDeprecatedCredential(clientCtx, credential, &warnedDeprecated)
+
 			contextInfo := EnvContextInfo{
 				Env:        clientCtx,
 				Credential: credential,
@@ -131,9 +161,22 @@ func SelectEnvironmentByAuthorizationKey(sdkKind basictypes.SDKKind, envs RelayE
 	}
 }
 
-// CORS is a middleware function that sets the appropriate CORS headers on a browser response
-// (not counting Access-Control-Allow-Methods, which is set by gorilla/mux's CORS middleware
-// based on the route handlers we've defined).
+// warnIfDeprecatedCredential logs a warning the first time a request is authorized with a credential that has
+// been deprecated by a key rotation, since whatever sent the request will stop working once that key expires.
+func warnIfDeprecatedCredential(env relayenv.EnvContext, cred credential.SDKCredential, warned *sync.Map) {
+	for _, c := range env.GetDeprecatedCredentials() {
+		if c == cred {
+			if _, alreadyWarned := warned.LoadOrStore(cred, struct{}{}); !alreadyWarned {
+				env.GetLoggers().Warnf(logMsgDeprecatedCredentialUsed, sdks.ObscureKey(cred.String()))
+			}
+			return
+		}
+	}
+}
+
+// CORS is a middleware function that sets the appropriate CORS headers on a browser response.
+// Access-Control-Allow-Methods is normally set by gorilla/mux's CORS middleware based on the route
+// handlers we've defined; this middleware only overrides it if the CORSContext specifies methods.
 //
 // Also, if the HTTP method is OPTIONS, it will short-circuit the rest of the middleware chain
 // so that the underlying handler is *not* called-- since an OPTIONS request should not do
@@ -143,9 +186,11 @@ func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var domains []string
 		var headers []string
+		var methods []string
 		if corsContext := browser.GetCORSContext(r.Context()); corsContext != nil {
 			domains = corsContext.AllowedOrigins()
 			headers = corsContext.AllowedHeaders()
+			methods = corsContext.AllowedMethods()
 		}
 		if len(domains) > 0 {
 			domain := domains[0]
@@ -163,6 +208,7 @@ func CORS(next http.Handler) http.Handler {
 			}
 			browser.SetCORSHeaders(w, origin, headers)
 		}
+		browser.SetCORSAllowedMethods(w, methods)
 		if r.Method != "OPTIONS" {
 			next.ServeHTTP(w, r)
 		}
@@ -175,6 +221,48 @@ func Streaming(next http.Handler) http.Handler {
 		// If Nginx is being used as a proxy/load balancer, adding this header tells it not to buffer this response because
 		// it is a streaming response. If Nginx is not being used, this header has no effect.
 		w.Header().Add("X-Accel-Buffering", "no")
+		// Any read or write timeouts configured for the HTTP server are meant for ordinary requests;
+		// a stream connection is expected to stay open indefinitely. If the ResponseWriter doesn't
+		// support changing deadlines, these calls have no effect.
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+		next.ServeHTTP(w, req)
+	})
+}
+
+// AddDataAgeHeader is a middleware function that sets the X-LaunchDarkly-Data-Age response header to
+// the number of whole seconds since the environment's data store was last updated. The header is
+// omitted if the store has not received any data yet. This must be applied after an environment
+// selector middleware.
+func AddDataAgeHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if env := GetEnvContextInfo(req.Context()).Env; env != nil {
+			if lastUpdate := env.GetLastDataUpdateTime(); !lastUpdate.IsZero() {
+				age := int64(time.Since(lastUpdate).Seconds())
+				w.Header().Set(DataAgeHeader, strconv.FormatInt(age, 10))
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// RejectWhileInitializing is a middleware function that returns a 503 error with a Retry-After header if
+// the environment's SDK client has not finished initializing and its data store has no flag data yet, so
+// that SDKs retry instead of receiving default or fallback values that look like real ones. If the store
+// already has data, such as from a persistent store, requests are handled normally. This must be applied
+// after an environment selector middleware.
+func RejectWhileInitializing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if env := GetEnvContextInfo(req.Context()).Env; env != nil {
+			if client := env.GetClient(); client == nil || !client.Initialized() {
+				if store := env.GetStore(); store == nil || !store.IsInitialized() {
+					w.Header().Set("Retry-After", strconv.Itoa(int(InitializingRetryAfter.Seconds())))
+					util.WriteErrorJSON(w, http.StatusServiceUnavailable, httpStatusMessageEnvInitializing)
+					return
+				}
+			}
+		}
 		next.ServeHTTP(w, req)
 	})
 }
@@ -188,7 +276,7 @@ func ContextFromBase64(base64Context string) (ldcontext.Context, error) {
 		return ldContext, errInvalidContextBase64
 	}
 
-	jsonErr := json.Unmarshal(jsonStr, &ldContext)
+	ldContext, jsonErr := ContextFromJSON(jsonStr)
 
 	if jsonErr != nil {
 		return ldContext, errInvalidContextJSON
@@ -196,20 +284,26 @@ func ContextFromBase64(base64Context string) (ldcontext.Context, error) {
 	return ldContext, nil
 }
 
+// base64urlDecode decodes base64 data that may use either the URL-safe or the standard alphabet, with or
+// without padding. Whitespace is ignored, since some encoders wrap long output into lines. Accepting all of
+// these means that a context passed in a GET URL always decodes to the same JSON that an SDK would have sent
+// in a REPORT body, regardless of which base64 variant the SDK uses.
 func base64urlDecode(base64String string) ([]byte, error) {
-	idStr, decodeErr := base64.URLEncoding.DecodeString(base64String)
-
-	if decodeErr != nil {
-		// base64String could be unpadded
-		// see https://github.com/golang/go/issues/4237#issuecomment-267792481
-		idStrRaw, decodeErrRaw := base64.RawURLEncoding.DecodeString(base64String)
-
-		if decodeErrRaw != nil {
-			return nil, errInvalidBase64
+	normalized := strings.TrimRight(strings.Map(func(r rune) rune {
+		switch r {
+		case '+':
+			return '-'
+		case '/':
+			return '_'
+		case ' ', '\t', '\r', '\n':
+			return -1
 		}
+		return r
+	}, base64String), "=")
 
-		return idStrRaw, nil
+	data, err := base64.RawURLEncoding.DecodeString(normalized)
+	if err != nil {
+		return nil, errInvalidBase64
 	}
-
-	return idStr, nil
+	return data, nil
 }
@@ -1,13 +1,16 @@
 package middleware
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
 
@@ -55,8 +58,9 @@ func buildPreRoutedRequestWithFilter(key credential.SDKCredential, filter config
 }
 
 type testEnvironments struct {
-	envs      map[sdkauth.ScopedCredential]relayenv.EnvContext
-	notInited bool
+	envs       map[sdkauth.ScopedCredential]relayenv.EnvContext
+	notInited  bool
+	generation uint64
 }
 
 var errNotReady = errors.New("not ready")
@@ -84,6 +88,10 @@ func (t testEnvironments) IsPayloadFilterNotFound(err error) bool {
 	return err == errPayloadFilterNotFound
 }
 
+func (t testEnvironments) GetGeneration() uint64 {
+	return t.generation
+}
+
 func (t testEnvironments) GetAllEnvironments() []relayenv.EnvContext {
 	var ret []relayenv.EnvContext
 	for _, e := range t.envs {
@@ -104,10 +112,12 @@ func (t testEnvironments) GetAllEnvironments() []relayenv.EnvContext {
 type testCORSContext struct {
 	origins []string
 	headers []string
+	methods []string
 }
 
 func (c testCORSContext) AllowedOrigins() []string { return c.origins }
 func (c testCORSContext) AllowedHeaders() []string { return c.headers }
+func (c testCORSContext) AllowedMethods() []string { return c.methods }
 
 func nullHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
@@ -382,6 +392,46 @@ func TestSelectEnvironmentByAuthorizationKey(t *testing.T) {
 
 		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
 	})
+
+	t.Run("reuses environment for the same connection until generation changes", func(t *testing.T) {
+		envs := &testEnvironments{
+			envs: map[sdkauth.ScopedCredential]relayenv.EnvContext{sdkauth.New(st.EnvMain.Config.SDKKey): env1},
+		}
+		selector := SelectEnvironmentByAuthorizationKey(basictypes.ServerSDK, envs)
+		envCh := make(chan relayenv.EnvContext, 1)
+		connCtx := WithConnectionCache(context.Background(), nil)
+
+		doRequest := func() relayenv.EnvContext {
+			req := buildPreRoutedRequestWithAuth(st.EnvMain.Config.SDKKey).WithContext(connCtx)
+			resp, _ := st.DoRequest(req, selector(handlerThatDetectsEnvironment(envCh)))
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			return <-envCh
+		}
+
+		assert.Equal(t, env1, doRequest())
+
+		// The lookup result changes without the generation changing, so the cached environment is still used
+		envs.envs[sdkauth.New(st.EnvMain.Config.SDKKey)] = env2
+		assert.Equal(t, env1, doRequest())
+
+		envs.generation++
+		assert.Equal(t, env2, doRequest())
+	})
+
+	t.Run("does not cache failed lookups", func(t *testing.T) {
+		envs := &testEnvironments{envs: map[sdkauth.ScopedCredential]relayenv.EnvContext{}}
+		selector := SelectEnvironmentByAuthorizationKey(basictypes.ServerSDK, envs)
+		connCtx := WithConnectionCache(context.Background(), nil)
+
+		req := buildPreRoutedRequestWithAuth(st.EnvMain.Config.SDKKey).WithContext(connCtx)
+		resp, _ := st.DoRequest(req, selector(nullHandler()))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		envs.envs[sdkauth.New(st.EnvMain.Config.SDKKey)] = env1
+		req = buildPreRoutedRequestWithAuth(st.EnvMain.Config.SDKKey).WithContext(connCtx)
+		resp, _ = st.DoRequest(req, selector(nullHandler()))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
 }
 
 func TestCORSMiddlewareSetsCorrectDefaultHeaders(t *testing.T) {
@@ -446,6 +496,29 @@ func TestCORSMiddlewareSetsAllowedHeaderFromContext(t *testing.T) {
 	assert.Equal(t, expectedHeaders, resp.Result().Header.Get("Access-Control-Allow-Headers"))
 }
 
+func TestCORSMiddlewareSetsAllowedMethodsFromContext(t *testing.T) {
+	cc := testCORSContext{methods: []string{"GET", "REPORT"}}
+	req := buildPreRoutedRequest("GET", nil, nil, nil, nil)
+	req = req.WithContext(browser.WithCORSContext(req.Context(), cc))
+	resp := httptest.NewRecorder()
+	resp.Header().Set("Access-Control-Allow-Methods", "GET,OPTIONS") // as if set by mux.CORSMethodMiddleware
+
+	CORS(nullHandler()).ServeHTTP(resp, req)
+
+	assert.Equal(t, "GET,REPORT", resp.Result().Header.Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSMiddlewareDoesNotChangeAllowedMethodsIfContextHasNone(t *testing.T) {
+	req := buildPreRoutedRequest("GET", nil, nil, nil, nil)
+	req = req.WithContext(browser.WithCORSContext(req.Context(), testCORSContext{}))
+	resp := httptest.NewRecorder()
+	resp.Header().Set("Access-Control-Allow-Methods", "GET,OPTIONS")
+
+	CORS(nullHandler()).ServeHTTP(resp, req)
+
+	assert.Equal(t, "GET,OPTIONS", resp.Result().Header.Get("Access-Control-Allow-Methods"))
+}
+
 func TestCORSMiddlewareOnlyCallsWrappedHandlerIfMethodIsNotOPTIONS(t *testing.T) {
 	totalTimesCalled := 0
 	wrappedHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -499,6 +572,38 @@ func TestStreaming(t *testing.T) {
 	assert.Equal(t, "no", resp.Result().Header.Get("X-Accel-Buffering"))
 }
 
+func TestStreamingIsExemptFromServerWriteTimeout(t *testing.T) {
+	slowHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+		_, _ = w.Write([]byte("ok"))
+	})
+	doRequest := func(handler http.Handler) (string, error) {
+		server := httptest.NewUnstartedServer(handler)
+		server.Config.WriteTimeout = time.Millisecond * 50
+		server.Start()
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("non-streaming handler is subject to write timeout", func(t *testing.T) {
+		_, err := doRequest(slowHandler)
+		assert.Error(t, err)
+	})
+
+	t.Run("streaming handler is not subject to write timeout", func(t *testing.T) {
+		body, err := doRequest(Streaming(slowHandler))
+		require.NoError(t, err)
+		assert.Equal(t, "ok", body)
+	})
+}
+
 func TestContextFromBase64(t *testing.T) {
 	t.Run("valid", func(t *testing.T) {
 		contextJSON := `{"kind":"org","key":"a","name":"b","c":true}`
@@ -529,6 +634,38 @@ func TestContextFromBase64(t *testing.T) {
 		assert.Equal(t, expectedContext, context)
 	})
 
+	t.Run("valid with either alphabet", func(t *testing.T) {
+		contextJSON := `{"kind":"org","key":"a","name":"~~~>>>???"}`
+		stdData := base64.StdEncoding.EncodeToString([]byte(contextJSON))
+		urlData := base64.URLEncoding.EncodeToString([]byte(contextJSON))
+		require.True(t, strings.ContainsAny(stdData, "+/"))
+		expectedContext := ldcontext.NewBuilder("a").Kind("org").Name("~~~>>>???").Build()
+		for _, data := range []string{stdData, urlData, strings.TrimRight(stdData, "=")} {
+			context, err := ContextFromBase64(data)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedContext, context)
+		}
+	})
+
+	t.Run("valid with whitespace", func(t *testing.T) {
+		contextJSON := `{"kind":"org","key":"a","name":"b","c":true}`
+		data := base64.StdEncoding.EncodeToString([]byte(contextJSON))
+		data = " " + data[:20] + "\r\n" + data[20:] + "\n"
+		expectedContext := ldcontext.NewBuilder("a").Kind("org").Name("b").SetBool("c", true).Build()
+		context, err := ContextFromBase64(data)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedContext, context)
+	})
+
+	t.Run("same result as JSON for anonymous context", func(t *testing.T) {
+		contextJSON := `{"kind":"user","anonymous":true,"name":"b"}`
+		expectedContext, err := ContextFromJSON([]byte("{ \"anonymous\": true, \"name\": \"b\", \"kind\": \"user\" }\n"))
+		require.NoError(t, err)
+		context, err := ContextFromBase64(base64.RawURLEncoding.EncodeToString([]byte(contextJSON)))
+		assert.NoError(t, err)
+		assert.Equal(t, expectedContext, context)
+	})
+
 	t.Run("invalid base64", func(t *testing.T) {
 		contextJSON := `{"kind":"org","key":"a","name":"b","c":true}`
 		data := base64.StdEncoding.EncodeToString([]byte(contextJSON)) + "x"
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// NormalizePaths creates a middleware function that adjusts the path of a request before it is routed, to
+// tolerate quirks of some SDKs and proxies. This must be applied outside of the router, since a router's
+// own middleware runs only after a route has matched.
+//
+// If stripTrailingSlash is true, any trailing slashes are removed from the path. A slash that was
+// percent-encoded in the request URL, such as at the end of a base64-encoded context, is part of a path
+// variable and is never removed, nor is anything before it.
+//
+// If ignoreCase is true, and the path does not match any route in the router, then the fixed parts of the
+// path (the ones that are not variables like {envId}) are compared to each route case-insensitively,
+// and replaced with the route's own spelling if they match. The parts of the path that correspond to
+// variables are never changed.
+func NormalizePaths(router *mux.Router, stripTrailingSlash, ignoreCase bool) func(http.Handler) http.Handler {
+	var templates [][]string
+	if ignoreCase {
+		_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			if route.GetHandler() == nil {
+				return nil // this is a PathPrefix for a subrouter, whose routes will also be visited
+			}
+			if template, err := route.GetPathTemplate(); err == nil {
+				templates = append(templates, strings.Split(template, "/"))
+			}
+			return nil
+		})
+	}
+	return func(next http.Handler) http.Handler {
+		if !stripTrailingSlash && !ignoreCase {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if stripTrailingSlash {
+				stripTrailingSlashes(req)
+			}
+			if ignoreCase {
+				var match mux.RouteMatch
+				if !router.Match(req, &match) && match.MatchErr != mux.ErrMethodMismatch {
+					normalizePathCase(req, templates)
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// stripTrailingSlashes removes the slashes at the end of the request path that were not percent-encoded.
+// Each of those corresponds to one slash at the end of the decoded path.
+func stripTrailingSlashes(req *http.Request) {
+	escapedPath := req.URL.EscapedPath()
+	if len(escapedPath) <= 1 || !strings.HasSuffix(escapedPath, "/") {
+		return
+	}
+	count := len(escapedPath) - len(trimTrailingSlashes(escapedPath))
+	req.URL.Path = req.URL.Path[:len(req.URL.Path)-count]
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = req.URL.RawPath[:len(req.URL.RawPath)-count]
+	}
+}
+
+func trimTrailingSlashes(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	return "/"
+}
+
+// normalizePathCase replaces the fixed segments of the request path with those of the first route
+// template that matches it case-insensitively.
+func normalizePathCase(req *http.Request, templates [][]string) {
+	segments := strings.Split(req.URL.Path, "/")
+	for _, template := range templates {
+		if !pathMatchesTemplateIgnoringCase(segments, template) {
+			continue
+		}
+		req.URL.Path = strings.Join(replaceFixedSegments(segments, template), "/")
+		if req.URL.RawPath != "" {
+			if rawSegments := strings.Split(req.URL.RawPath, "/"); len(rawSegments) == len(template) {
+				req.URL.RawPath = strings.Join(replaceFixedSegments(rawSegments, template), "/")
+			} else {
+				req.URL.RawPath = "" // an escaped slash in a variable; let the URL be re-escaped from Path
+			}
+		}
+		return
+	}
+}
+
+func pathMatchesTemplateIgnoringCase(segments, template []string) bool {
+	if len(segments) != len(template) {
+		return false
+	}
+	for i, t := range template {
+		if !isVariableSegment(t) && !strings.EqualFold(segments[i], t) {
+			return false
+		}
+	}
+	return true
+}
+
+func replaceFixedSegments(segments, template []string) []string {
+	ret := make([]string, len(segments))
+	for i, t := range template {
+		if isVariableSegment(t) {
+			ret[i] = segments[i]
+		} else {
+			ret[i] = t
+		}
+	}
+	return ret
+}
+
+func isVariableSegment(templateSegment string) bool {
+	return strings.Contains(templateSegment, "{")
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePaths(t *testing.T) {
+	makeRouter := func() *mux.Router {
+		router := mux.NewRouter()
+		writeVars := func(w http.ResponseWriter, req *http.Request) {
+			vars := mux.Vars(req)
+			_, _ = w.Write([]byte(vars["envId"] + " " + vars["context"]))
+		}
+		router.HandleFunc("/status", writeVars).Methods("GET")
+		subrouter := router.PathPrefix("/sdk/evalx/{envId}").Subrouter()
+		subrouter.HandleFunc("/contexts/{context}", writeVars).Methods("GET")
+		return router
+	}
+	doRequest := func(handler http.Handler, path string) (int, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp.Code, resp.Body.String()
+	}
+
+	t.Run("paths are not changed by default", func(t *testing.T) {
+		router := makeRouter()
+		handler := NormalizePaths(router, false, false)(router)
+		for _, path := range []string{"/status/", "/STATUS", "/SDK/evalx/env/contexts/ctx"} {
+			status, _ := doRequest(handler, path)
+			assert.Equal(t, http.StatusNotFound, status, path)
+		}
+	})
+
+	t.Run("trailing slashes are stripped", func(t *testing.T) {
+		router := makeRouter()
+		handler := NormalizePaths(router, true, false)(router)
+		for _, path := range []string{"/status/", "/status//", "/sdk/evalx/env/contexts/ctx/"} {
+			status, _ := doRequest(handler, path)
+			assert.Equal(t, http.StatusOK, status, path)
+		}
+		status, _ := doRequest(handler, "/STATUS/")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("case of fixed segments is ignored", func(t *testing.T) {
+		router := makeRouter()
+		handler := NormalizePaths(router, false, true)(router)
+		status, _ := doRequest(handler, "/Status")
+		assert.Equal(t, http.StatusOK, status)
+		status, body := doRequest(handler, "/SDK/EvalX/MyEnv/Contexts/MyContext")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "MyEnv MyContext", body)
+		status, _ = doRequest(handler, "/status/")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("both options together", func(t *testing.T) {
+		router := makeRouter()
+		handler := NormalizePaths(router, true, true)(router)
+		status, body := doRequest(handler, "/SDK/evalx/MyEnv/contexts/MyContext/")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "MyEnv MyContext", body)
+	})
+
+	t.Run("escaped slashes are not stripped", func(t *testing.T) {
+		var gotPath, gotRawPath string
+		handler := NormalizePaths(makeRouter(), true, false)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			gotPath, gotRawPath = req.URL.Path, req.URL.RawPath
+		}))
+		for _, suffix := range []string{"", "/", "//"} {
+			path := "/sdk/evalx/env/contexts/ab%2F" + suffix
+			_, _ = doRequest(handler, path)
+			assert.Equal(t, "/sdk/evalx/env/contexts/ab/", gotPath, path)
+			assert.Equal(t, "/sdk/evalx/env/contexts/ab%2F", gotRawPath, path)
+		}
+	})
+
+	t.Run("escaped characters in variables are preserved", func(t *testing.T) {
+		router := makeRouter()
+		handler := NormalizePaths(router, true, true)(router)
+		status, body := doRequest(handler, "/SDK/evalx/my%20env/contexts/ctx/")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "my env ctx", body)
+	})
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// LimitReportBody creates a middleware function that limits the size of the request body for REPORT
+// requests, which carry an evaluation context in the body. If the request declares a Content-Length
+// greater than maxBytes, it is rejected with a 413 error without reading the body; otherwise the body
+// is wrapped so that reading more than maxBytes fails, which the handler should treat as a 413 error.
+// If maxBytes is zero or negative, there is no limit.
+func LimitReportBody(maxBytes int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == "REPORT" {
+				if req.ContentLength > maxBytes {
+					util.WriteErrorJSONf(w, http.StatusRequestEntityTooLarge,
+						"Request body exceeds the maximum size of %d bytes", maxBytes)
+					return
+				}
+				req.Body = http.MaxBytesReader(w, req.Body, maxBytes)
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
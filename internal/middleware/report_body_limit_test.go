@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitReportBody(t *testing.T) {
+	readBodyHandler := func(readErr *error) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, *readErr = io.ReadAll(req.Body)
+		})
+	}
+
+	t.Run("body within limit can be read", func(t *testing.T) {
+		var readErr error
+		req, _ := http.NewRequest("REPORT", "", bytes.NewBufferString("12345"))
+		resp := httptest.NewRecorder()
+		LimitReportBody(5)(readBodyHandler(&readErr)).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NoError(t, readErr)
+	})
+
+	t.Run("declared length over limit is rejected without calling handler", func(t *testing.T) {
+		called := false
+		handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { called = true })
+		req, _ := http.NewRequest("REPORT", "", bytes.NewBufferString("123456"))
+		resp := httptest.NewRecorder()
+		LimitReportBody(5)(handler).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		assert.JSONEq(t, string(util.ErrorJSONMsg(http.StatusRequestEntityTooLarge,
+			"Request body exceeds the maximum size of 5 bytes")), resp.Body.String())
+		assert.False(t, called)
+	})
+
+	t.Run("undeclared length over limit causes read error", func(t *testing.T) {
+		var readErr error
+		req, _ := http.NewRequest("REPORT", "", bytes.NewBufferString("123456"))
+		req.ContentLength = -1
+		resp := httptest.NewRecorder()
+		LimitReportBody(5)(readBodyHandler(&readErr)).ServeHTTP(resp, req)
+		var tooLargeErr *http.MaxBytesError
+		assert.ErrorAs(t, readErr, &tooLargeErr)
+	})
+
+	t.Run("other methods are not limited", func(t *testing.T) {
+		var readErr error
+		req, _ := http.NewRequest("POST", "", bytes.NewBufferString("123456"))
+		resp := httptest.NewRecorder()
+		LimitReportBody(5)(readBodyHandler(&readErr)).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NoError(t, readErr)
+	})
+
+	t.Run("zero means unlimited", func(t *testing.T) {
+		var readErr error
+		req, _ := http.NewRequest("REPORT", "", bytes.NewBufferString("123456"))
+		resp := httptest.NewRecorder()
+		LimitReportBody(0)(readBodyHandler(&readErr)).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.NoError(t, readErr)
+	})
+}
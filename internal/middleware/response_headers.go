@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// ResponseHeaders creates a middleware function that adds a fixed set of headers to every response,
+// before calling the next handler, so that they are present even on streaming responses and on errors.
+// A handler can still override any of these headers. If the set is empty, the handler is unchanged.
+func ResponseHeaders(headers http.Header) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(headers) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			h := w.Header()
+			for name, values := range headers {
+				h[name] = append([]string(nil), values...) // copy so the handler can't modify the shared set
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	semver "github.com/launchdarkly/go-semver"
+)
+
+type sdkMinVersion struct {
+	version semver.Version
+	display string
+}
+
+// parseSDKUserAgent extracts the SDK name and version from a user agent string such as "GoClient/6.0.0".
+// Only the first space-delimited part of the string is used. It returns false if there is no name, or if
+// the version is not a semantic version (the minor and patch components can be omitted).
+func parseSDKUserAgent(userAgent string) (name, versionString string, version semver.Version, ok bool) {
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	name, versionString, found := strings.Cut(product, "/")
+	if !found || name == "" {
+		return "", "", semver.Version{}, false
+	}
+	version, err := semver.ParseAs(versionString, semver.ParseModeAllowMissingMinorAndPatch)
+	if err != nil {
+		return "", "", semver.Version{}, false
+	}
+	return name, versionString, version, true
+}
+
+// RequireSDKVersions creates a middleware function that rejects requests from SDKs that are older than a
+// minimum version, with a 426 error. Each entry in minVersions is in the same form as a user agent, such
+// as "GoClient/6.0.0", and sets the minimum version for SDKs with that name; SDKs that are not listed are
+// not restricted, and entries that cannot be parsed are ignored. Requests whose user agent is missing or
+// does not contain a version are allowed, unless rejectUnknown is true. If minVersions is empty, the
+// handler is unchanged.
+func RequireSDKVersions(minVersions []string, rejectUnknown bool) func(http.Handler) http.Handler {
+	mins := make(map[string]sdkMinVersion, len(minVersions))
+	for _, entry := range minVersions {
+		if name, versionString, version, ok := parseSDKUserAgent(entry); ok {
+			mins[name] = sdkMinVersion{version: version, display: versionString}
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		if len(mins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			name, versionString, version, ok := parseSDKUserAgent(getUserAgent(req))
+			if !ok {
+				if rejectUnknown {
+					util.WriteErrorJSON(w, http.StatusUpgradeRequired, "SDK version could not be determined from the user agent")
+					return
+				}
+			} else if minVersion, found := mins[name]; found && version.ComparePrecedence(minVersion.version) < 0 {
+				util.WriteErrorJSONf(w, http.StatusUpgradeRequired, "%s version %s is not supported; the minimum version is %s",
+					name, versionString, minVersion.display)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
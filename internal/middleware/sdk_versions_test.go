@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireSDKVersions(t *testing.T) {
+	minVersions := []string{"GoClient/6.1.0", "JSClient/3"}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	doRequest := func(policy func(http.Handler) http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp := httptest.NewRecorder()
+		policy(handler).ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("allowed versions", func(t *testing.T) {
+		policy := RequireSDKVersions(minVersions, false)
+		for _, userAgent := range []string{"GoClient/6.1.0", "GoClient/6.10.0", "GoClient/7.0.0-beta.1", "JSClient/3.0.0",
+			"NodeJSClient/1.0.0", "GoClient/6.1.0 extra-info"} {
+			t.Run(userAgent, func(t *testing.T) {
+				resp := doRequest(policy, map[string]string{userAgentHeader: userAgent})
+				assert.Equal(t, http.StatusOK, resp.Code)
+			})
+		}
+	})
+
+	t.Run("rejected versions", func(t *testing.T) {
+		policy := RequireSDKVersions(minVersions, false)
+		for _, userAgent := range []string{"GoClient/6.0.9", "GoClient/6.1.0-beta.1", "JSClient/2.99"} {
+			t.Run(userAgent, func(t *testing.T) {
+				resp := doRequest(policy, map[string]string{userAgentHeader: userAgent})
+				assert.Equal(t, http.StatusUpgradeRequired, resp.Code)
+				assert.Contains(t, resp.Body.String(), "minimum version")
+			})
+		}
+	})
+
+	t.Run("LaunchDarkly user agent header takes precedence", func(t *testing.T) {
+		policy := RequireSDKVersions(minVersions, false)
+		resp := doRequest(policy, map[string]string{userAgentHeader: "Mozilla/5.0", ldUserAgentHeader: "JSClient/2.0.0"})
+		assert.Equal(t, http.StatusUpgradeRequired, resp.Code)
+	})
+
+	t.Run("unknown versions", func(t *testing.T) {
+		for _, userAgent := range []string{"", "GoClient", "GoClient/abc", "/6.0.0"} {
+			t.Run(userAgent, func(t *testing.T) {
+				resp := doRequest(RequireSDKVersions(minVersions, false), map[string]string{userAgentHeader: userAgent})
+				assert.Equal(t, http.StatusOK, resp.Code)
+
+				resp = doRequest(RequireSDKVersions(minVersions, true), map[string]string{userAgentHeader: userAgent})
+				assert.Equal(t, http.StatusUpgradeRequired, resp.Code)
+			})
+		}
+	})
+
+	t.Run("empty list leaves handler unchanged", func(t *testing.T) {
+		resp := doRequest(RequireSDKVersions(nil, true), nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
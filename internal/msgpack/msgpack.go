@@ -0,0 +1,161 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jreader"
+)
+
+// ContentType is the media type of MessagePack data.
+const ContentType = "application/msgpack"
+
+// The MessagePack type markers that we use. The "fix" types encode a small length or value in the
+// marker byte itself.
+const (
+	markerPositiveFixInt = 0x00
+	markerFixMap         = 0x80
+	markerFixArray       = 0x90
+	markerFixStr         = 0xa0
+	markerNil            = 0xc0
+	markerFalse          = 0xc2
+	markerTrue           = 0xc3
+	markerFloat64        = 0xcb
+	markerUint8          = 0xcc
+	markerUint16         = 0xcd
+	markerUint32         = 0xce
+	markerUint64         = 0xcf
+	markerInt8           = 0xd0
+	markerInt16          = 0xd1
+	markerInt32          = 0xd2
+	markerInt64          = 0xd3
+	markerStr8           = 0xd9
+	markerStr16          = 0xda
+	markerStr32          = 0xdb
+	markerArray16        = 0xdc
+	markerArray32        = 0xdd
+	markerMap16          = 0xde
+	markerMap32          = 0xdf
+	markerNegativeFixInt = 0xe0
+)
+
+// maxHeaderSize is the size of the largest array or map header: a marker byte and a 32-bit count.
+const maxHeaderSize = 5
+
+// FromJSON converts a JSON document to MessagePack.
+//
+// The conversion is done in a single pass over the JSON data, without building an intermediate
+// representation, so object properties keep the order they had in the JSON. JSON numbers that are whole
+// numbers within the range of a 64-bit integer are encoded as MessagePack integers, using the smallest
+// integer type that can hold them; all other numbers are encoded as 64-bit floats.
+func FromJSON(data []byte) ([]byte, error) {
+	r := jreader.NewReader(data)
+	buf := appendValue(make([]byte, 0, len(data)), &r)
+	if r.Error() != nil {
+		return nil, r.Error()
+	}
+	if err := r.RequireEOF(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func appendValue(buf []byte, r *jreader.Reader) []byte {
+	value := r.Any()
+	switch value.Kind {
+	case jreader.BoolValue:
+		if value.Bool {
+			return append(buf, markerTrue)
+		}
+		return append(buf, markerFalse)
+	case jreader.NumberValue:
+		f := value.Number
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return appendInt(buf, int64(f))
+		}
+		buf = append(buf, markerFloat64)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(f))
+	case jreader.StringValue:
+		return appendString(buf, value.String)
+	case jreader.ArrayValue:
+		// The element count isn't known until the end of the array, so we reserve room for the largest
+		// header and then shrink it once we know the count.
+		start, count := len(buf), 0
+		buf = append(buf, make([]byte, maxHeaderSize)...)
+		for value.Array.Next() {
+			buf = appendValue(buf, r)
+			count++
+		}
+		return fillInLength(buf, start, count, markerFixArray, 16, markerArray16, markerArray32)
+	case jreader.ObjectValue:
+		start, count := len(buf), 0
+		buf = append(buf, make([]byte, maxHeaderSize)...)
+		for value.Object.Next() {
+			buf = appendString(buf, string(value.Object.Name()))
+			buf = appendValue(buf, r)
+			count++
+		}
+		return fillInLength(buf, start, count, markerFixMap, 16, markerMap16, markerMap32)
+	default:
+		return append(buf, markerNil)
+	}
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= 0x7f:
+		return append(buf, byte(n))
+	case n < 0 && n >= -32:
+		return append(buf, byte(n)) // negative fixint is the two's complement byte itself
+	case n >= 0 && n <= math.MaxUint8:
+		return append(buf, markerUint8, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, markerUint16), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, markerUint32), uint32(n))
+	case n >= 0:
+		return binary.BigEndian.AppendUint64(append(buf, markerUint64), uint64(n))
+	case n >= math.MinInt8:
+		return append(buf, markerInt8, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, markerInt16), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, markerInt32), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, markerInt64), uint64(n))
+	}
+}
+
+func appendString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, markerFixStr|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, markerStr8, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, markerStr16), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, markerStr32), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// fillInLength writes an array or map header with the given count into the maxHeaderSize bytes that
+// were reserved at buf[start:], moving the contents back if the header turns out to be shorter.
+func fillInLength(buf []byte, start, n int, fixMarker byte, fixLimit int, marker16, marker32 byte) []byte {
+	var header []byte
+	switch {
+	case n < fixLimit:
+		header = []byte{fixMarker | byte(n)}
+	case n <= math.MaxUint16:
+		header = binary.BigEndian.AppendUint16([]byte{marker16}, uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint32([]byte{marker32}, uint32(n))
+	}
+	copy(buf[start:], header)
+	if unused := maxHeaderSize - len(header); unused > 0 {
+		copy(buf[start+len(header):], buf[start+maxHeaderSize:])
+		buf = buf[:len(buf)-unused]
+	}
+	return buf
+}
@@ -0,0 +1,105 @@
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestFromJSONEncoding(t *testing.T) {
+	// Expected encodings are from the MessagePack specification.
+	for _, p := range []struct {
+		name     string
+		json     string
+		expected []byte
+	}{
+		{"null", `null`, []byte{0xc0}},
+		{"false", `false`, []byte{0xc2}},
+		{"true", `true`, []byte{0xc3}},
+		{"positive fixint", `127`, []byte{0x7f}},
+		{"negative fixint", `-32`, []byte{0xe0}},
+		{"uint8", `200`, []byte{0xcc, 0xc8}},
+		{"uint16", `1000`, []byte{0xcd, 0x03, 0xe8}},
+		{"uint32", `100000`, []byte{0xce, 0x00, 0x01, 0x86, 0xa0}},
+		{"uint64", `1700000000000`, []byte{0xcf, 0x00, 0x00, 0x01, 0x8b, 0xcf, 0xe5, 0x68, 0x00}},
+		{"int8", `-100`, []byte{0xd0, 0x9c}},
+		{"int16", `-1000`, []byte{0xd1, 0xfc, 0x18}},
+		{"int32", `-100000`, []byte{0xd2, 0xff, 0xfe, 0x79, 0x60}},
+		{"float64", `1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", `"abc"`, []byte{0xa3, 'a', 'b', 'c'}},
+		{"fixarray", `[1,"a"]`, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{"fixmap keeps key order", `{"b":true,"a":null}`, []byte{0x82, 0xa1, 'b', 0xc3, 0xa1, 'a', 0xc0}},
+		{"nested containers", `{"a":[[],{}]}`, []byte{0x81, 0xa1, 'a', 0x92, 0x90, 0x80}},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			data, err := FromJSON([]byte(p.json))
+			require.NoError(t, err)
+			assert.Equal(t, p.expected, data)
+		})
+	}
+
+	t.Run("str8", func(t *testing.T) {
+		s := strings.Repeat("x", 40)
+		data, err := FromJSON([]byte(`"` + s + `"`))
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{0xd9, 40}, s...), data)
+	})
+
+	t.Run("array16", func(t *testing.T) {
+		data, err := FromJSON([]byte(`[` + strings.TrimSuffix(strings.Repeat("0,", 20), ",") + `]`))
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{0xdc, 0, 20}, make([]byte, 20)...), data)
+	})
+
+	t.Run("map16", func(t *testing.T) {
+		data, err := FromJSON([]byte(`{` + strings.TrimSuffix(strings.Repeat(`"":0,`, 20), ",") + `}`))
+		require.NoError(t, err)
+		assert.Equal(t, append([]byte{0xde, 0, 20}, bytes.Repeat([]byte{0xa0, 0}, 20)...), data)
+	})
+
+	for _, badJSON := range []string{``, `{"a":`, `[1,]`, `{"a":1}x`, `null null`} {
+		t.Run("invalid JSON "+badJSON, func(t *testing.T) {
+			_, err := FromJSON([]byte(badJSON))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	bigObject := ldvalue.ObjectBuild()
+	bigArray := ldvalue.ArrayBuild()
+	for i := 0; i < 70000; i++ { // enough to need array32
+		bigArray.Add(ldvalue.Int(i))
+	}
+	for i := 0; i < 20; i++ { // enough to need map16
+		bigObject.Set(strings.Repeat("k", i+1), ldvalue.Int(-i))
+	}
+	for _, value := range []ldvalue.Value{
+		ldvalue.Null(),
+		ldvalue.Bool(true),
+		ldvalue.Int(math.MaxInt32),
+		ldvalue.Int(math.MinInt32),
+		ldvalue.Float64(-1e15),
+		ldvalue.Float64(0.25),
+		ldvalue.String(strings.Repeat("y", 70000)),
+		bigArray.Build(),
+		bigObject.Build(),
+		ldvalue.Parse([]byte(`{"flag1":{"value":{"a":[1,2.5,"c"]},"variation":1,"version":3,"trackEvents":true,
+			"reason":{"kind":"RULE_MATCH","ruleIndex":0,"ruleId":"x"}},"flag2":{"value":false,"variation":null,"version":1}}`)),
+	} {
+		data, err := FromJSON([]byte(value.JSONString()))
+		require.NoError(t, err)
+		var decoded bytes.Buffer
+		rest, err := msgp.UnmarshalAsJSON(&decoded, data)
+		require.NoError(t, err)
+		assert.Len(t, rest, 0)
+		assert.JSONEq(t, value.JSONString(), decoded.String())
+	}
+}
@@ -0,0 +1,7 @@
+// Package msgpack converts JSON data to MessagePack (https://msgpack.org), a compact binary
+// encoding with the same data model.
+//
+// Relay uses this to provide a smaller form of client-side evaluation responses for mobile SDKs. Rather
+// than having a separate schema, the MessagePack document always has the same structure as the JSON
+// document it was converted from.
+package msgpack
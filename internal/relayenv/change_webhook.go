@@ -0,0 +1,179 @@
+package relayenv
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+const (
+	// changeWebhookSignatureHeader contains the HMAC-SHA256 signature of the request body, computed with the
+	// configured secret, in the format "sha256=HEX". It is omitted if there is no secret.
+	changeWebhookSignatureHeader = "X-LD-Relay-Signature"
+
+	changeWebhookQueueSize      = 1000
+	changeWebhookMaxAttempts    = 3
+	changeWebhookRetryDelay     = time.Second
+	changeWebhookRequestTimeout = time.Second * 10
+)
+
+// changeWebhookPayload is the JSON body that changeWebhook posts to the webhook URL.
+type changeWebhookPayload struct {
+	Environment string           `json:"environment"`
+	EnvID       string           `json:"envId,omitempty"`
+	Flags       []flagChangeInfo `json:"flags"`
+}
+
+type flagChangeInfo struct {
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+}
+
+// changeWebhook posts a notification to a configured URL whenever flags change in an environment, so that
+// external systems can react to the change.
+//
+// Notifications are delivered by a separate goroutine, so a slow or failing webhook never holds up the
+// data store update that caused it. Changes that arrive while a notification is being delivered are
+// combined into the next one. If the queue fills up because the webhook cannot keep up, further changes
+// are dropped.
+type changeWebhook struct {
+	url        string
+	secret     string
+	payload    changeWebhookPayload
+	client     *http.Client
+	changesCh  chan flagChangeInfo
+	closeCh    chan struct{}
+	closeOnce  sync.Once
+	retryDelay time.Duration
+	loggers    ldlog.Loggers
+}
+
+func newChangeWebhook(
+	url string,
+	secret string,
+	envName string,
+	envID string,
+	client *http.Client,
+	loggers ldlog.Loggers,
+) *changeWebhook {
+	w := &changeWebhook{
+		url:        url,
+		secret:     secret,
+		payload:    changeWebhookPayload{Environment: envName, EnvID: envID},
+		client:     client,
+		changesCh:  make(chan flagChangeInfo, changeWebhookQueueSize),
+		closeCh:    make(chan struct{}),
+		retryDelay: changeWebhookRetryDelay,
+		loggers:    loggers,
+	}
+	go w.run()
+	return w
+}
+
+// notify queues a notification that a flag has changed. It never blocks.
+func (w *changeWebhook) notify(key string, version int) {
+	select {
+	case w.changesCh <- flagChangeInfo{Key: key, Version: version}:
+	default:
+		w.loggers.Warnf("Flag change webhook queue is full; not sending notification for flag %q", key)
+	}
+}
+
+func (w *changeWebhook) close() {
+	w.closeOnce.Do(func() { close(w.closeCh) })
+}
+
+func (w *changeWebhook) run() {
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case change := <-w.changesCh:
+			w.deliver(w.collectChanges(change))
+		}
+	}
+}
+
+// collectChanges combines the given change with any others that are already queued. If a flag changed
+// more than once, only its latest version is included.
+func (w *changeWebhook) collectChanges(first flagChangeInfo) []flagChangeInfo {
+	changes := []flagChangeInfo{first}
+	indexes := map[string]int{first.Key: 0}
+	for {
+		select {
+		case change := <-w.changesCh:
+			if i, ok := indexes[change.Key]; ok {
+				if change.Version > changes[i].Version {
+					changes[i].Version = change.Version
+				}
+				continue
+			}
+			indexes[change.Key] = len(changes)
+			changes = append(changes, change)
+		default:
+			return changes
+		}
+	}
+}
+
+func (w *changeWebhook) deliver(changes []flagChangeInfo) {
+	payload := w.payload
+	payload.Flags = changes
+	body, _ := json.Marshal(payload)
+	delay := w.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := w.post(body)
+		if err == nil {
+			return
+		}
+		if attempt >= changeWebhookMaxAttempts {
+			w.loggers.Warnf("Failed to deliver flag change webhook after %d attempts: %s", attempt, err)
+			return
+		}
+		w.loggers.Debugf("Flag change webhook failed (attempt %d of %d), retrying in %s: %s",
+			attempt, changeWebhookMaxAttempts, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-w.closeCh:
+			return
+		}
+		delay *= 2
+	}
+}
+
+func (w *changeWebhook) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), changeWebhookRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(body))
+	if err != nil { // COVERAGE: can't happen, since the URL was already validated
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set(changeWebhookSignatureHeader, signChangeWebhookBody(w.secret, body))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP error %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func signChangeWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,160 @@
+package relayenv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
+
+	"github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requireWebhookRequest(t *testing.T, requestsCh <-chan httphelpers.HTTPRequestInfo) httphelpers.HTTPRequestInfo {
+	return helpers.RequireValue(t, requestsCh, time.Second, "timed out waiting for webhook request")
+}
+
+func TestChangeWebhookPostsSignedPayload(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(200))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		w := newChangeWebhook(server.URL, "my-secret", "my-env", "my-env-id", http.DefaultClient, ldlog.NewDisabledLoggers())
+		defer w.close()
+		w.notify("flag1", 2)
+
+		r := requireWebhookRequest(t, requestsCh)
+		assert.Equal(t, "POST", r.Request.Method)
+		assert.Equal(t, "application/json", r.Request.Header.Get("Content-Type"))
+		assert.JSONEq(t, `{"environment":"my-env","envId":"my-env-id","flags":[{"key":"flag1","version":2}]}`,
+			string(r.Body))
+		assert.Equal(t, signChangeWebhookBody("my-secret", r.Body), r.Request.Header.Get(changeWebhookSignatureHeader))
+	})
+}
+
+func TestChangeWebhookSignature(t *testing.T) {
+	// expected value computed independently with: printf "{}" | openssl dgst -sha256 -hmac my-secret
+	assert.Equal(t, "sha256=d415d8aa3c63f8989e29bdfa9db8a195d3736dc967b236165abc47bf3b90fa77",
+		signChangeWebhookBody("my-secret", []byte("{}")))
+}
+
+func TestChangeWebhookOmitsSignatureWithoutSecret(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(200))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		w := newChangeWebhook(server.URL, "", "my-env", "", http.DefaultClient, ldlog.NewDisabledLoggers())
+		defer w.close()
+		w.notify("flag1", 2)
+
+		r := requireWebhookRequest(t, requestsCh)
+		assert.JSONEq(t, `{"environment":"my-env","flags":[{"key":"flag1","version":2}]}`, string(r.Body))
+		assert.Equal(t, "", r.Request.Header.Get(changeWebhookSignatureHeader))
+	})
+}
+
+func TestChangeWebhookRetriesAfterFailure(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.SequentialHandler(
+		httphelpers.HandlerWithStatus(503),
+		httphelpers.HandlerWithStatus(200),
+	))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		w := newChangeWebhook(server.URL, "", "my-env", "", http.DefaultClient, ldlog.NewDisabledLoggers())
+		w.retryDelay = time.Millisecond
+		defer w.close()
+		w.notify("flag1", 2)
+
+		r1 := requireWebhookRequest(t, requestsCh)
+		r2 := requireWebhookRequest(t, requestsCh)
+		assert.Equal(t, string(r1.Body), string(r2.Body))
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50, "should not have retried after success")
+	})
+}
+
+func TestChangeWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(503))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		mockLog := ldlogtest.NewMockLog()
+		w := newChangeWebhook(server.URL, "", "my-env", "", http.DefaultClient, mockLog.Loggers)
+		w.retryDelay = time.Millisecond
+		defer w.close()
+		w.notify("flag1", 2)
+
+		for i := 0; i < changeWebhookMaxAttempts; i++ {
+			requireWebhookRequest(t, requestsCh)
+		}
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50, "should not have retried again")
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Failed to deliver flag change webhook")
+	})
+}
+
+func TestChangeWebhookCombinesQueuedChanges(t *testing.T) {
+	w := &changeWebhook{changesCh: make(chan flagChangeInfo, 10)}
+	w.changesCh <- flagChangeInfo{Key: "flag2", Version: 1}
+	w.changesCh <- flagChangeInfo{Key: "flag1", Version: 4}
+	w.changesCh <- flagChangeInfo{Key: "flag2", Version: 2}
+
+	changes := w.collectChanges(flagChangeInfo{Key: "flag1", Version: 3})
+	assert.Equal(t, []flagChangeInfo{{Key: "flag1", Version: 4}, {Key: "flag2", Version: 2}}, changes)
+	assert.Len(t, w.changesCh, 0)
+}
+
+func TestChangeWebhookDropsChangesWhenQueueIsFull(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	w := &changeWebhook{changesCh: make(chan flagChangeInfo, 1), loggers: mockLog.Loggers}
+	w.notify("flag1", 1)
+	w.notify("flag2", 1) // doesn't block
+
+	assert.Len(t, w.changesCh, 1)
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, `queue is full; not sending notification for flag "flag2"`)
+}
+
+func TestEnvironmentSendsChangeWebhookForFlagUpdates(t *testing.T) {
+	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(200))
+	httphelpers.WithServer(handler, func(server *httptest.Server) {
+		envConfig := st.EnvMain.Config
+		webhookURL, err := configtypes.NewOptURLAbsoluteFromString(server.URL)
+		require.NoError(t, err)
+		envConfig.ChangeWebhookURL = webhookURL
+
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+		readyCh := make(chan ReadyResult, 1)
+		env := makeBasicEnv(t, envConfig, testclient.FakeLDClientFactory(true), mockLog.Loggers, readyCh)
+		defer env.Close()
+		requireEnvReady(t, readyCh)
+
+		store := env.GetStore()
+		require.NoError(t, store.Init(nil))
+		flag := ldbuilders.NewFlagBuilder("flag1").Version(5).Build()
+		_, err = st.UpsertFlag(store, flag)
+		require.NoError(t, err)
+		segment := ldbuilders.NewSegmentBuilder("segment1").Version(1).Build()
+		_, err = st.UpsertSegment(store, segment)
+		require.NoError(t, err)
+		_, err = st.UpsertFlag(store, flag) // not applied, because the version is unchanged
+		require.NoError(t, err)
+
+		r := requireWebhookRequest(t, requestsCh)
+		payload := ldvalue.Parse(r.Body)
+		assert.Equal(t, envName, payload.GetByKey("environment").StringValue())
+		assert.Equal(t, ldvalue.Parse([]byte(`[{"key":"flag1","version":5}]`)), payload.GetByKey("flags"))
+		helpers.AssertNoMoreValues(t, requestsCh, time.Millisecond*50, "unchanged flag and segment updates should not be sent")
+	})
+}
+
+func TestEnvironmentWithoutChangeWebhookURL(t *testing.T) {
+	readyCh := make(chan ReadyResult, 1)
+	env := makeBasicEnv(t, config.EnvConfig{SDKKey: st.EnvMain.Config.SDKKey}, testclient.FakeLDClientFactory(true),
+		ldlog.NewDisabledLoggers(), readyCh)
+	defer env.Close()
+	assert.Nil(t, env.(*envContextImpl).changeWebhook)
+}
@@ -0,0 +1,56 @@
+package relayenv
+
+import (
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+)
+
+// contextKindFlagViews restricts which flags are included in client-side evaluation results, based on
+// the kinds in the evaluation context. It maps each context kind to the flag key prefixes that contexts
+// of that kind can see; an empty prefix matches every flag. A nil map means that there is no restriction.
+type contextKindFlagViews map[ldcontext.Kind][]string
+
+// newContextKindFlagViews parses the "kind:prefix" entries of EnvConfig.ContextKindPrefixes. Config
+// validation has already ensured that each entry has that form.
+func newContextKindFlagViews(entries []string) contextKindFlagViews {
+	if len(entries) == 0 {
+		return nil
+	}
+	views := make(contextKindFlagViews)
+	for _, entry := range entries {
+		kind, prefix, _ := strings.Cut(entry, ":")
+		views[ldcontext.Kind(kind)] = append(views[ldcontext.Kind(kind)], prefix)
+	}
+	return views
+}
+
+// isVisible returns true if the flag is visible to any of the individual contexts within ldContext. A
+// context whose kinds do not appear in the map cannot see any flags.
+func (v contextKindFlagViews) isVisible(flagKey string, ldContext ldcontext.Context) bool {
+	if v == nil {
+		return true
+	}
+	if !ldContext.Multiple() {
+		return v.isVisibleToKind(flagKey, ldContext.Kind())
+	}
+	for _, c := range ldContext.GetAllIndividualContexts(nil) {
+		if v.isVisibleToKind(flagKey, c.Kind()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v contextKindFlagViews) isVisibleToKind(flagKey string, kind ldcontext.Kind) bool {
+	for _, prefix := range v[kind] {
+		if strings.HasPrefix(flagKey, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *envContextImpl) IsFlagVisibleToContext(flagKey string, ldContext ldcontext.Context) bool {
+	return c.contextKindViews.isVisible(flagKey, ldContext)
+}
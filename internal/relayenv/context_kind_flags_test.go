@@ -0,0 +1,46 @@
+package relayenv
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextKindFlagViews(t *testing.T) {
+	user := ldcontext.New("user-key")
+	org := ldcontext.NewWithKind("org", "org-key")
+	device := ldcontext.NewWithKind("device", "device-key")
+
+	t.Run("no views", func(t *testing.T) {
+		views := newContextKindFlagViews(nil)
+		assert.True(t, views.isVisible("any-flag", user))
+		assert.True(t, views.isVisible("any-flag", org))
+	})
+
+	t.Run("single-kind contexts", func(t *testing.T) {
+		views := newContextKindFlagViews([]string{"user:u-", "user:shared-", "org:o-"})
+		assert.True(t, views.isVisible("u-flag", user))
+		assert.True(t, views.isVisible("shared-flag", user))
+		assert.False(t, views.isVisible("o-flag", user))
+		assert.True(t, views.isVisible("o-flag", org))
+		assert.False(t, views.isVisible("u-flag", org))
+		assert.False(t, views.isVisible("u-flag", device))
+	})
+
+	t.Run("multi-kind contexts", func(t *testing.T) {
+		views := newContextKindFlagViews([]string{"user:u-", "org:o-"})
+		multi := ldcontext.NewMulti(user, org)
+		assert.True(t, views.isVisible("u-flag", multi))
+		assert.True(t, views.isVisible("o-flag", multi))
+		assert.False(t, views.isVisible("other-flag", multi))
+		assert.False(t, views.isVisible("o-flag", ldcontext.NewMulti(user, device)))
+	})
+
+	t.Run("empty prefix matches all flags", func(t *testing.T) {
+		views := newContextKindFlagViews([]string{"user:", "org:o-"})
+		assert.True(t, views.isVisible("any-flag", user))
+		assert.False(t, views.isVisible("any-flag", org))
+	})
+}
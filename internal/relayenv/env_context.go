@@ -11,13 +11,19 @@ import (
 
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v8/internal/events"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+	"github.com/launchdarkly/ld-relay/v8/internal/store"
 	"github.com/launchdarkly/ld-relay/v8/internal/streams"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 )
 
 // EnvContext is the interface for all Relay operations that are specific to one configured LD environment.
@@ -39,6 +45,10 @@ type EnvContext interface {
 	// SetIdentifiers updates the environment and project names and keys.
 	SetIdentifiers(EnvIdentifiers)
 
+	// GetEnvConfig returns the environment's configuration, updated with any changes that have been made
+	// since it was created, such as new credentials or TTL from auto-configuration.
+	GetEnvConfig() config.EnvConfig
+
 	// GetCredentials returns all currently enabled and non-deprecated credentials for the environment.
 	GetCredentials() []credential.SDKCredential
 
@@ -79,14 +89,34 @@ type EnvContext interface {
 	// segment store is not configured this returns nil.
 	GetBigSegmentStore() bigsegments.BigSegmentStore
 
+	// HasBigSegmentStore returns true if a big segment store is configured for this environment, even if
+	// GetBigSegmentStore is still returning nil because no big segments exist yet.
+	HasBigSegmentStore() bool
+
 	// GetLoggers returns a Loggers instance that is specific to this environment. We configure each of these to
 	// have its own prefix string and, optionally, its own log level.
 	GetLoggers() ldlog.Loggers
 
+	// SetLogLevel changes the log level of this environment's Loggers, including every copy of them that
+	// has already been passed to other components.
+	SetLogLevel(ldlog.LogLevel)
+
 	// GetHandler returns the HTTP handler for the specified kind of stream requests and credential for this
 	// environment. If there is none, it returns a handler for a 404 status (not nil).
 	GetStreamHandler(streams.StreamProvider, credential.SDKCredential) http.Handler
 
+	// GetStreamConnectionCount returns the number of stream connections that are currently being served
+	// for this environment, across all kinds of streams and credentials.
+	GetStreamConnectionCount() int
+
+	// SubscribeToEvaluations registers a client-side stream connection that sends evaluated flag values
+	// for the given evaluation context, rather than "ping" events. The returned channel receives a value
+	// whenever the environment's flag or segment data may have changed, so the caller can re-evaluate
+	// flags for that context; it is closed if the environment is closed. The caller must call the
+	// returned function when the connection ends. These connections are included in
+	// GetStreamConnectionCount.
+	SubscribeToEvaluations(ldcontext.Context) (<-chan struct{}, func())
+
 	// GetEventDispatcher returns the object that proxies events for this environment.
 	GetEventDispatcher() *events.EventDispatcher
 
@@ -116,9 +146,91 @@ type EnvContext interface {
 	// GetCreationTime returns the time that this EnvContext was created.
 	GetCreationTime() time.Time
 
+	// GetInitDuration returns how long it took from the creation of this EnvContext until its SDK client
+	// either finished initializing or failed, or zero if that has not happened yet.
+	GetInitDuration() time.Duration
+
+	// GetLastDataUpdateTime returns the time when the environment's data store last received flag or
+	// segment data from the data source, whether a full data set or a single update, or a zero time if it
+	// has not received any yet.
+	GetLastDataUpdateTime() time.Time
+
+	// GetStartupFlagKeys returns the keys of the flags in the environment's configured startup flag set,
+	// which client-side SDKs can request before the full set of flags. It returns nil if none is configured.
+	GetStartupFlagKeys() []string
+
+	// GetFallbackFlagValues returns the environment's configured fallback flag values, which client-side
+	// evaluation endpoints return if the data store has not received any flag data. It returns nil if none
+	// are configured.
+	GetFallbackFlagValues() map[string]ldvalue.Value
+
+	// IsFlagVisibleToContext returns true if client-side evaluation results for the given context should
+	// include the flag. This is always true unless the environment maps context kinds to subsets of flags.
+	IsFlagVisibleToContext(flagKey string, ldContext ldcontext.Context) bool
+
+	// IsFlagRedacted returns true if the flag's values should be hidden from the given kind of SDK. This is
+	// only ever true for client-side and mobile SDKs, and only if the environment has redacted flags.
+	IsFlagRedacted(sdkKind basictypes.SDKKind, flagKey string) bool
+
+	// TransformFlag applies Relay's flag transform, if one has been set, to a flag that is about to be
+	// served to the given kind of SDK. If the transform omits the flag, the result is a deleted item
+	// placeholder with the same version. If the flag is redacted for this kind of SDK, its variation
+	// values are replaced with nulls. Items that are not flags are returned unchanged.
+	TransformFlag(basictypes.SDKKind, ldstoretypes.ItemDescriptor) ldstoretypes.ItemDescriptor
+
+	// TransformFlags is like TransformFlag, but for a set of flags. If there is nothing to change, it
+	// returns the original slice.
+	TransformFlags(basictypes.SDKKind, []ldstoretypes.KeyedItemDescriptor) []ldstoretypes.KeyedItemDescriptor
+
+	// GetFlagTransformGeneration returns a number that changes whenever Relay's flag transform is set or
+	// removed, or zero if one has never been set. Cache validators for transformed data must include it,
+	// since a new transform can change flags without changing their versions.
+	GetFlagTransformGeneration() int64
+
+	// GetFlagVersion returns the definition of a flag as it was at the given version, or nil if that
+	// version is not available. The current version is always available from the data store; previous
+	// versions are available only if flag history is enabled with MainConfig.FlagHistoryDepth, and only
+	// if they were received while Relay was running.
+	GetFlagVersion(key string, version int) *ldmodel.FeatureFlag
+
+	// SetMaintenanceMode turns maintenance mode on or off. In maintenance mode, the environment stops its
+	// connection to LaunchDarkly and serves only the data that is already in the data store; turning it
+	// off restarts the connection.
+	SetMaintenanceMode(bool)
+
+	// IsInMaintenanceMode returns true if the environment is in maintenance mode.
+	IsInMaintenanceMode() bool
+
+	// Reconnect closes the environment's connection to LaunchDarkly and opens a new one, without
+	// recreating the SDK client or its data store. It returns immediately; GetReconnectStartTime returns
+	// a non-zero time until the new connection is ready. This has no effect in maintenance mode.
+	Reconnect()
+
+	// GetReconnectStartTime returns the time when a reconnect requested with Reconnect started, or a zero
+	// time if no reconnect is in progress.
+	GetReconnectStartTime() time.Time
+
 	// GetDataStoreInfo returns information about the environment's data store.
 	GetDataStoreInfo() sdks.DataStoreEnvironmentInfo
 
+	// GetStoreCircuitBreakerState returns the state of the data store circuit breaker, or an empty string if
+	// the circuit breaker is not enabled.
+	GetStoreCircuitBreakerState() store.CircuitBreakerState
+
+	// GetDataUpdateCounts returns the number of individual flag and segment updates that the environment
+	// has received from LaunchDarkly since it was created.
+	GetDataUpdateCounts() store.UpdateCounts
+
+	// GetFlagChangesSince returns the keys of the flags in the environment's data store that changed after
+	// the specified cursor, along with the current cursor. See store.SSERelayDataStoreAdapter.GetFlagChangesSince.
+	GetFlagChangesSince(since store.FlagChangeCursor) (map[string]int64, store.FlagChangeCursor)
+
+	// GetFlagCount returns the number of flags in the environment's data store, not counting deleted flags.
+	GetFlagCount() int
+
+	// GetFlagCountLimit returns the configured limit on the number of flags in the environment.
+	GetFlagCountLimit() FlagCountLimit
+
 	// FlushMetricsEvents is used in testing to ensure that metrics events are delivered promptly.
 	FlushMetricsEvents()
 }
@@ -148,6 +260,10 @@ type EnvIdentifiers struct {
 	// using a local configuration, this is always set; in auto-configuration mode, it is always empty (but
 	// EnvIdentifiers.GetDisplayName() will compute one).
 	ConfiguredName string
+
+	// Group is an optional name that the user specified for grouping environments in the status resource.
+	// It is only ever set when using a local configuration.
+	Group string
 }
 
 // GetDisplayName returns a human-readable unique name for this environment. If none was set in the
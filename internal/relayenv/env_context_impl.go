@@ -2,9 +2,12 @@ package relayenv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
@@ -12,16 +15,23 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v8/internal/events"
 	"github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+	"github.com/launchdarkly/ld-relay/v8/internal/logging"
 	"github.com/launchdarkly/ld-relay/v8/internal/metrics"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
 	"github.com/launchdarkly/ld-relay/v8/internal/store"
 	"github.com/launchdarkly/ld-relay/v8/internal/streams"
+	"github.com/launchdarkly/ld-relay/v8/internal/tombstones"
+	"github.com/launchdarkly/ld-relay/v8/internal/tracing"
 	"github.com/launchdarkly/ld-relay/v8/internal/util"
 
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	ld "github.com/launchdarkly/go-server-sdk/v7"
@@ -47,6 +57,37 @@ const (
 	LogNameIsEnvID LogNameMode = true
 )
 
+// InitOutcome describes how the initialization of an environment's SDK client ended.
+type InitOutcome string
+
+const (
+	// InitSucceeded means the SDK client was initialized successfully.
+	InitSucceeded InitOutcome = "succeeded"
+
+	// InitTimedOut means the SDK client did not receive data from LaunchDarkly within the configured
+	// initialization timeout. It will continue trying to connect in the background.
+	InitTimedOut InitOutcome = "timedOut"
+
+	// InitFailed means the SDK client could not be initialized for any other reason, such as an invalid
+	// SDK key or a data store that could not be verified.
+	InitFailed InitOutcome = "failed"
+)
+
+// ReadyResult is what NewEnvContext pushes to its ready channel once the SDK client initialization has
+// either succeeded or failed.
+type ReadyResult struct {
+	// Env is the environment that was initialized.
+	Env EnvContext
+	// Name is the environment's display name, as returned by EnvIdentifiers.GetDisplayName().
+	Name string
+	// Outcome describes whether initialization succeeded, and if not, why.
+	Outcome InitOutcome
+	// Err is the initialization error, or nil if it succeeded. This is the same as Env.GetInitError().
+	Err error
+	// InitDuration is how long it took from the creation of the environment until this result.
+	InitDuration time.Duration
+}
+
 func errInitPublisher(err error) error {
 	return fmt.Errorf("failed to initialize event publisher: %w", err)
 }
@@ -55,6 +96,17 @@ func errInitMetrics(err error) error {
 	return fmt.Errorf("failed to initialize metrics for environment: %w", err)
 }
 
+func errStoreNotVerified(reason string) error {
+	return fmt.Errorf("data store could not be verified as fully initialized: %s", reason)
+}
+
+// storeVerificationPollInterval is how often we re-check the data store while waiting for it to be
+// verified, if VerifyStoreOnInit is enabled.
+const storeVerificationPollInterval = time.Millisecond * 100
+
+// dataSourceConnectSpanName is the name of the trace span that covers the initialization of an SDK client.
+const dataSourceConnectSpanName = "data-source-connect"
+
 // EnvContextImplParams contains the constructor parameters for NewEnvContextImpl. These have their
 // own type because there are a lot of them, and many are irrelevant in tests.
 type EnvContextImplParams struct {
@@ -70,9 +122,14 @@ type EnvContextImplParams struct {
 	BigSegmentStoreFactory        bigsegments.BigSegmentStoreFactory
 	BigSegmentSynchronizerFactory bigsegments.BigSegmentSynchronizerFactory
 	SDKBigSegmentsConfigFactory   subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration] // set only in tests
+	TombstoneStoreFactory         tombstones.StoreFactory
 	UserAgent                     string
+	RelayVersion                  string // reported in augmented diagnostic events
 	LogNameMode                   LogNameMode
 	Loggers                       ldlog.Loggers
+	GlobalLogLevel                *logging.LevelGate // if set, applied to Loggers for messages that belong in the global log
+	FlagTransformer               *FlagTransformer   // if set, applied to flags before they are served to SDKs
+	InitLimiter                   *InitLimiter       // if set, limits how many environments initialize at once
 }
 
 type envContextImpl struct {
@@ -80,12 +137,16 @@ type envContextImpl struct {
 	clients          map[config.SDKKey]sdks.LDClientContext
 	storeAdapter     *store.SSERelayDataStoreAdapter
 	loggers          ldlog.Loggers
+	logLevel         *logging.LevelGate
 	credentials      map[credential.SDKCredential]bool // true if not deprecated
 	identifiers      EnvIdentifiers
+	envConfig        config.EnvConfig
 	secureMode       bool
 	envStreams       *streams.EnvStreams
 	streamProviders  []streams.StreamProvider
 	handlers         map[streams.StreamProvider]map[credential.SDKCredential]http.Handler
+	streamConnCount  int
+	evalSubscribers  map[*evaluationSubscriber]struct{}
 	jsContext        JSClientContext
 	evaluator        ldeval.Evaluator
 	eventDispatcher  *events.EventDispatcher
@@ -93,9 +154,14 @@ type envContextImpl struct {
 	bigSegmentStore  bigsegments.BigSegmentStore
 	bigSegmentsExist bool
 	sdkBigSegments   *ldstoreimpl.BigSegmentStoreWrapper
+	tombstonePurger  *tombstones.Purger
 	sdkConfig        ld.Config
+	dataSource       *pausableDataSourceFactory
+	reconnectStart   time.Time
 	sdkClientFactory sdks.ClientFactoryFunc
 	sdkInitTimeout   time.Duration
+	verifyStore      bool
+	verifyMinFlags   int
 	metricsManager   *metrics.Manager
 	metricsEnv       *metrics.EnvironmentManager
 	metricsEventPub  events.EventPublisher
@@ -103,8 +169,24 @@ type envContextImpl struct {
 	globalLoggers    ldlog.Loggers
 	ttl              time.Duration
 	initErr          error
+	initDuration     time.Duration
 	creationTime     time.Time
 	filterKey        config.FilterKey
+	startupFlagKeys  []string
+	fallbackValues   map[string]ldvalue.Value
+	contextKindViews contextKindFlagViews
+	flagCountLimit   FlagCountLimit
+	redactedFlags    redactedFlagKeys
+	flagTransformer  *FlagTransformer
+	flagHistory      *flagHistory
+	changeWebhook    *changeWebhook
+	closeCh          chan struct{}
+}
+
+// A client-side stream connection that is receiving evaluated flag values for a specific context.
+type evaluationSubscriber struct {
+	context ldcontext.Context
+	updates chan struct{}
 }
 
 // Implementation of the DataStoreQueries interface that the streams package uses as an abstraction of
@@ -124,13 +206,13 @@ type envContextStreamUpdates struct {
 // It immediately begins trying to initialize the SDK client for this environment. Since that might
 // take a while, it is done on a separate goroutine. The EnvContext instance is returned immediately
 // in an uninitialized state, and once the SDK client initialization has either succeeded or failed,
-// the same EnvContext will be pushed to the channel readyCh.
+// a ReadyResult describing the outcome for the same EnvContext will be pushed to the channel readyCh.
 //
 // NewEnvContext can also immediately return an error, with a nil EnvContext, if the configuration is
 // invalid.
 func NewEnvContext(
 	params EnvContextImplParams,
-	readyCh chan<- EnvContext,
+	readyCh chan<- ReadyResult,
 	// readyCh is a separate parameter because it's not a property of the environment itself, but
 	// just part of the semantics of the constructor
 ) (EnvContext, error) {
@@ -141,16 +223,25 @@ func NewEnvContext(
 	envConfig := params.EnvConfig
 	allConfig := params.AllConfig
 
-	envLoggers := params.Loggers
-	logPrefix := makeLogPrefix(params.LogNameMode, envConfig.SDKKey, envConfig.EnvID)
-	envLoggers.SetPrefix(logPrefix)
-	envLoggers.SetMinLevel(
+	globalLoggers := params.Loggers
+	if params.GlobalLogLevel != nil {
+		globalLoggers = params.GlobalLogLevel.Apply(params.Loggers)
+	}
+	logLevel := logging.NewLevelGate(
 		envConfig.LogLevel.GetOrElse(
 			allConfig.Main.LogLevel.GetOrElse(ldlog.Info),
 		),
 	)
+	envLoggers := logLevel.Apply(params.Loggers)
+	logPrefix := makeLogPrefix(params.LogNameMode, envConfig.SDKKey, envConfig.EnvID)
+	envLoggers.SetPrefix(logPrefix)
 
-	httpConfig, err := httpconfig.NewHTTPConfig(allConfig.Proxy, envConfig.SDKKey, params.UserAgent, params.Loggers)
+	httpLoggers := globalLoggers
+	if envConfig.ProxyURL.IsDefined() {
+		httpLoggers = envLoggers // so it's clear which environment the proxy messages are about
+	}
+	httpConfig, err := httpconfig.NewHTTPConfig(envConfig.GetProxyConfig(allConfig.Proxy), envConfig.SDKKey,
+		params.UserAgent, envConfig.TLSServerName, httpLoggers)
 	if err != nil {
 		return nil, err
 	}
@@ -166,21 +257,33 @@ func NewEnvContext(
 
 	envContext := &envContextImpl{
 		identifiers:      params.Identifiers,
+		envConfig:        envConfig,
 		clients:          make(map[config.SDKKey]sdks.LDClientContext),
 		credentials:      credentials,
 		loggers:          envLoggers,
+		logLevel:         logLevel,
 		secureMode:       envConfig.SecureMode,
 		streamProviders:  params.StreamProviders,
 		handlers:         make(map[streams.StreamProvider]map[credential.SDKCredential]http.Handler),
+		evalSubscribers:  make(map[*evaluationSubscriber]struct{}),
 		jsContext:        params.JSClientContext,
 		sdkClientFactory: params.ClientFactory,
 		sdkInitTimeout:   allConfig.Main.InitTimeout.GetOrElse(config.DefaultInitTimeout),
+		verifyStore:      allConfig.Main.VerifyStoreOnInit,
+		verifyMinFlags:   allConfig.Main.VerifyStoreMinFlags.GetOrElse(0),
 		metricsManager:   params.MetricsManager,
-		globalLoggers:    params.Loggers,
+		globalLoggers:    globalLoggers,
 		ttl:              envConfig.TTL.GetOrElse(0),
 		dataStoreInfo:    params.DataStoreInfo,
 		creationTime:     time.Now(),
 		filterKey:        params.EnvConfig.FilterKey,
+		startupFlagKeys:  envConfig.StartupFlags.Values(),
+		fallbackValues:   envConfig.FallbackFlagValues.Values(),
+		contextKindViews: newContextKindFlagViews(envConfig.ContextKindPrefixes.Values()),
+		redactedFlags:    newRedactedFlagKeys(envConfig.RedactedFlags.Values()),
+		flagCountLimit:   FlagCountLimit{Max: allConfig.Main.MaxFlagCount.GetOrElse(0), Enforce: allConfig.Main.EnforceMaxFlagCount},
+		flagTransformer:  params.FlagTransformer,
+		closeCh:          make(chan struct{}),
 	}
 
 	bigSegmentStoreFactory := params.BigSegmentStoreFactory
@@ -201,7 +304,8 @@ func NewEnvContext(
 		}
 		envContext.bigSegmentSync = factory(
 			httpConfig, bigSegmentStore, allConfig.Main.BaseURI.String(), allConfig.Main.StreamURI.String(),
-			envConfig.EnvID, envConfig.SDKKey, envLoggers, logPrefix)
+			envConfig.EnvID, envConfig.SDKKey, allConfig.Main.BigSegmentsMaxPatchRate.GetOrElse(0),
+			envLoggers, logPrefix)
 		thingsToCleanUp.AddFunc(envContext.bigSegmentSync.Close)
 		segmentUpdateCh := envContext.bigSegmentSync.SegmentUpdatesCh()
 		if segmentUpdateCh != nil {
@@ -219,6 +323,7 @@ func NewEnvContext(
 					if envContext.envStreams != nil {
 						envContext.envStreams.InvalidateClientSideState()
 					}
+					envContext.notifyEvaluationSubscribers()
 					// If we shut down the environment, the BigSegmentSynchronizer will be closed which
 					// will also cause this channel to be closed, exiting this goroutine.
 				}
@@ -229,6 +334,24 @@ func NewEnvContext(
 		// envContextStreamUpdates methods.
 	}
 
+	tombstoneStoreFactory := params.TombstoneStoreFactory
+	if tombstoneStoreFactory == nil {
+		tombstoneStoreFactory = tombstones.DefaultStoreFactory
+	}
+	tombstoneStore, err := tombstoneStoreFactory(envConfig, allConfig, envLoggers)
+	if err != nil {
+		return nil, err
+	}
+	if tombstoneStore != nil {
+		interval, age := tombstones.GetPurgeTimes(allConfig)
+		envContext.tombstonePurger = tombstones.NewPurger(tombstoneStore, interval, age, envLoggers)
+		thingsToCleanUp.AddCloser(envContext.tombstonePurger)
+	}
+
+	if allConfig.Main.FlagHistoryDepth.IsDefined() {
+		envContext.flagHistory = newFlagHistory(allConfig.Main.FlagHistoryDepth.GetOrElse(0))
+	}
+
 	envStreams := streams.NewEnvStreams(
 		params.StreamProviders,
 		envContextStoreQueries{envContext},
@@ -238,6 +361,9 @@ func NewEnvContext(
 	)
 	envContext.envStreams = envStreams
 	thingsToCleanUp.AddCloser(envStreams)
+	envStreams.SetOversizedEventHandler(func(string, int) {
+		metrics.Increment(envContext.GetMetricsContext(), metrics.OversizedStreamEvents)
+	})
 
 	envStreamUpdates := &envContextStreamUpdates{
 		context: envContext,
@@ -261,7 +387,25 @@ func NewEnvContext(
 	if dataStoreFactory == nil {
 		dataStoreFactory = ldcomponents.InMemoryDataStore()
 	}
-	storeAdapter := store.NewSSERelayDataStoreAdapter(dataStoreFactory, envStreamUpdates)
+	storeAdapter := store.NewSSERelayDataStoreAdapter(dataStoreFactory, envStreamUpdates, store.WriteRetryConfig{
+		MaxAttempts:  allConfig.Main.StoreWriteAttempts.GetOrElse(config.DefaultStoreWriteAttempts),
+		InitialDelay: allConfig.Main.StoreWriteRetryDelay.GetOrElse(config.DefaultStoreWriteRetryDelay),
+	}, store.CircuitBreakerConfig{
+		FailureThreshold: allConfig.Main.StoreBreakerFailures.GetOrElse(0),
+		LatencyThreshold: allConfig.Main.StoreBreakerLatency.GetOrElse(0),
+		OpenDuration:     allConfig.Main.StoreBreakerOpenTime.GetOrElse(config.DefaultStoreBreakerOpenTime),
+	})
+	var breakerOpen atomic.Bool // the half-open state counts as open, since it only lets a single read through
+	storeAdapter.SetCircuitBreakerHandler(func(state store.CircuitBreakerState) {
+		switch {
+		case state == store.CircuitBreakerOpen && breakerOpen.CompareAndSwap(false, true):
+			envLoggers.Warn("Data store circuit breaker is open; reads will use the last known flag data until the store recovers")
+			metrics.IncrementBy(envContext.GetMetricsContext(), metrics.StoreCircuitBreakerOpen, 1)
+		case state == store.CircuitBreakerClosed && breakerOpen.CompareAndSwap(true, false):
+			envLoggers.Info("Data store circuit breaker is closed; the data store has recovered")
+			metrics.IncrementBy(envContext.GetMetricsContext(), metrics.StoreCircuitBreakerOpen, -1)
+		}
+	})
 	envContext.storeAdapter = storeAdapter
 
 	var eventDispatcher *events.EventDispatcher
@@ -272,14 +416,25 @@ func NewEnvContext(
 			envLoggers.Info("Proxying events for this environment")
 			eventLoggers := envLoggers
 			eventLoggers.SetPrefix(logPrefix + " (event proxy)")
+			eventsConfig := allConfig.Events
+			if envConfig.EventsFlushInterval.IsDefined() {
+				eventsConfig.FlushInterval = envConfig.EventsFlushInterval
+			}
 			eventDispatcher = events.NewEventDispatcher(
 				envConfig.SDKKey,
 				envConfig.MobileKey,
 				envConfig.EnvID,
+				params.Identifiers.GetDisplayName(),
+				params.RelayVersion,
 				envLoggers,
-				allConfig.Events,
+				eventsConfig,
+				envConfig.EventForwardingURI.Values(),
+				envConfig.StrictEvents,
 				httpConfig,
 				storeAdapter,
+				func(count int) {
+					metrics.IncrementBy(envContext.GetMetricsContext(), metrics.UnknownFlagEvents, int64(count))
+				},
 				0, // 0 here means "use the default interval for any periodic cleanup task you may need to run"
 			)
 		}
@@ -315,12 +470,44 @@ func NewEnvContext(
 	}
 	envContext.metricsEnv = em
 
+	if envConfig.ChangeWebhookURL.IsDefined() {
+		envContext.changeWebhook = newChangeWebhook(envConfig.ChangeWebhookURL.String(), envConfig.ChangeWebhookSecret,
+			params.Identifiers.GetDisplayName(), string(envConfig.EnvID), httpConfig.Client(), envLoggers)
+		thingsToCleanUp.AddFunc(envContext.changeWebhook.close)
+	}
+
+	storeAdapter.SetUpdateHandler(func(kind ldstoretypes.DataKind) {
+		if kind == ldstoreimpl.Segments() {
+			metrics.Increment(envContext.GetMetricsContext(), metrics.SegmentUpdates)
+		} else {
+			metrics.Increment(envContext.GetMetricsContext(), metrics.FlagUpdates)
+		}
+	})
+	if envContext.changeWebhook != nil {
+		storeAdapter.SetFlagChangeHandler(envContext.changeWebhook.notify)
+	}
+
+	if dataFormat := params.DataStoreInfo.DataFormat; dataFormat != nil {
+		dataFormat.SetMismatchHandler(func(int) {
+			metrics.Increment(envContext.GetMetricsContext(), metrics.StoreFormatMismatches)
+		})
+	}
+
 	disconnectedStatusTime := allConfig.Main.DisconnectedStatusTime.GetOrElse(config.DefaultDisconnectedStatusTime)
 
-	dataSource := ldcomponents.StreamingDataSource()
+	streamingDataSource := ldcomponents.StreamingDataSource()
+	if allConfig.Main.InitialReconnectDelay.IsDefined() {
+		streamingDataSource.InitialReconnectDelay(allConfig.Main.InitialReconnectDelay.GetOrElse(0))
+	}
 
 	if params.EnvConfig.FilterKey != "" {
-		dataSource.PayloadFilter(string(params.EnvConfig.FilterKey))
+		streamingDataSource.PayloadFilter(string(params.EnvConfig.FilterKey))
+	}
+
+	dataSource := newPausableDataSourceFactory(streamingDataSource, allConfig.Main.MaintenanceMode)
+	envContext.dataSource = dataSource
+	if allConfig.Main.MaintenanceMode {
+		envLoggers.Warn("Starting in maintenance mode; will not connect to LaunchDarkly until maintenance mode is turned off")
 	}
 
 	envContext.sdkConfig = ld.Config{
@@ -347,7 +534,7 @@ func NewEnvContext(
 	if bigSegmentStore != nil {
 		configFactory := params.SDKBigSegmentsConfigFactory
 		if configFactory == nil {
-			configFactory, err = sdks.ConfigureBigSegments(allConfig, envConfig, params.Loggers)
+			configFactory, err = sdks.ConfigureBigSegments(allConfig, envConfig, globalLoggers)
 			if err != nil {
 				return nil, err
 			}
@@ -374,16 +561,63 @@ func NewEnvContext(
 		}
 	}
 
-	// Connecting may take time, so do this in parallel
-	go envContext.startSDKClient(envConfig.SDKKey, readyCh, allConfig.Main.IgnoreConnectionErrors)
+	// Connecting may take time, so do this in parallel. If a startup jitter is configured, we also wait
+	// a random amount of time first, so that many Relay instances starting at once don't all connect to
+	// LaunchDarkly at the same moment. If there is a limit on concurrent initializations, we then wait
+	// for one of the other environments to finish initializing if necessary.
+	startupDelay := startupJitterDelay(allConfig.Main.StartupJitter.GetOrElse(0))
+	go func() {
+		if startupDelay > 0 {
+			envLoggers.Infof("Waiting %s before connecting to LaunchDarkly", startupDelay)
+			select {
+			case <-time.After(startupDelay):
+			case <-envContext.closeCh:
+				return
+			}
+		}
+		if !params.InitLimiter.tryAcquire() {
+			envLoggers.Info("Waiting for other environments to finish initializing before connecting to LaunchDarkly")
+			if !params.InitLimiter.acquire(envContext.closeCh) {
+				return
+			}
+		}
+		defer params.InitLimiter.release()
+		envContext.startSDKClient(envConfig.SDKKey, readyCh, allConfig.Main.IgnoreConnectionErrors)
+	}()
 
 	thingsToCleanUp.Clear() // we've succeeded so we do not want to throw away these things
 
 	return envContext, nil
 }
 
-func (c *envContextImpl) startSDKClient(sdkKey config.SDKKey, readyCh chan<- EnvContext, suppressErrors bool) {
-	client, err := c.sdkClientFactory(sdkKey, c.sdkConfig, c.sdkInitTimeout)
+// startupJitterDelay returns a random duration that is at least zero and less than jitter.
+func startupJitterDelay(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jitter))) //nolint:gosec // doesn't need to be cryptographically secure
+}
+
+func (c *envContextImpl) startSDKClient(sdkKey config.SDKKey, readyCh chan<- ReadyResult, suppressErrors bool) {
+	startTime := time.Now()
+	var client sdks.LDClientContext
+	err := tracing.WithSpan(c.GetMetricsContext(), dataSourceConnectSpanName, func(context.Context) error {
+		var err error
+		client, err = c.sdkClientFactory(sdkKey, c.sdkConfig, c.sdkInitTimeout)
+		if err == ld.ErrInitializationFailed && c.IsInMaintenanceMode() {
+			// The data source was never started, so the SDK could not have received any data; that's expected
+			// in maintenance mode, and we'll serve whatever is already in the data store.
+			err = nil
+		}
+		if err == nil && client != nil && c.verifyStore {
+			// The SDK's own initialization signal only tells us that it has received data from LaunchDarkly
+			// or, if using a persistent store, that the store claims to be initialized. When several Relay
+			// instances share a store, we want stronger evidence that the store holds a full data set before
+			// we report that this environment is ready.
+			err = c.waitForVerifiedStore(c.sdkInitTimeout - time.Since(startTime))
+		}
+		return err
+	})
 	c.mu.Lock()
 	name := c.identifiers.GetDisplayName()
 	if client != nil {
@@ -406,28 +640,120 @@ func (c *envContextImpl) startSDKClient(sdkKey config.SDKKey, readyCh chan<- Env
 		c.evaluator = ldeval.NewEvaluatorWithOptions(dataProvider, evalOptions...)
 	}
 	c.initErr = err
+	if readyCh != nil {
+		// This is the environment's initial startup, rather than a new client for a changed SDK key.
+		c.initDuration = time.Since(c.creationTime)
+	}
+	initDuration := c.initDuration
 	c.mu.Unlock()
 
+	result := ReadyResult{Env: c, Name: name, Outcome: InitSucceeded, Err: err, InitDuration: initDuration}
 	if err != nil {
+		result.Outcome = InitFailed
+		if errors.Is(err, ld.ErrInitializationTimeout) {
+			result.Outcome = InitTimedOut
+		}
 		if suppressErrors {
 			c.globalLoggers.Warnf("Ignoring error initializing LaunchDarkly client for %q: %+v",
 				name, err)
 		} else {
 			c.globalLoggers.Errorf("Error initializing LaunchDarkly client for %q: %+v",
 				name, err)
-			if readyCh != nil {
-				readyCh <- c
-			}
-			return
 		}
 	} else {
 		c.globalLoggers.Infof("Initialized LaunchDarkly client for %q", name)
 	}
+	if client != nil && (errors.Is(err, ld.ErrInitializationFailed) || errors.Is(err, ld.ErrInitializationTimeout)) {
+		// The SDK client keeps trying to connect in the background, so the error may not be permanent. That
+		// isn't true of a store verification failure, which the data source status can't tell us anything about.
+		go c.trackInitRecovery(sdkKey, client)
+	}
 	if readyCh != nil {
-		readyCh <- c
+		metrics.RecordLatency(c.GetMetricsContext(), initDuration, metrics.EnvInitDuration)
+		readyCh <- result
 	}
 }
 
+// trackInitRecovery watches the data source status of an SDK client that failed to initialize, and
+// updates the environment's initialization error if the client later connects successfully or gives up,
+// so that the status resource and request handlers reflect the current state rather than the original
+// failure. It returns when the client is closed.
+func (c *envContextImpl) trackInitRecovery(sdkKey config.SDKKey, client sdks.LDClientContext) {
+	statusCh := client.AddDataSourceStatusListener()
+	c.updateInitErrorFromStatus(sdkKey, client, client.GetDataSourceStatus())
+	for status := range statusCh {
+		c.updateInitErrorFromStatus(sdkKey, client, status)
+	}
+}
+
+func (c *envContextImpl) updateInitErrorFromStatus(
+	sdkKey config.SDKKey,
+	client sdks.LDClientContext,
+	status interfaces.DataSourceStatus,
+) {
+	var err error
+	switch status.State {
+	case interfaces.DataSourceStateValid:
+		err = nil
+	case interfaces.DataSourceStateOff:
+		err = ld.ErrInitializationFailed
+	default:
+		return // still retrying, so the original error is still the most accurate description
+	}
+	c.mu.Lock()
+	if c.clients[sdkKey] != client || c.initErr == err {
+		// Either nothing has changed, or this client has been replaced by one for a different SDK key
+		c.mu.Unlock()
+		return
+	}
+	c.initErr = err
+	name := c.identifiers.GetDisplayName()
+	c.mu.Unlock()
+
+	if err == nil {
+		c.globalLoggers.Infof("LaunchDarkly client for %q has initialized after an earlier error", name)
+	} else {
+		c.globalLoggers.Errorf("LaunchDarkly client for %q has stopped retrying after an unrecoverable error", name)
+	}
+}
+
+// waitForVerifiedStore polls the data store until it is initialized and contains at least the
+// configured minimum number of flags, or until the timeout elapses.
+func (c *envContextImpl) waitForVerifiedStore(timeout time.Duration) error {
+	// We check the store directly, rather than through envContextStoreQueries, because the flag count
+	// should not depend on whether a flag transform omits some of the flags.
+	deadline := time.Now().Add(timeout)
+	for {
+		reason := ""
+		store := c.storeAdapter.GetStore()
+		if store == nil || !store.IsInitialized() {
+			reason = "store is not initialized"
+		} else if flags, err := store.GetAll(ldstoreimpl.Features()); err != nil {
+			reason = err.Error()
+		} else if n := countNonDeletedItems(flags); n < c.verifyMinFlags {
+			reason = fmt.Sprintf("store contains %d flags, expected at least %d", n, c.verifyMinFlags)
+		}
+		if reason == "" {
+			c.loggers.Debug("Verified that data store is fully initialized")
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return errStoreNotVerified(reason)
+		}
+		time.Sleep(storeVerificationPollInterval)
+	}
+}
+
+func countNonDeletedItems(items []ldstoretypes.KeyedItemDescriptor) int {
+	n := 0
+	for _, item := range items {
+		if item.Item.Item != nil {
+			n++
+		}
+	}
+	return n
+}
+
 func (c *envContextImpl) GetPayloadFilter() config.FilterKey {
 	return c.filterKey
 }
@@ -446,6 +772,33 @@ func (c *envContextImpl) SetIdentifiers(ei EnvIdentifiers) {
 	c.identifiers = ei
 }
 
+func (c *envContextImpl) GetEnvConfig() config.EnvConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ret := c.envConfig
+	for cred, nonDeprecated := range c.credentials {
+		if !nonDeprecated {
+			continue
+		}
+		switch cred := cred.(type) {
+		case config.SDKKey:
+			ret.SDKKey = cred
+		case config.MobileKey:
+			ret.MobileKey = cred
+		case config.EnvironmentID:
+			ret.EnvID = cred
+		}
+	}
+	ret.SecureMode = c.secureMode
+	ret.TTL = ct.OptDuration{}
+	if c.ttl > 0 {
+		ret.TTL = ct.NewOptDuration(c.ttl)
+	}
+	ret.LogLevel = config.NewOptLogLevel(c.logLevel.GetMinLevel())
+	return ret
+}
+
 func (c *envContextImpl) GetCredentials() []credential.SDKCredential {
 	return c.getCredentialsInternal(true)
 }
@@ -562,10 +915,18 @@ func (c *envContextImpl) GetBigSegmentStore() bigsegments.BigSegmentStore {
 	return nil
 }
 
+func (c *envContextImpl) HasBigSegmentStore() bool {
+	return c.bigSegmentStore != nil
+}
+
 func (c *envContextImpl) GetLoggers() ldlog.Loggers {
 	return c.loggers
 }
 
+func (c *envContextImpl) SetLogLevel(level ldlog.LogLevel) {
+	c.logLevel.SetMinLevel(level)
+}
+
 func (c *envContextImpl) GetStreamHandler(streamProvider streams.StreamProvider, credential credential.SDKCredential) http.Handler {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -573,7 +934,57 @@ func (c *envContextImpl) GetStreamHandler(streamProvider streams.StreamProvider,
 	if h == nil {
 		return http.HandlerFunc(invalidStreamHandler)
 	}
-	return h
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.mu.Lock()
+		c.streamConnCount++
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			c.streamConnCount--
+			c.mu.Unlock()
+		}()
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (c *envContextImpl) GetStreamConnectionCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.streamConnCount
+}
+
+func (c *envContextImpl) SubscribeToEvaluations(context ldcontext.Context) (<-chan struct{}, func()) {
+	// The channel has a buffer of one so that notifications never block the data store update; if the
+	// subscriber hasn't yet consumed a previous notification, it will re-evaluate everything anyway.
+	sub := &evaluationSubscriber{context: context, updates: make(chan struct{}, 1)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.evalSubscribers == nil {
+		// the environment has been closed
+		close(sub.updates)
+		return sub.updates, func() {}
+	}
+	c.evalSubscribers[sub] = struct{}{}
+	c.streamConnCount++
+	return sub.updates, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, ok := c.evalSubscribers[sub]; ok {
+			delete(c.evalSubscribers, sub)
+			c.streamConnCount--
+		}
+	}
+}
+
+func (c *envContextImpl) notifyEvaluationSubscribers() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for sub := range c.evalSubscribers {
+		select {
+		case sub.updates <- struct{}{}:
+		default:
+		}
+	}
 }
 
 func invalidStreamHandler(w http.ResponseWriter, req *http.Request) {
@@ -637,14 +1048,86 @@ func (c *envContextImpl) SetSecureMode(secureMode bool) {
 	c.secureMode = secureMode
 }
 
+func (c *envContextImpl) GetLastDataUpdateTime() time.Time {
+	return c.storeAdapter.GetLastUpdateTime()
+}
+
+func (c *envContextImpl) GetStartupFlagKeys() []string {
+	return c.startupFlagKeys
+}
+
+func (c *envContextImpl) GetFallbackFlagValues() map[string]ldvalue.Value {
+	return c.fallbackValues
+}
+
+func (c *envContextImpl) SetMaintenanceMode(maintenance bool) {
+	if maintenance == c.dataSource.isPaused() {
+		return
+	}
+	if maintenance {
+		c.loggers.Warn("Entering maintenance mode; disconnecting from LaunchDarkly and serving only stored data")
+	} else {
+		c.loggers.Info("Leaving maintenance mode; reconnecting to LaunchDarkly")
+	}
+	c.dataSource.setPaused(maintenance)
+}
+
+func (c *envContextImpl) IsInMaintenanceMode() bool {
+	return c.dataSource.isPaused()
+}
+
+func (c *envContextImpl) Reconnect() {
+	c.mu.Lock()
+	if !c.reconnectStart.IsZero() {
+		c.mu.Unlock()
+		return
+	}
+	c.reconnectStart = time.Now()
+	c.mu.Unlock()
+
+	c.loggers.Warn("Reconnecting to LaunchDarkly on request")
+	ready := c.dataSource.restart()
+	go func() {
+		<-ready
+		c.mu.Lock()
+		c.reconnectStart = time.Time{}
+		c.mu.Unlock()
+		c.loggers.Info("Reconnect to LaunchDarkly completed")
+	}()
+}
+
+func (c *envContextImpl) GetReconnectStartTime() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reconnectStart
+}
+
 func (c *envContextImpl) GetDataStoreInfo() sdks.DataStoreEnvironmentInfo {
 	return c.dataStoreInfo
 }
 
+func (c *envContextImpl) GetStoreCircuitBreakerState() store.CircuitBreakerState {
+	return c.storeAdapter.GetCircuitBreakerState()
+}
+
+func (c *envContextImpl) GetDataUpdateCounts() store.UpdateCounts {
+	return c.storeAdapter.GetUpdateCounts()
+}
+
+func (c *envContextImpl) GetFlagChangesSince(since store.FlagChangeCursor) (map[string]int64, store.FlagChangeCursor) {
+	return c.storeAdapter.GetFlagChangesSince(since)
+}
+
 func (c *envContextImpl) GetCreationTime() time.Time {
 	return c.creationTime
 }
 
+func (c *envContextImpl) GetInitDuration() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.initDuration
+}
+
 func (c *envContextImpl) FlushMetricsEvents() {
 	if c.metricsEnv != nil && c.metricsEventPub != nil {
 		c.metricsEnv.FlushEventsExporter()
@@ -654,11 +1137,28 @@ func (c *envContextImpl) FlushMetricsEvents() {
 
 func (c *envContextImpl) Close() error {
 	c.mu.Lock()
+	select {
+	case <-c.closeCh:
+		c.mu.Unlock()
+		return nil // already closed
+	default:
+		close(c.closeCh)
+	}
 	for _, client := range c.clients {
 		_ = client.Close()
 	}
 	c.clients = make(map[config.SDKKey]sdks.LDClientContext)
+	// Any stream request that arrives after this point, from a caller that looked up the environment
+	// before it was removed, should get a 404 rather than a stream that will never be updated.
+	c.handlers = make(map[streams.StreamProvider]map[credential.SDKCredential]http.Handler)
+	for sub := range c.evalSubscribers {
+		close(sub.updates)
+		c.streamConnCount--
+	}
+	c.evalSubscribers = nil
 	c.mu.Unlock()
+	// Closing the EnvStreams unregisters all of this environment's channels with forced disconnection,
+	// so every active stream connection is dropped here.
 	_ = c.envStreams.Close()
 	if c.metricsManager != nil && c.metricsEnv != nil {
 		c.metricsManager.RemoveEnvironment(c.metricsEnv)
@@ -678,6 +1178,12 @@ func (c *envContextImpl) Close() error {
 	if c.sdkBigSegments != nil {
 		c.sdkBigSegments.Close()
 	}
+	if c.tombstonePurger != nil {
+		_ = c.tombstonePurger.Close()
+	}
+	if c.changeWebhook != nil {
+		c.changeWebhook.close()
+	}
 	return nil
 }
 
@@ -701,8 +1207,13 @@ func (q envContextStoreQueries) IsInitialized() bool {
 }
 
 func (q envContextStoreQueries) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
+	// These queries are only used for server-side streams, so the flags are transformed for server-side SDKs.
 	if s := q.context.storeAdapter.GetStore(); s != nil {
-		return s.GetAll(kind)
+		items, err := s.GetAll(kind)
+		if err == nil && kind == ldstoreimpl.Features() {
+			items = q.context.TransformFlags(basictypes.ServerSDK, items)
+		}
+		return items, err
 	}
 	return nil, nil
 }
@@ -710,7 +1221,11 @@ func (q envContextStoreQueries) GetAll(kind ldstoretypes.DataKind) ([]ldstoretyp
 func (u *envContextStreamUpdates) SendAllDataUpdate(allData []ldstoretypes.Collection) {
 	// We use this delegator, rather than sending updates directory to context.envStreams, so that we
 	// can detect the presence of a big segment and turn on the big segment synchronizer as needed.
-	u.context.envStreams.SendAllDataUpdate(allData)
+	if u.context.flagHistory != nil {
+		u.context.flagHistory.recordAll(allData)
+	}
+	u.context.envStreams.SendAllDataUpdate(u.context.transformServerSideData(allData))
+	u.context.notifyEvaluationSubscribers()
 	if u.context.bigSegmentSync == nil {
 		return
 	}
@@ -733,7 +1248,15 @@ func (u *envContextStreamUpdates) SendAllDataUpdate(allData []ldstoretypes.Colle
 
 func (u *envContextStreamUpdates) SendSingleItemUpdate(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
 	// See comments in SendAllDataUpdate.
-	u.context.envStreams.SendSingleItemUpdate(kind, key, item)
+	if kind == ldstoreimpl.Features() && u.context.flagHistory != nil {
+		u.context.flagHistory.record(item)
+	}
+	if kind == ldstoreimpl.Features() {
+		u.context.envStreams.SendSingleItemUpdate(kind, key, u.context.TransformFlag(basictypes.ServerSDK, item))
+	} else {
+		u.context.envStreams.SendSingleItemUpdate(kind, key, item)
+	}
+	u.context.notifyEvaluationSubscribers()
 	if u.context.bigSegmentSync == nil {
 		return
 	}
@@ -750,6 +1273,7 @@ func (u *envContextStreamUpdates) SendSingleItemUpdate(kind ldstoretypes.DataKin
 
 func (u *envContextStreamUpdates) InvalidateClientSideState() {
 	u.context.envStreams.InvalidateClientSideState()
+	u.context.notifyEvaluationSubscribers()
 }
 
 func makeLogPrefix(logNameMode LogNameMode, sdkKey config.SDKKey, envID config.EnvironmentID) string {
@@ -3,6 +3,7 @@ package relayenv
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
@@ -31,6 +32,8 @@ import (
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
@@ -46,7 +49,7 @@ import (
 
 const envName = "envname"
 
-func requireEnvReady(t *testing.T, readyCh <-chan EnvContext) EnvContext {
+func requireEnvReady(t *testing.T, readyCh <-chan ReadyResult) ReadyResult {
 	return helpers.RequireValue(t, readyCh, time.Second, "timed out waiting for environment")
 }
 
@@ -55,7 +58,7 @@ func requireClientReady(t *testing.T, clientCh chan *testclient.FakeLDClient) *t
 }
 
 func makeBasicEnv(t *testing.T, envConfig config.EnvConfig, clientFactory sdks.ClientFactoryFunc,
-	loggers ldlog.Loggers, readyCh chan EnvContext) EnvContext {
+	loggers ldlog.Loggers, readyCh chan ReadyResult) EnvContext {
 	env, err := NewEnvContext(EnvContextImplParams{
 		Identifiers:   EnvIdentifiers{ConfiguredName: envName},
 		EnvConfig:     envConfig,
@@ -70,7 +73,7 @@ func TestConstructorBasicProperties(t *testing.T) {
 	envConfig := st.EnvWithAllCredentials.Config
 	envConfig.TTL = configtypes.NewOptDuration(time.Hour)
 	envConfig.SecureMode = true
-	readyCh := make(chan EnvContext, 1)
+	readyCh := make(chan ReadyResult, 1)
 
 	clientCh := make(chan *testclient.FakeLDClient, 1)
 	clientFactory := testclient.FakeLDClientFactoryWithChannel(true, clientCh)
@@ -93,16 +96,187 @@ func TestConstructorBasicProperties(t *testing.T) {
 	assert.Contains(t, creds, envConfig.MobileKey)
 	assert.Contains(t, creds, envConfig.EnvID)
 
-	assert.Equal(t, env, requireEnvReady(t, readyCh))
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
 	assert.Equal(t, env.GetClient(), requireClientReady(t, clientCh))
 	assert.Nil(t, env.GetInitError())
 
 	assert.NotNil(t, env.GetStore())
+	assert.False(t, env.HasBigSegmentStore())
+}
+
+func TestStartupJitterDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), startupJitterDelay(0))
+	for i := 0; i < 100; i++ {
+		delay := startupJitterDelay(time.Second)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.Less(t, delay, time.Second)
+	}
+}
+
+func TestClientIsNotStartedIfClosedDuringStartupJitter(t *testing.T) {
+	clientCh := make(chan *testclient.FakeLDClient, 1)
+	allConfig := config.Config{}
+	allConfig.Main.StartupJitter = configtypes.NewOptDuration(time.Hour)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:     st.EnvMain.Config,
+		AllConfig:     allConfig,
+		ClientFactory: testclient.FakeLDClientFactoryWithChannel(true, clientCh),
+		Loggers:       mockLog.Loggers,
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.Close())
+
+	helpers.AssertNoMoreValues(t, clientCh, time.Millisecond*100, "client should not have been created")
+	assert.Nil(t, env.GetClient())
+}
+
+func TestInitLimiterLimitsConcurrentClientInitialization(t *testing.T) {
+	limiter := NewInitLimiter(1)
+	startedCh := make(chan config.SDKKey, 2)
+	unblockCh := make(chan struct{})
+	clientFactory := func(sdkKey config.SDKKey, sdkConfig ld.Config, timeout time.Duration) (sdks.LDClientContext, error) {
+		startedCh <- sdkKey
+		<-unblockCh
+		return testclient.FakeLDClientFactory(true)(sdkKey, sdkConfig, timeout)
+	}
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	readyCh := make(chan ReadyResult, 2)
+	for _, e := range []st.TestEnv{st.EnvMain, st.EnvMobile} {
+		env, err := NewEnvContext(EnvContextImplParams{
+			Identifiers:   EnvIdentifiers{ConfiguredName: e.Name},
+			EnvConfig:     e.Config,
+			ClientFactory: clientFactory,
+			Loggers:       mockLog.Loggers,
+			InitLimiter:   limiter,
+		}, readyCh)
+		require.NoError(t, err)
+		defer env.Close()
+	}
+
+	firstKey := helpers.RequireValue(t, startedCh, time.Second, "timed out waiting for first client")
+	helpers.AssertNoMoreValues(t, startedCh, time.Millisecond*100, "second client should not have been started yet")
+
+	unblockCh <- struct{}{}
+	requireEnvReady(t, readyCh)
+	secondKey := helpers.RequireValue(t, startedCh, time.Second, "timed out waiting for second client")
+	assert.NotEqual(t, firstKey, secondKey)
+
+	unblockCh <- struct{}{}
+	requireEnvReady(t, readyCh)
+}
+
+func TestClientIsNotStartedIfClosedWhileWaitingForInitLimiter(t *testing.T) {
+	limiter := NewInitLimiter(1)
+	require.True(t, limiter.tryAcquire()) // simulate another environment that is still initializing
+	clientCh := make(chan *testclient.FakeLDClient, 1)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:     st.EnvMain.Config,
+		ClientFactory: testclient.FakeLDClientFactoryWithChannel(true, clientCh),
+		Loggers:       mockLog.Loggers,
+		InitLimiter:   limiter,
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.Close())
+
+	helpers.AssertNoMoreValues(t, clientCh, time.Millisecond*100, "client should not have been created")
+	assert.Nil(t, env.GetClient())
+}
+
+func TestInitLimiterWithNoLimit(t *testing.T) {
+	assert.Nil(t, NewInitLimiter(0))
+	var limiter *InitLimiter
+	assert.True(t, limiter.tryAcquire())
+	assert.True(t, limiter.acquire(nil))
+	limiter.release()
+}
+
+func TestInitialReconnectDelayIsPassedToDataSource(t *testing.T) {
+	getReconnectTime := func(allConfig config.Config) int {
+		env, err := NewEnvContext(EnvContextImplParams{
+			Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+			EnvConfig:     st.EnvMain.Config,
+			AllConfig:     allConfig,
+			ClientFactory: testclient.FakeLDClientFactory(true),
+			Loggers:       ldlog.NewDisabledLoggers(),
+		}, nil)
+		require.NoError(t, err)
+		defer env.Close()
+		description := env.(*envContextImpl).dataSource.wrappedFactory.(subsystems.DiagnosticDescription).
+			DescribeConfiguration(subsystems.BasicClientContext{})
+		return description.GetByKey("reconnectTimeMillis").IntValue()
+	}
+
+	assert.Equal(t, int(ldcomponents.DefaultInitialReconnectDelay/time.Millisecond), getReconnectTime(config.Config{}))
+
+	allConfig := config.Config{}
+	allConfig.Main.InitialReconnectDelay = configtypes.NewOptDuration(5 * time.Second)
+	assert.Equal(t, 5000, getReconnectTime(allConfig))
+}
+
+func TestEnvironmentProxyOverridesGlobalProxy(t *testing.T) {
+	getProxyURL := func(envConfig config.EnvConfig, allConfig config.Config) string {
+		env, err := NewEnvContext(EnvContextImplParams{
+			Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+			EnvConfig:     envConfig,
+			AllConfig:     allConfig,
+			ClientFactory: testclient.FakeLDClientFactory(true),
+			Loggers:       ldlog.NewDisabledLoggers(),
+		}, nil)
+		require.NoError(t, err)
+		defer env.Close()
+		httpConfig, err := env.(*envContextImpl).sdkConfig.HTTP.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		transport, ok := httpConfig.CreateHTTPClient().Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotNil(t, transport.Proxy)
+		proxyURL, err := transport.Proxy(httptest.NewRequest("GET", "https://sdk.launchdarkly.com", nil))
+		require.NoError(t, err)
+		if proxyURL == nil {
+			return ""
+		}
+		return proxyURL.String()
+	}
+
+	var allConfig config.Config
+	allConfig.Proxy.URL, _ = configtypes.NewOptURLAbsoluteFromString("http://global-proxy:8080")
+
+	assert.Equal(t, "http://global-proxy:8080", getProxyURL(st.EnvMain.Config, allConfig))
+
+	envConfig := st.EnvMain.Config
+	envConfig.ProxyURL, _ = configtypes.NewOptURLAbsoluteFromString("http://env-proxy:8080")
+	assert.Equal(t, "http://env-proxy:8080", getProxyURL(envConfig, allConfig))
+	assert.Equal(t, "http://env-proxy:8080", getProxyURL(envConfig, config.Config{}))
+}
+
+func TestEnvironmentProxyIsValidated(t *testing.T) {
+	envConfig := st.EnvMain.Config
+	envConfig.ProxyURL, _ = configtypes.NewOptURLAbsoluteFromString("http://env-proxy:8080")
+	envConfig.ProxyNTLMAuth = true
+	_, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:     envConfig,
+		ClientFactory: testclient.FakeLDClientFactory(true),
+		Loggers:       ldlog.NewDisabledLoggers(),
+	}, nil)
+	assert.Error(t, err)
 }
 
 func TestConstructorWithOnlySDKKey(t *testing.T) {
 	envConfig := st.EnvMain.Config
-	readyCh := make(chan EnvContext, 1)
+	readyCh := make(chan ReadyResult, 1)
 
 	clientCh := make(chan *testclient.FakeLDClient, 1)
 	clientFactory := testclient.FakeLDClientFactoryWithChannel(true, clientCh)
@@ -115,7 +289,7 @@ func TestConstructorWithOnlySDKKey(t *testing.T) {
 
 	assert.Equal(t, []credential.SDKCredential{envConfig.SDKKey}, env.GetCredentials())
 
-	assert.Equal(t, env, requireEnvReady(t, readyCh))
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
 	assert.Equal(t, env.GetClient(), requireClientReady(t, clientCh))
 	assert.Nil(t, env.GetInitError())
 }
@@ -220,7 +394,7 @@ func TestAddExistingCredentialDoesNothing(t *testing.T) {
 
 func TestChangeSDKKey(t *testing.T) {
 	envConfig := st.EnvMain.Config
-	readyCh := make(chan EnvContext, 1)
+	readyCh := make(chan ReadyResult, 1)
 	newKey := config.SDKKey("new-key")
 
 	clientCh := make(chan *testclient.FakeLDClient, 1)
@@ -232,7 +406,7 @@ func TestChangeSDKKey(t *testing.T) {
 	env := makeBasicEnv(t, envConfig, clientFactory, mockLog.Loggers, readyCh)
 	defer env.Close()
 
-	assert.Equal(t, env, requireEnvReady(t, readyCh))
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
 	client1 := requireClientReady(t, clientCh)
 	assert.Equal(t, env.GetClient(), client1)
 	assert.Nil(t, env.GetInitError())
@@ -261,7 +435,7 @@ func TestSDKClientCreationFails(t *testing.T) {
 	envConfig := st.EnvWithAllCredentials.Config
 	envConfig.TTL = configtypes.NewOptDuration(time.Hour)
 	envConfig.SecureMode = true
-	readyCh := make(chan EnvContext, 1)
+	readyCh := make(chan ReadyResult, 1)
 
 	fakeError := errors.New("sorry")
 
@@ -271,11 +445,196 @@ func TestSDKClientCreationFails(t *testing.T) {
 	env := makeBasicEnv(t, envConfig, testclient.ClientFactoryThatFails(fakeError), mockLog.Loggers, readyCh)
 	defer env.Close()
 
-	assert.Equal(t, env, requireEnvReady(t, readyCh))
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
 	assert.Equal(t, fakeError, env.GetInitError())
 	assert.Nil(t, env.GetStore())
 }
 
+func TestReadyResultDescribesInitOutcome(t *testing.T) {
+	for _, p := range []struct {
+		name            string
+		clientFactory   sdks.ClientFactoryFunc
+		expectedOutcome InitOutcome
+		expectedErr     error
+	}{
+		{"succeeded", testclient.FakeLDClientFactory(true), InitSucceeded, nil},
+		{"timed out", testclient.ClientFactoryThatFails(ld.ErrInitializationTimeout), InitTimedOut, ld.ErrInitializationTimeout},
+		{"failed", testclient.ClientFactoryThatFails(ld.ErrInitializationFailed), InitFailed, ld.ErrInitializationFailed},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			readyCh := make(chan ReadyResult, 1)
+			env := makeBasicEnv(t, st.EnvMain.Config, p.clientFactory, ldlog.NewDisabledLoggers(), readyCh)
+			defer env.Close()
+
+			result := requireEnvReady(t, readyCh)
+			assert.Equal(t, env, result.Env)
+			assert.Equal(t, envName, result.Name)
+			assert.Equal(t, p.expectedOutcome, result.Outcome)
+			assert.Equal(t, p.expectedErr, result.Err)
+		})
+	}
+}
+
+func TestInitErrorIsUpdatedFromDataSourceStatusAfterFailedInit(t *testing.T) {
+	for _, p := range []struct {
+		name          string
+		newState      interfaces.DataSourceState
+		expectedError error
+	}{
+		{"recovered", interfaces.DataSourceStateValid, nil},
+		{"permanently failed", interfaces.DataSourceStateOff, ld.ErrInitializationFailed},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			createdCh := make(chan *testclient.FakeLDClient, 1)
+			factory := func(sdkKey config.SDKKey, sdkConfig ld.Config, timeout time.Duration) (sdks.LDClientContext, error) {
+				client, _ := testclient.FakeLDClientFactoryWithChannel(false, createdCh)(sdkKey, sdkConfig, timeout)
+				return client, ld.ErrInitializationTimeout
+			}
+			readyCh := make(chan ReadyResult, 1)
+			env := makeBasicEnv(t, st.EnvMain.Config, factory, ldlog.NewDisabledLoggers(), readyCh)
+			defer env.Close()
+
+			requireEnvReady(t, readyCh)
+			client := helpers.RequireValue(t, createdCh, time.Second)
+			assert.Equal(t, ld.ErrInitializationTimeout, env.GetInitError())
+
+			client.SetDataSourceStatus(interfaces.DataSourceStatus{State: interfaces.DataSourceStateInterrupted})
+			time.Sleep(time.Millisecond * 20)
+			assert.Equal(t, ld.ErrInitializationTimeout, env.GetInitError())
+
+			client.SetDataSourceStatus(interfaces.DataSourceStatus{State: p.newState})
+			assert.Eventually(t, func() bool { return env.GetInitError() == p.expectedError },
+				time.Second, time.Millisecond*10)
+		})
+	}
+}
+
+func TestReadyResultIncludesInitDuration(t *testing.T) {
+	delay := time.Millisecond * 20
+	slowFactory := func(sdkKey config.SDKKey, sdkConfig ld.Config, timeout time.Duration) (sdks.LDClientContext, error) {
+		time.Sleep(delay)
+		return testclient.FakeLDClientFactory(true)(sdkKey, sdkConfig, timeout)
+	}
+	readyCh := make(chan ReadyResult, 1)
+	env := makeBasicEnv(t, st.EnvMain.Config, slowFactory, ldlog.NewDisabledLoggers(), readyCh)
+	defer env.Close()
+
+	result := requireEnvReady(t, readyCh)
+	assert.GreaterOrEqual(t, result.InitDuration, delay)
+	assert.Equal(t, result.InitDuration, env.GetInitDuration())
+}
+
+func TestStoreVerificationSucceedsWhenStoreIsInitialized(t *testing.T) {
+	var allConfig config.Config
+	allConfig.Main.VerifyStoreOnInit = true
+	allConfig.Main.VerifyStoreMinFlags, _ = configtypes.NewOptIntGreaterThanZero(1)
+	readyCh := make(chan ReadyResult, 1)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:      EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:        st.EnvMain.Config,
+		AllConfig:        allConfig,
+		ClientFactory:    testclient.FakeLDClientFactory(true),
+		DataStoreFactory: st.ExistingInstance(st.MakeStoreWithData(true)),
+		Loggers:          mockLog.Loggers,
+	}, readyCh)
+	require.NoError(t, err)
+	defer env.Close()
+
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
+	assert.Nil(t, env.GetInitError())
+}
+
+func TestStoreVerificationFailsIfStoreIsNotInitialized(t *testing.T) {
+	var allConfig config.Config
+	allConfig.Main.VerifyStoreOnInit = true
+	allConfig.Main.InitTimeout = configtypes.NewOptDuration(time.Millisecond * 10)
+	readyCh := make(chan ReadyResult, 1)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:      EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:        st.EnvMain.Config,
+		AllConfig:        allConfig,
+		ClientFactory:    testclient.FakeLDClientFactory(true),
+		DataStoreFactory: st.ExistingInstance(st.MakeStoreWithData(false)),
+		Loggers:          mockLog.Loggers,
+	}, readyCh)
+	require.NoError(t, err)
+	defer env.Close()
+
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
+	assert.Equal(t, errStoreNotVerified("store is not initialized"), env.GetInitError())
+}
+
+func TestStoreVerificationFailsIfStoreHasTooFewFlags(t *testing.T) {
+	var allConfig config.Config
+	allConfig.Main.VerifyStoreOnInit = true
+	allConfig.Main.VerifyStoreMinFlags, _ = configtypes.NewOptIntGreaterThanZero(1000)
+	allConfig.Main.InitTimeout = configtypes.NewOptDuration(time.Millisecond * 10)
+	readyCh := make(chan ReadyResult, 1)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	store := st.MakeStoreWithData(true)
+	flags, err := store.GetAll(ldstoreimpl.Features())
+	require.NoError(t, err)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:      EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:        st.EnvMain.Config,
+		AllConfig:        allConfig,
+		ClientFactory:    testclient.FakeLDClientFactory(true),
+		DataStoreFactory: st.ExistingInstance(store),
+		Loggers:          mockLog.Loggers,
+	}, readyCh)
+	require.NoError(t, err)
+	defer env.Close()
+
+	assert.Equal(t, env, requireEnvReady(t, readyCh).Env)
+	assert.Equal(t, errStoreNotVerified(fmt.Sprintf("store contains %d flags, expected at least 1000", len(flags))),
+		env.GetInitError())
+}
+
+func TestStoreVerificationFailureIsNotClearedByDataSourceStatus(t *testing.T) {
+	var allConfig config.Config
+	allConfig.Main.VerifyStoreOnInit = true
+	allConfig.Main.InitTimeout = configtypes.NewOptDuration(time.Millisecond * 10)
+	readyCh := make(chan ReadyResult, 1)
+	createdCh := make(chan *testclient.FakeLDClient, 1)
+
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	env, err := NewEnvContext(EnvContextImplParams{
+		Identifiers:      EnvIdentifiers{ConfiguredName: envName},
+		EnvConfig:        st.EnvMain.Config,
+		AllConfig:        allConfig,
+		ClientFactory:    testclient.FakeLDClientFactoryWithChannel(true, createdCh),
+		DataStoreFactory: st.ExistingInstance(st.MakeStoreWithData(false)),
+		Loggers:          mockLog.Loggers,
+	}, readyCh)
+	require.NoError(t, err)
+	defer env.Close()
+
+	requireEnvReady(t, readyCh)
+	client := helpers.RequireValue(t, createdCh, time.Second)
+	expectedErr := errStoreNotVerified("store is not initialized")
+	assert.Equal(t, expectedErr, env.GetInitError())
+
+	// The SDK's data source is working, but that doesn't mean the store has been verified
+	client.SetDataSourceStatus(interfaces.DataSourceStatus{State: interfaces.DataSourceStateValid})
+	time.Sleep(time.Millisecond * 20)
+	assert.Equal(t, expectedErr, env.GetInitError())
+	mockLog.AssertMessageMatch(t, false, ldlog.Info, "has initialized after an earlier error")
+}
+
 func TestDisplayName(t *testing.T) {
 	ei1 := EnvIdentifiers{ProjName: "a", EnvName: "b", ConfiguredName: "thing"}
 	assert.Equal(t, "thing", ei1.GetDisplayName())
@@ -297,7 +656,7 @@ func TestMetricsAreExportedForEnvironment(t *testing.T) {
 	httphelpers.WithServer(handler, func(server *httptest.Server) {
 		var allConfig config.Config
 		allConfig.Events.EventsURI, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
-		metricsManager, err := metrics.NewManager(config.MetricsConfig{}, time.Minute, mockLog.Loggers)
+		metricsManager, err := metrics.NewManager(config.MetricsConfig{}, "", time.Minute, mockLog.Loggers)
 		require.NoError(t, err)
 		env, err := NewEnvContext(EnvContextImplParams{
 			Identifiers:    EnvIdentifiers{ConfiguredName: envName},
@@ -351,7 +710,7 @@ func testMetricsDisabled(t *testing.T, allConfig config.Config) {
 	handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
 	httphelpers.WithServer(handler, func(server *httptest.Server) {
 		allConfig.Events.EventsURI, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
-		metricsManager, err := metrics.NewManager(config.MetricsConfig{}, time.Minute, mockLog.Loggers)
+		metricsManager, err := metrics.NewManager(config.MetricsConfig{}, "", time.Minute, mockLog.Loggers)
 		require.NoError(t, err)
 		env, err := NewEnvContext(EnvContextImplParams{
 			Identifiers:    EnvIdentifiers{ConfiguredName: envName},
@@ -422,6 +781,44 @@ func TestEventDispatcherIsCreatedIfSendEventsIsTrueAndNotInOfflineMode(t *testin
 	})
 }
 
+func TestEventDispatcherUsesEnvironmentFlushInterval(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	defer mockLog.DumpIfTestFailed(t)
+
+	eventRecorderHandler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+	httphelpers.WithServer(eventRecorderHandler, func(server *httptest.Server) {
+		var allConfig config.Config
+		allConfig.Events.SendEvents = true
+		allConfig.Events.EventsURI, _ = configtypes.NewOptURLAbsoluteFromString(server.URL)
+		allConfig.Events.FlushInterval = configtypes.NewOptDuration(time.Hour)
+		envConfig := st.EnvMain.Config
+		envConfig.EventsFlushInterval = configtypes.NewOptDuration(time.Millisecond * 10)
+		env, err := NewEnvContext(EnvContextImplParams{
+			Identifiers:   EnvIdentifiers{ConfiguredName: envName},
+			EnvConfig:     envConfig,
+			AllConfig:     allConfig,
+			ClientFactory: testclient.FakeLDClientFactory(true),
+			Loggers:       mockLog.Loggers,
+		}, nil)
+		require.NoError(t, err)
+		defer env.Close()
+
+		eventDispatchHandler := env.GetEventDispatcher().GetHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind)
+		require.NotNil(t, eventDispatchHandler)
+
+		headers := make(http.Header)
+		headers.Set("Content-Type", "application/json")
+		headers.Set("Authorization", string(st.EnvMain.Config.SDKKey))
+		headers.Set("X-LaunchDarkly-Event-Schema", strconv.Itoa(events.SummaryEventsSchemaVersion))
+		body := `[{"kind":"identify","creationDate":1000,"key":"userkey","user":{"key":"userkey"}}]`
+		eventDispatchHandler(httptest.NewRecorder(), st.BuildRequest("POST", server.URL+"/bulk", []byte(body), headers))
+
+		// With the global interval of one hour, the event would not have been flushed yet.
+		eventPost := helpers.RequireValue(t, requestsCh, time.Second)
+		require.Equal(t, string(body), string(eventPost.Body))
+	})
+}
+
 func TestEventDispatcherIsNotCreatedIfSendEventsIsTrueAndNotInOfflineMode(t *testing.T) {
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
@@ -482,6 +879,7 @@ func TestBigSegmentsSynchronizerIsCreatedIfBigSegmentStoreExists(t *testing.T) {
 		// We shouldn't expose the store until some big segments exist, so that Relay doesn't report
 		// misleading big segments status info in its status resource.
 		assert.Nil(t, env.GetBigSegmentStore())
+		assert.True(t, env.HasBigSegmentStore())
 	}
 
 	env.Close()
@@ -553,6 +951,7 @@ func TestBigSegmentsSynchronizerIsStartedByFullDataUpdateWithBigSegment(t *testi
 	// Now we should expose the big segment store so that Relay can include big segment status information
 	// in its status resource.
 	assert.NotNil(t, env.GetBigSegmentStore())
+	assert.True(t, env.HasBigSegmentStore())
 }
 
 func TestBigSegmentsSynchronizerIsStartedBySingleItemUpdateWithBigSegment(t *testing.T) {
@@ -617,8 +1016,8 @@ func TestReceivingBigSegmentsUpdateCausesClientSideInvalidationEvent(t *testing.
 	mockLog := ldlogtest.NewMockLog()
 	defer mockLog.DumpIfTestFailed(t)
 
-	jsClientStreams := streams.NewStreamProvider(basictypes.JSClientPingStream, time.Hour)
-	sdkStartedCh := make(chan EnvContext)
+	jsClientStreams := streams.NewStreamProvider(basictypes.JSClientPingStream, time.Hour, 0, 0, 0)
+	sdkStartedCh := make(chan ReadyResult)
 	env, err := NewEnvContext(EnvContextImplParams{
 		Identifiers:                   EnvIdentifiers{ConfiguredName: st.EnvMain.Name},
 		EnvConfig:                     envConfig,
@@ -681,6 +1080,7 @@ func (f *mockBigSegmentSynchronizerFactory) create(
 	streamURI string,
 	envID config.EnvironmentID,
 	sdkKey config.SDKKey,
+	maxPatchesPerSecond int,
 	loggers ldlog.Loggers,
 	logPrefix string,
 ) bigsegments.BigSegmentSynchronizer {
@@ -0,0 +1,42 @@
+package relayenv
+
+import (
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+)
+
+// FlagCountLimit is the number of flags that an environment is expected to stay within, as configured by
+// MainConfig.MaxFlagCount and MainConfig.EnforceMaxFlagCount.
+type FlagCountLimit struct {
+	// Max is the maximum number of flags, or zero if there is no limit.
+	Max int
+	// Enforce is true if client-side evaluation endpoints should refuse to return more than Max flags,
+	// rather than only reporting in the status resource that the limit has been exceeded.
+	Enforce bool
+}
+
+// Exceeded returns true if there is a limit and count is greater than it.
+func (l FlagCountLimit) Exceeded(count int) bool {
+	return l.Max > 0 && count > l.Max
+}
+
+func (c *envContextImpl) GetFlagCountLimit() FlagCountLimit {
+	return c.flagCountLimit
+}
+
+func (c *envContextImpl) GetFlagCount() int {
+	store := c.GetStore()
+	if store == nil {
+		return 0
+	}
+	items, err := store.GetAll(ldstoreimpl.Features())
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, item := range items {
+		if item.Item.Item != nil {
+			count++
+		}
+	}
+	return count
+}
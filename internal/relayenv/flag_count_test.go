@@ -0,0 +1,15 @@
+package relayenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagCountLimitExceeded(t *testing.T) {
+	assert.False(t, FlagCountLimit{}.Exceeded(1000000))
+	assert.False(t, FlagCountLimit{Max: 10}.Exceeded(9))
+	assert.False(t, FlagCountLimit{Max: 10}.Exceeded(10))
+	assert.True(t, FlagCountLimit{Max: 10}.Exceeded(11))
+	assert.True(t, FlagCountLimit{Max: 10, Enforce: true}.Exceeded(11))
+}
@@ -0,0 +1,78 @@
+package relayenv
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// flagHistory keeps the most recent versions of each flag that an environment has received, so that a
+// context can be evaluated against an older flag definition for debugging. It is kept in memory only.
+//
+// For each flag key, up to depth previous versions are kept in addition to the current one. Versions
+// are only recorded if they are newer than the latest one already recorded, so a full data set that
+// repeats the same versions does not push older versions out. A deleted flag's history is kept, since
+// it may still be of interest after the deletion.
+type flagHistory struct {
+	depth    int
+	versions map[string][]*ldmodel.FeatureFlag // oldest first
+	lock     sync.RWMutex
+}
+
+func newFlagHistory(depth int) *flagHistory {
+	return &flagHistory{depth: depth, versions: make(map[string][]*ldmodel.FeatureFlag)}
+}
+
+func (h *flagHistory) recordAll(allData []ldstoretypes.Collection) {
+	for _, coll := range allData {
+		if coll.Kind == ldstoreimpl.Features() {
+			for _, item := range coll.Items {
+				h.record(item.Item)
+			}
+		}
+	}
+}
+
+func (h *flagHistory) record(item ldstoretypes.ItemDescriptor) {
+	flag, ok := item.Item.(*ldmodel.FeatureFlag)
+	if !ok || flag == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	versions := h.versions[flag.Key]
+	if len(versions) > 0 && versions[len(versions)-1].Version >= flag.Version {
+		return
+	}
+	versions = append(versions, flag)
+	if len(versions) > h.depth+1 {
+		versions = append([]*ldmodel.FeatureFlag(nil), versions[len(versions)-h.depth-1:]...)
+	}
+	h.versions[flag.Key] = versions
+}
+
+func (h *flagHistory) get(key string, version int) *ldmodel.FeatureFlag {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	for _, flag := range h.versions[key] {
+		if flag.Version == version {
+			return flag
+		}
+	}
+	return nil
+}
+
+func (c *envContextImpl) GetFlagVersion(key string, version int) *ldmodel.FeatureFlag {
+	if store := c.GetStore(); store != nil {
+		item, err := store.Get(ldstoreimpl.Features(), key)
+		if flag, ok := item.Item.(*ldmodel.FeatureFlag); err == nil && ok && flag.Version == version {
+			return flag
+		}
+	}
+	if c.flagHistory != nil {
+		return c.flagHistory.get(key, version)
+	}
+	return nil
+}
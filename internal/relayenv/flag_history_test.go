@@ -0,0 +1,67 @@
+package relayenv
+
+import (
+	"testing"
+
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagHistory(t *testing.T) {
+	flagVersion := func(key string, version int) ldstoretypes.ItemDescriptor {
+		return st.FlagDesc(ldbuilders.NewFlagBuilder(key).Version(version).Build())
+	}
+	versionsOf := func(h *flagHistory, key string) []int {
+		var ret []int
+		for v := 1; v <= 10; v++ {
+			if flag := h.get(key, v); flag != nil {
+				assert.Equal(t, v, flag.Version)
+				ret = append(ret, v)
+			}
+		}
+		return ret
+	}
+
+	t.Run("keeps current version and up to depth previous versions", func(t *testing.T) {
+		h := newFlagHistory(2)
+		for v := 1; v <= 5; v++ {
+			h.record(flagVersion("a", v))
+		}
+		assert.Equal(t, []int{3, 4, 5}, versionsOf(h, "a"))
+	})
+
+	t.Run("ignores versions that are not newer", func(t *testing.T) {
+		h := newFlagHistory(2)
+		h.record(flagVersion("a", 2))
+		h.record(flagVersion("a", 1))
+		h.record(flagVersion("a", 2))
+		assert.Equal(t, []int{2}, versionsOf(h, "a"))
+	})
+
+	t.Run("ignores deleted items", func(t *testing.T) {
+		h := newFlagHistory(2)
+		h.record(flagVersion("a", 1))
+		h.record(ldstoretypes.ItemDescriptor{Version: 2})
+		assert.Equal(t, []int{1}, versionsOf(h, "a"))
+	})
+
+	t.Run("records flags from full data set", func(t *testing.T) {
+		h := newFlagHistory(1)
+		h.recordAll([]ldstoretypes.Collection{
+			{Kind: ldstoreimpl.Features(), Items: []ldstoretypes.KeyedItemDescriptor{
+				{Key: "a", Item: flagVersion("a", 1)},
+				{Key: "b", Item: flagVersion("b", 3)},
+			}},
+			{Kind: ldstoreimpl.Segments(), Items: []ldstoretypes.KeyedItemDescriptor{
+				{Key: "a", Item: st.SegmentDesc(ldbuilders.NewSegmentBuilder("a").Version(5).Build())},
+			}},
+		})
+		assert.Equal(t, []int{1}, versionsOf(h, "a"))
+		assert.Equal(t, []int{3}, versionsOf(h, "b"))
+	})
+}
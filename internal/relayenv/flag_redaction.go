@@ -0,0 +1,52 @@
+package relayenv
+
+import (
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// redactedFlagKeys is the set of flags whose values are hidden from client-side and mobile SDKs, as
+// configured by EnvConfig.RedactedFlags. Server-side SDKs always see the real values.
+type redactedFlagKeys map[string]struct{}
+
+func newRedactedFlagKeys(keys []string) redactedFlagKeys {
+	if len(keys) == 0 {
+		return nil
+	}
+	ret := make(redactedFlagKeys, len(keys))
+	for _, key := range keys {
+		ret[key] = struct{}{}
+	}
+	return ret
+}
+
+func (r redactedFlagKeys) appliesTo(sdkKind basictypes.SDKKind) bool {
+	return len(r) != 0 && sdkKind != basictypes.ServerSDK
+}
+
+// redactFlagItem returns a copy of the flag in which every variation value is null, if the flag is
+// redacted for this kind of SDK. Everything else about the flag is unchanged, so it evaluates to the same
+// variation index as the original.
+func (r redactedFlagKeys) redactFlagItem(sdkKind basictypes.SDKKind, item ldstoretypes.ItemDescriptor) ldstoretypes.ItemDescriptor {
+	flag, ok := item.Item.(*ldmodel.FeatureFlag)
+	if !ok || flag == nil || !r.appliesTo(sdkKind) {
+		return item
+	}
+	if _, redacted := r[flag.Key]; !redacted {
+		return item
+	}
+	copied := *flag
+	copied.Variations = make([]ldvalue.Value, len(flag.Variations))
+	return ldstoretypes.ItemDescriptor{Version: item.Version, Item: &copied}
+}
+
+func (c *envContextImpl) IsFlagRedacted(sdkKind basictypes.SDKKind, flagKey string) bool {
+	if !c.redactedFlags.appliesTo(sdkKind) {
+		return false
+	}
+	_, redacted := c.redactedFlags[flagKey]
+	return redacted
+}
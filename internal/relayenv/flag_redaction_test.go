@@ -0,0 +1,51 @@
+package relayenv
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactedFlagKeys(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("secret-flag").Version(3).
+		Variations(ldvalue.String("a"), ldvalue.String("b")).OffVariation(1).Build()
+	item := st.FlagDesc(flag)
+
+	t.Run("no redacted flags", func(t *testing.T) {
+		r := newRedactedFlagKeys(nil)
+		assert.False(t, r.appliesTo(basictypes.JSClientSDK))
+		assert.Equal(t, item, r.redactFlagItem(basictypes.JSClientSDK, item))
+	})
+
+	t.Run("redacted for client-side and mobile SDKs", func(t *testing.T) {
+		r := newRedactedFlagKeys([]string{flag.Key})
+		for _, sdkKind := range []basictypes.SDKKind{basictypes.JSClientSDK, basictypes.MobileSDK} {
+			redacted := r.redactFlagItem(sdkKind, item)
+			assert.Equal(t, item.Version, redacted.Version)
+			redactedFlag := redacted.Item.(*ldmodel.FeatureFlag)
+			assert.Equal(t, []ldvalue.Value{ldvalue.Null(), ldvalue.Null()}, redactedFlag.Variations)
+			assert.Equal(t, flag.OffVariation, redactedFlag.OffVariation)
+		}
+		assert.Equal(t, []ldvalue.Value{ldvalue.String("a"), ldvalue.String("b")}, flag.Variations) // original is unchanged
+	})
+
+	t.Run("not redacted for server-side SDKs", func(t *testing.T) {
+		r := newRedactedFlagKeys([]string{flag.Key})
+		assert.Equal(t, item, r.redactFlagItem(basictypes.ServerSDK, item))
+	})
+
+	t.Run("other flags and deleted items are unchanged", func(t *testing.T) {
+		r := newRedactedFlagKeys([]string{"other-flag"})
+		assert.Equal(t, item, r.redactFlagItem(basictypes.JSClientSDK, item))
+		deleted := ldstoretypes.ItemDescriptor{Version: 4}
+		assert.Equal(t, deleted, r.redactFlagItem(basictypes.JSClientSDK, deleted))
+	})
+}
@@ -0,0 +1,143 @@
+package relayenv
+
+import (
+	"sync/atomic"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// FlagTransformFunc is a function that can modify flags before Relay serves them to SDKs. It receives the
+// kind of SDK and the identifiers of the environment the flag belongs to. It returns the flag to serve
+// instead, which must be a new object if it differs from the original, or nil to omit the flag. It must not
+// modify the original flag, since that is shared with the SDK client's data store.
+type FlagTransformFunc func(sdkKind basictypes.SDKKind, env EnvIdentifiers, flag *ldmodel.FeatureFlag) *ldmodel.FeatureFlag
+
+// FlagTransformer holds the FlagTransformFunc, if any, that is currently in effect. A single instance is
+// shared by all of Relay's environments, so that changing it affects all of them at once. A nil
+// *FlagTransformer behaves like one that has no function.
+type FlagTransformer struct {
+	state atomic.Pointer[flagTransformState]
+}
+
+// flagTransformState pairs a transform function with a generation number that is incremented every time
+// the function is changed, so that cached responses can be invalidated.
+type flagTransformState struct {
+	fn         FlagTransformFunc
+	generation int64
+}
+
+// Set changes the current transform function. Passing nil turns off transformation.
+func (t *FlagTransformer) Set(fn FlagTransformFunc) {
+	for {
+		old := t.state.Load()
+		next := &flagTransformState{fn: fn, generation: 1}
+		if old != nil {
+			next.generation = old.generation + 1
+		}
+		if t.state.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (t *FlagTransformer) get() FlagTransformFunc {
+	if t == nil {
+		return nil
+	}
+	if s := t.state.Load(); s != nil {
+		return s.fn
+	}
+	return nil
+}
+
+// generation returns a number that changes whenever the transform function changes. It is zero if a
+// function has never been set.
+func (t *FlagTransformer) generation() int64 {
+	if t == nil {
+		return 0
+	}
+	if s := t.state.Load(); s != nil {
+		return s.generation
+	}
+	return 0
+}
+
+// transformFlagItem applies fn to a single flag. An omitted flag is represented by a deleted item
+// placeholder with the same version, so that it is treated like any other deleted flag.
+func transformFlagItem(
+	fn FlagTransformFunc,
+	sdkKind basictypes.SDKKind,
+	env EnvIdentifiers,
+	item ldstoretypes.ItemDescriptor,
+) ldstoretypes.ItemDescriptor {
+	flag, ok := item.Item.(*ldmodel.FeatureFlag)
+	if !ok {
+		return item
+	}
+	if transformed := fn(sdkKind, env, flag); transformed != nil {
+		return ldstoretypes.ItemDescriptor{Version: item.Version, Item: transformed}
+	}
+	return ldstoretypes.ItemDescriptor{Version: item.Version}
+}
+
+func (c *envContextImpl) GetFlagTransformGeneration() int64 {
+	return c.flagTransformer.generation()
+}
+
+func (c *envContextImpl) TransformFlag(sdkKind basictypes.SDKKind, item ldstoretypes.ItemDescriptor) ldstoretypes.ItemDescriptor {
+	if fn := c.flagTransformer.get(); fn != nil {
+		item = transformFlagItem(fn, sdkKind, c.GetIdentifiers(), item)
+	}
+	return c.redactedFlags.redactFlagItem(sdkKind, item)
+}
+
+func (c *envContextImpl) TransformFlags(
+	sdkKind basictypes.SDKKind,
+	items []ldstoretypes.KeyedItemDescriptor,
+) []ldstoretypes.KeyedItemDescriptor {
+	fn := c.flagTransformer.get()
+	redact := c.redactedFlags.appliesTo(sdkKind)
+	if fn == nil && !redact {
+		return items
+	}
+	env := c.GetIdentifiers()
+	ret := make([]ldstoretypes.KeyedItemDescriptor, 0, len(items))
+	for _, item := range items {
+		transformed := item.Item
+		if fn != nil {
+			transformed = transformFlagItem(fn, sdkKind, env, transformed)
+		}
+		if redact {
+			transformed = c.redactedFlags.redactFlagItem(sdkKind, transformed)
+		}
+		ret = append(ret, ldstoretypes.KeyedItemDescriptor{Key: item.Key, Item: transformed})
+	}
+	return ret
+}
+
+// transformServerSideData applies the flag transform to a full data set that is about to be sent to
+// server-side SDK streams. Unlike TransformFlags, flags that the transform omits are dropped, rather than
+// being turned into deleted items.
+func (c *envContextImpl) transformServerSideData(allData []ldstoretypes.Collection) []ldstoretypes.Collection {
+	if c.flagTransformer.get() == nil {
+		return allData
+	}
+	ret := make([]ldstoretypes.Collection, 0, len(allData))
+	for _, coll := range allData {
+		if coll.Kind == ldstoreimpl.Features() {
+			var items []ldstoretypes.KeyedItemDescriptor
+			for i, item := range c.TransformFlags(basictypes.ServerSDK, coll.Items) {
+				if item.Item.Item != nil || coll.Items[i].Item.Item == nil {
+					items = append(items, item)
+				}
+			}
+			coll = ldstoretypes.Collection{Kind: coll.Kind, Items: items}
+		}
+		ret = append(ret, coll)
+	}
+	return ret
+}
@@ -0,0 +1,50 @@
+package relayenv
+
+// InitLimiter limits how many environments can be initializing their SDK clients at the same time. It is
+// shared by all of the environments in a Relay instance. A nil *InitLimiter means there is no limit.
+type InitLimiter struct {
+	slots chan struct{}
+}
+
+// NewInitLimiter creates an InitLimiter that allows at most max environments to initialize at once. If
+// max is zero or negative, it returns nil, meaning there is no limit.
+func NewInitLimiter(max int) *InitLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &InitLimiter{slots: make(chan struct{}, max)}
+}
+
+// tryAcquire reserves a slot without waiting, returning false if none are available.
+func (l *InitLimiter) tryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquire waits until a slot is available and reserves it. It returns false without reserving a slot
+// if closeCh is closed first.
+func (l *InitLimiter) acquire(closeCh <-chan struct{}) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-closeCh:
+		return false
+	}
+}
+
+// release frees a slot that was reserved by acquire or tryAcquire.
+func (l *InitLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
@@ -11,6 +11,10 @@ type JSClientContext struct {
 	// Headers is the configured list of allowed headers for CORS requests.
 	Headers []string
 
+	// Methods is the configured list of allowed methods for CORS requests. If empty, the allowed
+	// methods are the ones that are routed for the request path.
+	Methods []string
+
 	// Proxy is a ReverseProxy that we create for requests that are to be directly proxied to a
 	// LaunchDarkly endpoint. Despite its name, the Relay Proxy does not normally use direct
 	// proxying, but in the case of the goals resource for JS clients it is the simplest way.
@@ -26,3 +30,8 @@ func (c JSClientContext) AllowedOrigins() []string {
 func (c JSClientContext) AllowedHeaders() []string {
 	return c.Headers
 }
+
+// AllowedMethods implements the internal interface for getting CORS allowed methods.
+func (c JSClientContext) AllowedMethods() []string {
+	return c.Methods
+}
@@ -0,0 +1,184 @@
+package relayenv
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+)
+
+// pausableDataSourceFactory wraps the SDK's data source configuration so that Relay can stop and
+// restart the data source without recreating the SDK client. This is how maintenance mode works: the
+// SDK client and its data store stay in place, but no connection to LaunchDarkly is attempted.
+//
+// The SDK may build more than one data source from the same configuration, if an SDK key change
+// causes a new client to be created; the factory keeps track of all of them.
+type pausableDataSourceFactory struct {
+	wrappedFactory subsystems.ComponentConfigurer[subsystems.DataSource]
+	dataSources    []*pausableDataSource
+	paused         bool
+	lock           sync.Mutex
+}
+
+type pausableDataSource struct {
+	wrappedFactory subsystems.ComponentConfigurer[subsystems.DataSource]
+	clientContext  subsystems.ClientContext
+	current        subsystems.DataSource
+	wasInitialized bool
+	closed         bool
+	lock           sync.Mutex
+}
+
+func newPausableDataSourceFactory(
+	wrappedFactory subsystems.ComponentConfigurer[subsystems.DataSource],
+	paused bool,
+) *pausableDataSourceFactory {
+	return &pausableDataSourceFactory{wrappedFactory: wrappedFactory, paused: paused}
+}
+
+func (f *pausableDataSourceFactory) Build(clientContext subsystems.ClientContext) (subsystems.DataSource, error) {
+	ds := &pausableDataSource{wrappedFactory: f.wrappedFactory, clientContext: clientContext}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.paused {
+		current, err := f.wrappedFactory.Build(clientContext)
+		if err != nil {
+			return nil, err
+		}
+		ds.current = current
+	}
+	f.dataSources = append(f.dataSources, ds)
+	return ds, nil
+}
+
+// setPaused stops or restarts all of the data sources that have been built by this factory. When
+// paused is false, each data source is rebuilt from the original configuration and started again.
+func (f *pausableDataSourceFactory) setPaused(paused bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if paused == f.paused {
+		return
+	}
+	f.paused = paused
+	retained := f.dataSources[:0]
+	for _, ds := range f.dataSources {
+		if ds.setPaused(paused) {
+			retained = append(retained, ds)
+		}
+	}
+	f.dataSources = retained
+}
+
+// restart closes all of the data sources that have been built by this factory and starts new ones in
+// their place. The returned channel is closed once all of the new data sources are ready, or
+// immediately if there is nothing to restart because the data sources are paused.
+func (f *pausableDataSourceFactory) restart() <-chan struct{} {
+	done := make(chan struct{})
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.paused {
+		close(done)
+		return done
+	}
+	var readyChannels []chan struct{}
+	retained := f.dataSources[:0]
+	for _, ds := range f.dataSources {
+		readyCh := make(chan struct{})
+		if ds.restart(readyCh) {
+			retained = append(retained, ds)
+			readyChannels = append(readyChannels, readyCh)
+		}
+	}
+	f.dataSources = retained
+	go func() {
+		for _, ch := range readyChannels {
+			<-ch
+		}
+		close(done)
+	}()
+	return done
+}
+
+func (f *pausableDataSourceFactory) isPaused() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.paused
+}
+
+// setPaused returns false if the data source has already been closed and can be forgotten.
+func (d *pausableDataSource) setPaused(paused bool) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return false
+	}
+	if paused {
+		if d.current != nil {
+			d.wasInitialized = d.wasInitialized || d.current.IsInitialized()
+			_ = d.current.Close()
+			d.current = nil
+		}
+		return true
+	}
+	if d.current == nil {
+		d.startNew(make(chan struct{}))
+	}
+	return true
+}
+
+// restart replaces the current data source with a new one; closeWhenReady is closed when the new one is
+// ready. This returns false, without closing closeWhenReady, if the data source has already been closed.
+func (d *pausableDataSource) restart(closeWhenReady chan struct{}) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.closed {
+		return false
+	}
+	if d.current != nil {
+		d.wasInitialized = d.wasInitialized || d.current.IsInitialized()
+		_ = d.current.Close()
+		d.current = nil
+	}
+	d.startNew(closeWhenReady)
+	return true
+}
+
+// startNew must be called with the lock held.
+func (d *pausableDataSource) startNew(closeWhenReady chan<- struct{}) {
+	current, err := d.wrappedFactory.Build(d.clientContext)
+	if err != nil {
+		d.clientContext.GetLogging().Loggers.Errorf("Unable to restart data source: %s", err)
+		close(closeWhenReady)
+		return
+	}
+	d.current = current
+	d.current.Start(closeWhenReady)
+}
+
+func (d *pausableDataSource) IsInitialized() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.wasInitialized || (d.current != nil && d.current.IsInitialized())
+}
+
+func (d *pausableDataSource) Start(closeWhenReady chan<- struct{}) {
+	d.lock.Lock()
+	current := d.current
+	d.lock.Unlock()
+	if current == nil {
+		// We're paused, so there's nothing to wait for; the SDK client will report that it is not
+		// initialized, and Relay will serve whatever is already in the data store.
+		close(closeWhenReady)
+		return
+	}
+	current.Start(closeWhenReady)
+}
+
+func (d *pausableDataSource) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed = true
+	if d.current != nil {
+		return d.current.Close()
+	}
+	return nil
+}
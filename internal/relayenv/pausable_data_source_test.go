@@ -0,0 +1,143 @@
+package relayenv
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDataSource struct {
+	started     bool
+	closed      bool
+	initialized bool
+	lock        sync.Mutex
+}
+
+type mockDataSourceFactory struct {
+	built []*mockDataSource
+}
+
+func (f *mockDataSourceFactory) Build(subsystems.ClientContext) (subsystems.DataSource, error) {
+	ds := &mockDataSource{}
+	f.built = append(f.built, ds)
+	return ds, nil
+}
+
+func (d *mockDataSource) IsInitialized() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.initialized
+}
+
+func (d *mockDataSource) Start(closeWhenReady chan<- struct{}) {
+	d.lock.Lock()
+	d.started = true
+	d.initialized = true
+	d.lock.Unlock()
+	close(closeWhenReady)
+}
+
+func (d *mockDataSource) Close() error {
+	d.lock.Lock()
+	d.closed = true
+	d.lock.Unlock()
+	return nil
+}
+
+func TestPausableDataSource(t *testing.T) {
+	t.Run("not paused", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, false)
+		ds, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		require.Len(t, wrapped.built, 1)
+
+		readyCh := make(chan struct{})
+		ds.Start(readyCh)
+		<-readyCh
+		assert.True(t, wrapped.built[0].started)
+		assert.True(t, ds.IsInitialized())
+
+		assert.NoError(t, ds.Close())
+		assert.True(t, wrapped.built[0].closed)
+	})
+
+	t.Run("paused at startup", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, true)
+		ds, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		assert.Len(t, wrapped.built, 0)
+
+		readyCh := make(chan struct{})
+		ds.Start(readyCh)
+		<-readyCh
+		assert.False(t, ds.IsInitialized())
+
+		f.setPaused(false)
+		require.Len(t, wrapped.built, 1)
+		assert.True(t, wrapped.built[0].started)
+		assert.True(t, ds.IsInitialized())
+		assert.False(t, f.isPaused())
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, false)
+		ds, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		ds.Start(make(chan struct{}))
+
+		f.setPaused(true)
+		assert.True(t, f.isPaused())
+		assert.True(t, wrapped.built[0].closed)
+		assert.True(t, ds.IsInitialized()) // it still has the data it received before pausing
+
+		f.setPaused(false)
+		require.Len(t, wrapped.built, 2)
+		assert.True(t, wrapped.built[1].started)
+		assert.False(t, wrapped.built[1].closed)
+	})
+
+	t.Run("closed data source is not restarted", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, false)
+		ds, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		ds.Start(make(chan struct{}))
+		require.NoError(t, ds.Close())
+
+		f.setPaused(true)
+		f.setPaused(false)
+		assert.Len(t, wrapped.built, 1)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, false)
+		ds, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		ds.Start(make(chan struct{}))
+
+		<-f.restart()
+		require.Len(t, wrapped.built, 2)
+		assert.True(t, wrapped.built[0].closed)
+		assert.True(t, wrapped.built[1].started)
+		assert.False(t, wrapped.built[1].closed)
+		assert.True(t, ds.IsInitialized())
+	})
+
+	t.Run("restart does nothing while paused", func(t *testing.T) {
+		wrapped := &mockDataSourceFactory{}
+		f := newPausableDataSourceFactory(wrapped, true)
+		_, err := f.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+
+		<-f.restart()
+		assert.Len(t, wrapped.built, 0)
+	})
+}
@@ -3,6 +3,7 @@ package sdks
 import (
 	"github.com/launchdarkly/ld-relay/v8/config"
 
+	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
@@ -21,6 +22,7 @@ func ConfigureBigSegments(
 	loggers ldlog.Loggers,
 ) (subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration], error) {
 	var storeFactory subsystems.ComponentConfigurer[subsystems.BigSegmentStore]
+	allConfig, envConfig = GetBigSegmentsStoreConfig(allConfig, envConfig)
 
 	if allConfig.Redis.URL.IsDefined() {
 		redisBuilder, redisURL := makeRedisDataStoreBuilder(ldredis.BigSegmentStore, allConfig, envConfig)
@@ -40,3 +42,27 @@ func ConfigureBigSegments(
 	}
 	return nil, nil
 }
+
+// GetBigSegmentsStoreConfig returns the database configuration to use for an environment's big segment
+// store. Normally this is the same as the global database configuration, but an environment can override
+// it with BigSegmentsRedisURL or BigSegmentsTableName. This function is exported to ensure consistency
+// between the SDK configuration and the internal big segment store that the synchronizer writes to.
+//
+// If BigSegmentsRedisURL is set, none of the other global Redis settings are used: any credentials or
+// TLS scheme for that database must be part of the URL, since the global password, username, and TLS
+// settings are meant for a different server.
+func GetBigSegmentsStoreConfig(
+	allConfig config.Config,
+	envConfig config.EnvConfig,
+) (config.Config, config.EnvConfig) {
+	switch {
+	case envConfig.BigSegmentsRedisURL.IsDefined():
+		allConfig.Redis = config.RedisConfig{URL: envConfig.BigSegmentsRedisURL}
+		allConfig.DynamoDB.Enabled = false
+	case envConfig.BigSegmentsTableName != "":
+		allConfig.Redis.URL = ct.OptURLAbsolute{}
+		allConfig.DynamoDB.Enabled = true
+		envConfig.TableName = envConfig.BigSegmentsTableName
+	}
+	return allConfig, envConfig
+}
@@ -20,6 +20,7 @@ type LDClientContext interface {
 	Initialized() bool
 	SecureModeHash(ldcontext.Context) string
 	GetDataSourceStatus() interfaces.DataSourceStatus
+	AddDataSourceStatusListener() <-chan interfaces.DataSourceStatus
 	GetDataStoreStatus() DataStoreStatusInfo
 	Close() error
 }
@@ -92,6 +93,12 @@ func (c *ldClientContextImpl) GetDataSourceStatus() interfaces.DataSourceStatus
 	return c.GetDataSourceStatusProvider().GetStatus()
 }
 
+// AddDataSourceStatusListener returns a channel that receives every change in the data source status. The
+// channel is closed when the client is closed; until then, the caller must keep reading from it.
+func (c *ldClientContextImpl) AddDataSourceStatusListener() <-chan interfaces.DataSourceStatus {
+	return c.GetDataSourceStatusProvider().AddStatusListener()
+}
+
 func (c *ldClientContextImpl) GetDataStoreStatus() DataStoreStatusInfo {
 	status := c.GetDataStoreStatusProvider().GetStatus()
 	c.lock.Lock()
@@ -0,0 +1,183 @@
+package sdks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// gzipItemPrefix marks a stored item as gzip-compressed. Serialized flags and segments are always JSON
+// objects, so an item that does not start with this prefix is assumed to be uncompressed. The compressed
+// bytes are base64-encoded because some databases, such as DynamoDB, store the item as a string.
+const gzipItemPrefix = "gz:"
+
+// compressedDataStoreConfigurer wraps a persistent data store configurer so that the store it creates
+// will gzip-compress items before writing them to the database, and decompress them after reading.
+//
+// Items that were written without compression can still be read, so compression can be turned on for
+// an existing database; the items will be rewritten in compressed form the next time Relay receives
+// the full data set from LaunchDarkly.
+type compressedDataStoreConfigurer struct {
+	wrapped subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
+}
+
+type compressedDataStore struct {
+	wrapped subsystems.PersistentDataStore
+}
+
+// compressedDataKind is used when passing a data kind to the wrapped store, in case the store needs to
+// deserialize an item that it has read from the database (for instance, to check its version number).
+type compressedDataKind struct {
+	wrapped ldstoretypes.DataKind
+}
+
+func (c compressedDataStoreConfigurer) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	store, err := c.wrapped.Build(clientContext)
+	if err != nil {
+		return nil, err
+	}
+	return &compressedDataStore{wrapped: store}, nil
+}
+
+// DescribeConfiguration is used by the SDK to describe the type of data store in diagnostic events.
+func (c compressedDataStoreConfigurer) DescribeConfiguration(clientContext subsystems.ClientContext) ldvalue.Value {
+	if dd, ok := c.wrapped.(subsystems.DiagnosticDescription); ok {
+		return dd.DescribeConfiguration(clientContext)
+	}
+	return ldvalue.Null()
+}
+
+func (s *compressedDataStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	compressedData := make([]ldstoretypes.SerializedCollection, 0, len(allData))
+	for _, coll := range allData {
+		items := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(coll.Items))
+		for _, item := range coll.Items {
+			items = append(items, ldstoretypes.KeyedSerializedItemDescriptor{
+				Key:  item.Key,
+				Item: compressItem(item.Item),
+			})
+		}
+		compressedData = append(compressedData, ldstoretypes.SerializedCollection{
+			Kind:  compressedDataKind{coll.Kind},
+			Items: items,
+		})
+	}
+	return s.wrapped.Init(compressedData)
+}
+
+func (s *compressedDataStore) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.SerializedItemDescriptor, error) {
+	item, err := s.wrapped.Get(compressedDataKind{kind}, key)
+	if err != nil {
+		return item, err
+	}
+	return decompressItem(item)
+}
+
+func (s *compressedDataStore) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	items, err := s.wrapped.GetAll(compressedDataKind{kind})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]ldstoretypes.KeyedSerializedItemDescriptor, 0, len(items))
+	for _, item := range items {
+		decompressed, err := decompressItem(item.Item)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, ldstoretypes.KeyedSerializedItemDescriptor{Key: item.Key, Item: decompressed})
+	}
+	return ret, nil
+}
+
+func (s *compressedDataStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	return s.wrapped.Upsert(compressedDataKind{kind}, key, compressItem(item))
+}
+
+func (s *compressedDataStore) IsInitialized() bool {
+	return s.wrapped.IsInitialized()
+}
+
+func (s *compressedDataStore) IsStoreAvailable() bool {
+	return s.wrapped.IsStoreAvailable()
+}
+
+func (s *compressedDataStore) Close() error {
+	return s.wrapped.Close()
+}
+
+func (k compressedDataKind) GetName() string {
+	return k.wrapped.GetName()
+}
+
+func (k compressedDataKind) Serialize(item ldstoretypes.ItemDescriptor) []byte {
+	return compressBytes(k.wrapped.Serialize(item))
+}
+
+func (k compressedDataKind) Deserialize(data []byte) (ldstoretypes.ItemDescriptor, error) {
+	decompressed, err := DecompressStoredItem(data)
+	if err != nil {
+		return ldstoretypes.ItemDescriptor{}, err
+	}
+	return k.wrapped.Deserialize(decompressed)
+}
+
+func compressItem(item ldstoretypes.SerializedItemDescriptor) ldstoretypes.SerializedItemDescriptor {
+	if item.SerializedItem != nil {
+		item.SerializedItem = compressBytes(item.SerializedItem)
+	}
+	return item
+}
+
+func decompressItem(item ldstoretypes.SerializedItemDescriptor) (ldstoretypes.SerializedItemDescriptor, error) {
+	if item.SerializedItem != nil {
+		data, err := DecompressStoredItem(item.SerializedItem)
+		if err != nil {
+			return ldstoretypes.SerializedItemDescriptor{}, err
+		}
+		item.SerializedItem = data
+	}
+	return item, nil
+}
+
+func compressBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data) // writing to a bytes.Buffer can't fail
+	_ = w.Close()
+	ret := make([]byte, len(gzipItemPrefix)+base64.StdEncoding.EncodedLen(buf.Len()))
+	copy(ret, gzipItemPrefix)
+	base64.StdEncoding.Encode(ret[len(gzipItemPrefix):], buf.Bytes())
+	return ret
+}
+
+// DecompressStoredItem returns the serialized JSON of an item that was read directly from a persistent data
+// store, decompressing it if Relay's data store compression option was enabled when it was written. Data
+// that was not compressed is returned unchanged.
+func DecompressStoredItem(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(gzipItemPrefix)) {
+		return data, nil // not compressed
+	}
+	encoded := data[len(gzipItemPrefix):]
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(compressed, encoded)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed[:n]))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint:errcheck
+	return io.ReadAll(r)
+}
@@ -0,0 +1,141 @@
+package sdks
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePersistentStore behaves like the Redis store in that it only knows the version of an existing item
+// by deserializing it with the data kind.
+type fakePersistentStore struct {
+	data map[string][]byte
+}
+
+func newFakePersistentStore() *fakePersistentStore {
+	return &fakePersistentStore{data: make(map[string][]byte)}
+}
+
+func (f *fakePersistentStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	f.data = make(map[string][]byte)
+	for _, coll := range allData {
+		for _, item := range coll.Items {
+			f.data[coll.Kind.GetName()+":"+item.Key] = item.Item.SerializedItem
+		}
+	}
+	return nil
+}
+
+func (f *fakePersistentStore) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.SerializedItemDescriptor, error) {
+	if data, ok := f.data[kind.GetName()+":"+key]; ok {
+		return ldstoretypes.SerializedItemDescriptor{SerializedItem: data}, nil
+	}
+	return ldstoretypes.SerializedItemDescriptor{}.NotFound(), nil
+}
+
+func (f *fakePersistentStore) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	return nil, nil
+}
+
+func (f *fakePersistentStore) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	if old, ok := f.data[kind.GetName()+":"+key]; ok {
+		parsed, err := kind.Deserialize(old)
+		if err != nil {
+			return false, err
+		}
+		if parsed.Version >= item.Version {
+			return false, nil
+		}
+	}
+	f.data[kind.GetName()+":"+key] = item.SerializedItem
+	return true, nil
+}
+
+func (f *fakePersistentStore) IsInitialized() bool    { return true }
+func (f *fakePersistentStore) IsStoreAvailable() bool { return true }
+func (f *fakePersistentStore) Close() error           { return nil }
+
+func serializedFlag(key string, version int) ldstoretypes.SerializedItemDescriptor {
+	flag := ldbuilders.NewFlagBuilder(key).Version(version).Build()
+	desc := ldstoretypes.ItemDescriptor{Version: version, Item: &flag}
+	return ldstoretypes.SerializedItemDescriptor{
+		Version:        version,
+		SerializedItem: ldstoreimpl.Features().Serialize(desc),
+	}
+}
+
+func TestCompressedDataStore(t *testing.T) {
+	flag1 := serializedFlag("flag1", 1)
+	flag2 := serializedFlag("flag2", 1)
+
+	t.Run("items are stored compressed and read back uncompressed", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		store := &compressedDataStore{wrapped: fake}
+
+		require.NoError(t, store.Init([]ldstoretypes.SerializedCollection{
+			{Kind: ldstoreimpl.Features(), Items: []ldstoretypes.KeyedSerializedItemDescriptor{{Key: "flag1", Item: flag1}}},
+		}))
+		_, err := store.Upsert(ldstoreimpl.Features(), "flag2", flag2)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, flag1.SerializedItem, fake.data["features:flag1"])
+		assert.NotEqual(t, flag2.SerializedItem, fake.data["features:flag2"])
+
+		item, err := store.Get(ldstoreimpl.Features(), "flag1")
+		require.NoError(t, err)
+		assert.Equal(t, flag1.SerializedItem, item.SerializedItem)
+		item, err = store.Get(ldstoreimpl.Features(), "flag2")
+		require.NoError(t, err)
+		assert.Equal(t, flag2.SerializedItem, item.SerializedItem)
+	})
+
+	t.Run("uncompressed items can be read", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		fake.data["features:flag1"] = flag1.SerializedItem
+		store := &compressedDataStore{wrapped: fake}
+
+		item, err := store.Get(ldstoreimpl.Features(), "flag1")
+		require.NoError(t, err)
+		assert.Equal(t, flag1.SerializedItem, item.SerializedItem)
+	})
+
+	t.Run("wrapped store can check versions of compressed and uncompressed items", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		fake.data["features:flag1"] = flag1.SerializedItem
+		store := &compressedDataStore{wrapped: fake}
+
+		updated, err := store.Upsert(ldstoreimpl.Features(), "flag1", serializedFlag("flag1", 2))
+		require.NoError(t, err)
+		assert.True(t, updated)
+
+		updated, err = store.Upsert(ldstoreimpl.Features(), "flag1", serializedFlag("flag1", 1))
+		require.NoError(t, err)
+		assert.False(t, updated)
+	})
+
+	t.Run("item that is not found", func(t *testing.T) {
+		store := &compressedDataStore{wrapped: newFakePersistentStore()}
+
+		item, err := store.Get(ldstoreimpl.Features(), "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, item.SerializedItem)
+	})
+
+	t.Run("corrupt compressed item", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		fake.data["features:flag1"] = []byte(gzipItemPrefix + "!!!")
+		store := &compressedDataStore{wrapped: fake}
+
+		_, err := store.Get(ldstoreimpl.Features(), "flag1")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,191 @@
+package sdks
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// RelayDataFormatVersion identifies the way that this version of Relay stores data in a persistent data
+// store. It must be incremented whenever a change to the stored data would cause older versions of Relay
+// to misread it, so that those versions can tell that they should not use the data.
+const RelayDataFormatVersion = 1
+
+const dataFormatItemKey = "dataFormat"
+
+// dataFormatKind is the data kind for the marker item that records RelayDataFormatVersion. It is only used
+// within a persistent data store; the SDK never sees it.
+type dataFormatKind struct{}
+
+type dataFormatMarker struct {
+	Version int `json:"version"`
+}
+
+// DataFormatStatus tracks whether a persistent data store contains data that was written in a newer format
+// than this version of Relay understands. It is shared between the data store and the status resource.
+type DataFormatStatus struct {
+	storedVersion int
+	onMismatch    func(storedVersion int)
+	lock          sync.Mutex
+}
+
+// NewDataFormatStatus creates a DataFormatStatus that has not yet detected any mismatch.
+func NewDataFormatStatus() *DataFormatStatus {
+	return &DataFormatStatus{}
+}
+
+// SetMismatchHandler specifies a function to be called whenever the data store is found to contain data in
+// a newer format, after previously either not having been checked or having had a compatible format.
+func (s *DataFormatStatus) SetMismatchHandler(fn func(storedVersion int)) {
+	s.lock.Lock()
+	s.onMismatch = fn
+	s.lock.Unlock()
+}
+
+// GetMismatchedVersion returns the data format version that was found in the data store if it is newer
+// than RelayDataFormatVersion, or zero if there is no such mismatch.
+func (s *DataFormatStatus) GetMismatchedVersion() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.storedVersion
+}
+
+func (s *DataFormatStatus) setMismatchedVersion(storedVersion int) {
+	s.lock.Lock()
+	changed := storedVersion != 0 && s.storedVersion != storedVersion
+	s.storedVersion = storedVersion
+	onMismatch := s.onMismatch
+	s.lock.Unlock()
+	if changed && onMismatch != nil {
+		onMismatch(storedVersion)
+	}
+}
+
+// dataFormatCheckingConfigurer wraps a persistent data store configurer so that the store it creates will
+// write a data format marker whenever it is initialized, and will refuse to report that it is initialized
+// if it contains a marker from a newer version of Relay. Without this, a Relay instance that shares a
+// database with newer instances could misread their data and serve incorrect flag values.
+//
+// Data written by versions of Relay that did not write a marker is assumed to be compatible.
+type dataFormatCheckingConfigurer struct {
+	wrapped subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
+	status  *DataFormatStatus
+}
+
+type dataFormatCheckingStore struct {
+	subsystems.PersistentDataStore
+	status     *DataFormatStatus
+	loggers    ldlog.Loggers
+	compatible bool
+	lock       sync.Mutex
+}
+
+func withDataFormatCheck(
+	builder subsystems.ComponentConfigurer[subsystems.PersistentDataStore],
+	status *DataFormatStatus,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	return dataFormatCheckingConfigurer{wrapped: builder, status: status}
+}
+
+func (c dataFormatCheckingConfigurer) Build(
+	clientContext subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	store, err := c.wrapped.Build(clientContext)
+	if err != nil {
+		return nil, err
+	}
+	return &dataFormatCheckingStore{
+		PersistentDataStore: store,
+		status:              c.status,
+		loggers:             clientContext.GetLogging().Loggers,
+	}, nil
+}
+
+// DescribeConfiguration is used by the SDK to describe the type of data store in diagnostic events.
+func (c dataFormatCheckingConfigurer) DescribeConfiguration(clientContext subsystems.ClientContext) ldvalue.Value {
+	if dd, ok := c.wrapped.(subsystems.DiagnosticDescription); ok {
+		return dd.DescribeConfiguration(clientContext)
+	}
+	return ldvalue.Null()
+}
+
+func (s *dataFormatCheckingStore) Init(allData []ldstoretypes.SerializedCollection) error {
+	marker, _ := json.Marshal(dataFormatMarker{Version: RelayDataFormatVersion})
+	withMarker := make([]ldstoretypes.SerializedCollection, 0, len(allData)+1)
+	withMarker = append(withMarker, allData...)
+	withMarker = append(withMarker, ldstoretypes.SerializedCollection{
+		Kind: dataFormatKind{},
+		Items: []ldstoretypes.KeyedSerializedItemDescriptor{{
+			Key:  dataFormatItemKey,
+			Item: ldstoretypes.SerializedItemDescriptor{Version: RelayDataFormatVersion, SerializedItem: marker},
+		}},
+	})
+	if err := s.PersistentDataStore.Init(withMarker); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.compatible = true
+	s.lock.Unlock()
+	s.status.setMismatchedVersion(0)
+	return nil
+}
+
+// IsInitialized returns false if the data in the store is in a newer format than we understand, even if
+// the store has been initialized, so that Relay and the SDK will not use the data. Once the data is known
+// to be compatible, we don't need to check again unless another Relay instance re-initializes the store,
+// in which case it would be compatible with itself and we have no way of knowing about it anyway.
+func (s *dataFormatCheckingStore) IsInitialized() bool {
+	if !s.PersistentDataStore.IsInitialized() {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.compatible {
+		return true
+	}
+	item, err := s.PersistentDataStore.Get(dataFormatKind{}, dataFormatItemKey)
+	if err != nil {
+		s.loggers.Warnf("Unable to read data format marker from data store: %s", err)
+		return false
+	}
+	if item.SerializedItem == nil {
+		s.compatible = true // written by a version of Relay that did not write a marker
+		return true
+	}
+	var marker dataFormatMarker
+	if err := json.Unmarshal(item.SerializedItem, &marker); err != nil || marker.Version <= 0 {
+		marker.Version = RelayDataFormatVersion + 1 // we can't parse it, so it must be from a newer version
+	}
+	if marker.Version > RelayDataFormatVersion {
+		if s.status.GetMismatchedVersion() == 0 {
+			s.loggers.Errorf("Data store contains data in format version %d, but this version of Relay only understands"+
+				" version %d; the data will not be used until it is replaced with data from LaunchDarkly",
+				marker.Version, RelayDataFormatVersion)
+		}
+		s.status.setMismatchedVersion(marker.Version)
+		return false
+	}
+	s.compatible = true
+	return true
+}
+
+func (k dataFormatKind) GetName() string {
+	return "relayDataFormat"
+}
+
+func (k dataFormatKind) Serialize(item ldstoretypes.ItemDescriptor) []byte {
+	marker, _ := json.Marshal(dataFormatMarker{Version: item.Version})
+	return marker
+}
+
+func (k dataFormatKind) Deserialize(data []byte) (ldstoretypes.ItemDescriptor, error) {
+	var marker dataFormatMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return ldstoretypes.ItemDescriptor{}, err
+	}
+	return ldstoretypes.ItemDescriptor{Version: marker.Version, Item: marker}, nil
+}
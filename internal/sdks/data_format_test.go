@@ -0,0 +1,87 @@
+package sdks
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeDataFormatCheckingStore(wrapped *fakePersistentStore) (*dataFormatCheckingStore, *DataFormatStatus, *ldlogtest.MockLog) {
+	status := NewDataFormatStatus()
+	mockLog := ldlogtest.NewMockLog()
+	return &dataFormatCheckingStore{PersistentDataStore: wrapped, status: status, loggers: mockLog.Loggers}, status, mockLog
+}
+
+func TestDataFormatCheckingStore(t *testing.T) {
+	flag1 := serializedFlag("flag1", 1)
+	allData := []ldstoretypes.SerializedCollection{
+		{Kind: ldstoreimpl.Features(), Items: []ldstoretypes.KeyedSerializedItemDescriptor{{Key: "flag1", Item: flag1}}},
+	}
+
+	t.Run("Init writes data format marker", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		store, status, _ := makeDataFormatCheckingStore(fake)
+
+		require.NoError(t, store.Init(allData))
+
+		assert.Equal(t, flag1.SerializedItem, fake.data["features:flag1"])
+		assert.JSONEq(t, `{"version":1}`, string(fake.data["relayDataFormat:dataFormat"]))
+		assert.True(t, store.IsInitialized())
+		assert.Equal(t, 0, status.GetMismatchedVersion())
+	})
+
+	t.Run("data without a marker is compatible", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		require.NoError(t, fake.Init(allData))
+		store, status, _ := makeDataFormatCheckingStore(fake)
+
+		assert.True(t, store.IsInitialized())
+		assert.Equal(t, 0, status.GetMismatchedVersion())
+	})
+
+	t.Run("data with an older or equal marker is compatible", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		require.NoError(t, fake.Init(allData))
+		fake.data["relayDataFormat:dataFormat"] = []byte(`{"version":1}`)
+		store, status, _ := makeDataFormatCheckingStore(fake)
+
+		assert.True(t, store.IsInitialized())
+		assert.Equal(t, 0, status.GetMismatchedVersion())
+	})
+
+	t.Run("data with a newer marker is not used", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		require.NoError(t, fake.Init(allData))
+		fake.data["relayDataFormat:dataFormat"] = []byte(`{"version":99}`)
+		store, status, mockLog := makeDataFormatCheckingStore(fake)
+		var mismatches []int
+		status.SetMismatchHandler(func(storedVersion int) { mismatches = append(mismatches, storedVersion) })
+
+		assert.False(t, store.IsInitialized())
+		assert.False(t, store.IsInitialized())
+		assert.Equal(t, 99, status.GetMismatchedVersion())
+		assert.Equal(t, []int{99}, mismatches)
+		assert.Len(t, mockLog.GetOutput(ldlog.Error), 1)
+		mockLog.AssertMessageMatch(t, true, ldlog.Error, "format version 99")
+
+		require.NoError(t, store.Init(allData))
+		assert.True(t, store.IsInitialized())
+		assert.Equal(t, 0, status.GetMismatchedVersion())
+	})
+
+	t.Run("unreadable marker is treated as newer", func(t *testing.T) {
+		fake := newFakePersistentStore()
+		require.NoError(t, fake.Init(allData))
+		fake.data["relayDataFormat:dataFormat"] = []byte(`not JSON`)
+		store, status, _ := makeDataFormatCheckingStore(fake)
+
+		assert.False(t, store.IsInitialized())
+		assert.Equal(t, RelayDataFormatVersion+1, status.GetMismatchedVersion())
+	})
+}
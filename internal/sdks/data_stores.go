@@ -2,6 +2,7 @@ package sdks
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"strings"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/go-redis/redis/v8"
 	redigo "github.com/gomodule/redigo/redis"
 	consul "github.com/hashicorp/consul/api"
 )
@@ -44,6 +46,10 @@ type DataStoreEnvironmentInfo struct {
 
 	// DBTable is the table name for this environment if using DynamoDB, or "" otherwise.
 	DBTable string
+
+	// DataFormat reports whether the database contains data in a newer format than this version of
+	// Relay understands. It is nil if we are not using a database.
+	DataFormat *DataFormatStatus
 }
 
 // ConfigureDataStore provides the appropriate Go SDK data store factory (in-memory, Redis, etc.) based on
@@ -63,15 +69,17 @@ func ConfigureDataStore(
 		loggers.Infof("Using Redis data store: %s with prefix: %s", redactedURL, envConfig.Prefix)
 
 		storeInfo := DataStoreEnvironmentInfo{
-			DBType:   "redis",
-			DBServer: redactedURL,
-			DBPrefix: envConfig.Prefix,
+			DBType:     "redis",
+			DBServer:   redactedURL,
+			DBPrefix:   envConfig.Prefix,
+			DataFormat: NewDataFormatStatus(),
 		}
 		if storeInfo.DBPrefix == "" {
 			storeInfo.DBPrefix = ldredis.DefaultPrefix
 		}
 
-		return ldcomponents.PersistentDataStore(redisBuilder).
+		return ldcomponents.PersistentDataStore(withDataFormatCheck(
+			withCompression(redisBuilder, allConfig.Redis.Compression, loggers), storeInfo.DataFormat)).
 			CacheTime(allConfig.Redis.LocalTTL.GetOrElse(config.DefaultDatabaseCacheTTL)), storeInfo, nil
 	}
 
@@ -89,15 +97,16 @@ func ConfigureDataStore(
 		builder.Address(dbConfig.Host) // this is deliberately done last so it's not overridden by builder.Config()
 
 		storeInfo := DataStoreEnvironmentInfo{
-			DBType:   "consul",
-			DBServer: dbConfig.Host,
-			DBPrefix: envConfig.Prefix,
+			DBType:     "consul",
+			DBServer:   dbConfig.Host,
+			DBPrefix:   envConfig.Prefix,
+			DataFormat: NewDataFormatStatus(),
 		}
 		if storeInfo.DBPrefix == "" {
 			storeInfo.DBPrefix = ldconsul.DefaultPrefix
 		}
 
-		return ldcomponents.PersistentDataStore(builder).
+		return ldcomponents.PersistentDataStore(withDataFormatCheck(builder, storeInfo.DataFormat)).
 			CacheTime(dbConfig.LocalTTL.GetOrElse(config.DefaultDatabaseCacheTTL)), storeInfo, nil
 	}
 
@@ -110,19 +119,33 @@ func ConfigureDataStore(
 		loggers.Infof("Using DynamoDB data store: %s with prefix: %s", tableName, envConfig.Prefix)
 
 		storeInfo := DataStoreEnvironmentInfo{
-			DBType:   "dynamodb",
-			DBServer: allConfig.DynamoDB.URL.String(),
-			DBPrefix: envConfig.Prefix,
-			DBTable:  tableName,
+			DBType:     "dynamodb",
+			DBServer:   allConfig.DynamoDB.URL.String(),
+			DBPrefix:   envConfig.Prefix,
+			DBTable:    tableName,
+			DataFormat: NewDataFormatStatus(),
 		}
 
-		return ldcomponents.PersistentDataStore(builder).
+		return ldcomponents.PersistentDataStore(withDataFormatCheck(
+			withCompression(builder, allConfig.DynamoDB.Compression, loggers), storeInfo.DataFormat)).
 			CacheTime(allConfig.DynamoDB.LocalTTL.GetOrElse(config.DefaultDatabaseCacheTTL)), storeInfo, nil
 	}
 
 	return ldcomponents.InMemoryDataStore(), DataStoreEnvironmentInfo{}, nil
 }
 
+func withCompression(
+	builder subsystems.ComponentConfigurer[subsystems.PersistentDataStore],
+	compression string,
+	loggers ldlog.Loggers,
+) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+	if compression != config.DataStoreCompressionGzip {
+		return builder
+	}
+	loggers.Info("Data store items will be compressed with gzip")
+	return compressedDataStoreConfigurer{wrapped: builder}
+}
+
 // GetRedisBasicProperties transforms the configuration properties to the standard parameters
 // used for Redis. This function is exported to ensure consistency between the SDK
 // configuration and the internal big segment store for Redis.
@@ -147,6 +170,42 @@ func GetRedisBasicProperties(
 	return
 }
 
+// GetRedisUniversalOptions transforms the configuration properties to the connection options used by
+// Relay components that access Redis directly with the go-redis client, rather than through the SDK.
+func GetRedisUniversalOptions(
+	dbConfig config.RedisConfig,
+	envConfig config.EnvConfig,
+) (opts redis.UniversalOptions, prefix string, err error) {
+	redisURL, prefix := GetRedisBasicProperties(dbConfig, envConfig)
+
+	// Relay's Redis configuration allows setting the server address either as a URL or as a
+	// host & port, but our config validation logic simplifies this so that it is always a URL.
+	// However, it is still possible to set the Password and TLS options separately from the
+	// URL, so we still need to check for those.
+	parsed, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return opts, prefix, err
+	}
+	opts.DB = parsed.DB
+	opts.Addrs = []string{parsed.Addr}
+	opts.Username = parsed.Username
+	opts.Password = parsed.Password
+	opts.TLSConfig = parsed.TLSConfig
+	if dbConfig.Password != "" {
+		opts.Password = dbConfig.Password
+	}
+	if dbConfig.Username != "" {
+		opts.Username = dbConfig.Username
+	}
+	if dbConfig.TLS && opts.TLSConfig == nil {
+		opts.TLSConfig = &tls.Config{
+			ServerName: dbConfig.URL.Get().Hostname(),
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return opts, prefix, nil
+}
+
 func makeRedisDataStoreBuilder[T any](
 	constructor func() *ldredis.StoreBuilder[T],
 	allConfig config.Config,
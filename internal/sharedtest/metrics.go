@@ -88,6 +88,12 @@ func (e *TestMetricsExporter) ExportView(viewData *view.Data) {
 		if countData, ok := vr.Data.(*view.CountData); ok {
 			tr.Count = countData.Value
 		}
+		if lastValueData, ok := vr.Data.(*view.LastValueData); ok {
+			tr.Sum = lastValueData.Value
+		}
+		if distributionData, ok := vr.Data.(*view.DistributionData); ok {
+			tr.Count = distributionData.Count
+		}
 		rows = append(rows, tr)
 	}
 
@@ -97,7 +103,20 @@ func (e *TestMetricsExporter) ExportView(viewData *view.Data) {
 		for k, v := range e.lastData {
 			dataCopy[k] = v
 		}
-		e.dataCh <- dataCopy
+		// Each snapshot contains all of the latest data, so if nobody is reading (for instance,
+		// because AwaitData has already returned) we can discard the oldest one rather than blocking
+		// the OpenCensus worker, which would stop it from exporting data for any later test.
+		for {
+			select {
+			case e.dataCh <- dataCopy:
+				return
+			default:
+				select {
+				case <-e.dataCh:
+				default:
+				}
+			}
+		}
 	}
 }
 
@@ -34,6 +34,7 @@ type FakeLDClient struct {
 	Key              config.SDKKey
 	CloseCh          chan struct{}
 	dataSourceStatus *interfaces.DataSourceStatus
+	statusListeners  []chan interfaces.DataSourceStatus
 	initialized      bool
 	lock             sync.Mutex
 }
@@ -64,11 +65,25 @@ func (c *FakeLDClient) GetDataSourceStatus() interfaces.DataSourceStatus {
 	return interfaces.DataSourceStatus{State: state}
 }
 
+func (c *FakeLDClient) AddDataSourceStatusListener() <-chan interfaces.DataSourceStatus {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ch := make(chan interfaces.DataSourceStatus, 10)
+	c.statusListeners = append(c.statusListeners, ch)
+	return ch
+}
+
 func (c *FakeLDClient) GetDataStoreStatus() sdks.DataStoreStatusInfo {
 	return sdks.DataStoreStatusInfo{Available: true}
 }
 
 func (c *FakeLDClient) Close() error {
+	c.lock.Lock()
+	for _, ch := range c.statusListeners {
+		close(ch)
+	}
+	c.statusListeners = nil
+	c.lock.Unlock()
 	if c.CloseCh != nil {
 		close(c.CloseCh)
 	}
@@ -79,6 +94,9 @@ func (c *FakeLDClient) SetDataSourceStatus(newStatus interfaces.DataSourceStatus
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.dataSourceStatus = &newStatus
+	for _, ch := range c.statusListeners {
+		ch <- newStatus
+	}
 }
 
 func (c *FakeLDClient) AwaitClose(t *testing.T, timeout time.Duration) {
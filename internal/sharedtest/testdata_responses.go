@@ -70,6 +70,17 @@ func AssertExpectedCORSHeaders(t *testing.T, resp *http.Response, endpointMethod
 	assert.Equal(t, host, resp.Header.Get("Access-Control-Allow-Origin"))
 }
 
+// MakeEvalBodyValueOnly returns the expected JSON body of a client-side evaluation response in the
+// older schema, which maps each flag key to its value only (see basictypes.EvalSchemaValueOnly).
+func MakeEvalBodyValueOnly(flags []TestFlag) string {
+	obj := make(map[string]interface{})
+	for _, f := range flags {
+		obj[f.Flag.Key] = f.ExpectedValue
+	}
+	out, _ := json.Marshal(obj)
+	return string(out)
+}
+
 func MakeEvalBody(flags []TestFlag, reasons bool) string {
 	obj := make(map[string]interface{})
 	for _, f := range flags {
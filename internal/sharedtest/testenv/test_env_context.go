@@ -5,6 +5,7 @@ package testenv
 import (
 	"time"
 
+	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
@@ -24,14 +25,24 @@ func NewTestEnvContextWithClientFactory(
 	name string,
 	f sdks.ClientFactoryFunc,
 	store subsystems.DataStore,
+) relayenv.EnvContext {
+	return NewTestEnvContextWithEnvConfig(name, f, store, config.EnvConfig{})
+}
+
+func NewTestEnvContextWithEnvConfig(
+	name string,
+	f sdks.ClientFactoryFunc,
+	store subsystems.DataStore,
+	envConfig config.EnvConfig,
 ) relayenv.EnvContext {
 	dataStoreFactory := ldcomponents.InMemoryDataStore()
 	if store != nil {
 		dataStoreFactory = sharedtest.ExistingInstance(store)
 	}
-	readyCh := make(chan relayenv.EnvContext)
+	readyCh := make(chan relayenv.ReadyResult)
 	_, err := relayenv.NewEnvContext(relayenv.EnvContextImplParams{
 		Identifiers:      relayenv.EnvIdentifiers{ConfiguredName: name},
+		EnvConfig:        envConfig,
 		ClientFactory:    f,
 		DataStoreFactory: dataStoreFactory,
 		UserAgent:        "fake-user-agent",
@@ -40,8 +51,8 @@ func NewTestEnvContextWithClientFactory(
 	if err != nil {
 		panic(err)
 	}
-	if c, ok, _ := helpers.TryReceive(readyCh, time.Second); ok {
-		return c
+	if result, ok, _ := helpers.TryReceive(readyCh, time.Second); ok {
+		return result.Env
 	}
 	panic("timed out waiting for client initialization")
 }
@@ -0,0 +1,199 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// CircuitBreakerConfig controls the circuit breaker that SSERelayDataStoreAdapter can put around reads
+// from the underlying data store. If FailureThreshold is zero or less, there is no circuit breaker.
+//
+// The circuit opens after FailureThreshold consecutive reads have failed, or have taken at least
+// LatencyThreshold if that is greater than zero. While it is open, reads are answered from the last known
+// data instead of the store. After OpenDuration, one read is allowed through to test whether the store has
+// recovered; if it succeeds, the circuit closes, and otherwise it stays open for another OpenDuration.
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	LatencyThreshold time.Duration
+	OpenDuration     time.Duration
+}
+
+// CircuitBreakerState describes the state of the data store circuit breaker.
+type CircuitBreakerState string
+
+const (
+	// CircuitBreakerClosed means that reads go to the data store as usual.
+	CircuitBreakerClosed CircuitBreakerState = "CLOSED"
+	// CircuitBreakerOpen means that reads are being answered from the last known data.
+	CircuitBreakerOpen CircuitBreakerState = "OPEN"
+	// CircuitBreakerHalfOpen means that a single read is being allowed through to test the data store.
+	CircuitBreakerHalfOpen CircuitBreakerState = "HALF_OPEN"
+)
+
+var errStoreCircuitOpen = errors.New("data store is unavailable (circuit breaker is open) and the data is not known")
+
+type circuitBreaker struct {
+	config   CircuitBreakerConfig
+	state    CircuitBreakerState
+	failures int
+	openedAt time.Time
+	handler  atomic.Pointer[func(CircuitBreakerState)]
+	lastData lastKnownData
+	mu       sync.Mutex
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{
+		config:   config,
+		state:    CircuitBreakerClosed,
+		lastData: newLastKnownData(),
+	}
+}
+
+func (b *circuitBreaker) getState() CircuitBreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow returns true if a read should go to the data store. If the circuit has been open for long
+// enough, this moves it to the half-open state and allows the read as a trial.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	switch b.state {
+	case CircuitBreakerClosed:
+		b.mu.Unlock()
+		return true
+	case CircuitBreakerOpen:
+		if time.Since(b.openedAt) >= b.config.OpenDuration {
+			b.setStateLocked(CircuitBreakerHalfOpen) // unlocks
+			return true
+		}
+	}
+	b.mu.Unlock()
+	return false
+}
+
+// record updates the state of the circuit after a read that went to the data store.
+func (b *circuitBreaker) record(err error, elapsed time.Duration) {
+	failed := err != nil || (b.config.LatencyThreshold > 0 && elapsed >= b.config.LatencyThreshold)
+	b.mu.Lock()
+	switch {
+	case b.state == CircuitBreakerHalfOpen && failed:
+		b.openedAt = time.Now()
+		b.setStateLocked(CircuitBreakerOpen) // unlocks
+	case b.state == CircuitBreakerHalfOpen:
+		b.failures = 0
+		b.setStateLocked(CircuitBreakerClosed) // unlocks
+	case b.state == CircuitBreakerClosed && failed:
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.openedAt = time.Now()
+			b.setStateLocked(CircuitBreakerOpen) // unlocks
+			return
+		}
+		b.mu.Unlock()
+	case b.state == CircuitBreakerClosed:
+		b.failures = 0
+		b.mu.Unlock()
+	default:
+		b.mu.Unlock() // this read started before the circuit opened, so it doesn't tell us anything new
+	}
+}
+
+// setStateLocked changes the state, releases the lock, and then calls the state change handler if any.
+func (b *circuitBreaker) setStateLocked(state CircuitBreakerState) {
+	b.state = state
+	b.mu.Unlock()
+	if handler := b.handler.Load(); handler != nil {
+		(*handler)(state)
+	}
+}
+
+// lastKnownData is a copy of the data that has been written to or read from the data store, which is used
+// to answer reads while the circuit is open. A data kind is only complete if a full data set for it has
+// been received, in which case an item that is not in the copy is known not to exist.
+type lastKnownData struct {
+	items    map[string]map[string]ldstoretypes.ItemDescriptor
+	complete map[string]bool
+	mu       sync.RWMutex
+}
+
+func newLastKnownData() lastKnownData {
+	return lastKnownData{
+		items:    make(map[string]map[string]ldstoretypes.ItemDescriptor),
+		complete: make(map[string]bool),
+	}
+}
+
+func (d *lastKnownData) init(allData []ldstoretypes.Collection) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.items = make(map[string]map[string]ldstoretypes.ItemDescriptor)
+	d.complete = make(map[string]bool)
+	for _, coll := range allData {
+		d.setAllLocked(coll.Kind, coll.Items)
+	}
+}
+
+func (d *lastKnownData) setAll(kind ldstoretypes.DataKind, items []ldstoretypes.KeyedItemDescriptor) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.setAllLocked(kind, items)
+}
+
+func (d *lastKnownData) setAllLocked(kind ldstoretypes.DataKind, items []ldstoretypes.KeyedItemDescriptor) {
+	itemsMap := make(map[string]ldstoretypes.ItemDescriptor, len(items))
+	for _, item := range items {
+		itemsMap[item.Key] = item.Item
+	}
+	d.items[kind.GetName()] = itemsMap
+	d.complete[kind.GetName()] = true
+}
+
+// upsert stores an item unless a newer version is already known.
+func (d *lastKnownData) upsert(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	itemsMap := d.items[kind.GetName()]
+	if itemsMap == nil {
+		itemsMap = make(map[string]ldstoretypes.ItemDescriptor)
+		d.items[kind.GetName()] = itemsMap
+	}
+	if existing, ok := itemsMap[key]; !ok || existing.Version < item.Version {
+		itemsMap[key] = item
+	}
+}
+
+func (d *lastKnownData) get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if item, ok := d.items[kind.GetName()][key]; ok {
+		return item, nil
+	}
+	if d.complete[kind.GetName()] {
+		return ldstoretypes.ItemDescriptor{}.NotFound(), nil
+	}
+	return ldstoretypes.ItemDescriptor{}, errStoreCircuitOpen
+}
+
+func (d *lastKnownData) getAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.complete[kind.GetName()] {
+		return nil, errStoreCircuitOpen
+	}
+	itemsMap := d.items[kind.GetName()]
+	ret := make([]ldstoretypes.KeyedItemDescriptor, 0, len(itemsMap))
+	for key, item := range itemsMap {
+		ret = append(ret, ldstoretypes.KeyedItemDescriptor{Key: key, Item: item})
+	}
+	return ret, nil
+}
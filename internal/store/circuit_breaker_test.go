@@ -0,0 +1,148 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestComponentsWithBreaker(config CircuitBreakerConfig) (*mockStore, *streamUpdatesStoreWrapper) {
+	baseStore := &mockStore{realStore: sharedtest.NewInMemoryStore()}
+	store := newStreamUpdatesStoreWrapper(&mockEnvStreamsUpdates{}, baseStore, WriteRetryConfig{},
+		newCircuitBreaker(config), &updateCounter{}, newFlagChangeTracker(), ldlog.NewDisabledLoggers())
+	return baseStore, store
+}
+
+func TestCircuitBreakerIsDisabledByDefault(t *testing.T) {
+	adapter := NewSSERelayDataStoreAdapter(&mockStoreFactory{}, &mockEnvStreamsUpdates{}, WriteRetryConfig{},
+		CircuitBreakerConfig{})
+	assert.Equal(t, CircuitBreakerState(""), adapter.GetCircuitBreakerState())
+	adapter.SetCircuitBreakerHandler(func(CircuitBreakerState) {}) // no effect, but shouldn't panic
+}
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Hour})
+	require.NoError(t, store.Init(allData))
+
+	baseStore.fakeError = fakeError
+	_, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	assert.Equal(t, fakeError, err)
+	assert.Equal(t, CircuitBreakerClosed, store.breaker.getState())
+
+	_, err = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	assert.Equal(t, fakeError, err)
+	assert.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+}
+
+func TestCircuitBreakerCountsOnlyConsecutiveFailures(t *testing.T) {
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Hour})
+	require.NoError(t, store.Init(allData))
+
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	baseStore.fakeError = nil
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	assert.Equal(t, CircuitBreakerClosed, store.breaker.getState())
+}
+
+func TestCircuitBreakerTreatsSlowReadsAsFailures(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, LatencyThreshold: time.Second, OpenDuration: time.Hour})
+	b.record(nil, time.Millisecond)
+	assert.Equal(t, CircuitBreakerClosed, b.getState())
+	b.record(nil, time.Second)
+	assert.Equal(t, CircuitBreakerOpen, b.getState())
+}
+
+func TestCircuitBreakerAnswersReadsFromLastKnownDataWhileOpen(t *testing.T) {
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Hour})
+	require.NoError(t, store.Init(allData))
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	item, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, sharedtest.FlagDesc(testFlag1), item)
+
+	item, err = store.Get(ldstoreimpl.Features(), "unknown-flag")
+	require.NoError(t, err)
+	assert.Nil(t, item.Item)
+
+	items, err := store.GetAll(ldstoreimpl.Segments())
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, sharedtest.SegmentDesc(testSegment1), items[0].Item)
+}
+
+func TestCircuitBreakerReturnsErrorWhileOpenIfDataIsNotKnown(t *testing.T) {
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Hour})
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	_, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	assert.Equal(t, errStoreCircuitOpen, err)
+	_, err = store.GetAll(ldstoreimpl.Features())
+	assert.Equal(t, errStoreCircuitOpen, err)
+}
+
+func TestCircuitBreakerLastKnownDataIncludesUpdates(t *testing.T) {
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Hour})
+	require.NoError(t, store.Init(allData))
+	_, err := store.Upsert(ldstoreimpl.Features(), testFlag2.Key, sharedtest.FlagDesc(testFlag2))
+	require.NoError(t, err)
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	item, err := store.Get(ldstoreimpl.Features(), testFlag2.Key)
+	require.NoError(t, err)
+	assert.Equal(t, sharedtest.FlagDesc(testFlag2), item)
+}
+
+func TestCircuitBreakerClosesIfTrialReadSucceeds(t *testing.T) {
+	openDuration := time.Millisecond * 10
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: openDuration})
+	var states []CircuitBreakerState
+	handler := func(state CircuitBreakerState) { states = append(states, state) }
+	store.breaker.handler.Store(&handler)
+	require.NoError(t, store.Init(allData))
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	baseStore.fakeError = nil
+	time.Sleep(openDuration)
+	_, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, CircuitBreakerClosed, store.breaker.getState())
+	assert.Equal(t, []CircuitBreakerState{CircuitBreakerOpen, CircuitBreakerHalfOpen, CircuitBreakerClosed}, states)
+}
+
+func TestCircuitBreakerReopensIfTrialReadFails(t *testing.T) {
+	openDuration := time.Millisecond * 10
+	baseStore, store := makeTestComponentsWithBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: openDuration})
+	require.NoError(t, store.Init(allData))
+	baseStore.fakeError = fakeError
+	_, _ = store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	time.Sleep(openDuration)
+	_, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	assert.Equal(t, fakeError, err)
+	assert.Equal(t, CircuitBreakerOpen, store.breaker.getState())
+
+	// the next read doesn't go to the store, since the circuit has just reopened
+	item, err := store.Get(ldstoreimpl.Features(), testFlag1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, sharedtest.FlagDesc(testFlag1), item)
+}
@@ -1,13 +1,23 @@
 package store
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/streams"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/pborman/uuid"
 )
 
 // SSERelayDataStoreAdapter is used to create the data store wrapper that manages updates. When data is
@@ -28,9 +38,83 @@ type SSERelayDataStoreAdapter struct {
 	store          subsystems.DataStore
 	wrappedFactory subsystems.ComponentConfigurer[subsystems.DataStore]
 	updates        streams.EnvStreamUpdates
+	writeRetry     WriteRetryConfig
+	breaker        *circuitBreaker
+	updateCounter  *updateCounter
+	flagChanges    *flagChangeTracker
 	mu             sync.RWMutex
 }
 
+// UpdateCounts contains the number of individual flag and segment updates that a data store has
+// received from the data source since it was created. Full data sets from Init are not counted.
+type UpdateCounts struct {
+	Flags    int64
+	Segments int64
+}
+
+type updateCounter struct {
+	flags      atomic.Int64
+	segments   atomic.Int64
+	lastUpdate atomic.Int64 // Unix time in nanoseconds, or zero if no data has been received
+	handler    atomic.Pointer[func(ldstoretypes.DataKind)]
+}
+
+// flagChangeTracker remembers the version of each flag that was last written to the data store, so that
+// it can report which flags really changed. The versions are nil until the first full data set is
+// received, since there is nothing to compare that data set to.
+//
+// It also remembers the write sequence number of the last change to each flag, including deletions and
+// flags that were removed by a full data set. The sequence number is incremented every time flag data is
+// written; unlike flag versions, which are independent for each flag, these numbers can be compared across
+// flags, so they can be used as a cursor for finding out which flags changed since a previous request.
+type flagChangeTracker struct {
+	versions  map[string]int
+	sequences map[string]int64
+	sequence  int64
+	epoch     string
+	handler   atomic.Pointer[func(key string, version int)]
+	lock      sync.Mutex
+}
+
+// FlagChangeCursor identifies a point in the history of flag changes in a data store. Epoch is a random
+// value that is chosen when the data store adapter is created, so a cursor that was obtained from a Relay
+// instance that has since restarted, or from a different Relay instance, never matches the current one.
+type FlagChangeCursor struct {
+	Epoch    string
+	Sequence int64
+}
+
+var errInvalidFlagChangeCursor = errors.New("flag change cursor must be in the format epoch:sequence")
+
+// ParseFlagChangeCursor parses a string that was produced by FlagChangeCursor.String.
+func ParseFlagChangeCursor(s string) (FlagChangeCursor, error) {
+	epoch, sequenceStr, ok := strings.Cut(s, ":")
+	if !ok || epoch == "" {
+		return FlagChangeCursor{}, errInvalidFlagChangeCursor
+	}
+	sequence, err := strconv.ParseInt(sequenceStr, 10, 64)
+	if err != nil || sequence < 0 {
+		return FlagChangeCursor{}, errInvalidFlagChangeCursor
+	}
+	return FlagChangeCursor{Epoch: epoch, Sequence: sequence}, nil
+}
+
+// String returns the cursor in the format epoch:sequence.
+func (c FlagChangeCursor) String() string {
+	return fmt.Sprintf("%s:%d", c.Epoch, c.Sequence)
+}
+
+// WriteRetryConfig controls how SSERelayDataStoreAdapter retries a failed write to the underlying data
+// store. MaxAttempts is the total number of attempts, including the first one; a value of 1 or less means
+// that writes are never retried. The delay before each retry starts at InitialDelay and doubles after each
+// failed attempt, up to maxWriteRetryDelay.
+type WriteRetryConfig struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+}
+
+const maxWriteRetryDelay = time.Second * 5
+
 // DataStoreProvider is an interface implemented by SSERelayDataStoreAdapter, describing a component that
 // may or may not yet have a data store.
 type DataStoreProvider interface {
@@ -55,14 +139,81 @@ func (a *SSERelayDataStoreAdapter) GetUpdates() streams.EnvStreamUpdates {
 	return updates
 }
 
+// SetUpdateHandler specifies a function to be called whenever the data store receives an update to a
+// single flag or segment.
+func (a *SSERelayDataStoreAdapter) SetUpdateHandler(fn func(kind ldstoretypes.DataKind)) {
+	a.updateCounter.handler.Store(&fn)
+}
+
+// SetFlagChangeHandler specifies a function to be called whenever a flag in the data store changes, with
+// the key and new version of the flag. Unlike the handler for SetUpdateHandler, this is not called for
+// an update that the data store did not apply because it already had that version or a newer one. When
+// the data store receives a full data set, it is called for each flag that was added, removed, or given
+// a different version, except for the first data set since there is nothing to compare it to.
+func (a *SSERelayDataStoreAdapter) SetFlagChangeHandler(fn func(key string, version int)) {
+	a.flagChanges.handler.Store(&fn)
+}
+
+// GetFlagChangesSince returns the keys of the flags that were added, changed, or deleted in the data store
+// after the specified cursor, mapped to the sequence number of each flag's last change. It also returns the
+// current cursor, which is the value to pass as since in order to get only the changes that happen after
+// this call. If since is not a cursor that was returned by this data store adapter (for instance, if it is
+// the zero value, or if it came from before a restart), the returned map is nil, meaning that the caller
+// cannot know which flags changed and should treat all flags as changed.
+func (a *SSERelayDataStoreAdapter) GetFlagChangesSince(since FlagChangeCursor) (map[string]int64, FlagChangeCursor) {
+	return a.flagChanges.changesSince(since)
+}
+
+// GetUpdateCounts returns the number of flag and segment updates the data store has received.
+func (a *SSERelayDataStoreAdapter) GetUpdateCounts() UpdateCounts {
+	return UpdateCounts{
+		Flags:    a.updateCounter.flags.Load(),
+		Segments: a.updateCounter.segments.Load(),
+	}
+}
+
+// GetLastUpdateTime returns the time when the data store last received data from the data source, either
+// a full data set or an update to a single flag or segment, or a zero time if it has not received any. This
+// includes updates that the data store did not apply because it already had that version, since those still
+// show that the data is current.
+func (a *SSERelayDataStoreAdapter) GetLastUpdateTime() time.Time {
+	if t := a.updateCounter.lastUpdate.Load(); t != 0 {
+		return time.Unix(0, t)
+	}
+	return time.Time{}
+}
+
+// GetCircuitBreakerState returns the state of the data store circuit breaker, or an empty string if
+// there is no circuit breaker.
+func (a *SSERelayDataStoreAdapter) GetCircuitBreakerState() CircuitBreakerState {
+	if a.breaker == nil {
+		return ""
+	}
+	return a.breaker.getState()
+}
+
+// SetCircuitBreakerHandler specifies a function to be called whenever the state of the data store
+// circuit breaker changes. It has no effect if there is no circuit breaker.
+func (a *SSERelayDataStoreAdapter) SetCircuitBreakerHandler(fn func(state CircuitBreakerState)) {
+	if a.breaker != nil {
+		a.breaker.handler.Store(&fn)
+	}
+}
+
 // NewSSERelayDataStoreAdapter creates a new instance where the store has not yet been created.
 func NewSSERelayDataStoreAdapter(
 	wrappedFactory subsystems.ComponentConfigurer[subsystems.DataStore],
 	updates streams.EnvStreamUpdates,
+	writeRetry WriteRetryConfig,
+	breakerConfig CircuitBreakerConfig,
 ) *SSERelayDataStoreAdapter {
 	return &SSERelayDataStoreAdapter{
 		wrappedFactory: wrappedFactory,
 		updates:        updates,
+		writeRetry:     writeRetry,
+		breaker:        newCircuitBreaker(breakerConfig),
+		updateCounter:  &updateCounter{},
+		flagChanges:    newFlagChangeTracker(),
 	}
 }
 
@@ -78,6 +229,10 @@ func (a *SSERelayDataStoreAdapter) Build(
 	sw = newStreamUpdatesStoreWrapper(
 		a.updates,
 		wrappedStore,
+		a.writeRetry,
+		a.breaker,
+		a.updateCounter,
+		a.flagChanges,
 		context.GetLogging().Loggers,
 	)
 
@@ -90,24 +245,55 @@ func (a *SSERelayDataStoreAdapter) Build(
 // A DataStore implementation that delegates to an underlying store but also publish
 // but also publishes stream updates when the store is modified.
 type streamUpdatesStoreWrapper struct {
-	store   subsystems.DataStore
-	updates streams.EnvStreamUpdates
-	loggers ldlog.Loggers
+	store      subsystems.DataStore
+	updates    streams.EnvStreamUpdates
+	writeRetry WriteRetryConfig
+	breaker    *circuitBreaker
+	counter    *updateCounter
+	changes    *flagChangeTracker
+	loggers    ldlog.Loggers
 }
 
 func newStreamUpdatesStoreWrapper(
 	updates streams.EnvStreamUpdates,
 	baseFeatureStore subsystems.DataStore,
+	writeRetry WriteRetryConfig,
+	breaker *circuitBreaker,
+	counter *updateCounter,
+	changes *flagChangeTracker,
 	loggers ldlog.Loggers,
 ) *streamUpdatesStoreWrapper {
 	relayStore := &streamUpdatesStoreWrapper{
-		store:   baseFeatureStore,
-		updates: updates,
-		loggers: loggers,
+		store:      baseFeatureStore,
+		updates:    updates,
+		writeRetry: writeRetry,
+		breaker:    breaker,
+		counter:    counter,
+		changes:    changes,
+		loggers:    loggers,
 	}
 	return relayStore
 }
 
+// withWriteRetry calls write until it succeeds or the configured number of attempts is used up, and
+// returns the last error.
+func (sw *streamUpdatesStoreWrapper) withWriteRetry(description string, write func() error) error {
+	delay := sw.writeRetry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt >= sw.writeRetry.MaxAttempts {
+			return err
+		}
+		sw.loggers.Debugf("Data store write failed for %s (attempt %d of %d), retrying in %s: %s",
+			description, attempt, sw.writeRetry.MaxAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxWriteRetryDelay {
+			delay = maxWriteRetryDelay
+		}
+	}
+}
+
 func (sw *streamUpdatesStoreWrapper) Close() error {
 	return sw.store.Close()
 }
@@ -117,19 +303,53 @@ func (sw *streamUpdatesStoreWrapper) IsStatusMonitoringEnabled() bool {
 }
 
 func (sw *streamUpdatesStoreWrapper) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
-	return sw.store.Get(kind, key)
+	if sw.breaker == nil {
+		return sw.store.Get(kind, key)
+	}
+	if !sw.breaker.allow() {
+		return sw.breaker.lastData.get(kind, key)
+	}
+	startTime := time.Now()
+	item, err := sw.store.Get(kind, key)
+	sw.breaker.record(err, time.Since(startTime))
+	if err == nil {
+		sw.breaker.lastData.upsert(kind, key, item)
+	}
+	return item, err
 }
 
 func (sw *streamUpdatesStoreWrapper) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
-	return sw.store.GetAll(kind)
+	if sw.breaker == nil {
+		return sw.store.GetAll(kind)
+	}
+	if !sw.breaker.allow() {
+		return sw.breaker.lastData.getAll(kind)
+	}
+	startTime := time.Now()
+	items, err := sw.store.GetAll(kind)
+	sw.breaker.record(err, time.Since(startTime))
+	if err == nil {
+		sw.breaker.lastData.setAll(kind, items)
+	}
+	return items, err
 }
 
 func (sw *streamUpdatesStoreWrapper) Init(allData []ldstoretypes.Collection) error {
 	sw.loggers.Debug("Received all feature flags")
-	err := sw.store.Init(allData)
+	err := sw.withWriteRetry("all data", func() error {
+		return sw.store.Init(allData)
+	})
 
-	// See comments in Upsert for why we call SendAllDataUpdate here even if Init returned an error.
+	// See comments in Upsert for why we call SendAllDataUpdate here even if Init returned an error. For
+	// the same reason, the data is what the circuit breaker should fall back to even if the write failed.
 	sw.updates.SendAllDataUpdate(allData)
+	sw.counter.recordAll()
+	if sw.breaker != nil {
+		sw.breaker.lastData.init(allData)
+	}
+	if err == nil {
+		sw.changes.init(allData)
+	}
 
 	return err
 }
@@ -140,7 +360,12 @@ func (sw *streamUpdatesStoreWrapper) Upsert(
 	item ldstoretypes.ItemDescriptor,
 ) (bool, error) {
 	sw.loggers.Debugf(`Received feature flag update: %s (version %d)`, key, item.Version)
-	updated, err := sw.store.Upsert(kind, key, item)
+	var updated bool
+	err := sw.withWriteRetry(key, func() error {
+		var err error
+		updated, err = sw.store.Upsert(kind, key, item)
+		return err
+	})
 
 	// Note that Upsert returns two values; the first is a boolean which is true if it really did the update,
 	// or false if it did not because the store already contained an equal or greater version number.
@@ -164,6 +389,13 @@ func (sw *streamUpdatesStoreWrapper) Upsert(
 	// truth.
 
 	sw.updates.SendSingleItemUpdate(kind, key, item)
+	sw.counter.record(kind)
+	if sw.breaker != nil {
+		sw.breaker.lastData.upsert(kind, key, item)
+	}
+	if updated && kind == ldstoreimpl.Features() {
+		sw.changes.upsert(key, item.Version)
+	}
 
 	return updated, err
 }
@@ -171,3 +403,109 @@ func (sw *streamUpdatesStoreWrapper) Upsert(
 func (sw *streamUpdatesStoreWrapper) IsInitialized() bool {
 	return sw.store.IsInitialized()
 }
+
+func (c *updateCounter) record(kind ldstoretypes.DataKind) {
+	switch kind {
+	case ldstoreimpl.Features():
+		c.flags.Add(1)
+	case ldstoreimpl.Segments():
+		c.segments.Add(1)
+	}
+	c.lastUpdate.Store(time.Now().UnixNano())
+	if fn := c.handler.Load(); fn != nil {
+		(*fn)(kind)
+	}
+}
+
+func (c *updateCounter) recordAll() {
+	c.lastUpdate.Store(time.Now().UnixNano())
+}
+
+func (t *flagChangeTracker) init(allData []ldstoretypes.Collection) {
+	newVersions := make(map[string]int)
+	for _, coll := range allData {
+		if coll.Kind == ldstoreimpl.Features() {
+			for _, item := range coll.Items {
+				newVersions[item.Key] = item.Item.Version
+			}
+		}
+	}
+
+	t.lock.Lock()
+	oldVersions := t.versions
+	t.versions = newVersions
+	var changedKeys []string
+	for key, version := range newVersions {
+		if oldVersion, ok := oldVersions[key]; !ok || oldVersion != version {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	for key := range oldVersions {
+		if _, ok := newVersions[key]; !ok {
+			changedKeys = append(changedKeys, key)
+		}
+	}
+	t.recordSequence(changedKeys...)
+	t.lock.Unlock()
+
+	if oldVersions == nil {
+		return
+	}
+	sort.Strings(changedKeys)
+	for _, key := range changedKeys {
+		version, ok := newVersions[key]
+		if !ok {
+			version = oldVersions[key] // a removed flag is reported with the last version we saw
+		}
+		t.notify(key, version)
+	}
+}
+
+func newFlagChangeTracker() *flagChangeTracker {
+	return &flagChangeTracker{epoch: uuid.New()}
+}
+
+func (t *flagChangeTracker) upsert(key string, version int) {
+	t.lock.Lock()
+	if t.versions != nil {
+		t.versions[key] = version
+	}
+	t.recordSequence(key)
+	t.lock.Unlock()
+	t.notify(key, version)
+}
+
+// recordSequence increments the write sequence number and stores it for each of the specified keys. The
+// caller must hold the lock, so that changesSince never returns a current sequence number without also
+// returning the changes that were made at that sequence number.
+func (t *flagChangeTracker) recordSequence(keys ...string) {
+	t.sequence++
+	if t.sequences == nil {
+		t.sequences = make(map[string]int64)
+	}
+	for _, key := range keys {
+		t.sequences[key] = t.sequence
+	}
+}
+
+func (t *flagChangeTracker) changesSince(since FlagChangeCursor) (map[string]int64, FlagChangeCursor) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	current := FlagChangeCursor{Epoch: t.epoch, Sequence: t.sequence}
+	if since.Epoch != t.epoch || since.Sequence > t.sequence {
+		return nil, current
+	}
+	changes := make(map[string]int64)
+	for key, sequence := range t.sequences {
+		if sequence > since.Sequence {
+			changes[key] = sequence
+		}
+	}
+	return changes, current
+}
+
+func (t *flagChangeTracker) notify(key string, version int) {
+	if fn := t.handler.Load(); fn != nil {
+		(*fn)(key, version)
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 
@@ -9,15 +10,21 @@ import (
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func makeTestComponents() (*mockStore, *streamUpdatesStoreWrapper, *mockEnvStreamsUpdates) {
+	return makeTestComponentsWithRetry(WriteRetryConfig{})
+}
+
+func makeTestComponentsWithRetry(writeRetry WriteRetryConfig) (*mockStore, *streamUpdatesStoreWrapper, *mockEnvStreamsUpdates) {
 	baseStore := &mockStore{realStore: sharedtest.NewInMemoryStore()}
 	updates := &mockEnvStreamsUpdates{}
-	store := newStreamUpdatesStoreWrapper(updates, baseStore, ldlog.NewDisabledLoggers())
+	store := newStreamUpdatesStoreWrapper(updates, baseStore, writeRetry, nil, &updateCounter{}, newFlagChangeTracker(),
+		ldlog.NewDisabledLoggers())
 	return baseStore, store, updates
 }
 
@@ -26,7 +33,7 @@ func TestStoreAdapterLazilyCreatesStore(t *testing.T) {
 	factory := &mockStoreFactory{instance: store}
 	updates := &mockEnvStreamsUpdates{}
 
-	adapter := NewSSERelayDataStoreAdapter(factory, updates)
+	adapter := NewSSERelayDataStoreAdapter(factory, updates, WriteRetryConfig{}, CircuitBreakerConfig{})
 	assert.Nil(t, adapter.GetStore())
 
 	context := subsystems.BasicClientContext{}
@@ -48,7 +55,7 @@ func TestStoreAdapterReturnsErrorIfStoreCannotBeCreated(t *testing.T) {
 	factory.fakeError = fakeError
 	updates := &mockEnvStreamsUpdates{}
 
-	adapter := NewSSERelayDataStoreAdapter(factory, updates)
+	adapter := NewSSERelayDataStoreAdapter(factory, updates, WriteRetryConfig{}, CircuitBreakerConfig{})
 	context := subsystems.BasicClientContext{}
 	created, err := adapter.Build(context)
 
@@ -266,6 +273,201 @@ func TestUpdatesAreSentEvenIfStoreReturnedError(t *testing.T) {
 	})
 }
 
+func TestStoreWritesAreRetried(t *testing.T) {
+	writeRetry := WriteRetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond}
+
+	t.Run("Init succeeds after retry", func(t *testing.T) {
+		baseStore, wrappedStore, updates := makeTestComponentsWithRetry(writeRetry)
+		baseStore.fakeError = fakeError
+		baseStore.fakeWriteErrors = 2
+		err := wrappedStore.Init(allData)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, baseStore.writeAttempts)
+		assert.True(t, baseStore.IsInitialized())
+
+		updates.expectAllDataUpdate(t)
+	})
+
+	t.Run("Upsert succeeds after retry", func(t *testing.T) {
+		baseStore, wrappedStore, updates := makeTestComponentsWithRetry(writeRetry)
+		baseStore.fakeError = fakeError
+		baseStore.fakeWriteErrors = 1
+		updated, err := sharedtest.UpsertFlag(wrappedStore, testFlag1)
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 2, baseStore.writeAttempts)
+
+		updates.expectItemUpdate(t)
+	})
+
+	t.Run("gives up after maximum attempts", func(t *testing.T) {
+		baseStore, wrappedStore, updates := makeTestComponentsWithRetry(writeRetry)
+		baseStore.fakeError = fakeError
+		_, err := sharedtest.UpsertFlag(wrappedStore, testFlag1)
+		assert.Equal(t, fakeError, err)
+		assert.Equal(t, 3, baseStore.writeAttempts)
+
+		updates.expectItemUpdate(t)
+	})
+
+	t.Run("no retries by default", func(t *testing.T) {
+		baseStore, wrappedStore, _ := makeTestComponents()
+		baseStore.fakeError = fakeError
+		_, err := sharedtest.UpsertFlag(wrappedStore, testFlag1)
+		assert.Equal(t, fakeError, err)
+		assert.Equal(t, 1, baseStore.writeAttempts)
+	})
+}
+
+func TestStoreAdapterCountsUpdates(t *testing.T) {
+	factory := &mockStoreFactory{instance: sharedtest.NewInMemoryStore()}
+	adapter := NewSSERelayDataStoreAdapter(factory, &mockEnvStreamsUpdates{}, WriteRetryConfig{}, CircuitBreakerConfig{})
+	var handledKinds []ldstoretypes.DataKind
+	adapter.SetUpdateHandler(func(kind ldstoretypes.DataKind) { handledKinds = append(handledKinds, kind) })
+
+	store, err := adapter.Build(subsystems.BasicClientContext{})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Init(allData))
+	_, _ = sharedtest.UpsertFlag(store, testFlag1)
+	_, _ = sharedtest.UpsertFlag(store, testFlag1) // counted even though the version is unchanged
+	_, _ = sharedtest.UpsertSegment(store, testSegment1)
+
+	assert.Equal(t, UpdateCounts{Flags: 2, Segments: 1}, adapter.GetUpdateCounts())
+	assert.Equal(t, []ldstoretypes.DataKind{ldstoreimpl.Features(), ldstoreimpl.Features(), ldstoreimpl.Segments()},
+		handledKinds)
+}
+
+func TestStoreAdapterRecordsLastUpdateTime(t *testing.T) {
+	factory := &mockStoreFactory{instance: sharedtest.NewInMemoryStore()}
+	adapter := NewSSERelayDataStoreAdapter(factory, &mockEnvStreamsUpdates{}, WriteRetryConfig{}, CircuitBreakerConfig{})
+	store, err := adapter.Build(subsystems.BasicClientContext{})
+	require.NoError(t, err)
+	assert.True(t, adapter.GetLastUpdateTime().IsZero())
+
+	beforeInit := time.Now()
+	require.NoError(t, store.Init(allData))
+	afterInit := adapter.GetLastUpdateTime()
+	assert.False(t, afterInit.Before(beforeInit))
+
+	time.Sleep(time.Millisecond)
+	_, _ = sharedtest.UpsertFlag(store, testFlag1) // recorded even though the version is unchanged
+	assert.True(t, adapter.GetLastUpdateTime().After(afterInit))
+}
+
+func TestStoreAdapterReportsFlagChanges(t *testing.T) {
+	type change struct {
+		key     string
+		version int
+	}
+	makeAdapter := func(t *testing.T) (subsystems.DataStore, *[]change) {
+		factory := &mockStoreFactory{instance: sharedtest.NewInMemoryStore()}
+		adapter := NewSSERelayDataStoreAdapter(factory, &mockEnvStreamsUpdates{}, WriteRetryConfig{}, CircuitBreakerConfig{})
+		var changes []change
+		adapter.SetFlagChangeHandler(func(key string, version int) {
+			changes = append(changes, change{key, version})
+		})
+		store, err := adapter.Build(subsystems.BasicClientContext{})
+		require.NoError(t, err)
+		return store, &changes
+	}
+	makeData := func(flags ...ldstoretypes.KeyedItemDescriptor) []ldstoretypes.Collection {
+		return []ldstoretypes.Collection{{Kind: ldstoreimpl.Features(), Items: flags}, allData[1]}
+	}
+	flagItem := func(key string, version int) ldstoretypes.KeyedItemDescriptor {
+		return ldstoretypes.KeyedItemDescriptor{Key: key,
+			Item: sharedtest.FlagDesc(ldbuilders.NewFlagBuilder(key).Version(version).Build())}
+	}
+
+	t.Run("upserts that are not applied are not reported", func(t *testing.T) {
+		store, changes := makeAdapter(t)
+		require.NoError(t, store.Init(allData))
+		_, _ = sharedtest.UpsertFlag(store, testFlag1) // same version as in allData
+		_, _ = sharedtest.UpsertSegment(store, ldbuilders.NewSegmentBuilder(testSegment1.Key).Version(2).Build())
+		testFlag1v2 := ldbuilders.NewFlagBuilder(testFlag1.Key).Version(2).Build()
+		_, _ = sharedtest.UpsertFlag(store, testFlag1v2)
+		_, _ = sharedtest.UpsertFlag(store, testFlag1) // older version
+
+		assert.Equal(t, []change{{testFlag1.Key, 2}}, *changes)
+	})
+
+	t.Run("first data set is not reported", func(t *testing.T) {
+		store, changes := makeAdapter(t)
+		require.NoError(t, store.Init(makeData(flagItem("flag1", 1), flagItem("flag2", 1))))
+		assert.Len(t, *changes, 0)
+	})
+
+	t.Run("later data sets report added, changed, and removed flags", func(t *testing.T) {
+		store, changes := makeAdapter(t)
+		require.NoError(t, store.Init(makeData(flagItem("flag1", 1), flagItem("flag2", 1), flagItem("flag3", 1))))
+		_, _ = sharedtest.UpsertFlag(store, ldbuilders.NewFlagBuilder("flag3").Version(2).Build())
+		*changes = nil
+
+		require.NoError(t, store.Init(makeData(flagItem("flag1", 1), flagItem("flag3", 4), flagItem("flag4", 1))))
+		assert.Equal(t, []change{{"flag2", 1}, {"flag3", 4}, {"flag4", 1}}, *changes)
+	})
+}
+
+func TestStoreAdapterTracksFlagChangeSequence(t *testing.T) {
+	factory := &mockStoreFactory{instance: sharedtest.NewInMemoryStore()}
+	adapter := NewSSERelayDataStoreAdapter(factory, &mockEnvStreamsUpdates{}, WriteRetryConfig{}, CircuitBreakerConfig{})
+	store, err := adapter.Build(subsystems.BasicClientContext{})
+	require.NoError(t, err)
+	flagItem := func(key string, version int) ldstoretypes.KeyedItemDescriptor {
+		return ldstoretypes.KeyedItemDescriptor{Key: key,
+			Item: sharedtest.FlagDesc(ldbuilders.NewFlagBuilder(key).Version(version).Build())}
+	}
+	makeData := func(flags ...ldstoretypes.KeyedItemDescriptor) []ldstoretypes.Collection {
+		return []ldstoretypes.Collection{{Kind: ldstoreimpl.Features(), Items: flags}, allData[1]}
+	}
+
+	changes, start := adapter.GetFlagChangesSince(FlagChangeCursor{})
+	assert.Nil(t, changes) // the zero cursor means we don't know which flags changed
+	require.NoError(t, store.Init(makeData(flagItem("flag1", 100), flagItem("flag2", 1), flagItem("flag3", 1))))
+	changes, afterInit := adapter.GetFlagChangesSince(start)
+	assert.Equal(t, start.Epoch, afterInit.Epoch)
+	assert.Greater(t, afterInit.Sequence, start.Sequence)
+	assert.Equal(t, map[string]int64{"flag1": afterInit.Sequence, "flag2": afterInit.Sequence,
+		"flag3": afterInit.Sequence}, changes)
+
+	// flag2 has a much lower version than flag1, but it is still reported as changed after flag1's write
+	_, _ = sharedtest.UpsertFlag(store, ldbuilders.NewFlagBuilder("flag1").Version(101).Build())
+	_, afterFlag1 := adapter.GetFlagChangesSince(afterInit)
+	_, _ = sharedtest.UpsertFlag(store, ldbuilders.NewFlagBuilder("flag2").Version(2).Build())
+	changes, afterFlag2 := adapter.GetFlagChangesSince(afterFlag1)
+	assert.Equal(t, map[string]int64{"flag2": afterFlag2.Sequence}, changes)
+
+	_, _ = sharedtest.UpsertFlag(store, ldbuilders.NewFlagBuilder("flag2").Version(2).Build()) // not applied
+	changes, current := adapter.GetFlagChangesSince(afterFlag2)
+	assert.Len(t, changes, 0)
+	assert.Equal(t, afterFlag2, current)
+
+	require.NoError(t, store.Init(makeData(flagItem("flag1", 101), flagItem("flag2", 2))))
+	changes, current = adapter.GetFlagChangesSince(afterFlag2)
+	assert.Equal(t, map[string]int64{"flag3": current.Sequence}, changes) // removed by the new data set
+
+	// A cursor from another adapter, as if Relay had restarted, doesn't match even if its sequence number
+	// is lower than the current one
+	otherAdapter := NewSSERelayDataStoreAdapter(factory, &mockEnvStreamsUpdates{}, WriteRetryConfig{},
+		CircuitBreakerConfig{})
+	_, otherCursor := otherAdapter.GetFlagChangesSince(FlagChangeCursor{})
+	assert.NotEqual(t, current.Epoch, otherCursor.Epoch)
+	changes, _ = adapter.GetFlagChangesSince(FlagChangeCursor{Epoch: otherCursor.Epoch, Sequence: 1})
+	assert.Nil(t, changes)
+}
+
+func TestParseFlagChangeCursor(t *testing.T) {
+	cursor, err := ParseFlagChangeCursor("abc:42")
+	require.NoError(t, err)
+	assert.Equal(t, FlagChangeCursor{Epoch: "abc", Sequence: 42}, cursor)
+	assert.Equal(t, "abc:42", cursor.String())
+
+	for _, s := range []string{"", "42", ":42", "abc:", "abc:x", "abc:-1"} {
+		_, err := ParseFlagChangeCursor(s)
+		assert.Error(t, err, s)
+	}
+}
+
 func TestStoreIsInitialized(t *testing.T) {
 	baseStore, wrappedStore, _ := makeTestComponents()
 	assert.False(t, wrappedStore.IsInitialized())
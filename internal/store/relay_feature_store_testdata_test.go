@@ -46,6 +46,8 @@ var (
 type mockStore struct {
 	realStore        subsystems.DataStore
 	fakeError        error
+	fakeWriteErrors  int // if nonzero, only this many writes return fakeError
+	writeAttempts    int
 	statusMonitoring bool
 	closed           bool
 }
@@ -62,9 +64,19 @@ type mockEnvStreamsUpdates struct {
 	singleItem []sharedtest.ReceivedItemUpdate
 }
 
+func (s *mockStore) writeError() error {
+	s.writeAttempts++
+	if s.fakeWriteErrors > 0 {
+		if s.writeAttempts > s.fakeWriteErrors {
+			return nil
+		}
+	}
+	return s.fakeError
+}
+
 func (s *mockStore) Init(allData []ldstoretypes.Collection) error {
-	if s.fakeError != nil {
-		return s.fakeError
+	if err := s.writeError(); err != nil {
+		return err
 	}
 	return s.realStore.Init(allData)
 }
@@ -84,8 +96,8 @@ func (s *mockStore) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItem
 }
 
 func (s *mockStore) Upsert(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) (bool, error) {
-	if s.fakeError != nil {
-		return false, s.fakeError
+	if err := s.writeError(); err != nil {
+		return false, err
 	}
 	return s.realStore.Upsert(kind, key, item)
 }
@@ -0,0 +1,57 @@
+package streams
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// connTimeLimit describes how long a stream connection from an SDK may stay open before Relay closes it,
+// so that the SDK reconnects (possibly to a different Relay instance).
+type connTimeLimit struct {
+	maxConnTime time.Duration
+	jitter      time.Duration
+}
+
+// MaxConnTimeForConnection returns how long a single stream connection may stay open, given the configured
+// maximum connection time and jitter. If jitter is greater than zero, a random amount of time that is less
+// than jitter is subtracted from maxConnTime, so that connections that were opened at the same time are
+// not all closed at the same time. It returns zero (no limit) if maxConnTime is zero.
+func MaxConnTimeForConnection(maxConnTime, jitter time.Duration) time.Duration {
+	if maxConnTime <= 0 {
+		return 0
+	}
+	if jitter <= 0 || jitter >= maxConnTime {
+		return maxConnTime
+	}
+	return maxConnTime - time.Duration(rand.Int63n(int64(jitter))) //nolint:gosec // doesn't need to be cryptographically secure
+}
+
+// CloseStreamGracefully writes a final heartbeat comment to a stream whose connection time limit has
+// elapsed, just before the handler returns and the connection is closed.
+func CloseStreamGracefully(w http.ResponseWriter) {
+	_, _ = io.WriteString(w, ":\n")
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// wrap returns a handler that closes the stream once the connection time limit has elapsed. The
+// eventsource server ends the stream when the request context is done, so we impose the limit by giving
+// it a context with a deadline, rather than using its own MaxConnTime, which cannot be varied per
+// connection and does not let us send a final heartbeat.
+func (l connTimeLimit) wrap(handler http.HandlerFunc) http.HandlerFunc {
+	if handler == nil || l.maxConnTime <= 0 {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), MaxConnTimeForConnection(l.maxConnTime, l.jitter))
+		defer cancel()
+		handler(w, req.WithContext(ctx))
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			CloseStreamGracefully(w)
+		}
+	}
+}
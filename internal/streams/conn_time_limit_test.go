@@ -0,0 +1,61 @@
+package streams
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxConnTimeForConnection(t *testing.T) {
+	assert.Equal(t, time.Duration(0), MaxConnTimeForConnection(0, time.Second))
+	assert.Equal(t, time.Minute, MaxConnTimeForConnection(time.Minute, 0))
+	assert.Equal(t, time.Minute, MaxConnTimeForConnection(time.Minute, time.Minute))
+
+	for i := 0; i < 100; i++ {
+		d := MaxConnTimeForConnection(time.Minute, 10*time.Second)
+		assert.Greater(t, d, 50*time.Second)
+		assert.LessOrEqual(t, d, time.Minute)
+	}
+}
+
+func TestConnTimeLimit(t *testing.T) {
+	waitForClose := func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		_, _ = w.Write([]byte("data\n"))
+	}
+
+	t.Run("no limit", func(t *testing.T) {
+		assert.Nil(t, connTimeLimit{maxConnTime: time.Minute}.wrap(nil))
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			_, hasDeadline := req.Context().Deadline()
+			assert.False(t, hasDeadline)
+		}
+		req, _ := http.NewRequest("GET", "", nil)
+		connTimeLimit{}.wrap(handler)(httptest.NewRecorder(), req)
+	})
+
+	t.Run("closes stream with final heartbeat", func(t *testing.T) {
+		maxConnTime := 50 * time.Millisecond
+		req, _ := http.NewRequest("GET", "", nil)
+		w := httptest.NewRecorder()
+		startTime := time.Now()
+		connTimeLimit{maxConnTime: maxConnTime, jitter: maxConnTime / 2}.wrap(waitForClose)(w, req)
+		assert.GreaterOrEqual(t, time.Since(startTime), maxConnTime/2)
+		assert.Equal(t, "data\n:\n", w.Body.String())
+		assert.True(t, w.Flushed)
+	})
+
+	t.Run("no final heartbeat if client closed the stream", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "", nil)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel()
+		w := httptest.NewRecorder()
+		connTimeLimit{maxConnTime: time.Minute}.wrap(waitForClose)(w, req.WithContext(ctx))
+		assert.Equal(t, "data\n", w.Body.String())
+	})
+}
@@ -2,6 +2,7 @@ package streams
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
@@ -53,6 +54,7 @@ type EnvStreams struct {
 	closeCh         chan struct{}
 	heartbeatsDone  chan struct{} // used in testing only
 	filterKey       config.FilterKey
+	oversizedEvents atomic.Pointer[OversizedEventHandler]
 }
 
 type streamInfo struct {
@@ -113,7 +115,7 @@ func (es *EnvStreams) AddCredential(credential credential.SDKCredential) {
 	}
 	scopedCred := sdkauth.NewScoped(es.filterKey, credential)
 	for _, sp := range es.streamProviders {
-		if esp := sp.Register(scopedCred, es.storeQueries, es.loggers); esp != nil {
+		if esp := sp.Register(scopedCred, es.storeQueries, es.loggers, es.reportOversizedEvent); esp != nil {
 			es.lock.Lock()
 			es.activeStreams = append(es.activeStreams, streamInfo{scopedCred, esp})
 			es.lock.Unlock()
@@ -144,6 +146,18 @@ func (es *EnvStreams) RemoveCredential(credential credential.SDKCredential) {
 	}
 }
 
+// SetOversizedEventHandler specifies a function to be called whenever a stream event for this environment
+// is not sent because it exceeds the maximum message size.
+func (es *EnvStreams) SetOversizedEventHandler(fn OversizedEventHandler) {
+	es.oversizedEvents.Store(&fn)
+}
+
+func (es *EnvStreams) reportOversizedEvent(eventName string, size int) {
+	if fn := es.oversizedEvents.Load(); fn != nil && *fn != nil {
+		(*fn)(eventName, size)
+	}
+}
+
 // SendAllDataUpdate sends all appropriate stream updates for when the full data set has been refreshed.
 func (es *EnvStreams) SendAllDataUpdate(
 	allData []ldstoretypes.Collection,
@@ -174,8 +188,12 @@ func (es *EnvStreams) InvalidateClientSideState() {
 // Close shuts down all currently active streams for this environment and releases its resources.
 func (es *EnvStreams) Close() error {
 	close(es.closeCh)
-	for _, esp := range es.getEnvStreamProviders() {
-		esp.Close()
+	es.lock.Lock()
+	active := es.activeStreams
+	es.activeStreams = nil
+	es.lock.Unlock()
+	for _, s := range active {
+		s.envStreamProvider.Close()
 	}
 	return nil
 }
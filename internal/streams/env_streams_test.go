@@ -48,6 +48,7 @@ func (p *mockStreamProvider) Register(
 	credential sdkauth.ScopedCredential,
 	store EnvStoreQueries,
 	loggers ldlog.Loggers,
+	onOversizedEvent OversizedEventHandler,
 ) EnvStreamProvider {
 	if reflect.TypeOf(credential.SDKCredential) != reflect.TypeOf(p.credentialOfDesiredType) {
 		return nil
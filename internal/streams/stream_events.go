@@ -104,6 +104,37 @@ func MakePingEvent() eventsource.Event {
 	// is not published by eventsource, causing the event to be ignored.
 }
 
+// MakeClientSideEvalPutEvent creates a "put" event for client-side SDKs that receive evaluated flag
+// values, rather than "ping" events, on their stream. The data is a JSON object whose properties are
+// flag keys, in the same format as the client-side evaluation endpoints.
+func MakeClientSideEvalPutEvent(data []byte) eventsource.Event {
+	return makeConstantEvent("put", string(data))
+}
+
+// MakeClientSideEvalPatchEvent creates a "patch" event for client-side SDKs that receive evaluated
+// flag values. The data is a single flag's evaluation result, including its "key".
+func MakeClientSideEvalPatchEvent(data []byte) eventsource.Event {
+	return makeConstantEvent("patch", string(data))
+}
+
+// MakeClientSideEvalDeleteEvent creates a "delete" event for client-side SDKs that receive evaluated
+// flag values.
+func MakeClientSideEvalDeleteEvent(key string, version int) eventsource.Event {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	obj.Name("key").String(key)
+	obj.Name("version").Int(version)
+	obj.End()
+	return makeConstantEvent("delete", string(w.Bytes()))
+}
+
+func makeConstantEvent(name, data string) eventsource.Event {
+	return deferredEvent{
+		name:   name,
+		result: util.NewStringMemoizer(func() string { return data }),
+	}
+}
+
 func encodeServerSideFlagsOnlyPutEventData(flags []ldstoretypes.KeyedItemDescriptor) func() string {
 	return func() string {
 		w := jwriter.NewWriter()
@@ -148,3 +148,23 @@ func TestMakePingEvent(t *testing.T) {
 	assert.Equal(t, " ", event.Data())
 	assert.Equal(t, "", event.Id())
 }
+
+func TestMakeClientSideEvalEvents(t *testing.T) {
+	t.Run("put", func(t *testing.T) {
+		event := MakeClientSideEvalPutEvent([]byte(`{"flag1":{"value":true,"version":1}}`))
+		assert.Equal(t, "put", event.Event())
+		assert.Equal(t, `{"flag1":{"value":true,"version":1}}`, event.Data())
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		event := MakeClientSideEvalPatchEvent([]byte(`{"key":"flag1","value":true,"version":2}`))
+		assert.Equal(t, "patch", event.Event())
+		assert.Equal(t, `{"key":"flag1","value":true,"version":2}`, event.Data())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		event := MakeClientSideEvalDeleteEvent("flag1", 3)
+		assert.Equal(t, "delete", event.Event())
+		assert.JSONEq(t, `{"key":"flag1","version":3}`, event.Data())
+	})
+}
@@ -27,8 +27,14 @@ type StreamProvider interface {
 
 	// Register tells the StreamProvider about an environment that it should support, and returns an
 	// implementation of EnvStreamsUpdates for pushing updates related to that environment. It can
-	// return nil if it does not support this type of credential.
-	Register(credential sdkauth.ScopedCredential, store EnvStoreQueries, loggers ldlog.Loggers) EnvStreamProvider
+	// return nil if it does not support this type of credential. If onOversizedEvent is not nil, it is
+	// called whenever an event for this environment is not sent because of the maximum message size.
+	Register(
+		credential sdkauth.ScopedCredential,
+		store EnvStoreQueries,
+		loggers ldlog.Loggers,
+		onOversizedEvent OversizedEventHandler,
+	) EnvStreamProvider
 
 	// Close tells the StreamProvider to release all of its resources and close all connections.
 	Close()
@@ -46,39 +52,102 @@ type EnvStreamProvider interface {
 	Close()
 }
 
+// OversizedEventHandler is a function that is called when a stream event is not sent because its data is
+// larger than the maximum message size. It receives the SSE event name, such as "put", and the data size.
+type OversizedEventHandler func(eventName string, size int)
+
 // NewStreamProvider creates a StreamProvider implementation for the specified kind of stream endpoint.
-func NewStreamProvider(kind basictypes.StreamKind, maxConnTime time.Duration) StreamProvider {
+//
+// If maxConnTime is greater than zero, each stream connection is closed after that amount of time, minus
+// a random amount of time less than maxConnTimeJitter, with a final heartbeat before it is closed.
+//
+// If maxMessageSize is greater than zero, server-side streams will not publish any event whose data is
+// larger than that many bytes; instead they log a warning. SSE has no way for SDKs to reassemble a message
+// that was split into pieces, so dropping the event is the only safe option. Client-side ping streams
+// never include flag data, so the limit does not apply to them.
+//
+// If pingCoalesceTime is greater than zero, client-side ping streams will send at most one "ping" event
+// per pingCoalesceTime in response to data updates, so that a burst of changes causes clients to refetch
+// their flags only once. Server-side streams ignore this parameter.
+func NewStreamProvider(
+	kind basictypes.StreamKind,
+	maxConnTime time.Duration,
+	maxConnTimeJitter time.Duration,
+	maxMessageSize int,
+	pingCoalesceTime time.Duration,
+) StreamProvider {
+	connTime := connTimeLimit{maxConnTime: maxConnTime, jitter: maxConnTimeJitter}
 	switch kind {
 	case basictypes.ServerSideFlagsOnlyStream:
 		return &serverSideFlagsOnlyStreamProvider{
-			server: newSSEServer(maxConnTime),
+			server:         newSSEServer(),
+			connTime:       connTime,
+			maxMessageSize: maxMessageSize,
 		}
 	case basictypes.MobilePingStream:
 		return &clientSidePingStreamProvider{
-			server:     newSSEServer(maxConnTime),
-			isJSClient: false,
+			server:       newSSEServer(),
+			connTime:     connTime,
+			isJSClient:   false,
+			coalesceTime: pingCoalesceTime,
 		}
 	case basictypes.JSClientPingStream:
 		return &clientSidePingStreamProvider{
-			server:     newSSEServer(maxConnTime),
-			isJSClient: true,
+			server:       newSSEServer(),
+			connTime:     connTime,
+			isJSClient:   true,
+			coalesceTime: pingCoalesceTime,
 		}
 	default:
 		return &serverSideStreamProvider{
-			server: newSSEServer(maxConnTime),
+			server:         newSSEServer(),
+			connTime:       connTime,
+			maxMessageSize: maxMessageSize,
 		}
 	}
 }
 
-func newSSEServer(maxConnTime time.Duration) *eventsource.Server {
+func newSSEServer() *eventsource.Server {
 	s := eventsource.NewServer()
 	s.Gzip = false
 	s.AllowCORS = true
 	s.ReplayAll = true
-	s.MaxConnTime = maxConnTime
 	return s
 }
 
+// isWithinMaxMessageSize returns true if maxMessageSize is zero (no limit) or if the event's data is no
+// larger than maxMessageSize bytes. Otherwise it logs a warning, calls onOversizedEvent if it is not nil,
+// and returns false; the caller should then call disconnectSubscribers.
+func isWithinMaxMessageSize(
+	event eventsource.Event,
+	maxMessageSize int,
+	loggers ldlog.Loggers,
+	onOversizedEvent OversizedEventHandler,
+) bool {
+	if maxMessageSize <= 0 {
+		return true
+	}
+	if size := len(event.Data()); size > maxMessageSize {
+		loggers.Warnf("Not sending %q event of %d bytes because it exceeds the maximum SSE message size of %d bytes;"+
+			" closing stream connections so that SDKs do not keep using incomplete data", event.Event(), size, maxMessageSize)
+		if onOversizedEvent != nil {
+			onOversizedEvent(event.Event(), size)
+		}
+		return false
+	}
+	return true
+}
+
+// disconnectSubscribers closes all connections to a stream channel, after we have failed to send an event
+// that its subscribers need because of the maximum message size. Otherwise, an SDK that connected would
+// never be initialized, or an SDK that was already connected would silently keep its stale data; this way,
+// they will retry the connection, or fall back to polling if they are configured to. The repository is
+// registered again so that new connections still get an initial event if it is now small enough.
+func disconnectSubscribers(server *eventsource.Server, channel string, repo eventsource.Repository) {
+	server.Unregister(channel, true)
+	server.Register(channel, repo)
+}
+
 func removeDeleted(items []ldstoretypes.KeyedItemDescriptor) []ldstoretypes.KeyedItemDescriptor {
 	var ret []ldstoretypes.KeyedItemDescriptor
 	for i, keyedItem := range items {
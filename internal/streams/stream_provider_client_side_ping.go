@@ -3,6 +3,7 @@ package streams
 import (
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
@@ -18,16 +19,28 @@ import (
 // This is the standard implementation of a stream for client-side/mobile SDKs that sends only "ping" events,
 // and does not do flag evaluations for specific users. The behavior of this stream is that it sends one "ping"
 // event on initial connection, and another "ping" every time there is a data update of any kind.
+//
+// If coalesceTime is greater than zero, pings caused by updates are sent at most once per coalesceTime: an
+// update that happens within that time after the last ping is deferred until the time has elapsed, and
+// any further updates in the meantime are folded into that same ping. This keeps clients from refetching
+// their flags once per change when many flags are changed at once.
 
 type clientSidePingStreamProvider struct {
-	server     *eventsource.Server
-	isJSClient bool
-	closeOnce  sync.Once
+	server       *eventsource.Server
+	connTime     connTimeLimit
+	isJSClient   bool
+	coalesceTime time.Duration
+	closeOnce    sync.Once
 }
 
 type clientSidePingEnvStreamProvider struct {
-	server   *eventsource.Server
-	channels []string
+	server       *eventsource.Server
+	channels     []string
+	coalesceTime time.Duration
+	lastPing     time.Time
+	pendingPing  *time.Timer
+	closed       bool
+	lock         sync.Mutex
 }
 
 type clientSidePingEnvStreamRepository struct {
@@ -51,20 +64,25 @@ func (s *clientSidePingStreamProvider) Handler(credential sdkauth.ScopedCredenti
 	if !s.validateCredential(credential.SDKCredential) {
 		return nil
 	}
-	return s.server.Handler(credential.String())
+	return s.connTime.wrap(s.server.Handler(credential.String()))
 }
 
 func (s *clientSidePingStreamProvider) Register(
 	credential sdkauth.ScopedCredential,
 	store EnvStoreQueries,
 	loggers ldlog.Loggers,
+	onOversizedEvent OversizedEventHandler,
 ) EnvStreamProvider {
 	if !s.validateCredential(credential.SDKCredential) {
 		return nil
 	}
 	repo := &clientSidePingEnvStreamRepository{store: store}
 	s.server.Register(credential.String(), repo)
-	envStream := &clientSidePingEnvStreamProvider{server: s.server, channels: []string{credential.String()}}
+	envStream := &clientSidePingEnvStreamProvider{
+		server:       s.server,
+		channels:     []string{credential.String()},
+		coalesceTime: s.coalesceTime,
+	}
 	return envStream
 }
 
@@ -75,15 +93,42 @@ func (s *clientSidePingStreamProvider) Close() {
 }
 
 func (e *clientSidePingEnvStreamProvider) SendAllDataUpdate(allData []ldstoretypes.Collection) {
-	e.server.Publish(e.channels, MakePingEvent())
+	e.sendPing()
 }
 
 func (e *clientSidePingEnvStreamProvider) SendSingleItemUpdate(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
-	e.server.Publish(e.channels, MakePingEvent())
+	e.sendPing()
 }
 
 func (e *clientSidePingEnvStreamProvider) InvalidateClientSideState() {
-	e.server.Publish(e.channels, MakePingEvent())
+	e.sendPing()
+}
+
+func (e *clientSidePingEnvStreamProvider) sendPing() {
+	if e.coalesceTime <= 0 {
+		e.server.Publish(e.channels, MakePingEvent())
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.closed || e.pendingPing != nil {
+		return // a ping is already scheduled, and it will tell clients about this update too
+	}
+	wait := e.coalesceTime - time.Since(e.lastPing)
+	if wait <= 0 {
+		e.lastPing = time.Now()
+		e.server.Publish(e.channels, MakePingEvent())
+		return
+	}
+	e.pendingPing = time.AfterFunc(wait, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		e.pendingPing = nil
+		if !e.closed {
+			e.lastPing = time.Now()
+			e.server.Publish(e.channels, MakePingEvent())
+		}
+	})
 }
 
 func (e *clientSidePingEnvStreamProvider) SendHeartbeat() {
@@ -91,6 +136,13 @@ func (e *clientSidePingEnvStreamProvider) SendHeartbeat() {
 }
 
 func (e *clientSidePingEnvStreamProvider) Close() {
+	e.lock.Lock()
+	e.closed = true
+	if e.pendingPing != nil {
+		e.pendingPing.Stop()
+		e.pendingPing = nil
+	}
+	e.lock.Unlock()
 	for _, key := range e.channels {
 		e.server.Unregister(key, true)
 	}
@@ -1,6 +1,7 @@
 package streams
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
@@ -9,10 +10,12 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 
+	"github.com/launchdarkly/eventsource"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,7 +30,7 @@ func TestStreamProviderMobilePing(t *testing.T) {
 	invalidCredential2 := sdkauth.New(testEnvID)
 
 	withStreamProvider := func(t *testing.T, maxConnTime time.Duration, action func(StreamProvider)) {
-		sp := NewStreamProvider(basictypes.MobilePingStream, maxConnTime)
+		sp := NewStreamProvider(basictypes.MobilePingStream, maxConnTime, 0, 0, 0)
 		require.NotNil(t, sp)
 		defer sp.Close()
 		action(sp)
@@ -38,7 +41,7 @@ func TestStreamProviderMobilePing(t *testing.T) {
 		withStreamProvider(t, maxConnTime, func(sp StreamProvider) {
 			require.IsType(t, &clientSidePingStreamProvider{}, sp)
 			assert.False(t, sp.(*clientSidePingStreamProvider).isJSClient)
-			verifyServerProperties(t, sp.(*clientSidePingStreamProvider).server, maxConnTime)
+			verifyServerProperties(t, sp.(*clientSidePingStreamProvider).server, sp.(*clientSidePingStreamProvider).connTime, maxConnTime)
 		})
 	})
 
@@ -53,10 +56,10 @@ func TestStreamProviderMobilePing(t *testing.T) {
 	t.Run("Register", func(t *testing.T) {
 		store := makeMockStore(nil, nil)
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers()))
-			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers()))
+			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers(), nil))
+			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers(), nil))
 
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 			require.IsType(t, &clientSidePingEnvStreamProvider{}, esp)
@@ -70,7 +73,7 @@ func TestStreamProviderJSClientPing(t *testing.T) {
 	invalidCredential2 := sdkauth.New(testMobileKey)
 
 	withStreamProvider := func(t *testing.T, maxConnTime time.Duration, action func(StreamProvider)) {
-		sp := NewStreamProvider(basictypes.JSClientPingStream, maxConnTime)
+		sp := NewStreamProvider(basictypes.JSClientPingStream, maxConnTime, 0, 0, 0)
 		require.NotNil(t, sp)
 		defer sp.Close()
 		action(sp)
@@ -81,7 +84,7 @@ func TestStreamProviderJSClientPing(t *testing.T) {
 		withStreamProvider(t, maxConnTime, func(sp StreamProvider) {
 			require.IsType(t, &clientSidePingStreamProvider{}, sp)
 			assert.True(t, sp.(*clientSidePingStreamProvider).isJSClient)
-			verifyServerProperties(t, sp.(*clientSidePingStreamProvider).server, maxConnTime)
+			verifyServerProperties(t, sp.(*clientSidePingStreamProvider).server, sp.(*clientSidePingStreamProvider).connTime, maxConnTime)
 		})
 	})
 
@@ -96,10 +99,10 @@ func TestStreamProviderJSClientPing(t *testing.T) {
 	t.Run("Register", func(t *testing.T) {
 		store := makeMockStore(nil, nil)
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers()))
-			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers()))
+			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers(), nil))
+			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers(), nil))
 
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 			require.IsType(t, &clientSidePingEnvStreamProvider{}, esp)
@@ -114,7 +117,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 
 	validCredential := sdkauth.New(testMobileKey)
 	withStreamProvider := func(t *testing.T, maxConnTime time.Duration, action func(StreamProvider)) {
-		sp := NewStreamProvider(basictypes.MobilePingStream, maxConnTime)
+		sp := NewStreamProvider(basictypes.MobilePingStream, maxConnTime, 0, 0, 0)
 		require.NotNil(t, sp)
 		defer sp.Close()
 		action(sp)
@@ -124,7 +127,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		store := makeMockStore([]ldmodel.FeatureFlag{testFlag1, testFlag2}, []ldmodel.Segment{testSegment1})
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -137,7 +140,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		store.initialized = false
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -149,7 +152,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -171,7 +174,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -195,7 +198,7 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -203,3 +206,33 @@ func TestStreamProviderAllClientSidePing(t *testing.T) {
 		})
 	})
 }
+
+func TestStreamProviderClientSidePingCoalescing(t *testing.T) {
+	validCredential := sdkauth.New(testMobileKey)
+	coalesceTime := 200 * time.Millisecond
+
+	sp := NewStreamProvider(basictypes.MobilePingStream, 0, 0, 0, coalesceTime)
+	require.NotNil(t, sp)
+	defer sp.Close()
+
+	esp := sp.Register(validCredential, makeMockStore(nil, nil), ldlog.NewDisabledLoggers(), nil)
+	require.NotNil(t, esp)
+	defer esp.Close()
+
+	req, _ := http.NewRequest("GET", "", nil)
+	sharedtest.WithStreamRequest(t, req, sp.Handler(validCredential), func(eventCh <-chan eventsource.Event) {
+		expectEvent(t, eventCh, MakePingEvent())
+
+		// The first update after a quiet period is sent right away.
+		esp.SendSingleItemUpdate(ldstoreimpl.Features(), testFlag1.Key, sharedtest.FlagDesc(testFlag1))
+		expectEvent(t, eventCh, MakePingEvent())
+
+		// Further updates within the coalescing time produce a single deferred ping.
+		esp.SendSingleItemUpdate(ldstoreimpl.Features(), testFlag1.Key, sharedtest.FlagDesc(testFlag1))
+		esp.SendSingleItemUpdate(ldstoreimpl.Features(), testFlag2.Key, sharedtest.FlagDesc(testFlag2))
+		esp.InvalidateClientSideState()
+		expectNoEvent(t, eventCh)
+		expectEvent(t, eventCh, MakePingEvent())
+		helpers.AssertNoMoreValues(t, eventCh, coalesceTime*2, "received unexpected event")
+	})
+}
@@ -18,18 +18,27 @@ import (
 // This is the standard implementation of the /all stream for server-side SDKs.
 
 type serverSideStreamProvider struct {
-	server    *eventsource.Server
-	closeOnce sync.Once
+	server         *eventsource.Server
+	connTime       connTimeLimit
+	maxMessageSize int
+	closeOnce      sync.Once
 }
 
 type serverSideEnvStreamProvider struct {
-	server   *eventsource.Server
-	channels []string
+	server         *eventsource.Server
+	channels       []string
+	repo           *serverSideEnvStreamRepository
+	maxMessageSize int
+	loggers        ldlog.Loggers
+	onOversized    OversizedEventHandler
 }
 
 type serverSideEnvStreamRepository struct {
-	store   EnvStoreQueries
-	loggers ldlog.Loggers
+	server         *eventsource.Server
+	store          EnvStoreQueries
+	loggers        ldlog.Loggers
+	maxMessageSize int
+	onOversized    OversizedEventHandler
 
 	flightGroup singleflight.Group
 }
@@ -38,20 +47,34 @@ func (s *serverSideStreamProvider) Handler(credential sdkauth.ScopedCredential)
 	if _, ok := credential.SDKCredential.(config.SDKKey); !ok {
 		return nil
 	}
-	return s.server.Handler(credential.String())
+	return s.connTime.wrap(s.server.Handler(credential.String()))
 }
 
 func (s *serverSideStreamProvider) Register(
 	credential sdkauth.ScopedCredential,
 	store EnvStoreQueries,
 	loggers ldlog.Loggers,
+	onOversizedEvent OversizedEventHandler,
 ) EnvStreamProvider {
 	if _, ok := credential.SDKCredential.(config.SDKKey); !ok {
 		return nil
 	}
-	repo := &serverSideEnvStreamRepository{store: store, loggers: loggers}
+	repo := &serverSideEnvStreamRepository{
+		server:         s.server,
+		store:          store,
+		loggers:        loggers,
+		maxMessageSize: s.maxMessageSize,
+		onOversized:    onOversizedEvent,
+	}
 	s.server.Register(credential.String(), repo)
-	envStream := &serverSideEnvStreamProvider{server: s.server, channels: []string{credential.String()}}
+	envStream := &serverSideEnvStreamProvider{
+		server:         s.server,
+		repo:           repo,
+		channels:       []string{credential.String()},
+		maxMessageSize: s.maxMessageSize,
+		loggers:        loggers,
+		onOversized:    onOversizedEvent,
+	}
 	return envStream
 }
 
@@ -62,19 +85,29 @@ func (s *serverSideStreamProvider) Close() {
 }
 
 func (e *serverSideEnvStreamProvider) SendAllDataUpdate(allData []ldstoretypes.Collection) {
-	e.server.Publish(e.channels, MakeServerSidePutEvent(allData))
+	e.publish(MakeServerSidePutEvent(allData))
 }
 
 func (e *serverSideEnvStreamProvider) SendSingleItemUpdate(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
 	if item.Item == nil {
-		e.server.Publish(e.channels, MakeServerSideDeleteEvent(kind, key, item.Version))
+		e.publish(MakeServerSideDeleteEvent(kind, key, item.Version))
 	} else {
-		e.server.Publish(e.channels, MakeServerSidePatchEvent(kind, key, item))
+		e.publish(MakeServerSidePatchEvent(kind, key, item))
 	}
 }
 
 func (e *serverSideEnvStreamProvider) InvalidateClientSideState() {}
 
+func (e *serverSideEnvStreamProvider) publish(event eventsource.Event) {
+	if !isWithinMaxMessageSize(event, e.maxMessageSize, e.loggers, e.onOversized) {
+		for _, channel := range e.channels {
+			disconnectSubscribers(e.server, channel, e.repo)
+		}
+		return
+	}
+	e.server.Publish(e.channels, event)
+}
+
 func (e *serverSideEnvStreamProvider) SendHeartbeat() {
 	e.server.PublishComment(e.channels, "")
 }
@@ -101,6 +134,11 @@ func (r *serverSideEnvStreamRepository) Replay(channel, id string) chan eventsou
 		if err != nil {
 			return
 		}
+		if !isWithinMaxMessageSize(event, r.maxMessageSize, r.loggers, r.onOversized) {
+			// This is called from a separate goroutine, so it can't block the eventsource server
+			disconnectSubscribers(r.server, channel, r)
+			return
+		}
 		out <- event
 	}()
 	return out
@@ -18,18 +18,27 @@ import (
 // This is the standard implementation of the /flags stream for old server-side SDKs.
 
 type serverSideFlagsOnlyStreamProvider struct {
-	server    *eventsource.Server
-	closeOnce sync.Once
+	server         *eventsource.Server
+	connTime       connTimeLimit
+	maxMessageSize int
+	closeOnce      sync.Once
 }
 
 type serverSideFlagsOnlyEnvStreamProvider struct {
-	server   *eventsource.Server
-	channels []string
+	server         *eventsource.Server
+	channels       []string
+	repo           *serverSideFlagsOnlyEnvStreamRepository
+	maxMessageSize int
+	loggers        ldlog.Loggers
+	onOversized    OversizedEventHandler
 }
 
 type serverSideFlagsOnlyEnvStreamRepository struct {
-	store   EnvStoreQueries
-	loggers ldlog.Loggers
+	server         *eventsource.Server
+	store          EnvStoreQueries
+	loggers        ldlog.Loggers
+	maxMessageSize int
+	onOversized    OversizedEventHandler
 
 	flightGroup singleflight.Group
 }
@@ -38,20 +47,34 @@ func (s *serverSideFlagsOnlyStreamProvider) Handler(params sdkauth.ScopedCredent
 	if _, ok := params.SDKCredential.(config.SDKKey); !ok {
 		return nil
 	}
-	return s.server.Handler(params.String())
+	return s.connTime.wrap(s.server.Handler(params.String()))
 }
 
 func (s *serverSideFlagsOnlyStreamProvider) Register(
 	params sdkauth.ScopedCredential,
 	store EnvStoreQueries,
 	loggers ldlog.Loggers,
+	onOversizedEvent OversizedEventHandler,
 ) EnvStreamProvider {
 	if _, ok := params.SDKCredential.(config.SDKKey); !ok {
 		return nil
 	}
-	repo := &serverSideFlagsOnlyEnvStreamRepository{store: store, loggers: loggers}
+	repo := &serverSideFlagsOnlyEnvStreamRepository{
+		server:         s.server,
+		store:          store,
+		loggers:        loggers,
+		maxMessageSize: s.maxMessageSize,
+		onOversized:    onOversizedEvent,
+	}
 	s.server.Register(params.String(), repo)
-	envStream := &serverSideFlagsOnlyEnvStreamProvider{server: s.server, channels: []string{params.String()}}
+	envStream := &serverSideFlagsOnlyEnvStreamProvider{
+		server:         s.server,
+		repo:           repo,
+		channels:       []string{params.String()},
+		maxMessageSize: s.maxMessageSize,
+		loggers:        loggers,
+		onOversized:    onOversizedEvent,
+	}
 	return envStream
 }
 
@@ -62,7 +85,7 @@ func (s *serverSideFlagsOnlyStreamProvider) Close() {
 }
 
 func (e *serverSideFlagsOnlyEnvStreamProvider) SendAllDataUpdate(allData []ldstoretypes.Collection) {
-	e.server.Publish(e.channels, MakeServerSideFlagsOnlyPutEvent(allData))
+	e.publish(MakeServerSideFlagsOnlyPutEvent(allData))
 }
 
 func (e *serverSideFlagsOnlyEnvStreamProvider) SendSingleItemUpdate(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
@@ -70,14 +93,24 @@ func (e *serverSideFlagsOnlyEnvStreamProvider) SendSingleItemUpdate(kind ldstore
 		return
 	}
 	if item.Item == nil {
-		e.server.Publish(e.channels, MakeServerSideFlagsOnlyDeleteEvent(key, item.Version))
+		e.publish(MakeServerSideFlagsOnlyDeleteEvent(key, item.Version))
 	} else {
-		e.server.Publish(e.channels, MakeServerSideFlagsOnlyPatchEvent(key, item))
+		e.publish(MakeServerSideFlagsOnlyPatchEvent(key, item))
 	}
 }
 
 func (e *serverSideFlagsOnlyEnvStreamProvider) InvalidateClientSideState() {}
 
+func (e *serverSideFlagsOnlyEnvStreamProvider) publish(event eventsource.Event) {
+	if !isWithinMaxMessageSize(event, e.maxMessageSize, e.loggers, e.onOversized) {
+		for _, channel := range e.channels {
+			disconnectSubscribers(e.server, channel, e.repo)
+		}
+		return
+	}
+	e.server.Publish(e.channels, event)
+}
+
 func (e *serverSideFlagsOnlyEnvStreamProvider) SendHeartbeat() {
 	e.server.PublishComment(e.channels, "")
 }
@@ -97,9 +130,14 @@ func (r *serverSideFlagsOnlyEnvStreamRepository) Replay(channel, id string) chan
 	go func() {
 		defer close(out)
 		event, err := r.getReplayEvent()
-		if err == nil && event != nil {
-			out <- event
+		if err != nil || event == nil {
+			return
+		}
+		if !isWithinMaxMessageSize(event, r.maxMessageSize, r.loggers, r.onOversized) {
+			disconnectSubscribers(r.server, channel, r) // see serverSideEnvStreamRepository.Replay
+			return
 		}
+		out <- event
 	}()
 	return out
 }
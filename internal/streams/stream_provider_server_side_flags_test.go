@@ -24,7 +24,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 	invalidCredential2 := sdkauth.New(testEnvID)
 
 	withStreamProvider := func(t *testing.T, maxConnTime time.Duration, action func(StreamProvider)) {
-		sp := NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, maxConnTime)
+		sp := NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, maxConnTime, 0, 0, 0)
 		require.NotNil(t, sp)
 		defer sp.Close()
 		action(sp)
@@ -34,7 +34,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		maxConnTime := time.Hour
 		withStreamProvider(t, maxConnTime, func(sp StreamProvider) {
 			require.IsType(t, &serverSideFlagsOnlyStreamProvider{}, sp)
-			verifyServerProperties(t, sp.(*serverSideFlagsOnlyStreamProvider).server, maxConnTime)
+			verifyServerProperties(t, sp.(*serverSideFlagsOnlyStreamProvider).server, sp.(*serverSideFlagsOnlyStreamProvider).connTime, maxConnTime)
 		})
 	})
 
@@ -49,10 +49,10 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 	t.Run("Register", func(t *testing.T) {
 		store := makeMockStore(nil, nil)
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers()))
-			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers()))
+			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers(), nil))
+			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers(), nil))
 
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 			require.IsType(t, &serverSideFlagsOnlyEnvStreamProvider{}, esp)
@@ -67,7 +67,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		}
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -85,7 +85,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 			{Kind: ldstoreimpl.Segments(), Items: storeWithoutDeleted.segments},
 		}
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -98,7 +98,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		store.initialized = false
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -113,7 +113,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		})
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -125,7 +125,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -147,7 +147,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -185,7 +185,7 @@ func TestStreamProviderServerSideFlagsOnly(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -2,6 +2,7 @@ package streams
 
 import (
 	"encoding/json"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/launchdarkly/eventsource"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
@@ -29,7 +31,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 	invalidCredential2 := sdkauth.New(testEnvID)
 
 	withStreamProvider := func(t *testing.T, maxConnTime time.Duration, action func(StreamProvider)) {
-		sp := NewStreamProvider(basictypes.ServerSideStream, maxConnTime)
+		sp := NewStreamProvider(basictypes.ServerSideStream, maxConnTime, 0, 0, 0)
 		require.NotNil(t, sp)
 		defer sp.Close()
 		action(sp)
@@ -39,7 +41,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		maxConnTime := time.Hour
 		withStreamProvider(t, maxConnTime, func(sp StreamProvider) {
 			require.IsType(t, &serverSideStreamProvider{}, sp)
-			verifyServerProperties(t, sp.(*serverSideStreamProvider).server, maxConnTime)
+			verifyServerProperties(t, sp.(*serverSideStreamProvider).server, sp.(*serverSideStreamProvider).connTime, maxConnTime)
 		})
 	})
 
@@ -54,10 +56,10 @@ func TestStreamProviderServerSide(t *testing.T) {
 	t.Run("Register", func(t *testing.T) {
 		store := makeMockStore(nil, nil)
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers()))
-			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers()))
+			assert.Nil(t, sp.Register(invalidCredential1, store, ldlog.NewDisabledLoggers(), nil))
+			assert.Nil(t, sp.Register(invalidCredential2, store, ldlog.NewDisabledLoggers(), nil))
 
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 			require.IsType(t, &serverSideEnvStreamProvider{}, esp)
@@ -71,7 +73,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 			{Kind: ldstoreimpl.Segments(), Items: store.segments},
 		}
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -94,7 +96,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 			{Kind: ldstoreimpl.Segments(), Items: storeWithoutDeleted.segments},
 		}
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -107,7 +109,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		store.initialized = false
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -125,7 +127,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		})
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -143,7 +145,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		})
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -155,7 +157,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -177,7 +179,7 @@ func TestStreamProviderServerSide(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
@@ -211,11 +213,77 @@ func TestStreamProviderServerSide(t *testing.T) {
 		})
 	})
 
+	t.Run("connection is closed if initial event exceeds maximum message size", func(t *testing.T) {
+		store := makeMockStore([]ldmodel.FeatureFlag{testFlag1}, nil)
+		allData := []ldstoretypes.Collection{
+			{Kind: ldstoreimpl.Features(), Items: store.flags},
+			{Kind: ldstoreimpl.Segments(), Items: store.segments},
+		}
+		maxSize := len(MakeServerSideDeleteEvent(ldstoreimpl.Features(), testFlag1.Key, 1).Data())
+		require.Greater(t, len(MakeServerSidePutEvent(allData).Data()), maxSize)
+
+		sp := NewStreamProvider(basictypes.ServerSideStream, 0, 0, maxSize, 0)
+		defer sp.Close()
+		mockLog := ldlogtest.NewMockLog()
+		oversizedEventsCh := make(chan string, 10)
+		esp := sp.Register(validCredential, store, mockLog.Loggers, func(eventName string, size int) {
+			assert.Greater(t, size, maxSize)
+			oversizedEventsCh <- eventName
+		})
+		require.NotNil(t, esp)
+		defer esp.Close()
+
+		req, _ := http.NewRequest("GET", "", nil)
+		sharedtest.WithStreamRequest(t, req, sp.Handler(validCredential), func(eventCh <-chan eventsource.Event) {
+			assert.Nil(t, helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for stream to close"))
+		})
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "exceeds the maximum SSE message size")
+		assert.Equal(t, "put", helpers.RequireValue(t, oversizedEventsCh, time.Second))
+	})
+
+	t.Run("connections are closed if update exceeds maximum message size", func(t *testing.T) {
+		store := makeMockStore(nil, nil)
+		emptyPutEvent := MakeServerSidePutEvent([]ldstoretypes.Collection{
+			{Kind: ldstoreimpl.Features()}, {Kind: ldstoreimpl.Segments()},
+		})
+		smallEvent := MakeServerSideDeleteEvent(ldstoreimpl.Features(), testFlag1.Key, 1)
+		maxSize := len(emptyPutEvent.Data())
+		if len(smallEvent.Data()) > maxSize {
+			maxSize = len(smallEvent.Data())
+		}
+		require.Greater(t, len(MakeServerSidePatchEvent(ldstoreimpl.Features(), testFlag1.Key,
+			sharedtest.FlagDesc(testFlag1)).Data()), maxSize)
+
+		sp := NewStreamProvider(basictypes.ServerSideStream, 0, 0, maxSize, 0)
+		defer sp.Close()
+		oversizedEventsCh := make(chan string, 10)
+		esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), func(eventName string, size int) {
+			oversizedEventsCh <- eventName
+		})
+		require.NotNil(t, esp)
+		defer esp.Close()
+
+		req, _ := http.NewRequest("GET", "", nil)
+		sharedtest.WithStreamRequest(t, req, sp.Handler(validCredential), func(eventCh <-chan eventsource.Event) {
+			expectEvent(t, eventCh, emptyPutEvent)
+
+			esp.SendSingleItemUpdate(ldstoreimpl.Features(), testFlag1.Key, sharedtest.DeletedItem(1))
+			expectEvent(t, eventCh, smallEvent)
+
+			esp.SendSingleItemUpdate(ldstoreimpl.Features(), testFlag1.Key, sharedtest.FlagDesc(testFlag1))
+			assert.Nil(t, helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for stream to close"))
+		})
+		assert.Equal(t, "patch", helpers.RequireValue(t, oversizedEventsCh, time.Second))
+
+		// New connections still get the initial event, since it isn't oversized
+		verifyHandlerInitialEvent(t, sp, validCredential, emptyPutEvent)
+	})
+
 	t.Run("Heartbeat", func(t *testing.T) {
 		store := makeMockStore(nil, nil)
 
 		withStreamProvider(t, 0, func(sp StreamProvider) {
-			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers())
+			esp := sp.Register(validCredential, store, ldlog.NewDisabledLoggers(), nil)
 			require.NotNil(t, esp)
 			defer esp.Close()
 
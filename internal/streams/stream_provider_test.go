@@ -39,12 +39,13 @@ func (e testEvent) Retry() int64 {
 	return 0
 }
 
-func verifyServerProperties(t *testing.T, server *eventsource.Server, maxConnTime time.Duration) {
+func verifyServerProperties(t *testing.T, server *eventsource.Server, connTime connTimeLimit, maxConnTime time.Duration) {
 	require.NotNil(t, server)
 	assert.False(t, server.Gzip)
 	assert.True(t, server.AllowCORS)
 	assert.True(t, server.ReplayAll)
-	assert.Equal(t, maxConnTime, server.MaxConnTime)
+	assert.Equal(t, time.Duration(0), server.MaxConnTime) // enforced by connTimeLimit instead
+	assert.Equal(t, maxConnTime, connTime.maxConnTime)
 }
 
 func verifyHandlerGetsPublishedEvent(t *testing.T, sp StreamProvider, credential sdkauth.ScopedCredential, key string, server *eventsource.Server) {
@@ -0,0 +1,2 @@
+// Package tombstones contains logic for purging deleted flags and segments from a persistent data store.
+package tombstones
@@ -0,0 +1,197 @@
+package tombstones
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+)
+
+// tombstone describes a deleted item that was found in the store.
+type tombstone struct {
+	key     string
+	version int
+	// raw is the item data exactly as it was stored. Implementations can use this to make sure that
+	// the item has not changed before they delete it.
+	raw []byte
+}
+
+// Store is the interface for reading and deleting tombstones in a persistent data store. Each instance
+// is specific to one LD environment.
+type Store interface {
+	io.Closer
+
+	// getTombstones returns all of the deleted items of the specified kind.
+	getTombstones(kind ldstoretypes.DataKind) ([]tombstone, error)
+	// deleteTombstone removes a deleted item from the store, but only if it has not changed since it was
+	// returned by getTombstones. It returns true if the item was removed.
+	deleteTombstone(kind ldstoretypes.DataKind, t tombstone) (bool, error)
+}
+
+// StoreFactory creates an implementation of Store, if the configuration implies that we should purge
+// tombstones; if not, it returns nil.
+type StoreFactory func(
+	envConfig config.EnvConfig,
+	allConfig config.Config,
+	loggers ldlog.Loggers,
+) (Store, error)
+
+// DefaultStoreFactory implements our standard logic for optionally creating a Store. Purging is
+// supported for Redis and DynamoDB, and is only enabled if the purge interval for that database is set.
+func DefaultStoreFactory(
+	envConfig config.EnvConfig,
+	allConfig config.Config,
+	loggers ldlog.Loggers,
+) (Store, error) {
+	if allConfig.Redis.URL.IsDefined() && allConfig.Redis.PurgeInterval.IsDefined() {
+		return newRedisTombstoneStore(allConfig.Redis, envConfig)
+	}
+	if allConfig.DynamoDB.Enabled && allConfig.DynamoDB.PurgeInterval.IsDefined() {
+		return newDynamoDBTombstoneStore(allConfig.DynamoDB, envConfig)
+	}
+	return nil, nil
+}
+
+// GetPurgeTimes returns the configured purge interval and tombstone age for whichever database is
+// enabled.
+func GetPurgeTimes(allConfig config.Config) (interval, age time.Duration) {
+	if allConfig.Redis.URL.IsDefined() {
+		return allConfig.Redis.PurgeInterval.GetOrElse(0), allConfig.Redis.PurgeAge.GetOrElse(config.DefaultPurgeAge)
+	}
+	return allConfig.DynamoDB.PurgeInterval.GetOrElse(0), allConfig.DynamoDB.PurgeAge.GetOrElse(config.DefaultPurgeAge)
+}
+
+// Purger periodically removes tombstones-- placeholders for deleted flags and segments-- from a persistent
+// data store.
+//
+// The data store does not record when an item was deleted, so the Purger only removes a tombstone after it
+// has seen the same tombstone, with the same version, in the store for at least the configured age. This
+// leaves time for any other Relay instance that is sharing the store and that has not yet received the
+// deletion to catch up; otherwise, such an instance could write an older version of the item back to the
+// store, since there would no longer be a newer version there to prevent it.
+type Purger struct {
+	store     Store
+	age       time.Duration
+	firstSeen map[string]map[string]seenTombstone
+	loggers   ldlog.Loggers
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	now       func() time.Time
+}
+
+type seenTombstone struct {
+	version int
+	time    time.Time
+}
+
+// NewPurger creates a Purger and starts checking the store at the specified interval. The Purger takes
+// ownership of the Store and will close it when the Purger is closed.
+func NewPurger(store Store, interval, age time.Duration, loggers ldlog.Loggers) *Purger {
+	p := newPurger(store, age, loggers)
+	loggers.Infof("Will purge deleted items from the data store after %s, checking every %s", age, interval)
+	go p.runUntilClosed(interval)
+	return p
+}
+
+func newPurger(store Store, age time.Duration, loggers ldlog.Loggers) *Purger {
+	return &Purger{
+		store:     store,
+		age:       age,
+		firstSeen: make(map[string]map[string]seenTombstone),
+		loggers:   loggers,
+		closeCh:   make(chan struct{}),
+		now:       time.Now,
+	}
+}
+
+// Close stops the Purger and closes the Store.
+func (p *Purger) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+		err = p.store.Close()
+	})
+	return err
+}
+
+func (p *Purger) runUntilClosed(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			p.purge()
+		}
+	}
+}
+
+func (p *Purger) purge() {
+	for _, kind := range ldstoreimpl.AllKinds() {
+		purged, err := p.purgeKind(kind)
+		if err != nil {
+			p.loggers.Warnf("Failed to purge deleted items of kind %q from the data store: %s", kind.GetName(), err)
+		}
+		if purged > 0 {
+			p.loggers.Infof("Purged %d deleted item(s) of kind %q from the data store", purged, kind.GetName())
+		}
+	}
+}
+
+func (p *Purger) purgeKind(kind ldstoretypes.DataKind) (int, error) {
+	tombstones, err := p.store.getTombstones(kind)
+	if err != nil {
+		return 0, err
+	}
+	now := p.now()
+	previous := p.firstSeen[kind.GetName()]
+	current := make(map[string]seenTombstone, len(tombstones))
+	purged := 0
+	var firstErr error
+	for _, t := range tombstones {
+		seen, ok := previous[t.key]
+		if !ok || seen.version != t.version {
+			current[t.key] = seenTombstone{version: t.version, time: now}
+			continue
+		}
+		if now.Sub(seen.time) < p.age {
+			current[t.key] = seen
+			continue
+		}
+		deleted, err := p.store.deleteTombstone(kind, t)
+		if err != nil {
+			current[t.key] = seen // we'll try again next time
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if deleted {
+			purged++
+			p.loggers.Debugf("Purged deleted item %q (version %d) of kind %q", t.key, t.version, kind.GetName())
+		}
+	}
+	p.firstSeen[kind.GetName()] = current
+	return purged, firstErr
+}
+
+// isTombstone parses an item that was read from the store, and returns its version and true if it is a
+// deleted item placeholder.
+func isTombstone(kind ldstoretypes.DataKind, data []byte) (int, bool) {
+	decompressed, err := sdks.DecompressStoredItem(data)
+	if err != nil {
+		return 0, false
+	}
+	item, err := kind.Deserialize(decompressed)
+	if err != nil || item.Item != nil {
+		return 0, false
+	}
+	return item.Version, true
+}
@@ -0,0 +1,143 @@
+package tombstones
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTombstoneStore struct {
+	items     map[string]map[string]ldstoretypes.ItemDescriptor
+	deleteErr error
+	closed    bool
+}
+
+func newFakeTombstoneStore() *fakeTombstoneStore {
+	return &fakeTombstoneStore{items: make(map[string]map[string]ldstoretypes.ItemDescriptor)}
+}
+
+func (f *fakeTombstoneStore) put(kind ldstoretypes.DataKind, key string, item ldstoretypes.ItemDescriptor) {
+	if f.items[kind.GetName()] == nil {
+		f.items[kind.GetName()] = make(map[string]ldstoretypes.ItemDescriptor)
+	}
+	f.items[kind.GetName()][key] = item
+}
+
+func (f *fakeTombstoneStore) getTombstones(kind ldstoretypes.DataKind) ([]tombstone, error) {
+	var ret []tombstone
+	for key, item := range f.items[kind.GetName()] {
+		data := kind.Serialize(item)
+		if version, ok := isTombstone(kind, data); ok {
+			ret = append(ret, tombstone{key: key, version: version, raw: data})
+		}
+	}
+	return ret, nil
+}
+
+func (f *fakeTombstoneStore) deleteTombstone(kind ldstoretypes.DataKind, t tombstone) (bool, error) {
+	if f.deleteErr != nil {
+		return false, f.deleteErr
+	}
+	item, ok := f.items[kind.GetName()][t.key]
+	if !ok || item.Item != nil || item.Version != t.version {
+		return false, nil
+	}
+	delete(f.items[kind.GetName()], t.key)
+	return true, nil
+}
+
+func (f *fakeTombstoneStore) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestPurger(t *testing.T) {
+	age := time.Hour
+	flag := ldbuilders.NewFlagBuilder("live-flag").Version(1).Build()
+
+	setup := func() (*fakeTombstoneStore, *Purger, *time.Time, *ldlogtest.MockLog) {
+		store := newFakeTombstoneStore()
+		store.put(ldstoreimpl.Features(), "live-flag", ldstoretypes.ItemDescriptor{Version: 1, Item: &flag})
+		store.put(ldstoreimpl.Features(), "deleted-flag", ldstoretypes.ItemDescriptor{Version: 2})
+		store.put(ldstoreimpl.Segments(), "deleted-segment", ldstoretypes.ItemDescriptor{Version: 3})
+		mockLog := ldlogtest.NewMockLog()
+		p := newPurger(store, age, mockLog.Loggers)
+		now := time.Now()
+		p.now = func() time.Time { return now }
+		return store, p, &now, mockLog
+	}
+
+	t.Run("tombstones are not purged the first time they are seen", func(t *testing.T) {
+		store, p, _, _ := setup()
+		p.purge()
+		assert.Len(t, store.items["features"], 2)
+		assert.Len(t, store.items["segments"], 1)
+	})
+
+	t.Run("tombstones are not purged before they reach the age limit", func(t *testing.T) {
+		store, p, now, _ := setup()
+		p.purge()
+		*now = now.Add(age - time.Second)
+		p.purge()
+		assert.Len(t, store.items["features"], 2)
+		assert.Len(t, store.items["segments"], 1)
+	})
+
+	t.Run("tombstones are purged after they reach the age limit", func(t *testing.T) {
+		store, p, now, mockLog := setup()
+		p.purge()
+		*now = now.Add(age)
+		p.purge()
+		assert.Equal(t, map[string]ldstoretypes.ItemDescriptor{
+			"live-flag": {Version: 1, Item: &flag},
+		}, store.items["features"])
+		assert.Len(t, store.items["segments"], 0)
+		mockLog.AssertMessageMatch(t, true, ldlog.Info, `Purged 1 deleted item\(s\) of kind "features"`)
+		mockLog.AssertMessageMatch(t, true, ldlog.Info, `Purged 1 deleted item\(s\) of kind "segments"`)
+	})
+
+	t.Run("age is measured from when the current version of the tombstone was first seen", func(t *testing.T) {
+		store, p, now, _ := setup()
+		p.purge()
+		*now = now.Add(age / 2)
+		store.put(ldstoreimpl.Features(), "deleted-flag", ldstoretypes.ItemDescriptor{Version: 4})
+		p.purge()
+		*now = now.Add(age / 2)
+		p.purge()
+		assert.Contains(t, store.items["features"], "deleted-flag")
+		assert.NotContains(t, store.items["segments"], "deleted-segment")
+
+		*now = now.Add(age / 2)
+		p.purge()
+		assert.NotContains(t, store.items["features"], "deleted-flag")
+	})
+
+	t.Run("deletion error is logged and retried", func(t *testing.T) {
+		store, p, now, mockLog := setup()
+		p.purge()
+		*now = now.Add(age)
+		store.deleteErr = errors.New("sorry")
+		p.purge()
+		assert.Len(t, store.items["features"], 2)
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, `Failed to purge deleted items of kind "features" from the data store: sorry`)
+
+		store.deleteErr = nil
+		p.purge()
+		assert.Len(t, store.items["features"], 1)
+	})
+
+	t.Run("close closes store", func(t *testing.T) {
+		store, p, _, _ := setup()
+		require.NoError(t, p.Close())
+		assert.True(t, store.closed)
+	})
+}
@@ -0,0 +1,134 @@
+package tombstones
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// These are the same attribute names that are used by the SDK's DynamoDB integration.
+const (
+	tablePartitionKey = "namespace"
+	tableSortKey      = "key"
+	versionAttribute  = "version"
+	itemJSONAttribute = "item"
+)
+
+// dynamoDBTombstoneStore implements Store for DynamoDB.
+type dynamoDBTombstoneStore struct {
+	client        *dynamodb.Client
+	context       context.Context
+	cancelContext context.CancelFunc
+	table         string
+	prefix        string
+}
+
+func newDynamoDBTombstoneStore(
+	dbConfig config.DynamoDBConfig,
+	envConfig config.EnvConfig,
+) (*dynamoDBTombstoneStore, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, table, prefix := sdks.GetDynamoDBBasicProperties(dbConfig, envConfig)
+	var optFns []func(*dynamodb.Options)
+	if endpoint != nil {
+		optFns = append(optFns, func(o *dynamodb.Options) {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(*endpoint)
+		})
+	}
+
+	context, cancelContext := context.WithCancel(context.Background())
+	return &dynamoDBTombstoneStore{
+		client:        dynamodb.NewFromConfig(awsConfig, optFns...),
+		context:       context,
+		cancelContext: cancelContext,
+		table:         table,
+		prefix:        prefix,
+	}, nil
+}
+
+func (store *dynamoDBTombstoneStore) namespaceForKind(kind ldstoretypes.DataKind) string {
+	if store.prefix == "" {
+		return kind.GetName()
+	}
+	return store.prefix + ":" + kind.GetName()
+}
+
+func (store *dynamoDBTombstoneStore) getTombstones(kind ldstoretypes.DataKind) ([]tombstone, error) {
+	paginator := dynamodb.NewQueryPaginator(store.client, &dynamodb.QueryInput{
+		TableName:              aws.String(store.table),
+		ConsistentRead:         aws.Bool(true),
+		KeyConditionExpression: aws.String("#namespace = :namespace"),
+		ExpressionAttributeNames: map[string]string{
+			"#namespace": tablePartitionKey,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":namespace": &types.AttributeValueMemberS{Value: store.namespaceForKind(kind)},
+		},
+	})
+	var ret []tombstone
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(store.context)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			key, keyOK := item[tableSortKey].(*types.AttributeValueMemberS)
+			data, dataOK := item[itemJSONAttribute].(*types.AttributeValueMemberS)
+			if !keyOK || !dataOK {
+				continue
+			}
+			if version, ok := isTombstone(kind, []byte(data.Value)); ok {
+				ret = append(ret, tombstone{key: key.Value, version: version, raw: []byte(data.Value)})
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (store *dynamoDBTombstoneStore) deleteTombstone(kind ldstoretypes.DataKind, t tombstone) (bool, error) {
+	// The condition makes sure that we will never delete an item that was just updated by another
+	// Relay instance.
+	_, err := store.client.DeleteItem(store.context, &dynamodb.DeleteItemInput{
+		TableName: aws.String(store.table),
+		Key: map[string]types.AttributeValue{
+			tablePartitionKey: &types.AttributeValueMemberS{Value: store.namespaceForKind(kind)},
+			tableSortKey:      &types.AttributeValueMemberS{Value: t.key},
+		},
+		ConditionExpression: aws.String("#version = :version AND #item = :item"),
+		ExpressionAttributeNames: map[string]string{
+			"#version": versionAttribute,
+			"#item":    itemJSONAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(t.version)},
+			":item":    &types.AttributeValueMemberS{Value: string(t.raw)},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (store *dynamoDBTombstoneStore) Close() error {
+	store.cancelContext()
+	return nil
+}
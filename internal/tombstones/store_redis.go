@@ -0,0 +1,84 @@
+package tombstones
+
+import (
+	"context"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisTombstoneStore implements Store for Redis. It uses the same key layout as the SDK's Redis
+// integration, in which all items of a kind are stored in a hash whose key is "{prefix}:{kind}".
+type redisTombstoneStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func newRedisTombstoneStore(redisConfig config.RedisConfig, envConfig config.EnvConfig) (*redisTombstoneStore, error) {
+	opts, prefix, err := sdks.GetRedisUniversalOptions(redisConfig, envConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &redisTombstoneStore{
+		client: redis.NewUniversalClient(&opts),
+		prefix: prefix,
+	}, nil
+}
+
+func (r *redisTombstoneStore) hashKey(kind ldstoretypes.DataKind) string {
+	return r.prefix + ":" + kind.GetName()
+}
+
+func (r *redisTombstoneStore) getTombstones(kind ldstoretypes.DataKind) ([]tombstone, error) {
+	values, err := r.client.HGetAll(context.Background(), r.hashKey(kind)).Result()
+	if err != nil {
+		return nil, err
+	}
+	var ret []tombstone
+	for key, value := range values {
+		if version, ok := isTombstone(kind, []byte(value)); ok {
+			ret = append(ret, tombstone{key: key, version: version, raw: []byte(value)})
+		}
+	}
+	return ret, nil
+}
+
+func (r *redisTombstoneStore) deleteTombstone(kind ldstoretypes.DataKind, t tombstone) (bool, error) {
+	ctx := context.Background()
+	hashKey := r.hashKey(kind)
+	deleted := false
+
+	// WATCH makes the transaction fail if the hash is modified after we read the current value, so we
+	// will never delete an item that was just updated by another Relay instance.
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		value, err := tx.HGet(ctx, hashKey, t.key).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if value != string(t.raw) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.HDel(ctx, hashKey, t.key).Err()
+		})
+		if err == nil {
+			deleted = true
+		}
+		return err
+	}, hashKey)
+	if err == redis.TxFailedErr {
+		return false, nil
+	}
+	return deleted, err
+}
+
+func (r *redisTombstoneStore) Close() error {
+	return r.client.Close()
+}
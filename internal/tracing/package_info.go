@@ -0,0 +1,3 @@
+// Package tracing contains helpers for creating OpenCensus trace spans and for propagating W3C trace
+// context to and from HTTP requests.
+package tracing
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"go.opencensus.io/plugin/ochttp/propagation/tracecontext"
+	"go.opencensus.io/trace"
+)
+
+type remoteParentContextKeyType struct{}
+
+var remoteParentContextKey = remoteParentContextKeyType{} //nolint:gochecknoglobals
+
+// exporterCount is the number of trace exporters registered with RegisterExporter.
+var exporterCount atomic.Int32 //nolint:gochecknoglobals
+
+// sampler is the sampler set with SetSampler, if any.
+var sampler atomic.Pointer[trace.Sampler] //nolint:gochecknoglobals
+
+// RegisterExporter registers an OpenCensus trace exporter. Relay's trace exporters should be registered
+// this way, rather than with trace.RegisterExporter, so that AddTraceHeaders knows that spans are exported.
+func RegisterExporter(e trace.Exporter) {
+	trace.RegisterExporter(e)
+	exporterCount.Add(1)
+}
+
+// UnregisterExporter unregisters a trace exporter that was registered with RegisterExporter.
+func UnregisterExporter(e trace.Exporter) {
+	trace.UnregisterExporter(e)
+	exporterCount.Add(-1)
+}
+
+// SetSampler sets the sampler for spans that are started by this package. It is applied to each span,
+// rather than with trace.ApplyConfig, so that it does not affect spans started by other code in the same
+// process. Passing nil restores OpenCensus's default sampler.
+func SetSampler(s trace.Sampler) {
+	if s == nil {
+		sampler.Store(nil)
+	} else {
+		sampler.Store(&s)
+	}
+}
+
+func withSampler(options ...trace.StartOption) []trace.StartOption {
+	if s := sampler.Load(); s != nil {
+		options = append(options, trace.WithSampler(*s))
+	}
+	return options
+}
+
+// WithTraceParent returns a Context that remembers the W3C trace context (the "traceparent" and
+// "tracestate" headers), if any, of an incoming request. A span that StartServerSpan starts from this
+// Context will be a child of the caller's span, so it shows up in the caller's trace.
+func WithTraceParent(ctx context.Context, req *http.Request) context.Context {
+	format := tracecontext.HTTPFormat{}
+	if sc, ok := format.SpanContextFromRequest(req); ok {
+		return context.WithValue(ctx, remoteParentContextKey, sc)
+	}
+	return ctx
+}
+
+// WithSpan runs a function within a new span with the specified name. If the function returns an error,
+// the span's status is set to indicate the failure. The Context passed to the function contains the span,
+// so it can be passed to AddTraceHeaders.
+func WithSpan(ctx context.Context, name string, f func(context.Context) error) error {
+	ctx, span := trace.StartSpan(ctx, name, withSampler()...)
+	defer span.End()
+	err := f(ctx)
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	return err
+}
+
+// AddTraceHeaders adds W3C trace context headers for the span in the Context, if any, to a set of
+// headers for an outgoing request, so that the receiver can attach its own spans to the same trace. It
+// does nothing if no trace exporter has been registered with RegisterExporter, since the span would not
+// be exported and the receiver would see a reference to a trace that does not exist.
+func AddTraceHeaders(ctx context.Context, headers http.Header) {
+	span := trace.FromContext(ctx)
+	if span == nil || exporterCount.Load() == 0 {
+		return
+	}
+	format := tracecontext.HTTPFormat{}
+	traceParent, traceState := format.SpanContextToHeaders(span.SpanContext())
+	headers.Set("traceparent", traceParent)
+	if traceState != "" {
+		headers.Set("tracestate", traceState)
+	}
+}
+
+// StartServerSpan starts a span for handling an incoming request. If ctx was returned by WithTraceParent,
+// the span is a child of the span from the incoming request.
+func StartServerSpan(ctx context.Context, name string) (context.Context, *trace.Span) {
+	if parent, ok := ctx.Value(remoteParentContextKey).(trace.SpanContext); ok {
+		return trace.StartSpanWithRemoteParent(ctx, name, parent, withSampler(trace.WithSpanKind(trace.SpanKindServer))...)
+	}
+	return trace.StartSpan(ctx, name, withSampler(trace.WithSpanKind(trace.SpanKindServer))...)
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+)
+
+const (
+	remoteTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	remoteSpanID      = "00f067aa0ba902b7"
+	remoteTraceParent = "00-" + remoteTraceID + "-" + remoteSpanID + "-01"
+)
+
+func TestStartServerSpan(t *testing.T) {
+	t.Run("span is a child of the incoming request's span", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://localhost", nil)
+		req.Header.Set("traceparent", remoteTraceParent)
+		ctx := WithTraceParent(context.Background(), req)
+
+		_, span := StartServerSpan(ctx, "my-route")
+		span.End()
+
+		sc := span.SpanContext()
+		assert.Equal(t, remoteTraceID, sc.TraceID.String())
+		assert.True(t, sc.IsSampled())
+	})
+
+	t.Run("span starts a new trace if the incoming request has no trace context", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "http://localhost", nil)
+		ctx := WithTraceParent(context.Background(), req)
+
+		_, span := StartServerSpan(ctx, "my-route")
+		span.End()
+
+		assert.NotEqual(t, remoteTraceID, span.SpanContext().TraceID.String())
+	})
+}
+
+func TestWithSpan(t *testing.T) {
+	exporter := st.NewTestMetricsExporter()
+	exporter.WithExporter(func() {
+		t.Run("successful function", func(t *testing.T) {
+			err := WithSpan(context.Background(), "my-span", func(ctx context.Context) error {
+				assert.NotNil(t, trace.FromContext(ctx))
+				return nil
+			})
+			require.NoError(t, err)
+
+			span := exporter.AwaitSpan(t, time.Second)
+			assert.Equal(t, "my-span", span.Name)
+			assert.Equal(t, int32(trace.StatusCodeOK), span.Code)
+		})
+
+		t.Run("failed function", func(t *testing.T) {
+			fail := errors.New("sorry")
+			err := WithSpan(context.Background(), "my-span", func(context.Context) error {
+				return fail
+			})
+			assert.Equal(t, fail, err)
+
+			span := exporter.AwaitSpan(t, time.Second)
+			assert.Equal(t, int32(trace.StatusCodeUnknown), span.Code)
+			assert.Equal(t, "sorry", span.Message)
+		})
+	})
+}
+
+func TestAddTraceHeaders(t *testing.T) {
+	t.Run("adds traceparent for the current span", func(t *testing.T) {
+		exporter := st.NewTestMetricsExporter()
+		RegisterExporter(exporter)
+		defer UnregisterExporter(exporter)
+
+		ctx, span := trace.StartSpan(context.Background(), "my-span")
+		defer span.End()
+
+		headers := make(http.Header)
+		AddTraceHeaders(ctx, headers)
+
+		sc := span.SpanContext()
+		assert.Contains(t, headers.Get("traceparent"), sc.TraceID.String()+"-"+sc.SpanID.String())
+	})
+
+	t.Run("does nothing if no exporter is registered", func(t *testing.T) {
+		ctx, span := trace.StartSpan(context.Background(), "my-span")
+		defer span.End()
+
+		headers := make(http.Header)
+		AddTraceHeaders(ctx, headers)
+		assert.Len(t, headers, 0)
+	})
+
+	t.Run("does nothing if there is no span", func(t *testing.T) {
+		headers := make(http.Header)
+		AddTraceHeaders(context.Background(), headers)
+		assert.Len(t, headers, 0)
+	})
+}
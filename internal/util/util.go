@@ -3,22 +3,57 @@ package util
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 )
 
+// errorJSON has the same schema as the body of an error response from LaunchDarkly.
 type errorJSON struct {
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
-// ErrorJSONMsg returns a json-encoded error message
-func ErrorJSONMsg(msg string) (j []byte) {
-	j, _ = json.Marshal(errorJSON{Message: msg})
+// ErrorJSONMsg returns a json-encoded error message for the given HTTP status.
+func ErrorJSONMsg(status int, msg string) (j []byte) {
+	j, _ = json.Marshal(errorJSON{Code: ErrorCodeForStatus(status), Message: msg})
 	return
 }
 
-// ErrorJSONMsgf returns a json-encoded error message using the printf formatter
-func ErrorJSONMsgf(fmtStr string, args ...interface{}) []byte {
-	return ErrorJSONMsg(fmt.Sprintf(fmtStr, args...))
+// ErrorJSONMsgf returns a json-encoded error message for the given HTTP status using the printf formatter
+func ErrorJSONMsgf(status int, fmtStr string, args ...interface{}) []byte {
+	return ErrorJSONMsg(status, fmt.Sprintf(fmtStr, args...))
+}
+
+// WriteErrorJSON writes an error response with the given HTTP status, whose body has the same schema as
+// LaunchDarkly's error responses, so that SDKs and other clients can handle errors from Relay the same
+// way as errors from LaunchDarkly.
+func WriteErrorJSON(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(ErrorJSONMsg(status, msg))
+}
+
+// WriteErrorJSONf is the same as WriteErrorJSON, using the printf formatter.
+func WriteErrorJSONf(w http.ResponseWriter, status int, fmtStr string, args ...interface{}) {
+	WriteErrorJSON(w, status, fmt.Sprintf(fmtStr, args...))
+}
+
+// ErrorCodeForStatus returns the error code that LaunchDarkly uses in error responses with the given HTTP
+// status, such as "not_found" for a 404 status.
+func ErrorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request"
+	case http.StatusInternalServerError:
+		return "internal_service_error"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	}
+	if text := http.StatusText(status); text != "" {
+		return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+	}
+	return "unknown_error"
 }
 
 // RedactURL is equivalent to parsing a URL string and then calling Redacted() to
@@ -1,14 +1,43 @@
 package util
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestErrorJSONMsg(t *testing.T) {
-	assert.Equal(t, `{"message":"sorry"}`, string(ErrorJSONMsg("sorry")))
-	assert.Equal(t, `{"message":"bad thing"}`, string(ErrorJSONMsgf("bad %s", "thing")))
+	assert.Equal(t, `{"code":"not_found","message":"sorry"}`, string(ErrorJSONMsg(http.StatusNotFound, "sorry")))
+	assert.Equal(t, `{"code":"invalid_request","message":"bad thing"}`, string(ErrorJSONMsgf(http.StatusBadRequest, "bad %s", "thing")))
+}
+
+func TestWriteErrorJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteErrorJSONf(w, http.StatusServiceUnavailable, "try %s", "later")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":"service_unavailable","message":"try later"}`, w.Body.String())
+}
+
+func TestErrorCodeForStatus(t *testing.T) {
+	for status, code := range map[int]string{
+		http.StatusBadRequest:            "invalid_request",
+		http.StatusUnauthorized:          "unauthorized",
+		http.StatusForbidden:             "forbidden",
+		http.StatusNotFound:              "not_found",
+		http.StatusMethodNotAllowed:      "method_not_allowed",
+		http.StatusRequestEntityTooLarge: "request_entity_too_large",
+		http.StatusUnsupportedMediaType:  "unsupported_media_type",
+		http.StatusUpgradeRequired:       "upgrade_required",
+		http.StatusTooManyRequests:       "rate_limited",
+		http.StatusInternalServerError:   "internal_service_error",
+		http.StatusServiceUnavailable:    "service_unavailable",
+		599:                              "unknown_error",
+	} {
+		assert.Equal(t, code, ErrorCodeForStatus(status), status)
+	}
 }
 
 func TestRedactURL(t *testing.T) {
@@ -2,6 +2,8 @@ package main
 
 import (
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/kardianos/minwinsvc"
 
@@ -10,6 +12,8 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/logging"
 	"github.com/launchdarkly/ld-relay/v8/relay"
 	"github.com/launchdarkly/ld-relay/v8/relay/version"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 )
 
 func main() {
@@ -59,11 +63,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	if opts.ConfigFile != "" {
+		go reloadConfigOnSignal(r, opts, loggers)
+	}
+
 	port := c.Main.Port.GetOrElse(config.DefaultPort)
 
 	_, errs := application.StartHTTPServer(
 		port,
 		r,
+		application.ServerTimeouts{
+			Read:       c.Main.ReadTimeout.GetOrElse(0),
+			ReadHeader: c.Main.ReadHeaderTimeout.GetOrElse(config.DefaultReadHeaderTimeout),
+			Write:      c.Main.WriteTimeout.GetOrElse(0),
+			Idle:       c.Main.IdleTimeout.GetOrElse(0),
+		},
+		c.Main.MaxHeaderBytes.GetOrElse(0),
 		c.Main.TLSEnabled,
 		c.Main.TLSCert,
 		c.Main.TLSKey,
@@ -76,3 +91,27 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// reloadConfigOnSignal reads the configuration again, in the same way as at startup, whenever the
+// process receives SIGHUP, and applies it to the running Relay.
+func reloadConfigOnSignal(r *relay.Relay, opts application.Options, loggers ldlog.Loggers) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		loggers.Infof("Received SIGHUP; reloading configuration with %s", opts.DescribeConfigSource())
+		var c config.Config
+		if err := config.LoadConfigFile(&c, opts.ConfigFile, loggers); err != nil {
+			loggers.Errorf("Error loading config file; configuration was not changed: %s", err)
+			continue
+		}
+		if opts.UseEnvironment {
+			if err := config.LoadConfigFromEnvironment(&c, loggers); err != nil {
+				loggers.Errorf("Configuration error; configuration was not changed: %s", err)
+				continue
+			}
+		}
+		if err := r.Reload(c); err != nil {
+			loggers.Errorf("Unable to apply new configuration; configuration was not changed: %s", err)
+		}
+	}
+}
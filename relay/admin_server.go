@@ -0,0 +1,63 @@
+package relay
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// adminServer is the listener for administrative endpoints when MainConfig.AdminPort is set, so that
+// those endpoints are not reachable through the port that SDKs connect to.
+type adminServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+func startAdminServer(handler http.Handler, mainConfig config.MainConfig, loggers ldlog.Loggers) (*adminServer, error) {
+	port := mainConfig.AdminPort.GetOrElse(0)
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           handler,
+		ReadTimeout:       mainConfig.ReadTimeout.GetOrElse(0),
+		ReadHeaderTimeout: mainConfig.ReadHeaderTimeout.GetOrElse(config.DefaultReadHeaderTimeout),
+		WriteTimeout:      mainConfig.WriteTimeout.GetOrElse(0),
+		IdleTimeout:       mainConfig.IdleTimeout.GetOrElse(0),
+		MaxHeaderBytes:    mainConfig.MaxHeaderBytes.GetOrElse(0),
+	}
+	if mainConfig.TLSEnabled && mainConfig.TLSMinVersion.Get() != 0 {
+		server.TLSConfig = &tls.Config{ //nolint:gosec // linter doesn't want to see MinVersion being set to a variable
+			MinVersion: mainConfig.TLSMinVersion.Get(),
+		}
+	}
+
+	// Separate Listen and Serve here instead of calling ListenAndServe() so that we can immediately
+	// detect if the port isn't available
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start admin listener on port %d: %w", port, err)
+	}
+	loggers.Infof("Starting admin server listening on port %d", port)
+	go func() {
+		var err error
+		if mainConfig.TLSEnabled {
+			err = server.ServeTLS(listener, mainConfig.TLSCert, mainConfig.TLSKey)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != http.ErrServerClosed { // Serve never returns a nil error value
+			loggers.Errorf("Admin server on port %d stopped unexpectedly: %s", port, err)
+		}
+	}()
+	return &adminServer{server: server, listener: listener}, nil
+}
+
+func (a *adminServer) close() error {
+	err := a.server.Close()
+	_ = a.listener.Close()
+	return err
+}
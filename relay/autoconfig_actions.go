@@ -8,11 +8,12 @@ import (
 )
 
 const (
-	logMsgAutoConfEnvInitError            = "Unable to initialize auto-configured environment %q: %s"
+	logMsgAutoConfEnvFailed               = "Unable to configure auto-configured environment %s: %s"
 	logMsgAutoConfUpdateUnknownEnv        = "Got auto-configuration update for environment %q but did not have previous configuration - will add"
 	logMsgAutoConfDeleteUnknownEnv        = "Got auto-configuration delete message for environment %s but did not have previous configuration - ignoring"
 	logMsgAutoConfReceivedAllEnvironments = "Finished processing auto-configuration data"
 	logMsgKeyExpiryUnknownEnv             = "Got auto-configuration key expiry message for environment %s but did not have previous configuration - ignoring"
+	logMsgAutoConfCredentialConflict      = "Not using new credential for environment %q because it is already used by environment %q"
 )
 
 // relayAutoConfigActions is an implementation of the autoconfig.MessageHandler interface. The low-level
@@ -30,7 +31,8 @@ func (a *relayAutoConfigActions) AddEnvironment(params envfactory.EnvironmentPar
 	envConfig := envfactory.NewEnvConfigFactoryForAutoConfig(a.r.config.AutoConfig).MakeEnvironmentConfig(params)
 	env, _, err := a.r.addEnvironment(params.Identifiers, envConfig, nil)
 	if err != nil {
-		a.r.loggers.Errorf(logMsgAutoConfEnvInitError, params.Identifiers.GetDisplayName(), err)
+		a.EnvironmentFailed(params.EnvID, err)
+		return
 	}
 
 	if params.ExpiringSDKKey.Defined() {
@@ -49,6 +51,13 @@ func (a *relayAutoConfigActions) UpdateEnvironment(params envfactory.Environment
 		return
 	}
 
+	// Nothing is changed unless the whole update is valid, so that a bad update can't leave the
+	// environment partly reconfigured.
+	if err := params.Validate(); err != nil {
+		a.EnvironmentFailed(params.EnvID, err)
+		return
+	}
+
 	env.SetIdentifiers(params.Identifiers)
 	env.SetTTL(params.TTL)
 	env.SetSecureMode(params.SecureMode)
@@ -60,6 +69,11 @@ func (a *relayAutoConfigActions) UpdateEnvironment(params envfactory.Environment
 		if status == credential.Unchanged {
 			continue
 		}
+		if other, found := a.r.envsByCredential.Lookup(sdkauth.NewScoped(params.Identifiers.FilterKey, newCred)); found && other != env {
+			a.r.loggers.Errorf(logMsgAutoConfCredentialConflict, params.Identifiers.GetDisplayName(),
+				other.GetIdentifiers().GetDisplayName())
+			continue
+		}
 
 		env.AddCredential(newCred)
 		a.r.addConnectionMapping(sdkauth.NewScoped(params.Identifiers.FilterKey, newCred), env)
@@ -74,6 +88,13 @@ func (a *relayAutoConfigActions) UpdateEnvironment(params envfactory.Environment
 	}
 }
 
+// EnvironmentFailed is called when an environment could not be added or updated. This is the equivalent
+// of relayFileDataActions.EnvironmentFailed; the autoconfig.MessageHandler interface has no such method,
+// because the failures are detected here rather than by the stream manager.
+func (a *relayAutoConfigActions) EnvironmentFailed(id config.EnvironmentID, err error) {
+	a.r.loggers.Errorf(logMsgAutoConfEnvFailed, id, err)
+}
+
 func (a *relayAutoConfigActions) DeleteEnvironment(id config.EnvironmentID, filter config.FilterKey) {
 	removed := a.r.removeEnvironment(sdkauth.NewScoped(filter, id))
 	if !removed {
@@ -9,6 +9,7 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 
 	"github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
@@ -231,6 +232,44 @@ func TestAutoConfigAddEnvironmentWithExpiringSDKKey(t *testing.T) {
 	})
 }
 
+func TestAutoConfigAddEnvironmentWithConflictingCredential(t *testing.T) {
+	initialEvent := makeAutoConfPutEvent(testAutoConfEnv1)
+	autoConfTest(t, testAutoConfDefaultConfig, &initialEvent, func(p autoConfTestParams) {
+		_ = p.awaitClient()
+		env1 := p.awaitEnvironment(testAutoConfEnv1.id)
+
+		conflicting := testAutoConfEnv2
+		conflicting.sdkKey = testAutoConfEnv1.sdkKey
+		p.stream.Enqueue(makeAutoConfPatchEvent(conflicting))
+
+		p.shouldNotCreateClient(time.Millisecond * 50)
+		p.shouldNotHaveEnvironment(conflicting.id, time.Millisecond*100)
+		p.assertEnvLookup(env1, testAutoConfEnv1.params())
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Error,
+			"Unable to configure auto-configured environment "+string(conflicting.id)+": .*already used")
+	})
+}
+
+func TestAutoConfigUpdateEnvironmentWithConflictingCredential(t *testing.T) {
+	initialEvent := makeAutoConfPutEvent(testAutoConfEnv1, testAutoConfEnv2)
+	autoConfTest(t, testAutoConfDefaultConfig, &initialEvent, func(p autoConfTestParams) {
+		_, _ = p.awaitClient(), p.awaitClient()
+		env1 := p.awaitEnvironment(testAutoConfEnv1.id)
+		env2 := p.awaitEnvironment(testAutoConfEnv2.id)
+
+		modified := testAutoConfEnv2
+		modified.mobKey = testAutoConfEnv1.mobKey
+		modified.version++
+		p.stream.Enqueue(makeAutoConfPatchEvent(modified))
+
+		require.Eventually(t, func() bool {
+			return p.mockLog.HasMessageMatch(ldlog.Error, "already used by environment")
+		}, time.Second, time.Millisecond*5)
+		p.assertEnvLookup(env1, testAutoConfEnv1.params())
+		p.assertEnvLookup(env2, testAutoConfEnv2.params())
+	})
+}
+
 func TestAutoConfigUpdateEnvironmentName(t *testing.T) {
 	initialEvent := makeAutoConfPutEvent(testAutoConfEnv1)
 	autoConfTest(t, testAutoConfDefaultConfig, &initialEvent, func(p autoConfTestParams) {
@@ -253,6 +292,29 @@ func TestAutoConfigUpdateEnvironmentName(t *testing.T) {
 	})
 }
 
+func TestAutoConfigInvalidUpdateDoesNotChangeEnvironment(t *testing.T) {
+	initialEvent := makeAutoConfPutEvent(testAutoConfEnv1)
+	autoConfTest(t, testAutoConfDefaultConfig, &initialEvent, func(p autoConfTestParams) {
+		_ = p.awaitClient()
+
+		env := p.awaitEnvironment(testAutoConfEnv1.id)
+		assertEnvProps(t, testAutoConfEnv1.params(), env)
+
+		invalid := testAutoConfEnv1
+		invalid.envName = "newenvname"
+		invalid.mobKey = "bad mobile key"
+		invalid.version++
+
+		p.stream.Enqueue(makeAutoConfPatchEvent(invalid))
+
+		requireLogMessage := func() bool {
+			return p.mockLog.HasMessageMatch(ldlog.Error, "Unable to configure auto-configured environment .* mobile key contains whitespace")
+		}
+		require.Eventually(t, requireLogMessage, time.Second, time.Millisecond*5)
+		assertEnvProps(t, testAutoConfEnv1.params(), env)
+	})
+}
+
 // Tests for changing SDK key/mobile key are in autoconfig_key_change_test.go, since there are so many consequences
 
 func TestAutoConfigDeleteEnvironment(t *testing.T) {
@@ -2,6 +2,7 @@ package relay
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 
 	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
@@ -117,6 +119,21 @@ func TestAutoConfigUpdateEnvironmentSDKKeyWithExpiry(t *testing.T) {
 		p.assertEnvLookup(env, testAutoConfEnv1.params()) // looking up env by old key still works
 		assert.Equal(t, []credential.SDKCredential{testAutoConfEnv1.sdkKey}, env.GetDeprecatedCredentials())
 
+		// requests with the old key are still accepted, but we warn (once) that it is still in use
+		for i := 0; i < 2; i++ {
+			headers := make(http.Header)
+			headers.Set("Authorization", testAutoConfEnv1.sdkKey.GetAuthorizationHeaderValue())
+			resp, _ := st.DoRequest(st.BuildRequest("GET", "/sdk/flags", nil, headers), p.relay.Handler)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+		warnings := 0
+		for _, msg := range p.mockLog.GetOutput(ldlog.Warn) {
+			if strings.Contains(msg, "will stop working when it expires") {
+				warnings++
+			}
+		}
+		assert.Equal(t, 1, warnings)
+
 		if !helpers.AssertChannelNotClosed(t, client1.CloseCh, time.Millisecond*300, "should not have closed client for deprecated key yet") {
 			t.FailNow()
 		}
@@ -0,0 +1,226 @@
+package relay
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/streams"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/launchdarkly/eventsource"
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+)
+
+// clientSideFlagState is the last evaluation result that was sent for a flag on an evaluation stream.
+type clientSideFlagState struct {
+	version int
+	data    []byte // the JSON representation without the "key" property, used to detect changes
+	patch   []byte // the same, with the "key" property
+}
+
+// evalStreamHandler is used for client-side streaming endpoints that require context properties, if
+// Relay is configured to send evaluated flag values on those streams instead of "ping" events. The
+// stream begins with a "put" event containing all of the flag values for the context; then, whenever
+// the environment's data changes, the flags are re-evaluated and a "patch" or "delete" event is sent
+// for each flag whose result is different.
+func evalStreamHandler(sdkKind basictypes.SDKKind, heartbeatInterval, maxConnTime, maxConnJitter time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		clientCtx := middleware.GetEnvContextInfo(req.Context())
+		loggers := clientCtx.Env.GetLoggers()
+		loggers.Debug("Application requested client-side evaluation stream")
+
+		ldContext, ok := getClientSideContextProperties(clientCtx.Env, sdkKind, req, w)
+		if !ok {
+			return
+		}
+		if !ldContext.Multiple() && ldContext.Key() == "" {
+			util.WriteErrorJSON(w, http.StatusBadRequest, "User must have a 'key' attribute")
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok { // COVERAGE: can't make this happen in unit tests
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		reasons := getReasonsMode(req)
+
+		updatesCh, unsubscribe := clientCtx.Env.SubscribeToEvaluations(ldContext)
+		defer unsubscribe()
+
+		// If the data store isn't initialized yet, we won't send anything until it is; the SDK will
+		// wait for the first "put". We evaluate before starting the stream, so that if there are too
+		// many flags we can return an error status instead.
+		var current map[string]clientSideFlagState
+		if store := clientCtx.Env.GetStore(); store != nil && store.IsInitialized() {
+			var limitErr *flagCountLimitError
+			if current, limitErr = evaluateFlagsForStream(clientCtx.Env, sdkKind, ldContext, reasons); limitErr != nil {
+				loggers.Warnf("Refusing to start evaluation stream for client-side SDK: %s", limitErr)
+				limitErr.writeResponse(w)
+				return
+			}
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream; charset=utf-8")
+		h.Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		h.Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		enc := eventsource.NewEncoder(w, false)
+		send := func(events ...eventsource.Event) bool {
+			for _, e := range events {
+				if err := enc.Encode(e); err != nil {
+					return false
+				}
+			}
+			flusher.Flush()
+			return true
+		}
+
+		var maxConnTimeCh <-chan time.Time
+		if maxConnTime > 0 {
+			t := time.NewTimer(streams.MaxConnTimeForConnection(maxConnTime, maxConnJitter))
+			defer t.Stop()
+			maxConnTimeCh = t.C
+		}
+		heartbeats := time.NewTicker(heartbeatInterval)
+		defer heartbeats.Stop()
+
+		if current != nil && !send(makeEvalStreamPutEvent(current)) {
+			return
+		}
+
+		for {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-maxConnTimeCh:
+				streams.CloseStreamGracefully(w)
+				return
+			case <-heartbeats.C:
+				if _, err := io.WriteString(w, ":\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case _, ok := <-updatesCh:
+				if !ok {
+					return // the environment was closed
+				}
+				updated, limitErr := evaluateFlagsForStream(clientCtx.Env, sdkKind, ldContext, reasons)
+				if limitErr != nil {
+					// Closing the stream makes the SDK reconnect, and it will get an error status then
+					loggers.Warnf("Closing evaluation stream for client-side SDK: %s", limitErr)
+					return
+				}
+				if updated == nil {
+					continue
+				}
+				var events []eventsource.Event
+				if current == nil {
+					events = append(events, makeEvalStreamPutEvent(updated))
+				} else {
+					events = makeEvalStreamChangeEvents(current, updated)
+				}
+				current = updated
+				if !send(events...) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// evaluateFlagsForStream evaluates all flags that are available to this kind of client-side SDK and visible to the
+// context. For deleted flags, and flags that are not available, it returns a state with only a version. It returns nil
+// if the data store could not be read, or an error if the environment enforces a flag count limit and there are too
+// many flags.
+func evaluateFlagsForStream(
+	env relayenv.EnvContext,
+	sdkKind basictypes.SDKKind,
+	ldContext ldcontext.Context,
+	reasons reasonsMode,
+) (map[string]clientSideFlagState, *flagCountLimitError) {
+	store := env.GetStore()
+	if store == nil {
+		return nil, nil
+	}
+	items, err := store.GetAll(ldstoreimpl.Features())
+	if err != nil {
+		env.GetLoggers().Warnf("Unable to fetch flags from feature store for evaluation stream: %s", err)
+		return nil, nil
+	}
+	items = env.TransformFlags(sdkKind, items)
+	isVisible := func(key string) bool { return env.IsFlagVisibleToContext(key, ldContext) }
+	if limitErr := checkFlagCountLimit(env, sdkKind, items, isVisible); limitErr != nil {
+		return nil, limitErr
+	}
+	evaluator := env.GetEvaluator()
+	if evaluator == nil {
+		return nil, nil
+	}
+	ret := make(map[string]clientSideFlagState, len(items))
+	for _, item := range items {
+		flag, ok := item.Item.Item.(*ldmodel.FeatureFlag)
+		if !ok || !isFlagAvailableToClient(flag, sdkKind) || !env.IsFlagVisibleToContext(flag.Key, ldContext) {
+			ret[item.Key] = clientSideFlagState{version: item.Item.Version}
+			continue
+		}
+		result := evaluator.Evaluate(flag, ldContext, nil)
+
+		w := jwriter.NewWriter()
+		obj := w.Object()
+		writeClientSideFlagResult(&obj, flag, result, reasons)
+		obj.End()
+
+		pw := jwriter.NewWriter()
+		patchObj := pw.Object()
+		patchObj.Name("key").String(flag.Key)
+		writeClientSideFlagResult(&patchObj, flag, result, reasons)
+		patchObj.End()
+
+		ret[item.Key] = clientSideFlagState{version: flag.Version, data: w.Bytes(), patch: pw.Bytes()}
+	}
+	return ret, nil
+}
+
+func makeEvalStreamPutEvent(flags map[string]clientSideFlagState) eventsource.Event {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	for key, state := range flags {
+		if state.data != nil {
+			obj.Name(key).Raw(state.data)
+		}
+	}
+	obj.End()
+	return streams.MakeClientSideEvalPutEvent(w.Bytes())
+}
+
+func makeEvalStreamChangeEvents(previous, updated map[string]clientSideFlagState) []eventsource.Event {
+	var events []eventsource.Event
+	for key, state := range updated {
+		prev, existed := previous[key]
+		switch {
+		case state.data != nil:
+			if !existed || string(prev.data) != string(state.data) {
+				events = append(events, streams.MakeClientSideEvalPatchEvent(state.patch))
+			}
+		case existed && prev.data != nil:
+			events = append(events, streams.MakeClientSideEvalDeleteEvent(key, state.version))
+		}
+	}
+	for key, prev := range previous {
+		if _, ok := updated[key]; !ok && prev.data != nil {
+			// The flag is gone from the store entirely, without a deletion marker
+			events = append(events, streams.MakeClientSideEvalDeleteEvent(key, prev.version+1))
+		}
+	}
+	return events
+}
@@ -16,36 +16,43 @@ import (
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
 )
 
-func getEventsImage(w http.ResponseWriter, req *http.Request) {
-	clientCtx := middleware.GetEnvContextInfo(req.Context())
-
-	if clientCtx.Env.GetEventDispatcher() == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		_, _ = w.Write(util.ErrorJSONMsg("Event proxy is not enabled for this environment"))
-		return
-	}
-	handler := clientCtx.Env.GetEventDispatcher().GetHandler(basictypes.JSClientSDK, ldevents.AnalyticsEventDataKind)
-	if handler == nil { // COVERAGE: abnormal condition that can't be caused in unit tests
-		w.WriteHeader(http.StatusServiceUnavailable)
-		_, _ = w.Write(util.ErrorJSONMsg("Event proxy for browser clients is not enabled for this environment"))
-		return
+// getEventsImage returns the handler for the JS SDK's image endpoint, which receives event data as a
+// base64-encoded "d" query parameter. Requests whose "d" parameter is longer than maxDataSize are
+// rejected without decoding it.
+func getEventsImage(maxDataSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clientCtx := middleware.GetEnvContextInfo(req.Context())
+
+		if clientCtx.Env.GetEventDispatcher() == nil {
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Event proxy is not enabled for this environment")
+			return
+		}
+		handler := clientCtx.Env.GetEventDispatcher().GetHandler(basictypes.JSClientSDK, ldevents.AnalyticsEventDataKind)
+		if handler == nil { // COVERAGE: abnormal condition that can't be caused in unit tests
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Event proxy for browser clients is not enabled for this environment")
+			return
+		}
+
+		d := req.URL.Query().Get("d")
+		if len(d) > maxDataSize {
+			util.WriteErrorJSONf(w, http.StatusRequestEntityTooLarge, "Event data exceeds the maximum size of %d bytes", maxDataSize)
+			return
+		}
+		if d != "" {
+			go func() {
+				nullW := httptest.NewRecorder()
+				eventData, _ := base64.StdEncoding.DecodeString(d)
+				eventsReq, _ := http.NewRequest("POST", "", bytes.NewBuffer(eventData))
+				eventsReq.Header.Add("Content-Type", "application/json")
+				eventsReq.Header.Add("X-LaunchDarkly-User-Agent", eventsReq.Header.Get("X-LaunchDarkly-User-Agent"))
+				eventsReq.Header.Add(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+				handler(nullW, eventsReq)
+			}()
+		}
+
+		w.Header().Set("Content-Type", "image/gif")
+		_, _ = w.Write(browser.Transparent1PixelImageData)
 	}
-
-	d := req.URL.Query().Get("d")
-	if d != "" {
-		go func() {
-			nullW := httptest.NewRecorder()
-			eventData, _ := base64.StdEncoding.DecodeString(d)
-			eventsReq, _ := http.NewRequest("POST", "", bytes.NewBuffer(eventData))
-			eventsReq.Header.Add("Content-Type", "application/json")
-			eventsReq.Header.Add("X-LaunchDarkly-User-Agent", eventsReq.Header.Get("X-LaunchDarkly-User-Agent"))
-			eventsReq.Header.Add(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
-			handler(nullW, eventsReq)
-		}()
-	}
-
-	w.Header().Set("Content-Type", "image/gif")
-	_, _ = w.Write(browser.Transparent1PixelImageData)
 }
 
 func getGoals(w http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,57 @@
+package relay
+
+import (
+	"time"
+)
+
+const (
+	defaultConfigSourceCheckInterval = time.Second
+
+	logMsgConfigSourceStale     = "Auto-configuration or file data source has not been able to provide updates since %s; Relay status is degraded"
+	logMsgConfigSourceRecovered = "Auto-configuration or file data source has recovered"
+)
+
+// getConfigSourceStatus returns the time when the auto-configuration stream or the file data source
+// stopped providing updates, or a zero time if it is working or if there is no such source; and whether
+// it has been failing for at least the configured stale time. If no stale time is configured, the
+// source is never considered stale.
+func (r *Relay) getConfigSourceStatus(staleTime time.Duration) (time.Time, bool) {
+	var interruptedSince time.Time
+	if r.autoConfigStream != nil {
+		interruptedSince = r.autoConfigStream.GetInterruptedSince()
+	} else if r.archiveManager != nil {
+		interruptedSince = r.archiveManager.GetInterruptedSince()
+	}
+	stale := staleTime > 0 && !interruptedSince.IsZero() && time.Since(interruptedSince) >= staleTime
+	return interruptedSince, stale
+}
+
+// monitorConfigSource periodically checks whether the auto-configuration stream or file data source
+// has become stale, so that we can log a warning once each time that happens. It exits when the Relay
+// is closed.
+func (r *Relay) monitorConfigSource(checkInterval time.Duration) {
+	if checkInterval <= 0 {
+		checkInterval = defaultConfigSourceCheckInterval
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	warned := false
+	for {
+		select {
+		case <-r.closeCh:
+			return
+		case <-ticker.C:
+		}
+		r.lock.RLock()
+		staleTime := r.config.Main.ConfigSourceStaleTime.GetOrElse(0) // can be changed by Reload
+		r.lock.RUnlock()
+		interruptedSince, stale := r.getConfigSourceStatus(staleTime)
+		if stale && !warned {
+			r.loggers.Warnf(logMsgConfigSourceStale, interruptedSince.Format(time.RFC3339))
+			warned = true
+		} else if interruptedSince.IsZero() && warned {
+			r.loggers.Info(logMsgConfigSourceRecovered)
+			warned = false
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	ct "github.com/launchdarkly/go-configtypes"
+)
+
+// redactedValue replaces passwords and other secrets in the output of debugConfigHandler.
+const redactedValue = "[REDACTED]"
+
+// debugConfigHandler returns the effective configuration as JSON, with secrets redacted. The environments
+// are the ones that currently exist, with any changes that have been made by auto-configuration or by
+// reloading the configuration, rather than the ones in the original configuration.
+func debugConfigHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		relay.lock.RLock()
+		c := relay.config
+		relay.lock.RUnlock()
+
+		c.Environment = make(map[string]*config.EnvConfig)
+		for _, env := range relay.getAllEnvironments() {
+			envConfig := env.GetEnvConfig()
+			c.Environment[env.GetIdentifiers().GetDisplayName()] = &envConfig
+		}
+
+		data, _ := json.Marshal(redactConfig(c))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
+
+// redactConfig returns a copy of the configuration in which SDK keys, mobile keys, and the auto-configuration
+// key are obscured in the same way as on the status resource, and passwords and tokens are replaced with
+// redactedValue. The Environment map must not be shared with any other Config, since its values are modified.
+func redactConfig(c config.Config) config.Config {
+	c.Main.AdminKey = redactString(c.Main.AdminKey)
+	c.AutoConfig.Key = config.AutoConfigKey(sdks.ObscureKey(string(c.AutoConfig.Key)))
+	c.Redis.Password = redactString(c.Redis.Password)
+	c.Redis.URL = redactURL(c.Redis.URL)
+	c.Consul.Token = redactString(c.Consul.Token)
+	c.Proxy.Password = redactString(c.Proxy.Password)
+	c.Proxy.URL = redactURL(c.Proxy.URL)
+	c.OpenTelemetry.Headers = redactHeaderValues(c.OpenTelemetry.Headers)
+	for _, envConfig := range c.Environment {
+		envConfig.SDKKey = config.SDKKey(sdks.ObscureKey(string(envConfig.SDKKey)))
+		envConfig.MobileKey = config.MobileKey(sdks.ObscureKey(string(envConfig.MobileKey)))
+		envConfig.ProxyPassword = redactString(envConfig.ProxyPassword)
+		envConfig.ProxyURL = redactURL(envConfig.ProxyURL)
+		envConfig.BigSegmentsRedisURL = redactURL(envConfig.BigSegmentsRedisURL)
+		envConfig.ChangeWebhookURL = redactURL(envConfig.ChangeWebhookURL)
+		envConfig.ChangeWebhookSecret = redactString(envConfig.ChangeWebhookSecret)
+	}
+	return c
+}
+
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+func redactURL(u ct.OptURLAbsolute) ct.OptURLAbsolute {
+	if !u.IsDefined() {
+		return u
+	}
+	ret, err := ct.NewOptURLAbsoluteFromString(util.RedactURL(u.String()))
+	if err != nil { // COVERAGE: can't happen, since the URL was already valid
+		return ct.OptURLAbsolute{}
+	}
+	return ret
+}
+
+// redactHeaderValues replaces the value in each header of the form "Name: value", since headers for
+// external services are often used for authentication.
+func redactHeaderValues(headers ct.OptStringList) ct.OptStringList {
+	if !headers.IsDefined() {
+		return headers
+	}
+	values := headers.Values()
+	ret := make([]string, 0, len(values))
+	for _, h := range values {
+		name, _, _ := strings.Cut(h, ":")
+		ret = append(ret, name+": "+redactedValue)
+	}
+	return ct.NewOptStringList(ret)
+}
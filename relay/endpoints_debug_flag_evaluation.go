@@ -0,0 +1,111 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/gorilla/mux"
+)
+
+// debugFlagEvaluationRep is the response body for the flag evaluation debugging endpoint.
+type debugFlagEvaluationRep struct {
+	Key            string                     `json:"key"`
+	Version        int                        `json:"version"`
+	Value          ldvalue.Value              `json:"value"`
+	VariationIndex ldvalue.OptionalInt        `json:"variationIndex"`
+	Reason         ldreason.EvaluationReason  `json:"reason"`
+	Prerequisites  []debugFlagPrerequisiteRep `json:"prerequisites,omitempty"`
+}
+
+type debugFlagPrerequisiteRep struct {
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+}
+
+// debugFlagEvaluationHandler evaluates one flag for the context in the request body. If the "version"
+// query parameter is set, the flag definition with that version is used instead of the current one;
+// that requires the version to still be retained in the environment's flag history. Prerequisite flags
+// and segments are always evaluated using their current definitions.
+func debugFlagEvaluationHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName := mux.Vars(req)["envName"]
+		flagKey := mux.Vars(req)["key"]
+		target := relay.getEnvironmentByStatusKey(envName)
+		if target == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", envName)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		var tooLargeErr *http.MaxBytesError
+		if errors.As(err, &tooLargeErr) {
+			util.WriteErrorJSONf(w, http.StatusRequestEntityTooLarge, "Request body exceeds the maximum size of %d bytes",
+				tooLargeErr.Limit)
+			return
+		}
+		ldContext, err := middleware.ContextFromJSON(body)
+		if err != nil {
+			util.WriteErrorJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		store := target.GetStore()
+		evaluator := target.GetEvaluator()
+		if store == nil || evaluator == nil {
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Environment is not initialized")
+			return
+		}
+
+		var flag *ldmodel.FeatureFlag
+		if versionParam := req.URL.Query().Get("version"); versionParam != "" {
+			version, err := strconv.Atoi(versionParam)
+			if err != nil {
+				util.WriteErrorJSON(w, http.StatusBadRequest, "version must be an integer")
+				return
+			}
+			if flag = target.GetFlagVersion(flagKey, version); flag == nil {
+				util.WriteErrorJSONf(w, http.StatusNotFound, "Version %d of flag %q is not available", version, flagKey)
+				return
+			}
+		} else {
+			item, err := store.Get(ldstoreimpl.Features(), flagKey)
+			if err != nil {
+				target.GetLoggers().Errorf("Error reading feature store: %s", err)
+				util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
+				return
+			}
+			var ok bool
+			if flag, ok = item.Item.(*ldmodel.FeatureFlag); !ok || flag == nil {
+				util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown flag %q", flagKey)
+				return
+			}
+		}
+
+		rep := debugFlagEvaluationRep{Key: flag.Key, Version: flag.Version}
+		result := evaluator.Evaluate(flag, ldContext, func(event ldeval.PrerequisiteFlagEvent) {
+			if event.TargetFlagKey == flag.Key {
+				rep.Prerequisites = append(rep.Prerequisites,
+					debugFlagPrerequisiteRep{Key: event.PrerequisiteFlag.Key, Version: event.PrerequisiteFlag.Version})
+			}
+		})
+		rep.Value = result.Detail.Value
+		rep.VariationIndex = result.Detail.VariationIndex
+		rep.Reason = result.Detail.Reason
+
+		data, _ := json.Marshal(rep)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
@@ -0,0 +1,135 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsDebugFlagEvaluation(t *testing.T) {
+	adminKey := "admin-key"
+	env := st.EnvMain
+	flagKey := "history-flag"
+	makeEvalRequest := func(envName, query, body, authKey string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost/debug/env/"+envName+"/flag/"+flagKey+"/evaluate"+query,
+			bytes.NewBufferString(body))
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	userJSON := `{"key":"user-key"}`
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Main.FlagHistoryDepth, _ = ct.NewOptIntGreaterThanZero(2)
+	config.Environment = st.MakeEnvConfigs(env)
+
+	// Each version of the flag returns its own version number as the value.
+	upsertVersions := func(t *testing.T, p relayTestParams, versions ...int) {
+		relayEnv, _ := p.relay.getEnvironment(sdkauth.New(env.Config.SDKKey))
+		require.NotNil(t, relayEnv)
+		for _, v := range versions {
+			flag := ldbuilders.NewFlagBuilder(flagKey).Version(v).On(false).OffVariation(0).
+				Variations(ldvalue.Int(v)).Build()
+			_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Features(), flagKey,
+				ldstoretypes.ItemDescriptor{Version: v, Item: &flag})
+			require.NoError(t, err)
+		}
+	}
+	evaluate := func(t *testing.T, p relayTestParams, query string) (int, debugFlagEvaluationRep) {
+		result, body := st.DoRequest(makeEvalRequest(env.Name, query, userJSON, adminKey), p.relay)
+		var rep debugFlagEvaluationRep
+		if result.StatusCode == http.StatusOK {
+			require.NoError(t, json.Unmarshal(body, &rep))
+		}
+		return result.StatusCode, rep
+	}
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeEvalRequest(env.Name, "", userJSON, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeEvalRequest("nonexistent", "", userJSON, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			status, _ := evaluate(t, p, "")
+			assert.Equal(t, http.StatusNotFound, status)
+		})
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			upsertVersions(t, p, 1)
+			status, _ := evaluate(t, p, "?version=x")
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	})
+
+	t.Run("evaluates current version", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			upsertVersions(t, p, 1, 2)
+			status, rep := evaluate(t, p, "")
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, debugFlagEvaluationRep{
+				Key:            flagKey,
+				Version:        2,
+				Value:          ldvalue.Int(2),
+				VariationIndex: ldvalue.NewOptionalInt(0),
+				Reason:         ldreason.NewEvalReasonOff(),
+			}, rep)
+		})
+	})
+
+	t.Run("evaluates retained previous versions", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			upsertVersions(t, p, 1, 2, 3, 4)
+			for _, v := range []int{2, 3, 4} {
+				status, rep := evaluate(t, p, "?version="+strconv.Itoa(v))
+				if assert.Equal(t, http.StatusOK, status, v) {
+					assert.Equal(t, v, rep.Version)
+					assert.Equal(t, ldvalue.Int(v), rep.Value)
+				}
+			}
+			status, _ := evaluate(t, p, "?version=1") // older than the configured depth of 2
+			assert.Equal(t, http.StatusNotFound, status)
+		})
+	})
+
+	t.Run("only current version is available without history", func(t *testing.T) {
+		config := config
+		config.Main.FlagHistoryDepth = ct.OptIntGreaterThanZero{}
+		withStartedRelay(t, config, func(p relayTestParams) {
+			upsertVersions(t, p, 1, 2)
+			status, _ := evaluate(t, p, "?version=2")
+			assert.Equal(t, http.StatusOK, status)
+			status, _ = evaluate(t, p, "?version=1")
+			assert.Equal(t, http.StatusNotFound, status)
+		})
+	})
+}
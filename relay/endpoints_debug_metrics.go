@@ -0,0 +1,24 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// debugMetricsHandler returns a JSON snapshot of the current values of all metrics, grouped by
+// environment. Unlike the metrics exporters, this does not require any metrics configuration.
+func debugMetricsHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		snapshot, err := relay.metricsManager.GetSnapshot()
+		if err != nil { // COVERAGE: can't make this happen in unit tests
+			relay.loggers.Errorf("Error reading metrics: %s", err)
+			util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading metrics: %s", err)
+			return
+		}
+		data, _ := json.Marshal(snapshot)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
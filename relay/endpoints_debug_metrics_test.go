@@ -0,0 +1,64 @@
+package relay
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointsDebugMetrics(t *testing.T) {
+	adminKey := "admin-key"
+	env := st.EnvMain
+	makeMetricsRequest := func(authKey string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://localhost/debug/metrics", nil)
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(env)
+
+	t.Run("endpoint is not available without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMetricsRequest(adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMetricsRequest(""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("returns request counts for environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			pollRequest := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", env.Config.SDKKey, nil)
+			result, _ := st.DoRequest(pollRequest, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+
+			assert.Eventually(t, func() bool {
+				result, body := st.DoRequest(makeMetricsRequest(adminKey), p.relay)
+				if result.StatusCode != http.StatusOK {
+					return false
+				}
+				requests := ldvalue.Parse(body).GetByKey("environments").GetByKey(env.Name).GetByKey("requests")
+				return requests.Count() != 0
+			}, time.Second, time.Millisecond*10)
+		})
+	})
+}
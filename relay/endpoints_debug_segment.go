@@ -0,0 +1,63 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/gorilla/mux"
+)
+
+// debugSegmentRep is the response body for the segment debugging endpoint.
+type debugSegmentRep struct {
+	Segment json.RawMessage `json:"segment"`
+	// MembershipStoredExternally is true for a big segment, whose included and excluded contexts are
+	// not part of the segment data, but are kept in a separate store.
+	MembershipStoredExternally bool `json:"membershipStoredExternally,omitempty"`
+}
+
+// debugSegmentHandler returns the stored definition of a segment in one environment, identified by the
+// same key that is used in the status resource. A deleted segment is treated as not found.
+func debugSegmentHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName, key := mux.Vars(req)["envName"], mux.Vars(req)["key"]
+		target := relay.getEnvironmentByStatusKey(envName)
+		if target == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", envName)
+			return
+		}
+		store := target.GetStore()
+		if store == nil {
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Environment is not initialized")
+			return
+		}
+		item, err := store.Get(ldstoreimpl.Segments(), key)
+		if err != nil {
+			target.GetLoggers().Errorf("Error reading feature store: %s", err)
+			util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
+			return
+		}
+		segment, ok := item.Item.(*ldmodel.Segment)
+		if !ok || segment == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown segment %q", key)
+			return
+		}
+
+		segmentJSON, err := json.Marshal(segment)
+		if err != nil {
+			target.GetLoggers().Errorf("Error marshaling JSON: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		data, _ := json.Marshal(debugSegmentRep{
+			Segment:                    segmentJSON,
+			MembershipStoredExternally: segment.Unbounded,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
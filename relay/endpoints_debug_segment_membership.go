@@ -0,0 +1,96 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"github.com/gorilla/mux"
+)
+
+// debugSegmentMembershipRep is the response body for the segment membership debugging endpoint.
+type debugSegmentMembershipRep struct {
+	Segments []string `json:"segments"`
+	// BigSegmentsStatus is the status of the big segment store as reported by the evaluator, if any big
+	// segments were checked. If it is anything other than HEALTHY, big segment membership may be wrong.
+	BigSegmentsStatus ldreason.BigSegmentsStatus `json:"bigSegmentsStatus,omitempty"`
+}
+
+// debugSegmentMembershipHandler returns the keys of all segments in one environment that match the
+// context in the request body. Each segment is checked by evaluating a flag whose only rule is a
+// segmentMatch clause for that segment, so the result is exactly what a flag targeting that segment
+// would see, including big segment membership.
+func debugSegmentMembershipHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName := mux.Vars(req)["envName"]
+		target := relay.getEnvironmentByStatusKey(envName)
+		if target == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", envName)
+			return
+		}
+
+		body, err := io.ReadAll(req.Body)
+		var tooLargeErr *http.MaxBytesError
+		if errors.As(err, &tooLargeErr) {
+			util.WriteErrorJSONf(w, http.StatusRequestEntityTooLarge, "Request body exceeds the maximum size of %d bytes",
+				tooLargeErr.Limit)
+			return
+		}
+		ldContext, err := middleware.ContextFromJSON(body)
+		if err != nil {
+			util.WriteErrorJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		store := target.GetStore()
+		evaluator := target.GetEvaluator()
+		if store == nil || evaluator == nil {
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Environment is not initialized")
+			return
+		}
+		items, err := store.GetAll(ldstoreimpl.Segments())
+		if err != nil {
+			target.GetLoggers().Errorf("Error reading feature store: %s", err)
+			util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
+			return
+		}
+
+		rep := debugSegmentMembershipRep{Segments: []string{}}
+		for _, item := range items {
+			segment, ok := item.Item.Item.(*ldmodel.Segment)
+			if !ok || segment == nil {
+				continue
+			}
+			flag := ldbuilders.NewFlagBuilder("$segment-membership").
+				On(true).
+				Variations(ldvalue.Bool(false), ldvalue.Bool(true)).
+				FallthroughVariation(0).
+				AddRule(ldbuilders.NewRuleBuilder().Variation(1).Clauses(ldbuilders.SegmentMatchClause(segment.Key))).
+				Build()
+			result := evaluator.Evaluate(&flag, ldContext, nil)
+			if result.Detail.Value.BoolValue() {
+				rep.Segments = append(rep.Segments, segment.Key)
+			}
+			if segment.Unbounded && (rep.BigSegmentsStatus == "" || rep.BigSegmentsStatus == ldreason.BigSegmentsHealthy) {
+				// If several big segments were checked, report the first status that was not HEALTHY.
+				rep.BigSegmentsStatus = result.Detail.Reason.GetBigSegmentsStatus()
+			}
+		}
+		sort.Strings(rep.Segments)
+
+		data, _ := json.Marshal(rep)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
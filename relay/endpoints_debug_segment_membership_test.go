@@ -0,0 +1,106 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsDebugSegmentMembership(t *testing.T) {
+	adminKey := "admin-key"
+	env := st.EnvMain
+	makeMembershipRequest := func(envName, body, authKey string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost/debug/env/"+envName+"/segment-membership",
+			bytes.NewBufferString(body))
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	userJSON := `{"key":"user-key","country":"nz"}`
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(env)
+
+	t.Run("endpoint is not available without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMembershipRequest(env.Name, userJSON, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMembershipRequest(env.Name, userJSON, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMembershipRequest("nonexistent", userJSON, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("invalid context", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMembershipRequest(env.Name, `{"key":`, adminKey), p.relay)
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		})
+	})
+
+	t.Run("returns matching segments", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			relayEnv, _ := p.relay.getEnvironment(sdkauth.New(env.Config.SDKKey))
+			require.NotNil(t, relayEnv)
+			upsert := func(segment ldmodel.Segment) {
+				_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Segments(), segment.Key,
+					ldstoretypes.ItemDescriptor{Version: segment.Version, Item: &segment})
+				require.NoError(t, err)
+			}
+			upsert(ldbuilders.NewSegmentBuilder("included").Version(1).Included("user-key").Build())
+			countryRule := ldbuilders.NewSegmentRuleBuilder().Clauses(
+				ldbuilders.Clause("country", ldmodel.OperatorIn, ldvalue.String("nz")))
+			upsert(ldbuilders.NewSegmentBuilder("by-rule").Version(1).AddRule(countryRule).Build())
+			upsert(ldbuilders.NewSegmentBuilder("excluded").Version(1).Excluded("user-key").AddRule(countryRule).Build())
+			upsert(ldbuilders.NewSegmentBuilder("other").Version(1).Included("other-key").Build())
+
+			result, body := st.DoRequest(makeMembershipRequest(env.Name, userJSON, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.JSONEq(t, `{"segments":["by-rule","included"]}`, string(body))
+		})
+	})
+
+	t.Run("big segment without a big segment store", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			relayEnv, _ := p.relay.getEnvironment(sdkauth.New(env.Config.SDKKey))
+			require.NotNil(t, relayEnv)
+			bigSegment := ldbuilders.NewSegmentBuilder("big-segment").Version(1).Unbounded(true).Generation(1).Build()
+			_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Segments(), bigSegment.Key,
+				ldstoretypes.ItemDescriptor{Version: bigSegment.Version, Item: &bigSegment})
+			require.NoError(t, err)
+
+			result, body := st.DoRequest(makeMembershipRequest(env.Name, userJSON, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.JSONEq(t, `{"segments":[],"bigSegmentsStatus":"NOT_CONFIGURED"}`, string(body))
+		})
+	})
+}
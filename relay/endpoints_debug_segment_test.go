@@ -0,0 +1,98 @@
+package relay
+
+import (
+	"net/http"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsDebugSegment(t *testing.T) {
+	adminKey := "admin-key"
+	env := st.EnvMain
+	makeSegmentRequest := func(envName, key, authKey string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://localhost/debug/env/"+envName+"/segment/"+key, nil)
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(env)
+
+	t.Run("endpoint is not available without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeSegmentRequest(env.Name, st.Segment1.Key, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeSegmentRequest(env.Name, st.Segment1.Key, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeSegmentRequest("nonexistent", st.Segment1.Key, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown segment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeSegmentRequest(env.Name, "nonexistent", adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("returns segment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := st.DoRequest(makeSegmentRequest(env.Name, st.Segment1.Key, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.JSONEq(t, `{"segment":`+jsonhelpers.ToJSONString(st.Segment1)+`}`, string(body))
+		})
+	})
+
+	t.Run("big segment and deleted segment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			relayEnv, _ := p.relay.getEnvironment(sdkauth.New(env.Config.SDKKey))
+			require.NotNil(t, relayEnv)
+			bigSegment := ldbuilders.NewSegmentBuilder("big-segment").Version(2).Unbounded(true).Generation(3).Build()
+			_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Segments(), bigSegment.Key,
+				ldstoretypes.ItemDescriptor{Version: bigSegment.Version, Item: &bigSegment})
+			require.NoError(t, err)
+			_, err = relayEnv.GetStore().Upsert(ldstoreimpl.Segments(), st.Segment1.Key,
+				ldstoretypes.ItemDescriptor{Version: st.Segment1.Version + 1})
+			require.NoError(t, err)
+
+			result, body := st.DoRequest(makeSegmentRequest(env.Name, bigSegment.Key, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			value := ldvalue.Parse(body)
+			assert.True(t, value.GetByKey("membershipStoredExternally").BoolValue())
+			assert.Equal(t, 2, value.GetByKey("segment").GetByKey("version").IntValue())
+			assert.True(t, value.GetByKey("segment").GetByKey("unbounded").BoolValue())
+
+			result, _ = st.DoRequest(makeSegmentRequest(env.Name, st.Segment1.Key, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+}
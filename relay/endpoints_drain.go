@@ -0,0 +1,65 @@
+package relay
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// drainRep is the request and response body for the drain endpoint.
+type drainRep struct {
+	Draining bool `json:"draining"`
+}
+
+// drainHandler turns draining mode on or off. While draining, new stream and evaluation requests are
+// rejected with a 503 error and the readiness resource reports that Relay is not ready, so that a load
+// balancer will stop routing to it; streams that are already open are not affected.
+func drainHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var params drainRep
+		body, err := io.ReadAll(req.Body)
+		if err == nil {
+			err = json.Unmarshal(body, &params)
+		}
+		if err != nil {
+			util.WriteErrorJSONf(w, http.StatusBadRequest, "Invalid drain request body: %s", err)
+			return
+		}
+
+		relay.drainer.SetDraining(params.Draining)
+		if params.Draining {
+			relay.loggers.Warn("Draining mode turned on; new stream and evaluation requests will be rejected")
+		} else {
+			relay.loggers.Info("Draining mode turned off")
+		}
+
+		data, _ := json.Marshal(params)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
+
+// healthHandler always returns a 200 status, to indicate that the Relay process is running.
+func healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// readyHandler returns a 200 status if Relay is ready to accept new requests, or a 503 status if it
+// is draining or, in auto-configuration mode, has not yet received its environment configurations.
+func readyHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		relay.lock.RLock()
+		fullyConfigured := relay.fullyConfigured
+		relay.lock.RUnlock()
+
+		if !fullyConfigured || relay.drainer.IsDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,115 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointsDrain(t *testing.T) {
+	adminKey := "admin-key"
+	makeDrainRequest := func(body, authKey string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost/debug/drain", bytes.NewBufferString(body))
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	getStatusCode := func(p relayTestParams, path string) int {
+		r, _ := http.NewRequest("GET", "http://localhost"+path, nil)
+		result, _ := st.DoRequest(r, p.relay)
+		return result.StatusCode
+	}
+	streamRequest := func() *http.Request {
+		return st.MakeSDKStreamEndpointRequest("http://localhost", basictypes.ServerSideStream, st.EnvMain, "", 0)
+	}
+	evalRequest := func() *http.Request {
+		return st.MakeSDKEvalEndpointRequest("http://localhost", basictypes.MobileSDK, st.EnvMobile, st.SimpleUserJSON, 0)
+	}
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	t.Run("endpoint is not available without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeDrainRequest(`{"draining":true}`, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeDrainRequest(`{"draining":true}`, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+			assert.False(t, p.relay.drainer.IsDraining())
+		})
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeDrainRequest(`{`, adminKey), p.relay)
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+			assert.False(t, p.relay.drainer.IsDraining())
+		})
+	})
+
+	t.Run("health and ready when not draining", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			assert.Equal(t, http.StatusOK, getStatusCode(p, "/health"))
+			assert.Equal(t, http.StatusOK, getStatusCode(p, "/ready"))
+		})
+	})
+
+	t.Run("draining rejects new requests", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := st.DoRequest(makeDrainRequest(`{"draining":true}`, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.JSONEq(t, `{"draining":true}`, string(body))
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Draining mode turned on")
+
+			assert.Equal(t, http.StatusOK, getStatusCode(p, "/health"))
+			assert.Equal(t, http.StatusServiceUnavailable, getStatusCode(p, "/ready"))
+			assert.Equal(t, http.StatusOK, getStatusCode(p, "/status"))
+
+			result, _ = st.DoRequest(streamRequest(), p.relay)
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+			result, _ = st.DoRequest(evalRequest(), p.relay)
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+
+			result, _ = st.DoRequest(makeDrainRequest(`{"draining":false}`, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+
+			assert.Equal(t, http.StatusOK, getStatusCode(p, "/ready"))
+			result, _ = st.DoRequest(evalRequest(), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+		})
+	})
+
+	t.Run("existing streams stay open while draining", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			st.WithStreamRequest(t, streamRequest(), p.relay, func(eventCh <-chan eventsource.Event) {
+				_ = helpers.RequireValue(t, eventCh, time.Second*3, "timed out waiting for initial event")
+
+				result, _ := st.DoRequest(makeDrainRequest(`{"draining":true}`, adminKey), p.relay)
+				assert.Equal(t, http.StatusOK, result.StatusCode)
+
+				helpers.AssertNoMoreValues(t, eventCh, 100*time.Millisecond)
+			})
+		})
+	})
+}
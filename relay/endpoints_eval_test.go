@@ -1,20 +1,40 @@
 package relay
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/credential"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/msgpack"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 
+	"github.com/launchdarkly/eventsource"
+	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 )
 
 // These user and context representations are designed to be equivalent in terms of the test flags
@@ -259,3 +279,655 @@ func TestEndpointsEvalJSClient(t *testing.T) {
 		}
 	})
 }
+
+func TestEndpointsEvalSchemaVersionHeader(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, params := range []struct {
+			name          string
+			schemaVersion string
+			expectedBody  string
+		}{
+			{"no header", "", st.MakeEvalBody(st.AllFlags, false)},
+			{"value-only schema", basictypes.EvalSchemaValueOnly, st.MakeEvalBodyValueOnly(st.AllFlags)},
+			{"detailed schema", basictypes.EvalSchemaDetailed, st.MakeEvalBody(st.AllFlags, false)},
+			{"unknown schema", "99", st.MakeEvalBody(st.AllFlags, false)},
+		} {
+			t.Run(params.name, func(t *testing.T) {
+				req := st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", sdkKey, basicContextJSON)
+				req.Header.Set("Content-Type", "application/json")
+				if params.schemaVersion != "" {
+					req.Header.Set(basictypes.EvalSchemaVersionHeader, params.schemaVersion)
+				}
+				result, body := st.DoRequest(req, p.relay)
+
+				if assert.Equal(t, http.StatusOK, result.StatusCode) {
+					m.In(t).Assert(body, st.ExpectJSONBody(params.expectedBody))
+				}
+			})
+		}
+	})
+}
+
+func TestEndpointsEvalMobileMessagePack(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		mobileRequest := func(accept string) *http.Request {
+			req := st.BuildRequestWithAuth("REPORT", "http://localhost/msdk/evalx/context",
+				st.EnvMobile.Config.MobileKey, basicContextJSON)
+			req.Header.Set("Content-Type", "application/json")
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			return req
+		}
+
+		for _, accept := range []string{
+			"application/msgpack",
+			"application/x-msgpack",
+			"application/json;q=0.5, application/msgpack",
+		} {
+			t.Run("MessagePack for "+accept, func(t *testing.T) {
+				result, body := st.DoRequest(mobileRequest(accept), p.relay)
+				require.Equal(t, http.StatusOK, result.StatusCode)
+				assert.Equal(t, msgpack.ContentType, result.Header.Get("Content-Type"))
+				assert.Equal(t, "Accept", result.Header.Get("Vary"))
+
+				var decoded bytes.Buffer
+				_, err := msgp.UnmarshalAsJSON(&decoded, body)
+				require.NoError(t, err)
+				m.In(t).Assert(decoded.Bytes(),
+					st.ExpectJSONBody(st.MakeEvalBody(st.MobileFlags, false)))
+			})
+		}
+
+		for _, accept := range []string{"", "application/json", "*/*", "application/msgpack;q=0"} {
+			t.Run("JSON for "+accept, func(t *testing.T) {
+				result, body := st.DoRequest(mobileRequest(accept), p.relay)
+				require.Equal(t, http.StatusOK, result.StatusCode)
+				assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+				m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody(st.MobileFlags, false)))
+			})
+		}
+
+		t.Run("JSON for server-side SDK", func(t *testing.T) {
+			req := st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context",
+				st.EnvMain.Config.SDKKey, basicContextJSON)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", msgpack.ContentType)
+			result, body := st.DoRequest(req, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+			m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody(st.AllFlags, false)))
+		})
+	})
+}
+
+func TestEndpointsEvalAnonymousUserWithoutKey(t *testing.T) {
+	env := st.EnvMobile
+	mobileKey := env.Config.MobileKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, params := range []struct {
+			name           string
+			data           []byte
+			expectedStatus int
+		}{
+			{"anonymous user", []byte(`{"anonymous":true}`), http.StatusOK},
+			{"anonymous context", []byte(`{"kind":"user","anonymous":true}`), http.StatusOK},
+			{"non-anonymous user", []byte(`{"name":"Keyless Joe"}`), http.StatusBadRequest},
+		} {
+			t.Run(params.name, func(t *testing.T) {
+				req := st.BuildRequestWithAuth("REPORT", "http://localhost/msdk/evalx/context", mobileKey, params.data)
+				req.Header.Set("Content-Type", "application/json")
+				result, _ := st.DoRequest(req, p.relay)
+				assert.Equal(t, params.expectedStatus, result.StatusCode)
+			})
+		}
+	})
+}
+
+func TestEndpointsEvalGetAndReportAreConsistent(t *testing.T) {
+	env := st.EnvMobile
+	mobileKey := env.Config.MobileKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, params := range []struct {
+			name string
+			data []byte
+		}{
+			{"user", basicUserJSON},
+			{"context", basicContextJSON},
+			// The standard base64 encoding of this one contains "+", but not "/" which could not be used in a path
+			{"anonymous context", []byte(`{"kind":"user","anonymous":true,"name":"~~~>>>"}`)},
+		} {
+			t.Run(params.name, func(t *testing.T) {
+				reportBody := append([]byte(" "), append(params.data, '\n')...)
+				req := st.BuildRequestWithAuth("REPORT", "http://localhost/msdk/evalx/context?withReasons=true", mobileKey, reportBody)
+				req.Header.Set("Content-Type", "application/json")
+				reportResult, expectedBody := st.DoRequest(req, p.relay)
+				require.Equal(t, http.StatusOK, reportResult.StatusCode)
+
+				for _, encoded := range []string{
+					base64.URLEncoding.EncodeToString(params.data),
+					base64.RawURLEncoding.EncodeToString(params.data),
+					base64.StdEncoding.EncodeToString(params.data),
+				} {
+					req := st.BuildRequestWithAuth("GET", "http://localhost/msdk/evalx/contexts/"+url.PathEscape(encoded)+"?withReasons=true", mobileKey, nil)
+					getResult, body := st.DoRequest(req, p.relay)
+					if assert.Equal(t, http.StatusOK, getResult.StatusCode, encoded) {
+						assert.JSONEq(t, string(expectedBody), string(body), encoded)
+					}
+				}
+			})
+		}
+	})
+}
+
+func TestEndpointsEvalDataAgeHeader(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	doRequest := func(p relayTestParams) *http.Response {
+		req := st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", sdkKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		result, _ := st.DoRequest(req, p.relay)
+		return result
+	}
+
+	t.Run("header is not sent by default", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result := doRequest(p)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Empty(t, result.Header.Get(middleware.DataAgeHeader))
+		})
+	})
+
+	t.Run("header is sent if enabled", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.SendDataAgeHeader = true
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result := doRequest(p)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.Equal(t, "0", result.Header.Get(middleware.DataAgeHeader))
+		})
+	})
+}
+
+func TestEndpointsEvalReportBodySizeLimit(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+	largeContextJSON := []byte(`{"kind": "user", "key": "me", "name": "` + strings.Repeat("x", 2000) + `"}`)
+
+	doRequest := func(p relayTestParams, body []byte) *http.Response {
+		req := st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", sdkKey, body)
+		req.Header.Set("Content-Type", "application/json")
+		result, _ := st.DoRequest(req, p.relay)
+		return result
+	}
+
+	t.Run("body within limit is accepted", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxReportBodySize = ct.NewOptInt(1000)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			assert.Equal(t, http.StatusOK, doRequest(p, basicContextJSON).StatusCode)
+		})
+	})
+
+	t.Run("body over limit is rejected", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxReportBodySize = ct.NewOptInt(1000)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			assert.Equal(t, http.StatusRequestEntityTooLarge, doRequest(p, largeContextJSON).StatusCode)
+		})
+	})
+
+	t.Run("zero means unlimited", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxReportBodySize = ct.NewOptInt(0)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			assert.Equal(t, http.StatusOK, doRequest(p, largeContextJSON).StatusCode)
+		})
+	})
+}
+
+func TestEndpointsEvalStartupFlags(t *testing.T) {
+	doRequest := func(p relayTestParams, url string, authKey credential.SDKCredential) (*http.Response, []byte) {
+		req := st.BuildRequestWithAuth("REPORT", url, authKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		return st.DoRequest(req, p.relay)
+	}
+
+	t.Run("mobile, startup flags configured", func(t *testing.T) {
+		env := st.EnvMobile
+		env.Config.StartupFlags = ct.NewOptStringList([]string{st.Flag4ClientSide.Flag.Key, st.Flag7Mobile.Flag.Key})
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := doRequest(p, "http://localhost/msdk/evalx/startup/context", env.Config.MobileKey)
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(
+					st.MakeEvalBody([]st.TestFlag{st.Flag4ClientSide, st.Flag7Mobile}, false)))
+			}
+		})
+	})
+
+	t.Run("mobile, no startup flags configured", func(t *testing.T) {
+		env := st.EnvMobile
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := doRequest(p, "http://localhost/msdk/evalx/startup/context", env.Config.MobileKey)
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody(st.MobileFlags, false)))
+			}
+		})
+	})
+
+	t.Run("JS client, flags not available to client-side SDKs are excluded", func(t *testing.T) {
+		env := st.EnvClientSide
+		env.Config.StartupFlags = ct.NewOptStringList([]string{st.Flag1ServerSide.Flag.Key, st.Flag5ClientSide.Flag.Key})
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			url := "http://localhost/sdk/evalx/" + string(env.Config.EnvID) + "/startup/context"
+			result, body := doRequest(p, url, env.Config.EnvID)
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(
+					st.MakeEvalBody([]st.TestFlag{st.Flag5ClientSide}, false)))
+			}
+		})
+	})
+}
+
+func TestEndpointsEvalContextKindPrefixes(t *testing.T) {
+	env := st.EnvMobile
+	env.Config.ContextKindPrefixes = ct.NewOptStringList([]string{"user:client-", "other:mobile-"})
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	doRequest := func(p relayTestParams, contextJSON []byte) (*http.Response, []byte) {
+		req := st.BuildRequestWithAuth("REPORT", "http://localhost/msdk/evalx/context", env.Config.MobileKey, contextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		return st.DoRequest(req, p.relay)
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("single-kind context sees flags for its kind", func(t *testing.T) {
+			result, body := doRequest(p, jsonhelpers.ToJSON(st.BasicUserForTestFlags))
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody([]st.TestFlag{st.Flag4ClientSide}, false)))
+			}
+		})
+
+		t.Run("multi-kind context sees flags for all of its kinds", func(t *testing.T) {
+			result, body := doRequest(p, basicContextJSON)
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(
+					st.MakeEvalBody([]st.TestFlag{st.Flag4ClientSide, st.Flag7Mobile}, false)))
+			}
+		})
+
+		t.Run("context of an unmapped kind sees no flags", func(t *testing.T) {
+			result, body := doRequest(p, jsonhelpers.ToJSON(ldcontext.NewWithKind("org", "org-key")))
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				assert.JSONEq(t, "{}", string(body))
+			}
+		})
+	})
+}
+
+func TestEndpointsEvalMaxFlagCount(t *testing.T) {
+	env := st.EnvMobile
+	env.Config.StartupFlags = ct.NewOptStringList([]string{st.Flag4ClientSide.Flag.Key})
+
+	doRequest := func(p relayTestParams, url string) (*http.Response, []byte) {
+		req := st.BuildRequestWithAuth("REPORT", url, env.Config.MobileKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		return st.DoRequest(req, p.relay)
+	}
+
+	t.Run("limit enforced", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxFlagCount, _ = ct.NewOptIntGreaterThanZero(len(st.MobileFlags) - 1)
+		config.Main.EnforceMaxFlagCount = true
+		config.Main.StreamFlagValues = true
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, url := range []string{
+				"http://localhost/msdk/evalx/context",
+				"http://localhost/meval",
+				"http://localhost/meval/poll",
+			} {
+				result, body := doRequest(p, url)
+				assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode, url)
+				assert.Contains(t, string(body), "exceeding the limit", url)
+			}
+
+			result, body := doRequest(p, "http://localhost/msdk/evalx/startup/context")
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody([]st.TestFlag{st.Flag4ClientSide}, false)))
+			}
+		})
+	})
+
+	t.Run("evaluation stream is closed when limit is exceeded", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxFlagCount, _ = ct.NewOptIntGreaterThanZero(len(st.MobileFlags))
+		config.Main.EnforceMaxFlagCount = true
+		config.Main.StreamFlagValues = true
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			envContext, _ := p.relay.getEnvironment(sdkauth.New(env.Config.MobileKey))
+			require.NotNil(t, envContext)
+
+			req := st.BuildRequestWithAuth("REPORT", "http://localhost/meval", env.Config.MobileKey, basicContextJSON)
+			req.Header.Set("Content-Type", "application/json")
+			st.WithStreamRequest(t, req, p.relay, func(eventCh <-chan eventsource.Event) {
+				event := helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for put")
+				assert.Equal(t, "put", event.Event())
+
+				newFlag := st.Flag7Mobile.Flag
+				newFlag.Key = "new-mobile-flag"
+				_, err := envContext.GetStore().Upsert(ldstoreimpl.Features(), newFlag.Key, st.FlagDesc(newFlag))
+				require.NoError(t, err)
+
+				assert.Nil(t, helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for stream to close"))
+			})
+		})
+	})
+
+	t.Run("limit not enforced", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+		config.Main.MaxFlagCount, _ = ct.NewOptIntGreaterThanZero(1)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := doRequest(p, "http://localhost/msdk/evalx/context")
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				m.In(t).Assert(body, st.ExpectJSONBody(st.MakeEvalBody(st.MobileFlags, false)))
+			}
+		})
+	})
+}
+
+func TestEndpointsEvalRedactedFlags(t *testing.T) {
+	env := st.EnvMobile
+	env.Config.RedactedFlags = ct.NewOptStringList([]string{st.Flag4ClientSide.Flag.Key})
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	type flagResult struct {
+		Value     ldvalue.Value       `json:"value"`
+		Variation ldvalue.OptionalInt `json:"variation"`
+	}
+	doRequest := func(p relayTestParams, url string, authKey credential.SDKCredential) map[string]flagResult {
+		req := st.BuildRequestWithAuth("REPORT", url, authKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		result, body := st.DoRequest(req, p.relay)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		var flags map[string]flagResult
+		require.NoError(t, json.Unmarshal(body, &flags))
+		return flags
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("mobile SDK gets a null value but the real variation", func(t *testing.T) {
+			flags := doRequest(p, "http://localhost/msdk/evalx/context", env.Config.MobileKey)
+			assert.Equal(t, ldvalue.Null(), flags[st.Flag4ClientSide.Flag.Key].Value)
+			assert.Equal(t, ldvalue.NewOptionalInt(st.Flag4ClientSide.ExpectedVariation),
+				flags[st.Flag4ClientSide.Flag.Key].Variation)
+			assert.Equal(t, ldvalue.CopyArbitraryValue(st.Flag7Mobile.ExpectedValue), flags[st.Flag7Mobile.Flag.Key].Value)
+		})
+
+		t.Run("server-side SDK gets the real value", func(t *testing.T) {
+			flags := doRequest(p, "http://localhost/sdk/evalx/context", env.Config.SDKKey)
+			assert.Equal(t, ldvalue.CopyArbitraryValue(st.Flag4ClientSide.ExpectedValue), flags[st.Flag4ClientSide.Flag.Key].Value)
+		})
+	})
+}
+
+func TestEndpointsEvalWithPrerequisites(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	baseFlag := ldbuilders.NewFlagBuilder("base-flag").Version(1).On(true).
+		Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).Build()
+	middleFlag := ldbuilders.NewFlagBuilder("middle-flag").Version(2).On(true).
+		AddPrerequisite(baseFlag.Key, 0).OffVariation(1).
+		Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).Build()
+	topFlag := ldbuilders.NewFlagBuilder("top-flag").Version(3).On(true).
+		AddPrerequisite(middleFlag.Key, 1).OffVariation(1).
+		Variations(ldvalue.String("a"), ldvalue.String("b")).FallthroughVariation(0).Build()
+
+	doRequest := func(p relayTestParams, url string) map[string]map[string]interface{} {
+		req := st.BuildRequestWithAuth("REPORT", url, sdkKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		result, body := st.DoRequest(req, p.relay)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		var flags map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &flags))
+		return flags
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		relayEnv, _ := p.relay.getEnvironment(sdkauth.New(sdkKey))
+		require.NotNil(t, relayEnv)
+		for _, flag := range []ldmodel.FeatureFlag{baseFlag, middleFlag, topFlag} {
+			f := flag
+			_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Features(), f.Key,
+				ldstoretypes.ItemDescriptor{Version: f.Version, Item: &f})
+			require.NoError(t, err)
+		}
+
+		t.Run("prerequisites are included when requested", func(t *testing.T) {
+			flags := doRequest(p, "http://localhost/sdk/evalx/context?withPrerequisites=true")
+
+			top := flags[topFlag.Key]
+			require.NotNil(t, top)
+			assert.Equal(t, "b", top["value"])
+			assert.Equal(t, jsonhelpers.ToJSONString([]interface{}{
+				map[string]interface{}{"key": baseFlag.Key, "prerequisiteOf": middleFlag.Key, "value": true,
+					"variation": 0, "version": 1, "reason": map[string]interface{}{"kind": "FALLTHROUGH"}},
+				map[string]interface{}{"key": middleFlag.Key, "prerequisiteOf": topFlag.Key, "value": true,
+					"variation": 0, "version": 2, "reason": map[string]interface{}{"kind": "FALLTHROUGH"}},
+			}), jsonhelpers.ToJSONString(top["prerequisites"]))
+			assert.NotContains(t, top, "reason")
+
+			assert.Equal(t, []interface{}{}, flags[baseFlag.Key]["prerequisites"])
+		})
+
+		t.Run("prerequisites are not included by default", func(t *testing.T) {
+			flags := doRequest(p, "http://localhost/sdk/evalx/context")
+
+			top := flags[topFlag.Key]
+			require.NotNil(t, top)
+			assert.Equal(t, "b", top["value"])
+			assert.NotContains(t, top, "prerequisites")
+		})
+	})
+
+	t.Run("JS client, prerequisites not available to client-side SDKs, and their prerequisites, are excluded", func(t *testing.T) {
+		env := st.EnvClientSide
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(env)
+
+		clientSideFlag := ldbuilders.NewFlagBuilder("client-side-flag").Version(1).On(true).
+			ClientSideUsingEnvironmentID(true).
+			Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).Build()
+		serverSideMiddleFlag := ldbuilders.NewFlagBuilder("server-side-middle-flag").Version(1).On(true).
+			AddPrerequisite(clientSideFlag.Key, 0).OffVariation(1).
+			Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).Build()
+		clientSideTopFlag := ldbuilders.NewFlagBuilder("client-side-top-flag").Version(1).On(true).
+			ClientSideUsingEnvironmentID(true).
+			AddPrerequisite(baseFlag.Key, 0).AddPrerequisite(serverSideMiddleFlag.Key, 0).
+			AddPrerequisite(clientSideFlag.Key, 0).OffVariation(1).
+			Variations(ldvalue.Bool(true), ldvalue.Bool(false)).FallthroughVariation(0).Build()
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			relayEnv, _ := p.relay.getEnvironment(sdkauth.New(env.Config.EnvID))
+			require.NotNil(t, relayEnv)
+			for _, flag := range []ldmodel.FeatureFlag{baseFlag, clientSideFlag, serverSideMiddleFlag, clientSideTopFlag} {
+				f := flag
+				_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Features(), f.Key,
+					ldstoretypes.ItemDescriptor{Version: f.Version, Item: &f})
+				require.NoError(t, err)
+			}
+
+			url := "http://localhost/sdk/evalx/" + string(env.Config.EnvID) + "/context?withPrerequisites=true"
+			req := st.BuildRequest("REPORT", url, basicContextJSON, http.Header{"Content-Type": []string{"application/json"}})
+			result, body := st.DoRequest(req, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+			var flags map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &flags))
+
+			assert.NotContains(t, flags, baseFlag.Key)
+			top := flags[clientSideTopFlag.Key]
+			require.NotNil(t, top)
+			assert.Equal(t, true, top["value"])
+			assert.Equal(t, jsonhelpers.ToJSONString([]interface{}{
+				map[string]interface{}{"key": clientSideFlag.Key, "prerequisiteOf": clientSideTopFlag.Key, "value": true,
+					"variation": 0, "version": 1, "reason": map[string]interface{}{"kind": "FALLTHROUGH"}},
+			}), jsonhelpers.ToJSONString(top["prerequisites"]))
+		})
+	})
+}
+
+func TestEndpointsEvalWithReasonsForTrackedFlags(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	untrackedFlag := ldbuilders.NewFlagBuilder("untracked-flag").Version(1).On(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).Build()
+	trackedFlag := ldbuilders.NewFlagBuilder("tracked-flag").Version(1).On(true).TrackEvents(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).Build()
+	experimentFlag := ldbuilders.NewFlagBuilder("experiment-flag").Version(1).On(true).TrackEventsFallthrough(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).Build()
+
+	doRequest := func(p relayTestParams, url string) map[string]map[string]interface{} {
+		req := st.BuildRequestWithAuth("REPORT", url, sdkKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		result, body := st.DoRequest(req, p.relay)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+		var flags map[string]map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &flags))
+		return flags
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		relayEnv, _ := p.relay.getEnvironment(sdkauth.New(sdkKey))
+		require.NotNil(t, relayEnv)
+		for _, flag := range []ldmodel.FeatureFlag{untrackedFlag, trackedFlag, experimentFlag} {
+			f := flag
+			_, err := relayEnv.GetStore().Upsert(ldstoreimpl.Features(), f.Key,
+				ldstoretypes.ItemDescriptor{Version: f.Version, Item: &f})
+			require.NoError(t, err)
+		}
+
+		for _, tc := range []struct {
+			query                          string
+			untrackedReason, trackedReason bool
+		}{
+			{"", false, false},
+			{"?withReasons=tracked", false, true},
+			{"?withReasons=true", true, true},
+		} {
+			t.Run("withReasons"+tc.query, func(t *testing.T) {
+				flags := doRequest(p, "http://localhost/sdk/evalx/context"+tc.query)
+				assert.Equal(t, tc.untrackedReason, flags[untrackedFlag.Key]["reason"] != nil)
+				assert.Equal(t, tc.trackedReason, flags[trackedFlag.Key]["reason"] != nil)
+				assert.NotNil(t, flags[experimentFlag.Key]["reason"])
+			})
+		}
+	})
+}
+
+func TestEndpointsEvalErrorResponsesUseLaunchDarklyErrorSchema(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, tc := range []struct {
+			name   string
+			req    *http.Request
+			status int
+			code   string
+		}{
+			{
+				name:   "missing credential",
+				req:    st.BuildRequest("REPORT", "http://localhost/sdk/evalx/context", basicContextJSON, nil),
+				status: http.StatusUnauthorized,
+				code:   "unauthorized",
+			},
+			{
+				name:   "unknown credential",
+				req:    st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", st.UndefinedSDKKey, basicContextJSON),
+				status: http.StatusUnauthorized,
+				code:   "unauthorized",
+			},
+			{
+				name:   "wrong content type",
+				req:    st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", sdkKey, basicContextJSON),
+				status: http.StatusUnsupportedMediaType,
+				code:   "unsupported_media_type",
+			},
+			{
+				name:   "invalid context",
+				req:    st.BuildRequestWithAuth("GET", "http://localhost/sdk/evalx/contexts/not-base64!", sdkKey, nil),
+				status: http.StatusBadRequest,
+				code:   "invalid_request",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				resp, body := st.DoRequest(tc.req, p.relay)
+				assert.Equal(t, tc.status, resp.StatusCode)
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+				var errorRep struct {
+					Code    string `json:"code"`
+					Message string `json:"message"`
+				}
+				require.NoError(t, json.Unmarshal(body, &errorRep), string(body))
+				assert.Equal(t, tc.code, errorRep.Code)
+				assert.NotEmpty(t, errorRep.Message)
+			})
+		}
+	})
+}
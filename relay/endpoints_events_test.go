@@ -1,6 +1,8 @@
 package relay
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,9 +25,10 @@ import (
 )
 
 type publishedEvent struct {
-	url     string
-	data    []byte
-	authKey string
+	url             string
+	data            []byte
+	authKey         string
+	contentEncoding string
 }
 
 type relayEventsTestParams struct {
@@ -46,7 +49,8 @@ func relayEventsTest(t *testing.T, config c.Config, action func(relayEventsTestP
 
 	eventsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		data, _ := io.ReadAll(req.Body)
-		eventsCh <- publishedEvent{url: req.URL.String(), data: data, authKey: req.Header.Get("Authorization")}
+		eventsCh <- publishedEvent{url: req.URL.String(), data: data, authKey: req.Header.Get("Authorization"),
+			contentEncoding: req.Header.Get("Content-Encoding")}
 		w.WriteHeader(http.StatusAccepted)
 	}))
 	defer eventsServer.Close()
@@ -78,6 +82,14 @@ func makeTestFeatureEventPayload(userKey string) []byte {
 	return data
 }
 
+func gzipTestPayload(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
 func TestEndpointsEventProxyServerSide(t *testing.T) {
 	env := st.EnvMain
 	sdkKey := env.Config.SDKKey
@@ -104,6 +116,19 @@ func TestEndpointsEventProxyServerSide(t *testing.T) {
 			}
 		})
 
+		t.Run("gzip-compressed bulk post", func(t *testing.T) {
+			r := makeRequest(sdkKey)
+			r.Body = io.NopCloser(bytes.NewReader(gzipTestPayload(body)))
+			r.ContentLength = -1
+			r.Header.Set("Content-Encoding", "gzip")
+			result, _ := st.DoRequest(r, p.relay)
+
+			if assert.Equal(t, http.StatusAccepted, result.StatusCode) {
+				event := p.requirePublishedEvent(t, body)
+				assert.Equal(t, "/bulk", event.url)
+			}
+		})
+
 		t.Run("unknown SDK key", func(t *testing.T) {
 			r := makeRequest(st.UndefinedSDKKey)
 			result, _ := st.DoRequest(r, p.relay)
@@ -125,6 +150,34 @@ func TestEndpointsEventProxyServerSide(t *testing.T) {
 				assert.Equal(t, string(sdkKey), event.authKey)
 			}
 		})
+
+		t.Run("gzip-compressed diagnostics forwarding", func(t *testing.T) {
+			eventData := []byte(`{"kind":"diagnostic"}`)
+			header := make(http.Header)
+			header.Set("Content-Type", "application/json")
+			header.Set("Content-Encoding", "gzip")
+			header.Set("Authorization", string(sdkKey))
+			r := st.BuildRequest("POST", "http://localhost/diagnostic", gzipTestPayload(eventData), header)
+			result, _ := st.DoRequest(r, p.relay)
+
+			if assert.Equal(t, http.StatusAccepted, result.StatusCode) {
+				event := p.requirePublishedEvent(t, eventData)
+				assert.Equal(t, "", event.contentEncoding)
+			}
+		})
+	})
+
+	t.Run("decompressed body too large", func(t *testing.T) {
+		config := config
+		config.Events.MaxDecompressedSize, _ = ct.NewOptIntGreaterThanZero(len(body) - 1)
+		relayEventsTest(t, config, func(p relayEventsTestParams) {
+			r := makeRequest(sdkKey)
+			r.Body = io.NopCloser(bytes.NewReader(gzipTestPayload(body)))
+			r.ContentLength = -1
+			r.Header.Set("Content-Encoding", "gzip")
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+		})
 	})
 
 	t.Run("events disabled", func(t *testing.T) {
@@ -277,6 +330,35 @@ func TestEndpointsEventProxyJSClient(t *testing.T) {
 		})
 	})
 
+	t.Run("image endpoint disabled", func(t *testing.T) {
+		config1 := config
+		config1.Environment = st.MakeEnvConfigs(env)
+		config1.Events.DisableImageEndpoint = true
+		relayEventsTest(t, config1, func(p relayEventsTestParams) {
+			r := specs[1].request()
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+
+			r = specs[0].request()
+			r.Header.Set(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+			result, _ = st.DoRequest(r, p.relay)
+			if assert.Equal(t, http.StatusAccepted, result.StatusCode) {
+				p.requirePublishedEvent(t, eventData)
+			}
+		})
+	})
+
+	t.Run("image data too large", func(t *testing.T) {
+		config1 := config
+		config1.Environment = st.MakeEnvConfigs(env)
+		config1.Events.MaxImageDataSize, _ = ct.NewOptIntGreaterThanZero(len(eventData))
+		relayEventsTest(t, config1, func(p relayEventsTestParams) {
+			result, _ := st.DoRequest(specs[1].request(), p.relay)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+			helpers.AssertNoMoreValues(t, p.publishedEvents, time.Millisecond*1500)
+		})
+	})
+
 	t.Run("events disabled", func(t *testing.T) {
 		withStartedRelay(t, config, func(p relayTestParams) {
 			for _, spec := range specs {
@@ -53,6 +53,40 @@ func TestEndpointsJSClientCORS(t *testing.T) {
 		})
 	})
 
+	t.Run("Access-Control-Allow-Header with global and per-environment custom values", func(t *testing.T) {
+		env := st.EnvClientSide
+		env.Config.AllowedHeader = configtypes.NewOptStringList([]string{"my-header-1"})
+		config := c.Config{Environment: st.MakeEnvConfigs(env)}
+		config.Main.CORSAllowedHeaders = configtypes.NewOptStringList([]string{"global-header"})
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(endpoint.request(), p.relay)
+			if assert.Equal(t, endpoint.expectedStatus, result.StatusCode) {
+				assert.Equal(t, browser.DefaultAllowedHeaders+",global-header,my-header-1", result.Header.Get("Access-Control-Allow-Headers"))
+			}
+		})
+	})
+
+	t.Run("default Access-Control-Allow-Methods", func(t *testing.T) {
+		config := c.Config{Environment: st.MakeEnvConfigs(st.EnvClientSide)}
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(endpoint.request(), p.relay)
+			if assert.Equal(t, endpoint.expectedStatus, result.StatusCode) {
+				assert.Equal(t, "GET,OPTIONS", result.Header.Get("Access-Control-Allow-Methods"))
+			}
+		})
+	})
+
+	t.Run("Access-Control-Allow-Methods with custom value", func(t *testing.T) {
+		config := c.Config{Environment: st.MakeEnvConfigs(st.EnvClientSide)}
+		config.Main.CORSAllowedMethods = configtypes.NewOptStringList([]string{"GET", "REPORT", "OPTIONS"})
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(endpoint.request(), p.relay)
+			if assert.Equal(t, endpoint.expectedStatus, result.StatusCode) {
+				assert.Equal(t, "GET,REPORT,OPTIONS", result.Header.Get("Access-Control-Allow-Methods"))
+			}
+		})
+	})
+
 	t.Run("default Access-Control-Allow-Origin", func(t *testing.T) {
 		config := c.Config{Environment: st.MakeEnvConfigs(st.EnvClientSide)}
 		withStartedRelay(t, config, func(p relayTestParams) {
@@ -0,0 +1,174 @@
+package relay
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsMobileLongPoll(t *testing.T) {
+	userJSON := []byte(`{"key":"me"}`)
+	mobileKey := st.EnvMobile.Config.MobileKey
+	expectedBody := st.MakeEvalBody(st.MobileFlags, false)
+
+	var config c.Config
+	config.Main.MobileLongPollTimeout = ct.NewOptDuration(time.Millisecond * 100)
+	config.Environment = st.MakeEnvConfigs(st.EnvMobile)
+
+	getRequest := endpointTestParams{"long poll GET", "GET", "/meval/poll/$DATA", userJSON, mobileKey, 200, st.ExpectNoBody()}
+	reportRequest := endpointTestParams{"long poll REPORT", "REPORT", "/meval/poll", userJSON, mobileKey, 200, st.ExpectNoBody()}
+	withEtag := func(r *http.Request, etag string) *http.Request {
+		r.Header.Set("If-None-Match", etag)
+		return r
+	}
+
+	for _, s := range []endpointTestParams{getRequest, reportRequest} {
+		t.Run(s.name, func(t *testing.T) {
+			t.Run("returns flag values immediately without If-None-Match", func(t *testing.T) {
+				withStartedRelay(t, config, func(p relayTestParams) {
+					result, body := st.DoRequest(s.request(), p.relay)
+					require.Equal(t, http.StatusOK, result.StatusCode)
+					assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+					assert.NotEqual(t, "", result.Header.Get("Etag"))
+					assert.JSONEq(t, expectedBody, string(body))
+				})
+			})
+
+			t.Run("returns flag values immediately if If-None-Match is out of date", func(t *testing.T) {
+				withStartedRelay(t, config, func(p relayTestParams) {
+					result, body := st.DoRequest(withEtag(s.request(), "relay-old"), p.relay)
+					require.Equal(t, http.StatusOK, result.StatusCode)
+					assert.JSONEq(t, expectedBody, string(body))
+				})
+			})
+
+			t.Run("returns 304 after timeout if nothing changes", func(t *testing.T) {
+				withStartedRelay(t, config, func(p relayTestParams) {
+					result, _ := st.DoRequest(s.request(), p.relay)
+					etag := result.Header.Get("Etag")
+
+					startTime := time.Now()
+					result, body := st.DoRequest(withEtag(s.request(), etag), p.relay)
+					assert.Equal(t, http.StatusNotModified, result.StatusCode)
+					assert.Equal(t, etag, result.Header.Get("Etag"))
+					assert.Len(t, body, 0)
+					assert.GreaterOrEqual(t, time.Since(startTime), time.Millisecond*100)
+				})
+			})
+
+			t.Run("returns new flag values when they change", func(t *testing.T) {
+				var longConfig c.Config
+				longConfig.Main.MobileLongPollTimeout = ct.NewOptDuration(time.Minute)
+				longConfig.Environment = config.Environment
+				withStartedRelay(t, longConfig, func(p relayTestParams) {
+					env, _ := p.relay.getEnvironment(sdkauth.New(mobileKey))
+					require.NotNil(t, env)
+					result, _ := st.DoRequest(s.request(), p.relay)
+					etag := result.Header.Get("Etag")
+
+					type response struct {
+						result *http.Response
+						body   []byte
+					}
+					responseCh := make(chan response, 1)
+					go func() {
+						result, body := st.DoRequest(withEtag(s.request(), etag), p.relay)
+						responseCh <- response{result, body}
+					}()
+
+					// An update that doesn't change the result for this context doesn't end the request
+					segment := st.Segment1
+					segment.Version++
+					_, err := env.GetStore().Upsert(ldstoreimpl.Segments(), segment.Key, st.SegmentDesc(segment))
+					require.NoError(t, err)
+					helpers.AssertNoMoreValues(t, responseCh, time.Millisecond*100, "request should still be waiting")
+
+					changedFlag := st.Flag7Mobile.Flag
+					changedFlag.Version++
+					changedFlag.On = false
+					changedFlag.OffVariation = ldvalue.NewOptionalInt(0)
+					changedFlag.Variations = []ldvalue.Value{ldvalue.String("changed")}
+					_, err = env.GetStore().Upsert(ldstoreimpl.Features(), changedFlag.Key, st.FlagDesc(changedFlag))
+					require.NoError(t, err)
+
+					r := helpers.RequireValue(t, responseCh, time.Second, "timed out waiting for response")
+					require.Equal(t, http.StatusOK, r.result.StatusCode)
+					assert.NotEqual(t, etag, r.result.Header.Get("Etag"))
+					assert.Equal(t, "changed", ldvalue.Parse(r.body).GetByKey(changedFlag.Key).GetByKey("value").StringValue())
+				})
+			})
+
+			t.Run("logs connection if enabled", func(t *testing.T) {
+				logConfig := config
+				logConfig.Main.LogConnections = true
+				withStartedRelay(t, logConfig, func(p relayTestParams) {
+					result, _ := st.DoRequest(s.request(), p.relay)
+					require.Equal(t, http.StatusOK, result.StatusCode)
+					p.mockLog.AssertMessageMatch(t, true, ldlog.Info, `Stream connection accepted \(SDK: mobile`)
+					p.mockLog.AssertMessageMatch(t, true, ldlog.Info, `Stream connection closed after .* \(SDK: mobile`)
+				})
+			})
+
+			t.Run("unknown mobile key", func(t *testing.T) {
+				withStartedRelay(t, config, func(p relayTestParams) {
+					s1 := s
+					s1.credential = st.UndefinedMobileKey
+					result, _ := st.DoRequest(s1.request(), p.relay)
+					assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+				})
+			})
+		})
+	}
+}
+
+func TestMobilePollFallbackHeader(t *testing.T) {
+	userJSON := []byte(`{"key":"me"}`)
+	mobileKey := st.EnvMobile.Config.MobileKey
+	requests := []endpointTestParams{
+		{"mobile evalx", "GET", "/msdk/evalx/contexts/$DATA", userJSON, mobileKey, 200, st.ExpectNoBody()},
+		{"mobile long poll", "GET", "/meval/poll/$DATA", userJSON, mobileKey, 200, st.ExpectNoBody()},
+		{"mobile unknown key", "GET", "/msdk/evalx/contexts/$DATA", userJSON, st.UndefinedMobileKey, 401, st.ExpectNoBody()},
+	}
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	t.Run("not sent by default", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, s := range requests {
+				result, _ := st.DoRequest(s.request(), p.relay)
+				assert.Equal(t, s.expectedStatus, result.StatusCode, s.name)
+				assert.Equal(t, "", result.Header.Get(pollFallbackHeader), s.name)
+			}
+		})
+	})
+
+	t.Run("sent if enabled", func(t *testing.T) {
+		config.Main.MobilePollFallback = true
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, s := range requests {
+				result, _ := st.DoRequest(s.request(), p.relay)
+				assert.Equal(t, s.expectedStatus, result.StatusCode, s.name)
+				assert.Equal(t, mobileLongPollPath, result.Header.Get(pollFallbackHeader), s.name)
+			}
+
+			// not sent for other kinds of SDKs
+			r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/latest-all", st.EnvMain.Config.SDKKey, nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, "", result.Header.Get(pollFallbackHeader))
+		})
+	})
+}
@@ -0,0 +1,59 @@
+package relay
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+)
+
+// maintenanceRep is the request and response body for the maintenance endpoint.
+type maintenanceRep struct {
+	Enabled      bool     `json:"enabled"`
+	Environment  string   `json:"environment,omitempty"`
+	Environments []string `json:"environments,omitempty"`
+}
+
+// maintenanceHandler turns maintenance mode on or off for one environment, identified by the same key
+// that is used in the status resource, or for all environments if no environment is specified.
+func maintenanceHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var params maintenanceRep
+		body, err := io.ReadAll(req.Body)
+		if err == nil {
+			err = json.Unmarshal(body, &params)
+		}
+		if err != nil {
+			util.WriteErrorJSONf(w, http.StatusBadRequest, "Invalid maintenance request body: %s", err)
+			return
+		}
+
+		var targets []relayenv.EnvContext
+		for _, env := range relay.getAllEnvironments() {
+			if params.Environment == "" || relay.getEnvStatusKey(env) == params.Environment {
+				targets = append(targets, env)
+			}
+		}
+		if params.Environment != "" && len(targets) == 0 {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", params.Environment)
+			return
+		}
+
+		resp := maintenanceRep{Enabled: params.Enabled, Environments: []string{}}
+		for _, env := range targets {
+			env.SetMaintenanceMode(params.Enabled)
+			resp.Environments = append(resp.Environments, relay.getEnvStatusKey(env))
+		}
+		if params.Enabled {
+			relay.loggers.Warnf("Maintenance mode turned on for %d environment(s)", len(targets))
+		} else {
+			relay.loggers.Infof("Maintenance mode turned off for %d environment(s)", len(targets))
+		}
+
+		data, _ := json.Marshal(resp)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
@@ -0,0 +1,156 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointsMaintenance(t *testing.T) {
+	adminKey := "admin-key"
+	makeMaintenanceRequestWithAuth := func(body, authKey string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost/debug/maintenance", bytes.NewBufferString(body))
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	makeMaintenanceRequest := func(body string) *http.Request {
+		return makeMaintenanceRequestWithAuth(body, adminKey)
+	}
+	getStatus := func(p relayTestParams) ldvalue.Value {
+		r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+		_, body := st.DoRequest(r, p.relay)
+		return ldvalue.Parse(body)
+	}
+
+	t.Run("endpoint is not available by default", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMaintenanceRequest(`{"enabled":true}`), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			assert.False(t, p.relay.getAllEnvironments()[0].IsInMaintenanceMode())
+		})
+	})
+
+	t.Run("Relay does not start without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		_, err := newRelayInternal(config, relayInternalOptions{
+			loggers:       ldlog.NewDisabledLoggers(),
+			clientFactory: testclient.CreateDummyClient,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Main.AdminKey = adminKey
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, authKey := range []string{"", "wrong-key"} {
+				result, _ := st.DoRequest(makeMaintenanceRequestWithAuth(`{"enabled":true}`, authKey), p.relay)
+				assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+			}
+			assert.False(t, p.relay.getAllEnvironments()[0].IsInMaintenanceMode())
+		})
+	})
+
+	t.Run("single environment", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Main.AdminKey = adminKey
+		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := st.DoRequest(
+				makeMaintenanceRequest(`{"enabled":true,"environment":"`+st.EnvMain.Name+`"}`), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			st.AssertJSONPathMatch(t, true, ldvalue.Parse(body), "enabled")
+			assert.Equal(t, ldvalue.ArrayOf(ldvalue.String(st.EnvMain.Name)), ldvalue.Parse(body).GetByKey("environments"))
+
+			status := getStatus(p)
+			st.AssertJSONPathMatch(t, "maintenance", status, "environments", st.EnvMain.Name, "status")
+			st.AssertJSONPathMatch(t, "connected", status, "environments", st.EnvMobile.Name, "status")
+			st.AssertJSONPathMatch(t, "healthy", status, "status")
+
+			result, _ = st.DoRequest(
+				makeMaintenanceRequest(`{"enabled":false,"environment":"`+st.EnvMain.Name+`"}`), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			st.AssertJSONPathMatch(t, "connected", getStatus(p), "environments", st.EnvMain.Name, "status")
+		})
+	})
+
+	t.Run("all environments", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Main.AdminKey = adminKey
+		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMaintenanceRequest(`{"enabled":true}`), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+
+			status := getStatus(p)
+			st.AssertJSONPathMatch(t, "maintenance", status, "environments", st.EnvMain.Name, "status")
+			st.AssertJSONPathMatch(t, "maintenance", status, "environments", st.EnvMobile.Name, "status")
+
+			result, _ = st.DoRequest(makeMaintenanceRequest(`{"enabled":false}`), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+
+			status = getStatus(p)
+			st.AssertJSONPathMatch(t, "connected", status, "environments", st.EnvMain.Name, "status")
+			st.AssertJSONPathMatch(t, "connected", status, "environments", st.EnvMobile.Name, "status")
+		})
+	})
+
+	t.Run("config flag starts all environments in maintenance mode", func(t *testing.T) {
+		var config c.Config
+		config.Main.MaintenanceMode = true
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			st.AssertJSONPathMatch(t, "maintenance", getStatus(p), "environments", st.EnvMain.Name, "status")
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Main.AdminKey = adminKey
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMaintenanceRequest(`{"enabled":true,"environment":"nope"}`), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			assert.False(t, p.relay.getAllEnvironments()[0].IsInMaintenanceMode())
+		})
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		var config c.Config
+		config.Main.EnableMaintenanceEndpoint = true
+		config.Main.AdminKey = adminKey
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeMaintenanceRequest(`{"enabled":`), p.relay)
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		})
+	})
+}
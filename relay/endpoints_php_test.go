@@ -3,14 +3,21 @@ package relay
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
 	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEndpointsPHPPolling(t *testing.T) {
@@ -21,13 +28,18 @@ func TestEndpointsPHPPolling(t *testing.T) {
 		{"get flag", "GET", fmt.Sprintf("/sdk/flags/%s", st.Flag1ServerSide.Flag.Key), nil, sdkKeyMain,
 			http.StatusOK, st.ExpectJSONEntity(st.Flag1ServerSide.Flag)},
 		{"get unknown flag", "GET", "/sdk/flags/no-such-flag", nil, sdkKeyMain,
-			http.StatusNotFound, st.ExpectNoBody()},
+			http.StatusNotFound, st.ExpectJSONBody(`{"code":"not_found","message":"Unknown flag \"no-such-flag\""}`)},
 		{"get all flags", "GET", "/sdk/flags", nil, sdkKeyMain,
 			http.StatusOK, st.ExpectJSONEntity(st.FlagsMap(st.AllFlags))},
 		{"get segment", "GET", fmt.Sprintf("/sdk/segments/%s", st.Segment1.Key), nil, sdkKeyMain,
 			http.StatusOK, st.ExpectJSONEntity(st.Segment1)},
 		{"get unknown segment", "GET", "/sdk/segments/no-such-segment", nil, sdkKeyMain,
-			http.StatusNotFound, st.ExpectNoBody()},
+			http.StatusNotFound, st.ExpectJSONBody(`{"code":"not_found","message":"Unknown segment \"no-such-segment\""}`)},
+		{"get all data", "GET", "/sdk/latest-all", nil, sdkKeyMain,
+			http.StatusOK, st.ExpectJSONEntity(map[string]interface{}{
+				"flags":    st.FlagsMap(st.AllFlags),
+				"segments": map[string]interface{}{st.Segment1.Key: st.Segment1},
+			})},
 	}
 
 	var config c.Config
@@ -83,3 +95,141 @@ func TestEndpointsPHPPolling(t *testing.T) {
 		}
 	})
 }
+
+func TestEndpointsPHPPollingSinceSequence(t *testing.T) {
+	sdkKey := st.EnvMain.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		env, _ := p.relay.getEnvironment(sdkauth.New(sdkKey))
+		require.NotNil(t, env)
+		store := env.GetStore()
+
+		getDelta := func(t *testing.T, since string) ldvalue.Value {
+			return getFlagsDelta(t, p.relay, sdkKey, since)
+		}
+
+		t.Run("since 0 returns all flags", func(t *testing.T) {
+			r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags?since=0", sdkKey, nil)
+			result, body := st.DoRequest(r, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+			m.In(t).Assert(body, m.JSONProperty("flags").Should(st.ExpectJSONEntity(st.FlagsMap(st.AllFlags))))
+			m.In(t).Assert(body, m.JSONProperty("deleted").Should(m.JSONStrEqual(`[]`)))
+			m.In(t).Assert(body, m.JSONProperty("sequence").Should(m.StringContains(":")))
+		})
+
+		t.Run("returns changes to flags with disjoint version ranges", func(t *testing.T) {
+			start := sequenceOf(getDelta(t, "0"))
+
+			_, err := st.UpsertFlag(store, ldbuilders.NewFlagBuilder("high-version-flag").Version(1000).Build())
+			require.NoError(t, err)
+			delta1 := getDelta(t, start)
+			assert.Equal(t, []string{"high-version-flag"}, flagKeys(delta1))
+			assert.NotEqual(t, start, sequenceOf(delta1))
+
+			// This flag's version is much lower than the other flag's, but it changed more recently
+			_, err = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("low-version-flag").Version(2).Build())
+			require.NoError(t, err)
+			delta2 := getDelta(t, sequenceOf(delta1))
+			assert.Equal(t, []string{"low-version-flag"}, flagKeys(delta2))
+			assert.NotEqual(t, sequenceOf(delta1), sequenceOf(delta2))
+
+			assert.ElementsMatch(t, []string{"high-version-flag", "low-version-flag"}, flagKeys(getDelta(t, start)))
+		})
+
+		t.Run("returns deleted flags", func(t *testing.T) {
+			start := sequenceOf(getDelta(t, "0"))
+			_, err := store.Upsert(ldstoreimpl.Features(), st.Flag1ServerSide.Flag.Key,
+				ldstoretypes.ItemDescriptor{Version: st.Flag1ServerSide.Flag.Version + 1})
+			require.NoError(t, err)
+
+			delta := getDelta(t, start)
+			assert.Len(t, flagKeys(delta), 0)
+			assert.Equal(t, ldvalue.ArrayOf(ldvalue.String(st.Flag1ServerSide.Flag.Key)), delta.GetByKey("deleted"))
+		})
+
+		t.Run("returns empty delta if nothing has changed", func(t *testing.T) {
+			sequence := sequenceOf(getDelta(t, "0"))
+			delta := getDelta(t, sequence)
+			assert.JSONEq(t, fmt.Sprintf(`{"flags":{},"deleted":[],"sequence":%q}`, sequence), delta.JSONString())
+		})
+
+		t.Run("returns all flags if sequence is from a different Relay instance", func(t *testing.T) {
+			current := sequenceOf(getDelta(t, "0"))
+			delta := getDelta(t, "some-other-epoch:1")
+			assert.Equal(t, current, sequenceOf(delta))
+			assert.Contains(t, flagKeys(delta), st.Flag2ServerSide.Flag.Key)
+			assert.Equal(t, 0, delta.GetByKey("deleted").Count())
+		})
+
+		t.Run("full flag set does not include deleted flags", func(t *testing.T) {
+			r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil)
+			result, body := st.DoRequest(r, p.relay)
+
+			if assert.Equal(t, http.StatusOK, result.StatusCode) {
+				assert.False(t, ldvalue.Parse(body).GetByKey(st.Flag1ServerSide.Flag.Key).IsDefined())
+			}
+		})
+
+		t.Run("invalid sequence number", func(t *testing.T) {
+			for _, since := range []string{"x", "-1", "1", "epoch:x", "epoch:-1"} {
+				r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags?since="+since, sdkKey, nil)
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, http.StatusBadRequest, result.StatusCode, since)
+			}
+		})
+	})
+}
+
+func TestEndpointsPHPPollingSinceSequenceFromBeforeRestart(t *testing.T) {
+	sdkKey := st.EnvMain.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	var oldSequence string
+	withStartedRelay(t, config, func(p relayTestParams) {
+		oldSequence = sequenceOf(getFlagsDelta(t, p.relay, sdkKey, "0"))
+	})
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		env, _ := p.relay.getEnvironment(sdkauth.New(sdkKey))
+		require.NotNil(t, env)
+
+		// Make enough changes that the new sequence number is past the old one, so that only the epoch
+		// can tell us that the old sequence number doesn't apply to this Relay instance
+		for i := 0; i < 5; i++ {
+			_, err := st.UpsertFlag(env.GetStore(), ldbuilders.NewFlagBuilder("new-flag").Version(i+1).Build())
+			require.NoError(t, err)
+		}
+		current := sequenceOf(getFlagsDelta(t, p.relay, sdkKey, "0"))
+		require.NotEqual(t, oldSequence, current)
+
+		delta := getFlagsDelta(t, p.relay, sdkKey, oldSequence)
+		assert.Equal(t, current, sequenceOf(delta))
+		expectedKeys := []string{"new-flag"}
+		for _, f := range st.AllFlags {
+			expectedKeys = append(expectedKeys, f.Flag.Key)
+		}
+		assert.ElementsMatch(t, expectedKeys, flagKeys(delta))
+		assert.Equal(t, 0, delta.GetByKey("deleted").Count())
+	})
+}
+
+func getFlagsDelta(t *testing.T, handler http.Handler, sdkKey c.SDKKey, since string) ldvalue.Value {
+	r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags?since="+url.QueryEscape(since), sdkKey, nil)
+	result, body := st.DoRequest(r, handler)
+	require.Equal(t, http.StatusOK, result.StatusCode)
+	assert.NotEqual(t, "", result.Header.Get("Etag"))
+	return ldvalue.Parse(body)
+}
+
+func flagKeys(delta ldvalue.Value) []string {
+	return delta.GetByKey("flags").Keys(nil)
+}
+
+func sequenceOf(delta ldvalue.Value) string {
+	return delta.GetByKey("sequence").StringValue()
+}
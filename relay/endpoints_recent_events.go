@@ -0,0 +1,42 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/events"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// recentEventsRep is the response body for the recent events endpoint.
+type recentEventsRep struct {
+	Batches []events.RecentEventBatch `json:"batches"`
+}
+
+// recentEventsHandler returns the most recent batches of analytics events that were received for one
+// environment, identified by the same key that is used in the status resource. This is only available
+// if events are being proxied and the configuration specifies how many batches to retain.
+func recentEventsHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName := mux.Vars(req)["envName"]
+		target := relay.getEnvironmentByStatusKey(envName)
+		if target == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", envName)
+			return
+		}
+		var batches []events.RecentEventBatch
+		if dispatcher := target.GetEventDispatcher(); dispatcher != nil {
+			batches = dispatcher.GetRecentEvents()
+		}
+		if batches == nil {
+			util.WriteErrorJSON(w, http.StatusNotFound, "Recent events are not being retained for this environment")
+			return
+		}
+
+		data, _ := json.Marshal(recentEventsRep{Batches: batches})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+}
@@ -0,0 +1,87 @@
+package relay
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/events"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointsRecentEvents(t *testing.T) {
+	adminKey := "admin-key"
+	env := st.EnvMain
+	makeRecentEventsRequest := func(envName, authKey string) *http.Request {
+		r, _ := http.NewRequest("GET", "http://localhost/debug/env/"+envName+"/recent-events", nil)
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	postEvents := func(p relayTestParams, body []byte) {
+		header := make(http.Header)
+		header.Set("Authorization", string(env.Config.SDKKey))
+		header.Set(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+		result, _ := st.DoRequest(st.BuildRequest("POST", "http://localhost/bulk", body, header), p.relay)
+		assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	}
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(env)
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeRecentEventsRequest(env.Name, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeRecentEventsRequest("nonexistent", adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("not available if recent events are not retained", func(t *testing.T) {
+		relayEventsTest(t, config, func(p relayEventsTestParams) {
+			postEvents(p.relayTestParams, makeTestFeatureEventPayload("me"))
+			result, _ := st.DoRequest(makeRecentEventsRequest(env.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("returns recent batches", func(t *testing.T) {
+		configWithBuffer := config
+		configWithBuffer.Events.RecentBatches, _ = ct.NewOptIntGreaterThanZero(2)
+		relayEventsTest(t, configWithBuffer, func(p relayEventsTestParams) {
+			result, body := st.DoRequest(makeRecentEventsRequest(env.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			assert.JSONEq(t, `{"batches":[]}`, string(body))
+
+			for _, key := range []string{"a", "b", "c"} {
+				postEvents(p.relayTestParams, makeTestFeatureEventPayload(key))
+			}
+
+			result, body = st.DoRequest(makeRecentEventsRequest(env.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			batches := ldvalue.Parse(body).GetByKey("batches")
+			if assert.Equal(t, 2, batches.Count()) {
+				for i, key := range []string{"b", "c"} {
+					batch := batches.GetByIndex(i)
+					assert.Equal(t, "/bulk", batch.GetByKey("path").StringValue())
+					assert.Equal(t, 1, batch.GetByKey("eventCount").IntValue())
+					assert.Equal(t, key, batch.GetByKey("events").GetByIndex(0).GetByKey("userKey").StringValue())
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,29 @@
+package relay
+
+import (
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/gorilla/mux"
+)
+
+// reconnectHandler closes and reopens the LaunchDarkly connection for one environment, identified by the
+// same key that is used in the status resource. It responds as soon as the reconnect has started; the
+// status resource shows the environment as "reconnecting" until it has finished.
+func reconnectHandler(relay *Relay) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		envName := mux.Vars(req)["envName"]
+		target := relay.getEnvironmentByStatusKey(envName)
+		if target == nil {
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown environment %q", envName)
+			return
+		}
+		if target.IsInMaintenanceMode() {
+			util.WriteErrorJSON(w, http.StatusConflict, "Cannot reconnect an environment that is in maintenance mode")
+			return
+		}
+		target.Reconnect()
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
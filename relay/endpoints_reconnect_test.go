@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/testhelpers/ldservices"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsReconnect(t *testing.T) {
+	adminKey := "admin-key"
+	makeReconnectRequest := func(envName, authKey string) *http.Request {
+		r, _ := http.NewRequest("POST", "http://localhost/debug/env/"+envName+"/reconnect", nil)
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	t.Run("endpoint is not available without an admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeReconnectRequest(st.EnvMain.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("requires admin key", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeReconnectRequest(st.EnvMain.Name, ""), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+			result, _ = st.DoRequest(makeReconnectRequest(st.EnvMain.Name, "wrong-key"), p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+		})
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, body := st.DoRequest(makeReconnectRequest("nonexistent", adminKey), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			assert.Contains(t, string(body), "nonexistent")
+		})
+	})
+
+	t.Run("reconnects environment", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(makeReconnectRequest(st.EnvMain.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusAccepted, result.StatusCode)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, "Reconnecting to LaunchDarkly on request")
+
+			require.Eventually(t, func() bool {
+				r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+				_, body := st.DoRequest(r, p.relay)
+				status := ldvalue.Parse(body)
+				return status.GetByKey("environments").GetByKey(st.EnvMain.Name).GetByKey("status").StringValue() == "connected"
+			}, time.Second, time.Millisecond*10)
+		})
+	})
+
+	t.Run("environment in maintenance mode", func(t *testing.T) {
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, env := range p.relay.getAllEnvironments() {
+				env.SetMaintenanceMode(true)
+			}
+			result, _ := st.DoRequest(makeReconnectRequest(st.EnvMain.Name, adminKey), p.relay)
+			assert.Equal(t, http.StatusConflict, result.StatusCode)
+		})
+	})
+}
+
+func TestEndpointsReconnectThatNeverCompletes(t *testing.T) {
+	adminKey := "admin-key"
+	testEnv := st.EnvWithAllCredentials
+	putEvent := ldservices.NewServerSDKData().Flags(&testFlag).ToPutEvent()
+	streamHandler, _ := ldservices.ServerSideStreamingServiceHandler(putEvent)
+	hangingStreamHandler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done() // never sends any data, so the new connection never becomes ready
+	})
+
+	config := c.Config{Environment: st.MakeEnvConfigs(testEnv)}
+	config.Main.AdminKey = adminKey
+	config.Main.DisconnectedStatusTime = configtypes.NewOptDuration(time.Millisecond * 200)
+
+	ldStreamHandler := httphelpers.SequentialHandler(streamHandler, hangingStreamHandler)
+	relayEndToEndTest(t, config, relayTestBehavior{}, ldStreamHandler, func(p relayEndToEndTestParams) {
+		p.waitForSuccessfulInit()
+		getStatus := func() (relayStatus, envStatus string) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			_, body := st.DoRequest(r, p.relay)
+			status := ldvalue.Parse(body)
+			return status.GetByKey("status").StringValue(),
+				status.GetByKey("environments").GetByKey(testEnv.Name).GetByKey("status").StringValue()
+		}
+
+		r, _ := http.NewRequest("POST", "http://localhost/debug/env/"+testEnv.Name+"/reconnect", nil)
+		r.Header.Set("Authorization", adminKey)
+		result, _ := st.DoRequest(r, p.relay)
+		require.Equal(t, http.StatusAccepted, result.StatusCode)
+
+		relayStatus, envStatus := getStatus()
+		assert.Equal(t, "healthy", relayStatus)
+		assert.Equal(t, "reconnecting", envStatus)
+
+		require.Eventually(t, func() bool {
+			relayStatus, _ := getStatus()
+			return relayStatus == "degraded"
+		}, time.Second*2, time.Millisecond*10)
+		_, envStatus = getStatus()
+		assert.Equal(t, "reconnecting", envStatus)
+	})
+}
@@ -18,24 +18,71 @@ import (
 const (
 	statusEnvConnected    = "connected"
 	statusEnvDisconnected = "disconnected"
+	statusEnvCached       = "cached"
+	statusEnvMaintenance  = "maintenance"
+	statusEnvReconnecting = "reconnecting"
 	statusRelayHealthy    = "healthy"
 	statusRelayDegraded   = "degraded"
 )
 
-func statusHandler(relay *Relay) http.Handler {
+// statusKeyDisplay determines how SDK keys and mobile keys appear in the status resource.
+type statusKeyDisplay int
+
+const (
+	statusKeysMasked statusKeyDisplay = iota
+	statusKeysHidden
+	statusKeysFull
+)
+
+func statusKeyDisplayFromConfig(value string) statusKeyDisplay {
+	if value == config.StatusKeyDisplayHidden {
+		return statusKeysHidden
+	}
+	return statusKeysMasked
+}
+
+// format returns the form of a key that should appear in the status resource, or an empty string if
+// the key should be omitted.
+func (d statusKeyDisplay) format(key string) string {
+	switch d {
+	case statusKeysHidden:
+		return ""
+	case statusKeysFull:
+		return key
+	default:
+		return sdks.ObscureKey(key)
+	}
+}
+
+// statusHandler serves the status resource. If fullKeys is true, SDK keys and mobile keys are shown
+// unmasked regardless of the StatusKeyDisplay setting; that is only for the admin-authenticated version
+// of the resource.
+func statusHandler(relay *Relay, fullKeys bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		resp := api.StatusRep{
 			Environments:  make(map[string]api.EnvironmentStatusRep),
 			Version:       relay.version,
 			ClientVersion: ld.Version,
+			Connections: api.ConnectionsStatusRep{
+				Current: relay.connectionLimiter.Count(),
+				Limit:   relay.connectionLimiter.MaxConnections(),
+			},
 		}
 
 		relay.lock.Lock()
 		fullyConfigured := relay.fullyConfigured
+		mainConfig := relay.config.Main // can be changed by Reload
 		relay.lock.Unlock()
 
+		keyDisplay := statusKeyDisplayFromConfig(mainConfig.StatusKeyDisplay)
+		if fullKeys {
+			keyDisplay = statusKeysFull
+		}
+
 		healthy := fullyConfigured
+		disconnectedStatusTime := mainConfig.DisconnectedStatusTime.GetOrElse(config.DefaultDisconnectedStatusTime)
+		groups := make(map[string]api.GroupStatusRep)
 		for _, clientCtx := range relay.getAllEnvironments() {
 			identifiers := clientCtx.GetIdentifiers()
 
@@ -44,14 +91,15 @@ func statusHandler(relay *Relay) http.Handler {
 				EnvName:  identifiers.EnvName,
 				ProjKey:  identifiers.ProjKey,
 				ProjName: identifiers.ProjName,
+				Group:    identifiers.Group,
 			}
 
 			for _, c := range clientCtx.GetCredentials() {
 				switch c := c.(type) {
 				case config.SDKKey:
-					status.SDKKey = sdks.ObscureKey(string(c))
+					status.SDKKey = keyDisplay.format(string(c))
 				case config.MobileKey:
-					status.MobileKey = sdks.ObscureKey(string(c))
+					status.MobileKey = keyDisplay.format(string(c))
 				case config.EnvironmentID:
 					status.EnvID = string(c)
 				}
@@ -59,7 +107,7 @@ func statusHandler(relay *Relay) http.Handler {
 
 			for _, c := range clientCtx.GetDeprecatedCredentials() {
 				if key, ok := c.(config.SDKKey); ok {
-					status.ExpiringSDKKey = sdks.ObscureKey(string(key))
+					status.ExpiringSDKKey = keyDisplay.format(string(key))
 				}
 			}
 
@@ -85,20 +133,44 @@ func statusHandler(relay *Relay) http.Handler {
 					}
 				}
 				if sourceStatus.State != interfaces.DataSourceStateValid &&
-					time.Since(sourceStatus.StateSince) >=
-						relay.config.Main.DisconnectedStatusTime.GetOrElse(config.DefaultDisconnectedStatusTime) {
+					time.Since(sourceStatus.StateSince) >= disconnectedStatusTime {
 					connected = false
 				}
 
+				if mainConfig.ReportCachedStatus {
+					// The data store, rather than the SDK client, is what we serve flag data from, so if the two
+					// disagree about whether the environment has data, the store takes precedence.
+					if store := clientCtx.GetStore(); store != nil && !store.IsInitialized() {
+						connected = false
+					}
+				}
+
 				storeStatus := client.GetDataStoreStatus()
 				status.DataStoreStatus.State = "VALID"
 				status.DataStoreStatus.StateSince = ldtime.UnixMillisFromTime(storeStatus.LastUpdated)
 				if !storeStatus.Available {
 					status.DataStoreStatus.State = "INTERRUPTED"
 				}
+				status.DataStoreStatus.CircuitBreaker = string(clientCtx.GetStoreCircuitBreakerState())
 
-				if connected {
+				if clientCtx.IsInMaintenanceMode() {
+					// The data source is deliberately stopped, so a non-VALID connection state is expected
+					// and does not by itself make Relay degraded.
+					status.Status = statusEnvMaintenance
+				} else if reconnectStart := clientCtx.GetReconnectStartTime(); !reconnectStart.IsZero() {
+					// Likewise, the connection was deliberately closed and is being reopened-- but if that takes
+					// as long as a connection failure would, it is no longer expected.
+					status.Status = statusEnvReconnecting
+					if time.Since(reconnectStart) >= disconnectedStatusTime {
+						healthy = false
+					}
+				} else if connected {
 					status.Status = statusEnvConnected
+				} else if store := clientCtx.GetStore(); mainConfig.ReportCachedStatus &&
+					store != nil && store.IsInitialized() {
+					// The live connection is down, but we are still serving the last known data from the store.
+					status.Status = statusEnvCached
+					healthy = false
 				} else {
 					status.Status = statusEnvDisconnected
 					healthy = false
@@ -107,7 +179,7 @@ func statusHandler(relay *Relay) http.Handler {
 
 			bigSegmentStore := clientCtx.GetBigSegmentStore()
 			if bigSegmentStore != nil {
-				bigSegmentStatus := api.BigSegmentStatusRep{}
+				bigSegmentStatus := api.BigSegmentStatusRep{Enabled: true}
 				synchronizedOn, err := bigSegmentStore.GetSynchronizedOn()
 				if err != nil {
 					bigSegmentStatus.Available = false
@@ -115,15 +187,21 @@ func statusHandler(relay *Relay) http.Handler {
 					bigSegmentStatus.Available = true
 					bigSegmentStatus.LastSynchronizedOn = synchronizedOn
 					now := ldtime.UnixMillisNow()
-					stalenessThreshold := relay.config.Main.BigSegmentsStaleThreshold.GetOrElse(config.DefaultBigSegmentsStaleThreshold)
+					stalenessThreshold := mainConfig.BigSegmentsStaleThreshold.GetOrElse(config.DefaultBigSegmentsStaleThreshold)
 					if !synchronizedOn.IsDefined() || now > (synchronizedOn+ldtime.UnixMillisecondTime(stalenessThreshold.Milliseconds())) {
 						bigSegmentStatus.PotentiallyStale = true
-						if relay.config.Main.BigSegmentsStaleAsDegraded {
+						if mainConfig.BigSegmentsStaleAsDegraded {
 							healthy = false
 						}
 					}
 				}
 				status.BigSegmentStatus = &bigSegmentStatus
+			} else if clientCtx.HasBigSegmentStore() {
+				// Synchronization doesn't start until the environment has at least one big segment, so the
+				// store is configured but there is no data in it for us to use yet.
+				status.BigSegmentStatus = &api.BigSegmentStatusRep{Enabled: true, Available: false}
+			} else {
+				status.BigSegmentStatus = &api.BigSegmentStatusRep{Enabled: false}
 			}
 
 			storeInfo := clientCtx.GetDataStoreInfo()
@@ -131,14 +209,42 @@ func statusHandler(relay *Relay) http.Handler {
 			status.DataStoreStatus.DBServer = storeInfo.DBServer
 			status.DataStoreStatus.DBPrefix = storeInfo.DBPrefix
 			status.DataStoreStatus.DBTable = storeInfo.DBTable
+			if storeInfo.DataFormat != nil && storeInfo.DataFormat.GetMismatchedVersion() != 0 {
+				// The database has data from a newer version of Relay, which we are refusing to use.
+				status.DataStoreStatus.State = "INCOMPATIBLE"
+				healthy = false
+			}
 
-			statusKey := identifiers.GetDisplayName()
-			if relay.envLogNameMode == relayenv.LogNameIsEnvID {
-				// If we're identifying environments by environment ID in the log (which we do if there's any
-				// chance that the environment name could change) then we should also identify them that way here.
-				statusKey = status.EnvID
+			updateCounts := clientCtx.GetDataUpdateCounts()
+			status.DataUpdates = api.DataUpdatesRep{Flags: updateCounts.Flags, Segments: updateCounts.Segments}
+			status.InitDuration = clientCtx.GetInitDuration().Milliseconds()
+			if initErr := clientCtx.GetInitError(); initErr != nil {
+				status.InitError = initErr.Error()
+			}
+			if limit := clientCtx.GetFlagCountLimit(); limit.Max > 0 {
+				count := clientCtx.GetFlagCount()
+				status.FlagCount = &api.FlagCountRep{Count: count, Limit: limit.Max, Exceeded: limit.Exceeded(count)}
+			}
+
+			resp.Environments[relay.getEnvStatusKey(clientCtx)] = status
+
+			group := groups[identifiers.Group]
+			group.Total++
+			if status.Status == statusEnvConnected {
+				group.Connected++
+			}
+			groups[identifiers.Group] = group
+		}
+
+		if interruptedSince, stale := relay.getConfigSourceStatus(
+			mainConfig.ConfigSourceStaleTime.GetOrElse(0)); !interruptedSince.IsZero() {
+			resp.ConfigSource = &api.ConfigSourceStatusRep{
+				InterruptedSince: ldtime.UnixMillisFromTime(interruptedSince),
+				Stale:            stale,
+			}
+			if stale {
+				healthy = false
 			}
-			resp.Environments[statusKey] = status
 		}
 
 		if healthy {
@@ -147,8 +253,45 @@ func statusHandler(relay *Relay) http.Handler {
 			resp.Status = statusRelayDegraded
 		}
 
-		data, _ := json.Marshal(resp)
+		var data []byte
+		if req.URL.Query().Get("group") == "true" {
+			data, _ = json.Marshal(api.GroupedStatusRep{
+				Groups:        groups,
+				Status:        resp.Status,
+				Version:       resp.Version,
+				ClientVersion: resp.ClientVersion,
+				Connections:   resp.Connections,
+			})
+		} else {
+			data, _ = json.Marshal(resp)
+		}
 
 		_, _ = w.Write(data)
 	})
 }
+
+// getEnvStatusKey returns the key that identifies an environment in the status resource.
+func (r *Relay) getEnvStatusKey(env relayenv.EnvContext) string {
+	if r.envLogNameMode == relayenv.LogNameIsEnvID {
+		// If we're identifying environments by environment ID in the log (which we do if there's any
+		// chance that the environment name could change) then we should also identify them that way here.
+		for _, c := range env.GetCredentials() {
+			if envID, ok := c.(config.EnvironmentID); ok {
+				return string(envID)
+			}
+		}
+		return ""
+	}
+	return env.GetIdentifiers().GetDisplayName()
+}
+
+// getEnvironmentByStatusKey returns the environment that is identified by the specified key in the
+// status resource, or nil if there is none.
+func (r *Relay) getEnvironmentByStatusKey(key string) relayenv.EnvContext {
+	for _, env := range r.getAllEnvironments() {
+		if r.getEnvStatusKey(env) == key {
+			return env
+		}
+	}
+	return nil
+}
@@ -8,15 +8,20 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
 	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 
+	"github.com/launchdarkly/eventsource"
 	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ld "github.com/launchdarkly/go-server-sdk/v7"
 	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +42,7 @@ func TestEndpointsStatus(t *testing.T) {
 				status, "environments", st.EnvMain.Name, "sdkKey")
 			st.AssertJSONPathMatch(t, "connected", status, "environments", st.EnvMain.Name, "status")
 			st.AssertJSONPathMatch(t, "VALID", status, "environments", st.EnvMain.Name, "connectionStatus", "state")
+			assert.Equal(t, ldvalue.Null(), status.GetByKey("environments").GetByKey(st.EnvMain.Name).GetByKey("initError"))
 
 			st.AssertJSONPathMatch(t, sdks.ObscureKey(string(st.EnvClientSide.Config.SDKKey)),
 				status, "environments", st.EnvClientSide.Name, "sdkKey")
@@ -58,6 +64,199 @@ func TestEndpointsStatus(t *testing.T) {
 		})
 	})
 
+	t.Run("keys are hidden if configured", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMobile)
+		config.Main.StatusKeyDisplay = c.StatusKeyDisplayHidden
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			env := ldvalue.Parse(body).GetByKey("environments").GetByKey(st.EnvMobile.Name)
+
+			assert.Equal(t, ldvalue.Null(), env.GetByKey("sdkKey"))
+			assert.Equal(t, ldvalue.Null(), env.GetByKey("mobileKey"))
+			assert.Equal(t, ldvalue.String("connected"), env.GetByKey("status"))
+		})
+	})
+
+	t.Run("flag count is reported if a limit is configured", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+		config.Main.MaxFlagCount, _ = ct.NewOptIntGreaterThanZero(len(st.AllFlags) - 1)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			status := ldvalue.Parse(body)
+
+			st.AssertJSONPathMatch(t, len(st.AllFlags), status, "environments", st.EnvMain.Name, "flagCount", "count")
+			st.AssertJSONPathMatch(t, len(st.AllFlags)-1, status, "environments", st.EnvMain.Name, "flagCount", "limit")
+			st.AssertJSONPathMatch(t, true, status, "environments", st.EnvMain.Name, "flagCount", "exceeded")
+			st.AssertJSONPathMatch(t, "healthy", status, "status")
+		})
+	})
+
+	t.Run("flag count is omitted if no limit is configured", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			_, body := st.DoRequest(r, p.relay)
+			env := ldvalue.Parse(body).GetByKey("environments").GetByKey(st.EnvMain.Name)
+			assert.Equal(t, ldvalue.Null(), env.GetByKey("flagCount"))
+		})
+	})
+
+	t.Run("big segment status is disabled if no big segment store is configured", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			_, body := st.DoRequest(r, p.relay)
+			env := ldvalue.Parse(body).GetByKey("environments").GetByKey(st.EnvMain.Name)
+			assert.JSONEq(t, `{"enabled":false}`, env.GetByKey("bigSegmentStatus").JSONString())
+		})
+	})
+
+	t.Run("big segment status is enabled but unavailable if store has not been synchronized", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+		behavior := relayTestBehavior{
+			bigSegmentStoreFactory: func(c.EnvConfig, c.Config, ldlog.Loggers) (bigsegments.BigSegmentStore, error) {
+				return bigsegments.NewNullBigSegmentStore(), nil
+			},
+			sdkBigSegmentsFactory: ldcomponents.BigSegments(
+				st.ExistingInstance[subsystems.BigSegmentStore](&st.NoOpSDKBigSegmentStore{}),
+			),
+		}
+
+		withStartedRelayCustom(t, config, behavior, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			_, body := st.DoRequest(r, p.relay)
+			status := ldvalue.Parse(body)
+			st.AssertJSONPathMatch(t, true, status, "environments", st.EnvMain.Name, "bigSegmentStatus", "enabled")
+			st.AssertJSONPathMatch(t, false, status, "environments", st.EnvMain.Name, "bigSegmentStatus", "available")
+			st.AssertJSONPathMatch(t, "healthy", status, "status")
+		})
+	})
+
+	t.Run("admin status shows full keys", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMobile)
+		config.Main.AdminKey = "admin-secret"
+		config.Main.StatusKeyDisplay = c.StatusKeyDisplayHidden
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/debug/status", nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+			r.Header.Set("Authorization", "admin-secret")
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			status := ldvalue.Parse(body)
+
+			st.AssertJSONPathMatch(t, string(st.EnvMobile.Config.SDKKey),
+				status, "environments", st.EnvMobile.Name, "sdkKey")
+			st.AssertJSONPathMatch(t, string(st.EnvMobile.Config.MobileKey),
+				status, "environments", st.EnvMobile.Name, "mobileKey")
+			st.AssertJSONPathMatch(t, "healthy", status, "status")
+		})
+	})
+
+	t.Run("status can require admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+		config.Main.AdminKey = "admin-secret"
+		config.Main.RequireAdminKeyForStatus = true
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+			r.Header.Set("Authorization", "wrong-key")
+			result, _ = st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+			r.Header.Set("Authorization", "admin-secret")
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			st.AssertJSONPathMatch(t, "healthy", ldvalue.Parse(body), "status")
+
+			for _, path := range []string{"/health", "/ready"} {
+				r, _ := http.NewRequest("GET", "http://localhost"+path, nil)
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, http.StatusOK, result.StatusCode, path)
+			}
+		})
+	})
+
+	t.Run("admin status is not available without admin key", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r, _ := http.NewRequest("GET", "http://localhost/debug/status", nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+	})
+
+	t.Run("stream connection count and limit", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+		config.Main.MaxTotalConnections, _ = ct.NewOptIntGreaterThanZero(1)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			getStatus := func() ldvalue.Value {
+				r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+				_, body := st.DoRequest(r, p.relay)
+				return ldvalue.Parse(body)
+			}
+			streamReq := st.BuildRequestWithAuth("GET", "http://localhost/all", st.EnvMain.Config.SDKKey, nil)
+
+			st.AssertJSONPathMatch(t, 0, getStatus(), "connections", "current")
+			st.AssertJSONPathMatch(t, 1, getStatus(), "connections", "limit")
+
+			st.WithStreamRequest(t, streamReq, p.relay, func(eventCh <-chan eventsource.Event) {
+				<-eventCh
+				st.AssertJSONPathMatch(t, 1, getStatus(), "connections", "current")
+
+				result, _ := st.DoRequest(st.BuildRequestWithAuth("GET", "http://localhost/all", st.EnvMain.Config.SDKKey, nil), p.relay)
+				assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+				assert.NotEmpty(t, result.Header.Get("Retry-After"))
+			})
+
+			st.AssertJSONPathMatch(t, 0, getStatus(), "connections", "current")
+		})
+	})
+
+	t.Run("data update counts", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			relayEnv, _ := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NotNil(t, relayEnv)
+			_, err := st.UpsertFlag(relayEnv.GetStore(), st.Flag1ServerSide.Flag)
+			require.NoError(t, err)
+			_, err = st.UpsertSegment(relayEnv.GetStore(), st.Segment1)
+			require.NoError(t, err)
+
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			_, body := st.DoRequest(r, p.relay)
+			status := ldvalue.Parse(body)
+			st.AssertJSONPathMatch(t, 1, status, "environments", st.EnvMain.Name, "dataUpdates", "flags")
+			st.AssertJSONPathMatch(t, 1, status, "environments", st.EnvMain.Name, "dataUpdates", "segments")
+		})
+	})
+
 	t.Run("connection interruption - less than DisconnectedStatusTime", func(t *testing.T) {
 		var config c.Config
 		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
@@ -130,4 +329,85 @@ func TestEndpointsStatus(t *testing.T) {
 			st.AssertJSONPathMatch(t, "degraded", status, "status")
 		})
 	})
+	t.Run("connection interruption with cached data - reportCachedStatus enabled", func(t *testing.T) {
+		threshold := time.Millisecond * 10
+
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+		config.Main.DisconnectedStatusTime = ct.NewOptDuration(threshold)
+		config.Main.ReportCachedStatus = true
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			envMain, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NotNil(t, envMain)
+			require.Nil(t, err)
+			require.True(t, envMain.GetStore().IsInitialized())
+			envMain.GetClient().(*testclient.FakeLDClient).SetDataSourceStatus(interfaces.DataSourceStatus{
+				State:      interfaces.DataSourceStateInterrupted,
+				StateSince: time.Now(),
+			})
+
+			time.Sleep(threshold + (time.Millisecond * 10))
+
+			r, _ := http.NewRequest("GET", "http://localhost/status", nil)
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			status := ldvalue.Parse(body)
+
+			st.AssertJSONPathMatch(t, "cached", status, "environments", st.EnvMain.Name, "status")
+			st.AssertJSONPathMatch(t, "INTERRUPTED", status, "environments", st.EnvMain.Name, "connectionStatus", "state")
+			st.AssertJSONPathMatch(t, "connected", status, "environments", st.EnvMobile.Name, "status")
+
+			st.AssertJSONPathMatch(t, "degraded", status, "status")
+		})
+	})
+
+	t.Run("grouped summary", func(t *testing.T) {
+		threshold := time.Millisecond * 10
+
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile, st.EnvClientSide)
+		config.Environment[st.EnvMain.Name].Group = "team-a"
+		config.Environment[st.EnvMobile.Name].Group = "team-a"
+		config.Main.DisconnectedStatusTime = ct.NewOptDuration(threshold)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			envMain, _ := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NotNil(t, envMain)
+			envMain.GetClient().(*testclient.FakeLDClient).SetDataSourceStatus(interfaces.DataSourceStatus{
+				State:      interfaces.DataSourceStateInterrupted,
+				StateSince: time.Now(),
+			})
+			time.Sleep(threshold + (time.Millisecond * 10))
+
+			r, _ := http.NewRequest("GET", "http://localhost/status?group=true", nil)
+			result, body := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+			status := ldvalue.Parse(body)
+
+			assert.Equal(t, ldvalue.Null(), status.GetByKey("environments"))
+			st.AssertJSONPathMatch(t, 1, status, "groups", "team-a", "connected")
+			st.AssertJSONPathMatch(t, 2, status, "groups", "team-a", "total")
+			st.AssertJSONPathMatch(t, 1, status, "groups", "", "connected")
+			st.AssertJSONPathMatch(t, 1, status, "groups", "", "total")
+			st.AssertJSONPathMatch(t, "degraded", status, "status")
+
+			r, _ = http.NewRequest("GET", "http://localhost/status", nil)
+			_, body = st.DoRequest(r, p.relay)
+			status = ldvalue.Parse(body)
+
+			assert.Equal(t, ldvalue.Null(), status.GetByKey("groups"))
+			st.AssertJSONPathMatch(t, "team-a", status, "environments", st.EnvMain.Name, "group")
+			assert.Equal(t, ldvalue.Null(), status.GetByKey("environments").GetByKey(st.EnvClientSide.Name).GetByKey("group"))
+		})
+	})
+}
+
+func TestStatusKeyDisplay(t *testing.T) {
+	key := "sdk-12345678-abcd-1234-abcd-1234567890ab"
+
+	assert.Equal(t, sdks.ObscureKey(key), statusKeyDisplayFromConfig("").format(key))
+	assert.Equal(t, sdks.ObscureKey(key), statusKeyDisplayFromConfig(c.StatusKeyDisplayMasked).format(key))
+	assert.Equal(t, "", statusKeyDisplayFromConfig(c.StatusKeyDisplayHidden).format(key))
+	assert.Equal(t, key, statusKeysFull.format(key))
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -19,6 +20,8 @@ import (
 	"github.com/launchdarkly/eventsource"
 	ct "github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 
 	"github.com/stretchr/testify/assert"
@@ -251,3 +254,83 @@ func TestEndpointsStreamingJSClient(t *testing.T) {
 		}
 	})
 }
+
+func TestEndpointsStreamingFlagValues(t *testing.T) {
+	userJSON := []byte(`{"key":"me"}`)
+	mobileKey := st.EnvMobile.Config.MobileKey
+	envID := st.EnvClientSide.Config.EnvID
+
+	specs := []struct {
+		streamEndpointTestParams
+		invalidCredential       credential.SDKCredential
+		invalidCredentialStatus int
+		flags                   []st.TestFlag
+	}{
+		{streamEndpointTestParams{endpointTestParams{"mobile stream GET", "GET", "/meval/$DATA", userJSON, mobileKey, 200, st.ExpectNoBody()},
+			"put", []byte(st.MakeEvalBody(st.MobileFlags, false))}, st.UndefinedMobileKey, http.StatusUnauthorized, st.MobileFlags},
+		{streamEndpointTestParams{endpointTestParams{"mobile stream REPORT", "REPORT", "/meval", userJSON, mobileKey, 200, st.ExpectNoBody()},
+			"put", []byte(st.MakeEvalBody(st.MobileFlags, false))}, st.UndefinedMobileKey, http.StatusUnauthorized, st.MobileFlags},
+		{streamEndpointTestParams{endpointTestParams{"client-side get eval stream", "GET", "/eval/$ENV/$DATA", userJSON, envID, 200, st.ExpectNoBody()},
+			"put", []byte(st.MakeEvalBody(st.ClientSideFlags, false))}, st.UndefinedEnvID, http.StatusNotFound, st.ClientSideFlags},
+		{streamEndpointTestParams{endpointTestParams{"client-side report eval stream", "REPORT", "/eval/$ENV", userJSON, envID, 200, st.ExpectNoBody()},
+			"put", []byte(st.MakeEvalBody(st.ClientSideFlags, false))}, st.UndefinedEnvID, http.StatusNotFound, st.ClientSideFlags},
+	}
+
+	var config c.Config
+	config.Main.StreamFlagValues = true
+	config.Environment = st.MakeEnvConfigs(st.EnvMobile, st.EnvClientSide)
+
+	for _, spec := range specs {
+		s := spec
+		t.Run(s.name, func(t *testing.T) {
+			s.runBasicStreamTests(t, config, s.invalidCredential, s.invalidCredentialStatus)
+		})
+	}
+
+	for _, spec := range specs {
+		s := spec
+		t.Run(s.name+" - updates", func(t *testing.T) {
+			withStartedRelay(t, config, func(p relayTestParams) {
+				env, _ := p.relay.getEnvironment(sdkauth.New(s.credential))
+				require.NotNil(t, env)
+				store := env.GetStore()
+
+				st.WithStreamRequest(t, s.request(), p.relay, func(eventCh <-chan eventsource.Event) {
+					event := helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for put")
+					assert.Equal(t, "put", event.Event())
+					assert.Equal(t, 1, env.GetStreamConnectionCount())
+
+					changedFlag := s.flags[0].Flag
+					changedFlag.Version++
+					changedFlag.On = false
+					changedFlag.OffVariation = ldvalue.NewOptionalInt(0)
+					changedFlag.Variations = []ldvalue.Value{ldvalue.String("changed")}
+					_, err := store.Upsert(ldstoreimpl.Features(), changedFlag.Key, st.FlagDesc(changedFlag))
+					require.NoError(t, err)
+
+					event = helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for patch")
+					assert.Equal(t, "patch", event.Event())
+					assert.JSONEq(t, `{"key":"`+changedFlag.Key+`","value":"changed","variation":0,"version":`+
+						strconv.Itoa(changedFlag.Version)+`}`, event.Data())
+
+					// An update that doesn't change the result for this context produces no events
+					segment := st.Segment1
+					segment.Version++
+					_, err = store.Upsert(ldstoreimpl.Segments(), segment.Key, st.SegmentDesc(segment))
+					require.NoError(t, err)
+					if !helpers.AssertNoMoreValues(t, eventCh, 100*time.Millisecond) {
+						t.FailNow()
+					}
+
+					deletedVersion := changedFlag.Version + 1
+					_, err = store.Upsert(ldstoreimpl.Features(), changedFlag.Key, st.DeletedItem(deletedVersion))
+					require.NoError(t, err)
+
+					event = helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for delete")
+					assert.Equal(t, "delete", event.Event())
+					assert.JSONEq(t, `{"key":"`+changedFlag.Key+`","version":`+strconv.Itoa(deletedVersion)+`}`, event.Data())
+				})
+			})
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package relay
+
+import (
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+)
+
+// validateCredentialHandler reports that the request's credential is valid. It relies on the environment
+// selector middleware to reject unknown credentials, and on RejectWhileInitializing to reject requests for
+// an environment that has no flag data yet, so it only runs if both checks have passed.
+func validateCredentialHandler() http.Handler {
+	return middleware.RejectWhileInitializing(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"valid":true}`))
+	}))
+}
@@ -0,0 +1,65 @@
+package relay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/credential"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testenv"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointsValidateCredential(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, tc := range []struct {
+			name       string
+			path       string
+			credential credential.SDKCredential
+			status     int
+		}{
+			{"valid SDK key", "/sdk/validate", st.EnvMain.Config.SDKKey, http.StatusOK},
+			{"valid mobile key", "/msdk/validate", st.EnvMobile.Config.MobileKey, http.StatusOK},
+			{"unknown SDK key", "/sdk/validate", st.UndefinedSDKKey, http.StatusUnauthorized},
+			{"unknown mobile key", "/msdk/validate", st.UndefinedMobileKey, http.StatusUnauthorized},
+			{"mobile key for SDK key endpoint", "/sdk/validate", st.EnvMobile.Config.MobileKey, http.StatusUnauthorized},
+			{"SDK key for mobile key endpoint", "/msdk/validate", st.EnvMain.Config.SDKKey, http.StatusUnauthorized},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				result, body := st.DoRequest(st.BuildRequestWithAuth("GET", "http://localhost"+tc.path, tc.credential, nil), p.relay)
+				assert.Equal(t, tc.status, result.StatusCode)
+				if tc.status == http.StatusOK {
+					assert.JSONEq(t, `{"valid":true}`, string(body))
+				}
+			})
+		}
+	})
+}
+
+func TestValidateCredentialHandlerRejectsWhileInitializing(t *testing.T) {
+	handler := validateCredentialHandler()
+
+	t.Run("environment has no data", func(t *testing.T) {
+		ctx := testenv.NewTestEnvContext("", false, st.MakeStoreWithData(false))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, buildPreRoutedRequest("GET", nil, nil, nil, ctx))
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("environment has data", func(t *testing.T) {
+		ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(false), st.MakeStoreWithData(true), c.EnvConfig{})
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, buildPreRoutedRequest("GET", nil, nil, nil, ctx))
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
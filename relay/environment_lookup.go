@@ -2,6 +2,7 @@ package relay
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
@@ -60,6 +61,8 @@ type EnvironmentLookup struct {
 	conns map[relayenv.EnvContext]struct{}
 	// mu protects any access to 'mapping' and 'conns'
 	mu sync.RWMutex
+	// generation is incremented whenever 'mapping' changes
+	generation atomic.Uint64
 }
 
 // NewEnvironmentLookup instantiates an empty instance of EnvironmentLookup. Calls into EnvironmentLookup
@@ -131,6 +134,12 @@ func (e *EnvironmentLookup) DeleteEnvironment(params sdkauth.ScopedCredential) (
 	return nil, false
 }
 
+// Generation returns a number that is incremented whenever a mapping is added or removed. Callers that
+// cache the result of Lookup can compare this value to tell whether the cached result may be out of date.
+func (e *EnvironmentLookup) Generation() uint64 {
+	return e.generation.Load()
+}
+
 // Environments returns a list of all managed environment connections. Environments are only
 // removed by DeleteEnvironment/DeleteEnvironment; removing credential mappings do not affect
 // the environment itself.
@@ -147,10 +156,12 @@ func (e *EnvironmentLookup) Environments() (envs []relayenv.EnvContext) {
 
 func (e *EnvironmentLookup) mapParams(key sdkauth.ScopedCredential, env relayenv.EnvContext) {
 	e.mapping[key] = env
+	e.generation.Add(1)
 }
 
 func (e *EnvironmentLookup) unmapParams(key sdkauth.ScopedCredential) {
 	delete(e.mapping, key)
+	e.generation.Add(1)
 }
 
 func (e *EnvironmentLookup) lookup(key sdkauth.ScopedCredential) (relayenv.EnvContext, bool) {
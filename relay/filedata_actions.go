@@ -1,6 +1,7 @@
 package relay
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
@@ -20,8 +21,13 @@ const (
 	logMsgOfflineEnvTimeoutError          = "Unable to initialize offline environment %q: timed out waiting for client creation"
 	logMsgInternalErrorUpdatedEnvNotFound = "Unexpected error in file data processing: environment ID %s not found when updating"
 	logMsgInternalErrorNoUpdatesForEnv    = "Unexpected error in file data processing: environment ID %s not found in envUpdates"
+	logMsgOfflineEnvFailed                = "Unable to load offline environment %s: %s"
 )
 
+func errOfflineEnvDuplicateName(name string) error {
+	return fmt.Errorf("another environment already has the name %q", name)
+}
+
 // relayFileDataActions is an implementation of the filedata.UpdateHandler interface. The low-level
 // filedata.ArchiveManager component, which manages the file data source, will call the interface
 // methods on this object to let us know when environments have been read from the file for the
@@ -41,6 +47,16 @@ type stubDataSourceToCaptureUpdates struct {
 }
 
 func (a *relayFileDataActions) AddEnvironment(ae filedata.ArchiveEnvironment) {
+	// Environment names are used as keys in the status resource, so two environments with the same name
+	// would silently overwrite each other there.
+	name := ae.Params.Identifiers.GetDisplayName()
+	for _, env := range a.r.getAllEnvironments() {
+		if env.GetIdentifiers().GetDisplayName() == name {
+			a.EnvironmentFailed(ae.Params.EnvID, errOfflineEnvDuplicateName(name))
+			return
+		}
+	}
+
 	updatesCh := make(chan subsystems.DataSourceUpdateSink)
 	transformConfig := func(baseConfig ld.Config) ld.Config {
 		config := baseConfig
@@ -51,7 +67,7 @@ func (a *relayFileDataActions) AddEnvironment(ae filedata.ArchiveEnvironment) {
 	envConfig := envfactory.NewEnvConfigFactoryForOfflineMode(a.r.config.OfflineMode).MakeEnvironmentConfig(ae.Params)
 	_, _, err := a.r.addEnvironment(ae.Params.Identifiers, envConfig, transformConfig)
 	if err != nil {
-		a.r.loggers.Errorf(logMsgAutoConfEnvInitError, ae.Params.Identifiers.GetDisplayName(), err)
+		a.EnvironmentFailed(ae.Params.EnvID, err)
 		return
 	}
 	select {
@@ -79,6 +95,13 @@ func (a *relayFileDataActions) UpdateEnvironment(ae filedata.ArchiveEnvironment)
 		return
 	}
 
+	// Nothing is changed unless the whole update is valid, so that a bad update can't leave the
+	// environment partly reconfigured.
+	if err := ae.Params.Validate(); err != nil {
+		a.EnvironmentFailed(ae.Params.EnvID, err)
+		return
+	}
+
 	env.SetIdentifiers(ae.Params.Identifiers)
 	env.SetTTL(ae.Params.TTL)
 	env.SetSecureMode(ae.Params.SecureMode)
@@ -90,7 +113,7 @@ func (a *relayFileDataActions) UpdateEnvironment(ae filedata.ArchiveEnvironment)
 }
 
 func (a *relayFileDataActions) EnvironmentFailed(id config.EnvironmentID, err error) {
-	// error logging goes here
+	a.r.loggers.Errorf(logMsgOfflineEnvFailed, id, err)
 }
 
 func (a *relayFileDataActions) DeleteEnvironment(id config.EnvironmentID, filter config.FilterKey) {
@@ -1,20 +1,26 @@
 package relay
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/api"
 	"github.com/launchdarkly/ld-relay/v8/internal/filedata"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 
+	"github.com/launchdarkly/eventsource"
 	"github.com/launchdarkly/go-configtypes"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
@@ -39,13 +45,29 @@ type offlineModeTestParams struct {
 	t                *testing.T
 	relay            *Relay
 	updateHandler    filedata.UpdateHandler
+	archiveManager   *stubArchiveManager
 	clientsCreatedCh <-chan testclient.CapturedLDClient
 	mockLog          *ldlogtest.MockLog
 }
 
-type stubArchiveManager struct{}
+type stubArchiveManager struct {
+	interruptedSince time.Time
+	lock             sync.Mutex
+}
+
+func (s *stubArchiveManager) Close() error { return nil }
+
+func (s *stubArchiveManager) GetInterruptedSince() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.interruptedSince
+}
 
-func (s stubArchiveManager) Close() error { return nil }
+func (s *stubArchiveManager) setInterruptedSince(t time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.interruptedSince = t
+}
 
 func offlineModeTest(
 	t *testing.T,
@@ -66,6 +88,7 @@ func offlineModeTest(
 		t:                t,
 		clientsCreatedCh: clientsCreatedCh,
 		mockLog:          mockLog,
+		archiveManager:   &stubArchiveManager{},
 	}
 
 	config.OfflineMode.FileDataSource = "filename is ignored in these tests"
@@ -76,8 +99,9 @@ func offlineModeTest(
 		archiveManagerFactory: func(filename string, handler filedata.UpdateHandler, loggers ldlog.Loggers) (
 			filedata.ArchiveManagerInterface, error) {
 			p.updateHandler = handler
-			return stubArchiveManager{}, nil
+			return p.archiveManager, nil
 		},
+		configSourceInterval: time.Millisecond * 10,
 	})
 	if err != nil {
 		panic(err)
@@ -125,6 +149,41 @@ func TestOfflineModeInit(t *testing.T) {
 	})
 }
 
+func TestOfflineModeRejectsDuplicateEnvironmentName(t *testing.T) {
+	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
+		p.updateHandler.AddEnvironment(testFileDataEnv1)
+		p.awaitClient()
+		p.awaitEnvironment(testFileDataEnv1.Params.EnvID)
+
+		duplicate := testFileDataEnv2
+		duplicate.Params.Identifiers = testFileDataEnv1.Params.Identifiers
+		p.updateHandler.AddEnvironment(duplicate)
+
+		p.shouldNotCreateClient(time.Millisecond * 100)
+		env, _ := p.relay.getEnvironment(sdkauth.NewScoped("", duplicate.Params.EnvID))
+		assert.Nil(t, env)
+		assert.Len(t, p.relay.getAllEnvironments(), 1)
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Error, "another environment already has the name")
+	})
+}
+
+func TestOfflineModeReportsFailureToAddEnvironment(t *testing.T) {
+	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
+		p.updateHandler.AddEnvironment(testFileDataEnv1)
+		p.awaitClient()
+		p.awaitEnvironment(testFileDataEnv1.Params.EnvID)
+
+		conflicting := testFileDataEnv2
+		conflicting.Params.SDKKey = testFileDataEnv1.Params.SDKKey
+		p.updateHandler.AddEnvironment(conflicting)
+
+		p.shouldNotCreateClient(time.Millisecond * 100)
+		assert.Len(t, p.relay.getAllEnvironments(), 1)
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Error,
+			"Unable to load offline environment "+string(conflicting.Params.EnvID)+": .*already used")
+	})
+}
+
 func TestOfflineModeUpdateEnvironment(t *testing.T) {
 	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
 		p.updateHandler.AddEnvironment(testFileDataEnv1)
@@ -161,6 +220,27 @@ func TestOfflineModeUpdateEnvironment(t *testing.T) {
 	})
 }
 
+func TestOfflineModeInvalidUpdateDoesNotChangeEnvironment(t *testing.T) {
+	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
+		p.updateHandler.AddEnvironment(testFileDataEnv1)
+		p.awaitClient()
+		env := p.awaitEnvironment(testFileDataEnv1.Params.EnvID)
+
+		invalid := testFileDataEnv1
+		invalid.Params.Identifiers.EnvName += "-modified"
+		invalid.Params.TTL = -time.Minute
+		invalid.SDKData = []ldstoretypes.Collection{{Kind: ldstoreimpl.Features(), Items: nil}}
+
+		p.updateHandler.UpdateEnvironment(invalid)
+
+		assert.Equal(t, testFileDataEnv1.Params.Identifiers, env.GetIdentifiers())
+		assert.Equal(t, testFileDataEnv1.Params.TTL, env.GetTTL())
+		flags, _ := env.GetStore().GetAll(ldstoreimpl.Features())
+		assert.Equal(t, testFileDataEnv1.SDKData[0].Items, flags)
+		p.mockLog.AssertMessageMatch(t, true, ldlog.Error, "Unable to load offline environment .*TTL must not be negative")
+	})
+}
+
 func TestOfflineModeDeleteEnvironment(t *testing.T) {
 	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
 		p.updateHandler.AddEnvironment(testFileDataEnv1)
@@ -180,6 +260,31 @@ func TestOfflineModeDeleteEnvironment(t *testing.T) {
 	})
 }
 
+func TestOfflineModeDeleteEnvironmentDisconnectsStreams(t *testing.T) {
+	offlineModeTest(t, config.Config{}, func(p offlineModeTestParams) {
+		p.updateHandler.AddEnvironment(testFileDataEnv1)
+		_ = p.awaitClient()
+		env := p.awaitEnvironment(testFileDataEnv1.Params.EnvID)
+		router := p.relay.makeRouter()
+
+		req := sharedtest.BuildRequestWithAuth("GET", "http://localhost/all", testFileDataEnv1.Params.SDKKey, nil)
+		sharedtest.WithStreamRequest(t, req, router, func(eventCh <-chan eventsource.Event) {
+			initialEvent := helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for initial event")
+			require.NotNil(t, initialEvent)
+			assert.Equal(t, 1, env.GetStreamConnectionCount())
+
+			p.updateHandler.DeleteEnvironment(testFileDataEnv1.Params.EnvID, testFileDataEnv1.Params.Identifiers.FilterKey)
+
+			// WithStreamRequest sends a nil value when the server closes the stream
+			endOfStream := helpers.RequireValue(t, eventCh, time.Second, "timed out waiting for stream to be closed")
+			assert.Nil(t, endOfStream)
+		})
+
+		assert.Equal(t, 0, env.GetStreamConnectionCount())
+		p.shouldNotHaveEnvironment(testFileDataEnv1.Params.EnvID, time.Second)
+	})
+}
+
 func TestOfflineModeEventsAreAcceptedAndDiscardedIfSendEventsIsTrue(t *testing.T) {
 	eventRecorderHandler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
 	httphelpers.WithServer(eventRecorderHandler, func(server *httptest.Server) {
@@ -205,3 +310,47 @@ func TestOfflineModeEventsAreAcceptedAndDiscardedIfSendEventsIsTrue(t *testing.T
 		})
 	})
 }
+
+func TestOfflineModeStatusIsDegradedWhenFileDataSourceIsStale(t *testing.T) {
+	var cfg config.Config
+	cfg.Main.ConfigSourceStaleTime = configtypes.NewOptDuration(time.Minute)
+
+	offlineModeTest(t, cfg, func(p offlineModeTestParams) {
+		getStatus := func() api.StatusRep {
+			rr := httptest.NewRecorder()
+			p.relay.Handler.ServeHTTP(rr, sharedtest.BuildRequest("GET", "/status", nil, nil))
+			var status api.StatusRep
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+			return status
+		}
+
+		status := getStatus()
+		assert.Equal(t, "healthy", status.Status)
+		assert.Nil(t, status.ConfigSource)
+
+		recentFailure := time.Now().Add(-time.Second)
+		p.archiveManager.setInterruptedSince(recentFailure)
+		status = getStatus()
+		assert.Equal(t, "healthy", status.Status)
+		require.NotNil(t, status.ConfigSource)
+		assert.Equal(t, ldtime.UnixMillisFromTime(recentFailure), status.ConfigSource.InterruptedSince)
+		assert.False(t, status.ConfigSource.Stale)
+
+		p.archiveManager.setInterruptedSince(time.Now().Add(-time.Hour))
+		status = getStatus()
+		assert.Equal(t, "degraded", status.Status)
+		require.NotNil(t, status.ConfigSource)
+		assert.True(t, status.ConfigSource.Stale)
+		require.Eventually(t, func() bool {
+			return p.mockLog.HasMessageMatch(ldlog.Warn, "has not been able to provide updates")
+		}, time.Second, time.Millisecond*10)
+
+		p.archiveManager.setInterruptedSince(time.Time{})
+		status = getStatus()
+		assert.Equal(t, "healthy", status.Status)
+		assert.Nil(t, status.ConfigSource)
+		require.Eventually(t, func() bool {
+			return p.mockLog.HasMessageMatch(ldlog.Info, "source has recovered")
+		}, time.Second, time.Millisecond*10)
+	})
+}
@@ -0,0 +1,61 @@
+package relay
+
+import (
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+)
+
+// FlagTransformParams describes the request that a FlagTransformFunc is being called for.
+type FlagTransformParams struct {
+	// SDKKind is the kind of SDK that the flag will be served to: "server", "mobile", or "js".
+	SDKKind string
+
+	// EnvironmentName is a human-readable name for the environment: either its name in the configuration,
+	// or, in auto-configuration mode, a combination of the project and environment names.
+	EnvironmentName string
+
+	// EnvKey and ProjKey are the environment and project keys. These are only set in auto-configuration mode.
+	EnvKey  string
+	ProjKey string
+
+	// FilterKey is the key of the environment's payload filter, or an empty string if it is unfiltered.
+	FilterKey string
+}
+
+// FlagTransformFunc is a function that can be used with Relay.SetFlagTransform to modify flags before
+// they are served to SDKs.
+//
+// It returns the flag to serve in place of the original one, or nil to omit the flag. It must not modify
+// the original flag, since that is shared with Relay's data store; to change a flag, make a copy of it
+// (being careful about slices and maps) and return the copy. It is called for every flag in every
+// response, so it should be fast, and it should be a pure function of its parameters.
+type FlagTransformFunc func(params FlagTransformParams, flag *ldmodel.FeatureFlag) *ldmodel.FeatureFlag
+
+// SetFlagTransform registers a function that is applied to flags before Relay serves them, in client-side
+// evaluation responses, polling responses, and server-side streams. Passing nil removes any previously
+// registered function, which is also the initial state. This is only meaningful when Relay is embedded in
+// another application; see docs/in-app.md.
+//
+// The transform affects data that is served after it is registered. Server-side SDKs that are already
+// connected to a stream do not receive transformed versions of flags until those flags change.
+func (r *Relay) SetFlagTransform(fn FlagTransformFunc) {
+	if fn == nil {
+		r.flagTransformer.Set(nil)
+		return
+	}
+	r.flagTransformer.Set(func(
+		sdkKind basictypes.SDKKind,
+		env relayenv.EnvIdentifiers,
+		flag *ldmodel.FeatureFlag,
+	) *ldmodel.FeatureFlag {
+		return fn(FlagTransformParams{
+			SDKKind:         string(sdkKind),
+			EnvironmentName: env.GetDisplayName(),
+			EnvKey:          env.EnvKey,
+			ProjKey:         env.ProjKey,
+			FilterKey:       string(env.FilterKey),
+		}, flag)
+	})
+}
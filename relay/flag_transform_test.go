@@ -0,0 +1,187 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// This transform omits Flag1ServerSide and Flag4ClientSide, and changes the variation values of every
+// other flag that has exactly one variation.
+func makeTestFlagTransform(calls chan<- FlagTransformParams) FlagTransformFunc {
+	return func(params FlagTransformParams, flag *ldmodel.FeatureFlag) *ldmodel.FeatureFlag {
+		select {
+		case calls <- params:
+		default:
+		}
+		if flag.Key == st.Flag1ServerSide.Flag.Key || flag.Key == st.Flag4ClientSide.Flag.Key {
+			return nil
+		}
+		if len(flag.Variations) != 1 {
+			return flag
+		}
+		transformed := *flag
+		transformed.Variations = []ldvalue.Value{ldvalue.String("transformed")}
+		return &transformed
+	}
+}
+
+func TestFlagTransformIsAppliedToEvaluations(t *testing.T) {
+	env := st.EnvMobile
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		calls := make(chan FlagTransformParams, 100)
+		p.relay.SetFlagTransform(makeTestFlagTransform(calls))
+
+		req := st.BuildRequestWithAuth("REPORT", "http://localhost/msdk/evalx/context", env.Config.MobileKey, basicContextJSON)
+		req.Header.Set("Content-Type", "application/json")
+		result, body := st.DoRequest(req, p.relay)
+		require.Equal(t, http.StatusOK, result.StatusCode)
+
+		var flags map[string]struct {
+			Value ldvalue.Value `json:"value"`
+		}
+		require.NoError(t, json.Unmarshal(body, &flags))
+		assert.NotContains(t, flags, st.Flag1ServerSide.Flag.Key)
+		assert.NotContains(t, flags, st.Flag4ClientSide.Flag.Key)
+		assert.Equal(t, ldvalue.String("transformed"), flags[st.Flag2ServerSide.Flag.Key].Value)
+		assert.Equal(t, ldvalue.String("transformed"), flags[st.Flag7Mobile.Flag.Key].Value)
+
+		params := <-calls
+		assert.Equal(t, "mobile", params.SDKKind)
+		assert.Equal(t, env.Name, params.EnvironmentName)
+	})
+}
+
+func TestFlagTransformIsAppliedToPolling(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		calls := make(chan FlagTransformParams, 100)
+		p.relay.SetFlagTransform(makeTestFlagTransform(calls))
+
+		t.Run("all flags", func(t *testing.T) {
+			req := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil)
+			result, body := st.DoRequest(req, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+
+			var flags map[string]ldmodel.FeatureFlag
+			require.NoError(t, json.Unmarshal(body, &flags))
+			assert.NotContains(t, flags, st.Flag1ServerSide.Flag.Key)
+			assert.Equal(t, []ldvalue.Value{ldvalue.String("transformed")}, flags[st.Flag2ServerSide.Flag.Key].Variations)
+			assert.Equal(t, "server", (<-calls).SDKKind)
+		})
+
+		t.Run("omitted flag", func(t *testing.T) {
+			req := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags/"+st.Flag1ServerSide.Flag.Key, sdkKey, nil)
+			result, _ := st.DoRequest(req, p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		})
+
+		t.Run("transformed flag", func(t *testing.T) {
+			req := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags/"+st.Flag2ServerSide.Flag.Key, sdkKey, nil)
+			result, body := st.DoRequest(req, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+
+			var flag ldmodel.FeatureFlag
+			require.NoError(t, json.Unmarshal(body, &flag))
+			assert.Equal(t, []ldvalue.Value{ldvalue.String("transformed")}, flag.Variations)
+		})
+
+		t.Run("original data is not modified", func(t *testing.T) {
+			p.relay.SetFlagTransform(nil)
+
+			req := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil)
+			result, body := st.DoRequest(req, p.relay)
+			require.Equal(t, http.StatusOK, result.StatusCode)
+			expected, _ := json.Marshal(st.FlagsMap(st.AllFlags))
+			assert.JSONEq(t, string(expected), string(body))
+		})
+	})
+}
+
+func TestFlagTransformIsAppliedToServerSideStream(t *testing.T) {
+	env := st.EnvMain
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		p.relay.SetFlagTransform(makeTestFlagTransform(nil))
+
+		req := st.BuildRequestWithAuth("GET", "http://localhost/all", env.Config.SDKKey, nil)
+		st.WithStreamRequest(t, req, p.relay, func(eventCh <-chan eventsource.Event) {
+			event := helpers.RequireValue(t, eventCh, time.Second*3, "timed out waiting for initial event")
+			require.Equal(t, "put", event.Event())
+
+			var put struct {
+				Data struct {
+					Flags map[string]ldmodel.FeatureFlag `json:"flags"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(event.Data()), &put))
+			assert.NotContains(t, put.Data.Flags, st.Flag1ServerSide.Flag.Key)
+			assert.Equal(t, []ldvalue.Value{ldvalue.String("transformed")}, put.Data.Flags[st.Flag2ServerSide.Flag.Key].Variations)
+		})
+	})
+}
+
+func TestFlagTransformChangesPollingEtags(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, url := range []string{
+			"http://localhost/sdk/flags",
+			"http://localhost/sdk/latest-all",
+			"http://localhost/sdk/flags/" + st.Flag2ServerSide.Flag.Key,
+		} {
+			t.Run(url, func(t *testing.T) {
+				defer p.relay.SetFlagTransform(nil)
+
+				getEtag := func(ifNoneMatch string) (int, string) {
+					req := st.BuildRequestWithAuth("GET", url, sdkKey, nil)
+					if ifNoneMatch != "" {
+						req.Header.Set("If-None-Match", ifNoneMatch)
+					}
+					result, _ := st.DoRequest(req, p.relay)
+					return result.StatusCode, result.Header.Get("Etag")
+				}
+
+				_, originalEtag := getEtag("")
+				require.NotEqual(t, "", originalEtag)
+
+				p.relay.SetFlagTransform(makeTestFlagTransform(nil))
+				status, transformedEtag := getEtag(originalEtag)
+				assert.Equal(t, http.StatusOK, status)
+				assert.NotEqual(t, originalEtag, transformedEtag)
+
+				p.relay.SetFlagTransform(nil)
+				status, _ = getEtag(transformedEtag)
+				assert.Equal(t, http.StatusOK, status)
+			})
+		}
+	})
+}
@@ -0,0 +1,277 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcFlagsServiceName is the fully qualified name of the gRPC service, as defined in the "gRPC flag
+// evaluation" section of docs/endpoints.md.
+const grpcFlagsServiceName = "launchdarkly.relay.Flags"
+
+// grpcServer is the listener for the gRPC flag evaluation service when MainConfig.GRPCPort is set.
+//
+// The service's request and response messages are google.protobuf.Struct values with the same properties
+// as the JSON bodies of the HTTP evaluation endpoints, so that clients can call it using only the standard
+// protobuf types, and so that Relay does not need any generated code.
+type grpcServer struct {
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// grpcEvaluateRep is the response for the Evaluate method. It has the same properties as each flag in
+// the response of the evalx endpoints.
+type grpcEvaluateRep struct {
+	Key       string                    `json:"key"`
+	Value     ldvalue.Value             `json:"value"`
+	Variation ldvalue.OptionalInt       `json:"variation"`
+	Version   int                       `json:"version"`
+	Reason    ldreason.EvaluationReason `json:"reason"`
+}
+
+// flagsServiceServer is the interface of the gRPC flag evaluation service; the grpc package uses it to
+// check that the implementation passed to RegisterService has the right type.
+type flagsServiceServer interface {
+	evaluate(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	getAllFlags(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+var grpcFlagsServiceDesc = grpc.ServiceDesc{ //nolint:gochecknoglobals
+	ServiceName: grpcFlagsServiceName,
+	HandlerType: (*flagsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Evaluate", Handler: makeGRPCMethodHandler("Evaluate", flagsServiceServer.evaluate)},
+		{MethodName: "GetAllFlags", Handler: makeGRPCMethodHandler("GetAllFlags", flagsServiceServer.getAllFlags)},
+	},
+}
+
+// makeGRPCMethodHandler adapts one method of flagsServiceServer to the form that grpc.MethodDesc uses,
+// which is normally generated from a .proto file.
+func makeGRPCMethodHandler(
+	methodName string,
+	method func(flagsServiceServer, context.Context, *structpb.Struct) (*structpb.Struct, error),
+) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := new(structpb.Struct)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return method(srv.(flagsServiceServer), ctx, req.(*structpb.Struct)) //nolint:forcetypeassert
+		}
+		if interceptor == nil {
+			return handler(ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + grpcFlagsServiceName + "/" + methodName}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+func startGRPCServer(relay *Relay, mainConfig config.MainConfig, loggers ldlog.Loggers) (*grpcServer, error) {
+	port := mainConfig.GRPCPort.GetOrElse(0)
+	var options []grpc.ServerOption
+	if mainConfig.TLSEnabled {
+		cert, err := tls.LoadX509KeyPair(mainConfig.TLSCert, mainConfig.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load TLS certificate for gRPC server: %w", err)
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(&tls.Config{ //nolint:gosec // linter doesn't want to see MinVersion being set to a variable
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   mainConfig.TLSMinVersion.Get(),
+		})))
+	}
+	server := grpc.NewServer(options...)
+	server.RegisterService(&grpcFlagsServiceDesc, grpcFlagsService{relay})
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("unable to start gRPC listener on port %d: %w", port, err)
+	}
+	loggers.Infof("Starting gRPC server listening on port %d", port)
+	go func() {
+		if err := server.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			loggers.Errorf("gRPC server on port %d stopped unexpectedly: %s", port, err)
+		}
+	}()
+	return &grpcServer{server: server, listener: listener}, nil
+}
+
+func (g *grpcServer) close() {
+	g.server.Stop() // this also closes the listener
+}
+
+// grpcFlagsService implements the gRPC flag evaluation service. Each request must have an "authorization"
+// metadata value that is an SDK key, just as the server-side HTTP endpoints require an Authorization header.
+type grpcFlagsService struct {
+	relay *Relay
+}
+
+// evaluate evaluates one flag. The request properties are "flagKey" and "context", where "context" is a
+// context or user in the same JSON format that the REPORT evaluation endpoints use.
+func (s grpcFlagsService) evaluate(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	env, err := s.getEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flagKey := req.GetFields()["flagKey"].GetStringValue()
+	if flagKey == "" {
+		return nil, status.Error(codes.InvalidArgument, "flagKey is required")
+	}
+	contextJSON, _ := req.GetFields()["context"].MarshalJSON()
+	ldContext, err := middleware.ContextFromJSON(contextJSON)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	store := env.GetStore()
+	evaluator := env.GetEvaluator()
+	client := env.GetClient()
+	if store == nil || evaluator == nil || ((client == nil || !client.Initialized()) && !store.IsInitialized()) {
+		return nil, status.Error(codes.Unavailable, "Service not initialized")
+	}
+	item, err := store.Get(ldstoreimpl.Features(), flagKey)
+	if err != nil {
+		env.GetLoggers().Errorf("Error reading feature store: %s", err)
+		return nil, status.Errorf(codes.Internal, "Error reading feature store: %s", err)
+	}
+	item = env.TransformFlag(basictypes.ServerSDK, item)
+	flag, ok := item.Item.(*ldmodel.FeatureFlag)
+	if !ok || flag == nil || !env.IsFlagVisibleToContext(flagKey, ldContext) {
+		return nil, status.Errorf(codes.NotFound, "Unknown flag %q", flagKey)
+	}
+
+	result := evaluator.Evaluate(flag, ldContext, nil)
+	data, _ := json.Marshal(grpcEvaluateRep{
+		Key:       flag.Key,
+		Value:     result.Detail.Value,
+		Variation: result.Detail.VariationIndex,
+		Version:   flag.Version,
+		Reason:    result.Detail.Reason,
+	})
+	return jsonToStruct(data)
+}
+
+// getAllFlags evaluates all flags. The request properties are "context", as for evaluate, and optionally
+// "withReasons". The response is the same as the response of the REPORT /sdk/evalx/context endpoint, and
+// is produced by the same handler, so that the two always behave the same.
+func (s grpcFlagsService) getAllFlags(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	env, err := s.getEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contextJSON, _ := req.GetFields()["context"].MarshalJSON()
+	url := "/sdk/evalx/context"
+	if req.GetFields()["withReasons"].GetBoolValue() {
+		url += "?withReasons=true"
+	}
+	httpReq, _ := http.NewRequestWithContext(ctx, "REPORT", url, bytes.NewReader(contextJSON))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq = httpReq.WithContext(middleware.WithEnvContextInfo(httpReq.Context(), middleware.EnvContextInfo{Env: env}))
+
+	recorder := newGRPCResponseRecorder()
+	evaluateAllFeatureFlags(basictypes.ServerSDK)(recorder, httpReq)
+	if recorder.status != http.StatusOK {
+		var errorRep struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(recorder.body.Bytes(), &errorRep)
+		return nil, status.Error(grpcCodeForHTTPStatus(recorder.status), errorRep.Message)
+	}
+	return jsonToStruct(recorder.body.Bytes())
+}
+
+// getEnvironment finds the environment for the SDK key in the request metadata, returning an error with
+// the same meaning as the one that the HTTP endpoints would return if it can't be used.
+func (s grpcFlagsService) getEnvironment(ctx context.Context) (relayenv.EnvContext, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get("authorization")
+	if len(keys) == 0 || keys[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "SDK key is required in authorization metadata")
+	}
+	if s.relay.drainer.IsDraining() {
+		return nil, status.Error(codes.Unavailable, "Relay Proxy is draining")
+	}
+	env, err := s.relay.getEnvironment(sdkauth.NewScoped("", config.SDKKey(keys[0])))
+	switch {
+	case IsNotReady(err):
+		return nil, status.Error(codes.Unavailable, "Relay Proxy is not yet fully initialized")
+	case err != nil || env.GetInitError() == ld.ErrInitializationFailed:
+		return nil, status.Error(codes.Unauthenticated, "Relay Proxy does not recognize the SDK key")
+	case env.GetClient() == nil:
+		return nil, status.Error(codes.Unavailable, "client was not initialized")
+	}
+	return env, nil
+}
+
+func jsonToStruct(data []byte) (*structpb.Struct, error) {
+	ret := new(structpb.Struct)
+	if err := ret.UnmarshalJSON(data); err != nil { // COVERAGE: we always produce a valid JSON object
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return ret, nil
+}
+
+func grpcCodeForHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcResponseRecorder is an http.ResponseWriter that keeps the response in memory, so that an HTTP
+// handler can be used to produce a gRPC response.
+type grpcResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newGRPCResponseRecorder() *grpcResponseRecorder {
+	return &grpcResponseRecorder{header: make(http.Header)}
+}
+
+func (g *grpcResponseRecorder) Header() http.Header {
+	return g.header
+}
+
+func (g *grpcResponseRecorder) Write(data []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	return g.body.Write(data)
+}
+
+func (g *grpcResponseRecorder) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
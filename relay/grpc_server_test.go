@@ -0,0 +1,130 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCServer(t *testing.T) {
+	grpcPort := st.GetAvailablePort(t)
+	optGRPCPort, err := ct.NewOptIntGreaterThanZero(grpcPort)
+	require.NoError(t, err)
+
+	var config c.Config
+	config.Main.GRPCPort = optGRPCPort
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", grpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close() //nolint:errcheck
+
+		call := func(method, sdkKey, requestJSON string) (string, error) {
+			ctx := context.Background()
+			if sdkKey != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", sdkKey)
+			}
+			req := new(structpb.Struct)
+			require.NoError(t, req.UnmarshalJSON([]byte(requestJSON)))
+			resp := new(structpb.Struct)
+			if err := conn.Invoke(ctx, "/launchdarkly.relay.Flags/"+method, req, resp); err != nil {
+				return "", err
+			}
+			data, err := resp.MarshalJSON()
+			require.NoError(t, err)
+			return string(data), nil
+		}
+		sdkKey := string(st.EnvMain.Config.SDKKey)
+		contextJSON := string(basicContextJSON)
+
+		t.Run("Evaluate", func(t *testing.T) {
+			resp, err := call("Evaluate", sdkKey, `{"flagKey": "another-flag-key", "context": `+contextJSON+`}`)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"key": "another-flag-key", "value": 3, "variation": 0, "version": 1,
+				"reason": {"kind": "FALLTHROUGH"}}`, resp)
+		})
+
+		t.Run("Evaluate with unknown flag", func(t *testing.T) {
+			_, err := call("Evaluate", sdkKey, `{"flagKey": "unknown-flag-key", "context": `+contextJSON+`}`)
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+
+		t.Run("Evaluate with invalid context", func(t *testing.T) {
+			_, err := call("Evaluate", sdkKey, `{"flagKey": "another-flag-key", "context": {"kind": "user"}}`)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+
+		t.Run("Evaluate applies flag transform", func(t *testing.T) {
+			p.relay.SetFlagTransform(makeTestFlagTransform(nil))
+			defer p.relay.SetFlagTransform(nil)
+
+			resp, err := call("Evaluate", sdkKey, `{"flagKey": "another-flag-key", "context": `+contextJSON+`}`)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"key": "another-flag-key", "value": "transformed", "variation": 0, "version": 1,
+				"reason": {"kind": "FALLTHROUGH"}}`, resp)
+
+			_, err = call("Evaluate", sdkKey, `{"flagKey": "`+st.Flag1ServerSide.Flag.Key+`", "context": `+contextJSON+`}`)
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+
+		t.Run("GetAllFlags", func(t *testing.T) {
+			resp, err := call("GetAllFlags", sdkKey, `{"context": `+contextJSON+`}`)
+			require.NoError(t, err)
+			assert.JSONEq(t, st.MakeEvalBody(st.AllFlags, false), resp)
+		})
+
+		t.Run("GetAllFlags with reasons", func(t *testing.T) {
+			resp, err := call("GetAllFlags", sdkKey, `{"context": `+contextJSON+`, "withReasons": true}`)
+			require.NoError(t, err)
+			assert.JSONEq(t, st.MakeEvalBody(st.AllFlags, true), resp)
+		})
+
+		t.Run("GetAllFlags with invalid context", func(t *testing.T) {
+			_, err := call("GetAllFlags", sdkKey, `{}`)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+
+		for _, method := range []string{"Evaluate", "GetAllFlags"} {
+			t.Run(method+" without SDK key", func(t *testing.T) {
+				_, err := call(method, "", `{"flagKey": "another-flag-key", "context": `+contextJSON+`}`)
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			})
+
+			t.Run(method+" with unknown SDK key", func(t *testing.T) {
+				_, err := call(method, string(st.UndefinedSDKKey), `{"flagKey": "another-flag-key", "context": `+contextJSON+`}`)
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+			})
+		}
+
+		t.Run("requests are rejected while draining", func(t *testing.T) {
+			p.relay.drainer.SetDraining(true)
+			defer p.relay.drainer.SetDraining(false)
+			_, err := call("Evaluate", sdkKey, `{"flagKey": "another-flag-key", "context": `+contextJSON+`}`)
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+		})
+	})
+}
+
+func TestGRPCServerIsNotStartedByDefault(t *testing.T) {
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		assert.Nil(t, p.relay.grpcServer)
+	})
+}
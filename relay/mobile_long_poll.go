@@ -0,0 +1,127 @@
+package relay
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/util"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+)
+
+const (
+	// pollFallbackHeader is added to mobile responses if MainConfig.MobilePollFallback is set, to tell
+	// clients that cannot use a streaming connection where they can long-poll for flag values instead.
+	pollFallbackHeader = "X-LD-Relay-Poll-Fallback"
+
+	mobileLongPollPath = "/meval/poll"
+)
+
+// Long-poll variant of the mobile evaluation stream, for clients that cannot keep a streaming connection open:
+// /meval/poll/{context} (GET)
+// /meval/poll (REPORT)
+//
+// The response body is a JSON object of evaluated flag values, the same as the data of the "put" event on the
+// evaluation stream, with an ETag. If the request has an If-None-Match header that matches the current flag
+// values, the request is held open until the environment's data changes in a way that affects them; if that
+// doesn't happen within the timeout, the response is a 304 status.
+func longPollEvalHandler(sdkKind basictypes.SDKKind, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		clientCtx := middleware.GetEnvContextInfo(req.Context())
+		loggers := clientCtx.Env.GetLoggers()
+		loggers.Debug("Application requested long-poll evaluation")
+
+		ldContext, ok := getClientSideContextProperties(clientCtx.Env, sdkKind, req, w)
+		if !ok {
+			return
+		}
+		if !ldContext.Multiple() && ldContext.Key() == "" {
+			util.WriteErrorJSON(w, http.StatusBadRequest, "User must have a 'key' attribute")
+			return
+		}
+		reasons := getReasonsMode(req)
+		cachedEtag := req.Header.Get("If-None-Match")
+
+		// We subscribe before evaluating, so that a change that happens in between is not missed.
+		updatesCh, unsubscribe := clientCtx.Env.SubscribeToEvaluations(ldContext)
+		defer unsubscribe()
+
+		// If the data store isn't initialized yet, we wait until it is, as the evaluation stream does.
+		var body []byte
+		var etag string
+		var limitErr *flagCountLimitError
+		evaluate := func() bool {
+			if store := clientCtx.Env.GetStore(); store == nil || !store.IsInitialized() {
+				return false
+			}
+			var flags map[string]clientSideFlagState
+			if flags, limitErr = evaluateFlagsForStream(clientCtx.Env, sdkKind, ldContext, reasons); limitErr != nil {
+				return true
+			}
+			if flags == nil {
+				return false
+			}
+			body = serializeLongPollFlags(flags)
+			etag = fmt.Sprintf("relay-%x", sha256.Sum256(body))
+			return etag != cachedEtag
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for !evaluate() {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-timer.C:
+				if body == nil {
+					util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Service not initialized")
+					return
+				}
+				w.Header().Set("Etag", etag)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			case _, ok := <-updatesCh:
+				if !ok { // the environment was closed
+					util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Environment is not available")
+					return
+				}
+			}
+		}
+
+		if limitErr != nil {
+			loggers.Warnf("Refusing to return flags to client-side SDK: %s", limitErr)
+			limitErr.writeResponse(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Header().Set("Etag", etag)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+}
+
+// serializeLongPollFlags returns the evaluated flag values as a JSON object. The keys are sorted so that
+// the same values always produce the same ETag.
+func serializeLongPollFlags(flags map[string]clientSideFlagState) []byte {
+	keys := make([]string, 0, len(flags))
+	for key, state := range flags {
+		if state.data != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	for _, key := range keys {
+		obj.Name(key).Raw(flags[key].data)
+	}
+	obj.End()
+	return w.Bytes()
+}
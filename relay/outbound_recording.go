@@ -0,0 +1,31 @@
+//go:build relaytestsupport
+
+package relay
+
+import "github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+
+// OutboundRequest describes an HTTP request that Relay made to LaunchDarkly or to another service, such
+// as a stream connection, a batch of events, or a Big Segments poll.
+type OutboundRequest = httpconfig.RecordedRequest
+
+// OutboundRequestRecorder captures the outbound requests of Relay instances. Use its Requests method to
+// get the requests that have been made so far, and Clear to discard them.
+type OutboundRequestRecorder = httpconfig.RequestRecorder
+
+// StartRecordingOutboundRequests causes every Relay instance that is created after this call, in the
+// same process, to record metadata about each outbound HTTP request in the returned recorder. It is meant
+// for integration tests of applications that embed Relay. Call StopRecordingOutboundRequests when done.
+//
+// This function exists only if Relay is built with the relaytestsupport build tag, so that production
+// builds never record requests.
+func StartRecordingOutboundRequests() *OutboundRequestRecorder {
+	return httpconfig.StartRecording()
+}
+
+// StopRecordingOutboundRequests causes Relay instances that are created after this call not to record
+// their outbound requests. Instances that were already created continue to record them.
+//
+// This function exists only if Relay is built with the relaytestsupport build tag.
+func StopRecordingOutboundRequests() {
+	httpconfig.StopRecording()
+}
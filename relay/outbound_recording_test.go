@@ -0,0 +1,56 @@
+//go:build relaytestsupport
+
+package relay
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/events"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundRequestRecording(t *testing.T) {
+	env := st.EnvMain
+	sdkKey := env.Config.SDKKey
+	var config c.Config
+	config.Environment = st.MakeEnvConfigs(env)
+	body := makeTestFeatureEventPayload("me")
+
+	recorder := StartRecordingOutboundRequests()
+	defer StopRecordingOutboundRequests()
+
+	relayEventsTest(t, config, func(p relayEventsTestParams) {
+		header := make(http.Header)
+		header.Set("Authorization", string(sdkKey))
+		header.Set(events.EventSchemaHeader, strconv.Itoa(events.SummaryEventsSchemaVersion))
+		result, _ := st.DoRequest(st.BuildRequest("POST", "http://localhost/bulk", body, header), p.relay)
+		require.Equal(t, http.StatusAccepted, result.StatusCode)
+		p.requirePublishedEvent(t, body)
+
+		// The request is recorded once the response is received, which is just after the events server
+		// has published the event
+		var eventPosts []OutboundRequest
+		require.Eventually(t, func() bool {
+			eventPosts = nil
+			for _, r := range recorder.Requests() {
+				if r.Method == "POST" {
+					eventPosts = append(eventPosts, r)
+				}
+			}
+			return len(eventPosts) == 1
+		}, time.Second, time.Millisecond*10)
+		assert.Regexp(t, "/bulk$", eventPosts[0].URL)
+		assert.Equal(t, string(sdkKey), eventPosts[0].Header.Get("Authorization"))
+		assert.Equal(t, http.StatusAccepted, eventPosts[0].StatusCode)
+
+		recorder.Clear()
+		assert.Len(t, recorder.Requests(), 0)
+	})
+}
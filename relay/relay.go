@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/launchdarkly/ld-relay/v8/internal/credential"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/projmanager"
@@ -18,9 +19,12 @@ import (
 	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/autoconfig"
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	"github.com/launchdarkly/ld-relay/v8/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v8/internal/filedata"
 	"github.com/launchdarkly/ld-relay/v8/internal/httpconfig"
+	"github.com/launchdarkly/ld-relay/v8/internal/logging"
 	"github.com/launchdarkly/ld-relay/v8/internal/metrics"
+	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
 	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
 	"github.com/launchdarkly/ld-relay/v8/internal/sdks"
 	"github.com/launchdarkly/ld-relay/v8/internal/streams"
@@ -29,6 +33,7 @@ import (
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 )
 
 var (
@@ -47,28 +52,41 @@ const rpacProtocolVersion = 2
 // It can also be referenced externally in order to embed Relay Proxy functionality into a customized
 // application; see docs/in-app.md.
 //
-// This type deliberately exports no methods other than ServeHTTP and Close. Everything else is an
-// implementation detail which is subject to change.
+// This type deliberately exports no methods other than ServeHTTP, Reload, SetFlagTransform, and Close.
+// Everything else is an implementation detail which is subject to change.
 type Relay struct {
 	http.Handler
 	envsByCredential              *EnvironmentLookup
+	flagTransformer               *relayenv.FlagTransformer
+	initLimiter                   *relayenv.InitLimiter
 	metricsManager                *metrics.Manager
 	clientFactory                 sdks.ClientFactoryFunc
+	bigSegmentStoreFactory        bigsegments.BigSegmentStoreFactory
+	sdkBigSegmentsFactory         subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration]
 	serverSideStreamProvider      streams.StreamProvider
 	serverSideFlagsStreamProvider streams.StreamProvider
 	mobileStreamProvider          streams.StreamProvider
 	jsClientStreamProvider        streams.StreamProvider
-	clientInitCh                  chan relayenv.EnvContext
+	connectionLimiter             *middleware.ConnectionLimiter
+	connectionLogger              *middleware.ConnectionLogger
+	drainer                       *middleware.Drainer
+	clientInitCh                  chan relayenv.ReadyResult
 	fullyConfigured               bool
 	clientSideSDKBaseURL          url.URL
 	version                       string
 	userAgent                     string
 	envLogNameMode                relayenv.LogNameMode
 	closed                        bool
+	closeCh                       chan struct{}
 	lock                          sync.RWMutex
 	autoConfigStream              *autoconfig.StreamManager
 	archiveManager                filedata.ArchiveManagerInterface
+	adminServer                   *adminServer
+	grpcServer                    *grpcServer
 	config                        config.Config
+	reloadLock                    sync.Mutex
+	logLevel                      *logging.LevelGate
+	baseLoggers                   ldlog.Loggers // not subject to logLevel; each environment has its own level
 	loggers                       ldlog.Loggers
 }
 
@@ -79,9 +97,12 @@ type ClientFactoryFunc func(sdkKey config.SDKKey, config ld.Config) (*ld.LDClien
 // Using a struct type for this instead of adding parameters to newRelayInternal helps to minimize
 // changes to test code whenever we make more things configurable.
 type relayInternalOptions struct {
-	loggers               ldlog.Loggers
-	clientFactory         sdks.ClientFactoryFunc
-	archiveManagerFactory func(string, filedata.UpdateHandler, ldlog.Loggers) (filedata.ArchiveManagerInterface, error)
+	loggers                ldlog.Loggers
+	clientFactory          sdks.ClientFactoryFunc
+	archiveManagerFactory  func(string, filedata.UpdateHandler, ldlog.Loggers) (filedata.ArchiveManagerInterface, error)
+	bigSegmentStoreFactory bigsegments.BigSegmentStoreFactory // nil = create big segment stores from the configuration
+	sdkBigSegmentsFactory  subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration]
+	configSourceInterval   time.Duration // zero = use the default; we set a brief interval in unit tests
 }
 
 // NewRelay creates a new Relay given a configuration and a method to create a client.
@@ -134,35 +155,51 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 		clientFactory = sdks.DefaultClientFactory()
 	}
 
-	if c.Main.LogLevel.IsDefined() {
-		loggers.SetMinLevel(c.Main.LogLevel.GetOrElse(ldlog.Info))
-	}
+	// The global log level is controlled by a LevelGate, rather than by loggers.SetMinLevel, so that Reload
+	// can change it for every component that has a copy of the loggers.
+	baseLoggers := loggers
+	logLevel := logging.NewLevelGate(c.Main.LogLevel.GetOrElse(loggers.GetMinLevel()))
+	loggers = logLevel.Apply(baseLoggers)
 
-	metricsManager, err := metrics.NewManager(c.MetricsConfig, 0, loggers)
+	metricsManager, err := metrics.NewManager(c.MetricsConfig, version.Version, 0, loggers)
 	if err != nil {
 		return nil, errNewMetricsManagerFailed(err)
 	}
 	thingsToCleanUp.AddFunc(metricsManager.Close)
 
-	clientInitCh := make(chan relayenv.EnvContext, len(c.Environment))
+	clientInitCh := make(chan relayenv.ReadyResult, len(c.Environment))
 
 	maxConnTime := c.Main.MaxClientConnectionTime.GetOrElse(0)
+	maxConnJitter := c.Main.MaxClientConnectionJitter.GetOrElse(0)
+	maxMessageSize := c.Main.MaxSSEMessageSize.GetOrElse(0)
+	mobilePing := c.Main.MobilePingCoalesceTime.GetOrElse(0)
+	jsClientPing := c.Main.JSClientPingCoalesceTime.GetOrElse(0)
 
 	userAgent := "LDRelay/" + version.Version
 
 	r := &Relay{
 		envsByCredential:              NewEnvironmentLookup(),
-		serverSideStreamProvider:      streams.NewStreamProvider(basictypes.ServerSideStream, maxConnTime),
-		serverSideFlagsStreamProvider: streams.NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, maxConnTime),
-		mobileStreamProvider:          streams.NewStreamProvider(basictypes.MobilePingStream, maxConnTime),
-		jsClientStreamProvider:        streams.NewStreamProvider(basictypes.JSClientPingStream, maxConnTime),
+		flagTransformer:               &relayenv.FlagTransformer{},
+		initLimiter:                   relayenv.NewInitLimiter(c.Main.MaxConcurrentInits.GetOrElse(0)),
+		serverSideStreamProvider:      streams.NewStreamProvider(basictypes.ServerSideStream, maxConnTime, maxConnJitter, maxMessageSize, 0),
+		serverSideFlagsStreamProvider: streams.NewStreamProvider(basictypes.ServerSideFlagsOnlyStream, maxConnTime, maxConnJitter, maxMessageSize, 0),
+		mobileStreamProvider:          streams.NewStreamProvider(basictypes.MobilePingStream, maxConnTime, maxConnJitter, maxMessageSize, mobilePing),
+		jsClientStreamProvider:        streams.NewStreamProvider(basictypes.JSClientPingStream, maxConnTime, maxConnJitter, maxMessageSize, jsClientPing),
+		connectionLimiter:             middleware.NewConnectionLimiter(c.Main.MaxTotalConnections.GetOrElse(0), c.Main.MaxConnectionWait.GetOrElse(0)),
+		connectionLogger:              middleware.NewConnectionLogger(c.Main.LogConnections),
+		drainer:                       middleware.NewDrainer(),
 		metricsManager:                metricsManager,
 		clientFactory:                 clientFactory,
+		bigSegmentStoreFactory:        options.bigSegmentStoreFactory,
+		sdkBigSegmentsFactory:         options.sdkBigSegmentsFactory,
 		clientInitCh:                  clientInitCh,
 		version:                       version.Version,
 		userAgent:                     userAgent,
 		envLogNameMode:                logNameMode,
 		config:                        c,
+		closeCh:                       make(chan struct{}),
+		logLevel:                      logLevel,
+		baseLoggers:                   baseLoggers,
 		loggers:                       loggers,
 	}
 
@@ -171,17 +208,27 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 	r.clientSideSDKBaseURL = *c.Main.ClientSideBaseURI.Get() // config.ValidateConfig has ensured that this has a value
 
 	for envName, envConfig := range makeFilteredEnvironments(&c) {
-		env, resultCh, err := r.addEnvironment(relayenv.EnvIdentifiers{ConfiguredName: envName}, *envConfig, nil)
+		env, resultCh, err := r.addEnvironment(relayenv.EnvIdentifiers{ConfiguredName: envName, Group: envConfig.Group}, *envConfig, nil)
 		if err != nil {
 			return nil, err
 		}
 		thingsToCleanUp.AddCloser(env)
 		go func() {
-			env := <-resultCh
-			r.clientInitCh <- env
+			result := <-resultCh
+			r.clientInitCh <- result
 		}()
 	}
 
+	if c.Main.RequireAllEnvironments && !c.Main.ExitAlways {
+		// Don't finish starting up unless every configured environment initialized successfully. This
+		// only covers the environments in the configuration, so it's done before any auto-configured or
+		// offline mode environments can be added.
+		options.loggers.Info("Waiting for all environments to initialize before serving requests")
+		if err := r.waitForAllClients(0); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(c.Environment) > 0 || c.OfflineMode.FileDataSource != "" {
 		r.fullyConfigured = true // it's only in auto-config mode that we have any interval of not knowing what the environments are
 	}
@@ -191,6 +238,7 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 			c.Proxy,
 			c.AutoConfig.Key,
 			userAgent,
+			"",
 			loggers,
 		)
 		if err != nil {
@@ -202,6 +250,7 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 			projmanager.NewProjectRouter(&relayAutoConfigActions{r}, loggers),
 			httpConfig,
 			0,
+			c.AutoConfig.KeyExpiryGracePeriod.GetOrElse(0),
 			rpacProtocolVersion,
 			loggers,
 		)
@@ -246,7 +295,41 @@ func newRelayInternal(c config.Config, options relayInternalOptions) (*Relay, er
 		}
 	}
 
-	r.Handler = r.makeRouter()
+	allowedMethods := c.Main.AllowedMethods.Values()
+	if len(allowedMethods) == 0 {
+		allowedMethods = defaultAllowedMethods
+	}
+	commonMiddleware := middleware.Chain(
+		middleware.ClientIP(c.Main.TrustedProxies.Values()),
+		middleware.ResponseHeaders(makeResponseHeaders(c.Main)),
+		middleware.AllowMethods(allowedMethods),
+	)
+	router := r.makeRouter()
+	r.Handler = commonMiddleware(middleware.NormalizePaths(router, c.Main.IgnoreTrailingSlash, c.Main.IgnorePathCase)(router))
+	if c.Main.AdminPort.IsDefined() {
+		adminServer, err := startAdminServer(commonMiddleware(r.makeAdminRouter()), c.Main, r.loggers)
+		if err != nil {
+			return nil, err
+		}
+		r.adminServer = adminServer
+	}
+	if c.Main.GRPCPort.IsDefined() {
+		grpcServer, err := startGRPCServer(r, c.Main, r.loggers)
+		if err != nil {
+			if r.adminServer != nil {
+				_ = r.adminServer.close()
+			}
+			return nil, err
+		}
+		r.grpcServer = grpcServer
+	}
+
+	// This is started only after the last possible error return, so that it is never left running for
+	// a Relay instance that failed to start.
+	if hasAutoConfigKey || hasFileDataSource {
+		go r.monitorConfigSource(options.configSourceInterval)
+	}
+
 	thingsToCleanUp.Clear() // we succeeded, don't close anything
 	return r, nil
 }
@@ -309,6 +392,7 @@ func (r *Relay) Close() error {
 	}
 
 	r.closed = true
+	close(r.closeCh)
 	r.lock.Unlock()
 
 	r.metricsManager.Close()
@@ -319,6 +403,12 @@ func (r *Relay) Close() error {
 	if r.archiveManager != nil {
 		_ = r.archiveManager.Close()
 	}
+	if r.grpcServer != nil {
+		r.grpcServer.close()
+	}
+	if r.adminServer != nil {
+		_ = r.adminServer.close()
+	}
 
 	for _, env := range r.envsByCredential.Environments() {
 		if err := env.Close(); err != nil {
@@ -395,7 +485,7 @@ func (r *Relay) addEnvironment(
 	identifiers relayenv.EnvIdentifiers,
 	envConfig config.EnvConfig,
 	transformClientConfig func(ld.Config) ld.Config,
-) (relayenv.EnvContext, <-chan relayenv.EnvContext, error) {
+) (relayenv.EnvContext, <-chan relayenv.ReadyResult, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
@@ -403,18 +493,30 @@ func (r *Relay) addEnvironment(
 		return nil, nil, errAlreadyClosed
 	}
 
+	// Each credential must map to only one environment; otherwise, requests using it could be routed to
+	// either one.
+	for _, cred := range []credential.SDKCredential{envConfig.SDKKey, envConfig.MobileKey, envConfig.EnvID} {
+		if !cred.Defined() {
+			continue
+		}
+		if other, found := r.envsByCredential.Lookup(sdkauth.NewScoped(identifiers.FilterKey, cred)); found {
+			return nil, nil, errEnvironmentCredentialConflict(identifiers.GetDisplayName(), other.GetIdentifiers().GetDisplayName())
+		}
+	}
+
 	dataStoreFactory, dataStoreInfo, err := sdks.ConfigureDataStore(r.config, envConfig, r.loggers)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resultCh := make(chan relayenv.EnvContext, 1)
+	resultCh := make(chan relayenv.ReadyResult, 1)
 
 	var jsClientContext relayenv.JSClientContext
 
 	if envConfig.EnvID.Defined() {
 		jsClientContext.Origins = envConfig.AllowedOrigin.Values()
-		jsClientContext.Headers = envConfig.AllowedHeader.Values()
+		jsClientContext.Headers = append(r.config.Main.CORSAllowedHeaders.Values(), envConfig.AllowedHeader.Values()...)
+		jsClientContext.Methods = r.config.Main.CORSAllowedMethods.Values()
 
 		cachingTransport := httpcache.NewMemoryCacheTransport()
 		jsClientContext.Proxy = &httputil.ReverseProxy{
@@ -444,18 +546,24 @@ func (r *Relay) addEnvironment(
 		return r.clientFactory(sdkKey, config, timeout)
 	}
 	clientContext, err := relayenv.NewEnvContext(relayenv.EnvContextImplParams{
-		Identifiers:      identifiers,
-		EnvConfig:        envConfig,
-		AllConfig:        r.config,
-		ClientFactory:    wrappedClientFactory,
-		DataStoreFactory: dataStoreFactory,
-		DataStoreInfo:    dataStoreInfo,
-		StreamProviders:  r.allStreamProviders(),
-		JSClientContext:  jsClientContext,
-		MetricsManager:   r.metricsManager,
-		UserAgent:        r.userAgent,
-		LogNameMode:      r.envLogNameMode,
-		Loggers:          r.loggers,
+		Identifiers:                 identifiers,
+		EnvConfig:                   envConfig,
+		AllConfig:                   r.config,
+		ClientFactory:               wrappedClientFactory,
+		DataStoreFactory:            dataStoreFactory,
+		DataStoreInfo:               dataStoreInfo,
+		BigSegmentStoreFactory:      r.bigSegmentStoreFactory,
+		SDKBigSegmentsConfigFactory: r.sdkBigSegmentsFactory,
+		StreamProviders:             r.allStreamProviders(),
+		JSClientContext:             jsClientContext,
+		MetricsManager:              r.metricsManager,
+		UserAgent:                   r.userAgent,
+		RelayVersion:                version.Version,
+		LogNameMode:                 r.envLogNameMode,
+		Loggers:                     r.baseLoggers,
+		GlobalLogLevel:              r.logLevel,
+		FlagTransformer:             r.flagTransformer,
+		InitLimiter:                 r.initLimiter,
 	}, resultCh)
 	if err != nil {
 		return nil, nil, errNewClientContextFailed(identifiers.GetDisplayName(), err)
@@ -525,18 +633,23 @@ func (r *Relay) waitForAllClients(timeout time.Duration) error {
 
 	resultCh := make(chan bool, 1)
 	go func() {
-		failed := false
+		outcomes := make(map[relayenv.InitOutcome]int)
 		for numFinished < numEnvironments {
-			ctx := <-r.clientInitCh
+			result := <-r.clientInitCh
 			numFinished++
-			if ctx.GetInitError() != nil {
-				failed = true
-			}
-			if r.config.Main.ExitOnError {
-				break // ExitOnError implies we shouldn't wait for more than one error
+			outcomes[result.Outcome]++
+			failed := result.Outcome != relayenv.InitSucceeded
+			if r.config.Main.ExitOnError && (failed || !r.config.Main.RequireAllEnvironments) {
+				// ExitOnError implies we shouldn't wait for more than one error; but if every environment is
+				// required, one success doesn't tell us that we can finish starting up
+				break
 			}
 		}
-		resultCh <- failed
+		if numFinished > 0 {
+			r.loggers.Infof("Environment initialization finished: %d succeeded, %d timed out, %d failed",
+				outcomes[relayenv.InitSucceeded], outcomes[relayenv.InitTimedOut], outcomes[relayenv.InitFailed])
+		}
+		resultCh <- outcomes[relayenv.InitTimedOut]+outcomes[relayenv.InitFailed] > 0
 	}()
 
 	select {
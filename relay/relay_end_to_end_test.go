@@ -14,10 +14,14 @@ import (
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
 
 	"github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
+	ld "github.com/launchdarkly/go-server-sdk/v7"
+	"github.com/launchdarkly/go-server-sdk/v7/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v7/ldcomponents"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 
@@ -186,6 +190,35 @@ func TestRelayEndToEndSuccess(t *testing.T) {
 	})
 }
 
+func TestRelayEndToEndPollingSDK(t *testing.T) {
+	flag := ldbuilders.NewFlagBuilder("polling-flag").Version(1).Variations(ldvalue.Bool(true)).OffVariation(0).Build()
+	segment := ldbuilders.NewSegmentBuilder("polling-segment").Version(1).Build()
+	putEvent := ldservices.NewServerSDKData().Flags(&flag).Segments(&segment).ToPutEvent()
+	streamHandler, _ := ldservices.ServerSideStreamingServiceHandler(putEvent)
+	testEnv := st.EnvWithAllCredentials
+
+	config := c.Config{Environment: st.MakeEnvConfigs(testEnv)}
+	relayEndToEndTest(t, config, relayTestBehavior{}, streamHandler, func(p relayEndToEndTestParams) {
+		p.waitForSuccessfulInit()
+
+		// A polling server-side SDK gets all of its data from the /sdk/latest-all endpoint.
+		sdkConfig := ld.Config{
+			DataSource:       ldcomponents.PollingDataSource(),
+			Events:           ldcomponents.NoEvents(),
+			Logging:          ldcomponents.Logging().Loggers(p.loggers),
+			ServiceEndpoints: interfaces.ServiceEndpoints{Polling: p.relayURL},
+		}
+		client, err := ld.MakeCustomClient(string(testEnv.Config.SDKKey), sdkConfig, time.Second*5)
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.True(t, client.Initialized())
+		value, err := client.BoolVariation(flag.Key, ldcontext.New("user-key"), false)
+		assert.NoError(t, err)
+		assert.True(t, value)
+	})
+}
+
 func TestRelayEndToEndPermanentFailure(t *testing.T) {
 	streamHandler := httphelpers.HandlerWithStatus(401)
 	testEnv := st.EnvWithAllCredentials
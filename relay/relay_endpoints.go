@@ -4,23 +4,32 @@ import (
 	"crypto/sha1" //nolint:gosec // we're not using SHA1 for encryption, just for generating an insecure hash
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/logging"
+	"github.com/launchdarkly/ld-relay/v8/internal/metrics"
 	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
+	"github.com/launchdarkly/ld-relay/v8/internal/msgpack"
 	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/store"
 	"github.com/launchdarkly/ld-relay/v8/internal/streams"
 	"github.com/launchdarkly/ld-relay/v8/internal/util"
 
 	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v3"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v3"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoreimpl"
 	"github.com/launchdarkly/go-server-sdk/v7/subsystems/ldstoretypes"
@@ -34,26 +43,42 @@ func getClientSideContextProperties(
 	req *http.Request,
 	w http.ResponseWriter,
 ) (ldcontext.Context, bool) {
+	ldContext, _, ok := getClientSideEvalProperties(clientCtx, sdkKind, req, w)
+	return ldContext, ok
+}
+
+// getClientSideEvalProperties is the same as getClientSideContextProperties, but also returns the default
+// flag values, if any, that were provided in the body of a REPORT request; see parseEvalReportBody.
+func getClientSideEvalProperties(
+	clientCtx relayenv.EnvContext,
+	sdkKind basictypes.SDKKind,
+	req *http.Request,
+	w http.ResponseWriter,
+) (ldcontext.Context, map[string]ldvalue.Value, bool) {
 	var ldContext ldcontext.Context
+	var defaults map[string]ldvalue.Value
 	var contextDecodeErr error
 
 	if req.Method == "REPORT" {
 		if req.Header.Get("Content-Type") != "application/json" {
-			w.WriteHeader(http.StatusUnsupportedMediaType)
-			_, _ = w.Write([]byte("Content-Type must be application/json."))
-			return ldContext, false
+			util.WriteErrorJSON(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json.")
+			return ldContext, nil, false
+		}
+		body, err := io.ReadAll(req.Body)
+		var tooLargeErr *http.MaxBytesError
+		if errors.As(err, &tooLargeErr) {
+			util.WriteErrorJSONf(w, http.StatusRequestEntityTooLarge, "Request body exceeds the maximum size of %d bytes",
+				tooLargeErr.Limit)
+			return ldContext, nil, false
 		}
-		body, _ := io.ReadAll(req.Body)
-		contextDecodeErr = json.Unmarshal(body, &ldContext)
+		ldContext, defaults, contextDecodeErr = parseEvalReportBody(body)
 	} else {
 		base64Context := mux.Vars(req)["context"] // this assumes we have used {context} as a placeholder in the route
 		ldContext, contextDecodeErr = middleware.ContextFromBase64(base64Context)
 	}
 	if contextDecodeErr != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg(contextDecodeErr.Error()))
-		return ldContext, false
+		util.WriteErrorJSON(w, http.StatusBadRequest, contextDecodeErr.Error())
+		return ldContext, nil, false
 	}
 
 	if clientCtx.IsSecureMode() && sdkKind == basictypes.JSClientSDK {
@@ -64,14 +89,41 @@ func getClientSideContextProperties(
 			valid = hash == validHash
 		}
 		if !valid {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write(util.ErrorJSONMsg("Environment is in secure mode, and context hash does not match."))
-			return ldContext, false
+			util.WriteErrorJSON(w, http.StatusBadRequest, "Environment is in secure mode, and context hash does not match.")
+			return ldContext, nil, false
 		}
 	}
 
-	return ldContext, true
+	return ldContext, defaults, true
+}
+
+// evalReportBody is the alternate form of the body of a REPORT evaluation request, which allows the caller
+// to provide a default value for each flag along with the context.
+type evalReportBody struct {
+	Context  json.RawMessage          `json:"context"`
+	Defaults map[string]ldvalue.Value `json:"defaults"`
+}
+
+// parseEvalReportBody parses the body of a REPORT evaluation request. This is normally just the context,
+// but it can also be an evalReportBody. The two forms can't be confused, because a context or an old-style
+// user always has a "kind" or "key" property at the top level, and evalReportBody has neither.
+func parseEvalReportBody(body []byte) (ldcontext.Context, map[string]ldvalue.Value, error) {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(body, &props); err == nil {
+		_, hasKind := props["kind"]
+		_, hasKey := props["key"]
+		_, hasContext := props["context"]
+		if hasContext && !hasKind && !hasKey {
+			var wrapper evalReportBody
+			if err := json.Unmarshal(body, &wrapper); err != nil {
+				return ldcontext.Context{}, nil, err
+			}
+			ldContext, err := middleware.ContextFromJSON(wrapper.Context)
+			return ldContext, wrapper.Defaults, err
+		}
+	}
+	ldContext, err := middleware.ContextFromJSON(body)
+	return ldContext, nil, err
 }
 
 // Old stream endpoint that just sends "ping" events: clientstream.ld.com/mping (mobile)
@@ -110,23 +162,75 @@ func streamHandler(streamProvider streams.StreamProvider, logMessage string) htt
 // PHP SDK polling endpoint for all flags: app.ld.com/sdk/flags
 func pollAllFlagsHandler(w http.ResponseWriter, req *http.Request) {
 	clientCtx := middleware.GetEnvContextInfo(req.Context())
+	sinceParam := req.URL.Query().Get("since")
+	var since, current store.FlagChangeCursor
+	var changes map[string]int64
+	if sinceParam != "" {
+		if sinceParam != "0" { // "0" requests the full flag set, which is what the zero cursor gets us
+			var err error
+			if since, err = store.ParseFlagChangeCursor(sinceParam); err != nil {
+				util.WriteErrorJSON(w, http.StatusBadRequest, "since must be 0 or a sequence value from a previous response")
+				return
+			}
+		}
+		// We get the changes before reading the flags, so that a flag that is updated in between will be
+		// included in the next response rather than being missed. If the cursor is from a different Relay
+		// instance, or from before this one restarted, changes is nil and we return everything.
+		changes, current = clientCtx.Env.GetFlagChangesSince(since)
+	}
 	data, err := clientCtx.Env.GetStore().GetAll(ldstoreimpl.Features())
 	if err != nil {
 		clientCtx.Env.GetLoggers().Errorf("Error reading feature store: %s", err)
-		w.WriteHeader(500)
+		util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
 		return
 	}
-	respData := serializeFlagsAsMap(data)
+	data = clientCtx.Env.TransformFlags(basictypes.ServerSDK, data)
 	// Compute an overall Etag for the data set by hashing flag keys and versions
 	hash := sha1.New()                                                         //nolint:gas // just used for insecure hashing
 	sort.Slice(data, func(i, j int) bool { return data[i].Key < data[j].Key }) // makes the hash deterministic
 	for _, item := range data {
 		_, _ = io.WriteString(hash, fmt.Sprintf("%s:%d", item.Key, item.Item.Version))
 	}
+	writeFlagTransformToHash(hash, clientCtx.Env)
+	var respData []byte
+	if sinceParam != "" {
+		_, _ = io.WriteString(hash, fmt.Sprintf("since:%s:%s", since, current))
+		respData = serializeFlagsDelta(data, changes, current)
+	} else {
+		respData = serializeFlagsAsMap(data)
+	}
 	etag := hex.EncodeToString(hash.Sum(nil))[:15]
 	writeCacheableJSONResponse(w, req, clientCtx.Env, respData, etag)
 }
 
+// Server-side SDK polling endpoint for all flags and segments: app.ld.com/sdk/latest-all
+func pollAllDataHandler(w http.ResponseWriter, req *http.Request) {
+	clientCtx := middleware.GetEnvContextInfo(req.Context())
+	store := clientCtx.Env.GetStore()
+	flags, err := store.GetAll(ldstoreimpl.Features())
+	if err == nil {
+		var segments []ldstoretypes.KeyedItemDescriptor
+		if segments, err = store.GetAll(ldstoreimpl.Segments()); err == nil {
+			flags = clientCtx.Env.TransformFlags(basictypes.ServerSDK, flags)
+			// Compute an overall Etag for the data set by hashing keys and versions of both kinds of data
+			hash := sha1.New() //nolint:gas // just used for insecure hashing
+			for _, coll := range [][]ldstoretypes.KeyedItemDescriptor{flags, segments} {
+				sort.Slice(coll, func(i, j int) bool { return coll[i].Key < coll[j].Key }) // makes the hash deterministic
+				for _, item := range coll {
+					_, _ = io.WriteString(hash, fmt.Sprintf("%s:%d", item.Key, item.Item.Version))
+				}
+				_, _ = io.WriteString(hash, "/")
+			}
+			writeFlagTransformToHash(hash, clientCtx.Env)
+			etag := hex.EncodeToString(hash.Sum(nil))[:15]
+			writeCacheableJSONResponse(w, req, clientCtx.Env, serializeAllData(flags, segments), etag)
+			return
+		}
+	}
+	clientCtx.Env.GetLoggers().Errorf("Error reading feature store: %s", err)
+	util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
+}
+
 // PHP SDK polling endpoint for a flag: app.ld.com/sdk/flags/{key}
 func pollFlagHandler(w http.ResponseWriter, req *http.Request) {
 	pollFlagOrSegment(middleware.GetEnvContextInfo(req.Context()).Env, ldstoreimpl.Features())(w, req)
@@ -157,16 +261,14 @@ func bulkEventHandler(sdkKind basictypes.SDKKind, eventsKind ldevents.EventDataK
 		clientCtx := middleware.GetEnvContextInfo(req.Context())
 		dispatcher := clientCtx.Env.GetEventDispatcher()
 		if dispatcher == nil {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write(util.ErrorJSONMsg("Event proxy is not enabled for this environment"))
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Event proxy is not enabled for this environment")
 			return
 		}
 		handler := dispatcher.GetHandler(sdkKind, eventsKind)
 		if handler == nil {
 			// Note, if this ever happens, it is a programming error since we are only supposed to
 			// be using a fixed set of Endpoint values that the dispatcher knows about.
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write(util.ErrorJSONMsg("Internal error in event proxy"))
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Internal error in event proxy")
 			logging.GetGlobalContextLoggers(req.Context()).Errorf("Tried to proxy %s events for %s but no handler was defined",
 				eventsKind, sdkKind)
 			return
@@ -183,93 +285,424 @@ func bulkEventHandler(sdkKind basictypes.SDKKind, eventsKind ldevents.EventDataK
 // /sdk/evalx/users/{context} (GET - with SDK key auth; this is a Relay-only endpoint)
 // /sdk/evalx/user (REPORT - with SDK key auth; this is a Relay-only endpoint)
 func evaluateAllFeatureFlags(sdkKind basictypes.SDKKind) func(w http.ResponseWriter, req *http.Request) {
+	latencyMeasure := evalLatencyMeasure(sdkKind)
+	return func(w http.ResponseWriter, req *http.Request) {
+		metricsCtx := middleware.GetEnvContextInfo(req.Context()).Env.GetMetricsContext()
+		metrics.WithLatency(metricsCtx, func() {
+			evaluateAllShared(w, req, sdkKind, false)
+		}, latencyMeasure)
+	}
+}
+
+// Client-side evaluation endpoint for the environment's startup flag set, if one is configured; otherwise
+// the same as evaluateAllFeatureFlags:
+// /sdk/evalx/{envId}/startup/contexts/{context} (GET)
+// /sdk/evalx/{envId}/startup/context (REPORT)
+// /msdk/evalx/startup/contexts/{context} (GET)
+// /msdk/evalx/startup/context (REPORT)
+func evaluateStartupFeatureFlags(sdkKind basictypes.SDKKind) func(w http.ResponseWriter, req *http.Request) {
+	latencyMeasure := evalLatencyMeasure(sdkKind)
 	return func(w http.ResponseWriter, req *http.Request) {
-		evaluateAllShared(w, req, sdkKind)
+		metricsCtx := middleware.GetEnvContextInfo(req.Context()).Env.GetMetricsContext()
+		metrics.WithLatency(metricsCtx, func() {
+			evaluateAllShared(w, req, sdkKind, true)
+		}, latencyMeasure)
+	}
+}
+
+func evalLatencyMeasure(sdkKind basictypes.SDKKind) metrics.LatencyMeasure {
+	switch sdkKind {
+	case basictypes.JSClientSDK:
+		return metrics.BrowserEvalLatency
+	case basictypes.MobileSDK:
+		return metrics.MobileEvalLatency
+	default:
+		return metrics.ServerEvalLatency
 	}
 }
 
-func evaluateAllShared(w http.ResponseWriter, req *http.Request, sdkKind basictypes.SDKKind) {
+func evaluateAllShared(w http.ResponseWriter, req *http.Request, sdkKind basictypes.SDKKind, startupOnly bool) {
 	clientCtx := middleware.GetEnvContextInfo(req.Context())
 	client := clientCtx.Env.GetClient()
 	store := clientCtx.Env.GetStore()
 	loggers := clientCtx.Env.GetLoggers()
 
-	ldContext, ok := getClientSideContextProperties(clientCtx.Env, sdkKind, req, w)
+	ldContext, defaults, ok := getClientSideEvalProperties(clientCtx.Env, sdkKind, req, w)
 	if !ok {
 		return
 	}
 
-	withReasons := req.URL.Query().Get("withReasons") == "true"
+	reasons := getReasonsMode(req)
+	withPrerequisites := req.URL.Query().Get("withPrerequisites") == "true"
+	valueOnly := req.Header.Get(basictypes.EvalSchemaVersionHeader) == basictypes.EvalSchemaValueOnly
+	useMessagePack := false
+	if sdkKind == basictypes.MobileSDK {
+		w.Header().Add("Vary", "Accept")
+		useMessagePack = acceptsMessagePack(req)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 
+	var fallbackValues map[string]ldvalue.Value
 	if !client.Initialized() {
 		if store.IsInitialized() {
 			loggers.Warn("Called before client initialization; using last known values from feature store")
+		} else if fallbackValues = clientCtx.Env.GetFallbackFlagValues(); fallbackValues != nil {
+			loggers.Warn("Called before client initialization. Feature store not available; using fallback flag values")
 		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
 			loggers.Warn("Called before client initialization. Feature store not available")
-			_, _ = w.Write(util.ErrorJSONMsg("Service not initialized"))
+			util.WriteErrorJSON(w, http.StatusServiceUnavailable, "Service not initialized")
 			return
 		}
 	}
 
 	if !ldContext.Multiple() && ldContext.Key() == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write(util.ErrorJSONMsg("User must have a 'key' attribute"))
+		util.WriteErrorJSON(w, http.StatusBadRequest, "User must have a 'key' attribute")
 		return
 	}
 
 	loggers.Debugf("Application requested client-side flags (%s) for context: %s", sdkKind, ldContext.Key())
 
+	var startupFlagKeys map[string]struct{}
+	if startupOnly && len(clientCtx.Env.GetStartupFlagKeys()) != 0 {
+		startupFlagKeys = make(map[string]struct{})
+		for _, key := range clientCtx.Env.GetStartupFlagKeys() {
+			startupFlagKeys[key] = struct{}{}
+		}
+	}
+	// includeFlag determines which flag keys belong in the response, apart from client-side availability.
+	includeFlag := func(key string) bool {
+		if startupFlagKeys != nil {
+			if _, isStartupFlag := startupFlagKeys[key]; !isStartupFlag {
+				return false
+			}
+		}
+		return clientCtx.Env.IsFlagVisibleToContext(key, ldContext)
+	}
+
+	if fallbackValues != nil {
+		values := make(map[string]ldvalue.Value, len(fallbackValues))
+		for key, value := range fallbackValues {
+			if clientCtx.Env.IsFlagRedacted(sdkKind, key) {
+				value = ldvalue.Null()
+			}
+			values[key] = value
+		}
+		writeEvalResponse(w, serializeFallbackFlagValues(values, includeFlag, valueOnly), useMessagePack)
+		return
+	}
+
 	items, err := store.GetAll(ldstoreimpl.Features())
 	if err != nil {
 		loggers.Warnf("Unable to fetch flags from feature store. Returning nil map. Error: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write(util.ErrorJSONMsgf("Error fetching flags from feature store: %s", err))
+		util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error fetching flags from feature store: %s", err)
+		return
+	}
+	items = clientCtx.Env.TransformFlags(sdkKind, items)
+
+	if err := checkFlagCountLimit(clientCtx.Env, sdkKind, items, includeFlag); err != nil {
+		loggers.Warnf("Refusing to return %d flags to client-side SDK; the limit is %d", err.count, err.max)
+		err.writeResponse(w)
 		return
 	}
 
 	evaluator := clientCtx.Env.GetEvaluator()
 
+	// applyDefault substitutes the caller's default value, if any, when the evaluation did not produce a
+	// variation, as an SDK would.
+	applyDefault := func(key string, result *ldeval.Result) {
+		if defaultValue, ok := defaults[key]; ok && result.Detail.IsDefaultValue() {
+			result.Detail.Value = defaultValue
+		}
+	}
+
 	responseWriter := jwriter.NewWriter()
 	responseObj := responseWriter.Object()
 	for _, item := range items {
 		if flag, ok := item.Item.Item.(*ldmodel.FeatureFlag); ok {
-			switch sdkKind {
-			case basictypes.JSClientSDK:
-				if !flag.ClientSideAvailability.UsingEnvironmentID {
-					continue
-				}
-			case basictypes.MobileSDK:
-				if !flag.ClientSideAvailability.UsingMobileKey {
-					continue
-				}
+			if !isFlagAvailableToClient(flag, sdkKind) || !includeFlag(flag.Key) {
+				continue
 			}
 
-			result := evaluator.Evaluate(flag, ldContext, nil)
-			detail := result.Detail
-			isExperiment := result.IsExperiment
+			if valueOnly {
+				result := evaluator.Evaluate(flag, ldContext, nil)
+				applyDefault(flag.Key, &result)
+				result.Detail.Value.WriteToJSONWriter(responseObj.Name(flag.Key))
+				continue
+			}
+
+			var prereqEvents []ldeval.PrerequisiteFlagEvent
+			var recordPrereq ldeval.PrerequisiteFlagEventRecorder
+			if withPrerequisites {
+				recordPrereq = func(event ldeval.PrerequisiteFlagEvent) {
+					prereqEvents = append(prereqEvents, event)
+				}
+			}
+			result := evaluator.Evaluate(flag, ldContext, recordPrereq)
+			applyDefault(flag.Key, &result)
 
 			valueObj := responseObj.Name(flag.Key).Object()
-			detail.Value.WriteToJSONWriter(valueObj.Name("value"))
-			detail.VariationIndex.WriteToJSONWriter(valueObj.Name("variation"))
-			valueObj.Name("version").Int(flag.Version)
-			valueObj.Maybe("trackEvents", flag.TrackEvents || isExperiment).Bool(true)
-			valueObj.Maybe("trackReason", isExperiment).Bool(true)
-			if withReasons || isExperiment {
-				detail.Reason.WriteToJSONWriter(valueObj.Name("reason"))
+			writeClientSideFlagResult(&valueObj, flag, result, reasons)
+			if withPrerequisites {
+				writePrerequisiteResults(&valueObj, flag.Key, prereqEvents, sdkKind, clientCtx.Env)
 			}
-			valueObj.Maybe("debugEventsUntilDate", flag.DebugEventsUntilDate != 0).
-				Float64(float64(flag.DebugEventsUntilDate))
 			valueObj.End()
 		}
 	}
+	writeUnknownFlagDefaults(&responseObj, defaults, items, startupFlagKeys, valueOnly)
 	responseObj.End()
-	result := responseWriter.Bytes()
+	writeEvalResponse(w, responseWriter.Bytes(), useMessagePack)
+}
 
+// writeUnknownFlagDefaults adds a result for each flag that the caller provided a default value for but
+// that does not exist, with the default value and a FLAG_NOT_FOUND error, as an SDK would return. Flags that
+// exist but were left out of the response for some other reason, such as client-side availability, are not
+// included.
+func writeUnknownFlagDefaults(
+	obj *jwriter.ObjectState,
+	defaults map[string]ldvalue.Value,
+	items []ldstoretypes.KeyedItemDescriptor,
+	startupFlagKeys map[string]struct{},
+	valueOnly bool,
+) {
+	if len(defaults) == 0 {
+		return
+	}
+	existing := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item.Item.Item != nil {
+			existing[item.Key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(defaults))
+	for key := range defaults {
+		if _, exists := existing[key]; exists {
+			continue
+		}
+		if _, isStartupFlag := startupFlagKeys[key]; startupFlagKeys != nil && !isStartupFlag {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	reason := ldreason.NewEvalReasonError(ldreason.EvalErrorFlagNotFound)
+	for _, key := range keys {
+		if valueOnly {
+			defaults[key].WriteToJSONWriter(obj.Name(key))
+			continue
+		}
+		valueObj := obj.Name(key).Object()
+		defaults[key].WriteToJSONWriter(valueObj.Name("value"))
+		valueObj.Name("version").Int(0)
+		reason.WriteToJSONWriter(valueObj.Name("reason"))
+		valueObj.End()
+	}
+}
+
+// writeEvalResponse writes a successful client-side evaluation result, converting it from JSON to
+// MessagePack if useMessagePack is true. The MessagePack form has exactly the same structure as the JSON.
+func writeEvalResponse(w http.ResponseWriter, jsonData []byte, useMessagePack bool) {
+	data := jsonData
+	if useMessagePack {
+		if converted, err := msgpack.FromJSON(jsonData); err == nil { // COVERAGE: we always produce valid JSON
+			w.Header().Set("Content-Type", msgpack.ContentType)
+			data = converted
+		}
+	}
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(result)
+	_, _ = w.Write(data)
+}
+
+// acceptsMessagePack returns true if the request's Accept header includes the MessagePack media type,
+// under either its standard name or the older name application/x-msgpack, with a nonzero quality value.
+func acceptsMessagePack(req *http.Request) bool {
+	for _, accept := range req.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil || (mediaType != msgpack.ContentType && mediaType != "application/x-msgpack") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// serializeFallbackFlagValues returns a client-side evaluation result containing the environment's
+// configured fallback flag values, for use when there is no flag data in the data store yet. Since these
+// are not real evaluations, there is no variation index or flag version, and the reason is always an
+// error with the kind CLIENT_NOT_READY so that the application can tell that it is not getting real results.
+// Only the flags for which includeFlag returns true are included.
+func serializeFallbackFlagValues(values map[string]ldvalue.Value, includeFlag func(key string) bool, valueOnly bool) []byte {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if includeFlag(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	reason := ldreason.NewEvalReasonError(ldreason.EvalErrorClientNotReady)
+	responseWriter := jwriter.NewWriter()
+	responseObj := responseWriter.Object()
+	for _, key := range keys {
+		if valueOnly {
+			values[key].WriteToJSONWriter(responseObj.Name(key))
+			continue
+		}
+		valueObj := responseObj.Name(key).Object()
+		values[key].WriteToJSONWriter(valueObj.Name("value"))
+		valueObj.Name("version").Int(0)
+		reason.WriteToJSONWriter(valueObj.Name("reason"))
+		valueObj.End()
+	}
+	responseObj.End()
+	return responseWriter.Bytes()
+}
+
+// isFlagAvailableToClient returns true if the flag should be visible to the given kind of client-side SDK.
+func isFlagAvailableToClient(flag *ldmodel.FeatureFlag, sdkKind basictypes.SDKKind) bool {
+	switch sdkKind {
+	case basictypes.JSClientSDK:
+		return flag.ClientSideAvailability.UsingEnvironmentID
+	case basictypes.MobileSDK:
+		return flag.ClientSideAvailability.UsingMobileKey
+	}
+	return true
+}
+
+// flagCountLimitError means that a client-side request would be served more flags than the environment's
+// enforced limit allows; see MainConfig.EnforceMaxFlagCount.
+type flagCountLimitError struct {
+	count int
+	max   int
+}
+
+func (e *flagCountLimitError) Error() string {
+	return fmt.Sprintf("environment has %d flags, exceeding the limit of %d", e.count, e.max)
+}
+
+func (e *flagCountLimitError) writeResponse(w http.ResponseWriter) {
+	util.WriteErrorJSONf(w, http.StatusServiceUnavailable,
+		"Environment has %d flags, exceeding the limit of %d; request a smaller set of flags", e.count, e.max)
+}
+
+// checkFlagCountLimit returns an error if the environment enforces a flag count limit, and the number of
+// flags that would be served to this kind of SDK, out of the ones selected by includeFlag, exceeds it.
+func checkFlagCountLimit(
+	env relayenv.EnvContext,
+	sdkKind basictypes.SDKKind,
+	items []ldstoretypes.KeyedItemDescriptor,
+	includeFlag func(key string) bool,
+) *flagCountLimitError {
+	limit := env.GetFlagCountLimit()
+	if !limit.Enforce || !limit.Exceeded(len(items)) {
+		return nil
+	}
+	count := 0
+	for _, item := range items {
+		if flag, ok := item.Item.Item.(*ldmodel.FeatureFlag); ok && isFlagAvailableToClient(flag, sdkKind) && includeFlag(flag.Key) {
+			count++
+		}
+	}
+	if limit.Exceeded(count) {
+		return &flagCountLimitError{count: count, max: limit.Max}
+	}
+	return nil
+}
+
+// reasonsMode determines which flags have an evaluation reason in client-side evaluation results.
+type reasonsMode int
+
+const (
+	// reasonsForExperiments includes reasons only for flags that are in an experiment, which always
+	// need them. This is the default.
+	reasonsForExperiments reasonsMode = iota
+	// reasonsForTrackedFlags also includes reasons for flags that have full event tracking enabled.
+	reasonsForTrackedFlags
+	// reasonsForAllFlags includes reasons for every flag.
+	reasonsForAllFlags
+)
+
+// getReasonsMode returns the reasonsMode selected by the "withReasons" query parameter, which can be
+// "true" for all flags or "tracked" for only the flags that SDKs would send full events for.
+func getReasonsMode(req *http.Request) reasonsMode {
+	switch req.URL.Query().Get("withReasons") {
+	case "true":
+		return reasonsForAllFlags
+	case "tracked":
+		return reasonsForTrackedFlags
+	default:
+		return reasonsForExperiments
+	}
+}
+
+// writeClientSideFlagResult writes the properties that client-side SDKs expect for an evaluated flag.
+func writeClientSideFlagResult(obj *jwriter.ObjectState, flag *ldmodel.FeatureFlag, result ldeval.Result, reasons reasonsMode) {
+	detail := result.Detail
+	isExperiment := result.IsExperiment
+	trackEvents := flag.TrackEvents || isExperiment
+	detail.Value.WriteToJSONWriter(obj.Name("value"))
+	detail.VariationIndex.WriteToJSONWriter(obj.Name("variation"))
+	obj.Name("version").Int(flag.Version)
+	obj.Maybe("trackEvents", trackEvents).Bool(true)
+	obj.Maybe("trackReason", isExperiment).Bool(true)
+	if reasons == reasonsForAllFlags || (reasons == reasonsForTrackedFlags && trackEvents) || isExperiment {
+		detail.Reason.WriteToJSONWriter(obj.Name("reason"))
+	}
+	obj.Maybe("debugEventsUntilDate", flag.DebugEventsUntilDate != 0).
+		Float64(float64(flag.DebugEventsUntilDate))
+}
+
+// writePrerequisiteResults writes a "prerequisites" array describing every prerequisite flag that was
+// evaluated while evaluating a flag, in the order that they were evaluated. This includes prerequisites of
+// prerequisites; "prerequisiteOf" is the key of the flag that referenced each one. The reason is always
+// included, since the purpose of this is to explain how the flag's result was reached. Prerequisites that
+// are not available to this kind of SDK are left out, so that client-side SDKs cannot see server-only flags,
+// and so are the prerequisites of anything that was left out, since their prerequisiteOf would reveal its
+// key. The values of prerequisites that are redacted for this kind of SDK are written as null.
+func writePrerequisiteResults(
+	obj *jwriter.ObjectState,
+	flagKey string,
+	events []ldeval.PrerequisiteFlagEvent,
+	sdkKind basictypes.SDKKind,
+	env relayenv.EnvContext,
+) {
+	// The event for a prerequisite comes after the events for its own prerequisites, so going backward
+	// we always see whether a flag was included before we see the prerequisites that it referenced.
+	included := make([]bool, len(events))
+	includedKeys := map[string]bool{flagKey: true}
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if includedKeys[event.TargetFlagKey] && isFlagAvailableToClient(event.PrerequisiteFlag, sdkKind) {
+			included[i] = true
+			includedKeys[event.PrerequisiteFlag.Key] = true
+		}
+	}
+
+	arr := obj.Name("prerequisites").Array()
+	for i, event := range events {
+		if !included[i] {
+			continue
+		}
+		prereqObj := arr.Object()
+		prereqObj.Name("key").String(event.PrerequisiteFlag.Key)
+		prereqObj.Name("prerequisiteOf").String(event.TargetFlagKey)
+		detail := event.PrerequisiteResult.Detail
+		if env.IsFlagRedacted(sdkKind, event.PrerequisiteFlag.Key) {
+			detail.Value = ldvalue.Null()
+		}
+		detail.Value.WriteToJSONWriter(prereqObj.Name("value"))
+		detail.VariationIndex.WriteToJSONWriter(prereqObj.Name("variation"))
+		prereqObj.Name("version").Int(event.PrerequisiteFlag.Version)
+		detail.Reason.WriteToJSONWriter(prereqObj.Name("reason"))
+		prereqObj.End()
+	}
+	arr.End()
 }
 
 func pollFlagOrSegment(clientContext relayenv.EnvContext, kind ldstoretypes.DataKind) func(http.ResponseWriter, *http.Request) {
@@ -278,23 +711,41 @@ func pollFlagOrSegment(clientContext relayenv.EnvContext, kind ldstoretypes.Data
 		item, err := clientContext.GetStore().Get(kind, key)
 		if err != nil {
 			clientContext.GetLoggers().Errorf("Error reading feature store: %s", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			util.WriteErrorJSONf(w, http.StatusInternalServerError, "Error reading feature store: %s", err)
 			return
 		}
+		item = clientContext.TransformFlag(basictypes.ServerSDK, item) // has no effect on segments
 		if item.Item == nil {
-			w.WriteHeader(http.StatusNotFound)
+			kindName := "segment"
+			if kind == ldstoreimpl.Features() {
+				kindName = "flag"
+			}
+			util.WriteErrorJSONf(w, http.StatusNotFound, "Unknown %s %q", kindName, key)
 		} else {
 			bytes, err := json.Marshal(item.Item)
 			if err == nil {
-				writeCacheableJSONResponse(w, req, clientContext, bytes, strconv.Itoa(item.Version))
+				etag := strconv.Itoa(item.Version)
+				if gen := clientContext.GetFlagTransformGeneration(); gen != 0 && kind == ldstoreimpl.Features() {
+					etag = fmt.Sprintf("%s-t%d", etag, gen)
+				}
+				writeCacheableJSONResponse(w, req, clientContext, bytes, etag)
 			} else {
 				clientContext.GetLoggers().Errorf("Error marshaling JSON: %s", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				util.WriteErrorJSON(w, http.StatusInternalServerError, "Error marshaling JSON")
 			}
 		}
 	}
 }
 
+// writeFlagTransformToHash adds the current flag transform, if one has ever been set, to an Etag hash.
+// Transformed flags keep their original versions, so without this a client could keep using a cached
+// response after the transform changed.
+func writeFlagTransformToHash(hash io.Writer, env relayenv.EnvContext) {
+	if gen := env.GetFlagTransformGeneration(); gen != 0 {
+		_, _ = io.WriteString(hash, fmt.Sprintf("transform:%d", gen))
+	}
+}
+
 func writeCacheableJSONResponse(w http.ResponseWriter, req *http.Request, clientContext relayenv.EnvContext,
 	bytes []byte, etagValue string) {
 	etag := fmt.Sprintf("relay-%s", etagValue) // just to make it extra clear that these are relay-specific etags
@@ -330,3 +781,70 @@ func serializeFlagsAsMap(coll []ldstoretypes.KeyedItemDescriptor) []byte {
 	obj.End()
 	return w.Bytes()
 }
+
+// serializeAllData returns flags and segments in the format that server-side SDKs expect from the
+// polling endpoint, which is the same as the data in a streaming "put" event. Deleted items are omitted.
+func serializeAllData(flags, segments []ldstoretypes.KeyedItemDescriptor) []byte {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	flagsObj := obj.Name("flags").Object()
+	for _, item := range flags {
+		if item.Item.Item != nil {
+			ldmodel.MarshalFeatureFlagToJSONWriter(*item.Item.Item.(*ldmodel.FeatureFlag), flagsObj.Name(item.Key))
+		}
+	}
+	flagsObj.End()
+	segmentsObj := obj.Name("segments").Object()
+	for _, item := range segments {
+		if item.Item.Item != nil {
+			ldmodel.MarshalSegmentToJSONWriter(*item.Item.Item.(*ldmodel.Segment), segmentsObj.Name(item.Key))
+		}
+	}
+	segmentsObj.End()
+	obj.End()
+	return w.Bytes()
+}
+
+// serializeFlagsDelta returns the flags that are in changes, along with the keys of any flags in changes
+// that have been deleted, and the current flag change cursor. Deleted flags may either still be in the
+// store as a placeholder, or be missing from it because a new data set did not include them. If changes is
+// nil, it returns all flags and no deletions.
+func serializeFlagsDelta(
+	coll []ldstoretypes.KeyedItemDescriptor,
+	changes map[string]int64,
+	current store.FlagChangeCursor,
+) []byte {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	flagsObj := obj.Name("flags").Object()
+	var deleted []string
+	found := make(map[string]bool, len(changes))
+	for _, item := range coll {
+		if _, changed := changes[item.Key]; changes != nil && !changed {
+			continue
+		}
+		found[item.Key] = true
+		if item.Item.Item == nil {
+			if changes != nil {
+				deleted = append(deleted, item.Key)
+			}
+		} else {
+			ldmodel.MarshalFeatureFlagToJSONWriter(*item.Item.Item.(*ldmodel.FeatureFlag), flagsObj.Name(item.Key))
+		}
+	}
+	flagsObj.End()
+	for key := range changes {
+		if !found[key] {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(deleted)
+	deletedArr := obj.Name("deleted").Array()
+	for _, key := range deleted {
+		deletedArr.String(key)
+	}
+	deletedArr.End()
+	obj.Name("sequence").String(current.String())
+	obj.End()
+	return w.Bytes()
+}
@@ -1,21 +1,29 @@
 package relay
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/middleware"
 	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
 	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testenv"
 
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v3/ldbuilders"
 	"github.com/launchdarkly/go-test-helpers/v3/jsonhelpers"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Shortcut for building a request when we are going to be passing it directly to an endpoint handler, rather than
@@ -41,7 +49,7 @@ func TestReportFlagEvalFailsWithUninitializedClientAndStore(t *testing.T) {
 
 	b, _ := io.ReadAll(resp.Body)
 
-	assert.JSONEq(t, `{"message":"Service not initialized"}`, string(b))
+	assert.JSONEq(t, `{"code":"service_unavailable","message":"Service not initialized"}`, string(b))
 }
 
 func TestReportFlagEvalWorksWithUninitializedClientButInitializedStore(t *testing.T) {
@@ -57,3 +65,214 @@ func TestReportFlagEvalWorksWithUninitializedClientButInitializedStore(t *testin
 	b, _ := io.ReadAll(resp.Body)
 	assert.JSONEq(t, st.MakeEvalBody(st.ClientSideFlags, false), string(b))
 }
+
+func TestReportFlagEvalUsesFallbackValuesWithUninitializedClientAndStore(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	envConfig := config.EnvConfig{
+		FallbackFlagValues: config.NewOptFlagValues(map[string]ldvalue.Value{
+			"flag1": ldvalue.Bool(true),
+			"flag2": ldvalue.String("x"),
+		}),
+		StartupFlags: ct.NewOptStringList([]string{"flag1"}),
+	}
+	ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(false), st.MakeStoreWithData(false), envConfig)
+	reason := `{"kind": "ERROR", "errorKind": "CLIENT_NOT_READY"}`
+
+	t.Run("all flags", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(`{"key": "my-user"}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		b, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"flag1": {"value": true, "version": 0, "reason": `+reason+`},
+			"flag2": {"value": "x", "version": 0, "reason": `+reason+`}}`, string(b))
+	})
+
+	t.Run("startup flags", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(`{"key": "my-user"}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateStartupFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		b, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"flag1": {"value": true, "version": 0, "reason": `+reason+`}}`, string(b))
+	})
+
+	t.Run("values only", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(`{"key": "my-user"}`), headers, nil, ctx)
+		req.Header.Set(basictypes.EvalSchemaVersionHeader, basictypes.EvalSchemaValueOnly)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		b, _ := io.ReadAll(resp.Body)
+		assert.JSONEq(t, `{"flag1": true, "flag2": "x"}`, string(b))
+	})
+}
+
+func TestReportFlagEvalIgnoresFallbackValuesWithInitializedStore(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	envConfig := config.EnvConfig{
+		FallbackFlagValues: config.NewOptFlagValues(map[string]ldvalue.Value{"flag1": ldvalue.Bool(true)}),
+	}
+	ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(false), st.MakeStoreWithData(true), envConfig)
+	req := buildPreRoutedRequest("REPORT", jsonhelpers.ToJSON(st.BasicUserForTestFlags), headers, nil, ctx)
+	resp := httptest.NewRecorder()
+	evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	b, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, st.MakeEvalBody(st.ClientSideFlags, false), string(b))
+}
+
+func TestReportFlagEvalWithDefaultValues(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	store := st.NewInMemoryStore()
+	_ = store.Init(nil)
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("on-flag").Version(1).On(true).
+		Variations(ldvalue.String("a")).FallthroughVariation(0).ClientSideUsingEnvironmentID(true).Build())
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("off-flag").Version(2).On(false).
+		Variations(ldvalue.String("a")).ClientSideUsingEnvironmentID(true).Build())
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("server-only-flag").Version(3).On(false).Build())
+	ctx := testenv.NewTestEnvContext("", false, store)
+	body := `{"context": {"kind": "user", "key": "my-user"},
+		"defaults": {"on-flag": "x", "off-flag": "y", "server-only-flag": "z", "unknown-flag": "w"}}`
+	notFound := `{"kind": "ERROR", "errorKind": "FLAG_NOT_FOUND"}`
+
+	t.Run("full results", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(body), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"on-flag": {"value": "a", "variation": 0, "version": 1},
+			"off-flag": {"value": "y", "variation": null, "version": 2},
+			"unknown-flag": {"value": "w", "version": 0, "reason": `+notFound+`}}`, resp.Body.String())
+	})
+
+	t.Run("values only", func(t *testing.T) {
+		valueOnlyHeaders := headers.Clone()
+		valueOnlyHeaders.Set(basictypes.EvalSchemaVersionHeader, basictypes.EvalSchemaValueOnly)
+		req := buildPreRoutedRequest("REPORT", []byte(body), valueOnlyHeaders, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"on-flag": "a", "off-flag": "y", "unknown-flag": "w"}`, resp.Body.String())
+	})
+
+	t.Run("without defaults", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(`{"kind": "user", "key": "my-user"}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"on-flag": {"value": "a", "variation": 0, "version": 1},
+			"off-flag": {"value": null, "variation": null, "version": 2}}`, resp.Body.String())
+	})
+
+	t.Run("invalid context", func(t *testing.T) {
+		req := buildPreRoutedRequest("REPORT", []byte(`{"context": {"kind": "user"}, "defaults": {}}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		evaluateAllFeatureFlags(basictypes.JSClientSDK)(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestReportFlagEvalIncludesReasonsOnlyWhenRequested(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	store := st.NewInMemoryStore()
+	_ = store.Init(nil)
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("plain-flag").Version(1).On(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).Build())
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("tracked-flag").Version(2).On(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).TrackEvents(true).Build())
+	_, _ = st.UpsertFlag(store, ldbuilders.NewFlagBuilder("experiment-flag").Version(3).On(true).
+		Variations(ldvalue.Bool(true)).FallthroughVariation(0).TrackEventsFallthrough(true).Build())
+	ctx := testenv.NewTestEnvContext("", false, store)
+
+	plain := `"plain-flag": {"value": true, "variation": 0, "version": 1}`
+	plainWithReason := `"plain-flag": {"value": true, "variation": 0, "version": 1, "reason": {"kind": "FALLTHROUGH"}}`
+	tracked := `"tracked-flag": {"value": true, "variation": 0, "version": 2, "trackEvents": true}`
+	trackedWithReason := `"tracked-flag": {"value": true, "variation": 0, "version": 2, "trackEvents": true,
+		"reason": {"kind": "FALLTHROUGH"}}`
+	// A flag in an experiment always has a reason, since the SDK needs it for analytics events, just as
+	// the LaunchDarkly endpoint would return.
+	experiment := `"experiment-flag": {"value": true, "variation": 0, "version": 3, "trackEvents": true,
+		"trackReason": true, "reason": {"kind": "FALLTHROUGH"}}`
+
+	for _, p := range []struct {
+		query    string
+		expected string
+	}{
+		{"", `{` + plain + `,` + tracked + `,` + experiment + `}`},
+		{"?withReasons=false", `{` + plain + `,` + tracked + `,` + experiment + `}`},
+		{"?withReasons=true", `{` + plainWithReason + `,` + trackedWithReason + `,` + experiment + `}`},
+		{"?withReasons=tracked", `{` + plain + `,` + trackedWithReason + `,` + experiment + `}`},
+	} {
+		t.Run("query "+p.query, func(t *testing.T) {
+			req := buildPreRoutedRequest("REPORT", []byte(`{"kind": "user", "key": "my-user"}`), headers, nil, ctx)
+			req.URL.RawQuery = strings.TrimPrefix(p.query, "?")
+			resp := httptest.NewRecorder()
+			evaluateAllFeatureFlags(basictypes.ServerSDK)(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, p.expected, resp.Body.String())
+		})
+	}
+}
+
+func TestReportFlagEvalRejectsWhileInitializingIfEnabled(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	envConfig := config.EnvConfig{
+		FallbackFlagValues: config.NewOptFlagValues(map[string]ldvalue.Value{"flag1": ldvalue.Bool(true)}),
+	}
+	handler := middleware.RejectWhileInitializing(http.HandlerFunc(evaluateAllFeatureFlags(basictypes.JSClientSDK)))
+
+	t.Run("store has no data", func(t *testing.T) {
+		ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(false), st.MakeStoreWithData(false), envConfig)
+		req := buildPreRoutedRequest("REPORT", []byte(`{"key": "my-user"}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.Equal(t, "5", resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("store has data", func(t *testing.T) {
+		ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(false), st.MakeStoreWithData(true), envConfig)
+		req := buildPreRoutedRequest("REPORT", jsonhelpers.ToJSON(st.BasicUserForTestFlags), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("Retry-After"))
+	})
+
+	t.Run("client is initialized", func(t *testing.T) {
+		ctx := testenv.NewTestEnvContextWithEnvConfig("", testclient.FakeLDClientFactory(true), st.MakeStoreWithData(true), envConfig)
+		req := buildPreRoutedRequest("REPORT", jsonhelpers.ToJSON(st.BasicUserForTestFlags), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("client was not created", func(t *testing.T) {
+		failingFactory := testclient.ClientFactoryThatFails(errors.New("sorry"))
+		ctx := testenv.NewTestEnvContextWithEnvConfig("", failingFactory, st.MakeStoreWithData(false), envConfig)
+		require.Nil(t, ctx.GetClient())
+		req := buildPreRoutedRequest("REPORT", []byte(`{"key": "my-user"}`), headers, nil, ctx)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	})
+}
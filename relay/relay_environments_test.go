@@ -11,6 +11,7 @@ import (
 
 	"github.com/launchdarkly/eventsource"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	ld "github.com/launchdarkly/go-server-sdk/v7"
 	helpers "github.com/launchdarkly/go-test-helpers/v3"
 	c "github.com/launchdarkly/ld-relay/v8/config"
@@ -122,8 +123,10 @@ func TestRelayAddEnvironment(t *testing.T) {
 	env1, _ := relay.getEnvironment(sdkauth.New(st.EnvMobile.Config.SDKKey))
 	assert.Equal(t, env, env1)
 
-	env2 := helpers.RequireValue(t, resultCh, time.Second, "timed out waiting for new environment to initialize")
-	assert.Equal(t, env, env2)
+	result := helpers.RequireValue(t, resultCh, time.Second, "timed out waiting for new environment to initialize")
+	assert.Equal(t, env, result.Env)
+	assert.Equal(t, st.EnvMobile.Name, result.Name)
+	assert.Equal(t, relayenv.InitSucceeded, result.Outcome)
 }
 
 func TestRelayAddEnvironmentAfterClosed(t *testing.T) {
@@ -230,15 +233,71 @@ func TestRelayWaitForAllEnvironments(t *testing.T) {
 	})
 
 	t.Run("returns error if any environment does not initialize successfully", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
 		relay, err := newRelayInternal(config, relayInternalOptions{
 			clientFactory: oneEnvFails(st.EnvMobile.Config.SDKKey, false, nil),
-			loggers:       ldlog.NewDisabledLoggers(),
+			loggers:       mockLog.Loggers,
 		})
 		require.NoError(t, err)
 		defer relay.Close()
 
 		err = relay.waitForAllClients(time.Second)
 		assert.Error(t, err)
+		mockLog.AssertMessageMatch(t, true, ldlog.Info,
+			"Environment initialization finished: 1 succeeded, 0 timed out, 1 failed")
+	})
+}
+
+func TestRelayRequireAllEnvironments(t *testing.T) {
+	config := c.Config{
+		Main:        c.MainConfig{RequireAllEnvironments: true},
+		Environment: st.MakeEnvConfigs(st.EnvMain, st.EnvMobile),
+	}
+
+	t.Run("starts if all environments initialize successfully", func(t *testing.T) {
+		relay, err := makeBasicRelay(config)
+		require.NoError(t, err)
+		defer relay.Close()
+
+		for _, env := range relay.getAllEnvironments() {
+			assert.NoError(t, env.GetInitError())
+		}
+	})
+
+	t.Run("does not start if any environment does not initialize successfully", func(t *testing.T) {
+		mockLog := ldlogtest.NewMockLog()
+		relay, err := newRelayInternal(config, relayInternalOptions{
+			clientFactory: oneEnvFails(st.EnvMobile.Config.SDKKey, false, nil),
+			loggers:       mockLog.Loggers,
+		})
+		assert.Equal(t, errSomeEnvironmentFailed, err)
+		assert.Nil(t, relay)
+		mockLog.AssertMessageMatch(t, true, ldlog.Info,
+			"Environment initialization finished: 1 succeeded, 0 timed out, 1 failed")
+	})
+
+	t.Run("with ExitOnError, waits for every environment rather than the first one", func(t *testing.T) {
+		exitOnErrorConfig := config
+		exitOnErrorConfig.Main.ExitOnError = true
+		slowFactory := func(sdkKey c.SDKKey, sdkConfig ld.Config, timeout time.Duration) (sdks.LDClientContext, error) {
+			if sdkKey == st.EnvMobile.Config.SDKKey {
+				time.Sleep(time.Millisecond * 100)
+			}
+			return testclient.FakeLDClientFactory(true)(sdkKey, sdkConfig, timeout)
+		}
+		mockLog := ldlogtest.NewMockLog()
+		relay, err := newRelayInternal(exitOnErrorConfig, relayInternalOptions{
+			clientFactory: slowFactory,
+			loggers:       mockLog.Loggers,
+		})
+		require.NoError(t, err)
+		defer relay.Close()
+
+		mockLog.AssertMessageMatch(t, true, ldlog.Info,
+			"Environment initialization finished: 2 succeeded, 0 timed out, 0 failed")
+		for _, env := range relay.getAllEnvironments() {
+			assert.NotNil(t, env.GetClient(), env.GetIdentifiers().ConfiguredName)
+		}
 	})
 }
 
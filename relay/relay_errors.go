@@ -15,6 +15,10 @@ func errNewClientContextFailed(envName string, err error) error {
 	return fmt.Errorf(`unable to create client context for "%s": %w`, envName, err)
 }
 
+func errEnvironmentCredentialConflict(envName, otherName string) error {
+	return fmt.Errorf(`a credential for "%s" is already used by environment "%s"`, envName, otherName)
+}
+
 func errNewMetricsManagerFailed(err error) error {
 	return fmt.Errorf("unable to create metrics manager: %w", err)
 }
@@ -0,0 +1,212 @@
+package relay
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/relayenv"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+const (
+	logMsgReloadApplied          = "Applied configuration change: %s"
+	logMsgReloadNeedsRestart     = "Configuration change to %s will not take effect until the Relay Proxy is restarted"
+	logMsgReloadEnvApplied       = "Applied configuration change to environment %q: %s"
+	logMsgReloadEnvAdded         = "Added environment %q"
+	logMsgReloadEnvRemoved       = "Removed environment %q"
+	logMsgReloadEnvRecreated     = "Restarting environment %q to apply configuration change: %s"
+	logMsgReloadEnvAddFailed     = "Unable to add environment %q: %s"
+	logMsgReloadEnvsNotSupported = "Environment configuration changes are ignored when using auto-configuration or offline mode"
+)
+
+// Reload applies a new configuration to a running Relay, as far as is possible without restarting it.
+//
+// The global and per-environment log levels, the connection limit, and the settings that affect the
+// status resource are changed immediately, as are the cache TTL and secure mode of each environment.
+// Environments that were added to or removed from the configuration are added or removed; an
+// environment whose other settings have changed is removed and added again, which disconnects its
+// clients. Any other change is logged with a warning that it requires a restart.
+//
+// If the new configuration is invalid, Reload returns an error and changes nothing.
+func (r *Relay) Reload(c config.Config) error {
+	if err := config.ValidateConfig(&c, r.loggers); err != nil {
+		return err
+	}
+
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+
+	r.lock.Lock()
+	if r.closed {
+		r.lock.Unlock()
+		return errAlreadyClosed
+	}
+	old := r.config
+	staticEnvironments := !old.AutoConfig.Key.Defined() && old.OfflineMode.FileDataSource == ""
+	if staticEnvironments && len(c.Environment) == 0 {
+		r.lock.Unlock()
+		return errNoEnvironments
+	}
+	for _, field := range changedConfigFields(old, c) {
+		if r.applyMainConfigChange(field, c.Main) {
+			r.loggers.Infof(logMsgReloadApplied, field)
+		} else {
+			r.loggers.Warnf(logMsgReloadNeedsRestart, field)
+		}
+	}
+	r.lock.Unlock()
+
+	if staticEnvironments {
+		r.reloadEnvironments(old, c)
+	} else if !reflect.DeepEqual(old.Environment, c.Environment) || !reflect.DeepEqual(old.Filters, c.Filters) {
+		r.loggers.Warn(logMsgReloadEnvsNotSupported)
+	}
+	return nil
+}
+
+// applyMainConfigChange updates a MainConfig field that can be changed without a restart, and returns
+// true; or, if the field cannot be changed, it returns false. The caller must hold r.lock.
+func (r *Relay) applyMainConfigChange(field string, m config.MainConfig) bool {
+	switch field {
+	case "Main.LogLevel":
+		r.config.Main.LogLevel = m.LogLevel
+		r.logLevel.SetMinLevel(m.LogLevel.GetOrElse(r.baseLoggers.GetMinLevel()))
+	case "Main.MaxTotalConnections":
+		r.config.Main.MaxTotalConnections = m.MaxTotalConnections
+		r.connectionLimiter.SetMaxConnections(m.MaxTotalConnections.GetOrElse(0))
+	case "Main.MaxConnectionWait":
+		r.config.Main.MaxConnectionWait = m.MaxConnectionWait
+		r.connectionLimiter.SetMaxWait(m.MaxConnectionWait.GetOrElse(0))
+	case "Main.LogConnections":
+		r.config.Main.LogConnections = m.LogConnections
+		r.connectionLogger.SetEnabled(m.LogConnections)
+	case "Main.DisconnectedStatusTime":
+		r.config.Main.DisconnectedStatusTime = m.DisconnectedStatusTime
+	case "Main.ConfigSourceStaleTime":
+		r.config.Main.ConfigSourceStaleTime = m.ConfigSourceStaleTime
+	case "Main.BigSegmentsStaleThreshold":
+		r.config.Main.BigSegmentsStaleThreshold = m.BigSegmentsStaleThreshold
+	case "Main.BigSegmentsStaleAsDegraded":
+		r.config.Main.BigSegmentsStaleAsDegraded = m.BigSegmentsStaleAsDegraded
+	case "Main.ReportCachedStatus":
+		r.config.Main.ReportCachedStatus = m.ReportCachedStatus
+	case "Main.StatusKeyDisplay":
+		r.config.Main.StatusKeyDisplay = m.StatusKeyDisplay
+	default:
+		return false
+	}
+	return true
+}
+
+// reloadEnvironments brings the set of environments up to date with a new configuration.
+func (r *Relay) reloadEnvironments(old, c config.Config) {
+	r.lock.Lock()
+	r.config.Environment = c.Environment
+	r.config.Filters = c.Filters
+	r.lock.Unlock()
+
+	oldEnvs, newEnvs := makeFilteredEnvironments(&old), makeFilteredEnvironments(&c)
+	var toAdd []string
+
+	// Remove environments before adding any, in case a credential has moved from one to another.
+	for _, name := range sortedEnvNames(oldEnvs) {
+		oldEnvConfig := oldEnvs[name]
+		envCredential := sdkauth.NewScoped(oldEnvConfig.FilterKey, oldEnvConfig.SDKKey)
+		newEnvConfig, ok := newEnvs[name]
+		if !ok {
+			r.removeEnvironment(envCredential)
+			r.loggers.Infof(logMsgReloadEnvRemoved, name)
+			continue
+		}
+		var liveFields, restartFields []string
+		for _, field := range changedFields("", *oldEnvConfig, *newEnvConfig) {
+			switch field {
+			case "LogLevel", "TTL", "SecureMode":
+				liveFields = append(liveFields, field)
+			default:
+				restartFields = append(restartFields, field)
+			}
+		}
+		if len(restartFields) > 0 {
+			r.loggers.Infof(logMsgReloadEnvRecreated, name, strings.Join(restartFields, ", "))
+			r.removeEnvironment(envCredential)
+			toAdd = append(toAdd, name)
+			continue
+		}
+		env, found := r.envsByCredential.Lookup(envCredential)
+		if !found { // COVERAGE: can't happen in unit tests
+			continue
+		}
+		env.SetTTL(newEnvConfig.TTL.GetOrElse(0))
+		env.SetSecureMode(newEnvConfig.SecureMode)
+		env.SetLogLevel(newEnvConfig.LogLevel.GetOrElse(c.Main.LogLevel.GetOrElse(ldlog.Info)))
+		if len(liveFields) > 0 {
+			r.loggers.Infof(logMsgReloadEnvApplied, name, strings.Join(liveFields, ", "))
+		}
+	}
+
+	for _, name := range sortedEnvNames(newEnvs) {
+		if _, ok := oldEnvs[name]; !ok {
+			toAdd = append(toAdd, name)
+		}
+	}
+	for _, name := range toAdd {
+		envConfig := newEnvs[name]
+		if _, _, err := r.addEnvironment(relayenv.EnvIdentifiers{ConfiguredName: name, Group: envConfig.Group}, *envConfig, nil); err != nil {
+			r.loggers.Errorf(logMsgReloadEnvAddFailed, name, err)
+			continue
+		}
+		r.loggers.Infof(logMsgReloadEnvAdded, name)
+	}
+}
+
+// changedConfigFields returns the names, in the form "Section.Field", of all fields that differ between
+// two configurations, except for the environment and filter sections.
+func changedConfigFields(old, c config.Config) []string {
+	var ret []string
+	for _, section := range []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"Main", old.Main, c.Main},
+		{"AutoConfig", old.AutoConfig, c.AutoConfig},
+		{"OfflineMode", old.OfflineMode, c.OfflineMode},
+		{"Events", old.Events, c.Events},
+		{"Redis", old.Redis, c.Redis},
+		{"Consul", old.Consul, c.Consul},
+		{"DynamoDB", old.DynamoDB, c.DynamoDB},
+		{"Proxy", old.Proxy, c.Proxy},
+		{"Datadog", old.Datadog, c.Datadog},
+		{"Stackdriver", old.Stackdriver, c.Stackdriver},
+		{"Prometheus", old.Prometheus, c.Prometheus},
+	} {
+		ret = append(ret, changedFields(section.name+".", section.old, section.new)...)
+	}
+	return ret
+}
+
+// changedFields returns the names of all fields that differ between two structs of the same type, with
+// the specified prefix added to each name.
+func changedFields(prefix string, old, new interface{}) []string {
+	oldValue, newValue := reflect.ValueOf(old), reflect.ValueOf(new)
+	var ret []string
+	for i := 0; i < oldValue.NumField(); i++ {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			ret = append(ret, prefix+oldValue.Type().Field(i).Name)
+		}
+	}
+	return ret
+}
+
+func sortedEnvNames(envs map[string]*config.EnvConfig) []string {
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,144 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	ct "github.com/launchdarkly/go-configtypes"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeReloadTestConfig(envs ...st.TestEnv) c.Config {
+	var config c.Config
+	config.Main.LogLevel = c.NewOptLogLevel(ldlog.Debug) // same as the default in withStartedRelay
+	config.Environment = st.MakeEnvConfigs(envs...)
+	return config
+}
+
+func TestReloadMainConfig(t *testing.T) {
+	t.Run("applies settings that can be changed live", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Main.MaxTotalConnections, _ = ct.NewOptIntGreaterThanZero(5)
+			newConfig.Main.MaxConnectionWait = ct.NewOptDuration(time.Second)
+			newConfig.Main.ReportCachedStatus = true
+			newConfig.Main.LogConnections = true
+			require.NoError(t, p.relay.Reload(newConfig))
+
+			assert.Equal(t, 5, p.relay.connectionLimiter.MaxConnections())
+			assert.Equal(t, time.Second, p.relay.connectionLimiter.MaxWait())
+			assert.True(t, p.relay.config.Main.ReportCachedStatus)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Applied configuration change: Main.MaxTotalConnections")
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Applied configuration change: Main.MaxConnectionWait")
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Applied configuration change: Main.LogConnections")
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Applied configuration change: Main.ReportCachedStatus")
+		})
+	})
+
+	t.Run("changes log level", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			env, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NoError(t, err)
+			envLoggers := env.GetLoggers()
+
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Main.LogLevel = c.NewOptLogLevel(ldlog.Warn)
+			require.NoError(t, p.relay.Reload(newConfig))
+
+			p.relay.loggers.Info("global info message")
+			p.relay.loggers.Warn("global warn message")
+			envLoggers.Info("env info message")
+			envLoggers.Warn("env warn message")
+			p.mockLog.AssertMessageMatch(t, false, ldlog.Info, "global info message")
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, "global warn message")
+			p.mockLog.AssertMessageMatch(t, false, ldlog.Info, "env info message")
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Warn, "env warn message")
+		})
+	})
+
+	t.Run("warns about settings that require a restart", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Main.Port, _ = ct.NewOptIntGreaterThanZero(9999)
+			require.NoError(t, p.relay.Reload(newConfig))
+
+			assert.False(t, p.relay.config.Main.Port.IsDefined())
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Warn,
+				"Configuration change to Main.Port will not take effect until the Relay Proxy is restarted")
+		})
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Main.MaxTotalConnections, _ = ct.NewOptIntGreaterThanZero(5)
+			newConfig.Events.Compression = "lzma"
+			assert.Error(t, p.relay.Reload(newConfig))
+			assert.Equal(t, 0, p.relay.connectionLimiter.MaxConnections())
+
+			assert.Equal(t, errNoEnvironments, p.relay.Reload(makeReloadTestConfig()))
+			_, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestReloadEnvironments(t *testing.T) {
+	t.Run("adds and removes environments", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain, st.EnvClientSide), func(p relayTestParams) {
+			require.NoError(t, p.relay.Reload(makeReloadTestConfig(st.EnvMain, st.EnvMobile)))
+
+			_, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			assert.NoError(t, err)
+			_, err = p.relay.getEnvironment(sdkauth.New(st.EnvMobile.Config.MobileKey))
+			assert.NoError(t, err)
+			_, err = p.relay.getEnvironment(sdkauth.New(st.EnvClientSide.Config.SDKKey))
+			assert.Equal(t, errUnrecognizedEnvironment, err)
+
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, `Added environment "`+st.EnvMobile.Name+`"`)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, `Removed environment "`+st.EnvClientSide.Name+`"`)
+		})
+	})
+
+	t.Run("applies settings that can be changed live", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			env, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NoError(t, err)
+
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Environment[st.EnvMain.Name].TTL = ct.NewOptDuration(time.Minute)
+			newConfig.Environment[st.EnvMain.Name].SecureMode = true
+			require.NoError(t, p.relay.Reload(newConfig))
+
+			sameEnv, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			require.NoError(t, err)
+			assert.Equal(t, env, sameEnv)
+			assert.Equal(t, time.Minute, env.GetTTL())
+			assert.True(t, env.IsSecureMode())
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info,
+				`Applied configuration change to environment "`+st.EnvMain.Name+`": SecureMode, TTL`)
+		})
+	})
+
+	t.Run("restarts environment for other changes", func(t *testing.T) {
+		withStartedRelay(t, makeReloadTestConfig(st.EnvMain), func(p relayTestParams) {
+			newConfig := makeReloadTestConfig(st.EnvMain)
+			newConfig.Environment[st.EnvMain.Name].SDKKey = st.UndefinedSDKKey
+			require.NoError(t, p.relay.Reload(newConfig))
+
+			_, err := p.relay.getEnvironment(sdkauth.New(st.EnvMain.Config.SDKKey))
+			assert.Equal(t, errUnrecognizedEnvironment, err)
+			_, err = p.relay.getEnvironment(sdkauth.New(st.UndefinedSDKKey))
+			assert.NoError(t, err)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info,
+				`Restarting environment "`+st.EnvMain.Name+`" to apply configuration change: SDKKey`)
+		})
+	})
+}
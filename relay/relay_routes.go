@@ -2,9 +2,12 @@ package relay
 
 import (
 	"net/http"
+	"net/http/pprof"
+	"strings"
 
 	"github.com/launchdarkly/ld-relay/v8/internal/sdkauth"
 
+	"github.com/launchdarkly/ld-relay/v8/config"
 	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
 	"github.com/launchdarkly/ld-relay/v8/internal/logging"
 	"github.com/launchdarkly/ld-relay/v8/internal/metrics"
@@ -17,11 +20,44 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// defaultAllowedMethods are all of the HTTP methods that are used by routes in makeRouter. Requests with
+// any other method are rejected before routing, unless the allowlist is changed with
+// MainConfig.AllowedMethods.
+var defaultAllowedMethods = []string{"GET", "POST", "REPORT", "OPTIONS"} //nolint:gochecknoglobals
+
+// hstsMaxAge is the max-age for the default Strict-Transport-Security header, which is only sent if TLS is
+// enabled.
+const hstsMaxAge = "max-age=31536000"
+
 const (
 	serverSideStreamLogMessage          = "Application requested server-side /all stream"
 	serverSideFlagsOnlyStreamLogMessage = "Application requested server-side /flags stream"
 )
 
+// makeResponseHeaders returns the headers that are added to every response. Unless MainConfig.NoDefaultResponseHeaders
+// is set, these start with some standard security headers; then each entry in MainConfig.ResponseHeaders, which
+// has already been validated as "Name: value", adds or replaces a header, or removes it if the value is empty.
+func makeResponseHeaders(mainConfig config.MainConfig) http.Header {
+	headers := make(http.Header)
+	if !mainConfig.NoDefaultResponseHeaders {
+		headers.Set("X-Content-Type-Options", "nosniff")
+		headers.Set("X-Frame-Options", "DENY")
+		if mainConfig.TLSEnabled {
+			headers.Set("Strict-Transport-Security", hstsMaxAge)
+		}
+	}
+	for _, entry := range mainConfig.ResponseHeaders.Values() {
+		name, value, _ := strings.Cut(entry, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if value == "" {
+			headers.Del(name)
+		} else {
+			headers.Set(name, value)
+		}
+	}
+	return headers
+}
+
 // makeRouter creates and configures a Router containing all of the standard routes for Relay.
 //
 // IMPORTANT: The route strings that are used here, such as "/sdk/evalx/{envId}/contexts/{context}", will appear
@@ -31,16 +67,43 @@ const (
 func (r *Relay) makeRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.Use(logging.GlobalContextLoggersMiddleware(r.loggers))
-	if r.loggers.GetMinLevel() == ldlog.Debug {
-		router.Use(logging.RequestLoggerMiddleware(r.loggers))
+	router.Use(r.debugRequestLogger)
+	router.Use(middleware.LimitReportBody(int64(r.config.Main.MaxReportBodySize.GetOrElse(config.DefaultMaxReportBodySize))))
+	if r.config.Main.RequireAdminKeyForStatus {
+		router.Handle("/status", middleware.AdminAuth(r.config.Main.AdminKey)(statusHandler(r, false))).Methods("GET")
+	} else {
+		router.Handle("/status", statusHandler(r, false)).Methods("GET")
+	}
+	router.Handle("/health", healthHandler()).Methods("GET")
+	router.Handle("/ready", readyHandler(r)).Methods("GET")
+	if !r.config.Main.AdminPort.IsDefined() {
+		r.addAdminRoutes(router, false)
 	}
-	router.Handle("/status", statusHandler(r)).Methods("GET")
 
 	environmentGetters := relayEnvironmentGetters{r}
 	sdkKeySelector := middleware.SelectEnvironmentByAuthorizationKey(basictypes.ServerSDK, environmentGetters)
 	mobileKeySelector := middleware.SelectEnvironmentByAuthorizationKey(basictypes.MobileSDK, environmentGetters)
 	jsClientSelector := middleware.SelectEnvironmentByAuthorizationKey(basictypes.JSClientSDK, environmentGetters)
 	offlineMode := r.config.OfflineMode.FileDataSource != ""
+	sdkVersionPolicy := middleware.RequireSDKVersions(r.config.Main.MinSDKVersions.Values(), r.config.Main.RejectUnknownSDKVersions)
+
+	// Evaluation and polling responses can optionally report how old the environment's data is, and can
+	// optionally be rejected while the environment has no data yet.
+	withEvalOptions := func(handler http.HandlerFunc) http.Handler {
+		var h http.Handler = handler
+		if r.config.Main.RetryWhileInitializing {
+			h = middleware.RejectWhileInitializing(h)
+		}
+		if r.config.Main.SendDataAgeHeader {
+			h = middleware.AddDataAgeHeader(h)
+		}
+		return h
+	}
+
+	// Client-side streams that include a context can optionally send evaluated flag values, instead of pings
+	heartbeatInterval := r.config.Main.HeartbeatInterval.GetOrElse(config.DefaultHeartbeatInterval)
+	maxClientConnTime := r.config.Main.MaxClientConnectionTime.GetOrElse(0)
+	maxClientConnJitter := r.config.Main.MaxClientConnectionJitter.GetOrElse(0)
 
 	// Client-side evaluation (for JS, not mobile)
 	jsClientSideMiddlewareStack := func(subrouter *mux.Router) mux.MiddlewareFunc {
@@ -48,6 +111,7 @@ func (r *Relay) makeRouter() *mux.Router {
 			mux.CORSMethodMiddleware(subrouter),
 			jsClientSelector, // selects an environment based on the client-side ID in the URL
 			middleware.CORS,  // must apply this after jsClientSelector because the CORS headers can be environment-specific
+			sdkVersionPolicy, // must apply this after CORS so that browsers can read the error
 			middleware.RequestCount(metrics.BrowserRequests),
 		)
 	}
@@ -57,14 +121,17 @@ func (r *Relay) makeRouter() *mux.Router {
 	goalsRouter.HandleFunc("/{envId}", getGoals).Methods("GET", "OPTIONS")
 
 	clientSideSdkEvalXRouter := router.PathPrefix("/sdk/evalx/{envId}/").Subrouter()
-	clientSideSdkEvalXRouter.Use(jsClientSideMiddlewareStack(clientSideSdkEvalXRouter))
-	clientSideSdkEvalXRouter.HandleFunc("/contexts/{context}", evaluateAllFeatureFlags(basictypes.JSClientSDK)).Methods("GET", "OPTIONS")
-	clientSideSdkEvalXRouter.HandleFunc("/context", evaluateAllFeatureFlags(basictypes.JSClientSDK)).Methods("REPORT", "OPTIONS")
-	clientSideSdkEvalXRouter.HandleFunc("/users/{context}", evaluateAllFeatureFlags(basictypes.JSClientSDK)).Methods("GET", "OPTIONS")
-	clientSideSdkEvalXRouter.HandleFunc("/user", evaluateAllFeatureFlags(basictypes.JSClientSDK)).Methods("REPORT", "OPTIONS")
+	clientSideSdkEvalXRouter.Use(jsClientSideMiddlewareStack(clientSideSdkEvalXRouter), r.drainer.Reject)
+	clientSideSdkEvalXRouter.Handle("/contexts/{context}", withEvalOptions(evaluateAllFeatureFlags(basictypes.JSClientSDK))).Methods("GET", "OPTIONS")
+	clientSideSdkEvalXRouter.Handle("/context", withEvalOptions(evaluateAllFeatureFlags(basictypes.JSClientSDK))).Methods("REPORT", "OPTIONS")
+	clientSideSdkEvalXRouter.Handle("/users/{context}", withEvalOptions(evaluateAllFeatureFlags(basictypes.JSClientSDK))).Methods("GET", "OPTIONS")
+	clientSideSdkEvalXRouter.Handle("/user", withEvalOptions(evaluateAllFeatureFlags(basictypes.JSClientSDK))).Methods("REPORT", "OPTIONS")
+	clientSideSdkEvalXRouter.Handle("/startup/contexts/{context}", withEvalOptions(evaluateStartupFeatureFlags(basictypes.JSClientSDK))).Methods("GET", "OPTIONS")
+	clientSideSdkEvalXRouter.Handle("/startup/context", withEvalOptions(evaluateStartupFeatureFlags(basictypes.JSClientSDK))).Methods("REPORT", "OPTIONS")
 
 	serverSideMiddlewareStack := middleware.Chain(
 		sdkKeySelector,
+		sdkVersionPolicy,
 		middleware.RequestCount(metrics.ServerRequests))
 
 	serverSideSdkRouter := router.PathPrefix("/sdk/").Subrouter()
@@ -73,89 +140,179 @@ func (r *Relay) makeRouter() *mux.Router {
 	// serverSideSdkRouter.Use(serverSideMiddlewareStack)
 
 	serverSideEvalXRouter := serverSideSdkRouter.PathPrefix("/evalx/").Subrouter()
-	serverSideEvalXRouter.Handle("/contexts/{context}", serverSideMiddlewareStack(http.HandlerFunc(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("GET")
-	serverSideEvalXRouter.Handle("/context", serverSideMiddlewareStack(http.HandlerFunc(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("REPORT")
+	serverSideEvalXRouter.Use(r.drainer.Reject)
+	serverSideEvalXRouter.Handle("/contexts/{context}", serverSideMiddlewareStack(withEvalOptions(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("GET")
+	serverSideEvalXRouter.Handle("/context", serverSideMiddlewareStack(withEvalOptions(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("REPORT")
 	// /users and /user are obsolete names for /contexts and /context, still used by some supported SDKs; the handler is
 	// the same, because in both cases LD accepts any valid user *or* context JSON.
-	serverSideEvalXRouter.Handle("/users/{context}", serverSideMiddlewareStack(http.HandlerFunc(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("GET")
-	serverSideEvalXRouter.Handle("/user", serverSideMiddlewareStack(http.HandlerFunc(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("REPORT")
+	serverSideEvalXRouter.Handle("/users/{context}", serverSideMiddlewareStack(withEvalOptions(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("GET")
+	serverSideEvalXRouter.Handle("/user", serverSideMiddlewareStack(withEvalOptions(evaluateAllFeatureFlags(basictypes.ServerSDK)))).Methods("REPORT")
 
 	// PHP SDK endpoints
-	serverSideSdkRouter.Handle("/flags", serverSideMiddlewareStack(middleware.PollingRequestCount(http.HandlerFunc(pollAllFlagsHandler)))).Methods("GET")
-	serverSideSdkRouter.Handle("/flags/{key}", serverSideMiddlewareStack(middleware.PollingRequestCount(http.HandlerFunc(pollFlagHandler)))).Methods("GET")
-	serverSideSdkRouter.Handle("/segments/{key}", serverSideMiddlewareStack(middleware.PollingRequestCount(http.HandlerFunc(pollSegmentHandler)))).Methods("GET")
+	serverSideSdkRouter.Handle("/flags", serverSideMiddlewareStack(middleware.PollingRequestCount(withEvalOptions(pollAllFlagsHandler)))).Methods("GET")
+	serverSideSdkRouter.Handle("/flags/{key}", serverSideMiddlewareStack(middleware.PollingRequestCount(withEvalOptions(pollFlagHandler)))).Methods("GET")
+	serverSideSdkRouter.Handle("/segments/{key}", serverSideMiddlewareStack(middleware.PollingRequestCount(withEvalOptions(pollSegmentHandler)))).Methods("GET")
+
+	// Polling endpoint for other server-side SDKs
+	serverSideSdkRouter.Handle("/latest-all", serverSideMiddlewareStack(middleware.PollingRequestCount(withEvalOptions(pollAllDataHandler)))).Methods("GET")
 
-	// Mobile evaluation
+	// Lightweight check that a credential is recognized, for application health checks
+	serverSideSdkRouter.Handle("/validate", serverSideMiddlewareStack(validateCredentialHandler())).Methods("GET")
+
+	// Mobile evaluation. Mobile responses can optionally tell clients where to long-poll if they can't stream.
+	pollFallbackHeaders := make(http.Header)
+	if r.config.Main.MobilePollFallback {
+		pollFallbackHeaders.Set(pollFallbackHeader, mobileLongPollPath)
+	}
+	withPollFallback := middleware.ResponseHeaders(pollFallbackHeaders)
 	mobileMiddlewareStack := middleware.Chain(
+		withPollFallback,
 		mobileKeySelector,
+		sdkVersionPolicy,
 		middleware.RequestCount(metrics.MobileRequests))
 
 	msdkRouter := router.PathPrefix("/msdk/").Subrouter()
 	msdkRouter.Use(mobileMiddlewareStack)
+	msdkRouter.Handle("/validate", validateCredentialHandler()).Methods("GET")
 
 	msdkEvalXRouter := msdkRouter.PathPrefix("/evalx/").Subrouter()
-	msdkEvalXRouter.HandleFunc("/contexts/{context}", evaluateAllFeatureFlags(basictypes.MobileSDK)).Methods("GET")
-	msdkEvalXRouter.HandleFunc("/context", evaluateAllFeatureFlags(basictypes.MobileSDK)).Methods("REPORT")
+	msdkEvalXRouter.Use(r.drainer.Reject)
+	msdkEvalXRouter.Handle("/contexts/{context}", withEvalOptions(evaluateAllFeatureFlags(basictypes.MobileSDK))).Methods("GET")
+	msdkEvalXRouter.Handle("/context", withEvalOptions(evaluateAllFeatureFlags(basictypes.MobileSDK))).Methods("REPORT")
 	// /users and /user are obsolete names for /contexts and /context, still used by some supported SDKs; the handler is
 	// the same, because in both cases LD accepts any valid user *or* context JSON.
-	msdkEvalXRouter.HandleFunc("/users/{context}", evaluateAllFeatureFlags(basictypes.MobileSDK)).Methods("GET")
-	msdkEvalXRouter.HandleFunc("/user", evaluateAllFeatureFlags(basictypes.MobileSDK)).Methods("REPORT")
+	msdkEvalXRouter.Handle("/users/{context}", withEvalOptions(evaluateAllFeatureFlags(basictypes.MobileSDK))).Methods("GET")
+	msdkEvalXRouter.Handle("/user", withEvalOptions(evaluateAllFeatureFlags(basictypes.MobileSDK))).Methods("REPORT")
+	msdkEvalXRouter.Handle("/startup/contexts/{context}", withEvalOptions(evaluateStartupFeatureFlags(basictypes.MobileSDK))).Methods("GET")
+	msdkEvalXRouter.Handle("/startup/context", withEvalOptions(evaluateStartupFeatureFlags(basictypes.MobileSDK))).Methods("REPORT")
 
 	mobileStreamRouter := router.PathPrefix("/meval").Subrouter()
-	mobileStreamRouter.Use(mobileMiddlewareStack, middleware.Streaming)
+	mobileStreamRouter.Use(mobileMiddlewareStack, r.drainer.Reject, middleware.Streaming)
 	mobilePingWithUser := pingStreamHandlerWithContext(basictypes.MobileSDK, r.mobileStreamProvider)
-	mobileStreamRouter.Handle("", middleware.CountMobileConns(mobilePingWithUser)).Methods("REPORT")
-	mobileStreamRouter.Handle("/{context}", middleware.CountMobileConns(mobilePingWithUser)).Methods("GET")
+	if r.config.Main.StreamFlagValues {
+		mobilePingWithUser = evalStreamHandler(basictypes.MobileSDK, heartbeatInterval, maxClientConnTime, maxClientConnJitter)
+	}
+	mobileLongPoll := longPollEvalHandler(basictypes.MobileSDK,
+		r.config.Main.MobileLongPollTimeout.GetOrElse(config.DefaultMobileLongPollTimeout))
+	mobileStreamRouter.Handle("/poll", r.connectionLimiter.Limit(middleware.CountMobileConns(r.connectionLogger.Log(basictypes.MobileSDK, mobileLongPoll)))).Methods("REPORT")
+	mobileStreamRouter.Handle("/poll/{context}", r.connectionLimiter.Limit(middleware.CountMobileConns(r.connectionLogger.Log(basictypes.MobileSDK, mobileLongPoll)))).Methods("GET")
+	mobileStreamRouter.Handle("", r.connectionLimiter.Limit(middleware.CountMobileConns(r.connectionLogger.Log(basictypes.MobileSDK, mobilePingWithUser)))).Methods("REPORT")
+	mobileStreamRouter.Handle("/{context}", r.connectionLimiter.Limit(middleware.CountMobileConns(r.connectionLogger.Log(basictypes.MobileSDK, mobilePingWithUser)))).Methods("GET")
 
-	router.Handle("/mping", mobileKeySelector(
-		middleware.CountMobileConns(middleware.Streaming(pingStreamHandler(r.mobileStreamProvider))))).Methods("GET")
+	router.Handle("/mping", withPollFallback(mobileKeySelector(sdkVersionPolicy(r.drainer.Reject(
+		r.connectionLimiter.Limit(middleware.CountMobileConns(
+			r.connectionLogger.Log(basictypes.MobileSDK, middleware.Streaming(pingStreamHandler(r.mobileStreamProvider)))))))))).Methods("GET")
 
 	jsPing := pingStreamHandler(r.jsClientStreamProvider)
 	jsPingWithUser := pingStreamHandlerWithContext(basictypes.JSClientSDK, r.jsClientStreamProvider)
+	if r.config.Main.StreamFlagValues {
+		jsPingWithUser = evalStreamHandler(basictypes.JSClientSDK, heartbeatInterval, maxClientConnTime, maxClientConnJitter)
+	}
 
 	clientSidePingRouter := router.PathPrefix("/ping/{envId}").Subrouter()
-	clientSidePingRouter.Use(jsClientSideMiddlewareStack(clientSidePingRouter), middleware.Streaming)
-	clientSidePingRouter.Handle("", middleware.CountBrowserConns(jsPing)).Methods("GET", "OPTIONS")
+	clientSidePingRouter.Use(jsClientSideMiddlewareStack(clientSidePingRouter), r.drainer.Reject, middleware.Streaming)
+	clientSidePingRouter.Handle("", r.connectionLimiter.Limit(middleware.CountBrowserConns(r.connectionLogger.Log(basictypes.JSClientSDK, jsPing)))).Methods("GET", "OPTIONS")
 
 	clientSideStreamEvalRouter := router.PathPrefix("/eval/{envId}").Subrouter()
-	clientSideStreamEvalRouter.Use(jsClientSideMiddlewareStack(clientSideStreamEvalRouter), middleware.Streaming)
-	// For now we implement eval as simply ping
-	clientSideStreamEvalRouter.Handle("/{context}", middleware.CountBrowserConns(jsPingWithUser)).Methods("GET", "OPTIONS")
-	clientSideStreamEvalRouter.Handle("", middleware.CountBrowserConns(jsPingWithUser)).Methods("REPORT", "OPTIONS")
+	clientSideStreamEvalRouter.Use(jsClientSideMiddlewareStack(clientSideStreamEvalRouter), r.drainer.Reject, middleware.Streaming)
+	// By default we implement eval as simply ping
+	clientSideStreamEvalRouter.Handle("/{context}", r.connectionLimiter.Limit(middleware.CountBrowserConns(r.connectionLogger.Log(basictypes.JSClientSDK, jsPingWithUser)))).Methods("GET", "OPTIONS")
+	clientSideStreamEvalRouter.Handle("", r.connectionLimiter.Limit(middleware.CountBrowserConns(r.connectionLogger.Log(basictypes.JSClientSDK, jsPingWithUser)))).Methods("REPORT", "OPTIONS")
+
+	decompressEvents := middleware.DecompressBody(
+		int64(r.config.Events.MaxDecompressedSize.GetOrElse(config.DefaultMaxDecompressedEventsSize)))
 
 	mobileEventsRouter := router.PathPrefix("/mobile").Subrouter()
 	mobileEventsRouter.Use(mobileMiddlewareStack)
-	mobileEventsRouter.Handle("/events/bulk", bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode)).Methods("POST")
-	mobileEventsRouter.Handle("/events", bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode)).Methods("POST")
-	mobileEventsRouter.Handle("", bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode)).Methods("POST")
-	mobileEventsRouter.Handle("/events/diagnostic", bulkEventHandler(basictypes.MobileSDK, ldevents.DiagnosticEventDataKind, offlineMode)).Methods("POST")
+	mobileEventsRouter.Handle("/events/bulk", decompressEvents(bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode))).Methods("POST")
+	mobileEventsRouter.Handle("/events", decompressEvents(bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode))).Methods("POST")
+	mobileEventsRouter.Handle("", decompressEvents(bulkEventHandler(basictypes.MobileSDK, ldevents.AnalyticsEventDataKind, offlineMode))).Methods("POST")
+	mobileEventsRouter.Handle("/events/diagnostic", decompressEvents(bulkEventHandler(basictypes.MobileSDK, ldevents.DiagnosticEventDataKind, offlineMode))).Methods("POST")
 
 	clientSideBulkEventsRouter := router.PathPrefix("/events/bulk/{envId}").Subrouter()
 	clientSideBulkEventsRouter.Use(jsClientSideMiddlewareStack(clientSideBulkEventsRouter))
-	clientSideBulkEventsRouter.Handle("", bulkEventHandler(basictypes.JSClientSDK, ldevents.AnalyticsEventDataKind, offlineMode)).Methods("POST", "OPTIONS")
+	clientSideBulkEventsRouter.Handle("", decompressEvents(bulkEventHandler(basictypes.JSClientSDK, ldevents.AnalyticsEventDataKind, offlineMode))).Methods("POST", "OPTIONS")
 
 	clientSideDiagnosticEventsRouter := router.PathPrefix("/events/diagnostic/{envId}").Subrouter()
 	clientSideDiagnosticEventsRouter.Use(jsClientSideMiddlewareStack(clientSideBulkEventsRouter))
-	clientSideDiagnosticEventsRouter.Handle("", bulkEventHandler(basictypes.JSClientSDK, ldevents.DiagnosticEventDataKind, offlineMode)).Methods("POST", "OPTIONS")
+	clientSideDiagnosticEventsRouter.Handle("", decompressEvents(bulkEventHandler(basictypes.JSClientSDK, ldevents.DiagnosticEventDataKind, offlineMode))).Methods("POST", "OPTIONS")
 
-	clientSideImageEventsRouter := router.PathPrefix("/a/{envId}.gif").Subrouter()
-	clientSideImageEventsRouter.Use(jsClientSideMiddlewareStack(clientSideImageEventsRouter))
-	clientSideImageEventsRouter.HandleFunc("", getEventsImage).Methods("GET", "OPTIONS")
+	if !r.config.Events.DisableImageEndpoint {
+		clientSideImageEventsRouter := router.PathPrefix("/a/{envId}.gif").Subrouter()
+		clientSideImageEventsRouter.Use(jsClientSideMiddlewareStack(clientSideImageEventsRouter))
+		maxImageDataSize := r.config.Events.MaxImageDataSize.GetOrElse(config.DefaultMaxImageDataSize)
+		clientSideImageEventsRouter.HandleFunc("", getEventsImage(maxImageDataSize)).Methods("GET", "OPTIONS")
+	}
 
 	serverSideRouter := router.PathPrefix("").Subrouter()
 	serverSideRouter.Use(serverSideMiddlewareStack)
-	serverSideRouter.Handle("/bulk", bulkEventHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, offlineMode)).Methods("POST")
-	serverSideRouter.Handle("/diagnostic", bulkEventHandler(basictypes.ServerSDK, ldevents.DiagnosticEventDataKind, offlineMode)).Methods("POST")
-	serverSideRouter.Handle("/all", middleware.CountServerConns(middleware.Streaming(
+	serverSideRouter.Handle("/bulk", decompressEvents(bulkEventHandler(basictypes.ServerSDK, ldevents.AnalyticsEventDataKind, offlineMode))).Methods("POST")
+	serverSideRouter.Handle("/diagnostic", decompressEvents(bulkEventHandler(basictypes.ServerSDK, ldevents.DiagnosticEventDataKind, offlineMode))).Methods("POST")
+	serverSideRouter.Handle("/all", r.drainer.Reject(r.connectionLimiter.Limit(middleware.CountServerConns(r.connectionLogger.Log(basictypes.ServerSDK, middleware.Streaming(
 		streamHandler(r.serverSideStreamProvider, serverSideStreamLogMessage),
-	))).Methods("GET")
-	serverSideRouter.Handle("/flags", middleware.CountServerConns(middleware.Streaming(
+	)))))).Methods("GET")
+	serverSideRouter.Handle("/flags", r.drainer.Reject(r.connectionLimiter.Limit(middleware.CountServerConns(r.connectionLogger.Log(basictypes.ServerSDK, middleware.Streaming(
 		streamHandler(r.serverSideFlagsStreamProvider, serverSideFlagsOnlyStreamLogMessage),
-	))).Methods("GET")
+	)))))).Methods("GET")
+
+	return router
+}
 
+// makeAdminRouter creates a Router for the administrative endpoints, which is served on a separate port if
+// MainConfig.AdminPort is set.
+func (r *Relay) makeAdminRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(logging.GlobalContextLoggersMiddleware(r.loggers))
+	router.Use(r.debugRequestLogger)
+	r.addAdminRoutes(router, true)
 	return router
 }
 
+// debugRequestLogger is a middleware that logs each request if the global log level is Debug. The level
+// is checked on every request, since Reload can change it.
+func (r *Relay) debugRequestLogger(next http.Handler) http.Handler {
+	logged := logging.RequestLoggerMiddleware(r.loggers)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.logLevel.GetMinLevel() == ldlog.Debug {
+			logged.ServeHTTP(w, req)
+		} else {
+			next.ServeHTTP(w, req)
+		}
+	})
+}
+
+// addAdminRoutes adds the administrative endpoints that are enabled by the configuration. The profiling
+// endpoints are only ever added on the separate admin port, since they should never be exposed to SDKs.
+func (r *Relay) addAdminRoutes(router *mux.Router, includeProfiling bool) {
+	if r.config.Main.AdminKey == "" {
+		return
+	}
+	adminAuth := middleware.AdminAuth(r.config.Main.AdminKey)
+	if r.config.Main.EnableMaintenanceEndpoint {
+		router.Handle("/debug/maintenance", adminAuth(maintenanceHandler(r))).Methods("POST")
+	}
+	adminRouter := router.PathPrefix("/debug/env").Subrouter()
+	adminRouter.Use(adminAuth)
+	adminRouter.Handle("/{envName}/reconnect", reconnectHandler(r)).Methods("POST")
+	adminRouter.Handle("/{envName}/recent-events", recentEventsHandler(r)).Methods("GET")
+	adminRouter.Handle("/{envName}/segment/{key}", debugSegmentHandler(r)).Methods("GET")
+	adminRouter.Handle("/{envName}/segment-membership", debugSegmentMembershipHandler(r)).Methods("POST")
+	adminRouter.Handle("/{envName}/flag/{key}/evaluate", debugFlagEvaluationHandler(r)).Methods("POST")
+	router.Handle("/debug/drain", adminAuth(drainHandler(r))).Methods("POST")
+	router.Handle("/debug/status", adminAuth(statusHandler(r, true))).Methods("GET")
+	router.Handle("/debug/metrics", adminAuth(debugMetricsHandler(r))).Methods("GET")
+	router.Handle("/debug/config", adminAuth(debugConfigHandler(r))).Methods("GET")
+	if includeProfiling {
+		pprofRouter := router.PathPrefix("/debug/pprof").Subrouter()
+		pprofRouter.Use(adminAuth)
+		pprofRouter.HandleFunc("/cmdline", pprof.Cmdline)
+		pprofRouter.HandleFunc("/profile", pprof.Profile)
+		pprofRouter.HandleFunc("/symbol", pprof.Symbol)
+		pprofRouter.HandleFunc("/trace", pprof.Trace)
+		pprofRouter.PathPrefix("/").HandlerFunc(pprof.Index)
+	}
+}
+
 // Adapter that implements the middleware.RelayEnvironments interface to expose non-exported methods of Relay
 type relayEnvironmentGetters struct {
 	*Relay
@@ -176,3 +333,7 @@ func (r relayEnvironmentGetters) IsNotReady(err error) bool {
 func (r relayEnvironmentGetters) IsPayloadFilterNotFound(err error) bool {
 	return IsPayloadFilterNotFound(err)
 }
+
+func (r relayEnvironmentGetters) GetGeneration() uint64 {
+	return r.envsByCredential.Generation()
+}
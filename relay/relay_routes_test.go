@@ -0,0 +1,242 @@
+package relay
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/basictypes"
+	st "github.com/launchdarkly/ld-relay/v8/internal/sharedtest"
+
+	"github.com/launchdarkly/eventsource"
+	ct "github.com/launchdarkly/go-configtypes"
+	helpers "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedMethods(t *testing.T) {
+	sdkKey := st.EnvMain.Config.SDKKey
+
+	t.Run("methods that are not in the default allowlist are rejected", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, method := range []string{"PUT", "DELETE", "PATCH", "TRACE"} {
+				r := st.BuildRequestWithAuth(method, "http://localhost/sdk/flags", sdkKey, nil)
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, http.StatusMethodNotAllowed, result.StatusCode, method)
+				assert.Equal(t, "GET, POST, REPORT, OPTIONS", result.Header.Get("Allow"))
+			}
+
+			r := st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil)
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+		})
+	})
+
+	t.Run("allowlist can be configured", func(t *testing.T) {
+		var config c.Config
+		config.Main.AllowedMethods = ct.NewOptStringList([]string{"GET"})
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r := st.BuildRequestWithAuth("REPORT", "http://localhost/sdk/evalx/context", sdkKey, []byte(st.SimpleUserJSON))
+			result, _ := st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusMethodNotAllowed, result.StatusCode)
+
+			r = st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil)
+			result, _ = st.DoRequest(r, p.relay)
+			assert.Equal(t, http.StatusOK, result.StatusCode)
+		})
+	})
+}
+
+func TestAdminPort(t *testing.T) {
+	adminKey := "admin-key"
+	adminPort := st.GetAvailablePort(t)
+
+	optAdminPort, err := ct.NewOptIntGreaterThanZero(adminPort)
+	require.NoError(t, err)
+
+	var config c.Config
+	config.Main.AdminKey = adminKey
+	config.Main.AdminPort = optAdminPort
+	config.Main.ReadTimeout = ct.NewOptDuration(time.Second)
+	config.Main.ReadHeaderTimeout = ct.NewOptDuration(2 * time.Second)
+	config.Main.WriteTimeout = ct.NewOptDuration(3 * time.Second)
+	config.Main.IdleTimeout = ct.NewOptDuration(4 * time.Second)
+	config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+	makeRequest := func(method, url, authKey, body string) *http.Request {
+		r, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		if authKey != "" {
+			r.Header.Set("Authorization", authKey)
+		}
+		return r
+	}
+	adminURL := func(path string) string {
+		return fmt.Sprintf("http://localhost:%d%s", adminPort, path)
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		t.Run("admin server uses configured timeouts", func(t *testing.T) {
+			require.NotNil(t, p.relay.adminServer)
+			server := p.relay.adminServer.server
+			assert.Equal(t, time.Second, server.ReadTimeout)
+			assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+			assert.Equal(t, 3*time.Second, server.WriteTimeout)
+			assert.Equal(t, 4*time.Second, server.IdleTimeout)
+		})
+
+		t.Run("admin endpoints are not available on main port", func(t *testing.T) {
+			result, _ := st.DoRequest(makeRequest("POST", "http://localhost/debug/drain", adminKey, `{"draining":true}`), p.relay)
+			assert.Equal(t, http.StatusNotFound, result.StatusCode)
+			assert.False(t, p.relay.drainer.IsDraining())
+		})
+
+		t.Run("admin endpoints are available on admin port", func(t *testing.T) {
+			resp, err := http.DefaultClient.Do(makeRequest("POST", adminURL("/debug/drain"), adminKey, `{"draining":false}`))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+
+		t.Run("admin endpoints on admin port require admin key", func(t *testing.T) {
+			resp, err := http.DefaultClient.Do(makeRequest("POST", adminURL("/debug/drain"), "", `{"draining":true}`))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.False(t, p.relay.drainer.IsDraining())
+		})
+
+		t.Run("profiling endpoints are available on admin port", func(t *testing.T) {
+			resp, err := http.DefaultClient.Do(makeRequest("GET", adminURL("/debug/pprof/"), adminKey, ""))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			resp, err = http.DefaultClient.Do(makeRequest("GET", adminURL("/debug/pprof/"), "", ""))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+
+		t.Run("SDK endpoints are not available on admin port", func(t *testing.T) {
+			r := st.BuildRequestWithAuth("GET", adminURL("/sdk/flags"), st.EnvMain.Config.SDKKey, nil)
+			resp, err := http.DefaultClient.Do(r)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+	})
+
+	t.Run("admin port is closed when Relay is closed", func(t *testing.T) {
+		_, err := http.DefaultClient.Do(makeRequest("GET", adminURL("/debug/pprof/"), adminKey, ""))
+		assert.Error(t, err)
+	})
+}
+
+func TestResponseHeaders(t *testing.T) {
+	sdkKey := st.EnvMain.Config.SDKKey
+
+	t.Run("default headers are added to all responses", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			for _, r := range []*http.Request{
+				st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", sdkKey, nil),
+				st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", st.UndefinedSDKKey, nil),
+				st.BuildRequestWithAuth("DELETE", "http://localhost/sdk/flags", sdkKey, nil),
+				st.BuildRequest("GET", "http://localhost/status", nil, nil),
+			} {
+				result, _ := st.DoRequest(r, p.relay)
+				assert.Equal(t, "nosniff", result.Header.Get("X-Content-Type-Options"), r.URL.Path)
+				assert.Equal(t, "DENY", result.Header.Get("X-Frame-Options"), r.URL.Path)
+				assert.Equal(t, "", result.Header.Get("Strict-Transport-Security"), r.URL.Path)
+			}
+		})
+	})
+
+	t.Run("default headers are added to stream responses", func(t *testing.T) {
+		var config c.Config
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			r := st.MakeSDKStreamEndpointRequest("http://localhost", basictypes.ServerSideStream, st.EnvMain, "", 0)
+			resp := st.WithStreamRequest(t, r, p.relay, func(eventCh <-chan eventsource.Event) {
+				_ = helpers.RequireValue(t, eventCh, time.Second*3, "timed out waiting for initial event")
+			})
+			assert.Equal(t, "nosniff", resp.Header.Get("X-Content-Type-Options"))
+		})
+	})
+
+	t.Run("configured headers override defaults", func(t *testing.T) {
+		var config c.Config
+		config.Main.TLSEnabled = true
+		config.Main.TLSCert, config.Main.TLSKey = "cert.pem", "key.pem" // not used, since no listener is started here
+		config.Main.ResponseHeaders = ct.NewOptStringList([]string{
+			"X-Frame-Options: SAMEORIGIN",
+			"X-Content-Type-Options:",
+			"Content-Security-Policy: default-src 'none'",
+		})
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(st.BuildRequest("GET", "http://localhost/status", nil, nil), p.relay)
+			assert.Equal(t, "SAMEORIGIN", result.Header.Get("X-Frame-Options"))
+			assert.NotContains(t, result.Header, "X-Content-Type-Options")
+			assert.Equal(t, "default-src 'none'", result.Header.Get("Content-Security-Policy"))
+			assert.Equal(t, "max-age=31536000", result.Header.Get("Strict-Transport-Security"))
+		})
+	})
+
+	t.Run("default headers can be disabled", func(t *testing.T) {
+		var config c.Config
+		config.Main.NoDefaultResponseHeaders = true
+		config.Environment = st.MakeEnvConfigs(st.EnvMain)
+
+		withStartedRelay(t, config, func(p relayTestParams) {
+			result, _ := st.DoRequest(st.BuildRequest("GET", "http://localhost/status", nil, nil), p.relay)
+			assert.NotContains(t, result.Header, "X-Content-Type-Options")
+			assert.NotContains(t, result.Header, "X-Frame-Options")
+		})
+	})
+}
+
+func TestMinSDKVersions(t *testing.T) {
+	var config c.Config
+	config.Main.MinSDKVersions = ct.NewOptStringList([]string{"GoClient/6.0.0", "AndroidClient/4.0.0"})
+	config.Environment = st.MakeEnvConfigs(st.EnvMain, st.EnvMobile)
+
+	withUserAgent := func(r *http.Request, userAgent string) *http.Request {
+		r.Header.Set("User-Agent", userAgent)
+		return r
+	}
+
+	withStartedRelay(t, config, func(p relayTestParams) {
+		for _, tc := range []struct {
+			request  *http.Request
+			expected int
+		}{
+			{withUserAgent(st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", st.EnvMain.Config.SDKKey, nil), "GoClient/5.9.0"),
+				http.StatusUpgradeRequired},
+			{withUserAgent(st.BuildRequestWithAuth("GET", "http://localhost/sdk/flags", st.EnvMain.Config.SDKKey, nil), "GoClient/6.0.0"),
+				http.StatusOK},
+			{withUserAgent(st.MakeSDKEvalEndpointRequest("http://localhost", basictypes.MobileSDK, st.EnvMobile, st.SimpleUserJSON, 0),
+				"AndroidClient/3.1.0"), http.StatusUpgradeRequired},
+			{withUserAgent(st.MakeSDKEvalEndpointRequest("http://localhost", basictypes.MobileSDK, st.EnvMobile, st.SimpleUserJSON, 0),
+				"iOS/3.1.0"), http.StatusOK},
+			{withUserAgent(st.BuildRequest("GET", "http://localhost/status", nil, nil), "GoClient/5.9.0"), http.StatusOK},
+		} {
+			result, _ := st.DoRequest(tc.request, p.relay)
+			assert.Equal(t, tc.expected, result.StatusCode, "%s %s", tc.request.URL.Path, tc.request.Header.Get("User-Agent"))
+		}
+	})
+}
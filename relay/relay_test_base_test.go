@@ -5,10 +5,12 @@ import (
 	"time"
 
 	c "github.com/launchdarkly/ld-relay/v8/config"
+	"github.com/launchdarkly/ld-relay/v8/internal/bigsegments"
 	"github.com/launchdarkly/ld-relay/v8/internal/sharedtest/testclient"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk/v7/subsystems"
 
 	"github.com/stretchr/testify/require"
 )
@@ -19,6 +21,9 @@ type relayTestBehavior struct {
 	skipWaitForEnvironments bool // true = we're using auto-config or expect startup to fail; false = wait for all environments
 	useRealSDKClient        bool // true = use real end-to-end HTTP; false = use a mock SDK client
 	doNotEnableDebugLogging bool // true = leave the default log level in place; false = enable debug logging
+
+	bigSegmentStoreFactory bigsegments.BigSegmentStoreFactory // nil = create big segment stores from the configuration
+	sdkBigSegmentsFactory  subsystems.ComponentConfigurer[subsystems.BigSegmentsConfiguration]
 }
 
 // Components that are passed from withStartedRelay/withStartedRelayCustom to the test logic.
@@ -49,7 +54,11 @@ func withStartedRelayCustom(t *testing.T, config c.Config, behavior relayTestBeh
 		config.Main.LogLevel = c.NewOptLogLevel(ldlog.Debug)
 		mockLog.Loggers.SetMinLevel(ldlog.Debug)
 	}
-	options := relayInternalOptions{loggers: mockLog.Loggers}
+	options := relayInternalOptions{
+		loggers:                mockLog.Loggers,
+		bigSegmentStoreFactory: behavior.bigSegmentStoreFactory,
+		sdkBigSegmentsFactory:  behavior.sdkBigSegmentsFactory,
+	}
 	if !behavior.useRealSDKClient {
 		options.clientFactory = testclient.CreateDummyClient
 	}